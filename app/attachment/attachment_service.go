@@ -0,0 +1,194 @@
+package attachment
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/google/uuid"
+	"io"
+	"log"
+	"time"
+)
+
+var (
+	ErrAttachmentNotFound = errors.New("attachment doesn't exist")
+	ErrAttachmentTooLarge = errors.New("attachment exceeds the maximum size")
+)
+
+// Variables meant for mocking
+var (
+	generateBlobId = func() string { return uuid.New().String() }
+	now            = func() time.Time { return time.Now().UTC() }
+)
+
+type Manager interface {
+	StoreAttachment(attachment *model.Attachment, content io.Reader) error
+	OpenAttachment(id uint64, userId uint64) (*model.Attachment, io.ReadCloser, error)
+	DeleteAttachment(attachment *model.Attachment) error
+	DeleteAttachmentBlobs(attachments model.Attachments)
+	RewrapDataKeys(attachments model.Attachments, oldVaultKey []byte, newVaultKey []byte) (model.Attachments, error)
+	StorageQuota() int64
+}
+
+type attachmentService struct {
+	maxSizeInBytes       int64
+	quotaInBytes         int64
+	attachmentRepository repository.AttachmentRepository
+	userRepository       repository.UserRepository
+	blobStore            BlobStore
+}
+
+func NewAttachmentService(
+	attachmentConfig *config.Attachment,
+	attachmentRepository repository.AttachmentRepository,
+	userRepository repository.UserRepository,
+	blobStore BlobStore,
+) *attachmentService {
+	return &attachmentService{
+		maxSizeInBytes:       attachmentConfig.MaxSizeInBytes,
+		quotaInBytes:         attachmentConfig.QuotaInBytes,
+		attachmentRepository: attachmentRepository,
+		userRepository:       userRepository,
+		blobStore:            blobStore,
+	}
+}
+
+// StoreAttachment streams the content encrypted with a data key of its own into the blob store and stores its metadata
+// together with the data key wrapped with the owner's vault key, so changing the vault key only means wrapping the data
+// keys again. The blob is bound to its key so blobs can't be swapped between attachments. The content has to be exactly
+// as large as the attachment's size, which counts towards the owner's storage quota.
+func (service *attachmentService) StoreAttachment(attachment *model.Attachment, content io.Reader) error {
+	if attachment.Size > service.maxSizeInBytes {
+		return ErrAttachmentTooLarge
+	}
+
+	vaultKey, err := service.vaultKey(attachment.UserId)
+	if err != nil {
+		return err
+	}
+	dataKey, err := security.GenerateDataKey()
+	if err != nil {
+		return err
+	}
+	attachment.WrappedDataKey, err = security.WrapDataKey(dataKey, vaultKey)
+	if err != nil {
+		return err
+	}
+
+	attachment.BlobKey = fmt.Sprintf("attachments/%d/%s", attachment.UserId, generateBlobId())
+	encryptedContent, err := security.NewEncryptingReader(io.LimitReader(content, attachment.Size), dataKey, []byte(attachment.BlobKey))
+	if err != nil {
+		return err
+	}
+	if err = service.blobStore.Put(attachment.BlobKey, encryptedContent, security.EncryptedStreamSize(attachment.Size)); err != nil {
+		return err
+	}
+
+	attachment.CreatedAt = now()
+	insertResult, err := service.attachmentRepository.InsertNewAttachment(attachment, service.quotaInBytes)
+	if err != nil {
+		service.deleteBlob(attachment.BlobKey)
+		return err
+	}
+	attachment.Id = uint64(insertResult.ID().(int64))
+
+	return nil
+}
+
+// OpenAttachment returns the decrypted content of the user's attachment, other users' attachments are reported
+// as missing so their existence isn't disclosed
+func (service *attachmentService) OpenAttachment(id uint64, userId uint64) (*model.Attachment, io.ReadCloser, error) {
+	attachment := &model.Attachment{}
+	if err := service.attachmentRepository.FetchAttachmentById(attachment, id); err != nil {
+		if err == repository.ErrNotFound {
+			return nil, nil, ErrAttachmentNotFound
+		}
+		return nil, nil, err
+	}
+	if attachment.UserId != userId {
+		return nil, nil, ErrAttachmentNotFound
+	}
+
+	vaultKey, err := service.vaultKey(userId)
+	if err != nil {
+		return nil, nil, err
+	}
+	dataKey, err := security.UnwrapDataKey(attachment.WrappedDataKey, vaultKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	blob, err := service.blobStore.Get(attachment.BlobKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	content, err := security.NewDecryptingReader(blob, dataKey, []byte(attachment.BlobKey))
+	if err != nil {
+		blob.Close()
+		return nil, nil, err
+	}
+
+	return attachment, &decryptedBlob{Reader: content, Closer: blob}, nil
+}
+
+// DeleteAttachment deletes the attachment's metadata before its blob, so a failure can at most leave an orphaned blob
+func (service *attachmentService) DeleteAttachment(attachment *model.Attachment) error {
+	if err := service.attachmentRepository.DeleteAttachmentById(attachment.Id); err != nil {
+		if err == repository.ErrNotFound {
+			return ErrAttachmentNotFound
+		}
+		return err
+	}
+
+	service.deleteBlob(attachment.BlobKey)
+	return nil
+}
+
+// DeleteAttachmentBlobs deletes the blobs of attachments whose metadata is already gone, like the ones of deleted entries
+func (service *attachmentService) DeleteAttachmentBlobs(attachments model.Attachments) {
+	for _, attachment := range attachments {
+		service.deleteBlob(attachment.BlobKey)
+	}
+}
+
+// RewrapDataKeys returns the attachments with their data keys wrapped with the new vault key instead of the old one,
+// for storing together with the new vault key
+func (service *attachmentService) RewrapDataKeys(
+	attachments model.Attachments, oldVaultKey []byte, newVaultKey []byte,
+) (model.Attachments, error) {
+	for index := range attachments {
+		wrappedDataKey, err := security.RewrapDataKey(attachments[index].WrappedDataKey, oldVaultKey, newVaultKey)
+		if err != nil {
+			return nil, err
+		}
+		attachments[index].WrappedDataKey = wrappedDataKey
+	}
+
+	return attachments, nil
+}
+
+func (service *attachmentService) StorageQuota() int64 {
+	return service.quotaInBytes
+}
+
+func (service *attachmentService) vaultKey(userId uint64) ([]byte, error) {
+	user := model.User{}
+	if err := service.userRepository.FetchMasterPasswordByUserId(&user, userId); err != nil {
+		return nil, err
+	}
+	return user.Password, nil
+}
+
+func (service *attachmentService) deleteBlob(key string) {
+	if err := service.blobStore.Delete(key); err != nil {
+		log.Printf("Error while deleting attachment blob %s: %s", key, err)
+	}
+}
+
+type decryptedBlob struct {
+	io.Reader
+	io.Closer
+}
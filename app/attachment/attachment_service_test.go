@@ -0,0 +1,220 @@
+package attachment
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// StoreAttachment should store the content encrypted and only its metadata in the repository
+func TestStoreAttachment(t *testing.T) {
+	attachmentRepositoryMock := mockutil.DefaultAttachmentRepositoryServiceMock()
+	blobStore := NewMemoryBlobStore()
+	service := newTestAttachmentService(attachmentRepositoryMock, blobStore)
+	attachment := newTestAttachment()
+
+	err := service.StoreAttachment(attachment, strings.NewReader(mockutil.DefaultAttachmentContent))
+	assert.Nil(t, err, "Should store the attachment without errors")
+	assert.Equal(t, attachment.Id, mockutil.DefaultIdAsUint64)
+	assert.True(t, strings.HasPrefix(attachment.BlobKey, "attachments/1/"), "Blobs should be grouped by their owner")
+	assert.False(t, attachment.CreatedAt.IsZero())
+	assert.NotNil(t, attachment.WrappedDataKey, "Content should be encrypted with a data key of its own")
+	attachmentRepositoryMock.AssertCalled(t, "InsertNewAttachment", attachment, mockutil.DefaultStorageQuota)
+
+	blob, err := blobStore.Get(attachment.BlobKey)
+	assert.Nil(t, err)
+	storedContent, _ := ioutil.ReadAll(blob)
+	assert.NotContains(t, string(storedContent), mockutil.DefaultAttachmentContent, "Content should be stored encrypted")
+}
+
+// StoreAttachment should reject attachments above the maximum size without storing anything
+func TestStoreAttachmentAboveMaximumSize(t *testing.T) {
+	attachmentRepositoryMock := mockutil.DefaultAttachmentRepositoryServiceMock()
+	service := newTestAttachmentService(attachmentRepositoryMock, NewMemoryBlobStore())
+	attachment := newTestAttachment()
+	attachment.Size = 101
+
+	err := service.StoreAttachment(attachment, strings.NewReader(strings.Repeat("a", 101)))
+	assert.Equal(t, err, ErrAttachmentTooLarge)
+	attachmentRepositoryMock.AssertNotCalled(t, "InsertNewAttachment", mock.Anything, mock.Anything)
+}
+
+// StoreAttachment should delete the stored blob again if its metadata can't be stored, e.g. when exceeding the quota
+func TestStoreAttachmentWithQuotaExceeded(t *testing.T) {
+	attachmentRepositoryMock := new(mockutil.AttachmentRepositoryServiceMock)
+	attachmentRepositoryMock.On("InsertNewAttachment", mock.Anything, mock.Anything).Return(nil, repository.ErrStorageQuotaExceeded)
+	blobStore := NewMemoryBlobStore()
+	service := newTestAttachmentService(attachmentRepositoryMock, blobStore)
+	attachment := newTestAttachment()
+
+	err := service.StoreAttachment(attachment, strings.NewReader(mockutil.DefaultAttachmentContent))
+	assert.Equal(t, err, repository.ErrStorageQuotaExceeded)
+	_, err = blobStore.Get(attachment.BlobKey)
+	assert.Equal(t, err, ErrBlobNotFound, "Blob should be deleted")
+}
+
+// OpenAttachment should decrypt the content of an attachment stored by StoreAttachment
+func TestOpenAttachment(t *testing.T) {
+	blobStore := NewMemoryBlobStore()
+	storedAttachment := newTestAttachment()
+	newTestAttachmentService(mockutil.DefaultAttachmentRepositoryServiceMock(), blobStore).
+		StoreAttachment(storedAttachment, strings.NewReader(mockutil.DefaultAttachmentContent))
+	service := newTestAttachmentService(attachmentRepositoryMockWithAttachment(*storedAttachment, nil), blobStore)
+
+	attachment, content, err := service.OpenAttachment(mockutil.DefaultIdAsUint64, mockutil.DefaultIdAsUint64)
+	assert.Nil(t, err, "Should open the attachment without errors")
+	defer content.Close()
+	decryptedContent, err := ioutil.ReadAll(content)
+	assert.Nil(t, err)
+	assert.Equal(t, string(decryptedContent), mockutil.DefaultAttachmentContent)
+	assert.Equal(t, attachment.Name, mockutil.DefaultAttachmentName)
+}
+
+// OpenAttachment should refuse attachments without a data key wrapped with the owner's vault key
+func TestOpenAttachmentWithoutDataKey(t *testing.T) {
+	attachment := *newTestAttachment()
+	attachment.WrappedDataKey = nil
+	service := newTestAttachmentService(attachmentRepositoryMockWithAttachment(attachment, nil), NewMemoryBlobStore())
+
+	_, content, err := service.OpenAttachment(mockutil.DefaultIdAsUint64, mockutil.DefaultIdAsUint64)
+	assert.Equal(t, err, security.ErrInvalidDataKey)
+	assert.Nil(t, content)
+}
+
+// OpenAttachment should report missing attachments and attachments of other users the same way
+func TestOpenAttachmentNotFound(t *testing.T) {
+	attachment := *newTestAttachment()
+	repositoryMocks := []*mockutil.AttachmentRepositoryServiceMock{
+		attachmentRepositoryMockWithAttachment(attachment, repository.ErrNotFound),
+		attachmentRepositoryMockWithAttachment(attachment, nil),
+	}
+
+	for _, attachmentRepositoryMock := range repositoryMocks {
+		service := newTestAttachmentService(attachmentRepositoryMock, NewMemoryBlobStore())
+		_, content, err := service.OpenAttachment(mockutil.DefaultIdAsUint64, mockutil.DefaultGranteeIdAsUint64)
+		assert.Equal(t, err, ErrAttachmentNotFound)
+		assert.Nil(t, content)
+	}
+}
+
+// OpenAttachment should refuse to decrypt a blob that belongs to another attachment
+func TestOpenAttachmentWithSwappedBlob(t *testing.T) {
+	blobStore := NewMemoryBlobStore()
+	storedAttachment := newTestAttachment()
+	newTestAttachmentService(mockutil.DefaultAttachmentRepositoryServiceMock(), blobStore).
+		StoreAttachment(storedAttachment, strings.NewReader(mockutil.DefaultAttachmentContent))
+	blob, _ := blobStore.Get(storedAttachment.BlobKey)
+	blobStore.Put("attachments/1/swapped", blob, 0)
+	storedAttachment.BlobKey = "attachments/1/swapped"
+	service := newTestAttachmentService(attachmentRepositoryMockWithAttachment(*storedAttachment, nil), blobStore)
+
+	_, content, err := service.OpenAttachment(mockutil.DefaultIdAsUint64, mockutil.DefaultIdAsUint64)
+	if err == nil {
+		_, err = ioutil.ReadAll(content)
+	}
+	assert.NotNil(t, err, "Should not decrypt a blob bound to another key")
+}
+
+// DeleteAttachment should delete the metadata and the blob of the attachment
+func TestDeleteAttachment(t *testing.T) {
+	blobStore := NewMemoryBlobStore()
+	attachment := newTestAttachment()
+	attachment.BlobKey = "attachments/1/blob"
+	blobStore.Put(attachment.BlobKey, strings.NewReader("blob"), 4)
+	attachmentRepositoryMock := mockutil.DefaultAttachmentRepositoryServiceMock()
+	service := newTestAttachmentService(attachmentRepositoryMock, blobStore)
+
+	err := service.DeleteAttachment(attachment)
+	assert.Nil(t, err)
+	_, err = blobStore.Get(attachment.BlobKey)
+	assert.Equal(t, err, ErrBlobNotFound)
+	attachmentRepositoryMock.AssertCalled(t, "DeleteAttachmentById", attachment.Id)
+}
+
+// DeleteAttachment should keep the blob if the metadata couldn't be deleted
+func TestDeleteAttachmentWithRepositoryError(t *testing.T) {
+	blobStore := NewMemoryBlobStore()
+	attachment := newTestAttachment()
+	attachment.BlobKey = "attachments/1/blob"
+	blobStore.Put(attachment.BlobKey, strings.NewReader("blob"), 4)
+	attachmentRepositoryMock := new(mockutil.AttachmentRepositoryServiceMock)
+	attachmentRepositoryMock.On("DeleteAttachmentById", mock.Anything).Return(errors.New(mockutil.MockedGenericErrorMessage))
+	service := newTestAttachmentService(attachmentRepositoryMock, blobStore)
+
+	err := service.DeleteAttachment(attachment)
+	assert.Equal(t, err, errors.New(mockutil.MockedGenericErrorMessage))
+	_, err = blobStore.Get(attachment.BlobKey)
+	assert.Nil(t, err, "Blob should be kept")
+}
+
+// RewrapDataKeys should wrap the data keys of the user's attachments with the new vault key
+func TestRewrapDataKeys(t *testing.T) {
+	blobStore := NewMemoryBlobStore()
+	storedAttachments := model.Attachments{}
+	for index := 0; index < 2; index++ {
+		storedAttachment := newTestAttachment()
+		err := newTestAttachmentService(mockutil.DefaultAttachmentRepositoryServiceMock(), blobStore).
+			StoreAttachment(storedAttachment, strings.NewReader(mockutil.DefaultAttachmentContent))
+		assert.Nil(t, err)
+		storedAttachments = append(storedAttachments, *storedAttachment)
+	}
+	service := newTestAttachmentService(mockutil.DefaultAttachmentRepositoryServiceMock(), blobStore)
+	newVaultKey := []byte("newVaultKeyThatNeedsToBeAtLeast32BytesLong")
+
+	attachments, err := service.RewrapDataKeys(storedAttachments, []byte(mockutil.MockedUserMasterPassword), newVaultKey)
+	assert.Nil(t, err, "Should rewrap the data keys without errors")
+	assert.Len(t, attachments, 2)
+	for _, attachment := range attachments {
+		dataKey, err := security.UnwrapDataKey(attachment.WrappedDataKey, newVaultKey)
+		assert.Nil(t, err, "Data key should be wrapped with the new vault key")
+		blob, _ := blobStore.Get(attachment.BlobKey)
+		content, _ := security.NewDecryptingReader(blob, dataKey, []byte(attachment.BlobKey))
+		decryptedContent, err := ioutil.ReadAll(content)
+		assert.Nil(t, err)
+		assert.Equal(t, string(decryptedContent), mockutil.DefaultAttachmentContent)
+	}
+}
+
+// RewrapDataKeys should return an error when a data key isn't wrapped with the old vault key
+func TestRewrapDataKeysWithWrongVaultKey(t *testing.T) {
+	storedAttachment := newTestAttachment()
+	newTestAttachmentService(mockutil.DefaultAttachmentRepositoryServiceMock(), NewMemoryBlobStore()).
+		StoreAttachment(storedAttachment, strings.NewReader(mockutil.DefaultAttachmentContent))
+	service := newTestAttachmentService(mockutil.DefaultAttachmentRepositoryServiceMock(), NewMemoryBlobStore())
+
+	attachments, err := service.RewrapDataKeys(
+		model.Attachments{*storedAttachment}, []byte("wrongVaultKeyThatNeedsToBeAtLeast32BytesLong"), []byte(mockutil.MockedUserMasterPassword),
+	)
+	assert.Equal(t, err, security.ErrInvalidDataKey)
+	assert.Nil(t, attachments)
+}
+
+func newTestAttachmentService(attachmentRepository repository.AttachmentRepository, blobStore BlobStore) *attachmentService {
+	return NewAttachmentService(
+		&config.Attachment{MaxSizeInBytes: 100, QuotaInBytes: mockutil.DefaultStorageQuota},
+		attachmentRepository,
+		mockutil.DefaultUserRepositoryServiceMock(),
+		blobStore,
+	)
+}
+
+func newTestAttachment() *model.Attachment {
+	attachment := mockutil.DefaultAttachment()
+	attachment.BlobKey = ""
+	return &attachment
+}
+
+func attachmentRepositoryMockWithAttachment(attachment model.Attachment, fetchError error) *mockutil.AttachmentRepositoryServiceMock {
+	serviceMock := new(mockutil.AttachmentRepositoryServiceMock)
+	serviceMock.On("FetchAttachmentById", mock.Anything, mock.Anything).Return(fetchError, attachment)
+
+	return serviceMock
+}
@@ -0,0 +1,32 @@
+package attachment
+
+import (
+	"errors"
+	"io"
+	"regexp"
+)
+
+var (
+	ErrBlobNotFound   = errors.New("blob doesn't exist")
+	ErrInvalidBlobKey = errors.New("invalid blob key")
+)
+
+var blobKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+(/[A-Za-z0-9_-]+)*$`)
+
+// BlobStore keeps the encrypted attachment contents, implementations never see any plaintext
+type BlobStore interface {
+	// Put stores the content under the key, the size is the exact number of bytes the content reader yields
+	Put(key string, content io.Reader, size int64) error
+	// Get returns ErrBlobNotFound for keys that don't exist
+	Get(key string) (io.ReadCloser, error)
+	// Delete doesn't fail for keys that don't exist
+	Delete(key string) error
+}
+
+// validateBlobKey only allows slash separated segments of URL safe characters, so keys can't escape their store
+func validateBlobKey(key string) error {
+	if !blobKeyPattern.MatchString(key) {
+		return ErrInvalidBlobKey
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package attachment
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// LocalBlobStore should store, fetch and delete blobs below its directory
+func TestLocalBlobStore(t *testing.T) {
+	testBlobStore(t, NewLocalBlobStore(t.TempDir()))
+}
+
+// MemoryBlobStore should store, fetch and delete blobs
+func TestMemoryBlobStore(t *testing.T) {
+	testBlobStore(t, NewMemoryBlobStore())
+}
+
+// S3BlobStore should store, fetch and delete blobs as objects of its bucket
+func TestS3BlobStore(t *testing.T) {
+	server := newFakeS3Server(t)
+	defer server.Close()
+
+	testBlobStore(t, newTestS3BlobStore(server.URL))
+}
+
+// Blob stores should reject keys that could escape the store
+func TestBlobStoresWithInvalidKeys(t *testing.T) {
+	stores := map[string]BlobStore{
+		"local":  NewLocalBlobStore(t.TempDir()),
+		"memory": NewMemoryBlobStore(),
+		"s3":     newTestS3BlobStore("http://localhost:9000"),
+	}
+
+	for name, store := range stores {
+		for _, key := range []string{"", "../escaped", "attachments/../../escaped", "/absolute", "trailing/", "query?key"} {
+			err := store.Put(key, strings.NewReader("blob"), 4)
+			assert.Equal(t, err, ErrInvalidBlobKey, "The %s store should reject the key %q", name, key)
+		}
+	}
+}
+
+// testBlobStore is the behaviour every blob store has to conform to
+func testBlobStore(t *testing.T, store BlobStore) {
+	err := store.Put("attachments/1/blob", strings.NewReader("encrypted content"), 17)
+	assert.Nil(t, err, "Should store the blob")
+	assertBlobContent(t, store, "attachments/1/blob", "encrypted content")
+
+	err = store.Put("attachments/1/blob", strings.NewReader("replaced"), 8)
+	assert.Nil(t, err, "Should replace the blob")
+	assertBlobContent(t, store, "attachments/1/blob", "replaced")
+
+	_, err = store.Get("attachments/1/missing")
+	assert.Equal(t, err, ErrBlobNotFound)
+
+	assert.Nil(t, store.Delete("attachments/1/blob"), "Should delete the blob")
+	_, err = store.Get("attachments/1/blob")
+	assert.Equal(t, err, ErrBlobNotFound)
+	assert.Nil(t, store.Delete("attachments/1/blob"), "Deleting a missing blob should not fail")
+}
+
+func assertBlobContent(t *testing.T, store BlobStore, key string, expectedContent string) {
+	blob, err := store.Get(key)
+	assert.Nil(t, err, "Should fetch the blob")
+	if err != nil {
+		return
+	}
+	defer blob.Close()
+
+	content, err := ioutil.ReadAll(blob)
+	assert.Nil(t, err)
+	assert.Equal(t, string(content), expectedContent)
+}
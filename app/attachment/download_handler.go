@@ -0,0 +1,53 @@
+package attachment
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	"github.com/go-chi/chi/v5"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+// DownloadHandler streams the decrypted attachment to its owner. Attachments are always served as opaque downloads,
+// so their content can't be rendered by the browser within the application's origin.
+func DownloadHandler(manager Manager, authenticator authentication.JwtAuthenticator) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Cache-Control", "no-store")
+
+		userAuthentication := authenticator.GetAuthenticatedUserDataFromContext(request.Context())
+		if userAuthentication == nil {
+			http.Error(writer, "unauthorized attachment download", http.StatusUnauthorized)
+			return
+		}
+
+		attachmentId, err := strconv.ParseUint(chi.URLParam(request, "attachmentId"), 10, 64)
+		if err != nil {
+			http.Error(writer, ErrAttachmentNotFound.Error(), http.StatusNotFound)
+			return
+		}
+
+		attachment, content, err := manager.OpenAttachment(attachmentId, userAuthentication.UserId)
+		switch err {
+		case nil:
+		case ErrAttachmentNotFound:
+			http.Error(writer, err.Error(), http.StatusNotFound)
+			return
+		default:
+			log.Printf("Error while opening attachment: %s", err)
+			http.Error(writer, "could not download attachment", http.StatusInternalServerError)
+			return
+		}
+		defer content.Close()
+
+		writer.Header().Set("Content-Type", "application/octet-stream")
+		writer.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Name}))
+		writer.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+		writer.Header().Set("X-Content-Type-Options", "nosniff")
+		// A failure past this point can only abort the response, which clients notice by its missing bytes
+		if _, err = io.Copy(writer, content); err != nil {
+			log.Printf("Error while streaming attachment %d: %s", attachment.Id, err)
+		}
+	}
+}
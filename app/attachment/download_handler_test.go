@@ -0,0 +1,69 @@
+package attachment
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+// DownloadHandler should stream the decrypted attachment to its owner as a download
+func TestDownloadHandler(t *testing.T) {
+	managerMock := mockutil.DefaultAttachmentManagerMock()
+
+	response := downloadAttachment(managerMock, mockutil.DefaultJwtAuthenticationServiceMock(), "1")
+	assert.Equal(t, response.Code, http.StatusOK)
+	assert.Equal(t, response.Body.String(), mockutil.DefaultAttachmentContent)
+	assert.Equal(t, response.Header().Get("Content-Type"), "application/octet-stream")
+	assert.Equal(t, response.Header().Get("Content-Disposition"), `attachment; filename=recovery-codes.txt`)
+	assert.Equal(t, response.Header().Get("Content-Length"), strconv.Itoa(len(mockutil.DefaultAttachmentContent)))
+	assert.Equal(t, response.Header().Get("X-Content-Type-Options"), "nosniff")
+	assert.Equal(t, response.Header().Get("Cache-Control"), "no-store", "Attachments should never be cached")
+	managerMock.AssertCalled(t, "OpenAttachment", mockutil.DefaultIdAsUint64, mockutil.DefaultIdAsUint64)
+}
+
+// DownloadHandler should reject unauthenticated downloads
+func TestDownloadHandlerWithoutAuthentication(t *testing.T) {
+	managerMock := mockutil.DefaultAttachmentManagerMock()
+	authenticatorMock := new(mockutil.JwtAuthenticationServiceMock)
+	authenticatorMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil)
+
+	response := downloadAttachment(managerMock, authenticatorMock, "1")
+	assert.Equal(t, response.Code, http.StatusUnauthorized)
+	managerMock.AssertNotCalled(t, "OpenAttachment", mock.Anything, mock.Anything)
+}
+
+// DownloadHandler should map errors while opening the attachment to response statuses
+func TestDownloadHandlerWithOpenErrors(t *testing.T) {
+	statuses := map[error]int{
+		ErrAttachmentNotFound:                          http.StatusNotFound,
+		errors.New(mockutil.MockedGenericErrorMessage): http.StatusInternalServerError,
+	}
+
+	for openError, status := range statuses {
+		managerMock := new(mockutil.AttachmentManagerMock)
+		managerMock.On("OpenAttachment", mock.Anything, mock.Anything).Return(nil, openError)
+
+		response := downloadAttachment(managerMock, mockutil.DefaultJwtAuthenticationServiceMock(), "1")
+		assert.Equal(t, response.Code, status)
+		assert.NotContains(t, response.Body.String(), mockutil.DefaultAttachmentContent)
+	}
+
+	response := downloadAttachment(mockutil.DefaultAttachmentManagerMock(), mockutil.DefaultJwtAuthenticationServiceMock(), "invalid")
+	assert.Equal(t, response.Code, http.StatusNotFound)
+}
+
+func downloadAttachment(manager Manager, authenticator authentication.JwtAuthenticator, attachmentId string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Get("/attachments/{attachmentId}", DownloadHandler(manager, authenticator))
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodGet, "/attachments/"+attachmentId, nil))
+	return response
+}
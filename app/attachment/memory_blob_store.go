@@ -0,0 +1,52 @@
+package attachment
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"sync"
+)
+
+// MemoryBlobStore keeps blobs in memory, it's meant for development and tests since blobs are lost on restart
+type MemoryBlobStore struct {
+	mutex sync.RWMutex
+	blobs map[string][]byte
+}
+
+func NewMemoryBlobStore() *MemoryBlobStore {
+	return &MemoryBlobStore{blobs: make(map[string][]byte)}
+}
+
+func (store *MemoryBlobStore) Put(key string, content io.Reader, size int64) error {
+	if err := validateBlobKey(key); err != nil {
+		return err
+	}
+
+	blob, err := ioutil.ReadAll(content)
+	if err != nil {
+		return err
+	}
+
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	store.blobs[key] = blob
+	return nil
+}
+
+func (store *MemoryBlobStore) Get(key string) (io.ReadCloser, error) {
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	blob, ok := store.blobs[key]
+	if !ok {
+		return nil, ErrBlobNotFound
+	}
+	return ioutil.NopCloser(bytes.NewReader(blob)), nil
+}
+
+func (store *MemoryBlobStore) Delete(key string) error {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+	delete(store.blobs, key)
+	return nil
+}
@@ -0,0 +1,176 @@
+package attachment
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	s3Service          = "s3"
+	s3SigningAlgorithm = "AWS4-HMAC-SHA256"
+	s3UnsignedPayload  = "UNSIGNED-PAYLOAD"
+	s3DateFormat       = "20060102"
+	s3TimeFormat       = "20060102T150405Z"
+)
+
+// Variable meant for mocking
+var s3Now = func() time.Time { return time.Now().UTC() }
+
+// S3BlobStore keeps blobs in a bucket of an S3 compatible object storage like AWS S3 or MinIO. Objects are addressed
+// path-style and requests are signed with AWS Signature Version 4, leaving the payload unsigned so uploads can be
+// streamed, their integrity is already covered by the encryption.
+type S3BlobStore struct {
+	endpoint        string
+	region          string
+	bucket          string
+	accessKeyId     string
+	secretAccessKey string
+	client          *http.Client
+}
+
+func NewS3BlobStore(attachmentConfig *config.Attachment) *S3BlobStore {
+	return &S3BlobStore{
+		endpoint:        strings.TrimSuffix(attachmentConfig.S3Endpoint, "/"),
+		region:          attachmentConfig.S3Region,
+		bucket:          attachmentConfig.S3Bucket,
+		accessKeyId:     attachmentConfig.S3AccessKeyId,
+		secretAccessKey: attachmentConfig.S3SecretAccessKey,
+		client:          &http.Client{},
+	}
+}
+
+func (store *S3BlobStore) Put(key string, content io.Reader, size int64) error {
+	request, err := store.newRequest(http.MethodPut, key, content)
+	if err != nil {
+		return err
+	}
+	request.ContentLength = size
+
+	response, err := store.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(response.Body)
+
+	if response.StatusCode != http.StatusOK {
+		return store.unexpectedStatus(http.MethodPut, key, response.StatusCode)
+	}
+	return nil
+}
+
+func (store *S3BlobStore) Get(key string) (io.ReadCloser, error) {
+	request, err := store.newRequest(http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := store.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+
+	switch response.StatusCode {
+	case http.StatusOK:
+		return response.Body, nil
+	case http.StatusNotFound:
+		drainAndClose(response.Body)
+		return nil, ErrBlobNotFound
+	default:
+		drainAndClose(response.Body)
+		return nil, store.unexpectedStatus(http.MethodGet, key, response.StatusCode)
+	}
+}
+
+func (store *S3BlobStore) Delete(key string) error {
+	request, err := store.newRequest(http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := store.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer drainAndClose(response.Body)
+
+	if response.StatusCode != http.StatusNoContent && response.StatusCode != http.StatusOK && response.StatusCode != http.StatusNotFound {
+		return store.unexpectedStatus(http.MethodDelete, key, response.StatusCode)
+	}
+	return nil
+}
+
+func (store *S3BlobStore) newRequest(method string, key string, body io.Reader) (*http.Request, error) {
+	if err := validateBlobKey(key); err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequest(method, store.endpoint+"/"+store.bucket+"/"+key, body)
+	if err != nil {
+		return nil, err
+	}
+	store.sign(request, s3Now())
+
+	return request, nil
+}
+
+// sign adds the AWS Signature Version 4 authorization of the request
+func (store *S3BlobStore) sign(request *http.Request, now time.Time) {
+	date := now.Format(s3DateFormat)
+	request.Header.Set("X-Amz-Date", now.Format(s3TimeFormat))
+	request.Header.Set("X-Amz-Content-Sha256", s3UnsignedPayload)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalRequest := strings.Join([]string{
+		request.Method,
+		request.URL.EscapedPath(),
+		request.URL.Query().Encode(),
+		"host:" + request.Host + "\n" +
+			"x-amz-content-sha256:" + s3UnsignedPayload + "\n" +
+			"x-amz-date:" + request.Header.Get("X-Amz-Date") + "\n",
+		signedHeaders,
+		s3UnsignedPayload,
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+
+	scope := date + "/" + store.region + "/" + s3Service + "/aws4_request"
+	stringToSign := strings.Join([]string{
+		s3SigningAlgorithm, request.Header.Get("X-Amz-Date"), scope, hex.EncodeToString(canonicalRequestHash[:]),
+	}, "\n")
+	signature := hmacSha256(deriveSigningKey(store.secretAccessKey, date, store.region, s3Service), stringToSign)
+
+	request.Header.Set("Authorization", fmt.Sprintf(
+		"%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s3SigningAlgorithm, store.accessKeyId, scope, signedHeaders, hex.EncodeToString(signature),
+	))
+}
+
+func (store *S3BlobStore) unexpectedStatus(method string, key string, status int) error {
+	return fmt.Errorf("s3 %s of %s responded with status %d", method, key, status)
+}
+
+func deriveSigningKey(secretAccessKey string, date string, region string, service string) []byte {
+	key := hmacSha256([]byte("AWS4"+secretAccessKey), date)
+	key = hmacSha256(key, region)
+	key = hmacSha256(key, service)
+	return hmacSha256(key, "aws4_request")
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// drainAndClose reads the rest of the response body so its connection can be reused
+func drainAndClose(body io.ReadCloser) {
+	io.Copy(ioutil.Discard, body)
+	body.Close()
+}
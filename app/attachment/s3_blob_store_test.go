@@ -0,0 +1,96 @@
+package attachment
+
+import (
+	"encoding/hex"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testS3Bucket          = "gokeeper-attachments"
+	testS3AccessKeyId     = "AKIDEXAMPLE"
+	testS3SecretAccessKey = "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"
+)
+
+// deriveSigningKey should match the signing key of the AWS Signature Version 4 documentation example
+func TestDeriveSigningKey(t *testing.T) {
+	signingKey := deriveSigningKey(testS3SecretAccessKey, "20120215", "us-east-1", "iam")
+	assert.Equal(t, hex.EncodeToString(signingKey), "f4780e2d9f65fa895f9c67b32ce1baf0b0d8a43505a000a1a9e090d414db404d")
+}
+
+// S3BlobStore should return an error when the storage rejects a request
+func TestS3BlobStoreWithRejectedRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+	store := newTestS3BlobStore(server.URL)
+
+	err := store.Put("attachments/1/blob", strings.NewReader("blob"), 4)
+	assert.EqualError(t, err, "s3 PUT of attachments/1/blob responded with status 403")
+	_, err = store.Get("attachments/1/blob")
+	assert.EqualError(t, err, "s3 GET of attachments/1/blob responded with status 403")
+	err = store.Delete("attachments/1/blob")
+	assert.EqualError(t, err, "s3 DELETE of attachments/1/blob responded with status 403")
+}
+
+func newTestS3BlobStore(endpoint string) *S3BlobStore {
+	return NewS3BlobStore(&config.Attachment{
+		S3Endpoint:        endpoint,
+		S3Region:          "us-east-1",
+		S3Bucket:          testS3Bucket,
+		S3AccessKeyId:     testS3AccessKeyId,
+		S3SecretAccessKey: testS3SecretAccessKey,
+	})
+}
+
+// newFakeS3Server is a MinIO-like stand-in for a single bucket, it only serves requests carrying a valid signature
+func newFakeS3Server(t *testing.T) *httptest.Server {
+	var mutex sync.Mutex
+	objects := map[string][]byte{}
+	verifier := newTestS3BlobStore("")
+
+	return httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		signedAt, err := time.Parse(s3TimeFormat, request.Header.Get("X-Amz-Date"))
+		expectedRequest := request.Clone(request.Context())
+		if err == nil {
+			verifier.sign(expectedRequest, signedAt)
+		}
+		if err != nil || request.Header.Get("Authorization") != expectedRequest.Header.Get("Authorization") {
+			writer.WriteHeader(http.StatusForbidden)
+			return
+		}
+		if !strings.HasPrefix(request.URL.Path, "/"+testS3Bucket+"/") {
+			writer.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		key := strings.TrimPrefix(request.URL.Path, "/"+testS3Bucket+"/")
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch request.Method {
+		case http.MethodPut:
+			object, err := ioutil.ReadAll(request.Body)
+			assert.Nil(t, err)
+			assert.Equal(t, int64(len(object)), request.ContentLength, "Uploads should announce their size")
+			objects[key] = object
+		case http.MethodGet:
+			object, ok := objects[key]
+			if !ok {
+				writer.WriteHeader(http.StatusNotFound)
+				return
+			}
+			writer.Write(object)
+		case http.MethodDelete:
+			delete(objects, key)
+			writer.WriteHeader(http.StatusNoContent)
+		}
+	}))
+}
@@ -0,0 +1,71 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"log"
+	"time"
+)
+
+// Variables meant for mocking
+var now = func() time.Time { return time.Now().UTC() }
+
+// Recorder records security relevant events and publishes them, recording never fails the request that caused the event
+type Recorder interface {
+	Record(event *model.AuditEvent)
+}
+
+// Publisher passes recorded events on to outside listeners
+type Publisher interface {
+	Publish(event *model.AuditEvent)
+}
+
+type auditService struct {
+	auditEventRepository repository.AuditEventRepository
+	publisher            Publisher
+}
+
+func NewAuditService(auditEventRepository repository.AuditEventRepository, publisher Publisher) *auditService {
+	return &auditService{auditEventRepository: auditEventRepository, publisher: publisher}
+}
+
+func (service *auditService) Record(event *model.AuditEvent) {
+	// The database keeps microseconds, the hash has to be computed from the same time that gets stored
+	event.CreatedAt = now().Truncate(time.Microsecond)
+	if err := service.auditEventRepository.AppendEvent(event, HashEvent); err != nil {
+		log.Printf("Error while recording %s audit event: %s", event.Type, err)
+		return
+	}
+	service.publisher.Publish(event)
+}
+
+// hashedEvent fixes the field order of the hashed content, so the hash doesn't depend on the model's layout
+type hashedEvent struct {
+	Sequence  int64   `json:"sequence"`
+	UserId    *uint64 `json:"userId"`
+	Type      string  `json:"type"`
+	IpAddress string  `json:"ipAddress"`
+	UserAgent string  `json:"userAgent"`
+	Details   *string `json:"details"`
+	CreatedAt string  `json:"createdAt"`
+}
+
+// HashEvent chains the event to its predecessor by hashing the previous hash together with the event's content
+func HashEvent(event *model.AuditEvent) []byte {
+	content, _ := json.Marshal(&hashedEvent{
+		Sequence:  event.Sequence,
+		UserId:    event.UserId,
+		Type:      event.Type,
+		IpAddress: event.IpAddress,
+		UserAgent: event.UserAgent,
+		Details:   event.Details,
+		CreatedAt: event.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+
+	hash := sha256.New()
+	hash.Write(event.PreviousHash)
+	hash.Write(content)
+	return hash.Sum(nil)
+}
@@ -0,0 +1,98 @@
+package audit
+
+import (
+	"bytes"
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+// Record should store the event with the current time truncated to what the database keeps
+func TestRecord(t *testing.T) {
+	defer func(original func() time.Time) { now = original }(now)
+	now = func() time.Time { return time.Date(2021, time.January, 1, 0, 0, 0, 123456789, time.UTC) }
+	auditEventRepositoryMock := mockutil.DefaultAuditEventRepositoryServiceMock()
+	publisherMock := mockutil.DefaultWebhookPublisherMock()
+
+	event := &model.AuditEvent{Type: model.AuditEventSignIn}
+	NewAuditService(auditEventRepositoryMock, publisherMock).Record(event)
+
+	assert.Equal(t, event.CreatedAt, time.Date(2021, time.January, 1, 0, 0, 0, 123456000, time.UTC))
+	auditEventRepositoryMock.AssertCalled(t, "AppendEvent", event, mock.Anything)
+	publisherMock.AssertCalled(t, "Publish", event)
+}
+
+// Record should neither panic nor publish the event if it can't be stored
+func TestRecordWithRepositoryError(t *testing.T) {
+	auditEventRepositoryMock := new(mockutil.AuditEventRepositoryServiceMock)
+	auditEventRepositoryMock.On("AppendEvent", mock.Anything, mock.Anything).Return(errors.New(mockutil.MockedGenericErrorMessage))
+	publisherMock := mockutil.DefaultWebhookPublisherMock()
+
+	assert.NotPanics(t, func() {
+		NewAuditService(auditEventRepositoryMock, publisherMock).Record(&model.AuditEvent{Type: model.AuditEventSignIn})
+	})
+	publisherMock.AssertNotCalled(t, "Publish", mock.Anything)
+}
+
+// HashEvent should depend on the previous hash and on every field of the event
+func TestHashEvent(t *testing.T) {
+	event := testAuditEvent(1, []byte{})
+	hash := HashEvent(&event)
+	assert.Equal(t, len(hash), 32, "Should be a SHA-256 hash")
+	assert.Equal(t, HashEvent(&event), hash, "Should be deterministic")
+
+	changedEvents := map[string]func(event *model.AuditEvent){
+		"previous hash": func(event *model.AuditEvent) { event.PreviousHash = []byte("previous") },
+		"sequence":      func(event *model.AuditEvent) { event.Sequence = 2 },
+		"user":          func(event *model.AuditEvent) { event.UserId = nil },
+		"type":          func(event *model.AuditEvent) { event.Type = model.AuditEventSignInFailed },
+		"ip address":    func(event *model.AuditEvent) { event.IpAddress = "10.0.0.1" },
+		"user agent":    func(event *model.AuditEvent) { event.UserAgent = "other-agent" },
+		"details":       func(event *model.AuditEvent) { event.Details = nil },
+		"time":          func(event *model.AuditEvent) { event.CreatedAt = event.CreatedAt.Add(time.Microsecond) },
+	}
+	for field, change := range changedEvents {
+		changedEvent := testAuditEvent(1, []byte{})
+		change(&changedEvent)
+		assert.False(t, bytes.Equal(HashEvent(&changedEvent), hash), "Hash should change with the %s", field)
+	}
+}
+
+func testAuditEvent(sequence int64, previousHash []byte) model.AuditEvent {
+	userId := mockutil.DefaultIdAsUint64
+	details := "entry 1"
+	return model.AuditEvent{
+		Id:           uint64(sequence),
+		Sequence:     sequence,
+		UserId:       &userId,
+		Type:         model.AuditEventPasswordCreated,
+		IpAddress:    "127.0.0.1",
+		UserAgent:    "test-agent",
+		Details:      &details,
+		CreatedAt:    time.Date(2021, time.January, 1, 0, 0, int(sequence), 0, time.UTC),
+		PreviousHash: previousHash,
+	}
+}
+
+// testAuditChain builds a valid chain of the given length
+func testAuditChain(length int) model.AuditEvents {
+	events := model.AuditEvents{}
+	previousHash := []byte{}
+	for sequence := int64(1); sequence <= int64(length); sequence++ {
+		event := testAuditEvent(sequence, previousHash)
+		event.Hash = HashEvent(&event)
+		previousHash = event.Hash
+		events = append(events, event)
+	}
+	return events
+}
+
+func auditEventRepositoryMockWithChain(events model.AuditEvents) *mockutil.AuditEventRepositoryServiceMock {
+	auditEventRepositoryMock := new(mockutil.AuditEventRepositoryServiceMock)
+	auditEventRepositoryMock.On("FetchEventsAfterSequence", mock.Anything, int64(0), batchSize).Return(nil, events)
+	return auditEventRepositoryMock
+}
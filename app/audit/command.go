@@ -0,0 +1,79 @@
+package audit
+
+import (
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"io"
+	"os"
+)
+
+const commandUsage = "usage: audit verify | audit export [--format jsonl|syslog] [--output file]"
+
+// RunCommand runs an audit subcommand and returns the process exit code
+func RunCommand(arguments []string, auditEventRepository repository.AuditEventRepository, output io.Writer) int {
+	if len(arguments) == 0 {
+		fmt.Fprintln(output, commandUsage)
+		return 2
+	}
+
+	switch arguments[0] {
+	case "verify":
+		return runVerify(auditEventRepository, output)
+	case "export":
+		return runExport(arguments[1:], auditEventRepository, output)
+	default:
+		fmt.Fprintln(output, commandUsage)
+		return 2
+	}
+}
+
+func runVerify(auditEventRepository repository.AuditEventRepository, output io.Writer) int {
+	result, err := VerifyChain(auditEventRepository)
+	if err != nil {
+		fmt.Fprintf(output, "Could not verify the audit log: %s\n", err)
+		return 1
+	}
+
+	if !result.Intact() {
+		fmt.Fprintf(
+			output, "Audit log verification failed: %s at sequence %d after %d intact event/s\n",
+			result.Status, result.BrokenSequence, result.VerifiedEvents,
+		)
+		return 1
+	}
+	fmt.Fprintf(output, "Audit log is intact, verified %d event/s, last hash %s\n", result.VerifiedEvents, hex.EncodeToString(result.LastHash))
+	return 0
+}
+
+func runExport(arguments []string, auditEventRepository repository.AuditEventRepository, output io.Writer) int {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	flags.SetOutput(output)
+	format := flags.String("format", FormatJsonLines, "export format, jsonl or syslog")
+	outputFile := flags.String("output", "", "file to write the export to, standard output by default")
+	if err := flags.Parse(arguments); err != nil {
+		return 2
+	}
+
+	writer := output
+	if *outputFile != "" {
+		file, err := os.OpenFile(*outputFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+		if err != nil {
+			fmt.Fprintf(output, "Could not create the export file: %s\n", err)
+			return 1
+		}
+		defer file.Close()
+		writer = file
+	}
+
+	exportedEvents, err := Export(auditEventRepository, writer, *format)
+	if err != nil {
+		fmt.Fprintf(output, "Could not export the audit log: %s\n", err)
+		return 1
+	}
+	if *outputFile != "" {
+		fmt.Fprintf(output, "Exported %d audit event/s to %s\n", exportedEvents, *outputFile)
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package audit
+
+import (
+	"bytes"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// RunCommand verify should succeed for an intact chain
+func TestRunVerifyCommand(t *testing.T) {
+	output := &bytes.Buffer{}
+
+	exitCode := RunCommand([]string{"verify"}, auditEventRepositoryMockWithChain(testAuditChain(2)), output)
+	assert.Equal(t, exitCode, 0)
+	assert.Contains(t, output.String(), "Audit log is intact, verified 2 event/s")
+}
+
+// RunCommand verify should fail and report where the chain breaks
+func TestRunVerifyCommandWithTamperedChain(t *testing.T) {
+	events := testAuditChain(2)
+	events[0].Type = model.AuditEventSignIn
+	output := &bytes.Buffer{}
+
+	exitCode := RunCommand([]string{"verify"}, auditEventRepositoryMockWithChain(events), output)
+	assert.Equal(t, exitCode, 1)
+	assert.Contains(t, output.String(), "tampered_event at sequence 1")
+}
+
+// RunCommand export should write the export to the given file
+func TestRunExportCommand(t *testing.T) {
+	exportFile := filepath.Join(t.TempDir(), "audit.jsonl")
+	output := &bytes.Buffer{}
+
+	exitCode := RunCommand(
+		[]string{"export", "--format", "jsonl", "--output", exportFile}, auditEventRepositoryMockWithChain(testAuditChain(2)), output,
+	)
+	assert.Equal(t, exitCode, 0)
+	assert.Contains(t, output.String(), "Exported 2 audit event/s")
+	export, _ := os.ReadFile(exportFile)
+	assert.Equal(t, len(strings.Split(strings.TrimSpace(string(export)), "\n")), 2)
+}
+
+// RunCommand should print the usage for unknown subcommands and formats
+func TestRunCommandWithInvalidArguments(t *testing.T) {
+	output := &bytes.Buffer{}
+	assert.Equal(t, RunCommand([]string{}, auditEventRepositoryMockWithChain(model.AuditEvents{}), output), 2)
+	assert.Equal(t, RunCommand([]string{"rewrite"}, auditEventRepositoryMockWithChain(model.AuditEvents{}), output), 2)
+	assert.Contains(t, output.String(), commandUsage)
+
+	exitCode := RunCommand([]string{"export", "--format", "csv"}, auditEventRepositoryMockWithChain(model.AuditEvents{}), output)
+	assert.Equal(t, exitCode, 1)
+}
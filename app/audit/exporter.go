@@ -0,0 +1,149 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatJsonLines = "jsonl"
+	FormatSyslog    = "syslog"
+)
+
+const (
+	// syslogFacility is the authpriv facility, meant for security and authorization messages
+	syslogFacility        = 10
+	syslogSeverityWarning = 4
+	syslogSeverityNotice  = 5
+	// syslogStructuredDataId uses the enterprise number reserved for documentation until one gets registered
+	syslogStructuredDataId = "audit@32473"
+)
+
+var ErrUnknownExportFormat = errors.New("unknown audit export format")
+
+// Variables meant for mocking
+var hostname = os.Hostname
+
+var failureEvents = map[string]bool{
+	model.AuditEventSignInFailed:           true,
+	model.AuditEventAuthenticationRejected: true,
+}
+
+type exportedEvent struct {
+	Sequence     int64   `json:"sequence"`
+	UserId       *uint64 `json:"userId"`
+	Type         string  `json:"type"`
+	IpAddress    string  `json:"ipAddress"`
+	UserAgent    string  `json:"userAgent"`
+	Details      *string `json:"details"`
+	CreatedAt    string  `json:"createdAt"`
+	PreviousHash string  `json:"previousHash"`
+	Hash         string  `json:"hash"`
+}
+
+// Export writes the whole chain in order, one event per line, and returns the number of exported events
+func Export(auditEventRepository repository.AuditEventRepository, writer io.Writer, format string) (int64, error) {
+	var formatEvent func(event *model.AuditEvent) (string, error)
+	switch format {
+	case FormatJsonLines:
+		formatEvent = formatJsonLine
+	case FormatSyslog:
+		host, err := hostname()
+		if err != nil || host == "" {
+			host = "-"
+		}
+		formatEvent = func(event *model.AuditEvent) (string, error) { return formatSyslogMessage(event, host), nil }
+	default:
+		return 0, ErrUnknownExportFormat
+	}
+
+	bufferedWriter := bufio.NewWriter(writer)
+	exportedEvents := int64(0)
+	lastSequence := int64(0)
+	for {
+		events := model.AuditEvents{}
+		if err := auditEventRepository.FetchEventsAfterSequence(&events, lastSequence, batchSize); err != nil {
+			return exportedEvents, err
+		}
+
+		for index := range events {
+			line, err := formatEvent(&events[index])
+			if err != nil {
+				return exportedEvents, err
+			}
+			if _, err = bufferedWriter.WriteString(line + "\n"); err != nil {
+				return exportedEvents, err
+			}
+			lastSequence = events[index].Sequence
+			exportedEvents++
+		}
+
+		if len(events) < batchSize {
+			return exportedEvents, bufferedWriter.Flush()
+		}
+	}
+}
+
+func formatJsonLine(event *model.AuditEvent) (string, error) {
+	line, err := json.Marshal(&exportedEvent{
+		Sequence:     event.Sequence,
+		UserId:       event.UserId,
+		Type:         event.Type,
+		IpAddress:    event.IpAddress,
+		UserAgent:    event.UserAgent,
+		Details:      event.Details,
+		CreatedAt:    event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		PreviousHash: hex.EncodeToString(event.PreviousHash),
+		Hash:         hex.EncodeToString(event.Hash),
+	})
+	return string(line), err
+}
+
+// formatSyslogMessage formats the event as an RFC 5424 message with the event's fields as structured data
+func formatSyslogMessage(event *model.AuditEvent, host string) string {
+	severity := syslogSeverityNotice
+	if failureEvents[event.Type] {
+		severity = syslogSeverityWarning
+	}
+
+	parameters := []string{
+		structuredDataParameter("sequence", strconv.FormatInt(event.Sequence, 10)),
+	}
+	if event.UserId != nil {
+		parameters = append(parameters, structuredDataParameter("userId", strconv.FormatUint(*event.UserId, 10)))
+	}
+	parameters = append(parameters,
+		structuredDataParameter("ipAddress", event.IpAddress),
+		structuredDataParameter("userAgent", event.UserAgent),
+	)
+	if event.Details != nil {
+		parameters = append(parameters, structuredDataParameter("details", *event.Details))
+	}
+	parameters = append(parameters, structuredDataParameter("hash", hex.EncodeToString(event.Hash)))
+
+	return fmt.Sprintf(
+		"<%d>1 %s %s gokeeper - %s [%s %s]",
+		syslogFacility*8+severity,
+		event.CreatedAt.UTC().Format("2006-01-02T15:04:05.000000Z07:00"),
+		host,
+		event.Type,
+		syslogStructuredDataId,
+		strings.Join(parameters, " "),
+	)
+}
+
+var structuredDataEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+
+func structuredDataParameter(name string, value string) string {
+	return name + `="` + structuredDataEscaper.Replace(value) + `"`
+}
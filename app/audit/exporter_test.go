@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"strings"
+	"testing"
+)
+
+// Export should write one JSON object per event with hex encoded hashes
+func TestExportJsonLines(t *testing.T) {
+	events := testAuditChain(2)
+	output := &bytes.Buffer{}
+
+	exportedEvents, err := Export(auditEventRepositoryMockWithChain(events), output, FormatJsonLines)
+	assert.Nil(t, err)
+	assert.Equal(t, exportedEvents, int64(2))
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, len(lines), 2)
+	exported := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &exported))
+	assert.Equal(t, exported["sequence"], float64(2))
+	assert.Equal(t, exported["type"], model.AuditEventPasswordCreated)
+	assert.Equal(t, exported["createdAt"], "2021-01-01T00:00:02Z")
+	assert.Equal(t, exported["previousHash"], hex.EncodeToString(events[0].Hash))
+	assert.Equal(t, exported["hash"], hex.EncodeToString(events[1].Hash))
+}
+
+// Export should write RFC 5424 messages with escaped structured data and a higher severity for failures
+func TestExportSyslog(t *testing.T) {
+	defer func(original func() (string, error)) { hostname = original }(hostname)
+	hostname = func() (string, error) { return "vault", nil }
+	events := testAuditChain(2)
+	events[1].Type = model.AuditEventSignInFailed
+	events[1].UserAgent = `agent "with" [brackets]`
+	output := &bytes.Buffer{}
+
+	_, err := Export(auditEventRepositoryMockWithChain(events), output, FormatSyslog)
+	assert.Nil(t, err)
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	assert.Equal(t, lines[0], "<85>1 2021-01-01T00:00:01.000000Z vault gokeeper - password_created [audit@32473"+
+		` sequence="1" userId="1" ipAddress="127.0.0.1" userAgent="test-agent" details="entry 1"`+
+		` hash="`+hex.EncodeToString(events[0].Hash)+`"]`)
+	assert.True(t, strings.HasPrefix(lines[1], "<84>1 "), "Failures should be exported as warnings")
+	assert.Contains(t, lines[1], `userAgent="agent \"with\" [brackets\]"`)
+}
+
+// Export should reject unknown formats
+func TestExportWithUnknownFormat(t *testing.T) {
+	_, err := Export(auditEventRepositoryMockWithChain(model.AuditEvents{}), os.Stdout, "csv")
+	assert.Equal(t, err, ErrUnknownExportFormat)
+}
@@ -0,0 +1,65 @@
+package audit
+
+import (
+	"bytes"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+)
+
+const batchSize = 500
+
+const (
+	ChainIntact        = "intact"
+	ChainGap           = "gap"
+	ChainBrokenLink    = "broken_link"
+	ChainTamperedEvent = "tampered_event"
+)
+
+// VerificationResult describes the first problem found in the chain, the last hash of an intact chain can be
+// stored outside the database to detect a rewrite of the whole chain later on
+type VerificationResult struct {
+	Status         string
+	VerifiedEvents int64
+	BrokenSequence int64
+	LastHash       []byte
+}
+
+func (result *VerificationResult) Intact() bool {
+	return result.Status == ChainIntact
+}
+
+// VerifyChain walks the whole chain in order and checks that no event is missing, that each event links to its
+// predecessor and that each event's content still matches its hash
+func VerifyChain(auditEventRepository repository.AuditEventRepository) (*VerificationResult, error) {
+	result := &VerificationResult{Status: ChainIntact, LastHash: []byte{}}
+	lastSequence := int64(0)
+	for {
+		events := model.AuditEvents{}
+		if err := auditEventRepository.FetchEventsAfterSequence(&events, lastSequence, batchSize); err != nil {
+			return nil, err
+		}
+
+		for index := range events {
+			event := &events[index]
+			switch {
+			case event.Sequence != lastSequence+1:
+				result.Status, result.BrokenSequence = ChainGap, lastSequence+1
+			case !bytes.Equal(event.PreviousHash, result.LastHash):
+				result.Status, result.BrokenSequence = ChainBrokenLink, event.Sequence
+			case !bytes.Equal(HashEvent(event), event.Hash):
+				result.Status, result.BrokenSequence = ChainTamperedEvent, event.Sequence
+			}
+			if !result.Intact() {
+				return result, nil
+			}
+
+			lastSequence = event.Sequence
+			result.LastHash = event.Hash
+			result.VerifiedEvents++
+		}
+
+		if len(events) < batchSize {
+			return result, nil
+		}
+	}
+}
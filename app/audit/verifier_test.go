@@ -0,0 +1,75 @@
+package audit
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+// VerifyChain should verify every event of an intact chain and return the last hash
+func TestVerifyChain(t *testing.T) {
+	events := testAuditChain(3)
+
+	result, err := VerifyChain(auditEventRepositoryMockWithChain(events))
+	assert.Nil(t, err)
+	assert.True(t, result.Intact())
+	assert.Equal(t, result.VerifiedEvents, int64(3))
+	assert.Equal(t, result.LastHash, events[2].Hash)
+}
+
+// VerifyChain should consider an empty chain intact
+func TestVerifyChainWithoutEvents(t *testing.T) {
+	result, err := VerifyChain(auditEventRepositoryMockWithChain(model.AuditEvents{}))
+	assert.Nil(t, err)
+	assert.True(t, result.Intact())
+	assert.Equal(t, result.VerifiedEvents, int64(0))
+}
+
+// VerifyChain should detect a deleted event
+func TestVerifyChainWithGap(t *testing.T) {
+	events := testAuditChain(3)
+
+	result, err := VerifyChain(auditEventRepositoryMockWithChain(model.AuditEvents{events[0], events[2]}))
+	assert.Nil(t, err)
+	assert.Equal(t, result.Status, ChainGap)
+	assert.Equal(t, result.BrokenSequence, int64(2))
+	assert.Equal(t, result.VerifiedEvents, int64(1))
+}
+
+// VerifyChain should detect an event whose content was changed
+func TestVerifyChainWithTamperedEvent(t *testing.T) {
+	events := testAuditChain(3)
+	events[1].IpAddress = "10.0.0.1"
+
+	result, err := VerifyChain(auditEventRepositoryMockWithChain(events))
+	assert.Nil(t, err)
+	assert.Equal(t, result.Status, ChainTamperedEvent)
+	assert.Equal(t, result.BrokenSequence, int64(2))
+}
+
+// VerifyChain should detect an event that was rewritten together with its hash
+func TestVerifyChainWithRewrittenEvent(t *testing.T) {
+	events := testAuditChain(3)
+	events[1].IpAddress = "10.0.0.1"
+	events[1].Hash = HashEvent(&events[1])
+
+	result, err := VerifyChain(auditEventRepositoryMockWithChain(events))
+	assert.Nil(t, err)
+	assert.Equal(t, result.Status, ChainBrokenLink)
+	assert.Equal(t, result.BrokenSequence, int64(3))
+}
+
+// VerifyChain should return an error if the events can't be fetched
+func TestVerifyChainWithRepositoryError(t *testing.T) {
+	auditEventRepositoryMock := new(mockutil.AuditEventRepositoryServiceMock)
+	auditEventRepositoryMock.On("FetchEventsAfterSequence", mock.Anything, mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage), nil,
+	)
+
+	result, err := VerifyChain(auditEventRepositoryMock)
+	assert.NotNil(t, err)
+	assert.Nil(t, result)
+}
@@ -2,22 +2,48 @@ package authentication
 
 import (
 	"context"
+	"errors"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
 	"github.com/dgrijalva/jwt-go"
 	"log"
 	"net/http"
+	"time"
 )
 
 type UserAuthentication struct {
-	UserId uint64
+	UserId    uint64
+	SessionId uint64
+}
+
+// SessionValidator refreshes the last seen time of the user's active sessions and returns an error for sessions that
+// aren't active or belong to another user
+type SessionValidator interface {
+	TouchActiveSessionById(lastSeenAt time.Time, id uint64, userId uint64) error
+}
+
+// EventRecorder records rejected authentication attempts in the audit log
+type EventRecorder interface {
+	Record(event *model.AuditEvent)
 }
 
 var userContextKey = &contextKey{"user"}
 
+var errUnauthorisedConnection = errors.New("unauthorised connection")
+
+// rejection describes why a token got rejected, the user is only known for valid tokens of inactive sessions
+type rejection struct {
+	userId *uint64
+	reason string
+}
+
 type contextKey struct {
 	name string
 }
 
-func AuthenticationMiddleware(jwtSigningKey string) func(http.Handler) http.Handler {
+func AuthenticationMiddleware(
+	jwtSigningKey string, sessionValidator SessionValidator, eventRecorder EventRecorder,
+) func(http.Handler) http.Handler {
 	return func(nextHandler http.Handler) http.Handler {
 		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
 			token := request.Header.Get("Authentication")
@@ -26,26 +52,73 @@ func AuthenticationMiddleware(jwtSigningKey string) func(http.Handler) http.Hand
 				return
 			}
 
-			userClaims := &UserClaims{}
-			decodedToken, err := decodeJwt(token, userClaims, jwtSigningKey)
-			if err != nil || !decodedToken.Valid {
-				if err != nil {
-					log.Printf("Error occurred while decoding JWT: %s", err)
-				}
-				log.Println("Invalid jwt, unauthorised request")
+			userAuthentication, tokenRejection := authenticate(token, jwtSigningKey, sessionValidator)
+			if userAuthentication == nil {
+				recordRejectedAuthentication(eventRecorder, request.Context(), tokenRejection)
 				writer.WriteHeader(http.StatusUnauthorized)
 				nextHandler.ServeHTTP(writer, request)
 				return
 			}
 
-			ctx := context.WithValue(request.Context(), userContextKey, &UserAuthentication{UserId: userClaims.UserID})
-			request = request.WithContext(ctx)
-
+			request = request.WithContext(context.WithValue(request.Context(), userContextKey, userAuthentication))
 			nextHandler.ServeHTTP(writer, request)
 		})
 	}
 }
 
+// WebsocketInitFunc authenticates graphql-ws connections with the token sent as Authentication in the connection
+// init payload, since browsers can't set headers on WebSocket requests. Connections without a token stay anonymous
+// while connections with a rejected token are refused.
+func WebsocketInitFunc(
+	jwtSigningKey string, sessionValidator SessionValidator, eventRecorder EventRecorder,
+) transport.WebsocketInitFunc {
+	return func(ctx context.Context, initPayload transport.InitPayload) (context.Context, error) {
+		token := initPayload.GetString("Authentication")
+		if token == "" {
+			return ctx, nil
+		}
+
+		userAuthentication, tokenRejection := authenticate(token, jwtSigningKey, sessionValidator)
+		if userAuthentication == nil {
+			recordRejectedAuthentication(eventRecorder, ctx, tokenRejection)
+			return nil, errUnauthorisedConnection
+		}
+		return context.WithValue(ctx, userContextKey, userAuthentication), nil
+	}
+}
+
+// authenticate returns the authentication of the token's active session, or the reason the token got rejected
+func authenticate(token string, jwtSigningKey string, sessionValidator SessionValidator) (*UserAuthentication, *rejection) {
+	userClaims := &UserClaims{}
+	decodedToken, err := decodeJwt(token, userClaims, jwtSigningKey)
+	if err != nil || !decodedToken.Valid {
+		if err != nil {
+			log.Printf("Error occurred while decoding JWT: %s", err)
+		}
+		log.Println("Invalid jwt, unauthorised request")
+		return nil, &rejection{reason: "invalid token"}
+	}
+
+	err = sessionValidator.TouchActiveSessionById(time.Now().UTC(), userClaims.SessionID, userClaims.UserID)
+	if err != nil {
+		log.Printf("Session %d is not active, unauthorised request: %s", userClaims.SessionID, err)
+		return nil, &rejection{userId: &userClaims.UserID, reason: "inactive session"}
+	}
+
+	return &UserAuthentication{UserId: userClaims.UserID, SessionId: userClaims.SessionID}, nil
+}
+
+func recordRejectedAuthentication(eventRecorder EventRecorder, ctx context.Context, rejection *rejection) {
+	clientInformation := GetClientInformationFromContext(ctx)
+	eventRecorder.Record(&model.AuditEvent{
+		UserId:    rejection.userId,
+		Type:      model.AuditEventAuthenticationRejected,
+		IpAddress: clientInformation.IpAddress,
+		UserAgent: clientInformation.UserAgent,
+		Details:   &rejection.reason,
+	})
+}
+
 func decodeJwt(token string, userClaims *UserClaims, jwtSigningKey string) (*jwt.Token, error) {
 	return jwt.ParseWithClaims(token, userClaims, func(token *jwt.Token) (interface{}, error) {
 		return []byte(jwtSigningKey), nil
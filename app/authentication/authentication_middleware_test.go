@@ -1,7 +1,11 @@
 package authentication
 
 import (
+	"context"
+	"errors"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
 	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
@@ -9,7 +13,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"strconv"
+	"sync"
 	"testing"
+	"time"
 )
 
 type AuthenticationMiddlewareTestSuite struct {
@@ -18,6 +24,8 @@ type AuthenticationMiddlewareTestSuite struct {
 	client            *http.Client
 	token             string
 	defaultSigningKey string
+	sessionValidator  *sessionValidatorMock
+	eventRecorder     *eventRecorderMock
 }
 
 func TestAuthenticationMiddlewareSuite(t *testing.T) {
@@ -28,7 +36,9 @@ func (suite *AuthenticationMiddlewareTestSuite) SetupSuite() {
 	suite.client = &http.Client{}
 	suite.defaultSigningKey = "signingKey"
 	suite.token = generateTestJwt(suite.defaultSigningKey, 1)
-	suite.server = setUpTestServerWithAuthenticationMiddleware(suite.defaultSigningKey)
+	suite.sessionValidator = &sessionValidatorMock{revokedSessions: map[uint64]bool{}, touchedSessions: map[uint64]time.Time{}}
+	suite.eventRecorder = &eventRecorderMock{}
+	suite.server = setUpTestServerWithAuthenticationMiddleware(suite.defaultSigningKey, suite.sessionValidator, suite.eventRecorder)
 }
 
 func (suite *AuthenticationMiddlewareTestSuite) TearDownSuite() {
@@ -44,7 +54,26 @@ func (suite *AuthenticationMiddlewareTestSuite) TestAuthenticationMiddleware() {
 
 	responseBody, _ := io.ReadAll(response.Body)
 
-	assert.Equal(suite.T(), string(responseBody), "UserId: 1")
+	assert.Equal(suite.T(), string(responseBody), "UserId: 1, SessionId: 1")
+	assert.False(suite.T(), suite.sessionValidator.lastSeenAt(1).IsZero(), "Session last seen time should be refreshed")
+}
+
+// AuthenticationMiddleware should not put user authentication data in request context for revoked sessions
+func (suite *AuthenticationMiddlewareTestSuite) TestAuthenticationMiddlewareWithRevokedSession() {
+	suite.sessionValidator.revoke(2)
+	request, _ := http.NewRequest("GET", suite.server.URL+"/", nil)
+	request.Header.Set("Authentication", generateTestJwtForSession(suite.defaultSigningKey, 1, 2))
+	response, _ := suite.client.Do(request)
+	defer response.Body.Close()
+
+	responseBody, _ := io.ReadAll(response.Body)
+
+	assert.Equal(suite.T(), response.StatusCode, http.StatusUnauthorized)
+	assert.Equal(suite.T(), string(responseBody), "No authentication header in client request")
+	event := suite.eventRecorder.lastEvent()
+	assert.Equal(suite.T(), event.Type, model.AuditEventAuthenticationRejected)
+	assert.Equal(suite.T(), *event.UserId, uint64(1))
+	assert.Equal(suite.T(), *event.Details, "inactive session")
 }
 
 // AuthenticationMiddleware should successfully process requests that don't have an authentication value in the header
@@ -70,15 +99,58 @@ func (suite *AuthenticationMiddlewareTestSuite) TestAuthenticationMiddlewareWith
 	responseBody, _ := io.ReadAll(response.Body)
 
 	assert.Equal(suite.T(), string(responseBody), "No authentication header in client request")
+	event := suite.eventRecorder.lastEvent()
+	assert.Equal(suite.T(), event.Type, model.AuditEventAuthenticationRejected)
+	assert.Nil(suite.T(), event.UserId)
+	assert.Equal(suite.T(), *event.Details, "invalid token")
 }
 
-func setUpTestServerWithAuthenticationMiddleware(jwtSigningKey string) *httptest.Server {
+// WebsocketInitFunc should put user authentication data from the init payload in the connection context
+func (suite *AuthenticationMiddlewareTestSuite) TestWebsocketInitFunc() {
+	initFunc := WebsocketInitFunc(suite.defaultSigningKey, suite.sessionValidator, suite.eventRecorder)
+
+	ctx, err := initFunc(context.Background(), transport.InitPayload{"Authentication": generateTestJwtForSession(suite.defaultSigningKey, 1, 3)})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), ctx.Value(userContextKey), &UserAuthentication{UserId: 1, SessionId: 3})
+	assert.False(suite.T(), suite.sessionValidator.lastSeenAt(3).IsZero(), "Session last seen time should be refreshed")
+}
+
+// WebsocketInitFunc should keep connections without a token anonymous
+func (suite *AuthenticationMiddlewareTestSuite) TestWebsocketInitFuncWithoutToken() {
+	initFunc := WebsocketInitFunc(suite.defaultSigningKey, suite.sessionValidator, suite.eventRecorder)
+
+	ctx, err := initFunc(context.Background(), transport.InitPayload{})
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), ctx.Value(userContextKey))
+}
+
+// WebsocketInitFunc should refuse connections with a rejected token
+func (suite *AuthenticationMiddlewareTestSuite) TestWebsocketInitFuncWithRejectedToken() {
+	suite.sessionValidator.revoke(4)
+	initFunc := WebsocketInitFunc(suite.defaultSigningKey, suite.sessionValidator, suite.eventRecorder)
+
+	ctx, err := initFunc(context.Background(), transport.InitPayload{"Authentication": generateTestJwtForSession(suite.defaultSigningKey, 1, 4)})
+	assert.Equal(suite.T(), err, errUnauthorisedConnection)
+	assert.Nil(suite.T(), ctx)
+	assert.Equal(suite.T(), *suite.eventRecorder.lastEvent().Details, "inactive session")
+
+	_, err = initFunc(context.Background(), transport.InitPayload{"Authentication": "invalid"})
+	assert.Equal(suite.T(), err, errUnauthorisedConnection)
+	assert.Equal(suite.T(), *suite.eventRecorder.lastEvent().Details, "invalid token")
+}
+
+func setUpTestServerWithAuthenticationMiddleware(
+	jwtSigningKey string, sessionValidator SessionValidator, eventRecorder EventRecorder,
+) *httptest.Server {
 	router := chi.NewRouter()
-	router.Use(AuthenticationMiddleware(jwtSigningKey))
+	router.Use(AuthenticationMiddleware(jwtSigningKey, sessionValidator, eventRecorder))
 
 	router.Get("/", func(writer http.ResponseWriter, request *http.Request) {
 		if userAuthenticationData, ok := request.Context().Value(userContextKey).(*UserAuthentication); ok {
-			writer.Write([]byte("UserId: " + strconv.FormatUint(userAuthenticationData.UserId, 10)))
+			writer.Write([]byte(
+				"UserId: " + strconv.FormatUint(userAuthenticationData.UserId, 10) +
+					", SessionId: " + strconv.FormatUint(userAuthenticationData.SessionId, 10),
+			))
 		} else {
 			writer.Write([]byte("No authentication header in client request"))
 		}
@@ -88,6 +160,10 @@ func setUpTestServerWithAuthenticationMiddleware(jwtSigningKey string) *httptest
 }
 
 func generateTestJwt(signingKey string, minutesToExpire int) string {
+	return generateTestJwtForSession(signingKey, minutesToExpire, 1)
+}
+
+func generateTestJwtForSession(signingKey string, minutesToExpire int, sessionId uint64) string {
 	authenticationService := NewJwtAuthenticationService(
 		&config.Authentication{
 			Issuer:               "issuer",
@@ -95,7 +171,55 @@ func generateTestJwt(signingKey string, minutesToExpire int) string {
 			JwtDurationInMinutes: minutesToExpire,
 		},
 	)
-	token, _ := authenticationService.GenerateJwt(uint64(1))
+	token, _ := authenticationService.GenerateJwt(uint64(1), sessionId)
 
 	return token
 }
+
+type sessionValidatorMock struct {
+	mutex           sync.Mutex
+	revokedSessions map[uint64]bool
+	touchedSessions map[uint64]time.Time
+}
+
+func (validator *sessionValidatorMock) TouchActiveSessionById(lastSeenAt time.Time, id uint64, userId uint64) error {
+	validator.mutex.Lock()
+	defer validator.mutex.Unlock()
+	if validator.revokedSessions[id] {
+		return errors.New("session revoked")
+	}
+	validator.touchedSessions[id] = lastSeenAt
+	return nil
+}
+
+func (validator *sessionValidatorMock) revoke(id uint64) {
+	validator.mutex.Lock()
+	defer validator.mutex.Unlock()
+	validator.revokedSessions[id] = true
+}
+
+func (validator *sessionValidatorMock) lastSeenAt(id uint64) time.Time {
+	validator.mutex.Lock()
+	defer validator.mutex.Unlock()
+	return validator.touchedSessions[id]
+}
+
+type eventRecorderMock struct {
+	mutex  sync.Mutex
+	events []*model.AuditEvent
+}
+
+func (recorder *eventRecorderMock) Record(event *model.AuditEvent) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	recorder.events = append(recorder.events, event)
+}
+
+func (recorder *eventRecorderMock) lastEvent() *model.AuditEvent {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+	if len(recorder.events) == 0 {
+		return &model.AuditEvent{}
+	}
+	return recorder.events[len(recorder.events)-1]
+}
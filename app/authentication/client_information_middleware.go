@@ -0,0 +1,39 @@
+package authentication
+
+import (
+	"context"
+	"net"
+	"net/http"
+)
+
+// ClientInformation describes the device a request was made from
+type ClientInformation struct {
+	UserAgent string
+	IpAddress string
+}
+
+var clientInformationContextKey = &contextKey{"client-information"}
+
+func ClientInformationMiddleware() func(http.Handler) http.Handler {
+	return func(nextHandler http.Handler) http.Handler {
+		return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+			ipAddress, _, err := net.SplitHostPort(request.RemoteAddr)
+			if err != nil {
+				ipAddress = request.RemoteAddr
+			}
+
+			ctx := context.WithValue(
+				request.Context(), clientInformationContextKey, &ClientInformation{UserAgent: request.UserAgent(), IpAddress: ipAddress},
+			)
+			nextHandler.ServeHTTP(writer, request.WithContext(ctx))
+		})
+	}
+}
+
+// GetClientInformationFromContext returns empty client information if the request didn't pass through the middleware
+func GetClientInformationFromContext(context context.Context) ClientInformation {
+	if clientInformation, ok := context.Value(clientInformationContextKey).(*ClientInformation); ok {
+		return *clientInformation
+	}
+	return ClientInformation{}
+}
@@ -0,0 +1,29 @@
+package authentication
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// ClientInformationMiddleware should put the client's user agent and IP address in request context
+func TestClientInformationMiddleware(t *testing.T) {
+	var clientInformation ClientInformation
+	handler := ClientInformationMiddleware()(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		clientInformation = GetClientInformationFromContext(request.Context())
+	}))
+
+	request := httptest.NewRequest("GET", "/", nil)
+	request.RemoteAddr = "10.0.0.1:54321"
+	request.Header.Set("User-Agent", "Firefox")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	assert.Equal(t, clientInformation, ClientInformation{UserAgent: "Firefox", IpAddress: "10.0.0.1"})
+}
+
+// GetClientInformationFromContext should return empty client information if there is none in context
+func TestGetClientInformationFromContextWithoutClientInformation(t *testing.T) {
+	assert.Equal(t, GetClientInformationFromContext(context.Background()), ClientInformation{})
+}
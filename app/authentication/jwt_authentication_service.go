@@ -12,31 +12,36 @@ import (
 var signingCall = func(token *jwt.Token, signingKey []byte) (string, error) { return token.SignedString(signingKey) }
 
 type JwtAuthenticator interface {
-	GenerateJwt(userID uint64) (string, error)
+	GenerateJwt(userID uint64, sessionID uint64) (string, error)
 	GetAuthenticatedUserDataFromContext(context context.Context) *UserAuthentication
+	NewDeviceApprovalRequired() bool
 }
 
 type jwtAuthenticationService struct {
-	issuer               string
-	jwtSigningKey        []byte
-	jwtDurationInMinutes int
+	issuer                    string
+	jwtSigningKey             []byte
+	jwtDurationInMinutes      int
+	newDeviceApprovalRequired bool
 }
 
 func NewJwtAuthenticationService(authenticationConfig *config.Authentication) *jwtAuthenticationService {
 	return &jwtAuthenticationService{
-		issuer:               authenticationConfig.Issuer,
-		jwtSigningKey:        []byte(authenticationConfig.JwtSigningKey),
-		jwtDurationInMinutes: authenticationConfig.JwtDurationInMinutes,
+		issuer:                    authenticationConfig.Issuer,
+		jwtSigningKey:             []byte(authenticationConfig.JwtSigningKey),
+		jwtDurationInMinutes:      authenticationConfig.JwtDurationInMinutes,
+		newDeviceApprovalRequired: authenticationConfig.RequireNewDeviceApproval,
 	}
 }
 
-func (service *jwtAuthenticationService) GenerateJwt(userID uint64) (string, error) {
+// GenerateJwt binds the token to a session, so the token stops working once the session is revoked
+func (service *jwtAuthenticationService) GenerateJwt(userID uint64, sessionID uint64) (string, error) {
 	userClaims := UserClaims{
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: time.Now().Add(time.Minute * time.Duration(service.jwtDurationInMinutes)).Unix(),
 			Issuer:    service.issuer,
 		},
-		UserID: userID,
+		UserID:    userID,
+		SessionID: sessionID,
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, userClaims)
@@ -56,3 +61,8 @@ func (service *jwtAuthenticationService) GetAuthenticatedUserDataFromContext(con
 	log.Println("User authentication data not found in request context")
 	return nil
 }
+
+// NewDeviceApprovalRequired reports whether sign ins from unknown devices need an approval from an already signed in session
+func (service *jwtAuthenticationService) NewDeviceApprovalRequired() bool {
+	return service.newDeviceApprovalRequired
+}
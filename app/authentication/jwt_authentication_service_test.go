@@ -12,7 +12,7 @@ import (
 // GenerateJwt should successfully generate a json web token
 func TestGenerateJwt(t *testing.T) {
 	authenticationService := setupAuthenticationService()
-	token, err := authenticationService.GenerateJwt(uint64(1))
+	token, err := authenticationService.GenerateJwt(uint64(1), uint64(1))
 	assert.Nil(t, err, "Should not return an error")
 	assert.NotNil(t, token, "Jwt token should be generated")
 }
@@ -21,11 +21,31 @@ func TestGenerateJwt(t *testing.T) {
 func TestGenerateJwtWithSigningError(t *testing.T) {
 	authenticationService := setupAuthenticationService()
 	signingCall = func(token *jwt.Token, signingKey []byte) (string, error) { return "", errors.New("mocked error") }
-	token, err := authenticationService.GenerateJwt(uint64(1))
+	defer func() {
+		signingCall = func(token *jwt.Token, signingKey []byte) (string, error) { return token.SignedString(signingKey) }
+	}()
+	token, err := authenticationService.GenerateJwt(uint64(1), uint64(1))
 	assert.Equal(t, err, errors.New("mocked error"), "Should return signing error when signing fails")
 	assert.Equal(t, token, "", "Jwt token should not be generated")
 }
 
+// GenerateJwt should bind the json web token to the given session
+func TestGenerateJwtWithSession(t *testing.T) {
+	authenticationService := setupAuthenticationService()
+	token, _ := authenticationService.GenerateJwt(uint64(1), uint64(2))
+
+	userClaims := &UserClaims{}
+	_, err := decodeJwt(token, userClaims, "signingKey")
+	assert.Nil(t, err, "Should decode the generated token without errors")
+	assert.Equal(t, userClaims.SessionID, uint64(2), "Token should carry the session id")
+}
+
+// NewDeviceApprovalRequired should return the configured value
+func TestNewDeviceApprovalRequired(t *testing.T) {
+	authenticationService := NewJwtAuthenticationService(&config.Authentication{RequireNewDeviceApproval: true})
+	assert.True(t, authenticationService.NewDeviceApprovalRequired())
+}
+
 // GetAuthenticatedUserDataFromContext should successfully get user authentication details from context
 func TestGetAuthenticatedUserDataFromContext(t *testing.T) {
 	authenticationService := setupAuthenticationService()
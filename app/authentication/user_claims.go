@@ -3,6 +3,7 @@ package authentication
 import "github.com/dgrijalva/jwt-go"
 
 type UserClaims struct {
-	UserID uint64 `json:"user_id"`
+	UserID    uint64 `json:"user_id"`
+	SessionID uint64 `json:"session_id"`
 	jwt.StandardClaims
 }
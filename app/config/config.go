@@ -6,15 +6,24 @@ import (
 	"os"
 )
 
+// Config is the configuration of the application, fields tagged as secret are redacted when it gets printed
 type Config struct {
-	*Profile        `yaml:"profile"`
-	*Server         `yaml:"server"`
-	*Datasource     `yaml:"datasource"`
-	*Authentication `yaml:"authentication"`
+	*Profile         `yaml:"profile"`
+	*Server          `yaml:"server"`
+	*Datasource      `yaml:"datasource"`
+	*Authentication  `yaml:"authentication"`
+	*Webauthn        `yaml:"webauthn"`
+	*Mail            `yaml:"mail"`
+	*EmergencyAccess `yaml:"emergency-access"`
+	*Send            `yaml:"send"`
+	*Webhook         `yaml:"webhook"`
+	*EventBus        `yaml:"event-bus"`
+	*Attachment      `yaml:"attachment"`
 }
 
 type Profile struct {
 	Production bool `yaml:"production"`
+	Demo       bool `yaml:"demo"`
 }
 
 type Server struct {
@@ -23,24 +32,94 @@ type Server struct {
 }
 
 type Datasource struct {
-	User                  string `yaml:"user"`
-	Password              string `yaml:"password"`
-	Host                  string `yaml:"host"`
-	Database              string `yaml:"database"`
-	MaxOpenConnections    int    `yaml:"max-open-connections"`
-	MaxConnectionLifetime int    `yaml:"connection-lifetime"`
+	Driver                        string   `yaml:"driver"`
+	User                          string   `yaml:"user"`
+	Password                      string   `yaml:"password" secret:"true"`
+	Host                          string   `yaml:"host"`
+	Database                      string   `yaml:"database"`
+	MaxOpenConnections            int      `yaml:"max-open-connections"`
+	MaxConnectionLifetime         int      `yaml:"connection-lifetime"`
+	MigrateOnStartup              bool     `yaml:"migrate-on-startup"`
+	ConnectionAttempts            int      `yaml:"connection-attempts"`
+	ConnectionBackoffInSeconds    int      `yaml:"connection-backoff-in-seconds"`
+	MaxConnectionBackoffInSeconds int      `yaml:"max-connection-backoff-in-seconds"`
+	HealthCheckIntervalInSeconds  int      `yaml:"health-check-interval-in-seconds"`
+	HealthCheckFailureThreshold   int      `yaml:"health-check-failure-threshold"`
+	Replicas                      []string `yaml:"replicas"`
+	MaxReplicaLagInSeconds        int      `yaml:"max-replica-lag-in-seconds"`
 }
 
 type Authentication struct {
-	Issuer               string `yaml:"issuer"`
-	JwtSigningKey        string `yaml:"jwt-signing-key"`
-	JwtDurationInMinutes int    `yaml:"jwt-duration-in-minutes"`
+	Issuer                   string `yaml:"issuer"`
+	JwtSigningKey            string `yaml:"jwt-signing-key" secret:"true"`
+	JwtDurationInMinutes     int    `yaml:"jwt-duration-in-minutes"`
+	RequireNewDeviceApproval bool   `yaml:"require-new-device-approval"`
 }
 
+type Webauthn struct {
+	RelyingPartyId            string `yaml:"relying-party-id"`
+	RelyingPartyName          string `yaml:"relying-party-name"`
+	Origin                    string `yaml:"origin"`
+	ChallengeTimeoutInSeconds int    `yaml:"challenge-timeout-in-seconds"`
+}
+
+type Mail struct {
+	Sink                        string `yaml:"sink"`
+	Host                        string `yaml:"host"`
+	Port                        string `yaml:"port"`
+	Username                    string `yaml:"username"`
+	Password                    string `yaml:"password" secret:"true"`
+	From                        string `yaml:"from"`
+	FileSinkDirectory           string `yaml:"file-sink-directory"`
+	MaxDeliveryAttempts         int    `yaml:"max-delivery-attempts"`
+	RetryIntervalInSeconds      int    `yaml:"retry-interval-in-seconds"`
+	VerificationUrl             string `yaml:"verification-url"`
+	VerificationTokenTtlInHours int    `yaml:"verification-token-ttl-in-hours"`
+	RequireVerifiedEmail        bool   `yaml:"require-verified-email"`
+}
+
+type EmergencyAccess struct {
+	SchedulerIntervalInSeconds int `yaml:"scheduler-interval-in-seconds"`
+}
+
+type Send struct {
+	Url                    string `yaml:"url"`
+	PurgeIntervalInSeconds int    `yaml:"purge-interval-in-seconds"`
+}
+
+type Webhook struct {
+	DeliveryIntervalInSeconds int `yaml:"delivery-interval-in-seconds"`
+	MaxDeliveryAttempts       int `yaml:"max-delivery-attempts"`
+	InitialBackoffInSeconds   int `yaml:"initial-backoff-in-seconds"`
+	MaxBackoffInSeconds       int `yaml:"max-backoff-in-seconds"`
+	TimeoutInSeconds          int `yaml:"timeout-in-seconds"`
+}
+
+type EventBus struct {
+	Adapter string `yaml:"adapter"`
+}
+
+type Attachment struct {
+	BlobStore         string `yaml:"blob-store"`
+	Directory         string `yaml:"directory"`
+	MaxSizeInBytes    int64  `yaml:"max-size-in-bytes"`
+	QuotaInBytes      int64  `yaml:"quota-in-bytes"`
+	S3Endpoint        string `yaml:"s3-endpoint"`
+	S3Region          string `yaml:"s3-region"`
+	S3Bucket          string `yaml:"s3-bucket"`
+	S3AccessKeyId     string `yaml:"s3-access-key-id"`
+	S3SecretAccessKey string `yaml:"s3-secret-access-key" secret:"true"`
+}
+
+// LoadConfiguration loads the configuration file on top of the defaults
 func LoadConfiguration(configPath string) *Config {
-	log.Printf("Loading configuration from %s", configPath)
-	config := &Config{}
+	config := Default()
+	decodeConfiguration(config, configPath)
+	return config
+}
 
+func decodeConfiguration(config *Config, configPath string) {
+	log.Printf("Loading configuration from %s", configPath)
 	file, err := os.Open(configPath)
 	if err != nil {
 		log.Panicf("Error occured while trying to read configuration file: %s", err)
@@ -51,6 +130,4 @@ func LoadConfiguration(configPath string) *Config {
 	if err = decoder.Decode(config); err != nil {
 		log.Panicf("Error occured while trying to decode configuration values: %s", err)
 	}
-
-	return config
 }
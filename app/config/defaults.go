@@ -0,0 +1,74 @@
+package config
+
+// Default returns the configuration every other layer overrides, it matches the development configuration file
+// except for the credentials, which have no defaults
+func Default() *Config {
+	return &Config{
+		Profile: &Profile{},
+		Server: &Server{
+			Hostname: "localhost",
+			Port:     "8080",
+		},
+		Datasource: &Datasource{
+			Driver:                        "postgres",
+			Host:                          "localhost:5432",
+			Database:                      "gokeeper",
+			MaxOpenConnections:            12,
+			MaxConnectionLifetime:         60,
+			ConnectionAttempts:            10,
+			ConnectionBackoffInSeconds:    1,
+			MaxConnectionBackoffInSeconds: 30,
+			HealthCheckIntervalInSeconds:  5,
+			HealthCheckFailureThreshold:   3,
+			Replicas:                      []string{},
+			MaxReplicaLagInSeconds:        5,
+		},
+		Authentication: &Authentication{
+			Issuer:               "gokeeper",
+			JwtDurationInMinutes: 30,
+		},
+		Webauthn: &Webauthn{
+			RelyingPartyId:            "localhost",
+			RelyingPartyName:          "GoKeeper",
+			Origin:                    "http://localhost:3000",
+			ChallengeTimeoutInSeconds: 120,
+		},
+		Mail: &Mail{
+			Sink:                        "log",
+			Host:                        "localhost",
+			Port:                        "25",
+			From:                        "GoKeeper <no-reply@gokeeper.local>",
+			FileSinkDirectory:           "./build/mail",
+			MaxDeliveryAttempts:         5,
+			RetryIntervalInSeconds:      2,
+			VerificationUrl:             "http://localhost:3000/verify-email?token=",
+			VerificationTokenTtlInHours: 24,
+		},
+		EmergencyAccess: &EmergencyAccess{
+			SchedulerIntervalInSeconds: 60,
+		},
+		Send: &Send{
+			Url:                    "http://localhost:3000/send/",
+			PurgeIntervalInSeconds: 300,
+		},
+		Webhook: &Webhook{
+			DeliveryIntervalInSeconds: 5,
+			MaxDeliveryAttempts:       8,
+			InitialBackoffInSeconds:   30,
+			MaxBackoffInSeconds:       3600,
+			TimeoutInSeconds:          10,
+		},
+		EventBus: &EventBus{
+			Adapter: "local",
+		},
+		Attachment: &Attachment{
+			BlobStore:      "local",
+			Directory:      "./build/attachments",
+			MaxSizeInBytes: 10485760,
+			QuotaInBytes:   104857600,
+			S3Endpoint:     "http://localhost:9000",
+			S3Region:       "us-east-1",
+			S3Bucket:       "gokeeper-attachments",
+		},
+	}
+}
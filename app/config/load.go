@@ -0,0 +1,193 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultConfigPath     = "./config.yml"
+	environmentPrefix     = "GOKEEPER_"
+	environmentFileSuffix = "_FILE"
+)
+
+// ErrMissingCredential is returned for credentials without a default that are left empty by every layer
+var ErrMissingCredential = errors.New("missing credential")
+
+// setting is a field of the configuration, addressed by its section and key like in the configuration file
+type setting struct {
+	path   string
+	value  reflect.Value
+	secret bool
+}
+
+// Load builds the configuration in layers, each overriding the ones before: the defaults, the configuration file
+// at the path of the config flag, the GOKEEPER_* environment variables and the command line flags.
+// Every field has an environment variable named after its path, like GOKEEPER_DATASOURCE_PASSWORD for
+// datasource.password, whose _FILE variant reads the value from the named file as mounted by Docker and Kubernetes
+// secrets. Every field but the secrets also has a flag named after its path, like -datasource.host, secrets are left
+// out since the command line is visible to every process on the host. Lists are comma separated.
+// A configuration file at the default path is optional. The arguments left after the flags are returned,
+// errors parsing the flags are written to the output together with the usage, and so are missing credentials.
+func Load(arguments []string, output io.Writer) (*Config, []string, error) {
+	config := Default()
+	configSettings := settings(config)
+
+	flagSet := flag.NewFlagSet("gokeeper", flag.ContinueOnError)
+	flagSet.SetOutput(output)
+	configPath := flagSet.String("config", defaultConfigPath, "path of the YAML configuration file")
+	flagValues := map[string]string{}
+	for _, configSetting := range configSettings {
+		if configSetting.secret {
+			continue
+		}
+		path := configSetting.path
+		usage := fmt.Sprintf("overrides %s, like the %s environment variable", path, configSetting.environmentVariable())
+		flagSet.Func(path, usage, func(value string) error {
+			flagValues[path] = value
+			return nil
+		})
+	}
+	if err := flagSet.Parse(arguments); err != nil {
+		return nil, nil, err
+	}
+
+	isConfigPathSet := false
+	flagSet.Visit(func(setFlag *flag.Flag) { isConfigPathSet = isConfigPathSet || setFlag.Name == "config" })
+	if _, err := os.Stat(*configPath); err == nil || isConfigPathSet {
+		decodeConfiguration(config, *configPath)
+	} else {
+		log.Printf("No configuration file at %s, configuring from the defaults, environment and flags", *configPath)
+	}
+
+	for _, configSetting := range configSettings {
+		value, isSet, err := environmentValue(configSetting.environmentVariable())
+		if err != nil {
+			log.Panicf("Error occured while trying to read configuration from the environment: %s", err)
+		}
+		if isSet {
+			configSetting.set(value, configSetting.environmentVariable())
+		}
+	}
+	for _, configSetting := range configSettings {
+		if value, isSet := flagValues[configSetting.path]; isSet {
+			configSetting.set(value, "-"+configSetting.path)
+		}
+	}
+
+	if err := checkCredentials(config, configSettings); err != nil {
+		fmt.Fprintln(output, err)
+		return nil, nil, err
+	}
+	return config, flagSet.Args(), nil
+}
+
+// checkCredentials makes sure the JWT signing key is set, tokens signed with an empty key could be forged by anyone,
+// and so is the password of a database server. Databases aren't connected to by the demo profile.
+func checkCredentials(config *Config, configSettings []setting) error {
+	requiredPaths := []string{"authentication.jwt-signing-key"}
+	if !config.Profile.Demo && config.Datasource.Driver != "sqlite" {
+		requiredPaths = append(requiredPaths, "datasource.password")
+	}
+
+	for _, configSetting := range configSettings {
+		for _, path := range requiredPaths {
+			if configSetting.path == path && configSetting.value.String() == "" {
+				return fmt.Errorf(
+					"%w: set %s with %s or %s", ErrMissingCredential,
+					path, configSetting.environmentVariable(), configSetting.environmentVariable()+environmentFileSuffix,
+				)
+			}
+		}
+	}
+	return nil
+}
+
+// settings lists every field of the configuration, sections missing from it get created on the way
+func settings(config *Config) []setting {
+	configSettings := []setting{}
+	configValue := reflect.ValueOf(config).Elem()
+	for sectionIndex := 0; sectionIndex < configValue.NumField(); sectionIndex++ {
+		section := configValue.Field(sectionIndex)
+		if section.IsNil() {
+			section.Set(reflect.New(section.Type().Elem()))
+		}
+		sectionName := yamlName(configValue.Type().Field(sectionIndex))
+
+		sectionValue := section.Elem()
+		for fieldIndex := 0; fieldIndex < sectionValue.NumField(); fieldIndex++ {
+			field := sectionValue.Type().Field(fieldIndex)
+			configSettings = append(configSettings, setting{
+				path:   sectionName + "." + yamlName(field),
+				value:  sectionValue.Field(fieldIndex),
+				secret: field.Tag.Get("secret") == "true",
+			})
+		}
+	}
+	return configSettings
+}
+
+func yamlName(field reflect.StructField) string {
+	return strings.Split(field.Tag.Get("yaml"), ",")[0]
+}
+
+func (configSetting setting) environmentVariable() string {
+	return environmentPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(configSetting.path))
+}
+
+// set parses the value into the field, source names where the value comes from for the panic on invalid values
+func (configSetting setting) set(value string, source string) {
+	switch configSetting.value.Kind() {
+	case reflect.String:
+		configSetting.value.SetString(value)
+		return
+	case reflect.Slice:
+		items := []string{}
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		configSetting.value.Set(reflect.ValueOf(items))
+		return
+	case reflect.Bool:
+		parsedValue, err := strconv.ParseBool(value)
+		if err == nil {
+			configSetting.value.SetBool(parsedValue)
+			return
+		}
+	case reflect.Int, reflect.Int64:
+		parsedValue, err := strconv.ParseInt(value, 10, 64)
+		if err == nil {
+			configSetting.value.SetInt(parsedValue)
+			return
+		}
+	}
+	log.Panicf("Invalid value of %s for %s: %q", source, configSetting.path, value)
+}
+
+// environmentValue returns the value of the environment variable, or the contents of the file named by its _FILE
+// variant without the trailing line break
+func environmentValue(name string) (string, bool, error) {
+	value, isSet := os.LookupEnv(name)
+	filePath, isFileSet := os.LookupEnv(name + environmentFileSuffix)
+	if !isFileSet {
+		return value, isSet, nil
+	}
+	if isSet {
+		return "", false, fmt.Errorf("both %s and %s are set", name, name+environmentFileSuffix)
+	}
+
+	contents, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", false, fmt.Errorf("could not read %s: %w", name+environmentFileSuffix, err)
+	}
+	return strings.TrimRight(string(contents), "\r\n"), true, nil
+}
@@ -0,0 +1,147 @@
+package config
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// Load should fall back to the defaults without a configuration file at the default path
+func TestLoadWithoutConfigurationFile(t *testing.T) {
+	setCredentials(t)
+
+	config, arguments, err := Load([]string{}, ioutil.Discard)
+	assert.Nil(t, err)
+	expectedConfig := Default()
+	expectedConfig.Datasource.Password = "datasource-secret"
+	expectedConfig.Authentication.JwtSigningKey = "signing-key-secret"
+	assert.Equal(t, config, expectedConfig)
+	assert.Empty(t, arguments)
+}
+
+// Load should override the defaults with the configuration file, the environment and the flags in turn
+func TestLoad(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "datasource-password")
+	assert.Nil(t, ioutil.WriteFile(secretFile, []byte("file-secret\n"), 0600))
+	setEnvironment(t, map[string]string{
+		"GOKEEPER_SERVER_PORT":                    "9090",
+		"GOKEEPER_SERVER_HOSTNAME":                "0.0.0.0",
+		"GOKEEPER_DATASOURCE_PASSWORD_FILE":       secretFile,
+		"GOKEEPER_PROFILE_PRODUCTION":             "true",
+		"GOKEEPER_AUTHENTICATION_JWT_SIGNING_KEY": "signing-key-secret",
+	})
+
+	config, arguments, err := Load(
+		[]string{"-config", "../../config.yml", "-server.port=9191", "-datasource.replicas", "replica-1:5432, replica-2:5432", "migrate", "up"},
+		ioutil.Discard,
+	)
+	assert.Nil(t, err)
+	assert.Equal(t, arguments, []string{"migrate", "up"}, "Should return the arguments left after the flags")
+	assert.Equal(t, config.Datasource.User, "gokeeperapp", "Should load the configuration file")
+	assert.Equal(t, config.Server.Hostname, "0.0.0.0", "Environment variables should override the configuration file")
+	assert.Equal(t, config.Datasource.Password, "file-secret", "Should read secrets from the files of the environment variables")
+	assert.True(t, config.Profile.Production)
+	assert.Equal(t, config.Server.Port, "9191", "Flags should override environment variables")
+	assert.Equal(t, config.Datasource.Replicas, []string{"replica-1:5432", "replica-2:5432"})
+}
+
+// Load should keep the defaults of everything the configuration file leaves out
+func TestLoadWithPartialConfigurationFile(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.yml")
+	assert.Nil(t, ioutil.WriteFile(configPath, []byte("server:\n  port: 1234\n"), 0600))
+	setCredentials(t)
+
+	config, _, err := Load([]string{"-config", configPath}, ioutil.Discard)
+	assert.Nil(t, err)
+	assert.Equal(t, config.Server.Port, "1234")
+	assert.Equal(t, config.Server.Hostname, Default().Server.Hostname)
+	assert.Equal(t, config.Datasource.Host, Default().Datasource.Host)
+	assert.Equal(t, config.Datasource.MaxOpenConnections, Default().Datasource.MaxOpenConnections)
+}
+
+// Load should return an error while a required credential is missing, the datasource password is only required
+// for database servers
+func TestLoadWithoutCredentials(t *testing.T) {
+	output := &strings.Builder{}
+	_, _, err := Load([]string{}, output)
+	assert.True(t, errors.Is(err, ErrMissingCredential), "Should require the datasource password")
+	assert.Contains(t, output.String(), "GOKEEPER_DATASOURCE_PASSWORD_FILE", "Should tell how to set the missing credential")
+
+	setEnvironment(t, map[string]string{"GOKEEPER_DATASOURCE_PASSWORD": "datasource-secret"})
+	_, _, err = Load([]string{}, ioutil.Discard)
+	assert.EqualError(
+		t, err, "missing credential: set authentication.jwt-signing-key with GOKEEPER_AUTHENTICATION_JWT_SIGNING_KEY "+
+			"or GOKEEPER_AUTHENTICATION_JWT_SIGNING_KEY_FILE",
+		"Should require the JWT signing key",
+	)
+
+	os.Unsetenv("GOKEEPER_DATASOURCE_PASSWORD")
+	setEnvironment(t, map[string]string{"GOKEEPER_AUTHENTICATION_JWT_SIGNING_KEY": "signing-key-secret"})
+	_, _, err = Load([]string{"-datasource.driver", "sqlite"}, ioutil.Discard)
+	assert.Nil(t, err, "A sqlite database shouldn't need a password")
+	_, _, err = Load([]string{"-profile.demo=true"}, ioutil.Discard)
+	assert.Nil(t, err, "The demo profile shouldn't need a database password")
+}
+
+// Load should have no flags for secrets, the command line is visible to other processes
+func TestLoadWithSecretFlag(t *testing.T) {
+	setCredentials(t)
+
+	for _, secretFlag := range []string{"-authentication.jwt-signing-key=key", "-datasource.password=password"} {
+		_, _, err := Load([]string{secretFlag}, ioutil.Discard)
+		assert.EqualError(t, err, "flag provided but not defined: "+strings.Split(secretFlag, "=")[0])
+	}
+}
+
+// Load should panic if the given configuration file can't be read
+func TestLoadWithMissingConfigurationFile(t *testing.T) {
+	assert.PanicsWithValue(
+		t, "Error occured while trying to read configuration file: open nonexistent-configuration-file: no such file or directory",
+		func() { Load([]string{"-config", "nonexistent-configuration-file"}, ioutil.Discard) },
+	)
+}
+
+// Load should panic if a value of the environment doesn't fit its field, or a secret is set both directly and as a file
+func TestLoadWithInvalidEnvironment(t *testing.T) {
+	setEnvironment(t, map[string]string{"GOKEEPER_DATASOURCE_MAX_OPEN_CONNECTIONS": "many"})
+	assert.PanicsWithValue(
+		t, `Invalid value of GOKEEPER_DATASOURCE_MAX_OPEN_CONNECTIONS for datasource.max-open-connections: "many"`,
+		func() { Load([]string{}, ioutil.Discard) },
+	)
+
+	setEnvironment(t, map[string]string{
+		"GOKEEPER_DATASOURCE_MAX_OPEN_CONNECTIONS":     "1",
+		"GOKEEPER_AUTHENTICATION_JWT_SIGNING_KEY":      "secret",
+		"GOKEEPER_AUTHENTICATION_JWT_SIGNING_KEY_FILE": "secret-file",
+	})
+	assert.PanicsWithValue(
+		t, "Error occured while trying to read configuration from the environment: "+
+			"both GOKEEPER_AUTHENTICATION_JWT_SIGNING_KEY and GOKEEPER_AUTHENTICATION_JWT_SIGNING_KEY_FILE are set",
+		func() { Load([]string{}, ioutil.Discard) },
+	)
+}
+
+// Load should return an error for unknown flags
+func TestLoadWithUnknownFlag(t *testing.T) {
+	_, _, err := Load([]string{"-datasource.unknown=true"}, ioutil.Discard)
+	assert.EqualError(t, err, "flag provided but not defined: -datasource.unknown")
+}
+
+func setCredentials(t *testing.T) {
+	setEnvironment(t, map[string]string{
+		"GOKEEPER_DATASOURCE_PASSWORD":            "datasource-secret",
+		"GOKEEPER_AUTHENTICATION_JWT_SIGNING_KEY": "signing-key-secret",
+	})
+}
+
+func setEnvironment(t *testing.T, environment map[string]string) {
+	for name, value := range environment {
+		os.Setenv(name, value)
+		name := name
+		t.Cleanup(func() { os.Unsetenv(name) })
+	}
+}
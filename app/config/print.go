@@ -0,0 +1,34 @@
+package config
+
+import (
+	"gopkg.in/yaml.v2"
+	"io"
+	"reflect"
+)
+
+const redactedSecret = "[redacted]"
+
+// Print writes the configuration as YAML, with the values of its secrets redacted
+func Print(config *Config, output io.Writer) error {
+	redactedConfig := &Config{}
+	configValue, redactedConfigValue := reflect.ValueOf(config).Elem(), reflect.ValueOf(redactedConfig).Elem()
+	for sectionIndex := 0; sectionIndex < configValue.NumField(); sectionIndex++ {
+		if section := configValue.Field(sectionIndex); !section.IsNil() {
+			redactedSection := reflect.New(section.Type().Elem())
+			redactedSection.Elem().Set(section.Elem())
+			redactedConfigValue.Field(sectionIndex).Set(redactedSection)
+		}
+	}
+	for _, configSetting := range settings(redactedConfig) {
+		if configSetting.secret && configSetting.value.String() != "" {
+			configSetting.value.SetString(redactedSecret)
+		}
+	}
+
+	encodedConfig, err := yaml.Marshal(redactedConfig)
+	if err != nil {
+		return err
+	}
+	_, err = output.Write(encodedConfig)
+	return err
+}
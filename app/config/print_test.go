@@ -0,0 +1,23 @@
+package config
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+// Print should write the configuration as YAML with its secrets redacted
+func TestPrint(t *testing.T) {
+	config := LoadConfiguration("../../config.yml")
+	config.Datasource.Password = "datasource-secret"
+	config.Authentication.JwtSigningKey = "signing-key-secret"
+	output := &strings.Builder{}
+
+	assert.Nil(t, Print(config, output))
+	assert.Contains(t, output.String(), "  jwt-signing-key: '[redacted]'\n")
+	assert.Contains(t, output.String(), "  user: gokeeperapp\n  password: '[redacted]'\n")
+	assert.Contains(t, output.String(), "  s3-secret-access-key: \"\"\n", "Secrets that aren't set should be left empty")
+	assert.NotContains(t, output.String(), config.Authentication.JwtSigningKey)
+	assert.NotContains(t, output.String(), config.Datasource.Password)
+	assert.Equal(t, config.Datasource.Password, "datasource-secret", "The configuration itself should be left as it is")
+}
@@ -1,53 +1,94 @@
 package database
 
 import (
+	"fmt"
 	"github.com/KristijanFaust/gokeeper/app/config"
 	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/mysql"
 	"github.com/upper/db/v4/adapter/postgresql"
+	"github.com/upper/db/v4/adapter/sqlite"
 	"log"
 	"reflect"
 	"time"
 )
 
+const (
+	DriverPostgres = "postgres"
+	DriverSqlite   = "sqlite"
+	DriverMysql    = "mysql"
+)
+
+// Foreign keys are disabled in SQLite by default, and transactions are started as immediate so they take the write
+// lock up front, which makes concurrent writers wait for each other instead of failing on lock upgrades
+var sqliteConnectionOptions = map[string]string{
+	"_foreign_keys": "1",
+	"_journal_mode": "WAL",
+	"_txlock":       "immediate",
+	"_busy_timeout": "10000",
+}
+
+// MySQL connections quote identifiers the ANSI way like the other backends and run in UTC, updates report the rows
+// they matched instead of the ones they changed, so updates writing the current values still find their rows
+var mysqlConnectionOptions = map[string]string{
+	"charset":         "utf8mb4",
+	"parseTime":       "true",
+	"clientFoundRows": "true",
+	"sql_mode":        "'ANSI_QUOTES,STRICT_ALL_TABLES,NO_ZERO_DATE,NO_ZERO_IN_DATE,ERROR_FOR_DIVISION_BY_ZERO'",
+	"time_zone":       "'+00:00'",
+}
+
 // Variables meant for mocking
 var (
 	pingDatabase    = db.Session.Ping
 	closeConnection = db.Session.Close
+	sleep           = time.Sleep
 )
 
+// InitializeDatabaseConnection connects to the database, retrying failed attempts with a backoff doubling from the
+// configured one up to its maximum, and panics once the configured attempts have run out
 func InitializeDatabaseConnection(datasourceConfig *config.Datasource) *db.Session {
 	if reflect.ValueOf(datasourceConfig).IsZero() {
 		log.Panic("Datasource configuration not loaded, cannot connect to database")
 	}
 
-	settings := &postgresql.ConnectionURL{
-		Database: datasourceConfig.Database,
-		Host:     datasourceConfig.Host,
-		User:     datasourceConfig.User,
-		Password: datasourceConfig.Password,
-	}
+	settings, open := connectionSettings(datasourceConfig)
 
-	session, err := postgresql.Open(settings)
-	for {
-		if err != nil {
-			log.Printf("Could not connect to database: %s - retrying connection any second", err)
-			session, err = postgresql.Open(settings)
-			time.Sleep(1 * time.Second)
-		} else {
-			break
+	backoff := time.Duration(datasourceConfig.ConnectionBackoffInSeconds) * time.Second
+	maxBackoff := time.Duration(datasourceConfig.MaxConnectionBackoffInSeconds) * time.Second
+	for attempt := 1; ; attempt++ {
+		session, err := connect(settings, open, datasourceConfig)
+		if err == nil {
+			log.Printf("Successfully connected to database: %s at %s", session.Name(), location(settings))
+			return &session
+		}
+		if attempt >= datasourceConfig.ConnectionAttempts {
+			log.Panicf("Could not connect to database after %d attempt/s: %s", attempt, err)
+		}
+
+		log.Printf("Could not connect to database: %s - retrying in %s", err, backoff)
+		sleep(backoff)
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
 		}
 	}
+}
+
+// connect opens a session and makes sure the database answers, a session that doesn't is closed again
+func connect(settings db.ConnectionURL, open func(db.ConnectionURL) (db.Session, error), datasourceConfig *config.Datasource) (db.Session, error) {
+	session, err := open(settings)
+	if err != nil {
+		return nil, err
+	}
 
 	session.SetMaxOpenConns(datasourceConfig.MaxOpenConnections)
 	session.SetMaxIdleConns(datasourceConfig.MaxOpenConnections / 3)
 	session.SetConnMaxLifetime(time.Duration(datasourceConfig.MaxConnectionLifetime) * time.Minute)
 
-	if err := pingDatabase(session); err != nil {
-		log.Panicf("Could not ping database: %s", err)
+	if err = pingDatabase(session); err != nil {
+		closeConnection(session)
+		return nil, fmt.Errorf("could not ping database: %w", err)
 	}
-
-	log.Printf("Successfully connected to database: %s at %s", session.Name(), settings.Host)
-	return &session
+	return session, nil
 }
 
 func CloseDatabaseConnection(session *db.Session) {
@@ -58,6 +99,64 @@ func CloseDatabaseConnection(session *db.Session) {
 
 	log.Printf(
 		"Successfully terminated connection to database: %s at %s",
-		(*session).Name(), (*session).ConnectionURL().(*postgresql.ConnectionURL).Host,
+		(*session).Name(), location((*session).ConnectionURL()),
 	)
 }
+
+// Driver returns the driver of the database the session is connected to
+func Driver(session db.Session) string {
+	switch session.ConnectionURL().(type) {
+	case sqlite.ConnectionURL:
+		return DriverSqlite
+	case mysql.ConnectionURL:
+		return DriverMysql
+	}
+	return DriverPostgres
+}
+
+// connectionSettings returns the connection URL of the configured driver together with the adapter opening it,
+// postgres is used when no driver is configured. The database of a SQLite datasource is the path of its file,
+// the mysql driver connects to MariaDB as well.
+func connectionSettings(datasourceConfig *config.Datasource) (db.ConnectionURL, func(db.ConnectionURL) (db.Session, error)) {
+	switch datasourceConfig.Driver {
+	case "", DriverPostgres:
+		settings := &postgresql.ConnectionURL{
+			Database: datasourceConfig.Database,
+			Host:     datasourceConfig.Host,
+			User:     datasourceConfig.User,
+			Password: datasourceConfig.Password,
+		}
+		return settings, postgresql.Open
+	case DriverSqlite:
+		settings := sqlite.ConnectionURL{Database: datasourceConfig.Database, Options: map[string]string{}}
+		for option, value := range sqliteConnectionOptions {
+			settings.Options[option] = value
+		}
+		return settings, sqlite.Open
+	case DriverMysql:
+		settings := mysql.ConnectionURL{
+			Database: datasourceConfig.Database,
+			Host:     datasourceConfig.Host,
+			User:     datasourceConfig.User,
+			Password: datasourceConfig.Password,
+			Options:  map[string]string{},
+		}
+		for option, value := range mysqlConnectionOptions {
+			settings.Options[option] = value
+		}
+		return settings, mysql.Open
+	}
+
+	log.Panicf("Unsupported datasource driver: %s", datasourceConfig.Driver)
+	return nil, nil
+}
+
+func location(connectionURL db.ConnectionURL) string {
+	switch settings := connectionURL.(type) {
+	case *postgresql.ConnectionURL:
+		return settings.Host
+	case mysql.ConnectionURL:
+		return settings.Host
+	}
+	return "local file"
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/mysql"
 	"testing"
+	"time"
 )
 
 type DatabaseTestSuite struct {
@@ -31,13 +33,52 @@ func (suite *DatabaseTestSuite) TestInitializeDatabaseConnection() {
 	if !suite.isDatabaseUp {
 		suite.T().Skip("Skipping test since database container is not ready")
 	}
-	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration()
+	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration(DriverPostgres)
 	var session *db.Session
 	assert.NotPanics(suite.T(), func() { session = InitializeDatabaseConnection(databaseConfig) }, "Database connections should initialize without panics")
 	assert.NotNil(suite.T(), session, "Session should be set up now")
 	CloseDatabaseConnection(session)
 }
 
+// InitializeDatabaseConnection should open a sqlite database file with foreign keys enforced
+func (suite *DatabaseTestSuite) TestInitializeSqliteDatabaseConnection() {
+	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration(DriverSqlite)
+	var session *db.Session
+	assert.NotPanics(suite.T(), func() { session = InitializeDatabaseConnection(databaseConfig) }, "Database connections should initialize without panics")
+	assert.Equal(suite.T(), Driver(*session), DriverSqlite)
+
+	var foreignKeys int
+	row, err := (*session).SQL().QueryRow("PRAGMA foreign_keys")
+	assert.Nil(suite.T(), err)
+	assert.Nil(suite.T(), row.Scan(&foreignKeys))
+	assert.Equal(suite.T(), foreignKeys, 1, "Foreign keys should be enforced")
+
+	databaseutil.StopTestDatabase(session)
+	CloseDatabaseConnection(session)
+}
+
+// connectionSettings should connect mysql datasources with ANSI quoted identifiers and matched row counts
+func (suite *DatabaseTestSuite) TestMysqlConnectionSettings() {
+	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration(DriverMysql)
+	settings, _ := connectionSettings(databaseConfig)
+
+	mysqlSettings, ok := settings.(mysql.ConnectionURL)
+	assert.True(suite.T(), ok, "Should connect with the mysql adapter")
+	assert.Equal(suite.T(), mysqlSettings.Host, databaseConfig.Host)
+	assert.Contains(suite.T(), mysqlSettings.Options["sql_mode"], "ANSI_QUOTES")
+	assert.Equal(suite.T(), mysqlSettings.Options["clientFoundRows"], "true")
+	assert.Equal(suite.T(), location(settings), databaseConfig.Host)
+}
+
+// InitializeDatabaseConnection should panic if the datasource driver isn't supported
+func (suite *DatabaseTestSuite) TestInitializeDatabaseConnectionWithUnsupportedDriver() {
+	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration("oracle")
+	assert.PanicsWithValue(
+		suite.T(), "Unsupported datasource driver: oracle",
+		func() { InitializeDatabaseConnection(databaseConfig) }, "Database connection setup should panic on unsupported drivers",
+	)
+}
+
 // InitializeDatabaseConnection should panic if datasource configuration is not loaded
 func (suite *DatabaseTestSuite) TestInitializeDatabaseConnectionWithoutDatasourceConfiguration() {
 	assert.PanicsWithValue(
@@ -46,12 +87,12 @@ func (suite *DatabaseTestSuite) TestInitializeDatabaseConnectionWithoutDatasourc
 	)
 }
 
-// InitializeDatabaseConnection should retry to connect to database indefinitely on a fixed interval
+// InitializeDatabaseConnection should retry to connect to database until the database is up
 func (suite *DatabaseTestSuite) TestInitializeDatabaseConnectionWithDatabaseConnectionError() {
 	if suite.isDatabaseUp {
 		testcontainersutil.DockerComposeDown()
 	}
-	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration()
+	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration(DriverPostgres)
 	var session *db.Session
 	go func() {
 		testcontainersutil.DockerComposeUp()
@@ -64,18 +105,53 @@ func (suite *DatabaseTestSuite) TestInitializeDatabaseConnectionWithDatabaseConn
 	CloseDatabaseConnection(session)
 }
 
-// InitializeDatabaseConnection should panic if ping to database is unsuccessful
+// InitializeDatabaseConnection should panic once every attempt to reach the database is unsuccessful
 func (suite *DatabaseTestSuite) TestInitializeDatabaseConnectionWithUnsuccessfulPing() {
-	if !suite.isDatabaseUp {
-		suite.T().Skip("Skipping test since database container is not ready")
-	}
-	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration()
+	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration(DriverSqlite)
+	databaseConfig.ConnectionAttempts = 3
+	databaseConfig.ConnectionBackoffInSeconds = 1
+	databaseConfig.MaxConnectionBackoffInSeconds = 2
+	var backoffs []time.Duration
+	sleep = func(backoff time.Duration) { backoffs = append(backoffs, backoff) }
 	pingDatabase = func(session db.Session) error { return db.ErrNotConnected }
-	defer func() { pingDatabase = db.Session.Ping }()
+	defer func() {
+		sleep = time.Sleep
+		pingDatabase = db.Session.Ping
+	}()
+
 	assert.PanicsWithValue(
-		suite.T(), "Could not ping database: upper: not connected to a database",
+		suite.T(), "Could not connect to database after 3 attempt/s: could not ping database: upper: not connected to a database",
 		func() { InitializeDatabaseConnection(databaseConfig) }, "Database connection setup should panic if ping to database is unsuccessful",
 	)
+	assert.Equal(suite.T(), backoffs, []time.Duration{time.Second, 2 * time.Second}, "Should back off between the attempts")
+}
+
+// InitializeDatabaseConnection should connect once the database answers after unsuccessful attempts
+func (suite *DatabaseTestSuite) TestInitializeDatabaseConnectionAfterUnsuccessfulPing() {
+	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration(DriverSqlite)
+	databaseConfig.ConnectionAttempts = 5
+	databaseConfig.ConnectionBackoffInSeconds = 1
+	databaseConfig.MaxConnectionBackoffInSeconds = 3
+	var backoffs []time.Duration
+	sleep = func(backoff time.Duration) { backoffs = append(backoffs, backoff) }
+	pingDatabase = func(session db.Session) error {
+		if len(backoffs) < 3 {
+			return db.ErrNotConnected
+		}
+		return session.Ping()
+	}
+	defer func() {
+		sleep = time.Sleep
+		pingDatabase = db.Session.Ping
+	}()
+
+	var session *db.Session
+	assert.NotPanics(suite.T(), func() { session = InitializeDatabaseConnection(databaseConfig) }, "Database connections should initialize without panics")
+	assert.NotNil(suite.T(), session, "Session should be set up now")
+	assert.Equal(suite.T(), backoffs, []time.Duration{time.Second, 2 * time.Second, 3 * time.Second}, "Backoff should be capped at its maximum")
+
+	databaseutil.StopTestDatabase(session)
+	CloseDatabaseConnection(session)
 }
 
 // CloseDatabaseConnection should successfully close database connection
@@ -83,7 +159,7 @@ func (suite *DatabaseTestSuite) TestCloseDatabaseConnection() {
 	if !suite.isDatabaseUp {
 		suite.T().Skip("Skipping test since database container is not ready")
 	}
-	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration()
+	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration(DriverPostgres)
 	session := InitializeDatabaseConnection(databaseConfig)
 	assert.NotPanics(suite.T(), func() { CloseDatabaseConnection(session) }, "Database connections should close without panics")
 }
@@ -93,7 +169,7 @@ func (suite *DatabaseTestSuite) TestCloseDatabaseConnectionWithError() {
 	if !suite.isDatabaseUp {
 		suite.T().Skip("Skipping test since database container is not ready")
 	}
-	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration()
+	databaseConfig := databaseutil.GenerateTestDatasourceConfiguration(DriverPostgres)
 	session := InitializeDatabaseConnection(databaseConfig)
 	closeConnection = func(session db.Session) error { return db.ErrNotConnected }
 	assert.NotPanics(suite.T(), func() { CloseDatabaseConnection(session) }, "Database connections should not panic on error")
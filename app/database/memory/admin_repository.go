@@ -0,0 +1,146 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"time"
+)
+
+// adminRepository records every change it makes in the admin audit trail along with the change
+type adminRepository struct {
+	store *Store
+}
+
+func NewAdminRepository(store *Store) *adminRepository {
+	return &adminRepository{store: store}
+}
+
+// FetchUsers fetches a page of users without their master password hashes or recovery keys
+func (repository *adminRepository) FetchUsers(users *model.Users, offset int, limit int) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*users = model.Users{}
+	start, end := page(len(store.users), offset, limit)
+	for _, user := range store.users[start:end] {
+		selectFields(&user, []string{"id", "email", "username", "email_verified", "role", "disabled"})
+		*users = append(*users, user)
+	}
+	return nil
+}
+
+func (repository *adminRepository) FetchUsageStatistics(statistics *model.UsageStatistics) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*statistics = model.UsageStatistics{
+		Users:         len(store.users),
+		Entries:       len(store.passwords),
+		Organizations: len(store.organizations),
+	}
+	for _, user := range store.users {
+		if user.Disabled {
+			statistics.DisabledUsers++
+		}
+		if user.Role == model.UserRoleAdmin {
+			statistics.Admins++
+		}
+	}
+	for _, session := range store.sessions {
+		if session.Status == model.SessionActive {
+			statistics.ActiveSessions++
+		}
+	}
+	now := time.Now().UTC()
+	for _, send := range store.sends {
+		if isSendAvailable(send, now) {
+			statistics.ActiveSends++
+		}
+	}
+	return nil
+}
+
+// FetchAuditEntriesDetails fetches a page of the audit trail, most recent entries first
+func (repository *adminRepository) FetchAuditEntriesDetails(entries *model.AdminAuditEntriesDetails, offset int, limit int) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	details := model.AdminAuditEntriesDetails{}
+	for index := len(store.adminAuditEntries) - 1; index >= 0; index-- {
+		entry := store.adminAuditEntries[index]
+		adminIndex, targetIndex := store.userIndex(entry.AdminId), store.userIndex(entry.TargetUserId)
+		if adminIndex < 0 || targetIndex < 0 {
+			continue
+		}
+		details = append(details, model.AdminAuditEntryDetails{
+			AdminAuditEntry: entry,
+			AdminEmail:      store.users[adminIndex].Email,
+			TargetEmail:     store.users[targetIndex].Email,
+		})
+	}
+
+	start, end := page(len(details), offset, limit)
+	*entries = details[start:end]
+	return nil
+}
+
+// UpdateUserDisabledById disables or enables the user, disabling also revokes all of the user's sessions
+func (repository *adminRepository) UpdateUserDisabledById(disabled bool, id uint64, auditEntry *model.AdminAuditEntry) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	index := store.userIndex(id)
+	if index < 0 {
+		return errNotFound
+	}
+	store.users[index].Disabled = disabled
+	if disabled {
+		store.revokeUserSessions(id)
+	}
+
+	store.insertAuditEntry(auditEntry)
+	return nil
+}
+
+func (repository *adminRepository) UpdateUserRoleById(role string, id uint64, auditEntry *model.AdminAuditEntry) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	index := store.userIndex(id)
+	if index < 0 {
+		return errNotFound
+	}
+	store.users[index].Role = role
+
+	store.insertAuditEntry(auditEntry)
+	return nil
+}
+
+func (repository *adminRepository) RevokeUserSessionsById(id uint64, auditEntry *model.AdminAuditEntry) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.revokeUserSessions(id)
+	store.insertAuditEntry(auditEntry)
+	return nil
+}
+
+func (store *Store) revokeUserSessions(userId uint64) {
+	now := time.Now().UTC()
+	for index := range store.sessions {
+		if store.sessions[index].UserId == userId {
+			revokeSession(&store.sessions[index], now)
+		}
+	}
+}
+
+func (store *Store) insertAuditEntry(auditEntry *model.AdminAuditEntry) {
+	auditEntry.CreatedAt = time.Now().UTC()
+	auditEntry.Id = store.nextId("admin_audit_entry")
+	store.adminAuditEntries = append(store.adminAuditEntries, *auditEntry)
+}
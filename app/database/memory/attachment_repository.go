@@ -0,0 +1,115 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/upper/db/v4"
+)
+
+type attachmentRepository struct {
+	store *Store
+}
+
+func NewAttachmentRepository(store *Store) *attachmentRepository {
+	return &attachmentRepository{store: store}
+}
+
+// InsertNewAttachment returns ErrStorageQuotaExceeded if the attachment doesn't fit into the user's storage quota
+func (repository *attachmentRepository) InsertNewAttachment(attachment *model.Attachment, quotaInBytes int64) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.insertAttachment(attachment, quotaInBytes)
+}
+
+func (repository *attachmentRepository) FetchAttachmentById(attachment *model.Attachment, id uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingAttachment := range store.attachments {
+		if existingAttachment.Id == id {
+			*attachment = existingAttachment
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (repository *attachmentRepository) FetchAttachmentsByPasswordId(attachments *model.Attachments, passwordId uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*attachments = model.Attachments{}
+	for _, attachment := range store.attachments {
+		if attachment.PasswordId == passwordId {
+			*attachments = append(*attachments, attachment)
+		}
+	}
+	return nil
+}
+
+func (repository *attachmentRepository) FetchAttachmentsByUserId(attachments *model.Attachments, userId uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*attachments = model.Attachments{}
+	for _, attachment := range store.attachments {
+		if attachment.UserId == userId {
+			*attachments = append(*attachments, attachment)
+		}
+	}
+	return nil
+}
+
+// FetchStorageUsageByUserId returns the total size of the user's attachments in bytes
+func (repository *attachmentRepository) FetchStorageUsageByUserId(userId uint64) (int64, error) {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	return store.storageUsage(userId), nil
+}
+
+func (repository *attachmentRepository) DeleteAttachmentById(id uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index, attachment := range store.attachments {
+		if attachment.Id == id {
+			store.attachments = append(store.attachments[:index], store.attachments[index+1:]...)
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (store *Store) insertAttachment(attachment *model.Attachment, quotaInBytes int64) (db.InsertResult, error) {
+	if store.storageUsage(attachment.UserId)+attachment.Size > quotaInBytes {
+		return nil, repository.ErrStorageQuotaExceeded
+	}
+	for _, existingAttachment := range store.attachments {
+		if existingAttachment.BlobKey == attachment.BlobKey {
+			return nil, errDuplicate
+		}
+	}
+
+	newAttachment := *attachment
+	newAttachment.Id = store.nextId("attachment")
+	store.attachments = append(store.attachments, newAttachment)
+	return db.NewInsertResult(int64(newAttachment.Id)), nil
+}
+
+func (store *Store) storageUsage(userId uint64) int64 {
+	var usedBytes int64
+	for _, attachment := range store.attachments {
+		if attachment.UserId == userId {
+			usedBytes += attachment.Size
+		}
+	}
+	return usedBytes
+}
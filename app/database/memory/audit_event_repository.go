@@ -0,0 +1,86 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+)
+
+type auditEventRepository struct {
+	store *Store
+}
+
+func NewAuditEventRepository(store *Store) *auditEventRepository {
+	return &auditEventRepository{store: store}
+}
+
+// AppendEvent links the event to the last one in the chain and stores it
+func (repository *auditEventRepository) AppendEvent(event *model.AuditEvent, hashEvent func(event *model.AuditEvent) []byte) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	event.Sequence = 1
+	event.PreviousHash = []byte{}
+	if len(store.auditEvents) > 0 {
+		lastEvent := store.auditEvents[len(store.auditEvents)-1]
+		event.Sequence = lastEvent.Sequence + 1
+		event.PreviousHash = lastEvent.Hash
+	}
+	event.Hash = hashEvent(event)
+	event.Id = store.nextId("audit_event")
+	store.auditEvents = append(store.auditEvents, *event)
+	return nil
+}
+
+// FetchEvents fetches a page of events matching the filter, most recent events first
+func (repository *auditEventRepository) FetchEvents(
+	events *model.AuditEvents, filter *model.AuditEventFilter, offset int, limit int,
+) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	matchingEvents := model.AuditEvents{}
+	for index := len(store.auditEvents) - 1; index >= 0; index-- {
+		if event := store.auditEvents[index]; matchesAuditEventFilter(event, filter) {
+			matchingEvents = append(matchingEvents, event)
+		}
+	}
+
+	start, end := page(len(matchingEvents), offset, limit)
+	*events = matchingEvents[start:end]
+	return nil
+}
+
+// FetchEventsAfterSequence fetches the next batch of the chain in order
+func (repository *auditEventRepository) FetchEventsAfterSequence(events *model.AuditEvents, sequence int64, limit int) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*events = model.AuditEvents{}
+	for _, event := range store.auditEvents {
+		if event.Sequence > sequence && len(*events) < limit {
+			*events = append(*events, event)
+		}
+	}
+	return nil
+}
+
+func matchesAuditEventFilter(event model.AuditEvent, filter *model.AuditEventFilter) bool {
+	if filter.UserId != nil && (event.UserId == nil || *event.UserId != *filter.UserId) {
+		return false
+	}
+	if len(filter.Types) > 0 {
+		matchesType := false
+		for _, eventType := range filter.Types {
+			matchesType = matchesType || event.Type == eventType
+		}
+		if !matchesType {
+			return false
+		}
+	}
+	if filter.From != nil && event.CreatedAt.Before(*filter.From) {
+		return false
+	}
+	return filter.To == nil || event.CreatedAt.Before(*filter.To)
+}
@@ -0,0 +1,199 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"sort"
+	"time"
+)
+
+type emergencyAccessRepository struct {
+	store *Store
+}
+
+func NewEmergencyAccessRepository(store *Store) *emergencyAccessRepository {
+	return &emergencyAccessRepository{store: store}
+}
+
+func (repository *emergencyAccessRepository) InsertNewGrant(grant *model.EmergencyAccessGrant) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existingGrant := range store.emergencyAccessGrants {
+		if existingGrant.GrantorId == grant.GrantorId && existingGrant.GranteeId == grant.GranteeId {
+			return nil, errDuplicate
+		}
+	}
+
+	newGrant := *grant
+	newGrant.Id = store.nextId("emergency_access_grant")
+	if newGrant.CreatedAt.IsZero() {
+		newGrant.CreatedAt = time.Now().UTC()
+	}
+	store.emergencyAccessGrants = append(store.emergencyAccessGrants, newGrant)
+	return db.NewInsertResult(int64(newGrant.Id)), nil
+}
+
+func (repository *emergencyAccessRepository) FetchGrantById(grant *model.EmergencyAccessGrant, id uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingGrant := range store.emergencyAccessGrants {
+		if existingGrant.Id == id {
+			*grant = existingGrant
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (repository *emergencyAccessRepository) FetchGrantDetailsById(grant *model.EmergencyAccessGrantDetails, id uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingGrant := range store.emergencyAccessGrants {
+		if existingGrant.Id == id {
+			if details, ok := store.grantDetails(existingGrant); ok {
+				*grant = details
+				return nil
+			}
+		}
+	}
+	return errNotFound
+}
+
+// FetchGrantsDetailsByUserId fetches both the grants given by the user and the grants given to the user
+func (repository *emergencyAccessRepository) FetchGrantsDetailsByUserId(grants *model.EmergencyAccessGrantsDetails, userId uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*grants = model.EmergencyAccessGrantsDetails{}
+	for _, grant := range store.emergencyAccessGrants {
+		if grant.GrantorId != userId && grant.GranteeId != userId {
+			continue
+		}
+		if details, ok := store.grantDetails(grant); ok {
+			*grants = append(*grants, details)
+		}
+	}
+	return nil
+}
+
+func (repository *emergencyAccessRepository) UpdateGrantStatusById(status string, id uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index := range store.emergencyAccessGrants {
+		if store.emergencyAccessGrants[index].Id == id {
+			store.emergencyAccessGrants[index].Status = status
+		}
+	}
+	return nil
+}
+
+func (repository *emergencyAccessRepository) InsertNewRequest(request *model.EmergencyAccessRequest) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	newRequest := *request
+	newRequest.Id = store.nextId("emergency_access_request")
+	store.emergencyAccessRequests = append(store.emergencyAccessRequests, newRequest)
+	return db.NewInsertResult(int64(newRequest.Id)), nil
+}
+
+func (repository *emergencyAccessRepository) FetchRequestById(request *model.EmergencyAccessRequest, id uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingRequest := range store.emergencyAccessRequests {
+		if existingRequest.Id == id {
+			*request = existingRequest
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+// FetchRequestsByGrantId fetches the grant's requests, latest first
+func (repository *emergencyAccessRepository) FetchRequestsByGrantId(requests *model.EmergencyAccessRequests, grantId uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*requests = model.EmergencyAccessRequests{}
+	for _, request := range store.emergencyAccessRequests {
+		if request.GrantId == grantId {
+			*requests = append(*requests, request)
+		}
+	}
+	sort.SliceStable(*requests, func(i, j int) bool { return (*requests)[i].Id > (*requests)[j].Id })
+	return nil
+}
+
+func (repository *emergencyAccessRepository) FetchPendingRequestsAvailableBefore(
+	requests *model.EmergencyAccessRequests, availableBefore time.Time,
+) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*requests = model.EmergencyAccessRequests{}
+	for _, request := range store.emergencyAccessRequests {
+		if request.Status == model.EmergencyAccessRequestPending && !request.AvailableAt.After(availableBefore) {
+			*requests = append(*requests, request)
+		}
+	}
+	return nil
+}
+
+// ApproveRequestById approves only pending requests, so a rejection can never be overridden by an approval
+func (repository *emergencyAccessRepository) ApproveRequestById(sealedVaultKey []byte, id uint64) error {
+	return repository.resolvePendingRequest(id, func(request *model.EmergencyAccessRequest) {
+		request.Status = model.EmergencyAccessRequestApproved
+		request.SealedVaultKey = sealedVaultKey
+	})
+}
+
+// RejectRequestById rejects only pending requests, approved requests are ended by revoking the grant
+func (repository *emergencyAccessRepository) RejectRequestById(id uint64) error {
+	return repository.resolvePendingRequest(id, func(request *model.EmergencyAccessRequest) {
+		request.Status = model.EmergencyAccessRequestRejected
+	})
+}
+
+func (repository *emergencyAccessRepository) resolvePendingRequest(id uint64, resolve func(request *model.EmergencyAccessRequest)) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index := range store.emergencyAccessRequests {
+		request := &store.emergencyAccessRequests[index]
+		if request.Id == id && request.Status == model.EmergencyAccessRequestPending {
+			resolve(request)
+			resolvedAt := time.Now().UTC()
+			request.ResolvedAt = &resolvedAt
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+// grantDetails joins the grant with the e-mail addresses of both parties
+func (store *Store) grantDetails(grant model.EmergencyAccessGrant) (model.EmergencyAccessGrantDetails, bool) {
+	grantorIndex, granteeIndex := store.userIndex(grant.GrantorId), store.userIndex(grant.GranteeId)
+	if grantorIndex < 0 || granteeIndex < 0 {
+		return model.EmergencyAccessGrantDetails{}, false
+	}
+	return model.EmergencyAccessGrantDetails{
+		EmergencyAccessGrant: grant,
+		GrantorEmail:         store.users[grantorIndex].Email,
+		GranteeEmail:         store.users[granteeIndex].Email,
+	}, true
+}
@@ -0,0 +1,47 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"time"
+)
+
+type keyPairRepository struct {
+	store *Store
+}
+
+func NewKeyPairRepository(store *Store) *keyPairRepository {
+	return &keyPairRepository{store: store}
+}
+
+func (repository *keyPairRepository) InsertNewKeyPair(keyPair *model.KeyPair) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existingKeyPair := range store.keyPairs {
+		if existingKeyPair.UserId == keyPair.UserId {
+			return errDuplicate
+		}
+	}
+
+	newKeyPair := *keyPair
+	if newKeyPair.CreatedAt.IsZero() {
+		newKeyPair.CreatedAt = time.Now().UTC()
+	}
+	store.keyPairs = append(store.keyPairs, newKeyPair)
+	return nil
+}
+
+func (repository *keyPairRepository) FetchKeyPairByUserId(keyPair *model.KeyPair, userId uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingKeyPair := range store.keyPairs {
+		if existingKeyPair.UserId == userId {
+			*keyPair = existingKeyPair
+			return nil
+		}
+	}
+	return errNotFound
+}
@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type knownDeviceRepository struct {
+	store *Store
+}
+
+func NewKnownDeviceRepository(store *Store) *knownDeviceRepository {
+	return &knownDeviceRepository{store: store}
+}
+
+func (repository *knownDeviceRepository) InsertNewKnownDevice(device *model.KnownDevice) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	newDevice := *device
+	newDevice.Id = store.nextId("known_device")
+	store.knownDevices = append(store.knownDevices, newDevice)
+	return db.NewInsertResult(int64(newDevice.Id)), nil
+}
+
+func (repository *knownDeviceRepository) FetchKnownDevicesByUserId(devices *model.KnownDevices, userId uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*devices = model.KnownDevices{}
+	for _, device := range store.knownDevices {
+		if device.UserId == userId {
+			*devices = append(*devices, device)
+		}
+	}
+	return nil
+}
+
+func (repository *knownDeviceRepository) UpdateLastSeenById(lastSeenAt time.Time, id uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index := range store.knownDevices {
+		if store.knownDevices[index].Id == id {
+			store.knownDevices[index].LastSeenAt = lastSeenAt
+		}
+	}
+	return nil
+}
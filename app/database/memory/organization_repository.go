@@ -0,0 +1,278 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/upper/db/v4"
+	"sort"
+	"time"
+)
+
+type organizationRepository struct {
+	store *Store
+}
+
+func NewOrganizationRepository(store *Store) *organizationRepository {
+	return &organizationRepository{store: store}
+}
+
+// InsertNewOrganization creates the organization together with its owner's membership
+func (repository *organizationRepository) InsertNewOrganization(
+	organization *model.Organization, owner *model.OrganizationMembership,
+) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now().UTC()
+	organization.Id = store.nextId("organization")
+	newOrganization := *organization
+	if newOrganization.KeyVersion == 0 {
+		newOrganization.KeyVersion = 1
+	}
+	if newOrganization.CreatedAt.IsZero() {
+		newOrganization.CreatedAt = now
+	}
+	store.organizations = append(store.organizations, newOrganization)
+
+	owner.OrganizationId = organization.Id
+	owner.Id = store.nextId("organization_member")
+	newOwner := *owner
+	if newOwner.CreatedAt.IsZero() {
+		newOwner.CreatedAt = now
+	}
+	store.organizationMembers = append(store.organizationMembers, newOwner)
+	return nil
+}
+
+func (repository *organizationRepository) FetchMembershipDetailsById(membership *model.OrganizationMembershipDetails, id uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingMembership := range store.organizationMembers {
+		if existingMembership.Id == id {
+			if details, ok := store.membershipDetails(existingMembership); ok {
+				*membership = details
+				return nil
+			}
+		}
+	}
+	return errNotFound
+}
+
+// FetchMembershipByOrganizationIdAndUserId fetches the membership together with the version of the organization key
+// its sealed key belongs to
+func (repository *organizationRepository) FetchMembershipByOrganizationIdAndUserId(
+	membership *model.OrganizationMembershipDetails, organizationId uint64, userId uint64,
+) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingMembership := range store.organizationMembers {
+		if existingMembership.OrganizationId == organizationId && existingMembership.UserId == userId {
+			if details, ok := store.membershipDetails(existingMembership); ok {
+				*membership = details
+				return nil
+			}
+		}
+	}
+	return errNotFound
+}
+
+func (repository *organizationRepository) FetchMembershipsByOrganizationId(
+	memberships *model.OrganizationMemberships, organizationId uint64,
+) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*memberships = model.OrganizationMemberships{}
+	for _, membership := range store.organizationMembers {
+		if membership.OrganizationId == organizationId {
+			*memberships = append(*memberships, membership)
+		}
+	}
+	return nil
+}
+
+func (repository *organizationRepository) FetchMembershipsDetailsByUserId(
+	memberships *model.OrganizationMembershipsDetails, userId uint64,
+) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*memberships = store.membershipsDetails(func(membership model.OrganizationMembership) bool { return membership.UserId == userId })
+	sort.SliceStable(*memberships, func(i, j int) bool {
+		return (*memberships)[i].OrganizationName < (*memberships)[j].OrganizationName
+	})
+	return nil
+}
+
+func (repository *organizationRepository) FetchMembershipsDetailsByOrganizationId(
+	memberships *model.OrganizationMembershipsDetails, organizationId uint64,
+) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*memberships = store.membershipsDetails(func(membership model.OrganizationMembership) bool {
+		return membership.OrganizationId == organizationId
+	})
+	return nil
+}
+
+// InsertNewMembership stores the membership, its key has to be sealed from the given version of the organization key,
+// otherwise ErrOrganizationChanged is returned
+func (repository *organizationRepository) InsertNewMembership(
+	membership *model.OrganizationMembership, organizationKeyVersion int,
+) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if keyVersion, ok := store.organizationKeyVersion(membership.OrganizationId); !ok {
+		return nil, errNotFound
+	} else if keyVersion != organizationKeyVersion {
+		return nil, errOrganizationChanged
+	}
+
+	for _, existingMembership := range store.organizationMembers {
+		if existingMembership.OrganizationId == membership.OrganizationId && existingMembership.UserId == membership.UserId {
+			return nil, errDuplicate
+		}
+	}
+
+	newMembership := *membership
+	newMembership.Id = store.nextId("organization_member")
+	if newMembership.CreatedAt.IsZero() {
+		newMembership.CreatedAt = time.Now().UTC()
+	}
+	store.organizationMembers = append(store.organizationMembers, newMembership)
+	return db.NewInsertResult(int64(newMembership.Id)), nil
+}
+
+func (repository *organizationRepository) UpdateMembershipStatusById(status string, id uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index := range store.organizationMembers {
+		if store.organizationMembers[index].Id == id {
+			store.organizationMembers[index].Status = status
+		}
+	}
+	return nil
+}
+
+// RotateOrganizationKey removes the membership and replaces the organization key at once, every remaining member
+// needs a newly sealed key and every shared entry has to be re-encrypted, otherwise ErrOrganizationChanged is returned
+// and nothing changes
+func (repository *organizationRepository) RotateOrganizationKey(
+	organizationId uint64, removedMembershipId uint64, sealedOrganizationKeys map[uint64][]byte, reencryptedPasswords model.Passwords,
+) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.rotateOrganizationKey(organizationId, removedMembershipId, sealedOrganizationKeys, reencryptedPasswords)
+}
+
+func (store *Store) rotateOrganizationKey(
+	organizationId uint64, removedMembershipId uint64, sealedOrganizationKeys map[uint64][]byte, reencryptedPasswords model.Passwords,
+) error {
+	remainingMemberships := 0
+	for _, membership := range store.organizationMembers {
+		if membership.OrganizationId != organizationId || membership.Id == removedMembershipId {
+			continue
+		}
+		if _, ok := sealedOrganizationKeys[membership.Id]; !ok {
+			return repository.ErrOrganizationChanged
+		}
+		remainingMemberships++
+	}
+	sharedPasswords := 0
+	for _, password := range store.passwords {
+		if password.OrganizationId != nil && *password.OrganizationId == organizationId {
+			sharedPasswords++
+		}
+	}
+	if remainingMemberships != len(sealedOrganizationKeys) || sharedPasswords != len(reencryptedPasswords) {
+		return repository.ErrOrganizationChanged
+	}
+	for _, password := range reencryptedPasswords {
+		index := store.passwordIndex(password.Id)
+		if index < 0 || store.passwords[index].OrganizationId == nil || *store.passwords[index].OrganizationId != organizationId {
+			return repository.ErrOrganizationChanged
+		}
+	}
+
+	memberships := store.organizationMembers[:0]
+	for _, membership := range store.organizationMembers {
+		if membership.OrganizationId == organizationId && membership.Id == removedMembershipId {
+			continue
+		}
+		if sealedOrganizationKey, ok := sealedOrganizationKeys[membership.Id]; ok && membership.OrganizationId == organizationId {
+			membership.SealedOrganizationKey = sealedOrganizationKey
+		}
+		memberships = append(memberships, membership)
+	}
+	store.organizationMembers = memberships
+
+	for _, password := range reencryptedPasswords {
+		index := store.passwordIndex(password.Id)
+		store.passwords[index].Password = password.Password
+		store.passwords[index].TotpUri = password.TotpUri
+	}
+
+	for index := range store.organizations {
+		if store.organizations[index].Id == organizationId {
+			store.organizations[index].KeyVersion++
+		}
+	}
+	return nil
+}
+
+// organizationKeyVersion returns the version of the organization key, false if the organization doesn't exist
+func (store *Store) organizationKeyVersion(organizationId uint64) (int, bool) {
+	for _, organization := range store.organizations {
+		if organization.Id == organizationId {
+			return organization.KeyVersion, true
+		}
+	}
+	return 0, false
+}
+
+// membershipsDetails joins the matching memberships with their organization's name and their member's e-mail address
+func (store *Store) membershipsDetails(matches func(membership model.OrganizationMembership) bool) model.OrganizationMembershipsDetails {
+	memberships := model.OrganizationMembershipsDetails{}
+	for _, membership := range store.organizationMembers {
+		if !matches(membership) {
+			continue
+		}
+		if details, ok := store.membershipDetails(membership); ok {
+			memberships = append(memberships, details)
+		}
+	}
+	return memberships
+}
+
+func (store *Store) membershipDetails(membership model.OrganizationMembership) (model.OrganizationMembershipDetails, bool) {
+	userIndex := store.userIndex(membership.UserId)
+	if userIndex < 0 {
+		return model.OrganizationMembershipDetails{}, false
+	}
+	for _, organization := range store.organizations {
+		if organization.Id == membership.OrganizationId {
+			return model.OrganizationMembershipDetails{
+				OrganizationMembership: membership,
+				OrganizationName:       organization.Name,
+				OrganizationKeyVersion: organization.KeyVersion,
+				Email:                  store.users[userIndex].Email,
+			}, true
+		}
+	}
+	return model.OrganizationMembershipDetails{}, false
+}
@@ -0,0 +1,294 @@
+package memory
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/upper/db/v4"
+	"sort"
+	"time"
+)
+
+type passwordRepository struct {
+	store *Store
+}
+
+func NewPasswordRepository(store *Store) *passwordRepository {
+	return &passwordRepository{store: store}
+}
+
+// InsertNewPassword inserts the first version of the password with the next revision of its owner's vault
+func (repository *passwordRepository) InsertNewPassword(password *model.Password) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	revision, err := store.nextVaultRevision(password.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	password.Revision = revision
+	password.Version = 1
+	newPassword := *password
+	newPassword.Id = store.nextId("password")
+	store.passwords = append(store.passwords, newPassword)
+	return db.NewInsertResult(int64(newPassword.Id)), nil
+}
+
+// UpdatePasswordById updates the name, the password and the TOTP URI of the entry and reloads it with its new version and revision,
+// a nil expected version updates the password unconditionally
+func (repository *passwordRepository) UpdatePasswordById(password *model.Password, expectedVersion *int64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.updatePassword(password, expectedVersion)
+}
+
+// DeletePasswordById leaves a tombstone in the owner's vault, a nil expected version deletes the password unconditionally
+func (repository *passwordRepository) DeletePasswordById(passwordId uint64, expectedVersion *int64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	index := store.passwordIndex(passwordId)
+	if index < 0 {
+		return errNotFound
+	}
+	deletedPassword := store.passwords[index]
+	if err := versionConflict(deletedPassword, expectedVersion); err != nil {
+		return err
+	}
+	if store.hasPasswordTombstone(passwordId, deletedPassword.UserId) {
+		return errDuplicate
+	}
+
+	revision, err := store.nextVaultRevision(deletedPassword.UserId)
+	if err != nil {
+		return err
+	}
+	store.passwords = append(store.passwords[:index], store.passwords[index+1:]...)
+	store.deletePasswordDependents(passwordId)
+	store.insertPasswordTombstone(passwordId, deletedPassword.UserId, revision)
+	return nil
+}
+
+func (repository *passwordRepository) FetchPasswordById(ctx context.Context, password *model.Password, passwordId uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	index := store.passwordIndex(passwordId)
+	if index < 0 {
+		return errNotFound
+	}
+	*password = store.passwords[index]
+	return nil
+}
+
+// FetchAllByUserId fetches the user's personal vault, entries moved to an organization are left out
+func (repository *passwordRepository) FetchAllByUserId(
+	ctx context.Context, passwords *model.Passwords, userId uint64, queryFields []string,
+) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*passwords = model.Passwords{}
+	for _, password := range store.passwords {
+		if password.UserId == userId && password.OrganizationId == nil {
+			selectFields(&password, queryFields)
+			*passwords = append(*passwords, password)
+		}
+	}
+	return nil
+}
+
+func (repository *passwordRepository) FetchAllByOrganizationId(passwords *model.Passwords, organizationId uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*passwords = model.Passwords{}
+	for _, password := range store.passwords {
+		if password.OrganizationId != nil && *password.OrganizationId == organizationId {
+			*passwords = append(*passwords, password)
+		}
+	}
+	return nil
+}
+
+// MovePasswordById moves an entry between a personal and a shared vault, a nil organization id means the personal vault
+// of the given user, the password and the TOTP URI have to be encrypted with the key of the target vault. Moving into an organization
+// requires its key to still be at the given version, otherwise ErrOrganizationChanged is returned. Leaving a personal vault
+// leaves a tombstone in it, the new revision of the password is returned.
+func (repository *passwordRepository) MovePasswordById(
+	userId uint64, organizationId *uint64, organizationKeyVersion int, password []byte, totpUri []byte, passwordId uint64,
+) (int64, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if organizationId != nil {
+		if keyVersion, ok := store.organizationKeyVersion(*organizationId); !ok {
+			return 0, errNotFound
+		} else if keyVersion != organizationKeyVersion {
+			return 0, errOrganizationChanged
+		}
+	}
+
+	index := store.passwordIndex(passwordId)
+	if index < 0 {
+		return 0, errNotFound
+	}
+	movedPassword := store.passwords[index]
+	if store.userIndex(userId) < 0 || store.userIndex(movedPassword.UserId) < 0 {
+		return 0, errNotFound
+	}
+
+	leavesPersonalVault := movedPassword.OrganizationId == nil && (organizationId != nil || movedPassword.UserId != userId)
+	if leavesPersonalVault && store.hasPasswordTombstone(passwordId, movedPassword.UserId) {
+		return 0, errDuplicate
+	}
+
+	revision, _ := store.nextVaultRevision(userId)
+	if leavesPersonalVault {
+		tombstoneRevision := revision
+		if movedPassword.UserId != userId {
+			tombstoneRevision, _ = store.nextVaultRevision(movedPassword.UserId)
+		}
+		store.insertPasswordTombstone(passwordId, movedPassword.UserId, tombstoneRevision)
+	}
+	if organizationId == nil {
+		store.deletePasswordTombstone(passwordId, userId)
+	}
+
+	movedPassword.UserId = userId
+	movedPassword.OrganizationId = organizationId
+	movedPassword.Password = password
+	movedPassword.TotpUri = totpUri
+	movedPassword.Revision = revision
+	store.passwords[index] = movedPassword
+	return revision, nil
+}
+
+// FetchVaultChanges fetches the entries and tombstones of the user's personal vault with a revision after the given one
+func (repository *passwordRepository) FetchVaultChanges(changes *model.VaultChanges, userId uint64, sinceRevision int64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	if store.userIndex(userId) < 0 {
+		return errNotFound
+	}
+	changes.Revision = store.vaultRevisions[userId]
+
+	changes.Passwords = model.Passwords{}
+	for _, password := range store.passwords {
+		if password.UserId == userId && password.OrganizationId == nil && password.Revision > sinceRevision {
+			changes.Passwords = append(changes.Passwords, password)
+		}
+	}
+	sort.SliceStable(changes.Passwords, func(i, j int) bool {
+		return changes.Passwords[i].Revision < changes.Passwords[j].Revision
+	})
+
+	changes.Tombstones = model.PasswordTombstones{}
+	for _, tombstone := range store.passwordTombstones {
+		if tombstone.UserId == userId && tombstone.Revision > sinceRevision {
+			changes.Tombstones = append(changes.Tombstones, tombstone)
+		}
+	}
+	sort.SliceStable(changes.Tombstones, func(i, j int) bool {
+		return changes.Tombstones[i].Revision < changes.Tombstones[j].Revision
+	})
+	return nil
+}
+
+// nextVaultRevision increments the revision of the user's vault
+func (store *Store) nextVaultRevision(userId uint64) (int64, error) {
+	if store.userIndex(userId) < 0 {
+		return 0, errNotFound
+	}
+	store.vaultRevisions[userId]++
+	return store.vaultRevisions[userId], nil
+}
+
+// updatePassword is a compare-and-swap on the version of the password, returning a VersionConflictError
+// if the password changed since the expected version
+func (store *Store) updatePassword(password *model.Password, expectedVersion *int64) error {
+	index := store.passwordIndex(password.Id)
+	if index < 0 {
+		return errNotFound
+	}
+	current := store.passwords[index]
+	if err := versionConflict(current, expectedVersion); err != nil {
+		return err
+	}
+
+	revision, err := store.nextVaultRevision(current.UserId)
+	if err != nil {
+		return err
+	}
+	current.Name = password.Name
+	current.Password = password.Password
+	current.TotpUri = password.TotpUri
+	current.Revision = revision
+	current.Version++
+	store.passwords[index] = current
+
+	*password = current
+	return nil
+}
+
+// versionConflict returns the conflict with the current version of the password if it changed since the expected version
+func versionConflict(current model.Password, expectedVersion *int64) error {
+	if expectedVersion != nil && *expectedVersion != current.Version {
+		return &repository.VersionConflictError{CurrentVersion: current.Version}
+	}
+	return nil
+}
+
+// deletePasswordDependents deletes the shares and attachments of a deleted password
+func (store *Store) deletePasswordDependents(passwordId uint64) {
+	shares := store.passwordShares[:0]
+	for _, share := range store.passwordShares {
+		if share.PasswordId != passwordId {
+			shares = append(shares, share)
+		}
+	}
+	store.passwordShares = shares
+
+	attachments := store.attachments[:0]
+	for _, attachment := range store.attachments {
+		if attachment.PasswordId != passwordId {
+			attachments = append(attachments, attachment)
+		}
+	}
+	store.attachments = attachments
+}
+
+func (store *Store) hasPasswordTombstone(passwordId uint64, userId uint64) bool {
+	for _, tombstone := range store.passwordTombstones {
+		if tombstone.PasswordId == passwordId && tombstone.UserId == userId {
+			return true
+		}
+	}
+	return false
+}
+
+func (store *Store) insertPasswordTombstone(passwordId uint64, userId uint64, revision int64) {
+	store.passwordTombstones = append(store.passwordTombstones, model.PasswordTombstone{
+		PasswordId: passwordId, UserId: userId, Revision: revision, DeletedAt: time.Now().UTC(),
+	})
+}
+
+func (store *Store) deletePasswordTombstone(passwordId uint64, userId uint64) {
+	for index, tombstone := range store.passwordTombstones {
+		if tombstone.PasswordId == passwordId && tombstone.UserId == userId {
+			store.passwordTombstones = append(store.passwordTombstones[:index], store.passwordTombstones[index+1:]...)
+			return
+		}
+	}
+}
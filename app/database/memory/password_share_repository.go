@@ -0,0 +1,157 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type passwordShareRepository struct {
+	store *Store
+}
+
+func NewPasswordShareRepository(store *Store) *passwordShareRepository {
+	return &passwordShareRepository{store: store}
+}
+
+func (repository *passwordShareRepository) InsertNewShare(share *model.PasswordShare) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existingShare := range store.passwordShares {
+		if existingShare.PasswordId == share.PasswordId && existingShare.RecipientId == share.RecipientId {
+			return nil, errDuplicate
+		}
+	}
+
+	newShare := *share
+	newShare.Id = store.nextId("password_share")
+	if newShare.CreatedAt.IsZero() {
+		newShare.CreatedAt = time.Now().UTC()
+	}
+	store.passwordShares = append(store.passwordShares, newShare)
+	return db.NewInsertResult(int64(newShare.Id)), nil
+}
+
+func (repository *passwordShareRepository) FetchShareDetailsById(share *model.PasswordShareDetails, id uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	shares := store.sharesDetails(func(share model.PasswordShare) bool { return share.Id == id })
+	if len(shares) == 0 {
+		return errNotFound
+	}
+	*share = shares[0]
+	return nil
+}
+
+func (repository *passwordShareRepository) FetchSharesDetailsByRecipientId(
+	shares *model.PasswordSharesDetails, recipientId uint64,
+) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*shares = store.sharesDetails(func(share model.PasswordShare) bool { return share.RecipientId == recipientId })
+	return nil
+}
+
+func (repository *passwordShareRepository) FetchSharesDetailsByPasswordId(
+	shares *model.PasswordSharesDetails, passwordId uint64,
+) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*shares = store.sharesDetails(func(share model.PasswordShare) bool { return share.PasswordId == passwordId })
+	return nil
+}
+
+func (repository *passwordShareRepository) DeleteShareById(id uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index, share := range store.passwordShares {
+		if share.Id == id {
+			store.passwordShares = append(store.passwordShares[:index], store.passwordShares[index+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// UpdateSharedPasswordById updates the entry together with every recipient's copy at once, a copy for each share
+// of the entry is required, otherwise ErrPasswordSharesChanged is returned and nothing changes. The entry is reloaded
+// with its new version and revision, a nil expected version updates the entry unconditionally.
+func (repository *passwordShareRepository) UpdateSharedPasswordById(
+	password *model.Password, sealedPasswords map[uint64][]byte, expectedVersion *int64,
+) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.updateSharedPassword(password, sealedPasswords, expectedVersion)
+}
+
+func (store *Store) updateSharedPassword(password *model.Password, sealedPasswords map[uint64][]byte, expectedVersion *int64) error {
+	index := store.passwordIndex(password.Id)
+	if index < 0 {
+		return errNotFound
+	}
+	if err := versionConflict(store.passwords[index], expectedVersion); err != nil {
+		return err
+	}
+
+	shares := 0
+	for _, share := range store.passwordShares {
+		if share.PasswordId != password.Id {
+			continue
+		}
+		if _, ok := sealedPasswords[share.Id]; !ok {
+			return repository.ErrPasswordSharesChanged
+		}
+		shares++
+	}
+	if shares != len(sealedPasswords) {
+		return repository.ErrPasswordSharesChanged
+	}
+
+	if err := store.updatePassword(password, expectedVersion); err != nil {
+		return err
+	}
+	for index := range store.passwordShares {
+		if sealedPassword, ok := sealedPasswords[store.passwordShares[index].Id]; ok && store.passwordShares[index].PasswordId == password.Id {
+			store.passwordShares[index].SealedPassword = sealedPassword
+		}
+	}
+	return nil
+}
+
+// sharesDetails joins the matching shares with the owner of their entry and the e-mail addresses of both parties
+func (store *Store) sharesDetails(matches func(share model.PasswordShare) bool) model.PasswordSharesDetails {
+	shares := model.PasswordSharesDetails{}
+	for _, share := range store.passwordShares {
+		if !matches(share) {
+			continue
+		}
+		passwordIndex := store.passwordIndex(share.PasswordId)
+		if passwordIndex < 0 {
+			continue
+		}
+		ownerIndex, recipientIndex := store.userIndex(store.passwords[passwordIndex].UserId), store.userIndex(share.RecipientId)
+		if ownerIndex < 0 || recipientIndex < 0 {
+			continue
+		}
+		shares = append(shares, model.PasswordShareDetails{
+			PasswordShare:  share,
+			OwnerId:        store.users[ownerIndex].Id,
+			OwnerEmail:     store.users[ownerIndex].Email,
+			RecipientEmail: store.users[recipientIndex].Email,
+		})
+	}
+	return shares
+}
@@ -0,0 +1,26 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+)
+
+// NewRepositories creates the repositories of the store, meant for demos and tests since nothing survives a restart
+func NewRepositories(store *Store) *repository.Repositories {
+	return &repository.Repositories{
+		User:            NewUserRepository(store),
+		Password:        NewPasswordRepository(store),
+		Webauthn:        NewWebauthnRepository(store),
+		KnownDevice:     NewKnownDeviceRepository(store),
+		KeyPair:         NewKeyPairRepository(store),
+		EmergencyAccess: NewEmergencyAccessRepository(store),
+		Session:         NewSessionRepository(store),
+		Organization:    NewOrganizationRepository(store),
+		PasswordShare:   NewPasswordShareRepository(store),
+		Send:            NewSendRepository(store),
+		Admin:           NewAdminRepository(store),
+		AuditEvent:      NewAuditEventRepository(store),
+		Webhook:         NewWebhookRepository(store),
+		Attachment:      NewAttachmentRepository(store),
+		UnitOfWork:      NewUnitOfWork(store),
+	}
+}
@@ -0,0 +1,14 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/repositoryutil"
+	"github.com/stretchr/testify/suite"
+	"testing"
+)
+
+func TestRepositoryConformanceSuite(t *testing.T) {
+	suite.Run(t, &repositoryutil.ConformanceSuite{
+		SetupRepositories: func() (*repository.Repositories, bool) { return NewRepositories(NewStore()), true },
+	})
+}
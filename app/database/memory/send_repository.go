@@ -0,0 +1,118 @@
+package memory
+
+import (
+	"bytes"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"sort"
+	"time"
+)
+
+type sendRepository struct {
+	store *Store
+}
+
+func NewSendRepository(store *Store) *sendRepository {
+	return &sendRepository{store: store}
+}
+
+func (repository *sendRepository) InsertNewSend(send *model.Send) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existingSend := range store.sends {
+		if bytes.Equal(existingSend.TokenHash, send.TokenHash) {
+			return nil, errDuplicate
+		}
+	}
+
+	newSend := *send
+	newSend.Id = store.nextId("send")
+	store.sends = append(store.sends, newSend)
+	return db.NewInsertResult(int64(newSend.Id)), nil
+}
+
+// FetchAvailableSendByTokenHash fetches a send that neither expired nor ran out of views
+func (repository *sendRepository) FetchAvailableSendByTokenHash(send *model.Send, tokenHash []byte, now time.Time) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingSend := range store.sends {
+		if bytes.Equal(existingSend.TokenHash, tokenHash) && isSendAvailable(existingSend, now) {
+			*send = existingSend
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (repository *sendRepository) FetchAvailableSendsByUserId(sends *model.Sends, userId uint64, now time.Time) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*sends = model.Sends{}
+	for _, send := range store.sends {
+		if send.UserId == userId && isSendAvailable(send, now) {
+			*sends = append(*sends, send)
+		}
+	}
+	sort.SliceStable(*sends, func(i, j int) bool { return (*sends)[i].CreatedAt.After((*sends)[j].CreatedAt) })
+	return nil
+}
+
+// ConsumeSendViewById counts a view of the send and burns it after its last view, sends that became unavailable
+// in the meantime return errNotFound so concurrent reads can't exceed the maximum view count
+func (repository *sendRepository) ConsumeSendViewById(id uint64, now time.Time) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index := range store.sends {
+		if store.sends[index].Id == id && isSendAvailable(store.sends[index], now) {
+			store.sends[index].ViewCount++
+			if store.sends[index].ViewCount >= store.sends[index].MaxViews {
+				store.sends = append(store.sends[:index], store.sends[index+1:]...)
+			}
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (repository *sendRepository) DeleteSendByIdAndUserId(id uint64, userId uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index, send := range store.sends {
+		if send.Id == id && send.UserId == userId {
+			store.sends = append(store.sends[:index], store.sends[index+1:]...)
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+// DeleteUnavailableSends purges expired and used up sends, returning how many were deleted
+func (repository *sendRepository) DeleteUnavailableSends(now time.Time) (int64, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	sends := store.sends[:0]
+	for _, send := range store.sends {
+		if isSendAvailable(send, now) {
+			sends = append(sends, send)
+		}
+	}
+	deleted := int64(len(store.sends) - len(sends))
+	store.sends = sends
+	return deleted, nil
+}
+
+func isSendAvailable(send model.Send, now time.Time) bool {
+	return send.ExpiresAt.After(now) && send.ViewCount < send.MaxViews
+}
@@ -0,0 +1,121 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"sort"
+	"time"
+)
+
+type sessionRepository struct {
+	store *Store
+}
+
+func NewSessionRepository(store *Store) *sessionRepository {
+	return &sessionRepository{store: store}
+}
+
+func (repository *sessionRepository) InsertNewSession(session *model.Session) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	newSession := *session
+	newSession.Id = store.nextId("user_session")
+	store.sessions = append(store.sessions, newSession)
+	return db.NewInsertResult(int64(newSession.Id)), nil
+}
+
+func (repository *sessionRepository) FetchSessionById(session *model.Session, id uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingSession := range store.sessions {
+		if existingSession.Id == id {
+			*session = existingSession
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+// FetchSessionsByUserId fetches the user's sessions that weren't revoked, most recently used first
+func (repository *sessionRepository) FetchSessionsByUserId(sessions *model.Sessions, userId uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*sessions = model.Sessions{}
+	for _, session := range store.sessions {
+		if session.UserId == userId && session.Status != model.SessionRevoked {
+			*sessions = append(*sessions, session)
+		}
+	}
+	sort.SliceStable(*sessions, func(i, j int) bool { return (*sessions)[i].LastSeenAt.After((*sessions)[j].LastSeenAt) })
+	return nil
+}
+
+// UpdateSessionStatusById changes the status only if the session is still in the expected status,
+// so concurrent approvals and sign ins can't claim the same session twice
+func (repository *sessionRepository) UpdateSessionStatusById(currentStatus string, newStatus string, id uint64) error {
+	return repository.updateSession(id, func(session *model.Session) bool {
+		if session.Status != currentStatus {
+			return false
+		}
+		session.Status = newStatus
+		return true
+	})
+}
+
+func (repository *sessionRepository) RevokeSessionById(id uint64) error {
+	return repository.updateSession(id, func(session *model.Session) bool {
+		return revokeSession(session, time.Now().UTC())
+	})
+}
+
+func (repository *sessionRepository) RevokeSessionsByUserId(userId uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	store.revokeUserSessions(userId)
+	return nil
+}
+
+// TouchActiveSessionById refreshes the last seen time of the user's session, sessions that aren't active anymore
+// or belong to another user return errNotFound
+func (repository *sessionRepository) TouchActiveSessionById(lastSeenAt time.Time, id uint64, userId uint64) error {
+	return repository.updateSession(id, func(session *model.Session) bool {
+		if session.UserId != userId || session.Status != model.SessionActive {
+			return false
+		}
+		session.LastSeenAt = lastSeenAt
+		return true
+	})
+}
+
+// updateSession applies the update to the session, returning errNotFound if the session is missing
+// or the update doesn't apply to it
+func (repository *sessionRepository) updateSession(id uint64, update func(session *model.Session) bool) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index := range store.sessions {
+		if store.sessions[index].Id == id && update(&store.sessions[index]) {
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+// revokeSession revokes the session unless it's revoked already
+func revokeSession(session *model.Session, revokedAt time.Time) bool {
+	if session.Status == model.SessionRevoked {
+		return false
+	}
+	session.Status = model.SessionRevoked
+	session.RevokedAt = &revokedAt
+	return true
+}
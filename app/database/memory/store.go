@@ -0,0 +1,152 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/iancoleman/strcase"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Store keeps the records of every repository in memory in the order they were inserted, a single lock guards all of them
+// so operations spanning several records are as atomic as they are in a database transaction
+type Store struct {
+	mutex sync.RWMutex
+	records
+}
+
+type records struct {
+	lastIds map[string]uint64
+
+	users                   []model.User
+	vaultRevisions          map[uint64]int64
+	emailVerificationTokens []model.EmailVerificationToken
+	accountRecoveries       []model.AccountRecovery
+	passwords               []model.Password
+	passwordTombstones      []model.PasswordTombstone
+	keyPairs                []model.KeyPair
+	knownDevices            []model.KnownDevice
+	webauthnCredentials     []model.WebauthnCredential
+	webauthnChallenges      []model.WebauthnChallenge
+	emergencyAccessGrants   []model.EmergencyAccessGrant
+	emergencyAccessRequests []model.EmergencyAccessRequest
+	sessions                []model.Session
+	organizations           []model.Organization
+	organizationMembers     []model.OrganizationMembership
+	passwordShares          []model.PasswordShare
+	sends                   []model.Send
+	adminAuditEntries       []model.AdminAuditEntry
+	auditEvents             []model.AuditEvent
+	webhooks                []model.Webhook
+	webhookDeliveries       []model.WebhookDelivery
+	webhookDeadLetters      []model.WebhookDeadLetter
+	attachments             []model.Attachment
+}
+
+func NewStore() *Store {
+	return &Store{records: records{lastIds: map[string]uint64{}, vaultRevisions: map[uint64]int64{}}}
+}
+
+// copyRecords copies the records of the store, changes to the copy leave the store as it is
+func (store *Store) copyRecords() records {
+	lastIds := make(map[string]uint64, len(store.lastIds))
+	for table, id := range store.lastIds {
+		lastIds[table] = id
+	}
+	vaultRevisions := make(map[uint64]int64, len(store.vaultRevisions))
+	for userId, revision := range store.vaultRevisions {
+		vaultRevisions[userId] = revision
+	}
+
+	return records{
+		lastIds:                 lastIds,
+		users:                   append([]model.User(nil), store.users...),
+		vaultRevisions:          vaultRevisions,
+		emailVerificationTokens: append([]model.EmailVerificationToken(nil), store.emailVerificationTokens...),
+		accountRecoveries:       append([]model.AccountRecovery(nil), store.accountRecoveries...),
+		passwords:               append([]model.Password(nil), store.passwords...),
+		passwordTombstones:      append([]model.PasswordTombstone(nil), store.passwordTombstones...),
+		keyPairs:                append([]model.KeyPair(nil), store.keyPairs...),
+		knownDevices:            append([]model.KnownDevice(nil), store.knownDevices...),
+		webauthnCredentials:     append([]model.WebauthnCredential(nil), store.webauthnCredentials...),
+		webauthnChallenges:      append([]model.WebauthnChallenge(nil), store.webauthnChallenges...),
+		emergencyAccessGrants:   append([]model.EmergencyAccessGrant(nil), store.emergencyAccessGrants...),
+		emergencyAccessRequests: append([]model.EmergencyAccessRequest(nil), store.emergencyAccessRequests...),
+		sessions:                append([]model.Session(nil), store.sessions...),
+		organizations:           append([]model.Organization(nil), store.organizations...),
+		organizationMembers:     append([]model.OrganizationMembership(nil), store.organizationMembers...),
+		passwordShares:          append([]model.PasswordShare(nil), store.passwordShares...),
+		sends:                   append([]model.Send(nil), store.sends...),
+		adminAuditEntries:       append([]model.AdminAuditEntry(nil), store.adminAuditEntries...),
+		auditEvents:             append([]model.AuditEvent(nil), store.auditEvents...),
+		webhooks:                append([]model.Webhook(nil), store.webhooks...),
+		webhookDeliveries:       append([]model.WebhookDelivery(nil), store.webhookDeliveries...),
+		webhookDeadLetters:      append([]model.WebhookDeadLetter(nil), store.webhookDeadLetters...),
+		attachments:             append([]model.Attachment(nil), store.attachments...),
+	}
+}
+
+// nextId returns the next id of the table, ids start at 1 like the serial ids of a database
+func (store *Store) nextId(table string) uint64 {
+	store.lastIds[table]++
+	return store.lastIds[table]
+}
+
+func (store *Store) userIndex(id uint64) int {
+	for index := range store.users {
+		if store.users[index].Id == id {
+			return index
+		}
+	}
+	return -1
+}
+
+func (store *Store) passwordIndex(id uint64) int {
+	for index := range store.passwords {
+		if store.passwords[index].Id == id {
+			return index
+		}
+	}
+	return -1
+}
+
+// Aliases of the repository errors, the receivers of the repositories shadow the repository package
+var (
+	errNotFound            = repository.ErrNotFound
+	errDuplicate           = repository.ErrDuplicate
+	errOrganizationChanged = repository.ErrOrganizationChanged
+)
+
+// selectFields zeroes the fields of the record that aren't among the query fields, the same way a query selecting
+// only their columns leaves them, no query fields select all of them
+func selectFields(record interface{}, queryFields []string) {
+	if len(queryFields) == 0 {
+		return
+	}
+
+	columns := make(map[string]bool, len(queryFields))
+	for _, field := range queryFields {
+		columns[strcase.ToSnake(field)] = true
+	}
+
+	value := reflect.ValueOf(record).Elem()
+	for index := 0; index < value.NumField(); index++ {
+		column := strings.Split(value.Type().Field(index).Tag.Get("db"), ",")[0]
+		if !columns[column] {
+			value.Field(index).Set(reflect.Zero(value.Field(index).Type()))
+		}
+	}
+}
+
+// page returns the bounds of the page within the given number of records
+func page(length int, offset int, limit int) (int, int) {
+	if offset > length {
+		offset = length
+	}
+	end := offset + limit
+	if limit < 0 || end > length {
+		end = length
+	}
+	return offset, end
+}
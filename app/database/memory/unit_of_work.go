@@ -0,0 +1,30 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+)
+
+type unitOfWork struct {
+	store *Store
+}
+
+func NewUnitOfWork(store *Store) *unitOfWork {
+	return &unitOfWork{store: store}
+}
+
+// Execute runs the work on a copy of the records while holding the lock of the store, the copy replaces the records
+// only if the work returns no error, so failed and panicking work leaves the store as it was. Work never conflicts
+// with concurrent changes since those wait for the lock, which is also why the work must not use any repositories
+// but the ones it is given.
+func (unitOfWork *unitOfWork) Execute(work func(repositories *repository.Repositories) error) error {
+	store := unitOfWork.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	transaction := &Store{records: store.copyRecords()}
+	if err := work(NewRepositories(transaction)); err != nil {
+		return err
+	}
+	store.records = transaction.records
+	return nil
+}
@@ -0,0 +1,182 @@
+package memory
+
+import (
+	"bytes"
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type userRepository struct {
+	store *Store
+}
+
+func NewUserRepository(store *Store) *userRepository {
+	return &userRepository{store: store}
+}
+
+func (repository *userRepository) InsertNewUser(user *model.User) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existingUser := range store.users {
+		if existingUser.Email == user.Email {
+			return nil, errDuplicate
+		}
+	}
+
+	newUser := *user
+	newUser.Id = store.nextId("user")
+	if newUser.Role == "" {
+		newUser.Role = model.UserRoleUser
+	}
+	store.users = append(store.users, newUser)
+	return db.NewInsertResult(int64(newUser.Id)), nil
+}
+
+func (repository *userRepository) FetchByEmail(ctx context.Context, user *model.User, email string, queryFields []string) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingUser := range store.users {
+		if existingUser.Email == email {
+			*user = existingUser
+			selectFields(user, queryFields)
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (repository *userRepository) FetchById(user *model.User, id uint64, queryFields []string) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	index := store.userIndex(id)
+	if index < 0 {
+		return errNotFound
+	}
+	*user = store.users[index]
+	selectFields(user, queryFields)
+	return nil
+}
+
+func (repository *userRepository) FetchMasterPasswordByUserId(user *model.User, id uint64) error {
+	return repository.FetchById(user, id, []string{"password"})
+}
+
+func (repository *userRepository) UpdateEmailVerifiedById(emailVerified bool, id uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if index := store.userIndex(id); index >= 0 {
+		store.users[index].EmailVerified = emailVerified
+	}
+	return nil
+}
+
+func (repository *userRepository) InsertEmailVerificationToken(token *model.EmailVerificationToken) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existingToken := range store.emailVerificationTokens {
+		if bytes.Equal(existingToken.TokenHash, token.TokenHash) {
+			return nil, errDuplicate
+		}
+	}
+
+	newToken := *token
+	newToken.Id = store.nextId("email_verification_token")
+	store.emailVerificationTokens = append(store.emailVerificationTokens, newToken)
+	return db.NewInsertResult(int64(newToken.Id)), nil
+}
+
+// ConsumeEmailVerificationToken fetches an unexpired token and deletes it, so every token can be used only once
+func (repository *userRepository) ConsumeEmailVerificationToken(token *model.EmailVerificationToken, tokenHash []byte) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now().UTC()
+	for index, existingToken := range store.emailVerificationTokens {
+		if bytes.Equal(existingToken.TokenHash, tokenHash) && existingToken.ExpiresAt.After(now) {
+			*token = existingToken
+			store.emailVerificationTokens = append(store.emailVerificationTokens[:index], store.emailVerificationTokens[index+1:]...)
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (repository *userRepository) UpdateRecoveryWrappedKeyById(recoveryWrappedKey []byte, id uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if index := store.userIndex(id); index >= 0 {
+		store.users[index].RecoveryWrappedKey = recoveryWrappedKey
+	}
+	return nil
+}
+
+// UpdateMasterPasswordById replaces the master password together with everything encrypted or wrapped with it at once,
+// users without a key pair pass a nil private key
+func (repository *userRepository) UpdateMasterPasswordById(
+	masterPassword []byte,
+	recoveryWrappedKey []byte,
+	wrappedPrivateKey []byte,
+	reencryptedPasswords model.Passwords,
+	rewrappedAttachments model.Attachments,
+	id uint64,
+) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if index := store.userIndex(id); index >= 0 {
+		store.users[index].Password = masterPassword
+		store.users[index].RecoveryWrappedKey = recoveryWrappedKey
+	}
+
+	if wrappedPrivateKey != nil {
+		for index := range store.keyPairs {
+			if store.keyPairs[index].UserId == id {
+				store.keyPairs[index].WrappedPrivateKey = wrappedPrivateKey
+			}
+		}
+	}
+
+	for _, password := range reencryptedPasswords {
+		if index := store.passwordIndex(password.Id); index >= 0 && store.passwords[index].UserId == id {
+			store.passwords[index].Password = password.Password
+			store.passwords[index].TotpUri = password.TotpUri
+		}
+	}
+
+	for _, attachment := range rewrappedAttachments {
+		for index := range store.attachments {
+			if store.attachments[index].Id == attachment.Id && store.attachments[index].UserId == id {
+				store.attachments[index].WrappedDataKey = attachment.WrappedDataKey
+			}
+		}
+	}
+
+	return nil
+}
+
+func (repository *userRepository) InsertAccountRecovery(recovery *model.AccountRecovery) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	newRecovery := *recovery
+	newRecovery.Id = store.nextId("account_recovery")
+	store.accountRecoveries = append(store.accountRecoveries, newRecovery)
+	return db.NewInsertResult(int64(newRecovery.Id)), nil
+}
@@ -0,0 +1,111 @@
+package memory
+
+import (
+	"bytes"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type webauthnRepository struct {
+	store *Store
+}
+
+func NewWebauthnRepository(store *Store) *webauthnRepository {
+	return &webauthnRepository{store: store}
+}
+
+func (repository *webauthnRepository) InsertNewCredential(credential *model.WebauthnCredential) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existingCredential := range store.webauthnCredentials {
+		if bytes.Equal(existingCredential.CredentialId, credential.CredentialId) {
+			return nil, errDuplicate
+		}
+	}
+
+	newCredential := *credential
+	newCredential.Id = store.nextId("webauthn_credential")
+	if newCredential.CreatedAt.IsZero() {
+		newCredential.CreatedAt = time.Now().UTC()
+	}
+	store.webauthnCredentials = append(store.webauthnCredentials, newCredential)
+	return db.NewInsertResult(int64(newCredential.Id)), nil
+}
+
+func (repository *webauthnRepository) FetchCredentialsByUserId(credentials *model.WebauthnCredentials, userId uint64) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*credentials = model.WebauthnCredentials{}
+	for _, credential := range store.webauthnCredentials {
+		if credential.UserId == userId {
+			*credentials = append(*credentials, credential)
+		}
+	}
+	return nil
+}
+
+func (repository *webauthnRepository) FetchCredentialByCredentialId(credential *model.WebauthnCredential, credentialId []byte) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	for _, existingCredential := range store.webauthnCredentials {
+		if bytes.Equal(existingCredential.CredentialId, credentialId) {
+			*credential = existingCredential
+			return nil
+		}
+	}
+	return errNotFound
+}
+
+func (repository *webauthnRepository) UpdateSignCountById(signCount uint32, id uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index := range store.webauthnCredentials {
+		if store.webauthnCredentials[index].Id == id {
+			store.webauthnCredentials[index].SignCount = signCount
+		}
+	}
+	return nil
+}
+
+func (repository *webauthnRepository) InsertNewChallenge(challenge *model.WebauthnChallenge) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, existingChallenge := range store.webauthnChallenges {
+		if bytes.Equal(existingChallenge.Challenge, challenge.Challenge) {
+			return nil, errDuplicate
+		}
+	}
+
+	newChallenge := *challenge
+	newChallenge.Id = store.nextId("webauthn_challenge")
+	store.webauthnChallenges = append(store.webauthnChallenges, newChallenge)
+	return db.NewInsertResult(int64(newChallenge.Id)), nil
+}
+
+// ConsumeChallenge fetches an unexpired challenge and deletes it, so every challenge can be used for a single ceremony only
+func (repository *webauthnRepository) ConsumeChallenge(challenge *model.WebauthnChallenge, value []byte, ceremony string) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	now := time.Now().UTC()
+	for index, existingChallenge := range store.webauthnChallenges {
+		if bytes.Equal(existingChallenge.Challenge, value) && existingChallenge.Ceremony == ceremony && existingChallenge.ExpiresAt.After(now) {
+			*challenge = existingChallenge
+			store.webauthnChallenges = append(store.webauthnChallenges[:index], store.webauthnChallenges[index+1:]...)
+			return nil
+		}
+	}
+	return errNotFound
+}
@@ -0,0 +1,232 @@
+package memory
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"sort"
+	"time"
+)
+
+type webhookRepository struct {
+	store *Store
+}
+
+func NewWebhookRepository(store *Store) *webhookRepository {
+	return &webhookRepository{store: store}
+}
+
+func (repository *webhookRepository) InsertNewWebhook(webhook *model.Webhook) (db.InsertResult, error) {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	newWebhook := *webhook
+	newWebhook.Id = store.nextId("webhook")
+	if newWebhook.CreatedAt.IsZero() {
+		newWebhook.CreatedAt = time.Now().UTC()
+	}
+	store.webhooks = append(store.webhooks, newWebhook)
+	return db.NewInsertResult(int64(newWebhook.Id)), nil
+}
+
+func (repository *webhookRepository) FetchWebhooks(webhooks *model.Webhooks) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*webhooks = append(model.Webhooks{}, store.webhooks...)
+	return nil
+}
+
+func (repository *webhookRepository) FetchWebhooksByEventType(webhooks *model.Webhooks, eventType string) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	*webhooks = model.Webhooks{}
+	for _, webhook := range store.webhooks {
+		for _, webhookEventType := range webhook.EventTypes {
+			if webhookEventType == eventType {
+				*webhooks = append(*webhooks, webhook)
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// DeleteWebhookById deletes the webhook together with its delivery history and dead letters
+func (repository *webhookRepository) DeleteWebhookById(id uint64) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	webhooks := store.webhooks[:0]
+	for _, webhook := range store.webhooks {
+		if webhook.Id != id {
+			webhooks = append(webhooks, webhook)
+		}
+	}
+	if len(webhooks) == len(store.webhooks) {
+		return errNotFound
+	}
+	store.webhooks = webhooks
+
+	deliveries := store.webhookDeliveries[:0]
+	for _, delivery := range store.webhookDeliveries {
+		if delivery.WebhookId != id {
+			deliveries = append(deliveries, delivery)
+		}
+	}
+	store.webhookDeliveries = deliveries
+
+	deadLetters := store.webhookDeadLetters[:0]
+	for _, deadLetter := range store.webhookDeadLetters {
+		if deadLetter.WebhookId != id {
+			deadLetters = append(deadLetters, deadLetter)
+		}
+	}
+	store.webhookDeadLetters = deadLetters
+	return nil
+}
+
+func (repository *webhookRepository) InsertNewDeliveries(deliveries model.WebhookDeliveries) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, delivery := range deliveries {
+		delivery.Id = store.nextId("webhook_delivery")
+		if delivery.CreatedAt.IsZero() {
+			delivery.CreatedAt = time.Now().UTC()
+		}
+		store.webhookDeliveries = append(store.webhookDeliveries, delivery)
+	}
+	return nil
+}
+
+func (repository *webhookRepository) FetchDueDeliveries(deliveries *model.WebhookDeliveries, now time.Time, limit int) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	dueDeliveries := model.WebhookDeliveries{}
+	for _, delivery := range store.webhookDeliveries {
+		if delivery.Status == model.WebhookDeliveryPending && !delivery.NextAttemptAt.After(now) {
+			dueDeliveries = append(dueDeliveries, delivery)
+		}
+	}
+	sort.SliceStable(dueDeliveries, func(i, j int) bool { return dueDeliveries[i].NextAttemptAt.Before(dueDeliveries[j].NextAttemptAt) })
+
+	_, end := page(len(dueDeliveries), 0, limit)
+	*deliveries = dueDeliveries[:end]
+	return nil
+}
+
+func (repository *webhookRepository) FetchDeliveriesByWebhookId(
+	deliveries *model.WebhookDeliveries, webhookId uint64, offset int, limit int,
+) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	webhookDeliveries := model.WebhookDeliveries{}
+	for index := len(store.webhookDeliveries) - 1; index >= 0; index-- {
+		if store.webhookDeliveries[index].WebhookId == webhookId {
+			webhookDeliveries = append(webhookDeliveries, store.webhookDeliveries[index])
+		}
+	}
+
+	start, end := page(len(webhookDeliveries), offset, limit)
+	*deliveries = webhookDeliveries[start:end]
+	return nil
+}
+
+func (repository *webhookRepository) UpdateDeliveryAttempt(delivery *model.WebhookDelivery) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	return store.updateDeliveryAttempt(delivery)
+}
+
+// MoveDeliveryToDeadLetter stores the last attempt of a delivery that exhausted its attempts and parks it as a dead letter
+func (repository *webhookRepository) MoveDeliveryToDeadLetter(delivery *model.WebhookDelivery) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for _, deadLetter := range store.webhookDeadLetters {
+		if deadLetter.DeliveryId == delivery.Id {
+			return errDuplicate
+		}
+	}
+
+	delivery.Status = model.WebhookDeliveryDead
+	if err := store.updateDeliveryAttempt(delivery); err != nil {
+		return err
+	}
+	store.webhookDeadLetters = append(store.webhookDeadLetters, model.WebhookDeadLetter{
+		Id:         store.nextId("webhook_dead_letter"),
+		DeliveryId: delivery.Id,
+		WebhookId:  delivery.WebhookId,
+		EventType:  delivery.EventType,
+		LastError:  delivery.LastError,
+		FailedAt:   time.Now().UTC(),
+	})
+	return nil
+}
+
+func (repository *webhookRepository) FetchDeadLetters(deadLetters *model.WebhookDeadLetters, offset int, limit int) error {
+	store := repository.store
+	store.mutex.RLock()
+	defer store.mutex.RUnlock()
+
+	latestDeadLetters := model.WebhookDeadLetters{}
+	for index := len(store.webhookDeadLetters) - 1; index >= 0; index-- {
+		latestDeadLetters = append(latestDeadLetters, store.webhookDeadLetters[index])
+	}
+
+	start, end := page(len(latestDeadLetters), offset, limit)
+	*deadLetters = latestDeadLetters[start:end]
+	return nil
+}
+
+// RedeliverDeadLetterById removes the dead letter and schedules its delivery again with a fresh set of attempts
+func (repository *webhookRepository) RedeliverDeadLetterById(id uint64, now time.Time) error {
+	store := repository.store
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	for index, deadLetter := range store.webhookDeadLetters {
+		if deadLetter.Id != id {
+			continue
+		}
+		for deliveryIndex := range store.webhookDeliveries {
+			delivery := &store.webhookDeliveries[deliveryIndex]
+			if delivery.Id == deadLetter.DeliveryId {
+				store.webhookDeadLetters = append(store.webhookDeadLetters[:index], store.webhookDeadLetters[index+1:]...)
+				delivery.Status = model.WebhookDeliveryPending
+				delivery.Attempts = 0
+				delivery.NextAttemptAt = now
+				return nil
+			}
+		}
+	}
+	return errNotFound
+}
+
+func (store *Store) updateDeliveryAttempt(delivery *model.WebhookDelivery) error {
+	for index := range store.webhookDeliveries {
+		storedDelivery := &store.webhookDeliveries[index]
+		if storedDelivery.Id == delivery.Id {
+			storedDelivery.Status = delivery.Status
+			storedDelivery.Attempts = delivery.Attempts
+			storedDelivery.NextAttemptAt = delivery.NextAttemptAt
+			storedDelivery.ResponseStatus = delivery.ResponseStatus
+			storedDelivery.LastError = delivery.LastError
+			return nil
+		}
+	}
+	return errNotFound
+}
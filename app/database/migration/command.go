@@ -0,0 +1,143 @@
+package migration
+
+import (
+	"errors"
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/golang-migrate/migrate/v4"
+	"io"
+	"os"
+	"strconv"
+)
+
+const commandUsage = "usage: migrate up | migrate down [steps] | migrate status | migrate version"
+
+// RunCommand runs a migrate subcommand against the datasource and returns the process exit code
+func RunCommand(arguments []string, datasourceConfig *config.Datasource, output io.Writer) int {
+	if len(arguments) == 0 {
+		fmt.Fprintln(output, commandUsage)
+		return 2
+	}
+
+	var run func(migrator *migrate.Migrate, output io.Writer) error
+	switch arguments[0] {
+	case "up":
+		run = runUp
+	case "down":
+		steps, err := downSteps(arguments[1:])
+		if err != nil {
+			fmt.Fprintln(output, commandUsage)
+			return 2
+		}
+		run = func(migrator *migrate.Migrate, output io.Writer) error {
+			return runDown(migrator, steps, output)
+		}
+	case "status":
+		run = func(migrator *migrate.Migrate, output io.Writer) error {
+			return runStatus(migrator, datasourceConfig.Driver, output)
+		}
+	case "version":
+		run = runVersion
+	default:
+		fmt.Fprintln(output, commandUsage)
+		return 2
+	}
+
+	migrator, err := New(datasourceConfig)
+	if err != nil {
+		fmt.Fprintf(output, "Could not set up the migrations: %s\n", err)
+		return 1
+	}
+	defer migrator.Close()
+
+	if err = run(migrator, output); err != nil {
+		fmt.Fprintf(output, "Migration failed: %s\n", err)
+		return 1
+	}
+	return 0
+}
+
+// downSteps parses the number of migrations to revert, one by default
+func downSteps(arguments []string) (int, error) {
+	if len(arguments) == 0 {
+		return 1, nil
+	}
+	steps, err := strconv.Atoi(arguments[0])
+	if err != nil || steps < 1 || len(arguments) > 1 {
+		return 0, errors.New("invalid number of steps")
+	}
+	return steps, nil
+}
+
+func runUp(migrator *migrate.Migrate, output io.Writer) error {
+	err := migrator.Up()
+	if err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+	return runVersion(migrator, output)
+}
+
+// runDown reverts the given number of migrations, or all of them if there are less applied
+func runDown(migrator *migrate.Migrate, steps int, output io.Writer) error {
+	var shortLimit migrate.ErrShortLimit
+	err := migrator.Steps(-steps)
+	if err != nil && !errors.Is(err, os.ErrNotExist) && !errors.As(err, &shortLimit) {
+		return err
+	}
+	return runVersion(migrator, output)
+}
+
+// runStatus lists every migration of the driver and whether it has been applied
+func runStatus(migrator *migrate.Migrate, driver string, output io.Writer) error {
+	if err := runVersion(migrator, output); err != nil {
+		return err
+	}
+	appliedVersion, _, err := migrator.Version()
+	if err != nil && err != migrate.ErrNilVersion {
+		return err
+	}
+
+	migrationSource, err := newSource(driver)
+	if err != nil {
+		return err
+	}
+	defer migrationSource.Close()
+
+	version, err := migrationSource.First()
+	for err == nil {
+		reader, name, readErr := migrationSource.ReadUp(version)
+		if readErr != nil {
+			return readErr
+		}
+		reader.Close()
+
+		status := "pending"
+		if version <= appliedVersion {
+			status = "applied"
+		}
+		fmt.Fprintf(output, "%06d %-7s %s\n", version, status, name)
+		version, err = migrationSource.Next(version)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func runVersion(migrator *migrate.Migrate, output io.Writer) error {
+	version, dirty, err := migrator.Version()
+	if err == migrate.ErrNilVersion {
+		fmt.Fprintln(output, "No migrations applied")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if dirty {
+		fmt.Fprintf(output, "Database is at version %d, which failed to apply and has to be fixed by hand\n", version)
+		return nil
+	}
+	fmt.Fprintf(output, "Database is at version %d\n", version)
+	return nil
+}
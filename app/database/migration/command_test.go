@@ -0,0 +1,77 @@
+package migration
+
+import (
+	"bytes"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/stretchr/testify/assert"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// RunCommand up should apply the pending migrations and report the version
+func TestRunUpCommand(t *testing.T) {
+	datasourceConfig := testDatasourceConfig(t)
+	output := &bytes.Buffer{}
+
+	exitCode := RunCommand([]string{"up"}, datasourceConfig, output)
+	assert.Equal(t, exitCode, 0)
+	assert.Contains(t, output.String(), "Database is at version 16")
+}
+
+// RunCommand down should revert one migration by default and all of them at most
+func TestRunDownCommand(t *testing.T) {
+	datasourceConfig := testDatasourceConfig(t)
+	RunCommand([]string{"up"}, datasourceConfig, &bytes.Buffer{})
+
+	output := &bytes.Buffer{}
+	exitCode := RunCommand([]string{"down"}, datasourceConfig, output)
+	assert.Equal(t, exitCode, 0)
+	assert.Contains(t, output.String(), "Database is at version 15")
+
+	output.Reset()
+	exitCode = RunCommand([]string{"down", "100"}, datasourceConfig, output)
+	assert.Equal(t, exitCode, 0)
+	assert.Contains(t, output.String(), "No migrations applied")
+}
+
+// RunCommand status should list the applied and pending migrations
+func TestRunStatusCommand(t *testing.T) {
+	datasourceConfig := testDatasourceConfig(t)
+	RunCommand([]string{"up"}, datasourceConfig, &bytes.Buffer{})
+	RunCommand([]string{"down", "2"}, datasourceConfig, &bytes.Buffer{})
+
+	output := &bytes.Buffer{}
+	exitCode := RunCommand([]string{"status"}, datasourceConfig, output)
+	assert.Equal(t, exitCode, 0)
+	assert.Contains(t, output.String(), "Database is at version 14")
+	assert.Contains(t, output.String(), "000001 applied init_schema")
+	assert.Contains(t, output.String(), "000016 pending password_totp")
+	assert.Equal(t, strings.Count(output.String(), "pending"), 2)
+}
+
+// RunCommand version should report databases without any migrations
+func TestRunVersionCommand(t *testing.T) {
+	output := &bytes.Buffer{}
+
+	exitCode := RunCommand([]string{"version"}, testDatasourceConfig(t), output)
+	assert.Equal(t, exitCode, 0)
+	assert.Contains(t, output.String(), "No migrations applied")
+}
+
+// RunCommand should print the usage for unknown subcommands and invalid steps, and fail for unsupported drivers
+func TestRunCommandWithInvalidArguments(t *testing.T) {
+	output := &bytes.Buffer{}
+	assert.Equal(t, RunCommand([]string{}, testDatasourceConfig(t), output), 2)
+	assert.Equal(t, RunCommand([]string{"redo"}, testDatasourceConfig(t), output), 2)
+	assert.Equal(t, RunCommand([]string{"down", "zero"}, testDatasourceConfig(t), output), 2)
+	assert.Contains(t, output.String(), commandUsage)
+
+	output.Reset()
+	assert.Equal(t, RunCommand([]string{"up"}, &config.Datasource{Driver: "oracle"}, output), 1)
+	assert.Contains(t, output.String(), "Could not set up the migrations")
+}
+
+func testDatasourceConfig(t *testing.T) *config.Datasource {
+	return &config.Datasource{Driver: driverSqlite, Database: filepath.Join(t.TempDir(), "gokeeper.db")}
+}
@@ -0,0 +1,104 @@
+package migration
+
+import (
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	schema "github.com/KristijanFaust/gokeeper/support/database"
+	"github.com/go-sql-driver/mysql"
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/mysql"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/httpfs"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+// The datasource drivers, the database package can't be imported since its tests migrate their databases
+const (
+	driverPostgres = "postgres"
+	driverSqlite   = "sqlite"
+	driverMysql    = "mysql"
+)
+
+// logger logs the migrations as they get applied
+type logger struct{}
+
+func (logger) Printf(format string, arguments ...interface{}) {
+	log.Printf("Migration: "+format, arguments...)
+}
+
+func (logger) Verbose() bool {
+	return false
+}
+
+// New creates the migrator of the datasource from the migrations embedded for its driver. Postgres and MySQL databases
+// are migrated under an advisory lock, so instances starting at the same time wait for each other instead of applying
+// the same migration twice. SQLite databases are meant for a single instance and have no such lock.
+func New(datasourceConfig *config.Datasource) (*migrate.Migrate, error) {
+	databaseUrl, err := databaseUrl(datasourceConfig)
+	if err != nil {
+		return nil, err
+	}
+	migrationSource, err := newSource(datasourceConfig.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	migrator, err := migrate.NewWithSourceInstance("httpfs", migrationSource, databaseUrl)
+	if err != nil {
+		return nil, err
+	}
+	migrator.Log = logger{}
+	return migrator, nil
+}
+
+// Up applies the pending migrations of the datasource
+func Up(datasourceConfig *config.Datasource) error {
+	migrator, err := New(datasourceConfig)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err = migrator.Up(); err != nil && err != migrate.ErrNoChange {
+		return err
+	}
+	return nil
+}
+
+func newSource(driver string) (source.Driver, error) {
+	if driver == "" {
+		driver = driverPostgres
+	}
+	return httpfs.New(http.FS(schema.Migrations), driver+"/migration")
+}
+
+// databaseUrl returns the url golang-migrate connects to the datasource with, connecting the same way the application does
+func databaseUrl(datasourceConfig *config.Datasource) (string, error) {
+	switch datasourceConfig.Driver {
+	case "", driverPostgres:
+		databaseUrl := url.URL{
+			Scheme:   "postgres",
+			User:     url.UserPassword(datasourceConfig.User, datasourceConfig.Password),
+			Host:     datasourceConfig.Host,
+			Path:     "/" + datasourceConfig.Database,
+			RawQuery: "sslmode=disable",
+		}
+		return databaseUrl.String(), nil
+	case driverSqlite:
+		return "sqlite3://" + datasourceConfig.Database, nil
+	case driverMysql:
+		// golang-migrate unescapes the credentials of mysql urls
+		mysqlConfig := mysql.NewConfig()
+		mysqlConfig.User = url.QueryEscape(datasourceConfig.User)
+		mysqlConfig.Passwd = url.QueryEscape(datasourceConfig.Password)
+		mysqlConfig.Net = "tcp"
+		mysqlConfig.Addr = datasourceConfig.Host
+		mysqlConfig.DBName = datasourceConfig.Database
+		return "mysql://" + mysqlConfig.FormatDSN(), nil
+	}
+	return "", fmt.Errorf("unsupported datasource driver: %s", datasourceConfig.Driver)
+}
@@ -0,0 +1,11 @@
+package model
+
+import "time"
+
+type AccountRecovery struct {
+	Id          uint64    `db:"id,omitempty"`
+	UserId      uint64    `db:"user_id"`
+	UserAgent   string    `db:"user_agent"`
+	IpAddress   string    `db:"ip_address"`
+	RecoveredAt time.Time `db:"recovered_at"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+const (
+	AdminActionDisableUser  = "disable_user"
+	AdminActionEnableUser   = "enable_user"
+	AdminActionForceSignOut = "force_sign_out"
+	AdminActionChangeRole   = "change_role"
+)
+
+type AdminAuditEntry struct {
+	Id           uint64    `db:"id,omitempty"`
+	AdminId      uint64    `db:"admin_id"`
+	Action       string    `db:"action"`
+	TargetUserId uint64    `db:"target_user_id"`
+	Details      *string   `db:"details,omitempty"`
+	CreatedAt    time.Time `db:"created_at"`
+}
+
+type AdminAuditEntryDetails struct {
+	AdminAuditEntry `db:",inline"`
+	AdminEmail      string `db:"admin_email"`
+	TargetEmail     string `db:"target_email"`
+}
+
+type AdminAuditEntriesDetails []AdminAuditEntryDetails
+
+// UsageStatistics only counts records, it never carries any vault contents
+type UsageStatistics struct {
+	Users          int `db:"users"`
+	DisabledUsers  int `db:"disabled_users"`
+	Admins         int `db:"admins"`
+	Entries        int `db:"entries"`
+	Organizations  int `db:"organizations"`
+	ActiveSessions int `db:"active_sessions"`
+	ActiveSends    int `db:"active_sends"`
+}
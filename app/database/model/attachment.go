@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+type Attachment struct {
+	Id             uint64    `db:"id,omitempty"`
+	PasswordId     uint64    `db:"password_id"`
+	UserId         uint64    `db:"user_id"`
+	Name           string    `db:"name"`
+	ContentType    string    `db:"content_type"`
+	Size           int64     `db:"size"`
+	BlobKey        string    `db:"blob_key"`
+	WrappedDataKey []byte    `db:"wrapped_data_key"`
+	CreatedAt      time.Time `db:"created_at"`
+}
+
+type Attachments []Attachment
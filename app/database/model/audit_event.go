@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+const (
+	AuditEventSignUp                 = "sign_up"
+	AuditEventSignIn                 = "sign_in"
+	AuditEventSignInFailed           = "sign_in_failed"
+	AuditEventAuthenticationRejected = "authentication_rejected"
+	AuditEventPasswordCreated        = "password_created"
+	AuditEventPasswordsViewed        = "passwords_viewed"
+	AuditEventPasswordUpdated        = "password_updated"
+	AuditEventPasswordDeleted        = "password_deleted"
+	AuditEventUserDisabled           = "user_disabled"
+	AuditEventUserEnabled            = "user_enabled"
+	AuditEventUserSignedOut          = "user_signed_out"
+	AuditEventUserRoleChanged        = "user_role_changed"
+)
+
+type AuditEvent struct {
+	Id           uint64    `db:"id,omitempty"`
+	Sequence     int64     `db:"sequence"`
+	UserId       *uint64   `db:"user_id,omitempty"`
+	Type         string    `db:"type"`
+	IpAddress    string    `db:"ip_address"`
+	UserAgent    string    `db:"user_agent"`
+	Details      *string   `db:"details,omitempty"`
+	CreatedAt    time.Time `db:"created_at"`
+	PreviousHash []byte    `db:"previous_hash"`
+	Hash         []byte    `db:"hash"`
+}
+
+type AuditEvents []AuditEvent
+
+type AuditEventFilter struct {
+	UserId *uint64
+	Types  []string
+	From   *time.Time
+	To     *time.Time
+}
@@ -0,0 +1,51 @@
+package model
+
+import "time"
+
+const (
+	EmergencyAccessView     = "view"
+	EmergencyAccessTakeover = "takeover"
+)
+
+const (
+	EmergencyAccessGrantInvited  = "invited"
+	EmergencyAccessGrantAccepted = "accepted"
+	EmergencyAccessGrantRevoked  = "revoked"
+)
+
+const (
+	EmergencyAccessRequestPending  = "pending"
+	EmergencyAccessRequestApproved = "approved"
+	EmergencyAccessRequestRejected = "rejected"
+)
+
+type EmergencyAccessGrant struct {
+	Id             uint64    `db:"id,omitempty"`
+	GrantorId      uint64    `db:"grantor_id"`
+	GranteeId      uint64    `db:"grantee_id"`
+	AccessType     string    `db:"access_type"`
+	WaitTimeInDays int       `db:"wait_time_in_days"`
+	Status         string    `db:"status"`
+	CreatedAt      time.Time `db:"created_at,omitempty"`
+}
+
+// EmergencyAccessGrantDetails is a grant joined with the e-mail addresses of both parties
+type EmergencyAccessGrantDetails struct {
+	EmergencyAccessGrant `db:",inline"`
+	GrantorEmail         string `db:"grantor_email"`
+	GranteeEmail         string `db:"grantee_email"`
+}
+
+type EmergencyAccessGrantsDetails []EmergencyAccessGrantDetails
+
+type EmergencyAccessRequest struct {
+	Id             uint64     `db:"id,omitempty"`
+	GrantId        uint64     `db:"grant_id"`
+	Status         string     `db:"status"`
+	RequestedAt    time.Time  `db:"requested_at"`
+	AvailableAt    time.Time  `db:"available_at"`
+	ResolvedAt     *time.Time `db:"resolved_at,omitempty"`
+	SealedVaultKey []byte     `db:"sealed_vault_key,omitempty"`
+}
+
+type EmergencyAccessRequests []EmergencyAccessRequest
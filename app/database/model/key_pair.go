@@ -0,0 +1,10 @@
+package model
+
+import "time"
+
+type KeyPair struct {
+	UserId            uint64    `db:"user_id"`
+	PublicKey         []byte    `db:"public_key"`
+	WrappedPrivateKey []byte    `db:"wrapped_private_key"`
+	CreatedAt         time.Time `db:"created_at,omitempty"`
+}
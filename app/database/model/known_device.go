@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+type KnownDevice struct {
+	Id         uint64    `db:"id,omitempty"`
+	UserId     uint64    `db:"user_id"`
+	UserAgent  string    `db:"user_agent"`
+	IpAddress  string    `db:"ip_address"`
+	LastSeenAt time.Time `db:"last_seen_at"`
+}
+
+type KnownDevices []KnownDevice
@@ -0,0 +1,42 @@
+package model
+
+import "time"
+
+const (
+	OrganizationOwner  = "owner"
+	OrganizationAdmin  = "admin"
+	OrganizationMember = "member"
+)
+
+const (
+	OrganizationMemberInvited  = "invited"
+	OrganizationMemberAccepted = "accepted"
+)
+
+type Organization struct {
+	Id         uint64    `db:"id,omitempty"`
+	Name       string    `db:"name"`
+	KeyVersion int       `db:"key_version,omitempty"`
+	CreatedAt  time.Time `db:"created_at,omitempty"`
+}
+
+type OrganizationMembership struct {
+	Id                    uint64    `db:"id,omitempty"`
+	OrganizationId        uint64    `db:"organization_id"`
+	UserId                uint64    `db:"user_id"`
+	Role                  string    `db:"role"`
+	Status                string    `db:"status"`
+	SealedOrganizationKey []byte    `db:"sealed_organization_key"`
+	CreatedAt             time.Time `db:"created_at,omitempty"`
+}
+
+type OrganizationMemberships []OrganizationMembership
+
+type OrganizationMembershipDetails struct {
+	OrganizationMembership `db:",inline"`
+	OrganizationName       string `db:"organization_name"`
+	OrganizationKeyVersion int    `db:"organization_key_version"`
+	Email                  string `db:"email"`
+}
+
+type OrganizationMembershipsDetails []OrganizationMembershipDetails
@@ -1,10 +1,33 @@
 package model
 
+import "time"
+
 type Password struct {
-	Id       uint64 `db:"id,omitempty"`
-	UserId   uint64 `db:"user_id"`
-	Name     string `db:"name"`
-	Password []byte `db:"password"`
+	Id             uint64  `db:"id,omitempty"`
+	UserId         uint64  `db:"user_id"`
+	OrganizationId *uint64 `db:"organization_id,omitempty"`
+	Name           string  `db:"name"`
+	Password       []byte  `db:"password"`
+	TotpUri        []byte  `db:"totp_uri"`
+	Revision       int64   `db:"revision"`
+	Version        int64   `db:"version"`
 }
 
 type Passwords []Password
+
+// PasswordTombstone marks an entry that left the user's personal vault at the given revision
+type PasswordTombstone struct {
+	PasswordId uint64    `db:"password_id"`
+	UserId     uint64    `db:"user_id"`
+	Revision   int64     `db:"revision"`
+	DeletedAt  time.Time `db:"deleted_at,omitempty"`
+}
+
+type PasswordTombstones []PasswordTombstone
+
+// VaultChanges are the changes of a personal vault after a revision, up to the vault's current revision
+type VaultChanges struct {
+	Revision   int64
+	Passwords  Passwords
+	Tombstones PasswordTombstones
+}
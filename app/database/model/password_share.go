@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+const (
+	SharePermissionRead  = "read"
+	SharePermissionWrite = "write"
+)
+
+type PasswordShare struct {
+	Id             uint64    `db:"id,omitempty"`
+	PasswordId     uint64    `db:"password_id"`
+	RecipientId    uint64    `db:"recipient_id"`
+	Permission     string    `db:"permission"`
+	SealedPassword []byte    `db:"sealed_password"`
+	CreatedAt      time.Time `db:"created_at,omitempty"`
+}
+
+type PasswordShareDetails struct {
+	PasswordShare  `db:",inline"`
+	OwnerId        uint64 `db:"owner_id"`
+	OwnerEmail     string `db:"owner_email"`
+	RecipientEmail string `db:"recipient_email"`
+}
+
+type PasswordSharesDetails []PasswordShareDetails
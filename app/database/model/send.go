@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type Send struct {
+	Id              uint64    `db:"id,omitempty"`
+	UserId          uint64    `db:"user_id"`
+	Name            string    `db:"name"`
+	TokenHash       []byte    `db:"token_hash"`
+	EncryptedSecret []byte    `db:"encrypted_secret"`
+	HasPassphrase   bool      `db:"has_passphrase"`
+	MaxViews        int       `db:"max_views"`
+	ViewCount       int       `db:"view_count"`
+	ExpiresAt       time.Time `db:"expires_at"`
+	CreatedAt       time.Time `db:"created_at"`
+}
+
+type Sends []Send
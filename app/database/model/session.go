@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+const (
+	SessionPendingApproval = "pending_approval"
+	SessionApproved        = "approved"
+	SessionActive          = "active"
+	SessionRevoked         = "revoked"
+)
+
+type Session struct {
+	Id         uint64     `db:"id,omitempty"`
+	UserId     uint64     `db:"user_id"`
+	DeviceName string     `db:"device_name"`
+	UserAgent  string     `db:"user_agent"`
+	IpAddress  string     `db:"ip_address"`
+	Status     string     `db:"status"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastSeenAt time.Time  `db:"last_seen_at"`
+	RevokedAt  *time.Time `db:"revoked_at,omitempty"`
+}
+
+type Sessions []Session
@@ -1,8 +1,28 @@
 package model
 
+import "time"
+
+const (
+	UserRoleUser  = "user"
+	UserRoleAdmin = "admin"
+)
+
 type User struct {
-	Id       uint64 `db:"id,omitempty"`
-	Email    string `db:"email"`
-	Username string `db:"username"`
-	Password []byte `db:"password"`
+	Id                 uint64 `db:"id,omitempty"`
+	Email              string `db:"email"`
+	Username           string `db:"username"`
+	Password           []byte `db:"password"`
+	EmailVerified      bool   `db:"email_verified"`
+	RecoveryWrappedKey []byte `db:"recovery_wrapped_key,omitempty"`
+	Role               string `db:"role,omitempty"`
+	Disabled           bool   `db:"disabled"`
+}
+
+type Users []User
+
+type EmailVerificationToken struct {
+	Id        uint64    `db:"id,omitempty"`
+	UserId    uint64    `db:"user_id"`
+	TokenHash []byte    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
 }
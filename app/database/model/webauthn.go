@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+type WebauthnCredential struct {
+	Id            uint64    `db:"id,omitempty"`
+	UserId        uint64    `db:"user_id"`
+	CredentialId  []byte    `db:"credential_id"`
+	PublicKey     []byte    `db:"public_key"`
+	SignCount     uint32    `db:"sign_count"`
+	Name          string    `db:"name"`
+	PrfWrappedKey []byte    `db:"prf_wrapped_key,omitempty"`
+	CreatedAt     time.Time `db:"created_at,omitempty"`
+}
+
+type WebauthnCredentials []WebauthnCredential
+
+type WebauthnChallenge struct {
+	Id        uint64    `db:"id,omitempty"`
+	UserId    uint64    `db:"user_id"`
+	Challenge []byte    `db:"challenge"`
+	Ceremony  string    `db:"ceremony"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
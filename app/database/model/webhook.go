@@ -0,0 +1,49 @@
+package model
+
+import (
+	"github.com/upper/db/v4/adapter/postgresql"
+	"time"
+)
+
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryDead      = "dead"
+)
+
+type Webhook struct {
+	Id         uint64                 `db:"id,omitempty"`
+	CreatedBy  uint64                 `db:"created_by"`
+	Url        string                 `db:"url"`
+	Secret     []byte                 `db:"secret"`
+	EventTypes postgresql.StringArray `db:"event_types"`
+	CreatedAt  time.Time              `db:"created_at,omitempty"`
+}
+
+type Webhooks []Webhook
+
+type WebhookDelivery struct {
+	Id             uint64    `db:"id,omitempty"`
+	WebhookId      uint64    `db:"webhook_id"`
+	EventType      string    `db:"event_type"`
+	Payload        string    `db:"payload"`
+	Status         string    `db:"status"`
+	Attempts       int       `db:"attempts"`
+	NextAttemptAt  time.Time `db:"next_attempt_at"`
+	ResponseStatus *int      `db:"response_status,omitempty"`
+	LastError      *string   `db:"last_error,omitempty"`
+	CreatedAt      time.Time `db:"created_at,omitempty"`
+}
+
+type WebhookDeliveries []WebhookDelivery
+
+type WebhookDeadLetter struct {
+	Id         uint64    `db:"id,omitempty"`
+	DeliveryId uint64    `db:"delivery_id"`
+	WebhookId  uint64    `db:"webhook_id"`
+	EventType  string    `db:"event_type"`
+	LastError  *string   `db:"last_error,omitempty"`
+	FailedAt   time.Time `db:"failed_at,omitempty"`
+}
+
+type WebhookDeadLetters []WebhookDeadLetter
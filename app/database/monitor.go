@@ -0,0 +1,116 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"github.com/upper/db/v4"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Metrics of the database health, published as expvar variables but only served by MetricsHandler, the other expvar
+// variables like the command line aren't meant to be public
+var metrics = expvar.NewMap("database")
+
+// MetricsHandler serves the database metrics as JSON
+func MetricsHandler(writer http.ResponseWriter, request *http.Request) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.Write([]byte(`{"database":` + metrics.String() + "}"))
+}
+
+// healthCheck pings the database, giving up once the timeout is over, it's a variable meant for mocking
+var healthCheck = func(session db.Session, timeout time.Duration) error {
+	// A closed session has no database left to ping
+	sqlDatabase, ok := session.Driver().(*sql.DB)
+	if !ok || sqlDatabase == nil {
+		return session.Ping()
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return sqlDatabase.PingContext(ctx)
+}
+
+// Monitor periodically health checks the database, it reports the storage as unavailable once the threshold of failed
+// health checks in a row is reached, and as available again as soon as a health check succeeds
+type Monitor struct {
+	session             *db.Session
+	interval            time.Duration
+	failureThreshold    int
+	available           int32
+	consecutiveFailures int
+	stop                chan struct{}
+	stopOnce            sync.Once
+	waitGroup           sync.WaitGroup
+}
+
+func NewMonitor(session *db.Session, interval time.Duration, failureThreshold int) *Monitor {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	monitor := &Monitor{
+		session:          session,
+		interval:         interval,
+		failureThreshold: failureThreshold,
+		available:        1,
+		stop:             make(chan struct{}),
+	}
+	metrics.Set("available", expvar.Func(func() interface{} { return monitor.Available() }))
+	return monitor
+}
+
+func (monitor *Monitor) Start() {
+	monitor.waitGroup.Add(1)
+	go func() {
+		defer monitor.waitGroup.Done()
+		ticker := time.NewTicker(monitor.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-monitor.stop:
+				return
+			case <-ticker.C:
+				monitor.Check()
+			}
+		}
+	}()
+}
+
+// Stop waits for a running health check to finish
+func (monitor *Monitor) Stop() {
+	monitor.stopOnce.Do(func() { close(monitor.stop) })
+	monitor.waitGroup.Wait()
+}
+
+// Available tells whether the database answered the latest health checks
+func (monitor *Monitor) Available() bool {
+	return atomic.LoadInt32(&monitor.available) == 1
+}
+
+// Check health checks the database once and returns whether it's considered available afterwards,
+// a health check may take at most the interval between them
+func (monitor *Monitor) Check() bool {
+	metrics.Add("health_checks", 1)
+	err := healthCheck(*monitor.session, monitor.interval)
+	if err == nil {
+		monitor.consecutiveFailures = 0
+		if atomic.CompareAndSwapInt32(&monitor.available, 0, 1) {
+			metrics.Add("recoveries", 1)
+			log.Printf("Database is available again")
+		}
+		return true
+	}
+
+	metrics.Add("failed_health_checks", 1)
+	monitor.consecutiveFailures++
+	log.Printf("Database health check %d/%d failed: %s", monitor.consecutiveFailures, monitor.failureThreshold, err)
+	if monitor.consecutiveFailures >= monitor.failureThreshold && atomic.CompareAndSwapInt32(&monitor.available, 1, 0) {
+		metrics.Add("outages", 1)
+		log.Printf("Database is unavailable, failing requests fast until it recovers")
+	}
+	return monitor.Available()
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"encoding/json"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Check should report the database as unavailable only after the threshold of failed health checks in a row
+func TestMonitorCheckWithFailedHealthChecks(t *testing.T) {
+	defer func(original func(db.Session, time.Duration) error) { healthCheck = original }(healthCheck)
+	healthCheck = func(session db.Session, timeout time.Duration) error { return db.ErrNotConnected }
+	session := db.Session(nil)
+	monitor := NewMonitor(&session, time.Second, 2)
+
+	assert.True(t, monitor.Check(), "A single failed health check should be tolerated")
+	assert.False(t, monitor.Check(), "Should be unavailable once the threshold is reached")
+	assert.False(t, monitor.Available())
+	assert.Equal(t, metrics.Get("available").String(), "false")
+}
+
+// Check should report the database as available again as soon as a health check succeeds
+func TestMonitorCheckRecovery(t *testing.T) {
+	defer func(original func(db.Session, time.Duration) error) { healthCheck = original }(healthCheck)
+	healthCheckError := error(db.ErrNotConnected)
+	healthCheck = func(session db.Session, timeout time.Duration) error { return healthCheckError }
+	session := db.Session(nil)
+	monitor := NewMonitor(&session, time.Second, 1)
+	recoveries := metricValue("recoveries")
+
+	assert.False(t, monitor.Check())
+	healthCheckError = nil
+	assert.True(t, monitor.Check(), "Should be available after a successful health check")
+	assert.True(t, monitor.Available())
+	assert.Equal(t, metricValue("recoveries"), recoveries+1)
+
+	healthCheckError = db.ErrNotConnected
+	assert.False(t, monitor.Check(), "Failed health checks should be counted from the recovery on")
+}
+
+// Check should ping the database of the session
+func TestMonitorCheckWithSqliteDatabase(t *testing.T) {
+	session := InitializeDatabaseConnection(databaseutil.GenerateTestDatasourceConfiguration(DriverSqlite))
+	defer databaseutil.StopTestDatabase(session)
+	monitor := NewMonitor(session, time.Second, 1)
+
+	assert.True(t, monitor.Check())
+	CloseDatabaseConnection(session)
+	assert.False(t, monitor.Check(), "Should be unavailable once the connection is closed")
+}
+
+// Monitor should health check the database periodically until it's stopped
+func TestMonitorStartAndStop(t *testing.T) {
+	defer func(original func(db.Session, time.Duration) error) { healthCheck = original }(healthCheck)
+	var healthChecks int32
+	healthCheck = func(session db.Session, timeout time.Duration) error {
+		atomic.AddInt32(&healthChecks, 1)
+		return nil
+	}
+	session := db.Session(nil)
+	monitor := NewMonitor(&session, 10*time.Millisecond, 1)
+
+	monitor.Start()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&healthChecks) > 0 }, time.Second, 10*time.Millisecond)
+	monitor.Stop()
+	monitor.Stop()
+
+	stoppedAt := atomic.LoadInt32(&healthChecks)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, atomic.LoadInt32(&healthChecks), stoppedAt, "Health checks should stop with the monitor")
+}
+
+// MetricsHandler should serve only the database metrics, not the other expvar variables
+func TestMetricsHandler(t *testing.T) {
+	session := db.Session(nil)
+	NewMonitor(&session, time.Second, 1)
+	recorder := httptest.NewRecorder()
+
+	MetricsHandler(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, recorder.Header().Get("Content-Type"), "application/json")
+	servedMetrics := map[string]map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal(recorder.Body.Bytes(), &servedMetrics), "Should serve valid JSON")
+	assert.Len(t, servedMetrics, 1, "Should serve the database metrics only")
+	assert.Equal(t, servedMetrics["database"]["available"], true)
+}
+
+func metricValue(name string) int64 {
+	if metric, ok := metrics.Get(name).(interface{ Value() int64 }); ok {
+		return metric.Value()
+	}
+	return 0
+}
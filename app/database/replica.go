@@ -0,0 +1,265 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/upper/db/v4"
+	"log"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// errReplicationStopped is returned for replicas that don't replicate from their primary anymore
+var errReplicationStopped = errors.New("replication is not running")
+
+// postgresReplicaLagQuery tells whether the replica stopped streaming from its primary, and how far it's behind. The lag
+// is zero for replicas that replayed everything they received, since the time of the last replayed transaction only
+// tells how long the primary has been idle for those, but such a replica is only current while it's still streaming.
+const postgresReplicaLagQuery = `SELECT pg_is_in_recovery() AND NOT EXISTS (SELECT 1 FROM pg_stat_wal_receiver WHERE status = 'streaming'),
+	CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0) END`
+
+// replicaLag tells how far the replica is behind its primary, it's a variable meant for mocking
+var replicaLag = func(session db.Session) (time.Duration, error) {
+	switch Driver(session) {
+	case DriverPostgres:
+		row, err := session.SQL().QueryRow(postgresReplicaLagQuery)
+		if err != nil {
+			return 0, err
+		}
+		return postgresReplicaLag(row)
+	case DriverMysql:
+		return mysqlReplicaLag(session)
+	}
+	return 0, nil
+}
+
+// ReplicaSet balances reads over the read replicas of the primary database, the replicas are health checked periodically
+// and left out of the rotation while they don't answer or lag behind the primary by more than the maximum lag
+type ReplicaSet struct {
+	replicas  []*replica
+	next      uint32
+	interval  time.Duration
+	maxLag    time.Duration
+	stop      chan struct{}
+	stopOnce  sync.Once
+	waitGroup sync.WaitGroup
+}
+
+type replica struct {
+	session          db.Session
+	settings         db.ConnectionURL
+	open             func(db.ConnectionURL) (db.Session, error)
+	datasourceConfig *config.Datasource
+	healthy          int32
+}
+
+// ConnectReplicas connects to the configured read replicas, which share everything but the host with the primary,
+// a replica that can't be reached yet stays out of the rotation until a health check connects to it.
+// There is no replica set without any replicas configured.
+func ConnectReplicas(datasourceConfig *config.Datasource) *ReplicaSet {
+	if len(datasourceConfig.Replicas) == 0 {
+		return nil
+	}
+	if datasourceConfig.Driver == DriverSqlite {
+		log.Panic("Read replicas are not supported by the sqlite datasource driver")
+	}
+
+	replicas := make([]*replica, 0, len(datasourceConfig.Replicas))
+	for _, host := range datasourceConfig.Replicas {
+		replicaConfig := *datasourceConfig
+		replicaConfig.Host = host
+		// Replicas start in the rotation so the first health check reports the unhealthy ones
+		replica := &replica{datasourceConfig: &replicaConfig, healthy: 1}
+		replica.settings, replica.open = connectionSettings(&replicaConfig)
+		replicas = append(replicas, replica)
+	}
+
+	replicaSet := newReplicaSet(
+		replicas,
+		time.Duration(datasourceConfig.HealthCheckIntervalInSeconds)*time.Second,
+		time.Duration(datasourceConfig.MaxReplicaLagInSeconds)*time.Second,
+	)
+	log.Printf("Reading from %d/%d replica/s", replicaSet.Check(), len(replicas))
+	return replicaSet
+}
+
+// NewReplicaSet balances reads over replicas that are connected already, all of them are in the rotation until checked
+func NewReplicaSet(sessions []db.Session, interval time.Duration, maxLag time.Duration) *ReplicaSet {
+	replicas := make([]*replica, 0, len(sessions))
+	for _, session := range sessions {
+		replicas = append(replicas, &replica{session: session, settings: session.ConnectionURL(), healthy: 1})
+	}
+	return newReplicaSet(replicas, interval, maxLag)
+}
+
+func newReplicaSet(replicas []*replica, interval time.Duration, maxLag time.Duration) *ReplicaSet {
+	replicaSet := &ReplicaSet{replicas: replicas, interval: interval, maxLag: maxLag, stop: make(chan struct{})}
+	metrics.Set("healthy_replicas", expvar.Func(func() interface{} { return replicaSet.Healthy() }))
+	return replicaSet
+}
+
+func (replicaSet *ReplicaSet) Start() {
+	replicaSet.waitGroup.Add(1)
+	go func() {
+		defer replicaSet.waitGroup.Done()
+		ticker := time.NewTicker(replicaSet.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-replicaSet.stop:
+				return
+			case <-ticker.C:
+				replicaSet.Check()
+			}
+		}
+	}()
+}
+
+// Stop waits for running health checks to finish
+func (replicaSet *ReplicaSet) Stop() {
+	replicaSet.stopOnce.Do(func() { close(replicaSet.stop) })
+	replicaSet.waitGroup.Wait()
+}
+
+// Close closes the connections to the replicas, it has to be stopped beforehand
+func (replicaSet *ReplicaSet) Close() {
+	for _, replica := range replicaSet.replicas {
+		if replica.session == nil {
+			continue
+		}
+		if err := closeConnection(replica.session); err != nil {
+			log.Printf("Could not close connection to read replica at %s\nError: %s", location(replica.settings), err)
+		}
+	}
+}
+
+// Session returns the session of the next replica in the rotation, or nil if there is none,
+// in which case reads have to go to the primary
+func (replicaSet *ReplicaSet) Session() db.Session {
+	if replicaSet == nil || len(replicaSet.replicas) == 0 {
+		return nil
+	}
+
+	start := int(atomic.AddUint32(&replicaSet.next, 1))
+	for offset := 0; offset < len(replicaSet.replicas); offset++ {
+		replica := replicaSet.replicas[(start+offset)%len(replicaSet.replicas)]
+		if atomic.LoadInt32(&replica.healthy) == 1 {
+			return replica.session
+		}
+	}
+	return nil
+}
+
+// Healthy returns the number of replicas in the rotation
+func (replicaSet *ReplicaSet) Healthy() int {
+	healthy := 0
+	for _, replica := range replicaSet.replicas {
+		healthy += int(atomic.LoadInt32(&replica.healthy))
+	}
+	return healthy
+}
+
+// Check health checks every replica once and returns the number of them left in the rotation,
+// a health check may take at most the interval between them
+func (replicaSet *ReplicaSet) Check() int {
+	for _, replica := range replicaSet.replicas {
+		replica.setHealthy(replica.check(replicaSet.interval, replicaSet.maxLag))
+	}
+	return replicaSet.Healthy()
+}
+
+func (replica *replica) check(timeout time.Duration, maxLag time.Duration) error {
+	if replica.session == nil {
+		session, err := connect(replica.settings, replica.open, replica.datasourceConfig)
+		if err != nil {
+			return err
+		}
+		replica.session = session
+	}
+
+	if err := healthCheck(replica.session, timeout); err != nil {
+		return err
+	}
+	lag, err := replicaLag(replica.session)
+	if err != nil {
+		return err
+	}
+	if lag > maxLag {
+		return fmt.Errorf("lagging %s behind the primary", lag)
+	}
+	return nil
+}
+
+// setHealthy puts the replica in or out of the rotation depending on the outcome of its health check
+func (replica *replica) setHealthy(err error) {
+	if err == nil {
+		if atomic.CompareAndSwapInt32(&replica.healthy, 0, 1) {
+			log.Printf("Read replica at %s is back in the rotation", location(replica.settings))
+		}
+		return
+	}
+
+	metrics.Add("failed_replica_health_checks", 1)
+	if atomic.CompareAndSwapInt32(&replica.healthy, 1, 0) {
+		log.Printf("Read replica at %s is out of the rotation: %s", location(replica.settings), err)
+	}
+}
+
+// postgresReplicaLag reads the lag off the row of the lag query
+func postgresReplicaLag(row *sql.Row) (time.Duration, error) {
+	var isStopped bool
+	var lagInSeconds float64
+	if err := row.Scan(&isStopped, &lagInSeconds); err != nil {
+		return 0, err
+	}
+	if isStopped {
+		return 0, errReplicationStopped
+	}
+	return time.Duration(lagInSeconds * float64(time.Second)), nil
+}
+
+// mysqlReplicaLag reads the lag off the replication status, which is empty for databases that aren't replicas.
+// Older servers only know SHOW SLAVE STATUS, which recent MySQL servers removed in favour of SHOW REPLICA STATUS.
+func mysqlReplicaLag(session db.Session) (time.Duration, error) {
+	rows, err := session.SQL().Query("SHOW REPLICA STATUS")
+	if err != nil {
+		if rows, err = session.SQL().Query("SHOW SLAVE STATUS"); err != nil {
+			return 0, err
+		}
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return 0, rows.Err()
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, err
+	}
+	values := make([]sql.RawBytes, len(columns))
+	destinations := make([]interface{}, len(columns))
+	for index := range values {
+		destinations[index] = &values[index]
+	}
+	if err = rows.Scan(destinations...); err != nil {
+		return 0, err
+	}
+
+	for index, column := range columns {
+		if column == "Seconds_Behind_Source" || column == "Seconds_Behind_Master" {
+			if values[index] == nil {
+				return 0, errReplicationStopped
+			}
+			lagInSeconds, err := strconv.Atoi(string(values[index]))
+			return time.Duration(lagInSeconds) * time.Second, err
+		}
+	}
+	return 0, nil
+}
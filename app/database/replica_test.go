@@ -0,0 +1,143 @@
+package database
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func initializeSqliteReplicas(t *testing.T, count int) []db.Session {
+	sessions := []db.Session{}
+	for index := 0; index < count; index++ {
+		session := InitializeDatabaseConnection(databaseutil.GenerateTestDatasourceConfiguration(DriverSqlite))
+		t.Cleanup(func() { databaseutil.StopTestDatabase(session) })
+		sessions = append(sessions, *session)
+	}
+	return sessions
+}
+
+// Session should balance reads over the replicas in turn
+func TestReplicaSetSession(t *testing.T) {
+	sessions := initializeSqliteReplicas(t, 2)
+	replicaSet := NewReplicaSet(sessions, time.Second, time.Second)
+	defer replicaSet.Close()
+
+	first, second := replicaSet.Session(), replicaSet.Session()
+	assert.NotEqual(t, first, second, "Should read from the other replica next")
+	assert.Equal(t, replicaSet.Session(), first, "Should start over after the last replica")
+}
+
+// Check should leave lagging replicas out of the rotation until they catch up
+func TestReplicaSetCheckWithLaggingReplica(t *testing.T) {
+	defer func(original func(db.Session) (time.Duration, error)) { replicaLag = original }(replicaLag)
+	sessions := initializeSqliteReplicas(t, 2)
+	lag := 10 * time.Second
+	replicaLag = func(session db.Session) (time.Duration, error) {
+		if session == sessions[0] {
+			return lag, nil
+		}
+		return 0, nil
+	}
+	replicaSet := NewReplicaSet(sessions, time.Second, 5*time.Second)
+	defer replicaSet.Close()
+
+	assert.Equal(t, replicaSet.Check(), 1, "The lagging replica should be out of the rotation")
+	assert.Equal(t, metrics.Get("healthy_replicas").String(), "1")
+	for index := 0; index < 3; index++ {
+		assert.Equal(t, replicaSet.Session(), sessions[1], "Should read only from the replica in the rotation")
+	}
+
+	lag = time.Second
+	assert.Equal(t, replicaSet.Check(), 2, "The replica should be back in the rotation once it caught up")
+}
+
+// postgresReplicaLag should report a replica that stopped streaming from its primary as stopped, even without any lag
+func TestPostgresReplicaLag(t *testing.T) {
+	session := initializeSqliteReplicas(t, 1)[0]
+
+	row, _ := session.SQL().QueryRow("SELECT 0, 2.5")
+	lag, err := postgresReplicaLag(row)
+	assert.Nil(t, err, "Should read the lag of a streaming replica")
+	assert.Equal(t, lag, 2500*time.Millisecond)
+
+	row, _ = session.SQL().QueryRow("SELECT 1, 0")
+	lag, err = postgresReplicaLag(row)
+	assert.Equal(t, err, errReplicationStopped, "Should report the stopped replication")
+	assert.Equal(t, lag, time.Duration(0))
+}
+
+// Check should leave replicas that stopped replicating out of the rotation
+func TestReplicaSetCheckWithStoppedReplication(t *testing.T) {
+	defer func(original func(db.Session) (time.Duration, error)) { replicaLag = original }(replicaLag)
+	sessions := initializeSqliteReplicas(t, 2)
+	replicaLag = func(session db.Session) (time.Duration, error) {
+		if session == sessions[0] {
+			return 0, errReplicationStopped
+		}
+		return 0, nil
+	}
+	replicaSet := NewReplicaSet(sessions, time.Second, 5*time.Second)
+	defer replicaSet.Close()
+
+	assert.Equal(t, replicaSet.Check(), 1, "The stopped replica should be out of the rotation")
+	assert.Equal(t, replicaSet.Session(), sessions[1], "Should read only from the replica in the rotation")
+}
+
+// Session should return no session while there isn't any replica in the rotation
+func TestReplicaSetSessionWithoutReplicasInRotation(t *testing.T) {
+	sessions := initializeSqliteReplicas(t, 1)
+	replicaSet := NewReplicaSet(sessions, time.Second, time.Second)
+	CloseDatabaseConnection(&sessions[0])
+
+	assert.Equal(t, replicaSet.Check(), 0, "Replicas that don't answer should be out of the rotation")
+	assert.Nil(t, replicaSet.Session())
+	assert.Nil(t, (*ReplicaSet)(nil).Session(), "There are no replicas without a replica set")
+}
+
+// ConnectReplicas should connect to every configured replica and leave the unreachable ones out of the rotation
+func TestConnectReplicas(t *testing.T) {
+	defer func(original func(db.Session) error) { pingDatabase = original }(pingDatabase)
+	pingDatabase = func(session db.Session) error { return db.ErrNotConnected }
+	datasourceConfig := databaseutil.GenerateTestDatasourceConfiguration(DriverPostgres)
+	datasourceConfig.Replicas = []string{"localhost:50010", "localhost:50011"}
+
+	replicaSet := ConnectReplicas(datasourceConfig)
+	assert.Equal(t, replicaSet.Healthy(), 0)
+	assert.Equal(t, location(replicaSet.replicas[1].settings), "localhost:50011")
+	assert.Nil(t, replicaSet.Session(), "Unreachable replicas should be out of the rotation")
+	replicaSet.Close()
+}
+
+// ConnectReplicas should return no replica set without any replicas configured, and panic for sqlite datasources
+func TestConnectReplicasWithoutReplicas(t *testing.T) {
+	assert.Nil(t, ConnectReplicas(&config.Datasource{Driver: DriverPostgres}))
+	assert.PanicsWithValue(
+		t, "Read replicas are not supported by the sqlite datasource driver",
+		func() { ConnectReplicas(&config.Datasource{Driver: DriverSqlite, Replicas: []string{"replica"}}) },
+	)
+}
+
+// ReplicaSet should health check the replicas periodically until it's stopped
+func TestReplicaSetStartAndStop(t *testing.T) {
+	defer func(original func(db.Session) (time.Duration, error)) { replicaLag = original }(replicaLag)
+	var healthChecks int32
+	replicaLag = func(session db.Session) (time.Duration, error) {
+		atomic.AddInt32(&healthChecks, 1)
+		return 0, nil
+	}
+	replicaSet := NewReplicaSet(initializeSqliteReplicas(t, 1), 10*time.Millisecond, time.Second)
+	defer replicaSet.Close()
+
+	replicaSet.Start()
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&healthChecks) > 0 }, time.Second, 10*time.Millisecond)
+	replicaSet.Stop()
+	replicaSet.Stop()
+
+	stoppedAt := atomic.LoadInt32(&healthChecks)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, atomic.LoadInt32(&healthChecks), stoppedAt, "Health checks should stop with the replica set")
+}
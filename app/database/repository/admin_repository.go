@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+// AdminRepository records every change it makes in the admin audit trail within the same transaction
+type AdminRepository interface {
+	FetchUsers(users *model.Users, offset int, limit int) error
+	FetchUsageStatistics(statistics *model.UsageStatistics) error
+	FetchAuditEntriesDetails(entries *model.AdminAuditEntriesDetails, offset int, limit int) error
+	UpdateUserDisabledById(disabled bool, id uint64, auditEntry *model.AdminAuditEntry) error
+	UpdateUserRoleById(role string, id uint64, auditEntry *model.AdminAuditEntry) error
+	RevokeUserSessionsById(id uint64, auditEntry *model.AdminAuditEntry) error
+}
+
+type adminRepositoryService struct {
+	session *db.Session
+}
+
+func NewAdminRepositoryService(session *db.Session) *adminRepositoryService {
+	return &adminRepositoryService{session: session}
+}
+
+// FetchUsers fetches a page of users without their master password hashes or recovery keys
+func (repository *adminRepositoryService) FetchUsers(users *model.Users, offset int, limit int) error {
+	return translateError((*repository.session).SQL().
+		Select("id", "email", "username", "email_verified", "role", "disabled").
+		From("user").
+		OrderBy("id").
+		Offset(offset).
+		Limit(limit).
+		All(users))
+}
+
+func (repository *adminRepositoryService) FetchUsageStatistics(statistics *model.UsageStatistics) error {
+	return translateError((*repository.session).SQL().Iterator(`
+		SELECT
+			(SELECT count(*) FROM "user") AS users,
+			(SELECT count(*) FROM "user" WHERE "disabled") AS disabled_users,
+			(SELECT count(*) FROM "user" WHERE "role" = ?) AS admins,
+			(SELECT count(*) FROM "password") AS entries,
+			(SELECT count(*) FROM "organization") AS organizations,
+			(SELECT count(*) FROM "user_session" WHERE "status" = ?) AS active_sessions,
+			(SELECT count(*) FROM "send" WHERE "expires_at" > ? AND "view_count" < "max_views") AS active_sends`,
+		model.UserRoleAdmin, model.SessionActive, time.Now().UTC(),
+	).One(statistics))
+}
+
+// FetchAuditEntriesDetails fetches a page of the audit trail, most recent entries first
+func (repository *adminRepositoryService) FetchAuditEntriesDetails(entries *model.AdminAuditEntriesDetails, offset int, limit int) error {
+	return translateError((*repository.session).SQL().
+		Select("e.*", "a.email AS admin_email", "t.email AS target_email").
+		From("admin_audit_entry AS e").
+		Join("user AS a").On("a.id = e.admin_id").
+		Join("user AS t").On("t.id = e.target_user_id").
+		OrderBy("-e.id").
+		Offset(offset).
+		Limit(limit).
+		All(entries))
+}
+
+// UpdateUserDisabledById disables or enables the user, disabling also revokes all of the user's sessions
+func (repository *adminRepositoryService) UpdateUserDisabledById(disabled bool, id uint64, auditEntry *model.AdminAuditEntry) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		update := session.SQL().Update("user").Set("disabled", disabled).Where("id = ?", id)
+		if err := execAffectingRows(update); err != nil {
+			return err
+		}
+
+		if disabled {
+			if err := revokeUserSessions(session, id); err != nil {
+				return err
+			}
+		}
+
+		return insertAuditEntry(session, auditEntry)
+	}))
+}
+
+func (repository *adminRepositoryService) UpdateUserRoleById(role string, id uint64, auditEntry *model.AdminAuditEntry) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		update := session.SQL().Update("user").Set("role", role).Where("id = ?", id)
+		if err := execAffectingRows(update); err != nil {
+			return err
+		}
+
+		return insertAuditEntry(session, auditEntry)
+	}))
+}
+
+func (repository *adminRepositoryService) RevokeUserSessionsById(id uint64, auditEntry *model.AdminAuditEntry) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		if err := revokeUserSessions(session, id); err != nil {
+			return err
+		}
+
+		return insertAuditEntry(session, auditEntry)
+	}))
+}
+
+func revokeUserSessions(session db.Session, userId uint64) error {
+	_, err := session.SQL().
+		Update("user_session").
+		Set("status", model.SessionRevoked, "revoked_at", time.Now().UTC()).
+		Where("user_id = ? AND status <> ?", userId, model.SessionRevoked).
+		Exec()
+	return err
+}
+
+func insertAuditEntry(session db.Session, auditEntry *model.AdminAuditEntry) error {
+	auditEntry.CreatedAt = time.Now().UTC()
+	insertResult, err := session.Collection("admin_audit_entry").Insert(auditEntry)
+	if err != nil {
+		return err
+	}
+	auditEntry.Id = uint64(insertResult.ID().(int64))
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+	"time"
+)
+
+type AdminRepositoryTestSuite struct {
+	suite.Suite
+	driver             string
+	session            *db.Session
+	isDatabaseUp       bool
+	isDatabaseMigrated bool
+	userRepository     UserRepository
+	sessionRepository  SessionRepository
+	adminRepository    AdminRepository
+}
+
+func TestAdminSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &AdminRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *AdminRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.sessionRepository = NewSessionRepositoryService(suite.session)
+	suite.adminRepository = NewAdminRepositoryService(suite.session)
+}
+
+func (suite *AdminRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// FetchUsers should fetch users with their roles but without any secrets
+func (suite *AdminRepositoryTestSuite) TestFetchUsers() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	suite.insertTestUser("testFetchUsers@test.com")
+
+	users := model.Users{}
+	err := suite.adminRepository.FetchUsers(&users, 0, 100)
+	assert.Nil(suite.T(), err)
+	assert.NotEmpty(suite.T(), users)
+	for _, user := range users {
+		assert.Nil(suite.T(), user.Password, "Master password hashes should never be fetched")
+		assert.Nil(suite.T(), user.RecoveryWrappedKey, "Recovery keys should never be fetched")
+		assert.Equal(suite.T(), user.Role, model.UserRoleUser, "Users should get the user role by default")
+	}
+}
+
+// UpdateUserDisabledById should disable the user, revoke the user's sessions and record the action
+func (suite *AdminRepositoryTestSuite) TestUpdateUserDisabledById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	adminId := suite.insertTestUser("testDisableUserAdmin@test.com")
+	userId := suite.insertTestUser("testDisableUser@test.com")
+	now := time.Now().UTC()
+	sessionId, _ := suite.sessionRepository.InsertNewSession(&model.Session{
+		UserId: userId, DeviceName: "laptop", Status: model.SessionActive, CreatedAt: now, LastSeenAt: now,
+	})
+
+	auditEntry := &model.AdminAuditEntry{AdminId: adminId, Action: model.AdminActionDisableUser, TargetUserId: userId}
+	err := suite.adminRepository.UpdateUserDisabledById(true, userId, auditEntry)
+	assert.Nil(suite.T(), err)
+	assert.NotZero(suite.T(), auditEntry.Id)
+
+	user := model.User{}
+	suite.userRepository.FetchById(&user, userId, []string{"disabled"})
+	assert.True(suite.T(), user.Disabled)
+	session := model.Session{}
+	suite.sessionRepository.FetchSessionById(&session, uint64(sessionId.ID().(int64)))
+	assert.Equal(suite.T(), session.Status, model.SessionRevoked)
+
+	entries := model.AdminAuditEntriesDetails{}
+	suite.adminRepository.FetchAuditEntriesDetails(&entries, 0, 1)
+	assert.Equal(suite.T(), entries[0].Id, auditEntry.Id)
+	assert.Equal(suite.T(), entries[0].AdminEmail, "testDisableUserAdmin@test.com")
+	assert.Equal(suite.T(), entries[0].TargetEmail, "testDisableUser@test.com")
+}
+
+// UpdateUserRoleById should not record anything for non existing users
+func (suite *AdminRepositoryTestSuite) TestUpdateUserRoleByIdWithNonExistingUser() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	adminId := suite.insertTestUser("testChangeRoleAdmin@test.com")
+	auditEntry := &model.AdminAuditEntry{AdminId: adminId, Action: model.AdminActionChangeRole, TargetUserId: 0}
+
+	err := suite.adminRepository.UpdateUserRoleById(model.UserRoleAdmin, 0, auditEntry)
+	assert.Equal(suite.T(), err, ErrNotFound)
+	assert.Zero(suite.T(), auditEntry.Id)
+}
+
+// FetchUsageStatistics should count the records
+func (suite *AdminRepositoryTestSuite) TestFetchUsageStatistics() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	suite.insertTestUser("testUsageStatistics@test.com")
+
+	statistics := model.UsageStatistics{}
+	err := suite.adminRepository.FetchUsageStatistics(&statistics)
+	assert.Nil(suite.T(), err)
+	assert.GreaterOrEqual(suite.T(), statistics.Users, 1)
+}
+
+func (suite *AdminRepositoryTestSuite) insertTestUser(email string) uint64 {
+	userId, _ := suite.userRepository.InsertNewUser(&model.User{Email: email, Username: "adminUser", Password: []byte("password")})
+	return uint64(userId.ID().(int64))
+}
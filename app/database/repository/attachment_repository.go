@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+)
+
+var ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+
+type AttachmentRepository interface {
+	InsertNewAttachment(attachment *model.Attachment, quotaInBytes int64) (db.InsertResult, error)
+	FetchAttachmentById(attachment *model.Attachment, id uint64) error
+	FetchAttachmentsByPasswordId(attachments *model.Attachments, passwordId uint64) error
+	FetchAttachmentsByUserId(attachments *model.Attachments, userId uint64) error
+	FetchStorageUsageByUserId(userId uint64) (int64, error)
+	DeleteAttachmentById(id uint64) error
+}
+
+type attachmentRepositoryService struct {
+	session *db.Session
+}
+
+func NewAttachmentRepositoryService(session *db.Session) *attachmentRepositoryService {
+	return &attachmentRepositoryService{session: session}
+}
+
+func (repository *attachmentRepositoryService) Attachment() db.Collection {
+	return (*repository.session).Collection("attachment")
+}
+
+// InsertNewAttachment returns ErrStorageQuotaExceeded if the attachment doesn't fit into the user's storage quota,
+// the user is locked while checking the quota so concurrent uploads can't exceed it together
+func (repository *attachmentRepositoryService) InsertNewAttachment(attachment *model.Attachment, quotaInBytes int64) (db.InsertResult, error) {
+	var insertResult db.InsertResult
+	err := transaction(*repository.session, func(session db.Session) error {
+		if err := lockUser(session, attachment.UserId); err != nil {
+			return err
+		}
+
+		usedBytes, err := storageUsage(session, attachment.UserId)
+		if err != nil {
+			return err
+		}
+		if usedBytes+attachment.Size > quotaInBytes {
+			return ErrStorageQuotaExceeded
+		}
+
+		insertResult, err = session.Collection("attachment").Insert(attachment)
+		return err
+	})
+	return insertResult, translateError(err)
+}
+
+func (repository *attachmentRepositoryService) FetchAttachmentById(attachment *model.Attachment, id uint64) error {
+	return translateError((*repository.session).SQL().Select().From("attachment").Where("id = ?", id).One(attachment))
+}
+
+func (repository *attachmentRepositoryService) FetchAttachmentsByPasswordId(attachments *model.Attachments, passwordId uint64) error {
+	return translateError(
+		(*repository.session).SQL().Select().From("attachment").Where("password_id = ?", passwordId).OrderBy("id").All(attachments),
+	)
+}
+
+func (repository *attachmentRepositoryService) FetchAttachmentsByUserId(attachments *model.Attachments, userId uint64) error {
+	return translateError(
+		(*repository.session).SQL().Select().From("attachment").Where("user_id = ?", userId).OrderBy("id").All(attachments),
+	)
+}
+
+// FetchStorageUsageByUserId returns the total size of the user's attachments in bytes
+func (repository *attachmentRepositoryService) FetchStorageUsageByUserId(userId uint64) (int64, error) {
+	usage, err := storageUsage(*repository.session, userId)
+	return usage, translateError(err)
+}
+
+func (repository *attachmentRepositoryService) DeleteAttachmentById(id uint64) error {
+	return translateError(execAffectingRows((*repository.session).SQL().DeleteFrom("attachment").Where("id = ?", id)))
+}
+
+func storageUsage(session db.Session, userId uint64) (int64, error) {
+	row, err := session.SQL().QueryRow(`SELECT COALESCE(SUM("size"), 0) FROM "attachment" WHERE "user_id" = ?`, userId)
+	if err != nil {
+		return 0, err
+	}
+
+	var usedBytes int64
+	err = row.Scan(&usedBytes)
+	return usedBytes, err
+}
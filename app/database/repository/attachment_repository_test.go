@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+	"time"
+)
+
+type AttachmentRepositoryTestSuite struct {
+	suite.Suite
+	driver               string
+	session              *db.Session
+	isDatabaseUp         bool
+	isDatabaseMigrated   bool
+	userRepository       UserRepository
+	passwordRepository   PasswordRepository
+	attachmentRepository AttachmentRepository
+}
+
+func TestAttachmentSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &AttachmentRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *AttachmentRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.passwordRepository = NewPasswordRepositoryService(suite.session)
+	suite.attachmentRepository = NewAttachmentRepositoryService(suite.session)
+}
+
+func (suite *AttachmentRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// InsertNewAttachment should only insert attachments that fit into the user's storage quota
+func (suite *AttachmentRepositoryTestSuite) TestInsertNewAttachmentWithQuota() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testAttachmentQuota@test.com")
+	passwordId := suite.insertTestPassword(userId)
+
+	_, err := suite.attachmentRepository.InsertNewAttachment(testAttachment(userId, passwordId, "testAttachmentQuota1", 60), 100)
+	assert.Nil(suite.T(), err)
+	_, err = suite.attachmentRepository.InsertNewAttachment(testAttachment(userId, passwordId, "testAttachmentQuota2", 60), 100)
+	assert.Equal(suite.T(), err, ErrStorageQuotaExceeded)
+	_, err = suite.attachmentRepository.InsertNewAttachment(testAttachment(userId, passwordId, "testAttachmentQuota3", 40), 100)
+	assert.Nil(suite.T(), err)
+
+	usedBytes, err := suite.attachmentRepository.FetchStorageUsageByUserId(userId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), usedBytes, int64(100))
+}
+
+// FetchAttachmentsByPasswordId should fetch the entry's attachments, which get deleted together with the entry
+func (suite *AttachmentRepositoryTestSuite) TestFetchAttachmentsByPasswordId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testFetchAttachments@test.com")
+	passwordId := suite.insertTestPassword(userId)
+	insertResult, _ := suite.attachmentRepository.InsertNewAttachment(testAttachment(userId, passwordId, "testFetchAttachments", 10), 100)
+	suite.attachmentRepository.InsertNewAttachment(testAttachment(userId, suite.insertTestPassword(userId), "testFetchAttachmentsOther", 10), 100)
+
+	attachments := model.Attachments{}
+	err := suite.attachmentRepository.FetchAttachmentsByPasswordId(&attachments, passwordId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), len(attachments), 1)
+	assert.Equal(suite.T(), attachments[0].Id, uint64(insertResult.ID().(int64)))
+	assert.Equal(suite.T(), attachments[0].BlobKey, "testFetchAttachments")
+
+	suite.passwordRepository.DeletePasswordById(passwordId, nil)
+	attachment := model.Attachment{}
+	err = suite.attachmentRepository.FetchAttachmentById(&attachment, attachments[0].Id)
+	assert.Equal(suite.T(), err, ErrNotFound, "Attachments should be deleted together with their entry")
+}
+
+// DeleteAttachmentById should delete the attachment and return ErrNotFound for missing attachments
+func (suite *AttachmentRepositoryTestSuite) TestDeleteAttachmentById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testDeleteAttachment@test.com")
+	insertResult, _ := suite.attachmentRepository.InsertNewAttachment(
+		testAttachment(userId, suite.insertTestPassword(userId), "testDeleteAttachment", 10), 100,
+	)
+	attachmentId := uint64(insertResult.ID().(int64))
+
+	err := suite.attachmentRepository.DeleteAttachmentById(attachmentId)
+	assert.Nil(suite.T(), err)
+	err = suite.attachmentRepository.DeleteAttachmentById(attachmentId)
+	assert.Equal(suite.T(), err, ErrNotFound)
+}
+
+func (suite *AttachmentRepositoryTestSuite) insertTestUser(email string) uint64 {
+	userId, _ := suite.userRepository.InsertNewUser(&model.User{Email: email, Username: "attachmentUser", Password: []byte("password")})
+	return uint64(userId.ID().(int64))
+}
+
+func (suite *AttachmentRepositoryTestSuite) insertTestPassword(userId uint64) uint64 {
+	passwordId, _ := suite.passwordRepository.InsertNewPassword(&model.Password{UserId: userId, Name: "attached", Password: []byte("password")})
+	return uint64(passwordId.ID().(int64))
+}
+
+func testAttachment(userId uint64, passwordId uint64, blobKey string, size int64) *model.Attachment {
+	return &model.Attachment{
+		PasswordId: passwordId, UserId: userId, Name: "recovery-codes.txt", ContentType: "text/plain",
+		Size: size, BlobKey: blobKey, WrappedDataKey: []byte("wrappedDataKey"), CreatedAt: time.Now().UTC(),
+	}
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+)
+
+// auditEventChainLock is the advisory lock key serializing appends to the audit event chain
+const auditEventChainLock = 7_305_232_001
+
+type AuditEventRepository interface {
+	AppendEvent(event *model.AuditEvent, hashEvent func(event *model.AuditEvent) []byte) error
+	FetchEvents(events *model.AuditEvents, filter *model.AuditEventFilter, offset int, limit int) error
+	FetchEventsAfterSequence(events *model.AuditEvents, sequence int64, limit int) error
+}
+
+type auditEventRepositoryService struct {
+	session *db.Session
+}
+
+func NewAuditEventRepositoryService(session *db.Session) *auditEventRepositoryService {
+	return &auditEventRepositoryService{session: session}
+}
+
+func (repository *auditEventRepositoryService) AuditEvent() db.Collection {
+	return (*repository.session).Collection("audit_event")
+}
+
+// AppendEvent links the event to the last one in the chain and stores it, appends are serialized
+// so concurrent events can't link to the same predecessor, SQLite serializes them with its immediate transactions.
+// MySQL has no transaction scoped advisory locks, appends lock the single row of the chain's lock table instead.
+func (repository *auditEventRepositoryService) AppendEvent(
+	event *model.AuditEvent, hashEvent func(event *model.AuditEvent) []byte,
+) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		switch database.Driver(session) {
+		case database.DriverPostgres:
+			if _, err := session.SQL().Exec("SELECT pg_advisory_xact_lock(?)", auditEventChainLock); err != nil {
+				return err
+			}
+		case database.DriverMysql:
+			if _, err := session.SQL().Exec(`SELECT "id" FROM "audit_event_chain_lock" FOR UPDATE`); err != nil {
+				return err
+			}
+		}
+
+		lastEvent := model.AuditEvent{}
+		err := session.SQL().Select("sequence", "hash").From("audit_event").OrderBy("-sequence").Limit(1).One(&lastEvent)
+		if err != nil && err != db.ErrNoMoreRows {
+			return err
+		}
+
+		event.Sequence = lastEvent.Sequence + 1
+		event.PreviousHash = lastEvent.Hash
+		if event.PreviousHash == nil {
+			// The first event of the chain has no predecessor to link to
+			event.PreviousHash = []byte{}
+		}
+		event.Hash = hashEvent(event)
+		insertResult, err := session.Collection("audit_event").Insert(event)
+		if err != nil {
+			return err
+		}
+		event.Id = uint64(insertResult.ID().(int64))
+
+		return nil
+	}))
+}
+
+// FetchEvents fetches a page of events matching the filter, most recent events first
+func (repository *auditEventRepositoryService) FetchEvents(
+	events *model.AuditEvents, filter *model.AuditEventFilter, offset int, limit int,
+) error {
+	query := (*repository.session).SQL().Select().From("audit_event")
+	if filter.UserId != nil {
+		query = query.And("user_id = ?", *filter.UserId)
+	}
+	if len(filter.Types) > 0 {
+		query = query.And("type IN ?", filter.Types)
+	}
+	if filter.From != nil {
+		query = query.And("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.And("created_at < ?", *filter.To)
+	}
+
+	return translateError(query.OrderBy("-sequence").Offset(offset).Limit(limit).All(events))
+}
+
+// FetchEventsAfterSequence fetches the next batch of the chain in order
+func (repository *auditEventRepositoryService) FetchEventsAfterSequence(events *model.AuditEvents, sequence int64, limit int) error {
+	return translateError((*repository.session).SQL().
+		Select().
+		From("audit_event").
+		Where("sequence > ?", sequence).
+		OrderBy("sequence").
+		Limit(limit).
+		All(events))
+}
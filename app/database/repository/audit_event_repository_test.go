@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"crypto/sha256"
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"strconv"
+	"testing"
+	"time"
+)
+
+type AuditEventRepositoryTestSuite struct {
+	suite.Suite
+	driver               string
+	session              *db.Session
+	isDatabaseUp         bool
+	isDatabaseMigrated   bool
+	auditEventRepository AuditEventRepository
+}
+
+func TestAuditEventSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &AuditEventRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *AuditEventRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.auditEventRepository = NewAuditEventRepositoryService(suite.session)
+}
+
+func (suite *AuditEventRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// AppendEvent should link every event to its predecessor
+func (suite *AuditEventRepositoryTestSuite) TestAppendEvent() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	firstEvent := suite.appendTestEvent(1001, model.AuditEventSignIn)
+	secondEvent := suite.appendTestEvent(1001, model.AuditEventPasswordCreated)
+	assert.Equal(suite.T(), secondEvent.Sequence, firstEvent.Sequence+1)
+	assert.Equal(suite.T(), secondEvent.PreviousHash, firstEvent.Hash)
+
+	events := model.AuditEvents{}
+	err := suite.auditEventRepository.FetchEventsAfterSequence(&events, firstEvent.Sequence-1, 2)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), len(events), 2)
+	assert.Equal(suite.T(), events[1].Hash, testHashEvent(&events[1]))
+}
+
+// FetchEvents should fetch the user's events of the given types, most recent events first
+func (suite *AuditEventRepositoryTestSuite) TestFetchEvents() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	suite.appendTestEvent(1002, model.AuditEventSignIn)
+	suite.appendTestEvent(1002, model.AuditEventSignInFailed)
+	lastEvent := suite.appendTestEvent(1002, model.AuditEventSignIn)
+	suite.appendTestEvent(1003, model.AuditEventSignIn)
+
+	userId := uint64(1002)
+	events := model.AuditEvents{}
+	err := suite.auditEventRepository.FetchEvents(&events, &model.AuditEventFilter{UserId: &userId, Types: []string{model.AuditEventSignIn}}, 0, 10)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), len(events), 2)
+	assert.Equal(suite.T(), events[0].Id, lastEvent.Id)
+
+	future := time.Now().UTC().Add(time.Hour)
+	err = suite.auditEventRepository.FetchEvents(&events, &model.AuditEventFilter{UserId: &userId, From: &future}, 0, 10)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), len(events), 0)
+}
+
+// Stored events should not be changeable or deletable
+func (suite *AuditEventRepositoryTestSuite) TestEventsAreAppendOnly() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	event := suite.appendTestEvent(1004, model.AuditEventSignIn)
+
+	err := (*suite.session).Tx(func(session db.Session) error {
+		_, err := session.SQL().Update("audit_event").Set("ip_address", "10.0.0.1").Where("id", event.Id).Exec()
+		return err
+	})
+	assert.NotNil(suite.T(), err, "Should not update a stored event")
+	err = (*suite.session).Tx(func(session db.Session) error {
+		_, err := session.SQL().DeleteFrom("audit_event").Where("id", event.Id).Exec()
+		return err
+	})
+	assert.NotNil(suite.T(), err, "Should not delete a stored event")
+}
+
+func (suite *AuditEventRepositoryTestSuite) appendTestEvent(userId uint64, eventType string) *model.AuditEvent {
+	details := "user " + strconv.FormatUint(userId, 10)
+	event := &model.AuditEvent{
+		UserId:    &userId,
+		Type:      eventType,
+		IpAddress: "127.0.0.1",
+		UserAgent: "test-agent",
+		Details:   &details,
+		CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	suite.auditEventRepository.AppendEvent(event, testHashEvent)
+	return event
+}
+
+func testHashEvent(event *model.AuditEvent) []byte {
+	hash := sha256.New()
+	hash.Write(event.PreviousHash)
+	hash.Write([]byte(strconv.FormatInt(event.Sequence, 10) + event.Type))
+	return hash.Sum(nil)
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type EmergencyAccessRepository interface {
+	InsertNewGrant(grant *model.EmergencyAccessGrant) (db.InsertResult, error)
+	FetchGrantById(grant *model.EmergencyAccessGrant, id uint64) error
+	FetchGrantDetailsById(grant *model.EmergencyAccessGrantDetails, id uint64) error
+	FetchGrantsDetailsByUserId(grants *model.EmergencyAccessGrantsDetails, userId uint64) error
+	UpdateGrantStatusById(status string, id uint64) error
+	InsertNewRequest(request *model.EmergencyAccessRequest) (db.InsertResult, error)
+	FetchRequestById(request *model.EmergencyAccessRequest, id uint64) error
+	FetchRequestsByGrantId(requests *model.EmergencyAccessRequests, grantId uint64) error
+	FetchPendingRequestsAvailableBefore(requests *model.EmergencyAccessRequests, availableBefore time.Time) error
+	ApproveRequestById(sealedVaultKey []byte, id uint64) error
+	RejectRequestById(id uint64) error
+}
+
+type emergencyAccessRepositoryService struct {
+	session *db.Session
+}
+
+func NewEmergencyAccessRepositoryService(session *db.Session) *emergencyAccessRepositoryService {
+	return &emergencyAccessRepositoryService{session: session}
+}
+
+func (repository *emergencyAccessRepositoryService) EmergencyAccessGrant() db.Collection {
+	return (*repository.session).Collection("emergency_access_grant")
+}
+
+func (repository *emergencyAccessRepositoryService) EmergencyAccessRequest() db.Collection {
+	return (*repository.session).Collection("emergency_access_request")
+}
+
+func (repository *emergencyAccessRepositoryService) InsertNewGrant(grant *model.EmergencyAccessGrant) (db.InsertResult, error) {
+	return insert(repository.EmergencyAccessGrant(), grant)
+}
+
+func (repository *emergencyAccessRepositoryService) FetchGrantById(grant *model.EmergencyAccessGrant, id uint64) error {
+	return translateError((*repository.session).SQL().Select().From("emergency_access_grant").Where("id = ?", id).One(grant))
+}
+
+func (repository *emergencyAccessRepositoryService) FetchGrantDetailsById(grant *model.EmergencyAccessGrantDetails, id uint64) error {
+	return translateError(repository.grantDetailsQuery().Where("g.id = ?", id).One(grant))
+}
+
+// FetchGrantsDetailsByUserId fetches both the grants given by the user and the grants given to the user
+func (repository *emergencyAccessRepositoryService) FetchGrantsDetailsByUserId(grants *model.EmergencyAccessGrantsDetails, userId uint64) error {
+	return translateError(
+		repository.grantDetailsQuery().Where("g.grantor_id = ? OR g.grantee_id = ?", userId, userId).OrderBy("g.id").All(grants),
+	)
+}
+
+func (repository *emergencyAccessRepositoryService) UpdateGrantStatusById(status string, id uint64) error {
+	update := (*repository.session).SQL().Update("emergency_access_grant").Set("status", status).Where("id = ?", id)
+	_, err := update.Exec()
+	return translateError(err)
+}
+
+func (repository *emergencyAccessRepositoryService) InsertNewRequest(request *model.EmergencyAccessRequest) (db.InsertResult, error) {
+	return insert(repository.EmergencyAccessRequest(), request)
+}
+
+func (repository *emergencyAccessRepositoryService) FetchRequestById(request *model.EmergencyAccessRequest, id uint64) error {
+	return translateError((*repository.session).SQL().Select().From("emergency_access_request").Where("id = ?", id).One(request))
+}
+
+// FetchRequestsByGrantId fetches the grant's requests, latest first
+func (repository *emergencyAccessRepositoryService) FetchRequestsByGrantId(requests *model.EmergencyAccessRequests, grantId uint64) error {
+	return translateError(
+		(*repository.session).SQL().Select().From("emergency_access_request").Where("grant_id = ?", grantId).OrderBy("-id").All(requests),
+	)
+}
+
+func (repository *emergencyAccessRepositoryService) FetchPendingRequestsAvailableBefore(
+	requests *model.EmergencyAccessRequests, availableBefore time.Time,
+) error {
+	return translateError((*repository.session).SQL().
+		Select().
+		From("emergency_access_request").
+		Where("status = ? AND available_at <= ?", model.EmergencyAccessRequestPending, availableBefore).
+		All(requests))
+}
+
+// ApproveRequestById approves only pending requests, so a rejection can never be overridden by an approval
+func (repository *emergencyAccessRepositoryService) ApproveRequestById(sealedVaultKey []byte, id uint64) error {
+	return translateError(
+		repository.resolvePendingRequest(id, "status", model.EmergencyAccessRequestApproved, "sealed_vault_key", sealedVaultKey),
+	)
+}
+
+// RejectRequestById rejects only pending requests, approved requests are ended by revoking the grant
+func (repository *emergencyAccessRepositoryService) RejectRequestById(id uint64) error {
+	return translateError(repository.resolvePendingRequest(id, "status", model.EmergencyAccessRequestRejected))
+}
+
+func (repository *emergencyAccessRepositoryService) grantDetailsQuery() db.Selector {
+	return (*repository.session).SQL().
+		Select("g.*", "grantor.email AS grantor_email", "grantee.email AS grantee_email").
+		From("emergency_access_grant AS g").
+		Join("user AS grantor").On("grantor.id = g.grantor_id").
+		Join("user AS grantee").On("grantee.id = g.grantee_id")
+}
+
+func (repository *emergencyAccessRepositoryService) resolvePendingRequest(id uint64, columnsAndValues ...interface{}) error {
+	update := (*repository.session).SQL().
+		Update("emergency_access_request").
+		Set(append(columnsAndValues, "resolved_at", time.Now().UTC())...).
+		Where("id = ? AND status = ?", id, model.EmergencyAccessRequestPending)
+	return execAffectingRows(update)
+}
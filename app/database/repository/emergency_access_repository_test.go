@@ -0,0 +1,211 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+	"time"
+)
+
+type EmergencyAccessRepositoryTestSuite struct {
+	suite.Suite
+	driver                    string
+	session                   *db.Session
+	isDatabaseUp              bool
+	isDatabaseMigrated        bool
+	userRepository            UserRepository
+	emergencyAccessRepository EmergencyAccessRepository
+}
+
+func TestEmergencyAccessSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &EmergencyAccessRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *EmergencyAccessRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.emergencyAccessRepository = NewEmergencyAccessRepositoryService(suite.session)
+}
+
+func (suite *EmergencyAccessRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// FetchGrantDetailsById should fetch the grant together with the grantor's and grantee's e-mail addresses
+func (suite *EmergencyAccessRepositoryTestSuite) TestFetchGrantDetailsById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	grantId := suite.insertTestGrant("testGrantDetailsGrantor@test.com", "testGrantDetailsGrantee@test.com")
+
+	grant := model.EmergencyAccessGrantDetails{}
+	err := suite.emergencyAccessRepository.FetchGrantDetailsById(&grant, grantId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), grant.Id, grantId)
+	assert.Equal(suite.T(), grant.GrantorEmail, "testGrantDetailsGrantor@test.com")
+	assert.Equal(suite.T(), grant.GranteeEmail, "testGrantDetailsGrantee@test.com")
+	assert.Equal(suite.T(), grant.Status, model.EmergencyAccessGrantInvited)
+}
+
+// FetchGrantsDetailsByUserId should fetch grants given by the user and grants given to the user
+func (suite *EmergencyAccessRepositoryTestSuite) TestFetchGrantsDetailsByUserId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	givenGrantId := suite.insertTestGrant("testGrantsUser@test.com", "testGrantsGrantee@test.com")
+	givenGrant := model.EmergencyAccessGrant{}
+	suite.emergencyAccessRepository.FetchGrantById(&givenGrant, givenGrantId)
+	receivedGrantInsertResult, _ := suite.emergencyAccessRepository.InsertNewGrant(&model.EmergencyAccessGrant{
+		GrantorId: givenGrant.GranteeId, GranteeId: givenGrant.GrantorId, AccessType: model.EmergencyAccessView,
+		WaitTimeInDays: 3, Status: model.EmergencyAccessGrantInvited,
+	})
+	suite.insertTestGrant("testGrantsOtherGrantor@test.com", "testGrantsOtherGrantee@test.com")
+
+	grants := model.EmergencyAccessGrantsDetails{}
+	err := suite.emergencyAccessRepository.FetchGrantsDetailsByUserId(&grants, givenGrant.GrantorId)
+	assert.Nil(suite.T(), err)
+
+	assert.Equal(suite.T(), len(grants), 2)
+	assert.Equal(suite.T(), grants[0].Id, givenGrantId)
+	assert.Equal(suite.T(), grants[1].Id, uint64(receivedGrantInsertResult.ID().(int64)))
+}
+
+// UpdateGrantStatusById should successfully update the grant status
+func (suite *EmergencyAccessRepositoryTestSuite) TestUpdateGrantStatusById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	grantId := suite.insertTestGrant("testUpdateGrantGrantor@test.com", "testUpdateGrantGrantee@test.com")
+
+	err := suite.emergencyAccessRepository.UpdateGrantStatusById(model.EmergencyAccessGrantAccepted, grantId)
+	assert.Nil(suite.T(), err)
+
+	grant := model.EmergencyAccessGrant{}
+	suite.emergencyAccessRepository.FetchGrantById(&grant, grantId)
+	assert.Equal(suite.T(), grant.Status, model.EmergencyAccessGrantAccepted)
+}
+
+// FetchRequestsByGrantId should fetch the grant's requests with the latest request first
+func (suite *EmergencyAccessRepositoryTestSuite) TestFetchRequestsByGrantId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	grantId := suite.insertTestGrant("testFetchRequestsGrantor@test.com", "testFetchRequestsGrantee@test.com")
+	suite.insertTestRequest(grantId, model.EmergencyAccessRequestRejected, time.Now().UTC())
+	latestRequestId := suite.insertTestRequest(grantId, model.EmergencyAccessRequestPending, time.Now().UTC())
+
+	requests := model.EmergencyAccessRequests{}
+	err := suite.emergencyAccessRepository.FetchRequestsByGrantId(&requests, grantId)
+	assert.Nil(suite.T(), err)
+
+	assert.Equal(suite.T(), len(requests), 2)
+	assert.Equal(suite.T(), requests[0].Id, latestRequestId)
+}
+
+// FetchPendingRequestsAvailableBefore should fetch only pending requests whose waiting period elapsed
+func (suite *EmergencyAccessRepositoryTestSuite) TestFetchPendingRequestsAvailableBefore() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	grantId := suite.insertTestGrant("testElapsedRequestsGrantor@test.com", "testElapsedRequestsGrantee@test.com")
+	now := time.Now().UTC()
+	elapsedRequestId := suite.insertTestRequest(grantId, model.EmergencyAccessRequestPending, now.Add(-time.Hour))
+	suite.insertTestRequest(grantId, model.EmergencyAccessRequestPending, now.Add(time.Hour))
+	suite.insertTestRequest(grantId, model.EmergencyAccessRequestRejected, now.Add(-time.Hour))
+
+	requests := model.EmergencyAccessRequests{}
+	err := suite.emergencyAccessRepository.FetchPendingRequestsAvailableBefore(&requests, now)
+	assert.Nil(suite.T(), err)
+
+	assert.Equal(suite.T(), len(requests), 1)
+	assert.Equal(suite.T(), requests[0].Id, elapsedRequestId)
+}
+
+// ApproveRequestById should approve a pending request and store the sealed vault key
+func (suite *EmergencyAccessRepositoryTestSuite) TestApproveRequestById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	grantId := suite.insertTestGrant("testApproveRequestGrantor@test.com", "testApproveRequestGrantee@test.com")
+	requestId := suite.insertTestRequest(grantId, model.EmergencyAccessRequestPending, time.Now().UTC())
+
+	err := suite.emergencyAccessRepository.ApproveRequestById([]byte("sealedVaultKey"), requestId)
+	assert.Nil(suite.T(), err)
+
+	request := model.EmergencyAccessRequest{}
+	suite.emergencyAccessRepository.FetchRequestById(&request, requestId)
+	assert.Equal(suite.T(), request.Status, model.EmergencyAccessRequestApproved)
+	assert.Equal(suite.T(), request.SealedVaultKey, []byte("sealedVaultKey"))
+	assert.NotNil(suite.T(), request.ResolvedAt)
+}
+
+// ApproveRequestById should never approve an already rejected request
+func (suite *EmergencyAccessRepositoryTestSuite) TestApproveRequestByIdWithRejectedRequest() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	grantId := suite.insertTestGrant("testApproveRejectedGrantor@test.com", "testApproveRejectedGrantee@test.com")
+	requestId := suite.insertTestRequest(grantId, model.EmergencyAccessRequestRejected, time.Now().UTC())
+
+	err := suite.emergencyAccessRepository.ApproveRequestById([]byte("sealedVaultKey"), requestId)
+	assert.Equal(suite.T(), err, ErrNotFound)
+
+	request := model.EmergencyAccessRequest{}
+	suite.emergencyAccessRepository.FetchRequestById(&request, requestId)
+	assert.Equal(suite.T(), request.Status, model.EmergencyAccessRequestRejected)
+	assert.Nil(suite.T(), request.SealedVaultKey)
+}
+
+// RejectRequestById should reject a pending request
+func (suite *EmergencyAccessRepositoryTestSuite) TestRejectRequestById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	grantId := suite.insertTestGrant("testRejectRequestGrantor@test.com", "testRejectRequestGrantee@test.com")
+	requestId := suite.insertTestRequest(grantId, model.EmergencyAccessRequestPending, time.Now().UTC())
+
+	err := suite.emergencyAccessRepository.RejectRequestById(requestId)
+	assert.Nil(suite.T(), err)
+
+	request := model.EmergencyAccessRequest{}
+	suite.emergencyAccessRepository.FetchRequestById(&request, requestId)
+	assert.Equal(suite.T(), request.Status, model.EmergencyAccessRequestRejected)
+}
+
+func (suite *EmergencyAccessRepositoryTestSuite) insertTestGrant(grantorEmail string, granteeEmail string) uint64 {
+	grantorId, _ := suite.userRepository.InsertNewUser(&model.User{Email: grantorEmail, Username: "grantor", Password: []byte("password")})
+	granteeId, _ := suite.userRepository.InsertNewUser(&model.User{Email: granteeEmail, Username: "grantee", Password: []byte("password")})
+	grantId, _ := suite.emergencyAccessRepository.InsertNewGrant(&model.EmergencyAccessGrant{
+		GrantorId:      uint64(grantorId.ID().(int64)),
+		GranteeId:      uint64(granteeId.ID().(int64)),
+		AccessType:     model.EmergencyAccessTakeover,
+		WaitTimeInDays: 7,
+		Status:         model.EmergencyAccessGrantInvited,
+	})
+	return uint64(grantId.ID().(int64))
+}
+
+func (suite *EmergencyAccessRepositoryTestSuite) insertTestRequest(grantId uint64, status string, availableAt time.Time) uint64 {
+	requestId, _ := suite.emergencyAccessRepository.InsertNewRequest(&model.EmergencyAccessRequest{
+		GrantId: grantId, Status: status, RequestedAt: time.Now().UTC(), AvailableAt: availableAt,
+	})
+	return uint64(requestId.ID().(int64))
+}
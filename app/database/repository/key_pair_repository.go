@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+)
+
+type KeyPairRepository interface {
+	InsertNewKeyPair(keyPair *model.KeyPair) error
+	FetchKeyPairByUserId(keyPair *model.KeyPair, userId uint64) error
+}
+
+type keyPairRepositoryService struct {
+	session *db.Session
+}
+
+func NewKeyPairRepositoryService(session *db.Session) *keyPairRepositoryService {
+	return &keyPairRepositoryService{session: session}
+}
+
+func (repository *keyPairRepositoryService) KeyPair() db.Collection {
+	return (*repository.session).Collection("user_key_pair")
+}
+
+func (repository *keyPairRepositoryService) InsertNewKeyPair(keyPair *model.KeyPair) error {
+	_, err := insert(repository.KeyPair(), keyPair)
+	return translateError(err)
+}
+
+func (repository *keyPairRepositoryService) FetchKeyPairByUserId(keyPair *model.KeyPair, userId uint64) error {
+	return translateError((*repository.session).SQL().Select().From("user_key_pair").Where("user_id = ?", userId).One(keyPair))
+}
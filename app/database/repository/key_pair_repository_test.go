@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+)
+
+type KeyPairRepositoryTestSuite struct {
+	suite.Suite
+	driver             string
+	session            *db.Session
+	isDatabaseUp       bool
+	isDatabaseMigrated bool
+	userRepository     UserRepository
+	keyPairRepository  KeyPairRepository
+}
+
+func TestKeyPairSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &KeyPairRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *KeyPairRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.keyPairRepository = NewKeyPairRepositoryService(suite.session)
+}
+
+func (suite *KeyPairRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// InsertNewKeyPair and FetchKeyPairByUserId should successfully store and fetch a user's key pair
+func (suite *KeyPairRepositoryTestSuite) TestInsertNewKeyPairAndFetchKeyPairByUserId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userInsertResult, _ := suite.userRepository.InsertNewUser(
+		&model.User{Email: "testKeyPair@test.com", Username: "keyPairUser", Password: []byte("password")},
+	)
+	userId := uint64(userInsertResult.ID().(int64))
+
+	err := suite.keyPairRepository.InsertNewKeyPair(
+		&model.KeyPair{UserId: userId, PublicKey: []byte("publicKey"), WrappedPrivateKey: []byte("wrappedPrivateKey")},
+	)
+	assert.Nil(suite.T(), err)
+
+	keyPair := model.KeyPair{}
+	err = suite.keyPairRepository.FetchKeyPairByUserId(&keyPair, userId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), keyPair.PublicKey, []byte("publicKey"))
+	assert.Equal(suite.T(), keyPair.WrappedPrivateKey, []byte("wrappedPrivateKey"))
+}
+
+// InsertNewKeyPair should allow only one key pair per user
+func (suite *KeyPairRepositoryTestSuite) TestInsertNewKeyPairForUserWithKeyPair() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userInsertResult, _ := suite.userRepository.InsertNewUser(
+		&model.User{Email: "testDuplicateKeyPair@test.com", Username: "keyPairUser", Password: []byte("password")},
+	)
+	keyPair := &model.KeyPair{UserId: uint64(userInsertResult.ID().(int64)), PublicKey: []byte("publicKey"), WrappedPrivateKey: []byte("key")}
+
+	suite.keyPairRepository.InsertNewKeyPair(keyPair)
+	err := suite.keyPairRepository.InsertNewKeyPair(keyPair)
+	assert.NotNil(suite.T(), err)
+}
@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type KnownDeviceRepository interface {
+	InsertNewKnownDevice(device *model.KnownDevice) (db.InsertResult, error)
+	FetchKnownDevicesByUserId(devices *model.KnownDevices, userId uint64) error
+	UpdateLastSeenById(lastSeenAt time.Time, id uint64) error
+}
+
+type knownDeviceRepositoryService struct {
+	session *db.Session
+}
+
+func NewKnownDeviceRepositoryService(session *db.Session) *knownDeviceRepositoryService {
+	return &knownDeviceRepositoryService{session: session}
+}
+
+func (repository *knownDeviceRepositoryService) KnownDevice() db.Collection {
+	return (*repository.session).Collection("known_device")
+}
+
+func (repository *knownDeviceRepositoryService) InsertNewKnownDevice(device *model.KnownDevice) (db.InsertResult, error) {
+	return insert(repository.KnownDevice(), device)
+}
+
+func (repository *knownDeviceRepositoryService) FetchKnownDevicesByUserId(devices *model.KnownDevices, userId uint64) error {
+	return translateError((*repository.session).SQL().Select().From("known_device").Where("user_id = ?", userId).All(devices))
+}
+
+func (repository *knownDeviceRepositoryService) UpdateLastSeenById(lastSeenAt time.Time, id uint64) error {
+	update := (*repository.session).SQL().Update("known_device").Set("last_seen_at", lastSeenAt).Where("id = ?", id)
+	_, err := update.Exec()
+	return translateError(err)
+}
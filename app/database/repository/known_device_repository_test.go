@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+	"time"
+)
+
+type KnownDeviceRepositoryTestSuite struct {
+	suite.Suite
+	driver                string
+	session               *db.Session
+	isDatabaseUp          bool
+	isDatabaseMigrated    bool
+	userRepository        UserRepository
+	knownDeviceRepository KnownDeviceRepository
+}
+
+func TestKnownDeviceSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &KnownDeviceRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *KnownDeviceRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.knownDeviceRepository = NewKnownDeviceRepositoryService(suite.session)
+}
+
+func (suite *KnownDeviceRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// FetchKnownDevicesByUserId should fetch only the known devices of the given user
+func (suite *KnownDeviceRepositoryTestSuite) TestFetchKnownDevicesByUserId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testFetchKnownDevices@test.com")
+	additionalUserId := suite.insertTestUser("testFetchKnownDevicesAdditional@test.com")
+	now := time.Now().UTC()
+	suite.knownDeviceRepository.InsertNewKnownDevice(&model.KnownDevice{UserId: userId, UserAgent: "Firefox", IpAddress: "10.0.0.1", LastSeenAt: now})
+	suite.knownDeviceRepository.InsertNewKnownDevice(&model.KnownDevice{UserId: userId, UserAgent: "Chrome", IpAddress: "10.0.0.2", LastSeenAt: now})
+	suite.knownDeviceRepository.InsertNewKnownDevice(&model.KnownDevice{UserId: additionalUserId, UserAgent: "Safari", IpAddress: "10.0.0.3", LastSeenAt: now})
+
+	knownDevices := model.KnownDevices{}
+	err := suite.knownDeviceRepository.FetchKnownDevicesByUserId(&knownDevices, userId)
+	assert.Nil(suite.T(), err)
+
+	assert.Equal(suite.T(), len(knownDevices), 2)
+	for _, knownDevice := range knownDevices {
+		assert.Equal(suite.T(), knownDevice.UserId, userId)
+	}
+}
+
+// UpdateLastSeenById should successfully update the last seen time of a known device
+func (suite *KnownDeviceRepositoryTestSuite) TestUpdateLastSeenById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testUpdateLastSeen@test.com")
+	insertResult, err := suite.knownDeviceRepository.InsertNewKnownDevice(
+		&model.KnownDevice{UserId: userId, UserAgent: "Firefox", IpAddress: "10.0.0.1", LastSeenAt: time.Now().UTC().Add(-time.Hour)},
+	)
+	assert.Nil(suite.T(), err)
+
+	lastSeenAt := time.Now().UTC().Truncate(time.Second)
+	err = suite.knownDeviceRepository.UpdateLastSeenById(lastSeenAt, uint64(insertResult.ID().(int64)))
+	assert.Nil(suite.T(), err)
+
+	updatedDevice := model.KnownDevice{}
+	(*suite.session).Collection("known_device").Find("id", insertResult.ID()).One(&updatedDevice)
+	assert.True(suite.T(), updatedDevice.LastSeenAt.Equal(lastSeenAt))
+}
+
+func (suite *KnownDeviceRepositoryTestSuite) insertTestUser(email string) uint64 {
+	userId, _ := suite.userRepository.InsertNewUser(&model.User{Email: email, Username: "knownDeviceUser", Password: []byte("password")})
+	return uint64(userId.ID().(int64))
+}
@@ -0,0 +1,202 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+)
+
+// ErrOrganizationChanged is a conflict matching ErrConflict, returned when the organization key was rotated or its members
+// and entries changed in the meantime
+var ErrOrganizationChanged = fmt.Errorf("%w: organization key, members or entries changed concurrently", ErrConflict)
+
+type OrganizationRepository interface {
+	InsertNewOrganization(organization *model.Organization, owner *model.OrganizationMembership) error
+	FetchMembershipDetailsById(membership *model.OrganizationMembershipDetails, id uint64) error
+	FetchMembershipByOrganizationIdAndUserId(membership *model.OrganizationMembershipDetails, organizationId uint64, userId uint64) error
+	FetchMembershipsByOrganizationId(memberships *model.OrganizationMemberships, organizationId uint64) error
+	FetchMembershipsDetailsByUserId(memberships *model.OrganizationMembershipsDetails, userId uint64) error
+	FetchMembershipsDetailsByOrganizationId(memberships *model.OrganizationMembershipsDetails, organizationId uint64) error
+	InsertNewMembership(membership *model.OrganizationMembership, organizationKeyVersion int) (db.InsertResult, error)
+	UpdateMembershipStatusById(status string, id uint64) error
+	RotateOrganizationKey(
+		organizationId uint64, removedMembershipId uint64, sealedOrganizationKeys map[uint64][]byte, reencryptedPasswords model.Passwords,
+	) error
+}
+
+type organizationRepositoryService struct {
+	session *db.Session
+}
+
+func NewOrganizationRepositoryService(session *db.Session) *organizationRepositoryService {
+	return &organizationRepositoryService{session: session}
+}
+
+func (repository *organizationRepositoryService) Organization() db.Collection {
+	return (*repository.session).Collection("organization")
+}
+
+func (repository *organizationRepositoryService) OrganizationMember() db.Collection {
+	return (*repository.session).Collection("organization_member")
+}
+
+// InsertNewOrganization creates the organization together with its owner's membership
+func (repository *organizationRepositoryService) InsertNewOrganization(
+	organization *model.Organization, owner *model.OrganizationMembership,
+) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		organizationInsertResult, err := session.Collection("organization").Insert(organization)
+		if err != nil {
+			return err
+		}
+		organization.Id = uint64(organizationInsertResult.ID().(int64))
+
+		owner.OrganizationId = organization.Id
+		ownerInsertResult, err := session.Collection("organization_member").Insert(owner)
+		if err != nil {
+			return err
+		}
+		owner.Id = uint64(ownerInsertResult.ID().(int64))
+
+		return nil
+	}))
+}
+
+func (repository *organizationRepositoryService) FetchMembershipDetailsById(membership *model.OrganizationMembershipDetails, id uint64) error {
+	return translateError(repository.membershipDetailsQuery().Where("m.id = ?", id).One(membership))
+}
+
+// FetchMembershipByOrganizationIdAndUserId fetches the membership together with the version of the organization key
+// its sealed key belongs to
+func (repository *organizationRepositoryService) FetchMembershipByOrganizationIdAndUserId(
+	membership *model.OrganizationMembershipDetails, organizationId uint64, userId uint64,
+) error {
+	return translateError(
+		repository.membershipDetailsQuery().Where("m.organization_id = ? AND m.user_id = ?", organizationId, userId).One(membership),
+	)
+}
+
+func (repository *organizationRepositoryService) FetchMembershipsByOrganizationId(
+	memberships *model.OrganizationMemberships, organizationId uint64,
+) error {
+	return translateError(
+		(*repository.session).SQL().Select().From("organization_member").Where("organization_id = ?", organizationId).All(memberships),
+	)
+}
+
+func (repository *organizationRepositoryService) FetchMembershipsDetailsByUserId(
+	memberships *model.OrganizationMembershipsDetails, userId uint64,
+) error {
+	return translateError(repository.membershipDetailsQuery().Where("m.user_id = ?", userId).OrderBy("o.name").All(memberships))
+}
+
+func (repository *organizationRepositoryService) FetchMembershipsDetailsByOrganizationId(
+	memberships *model.OrganizationMembershipsDetails, organizationId uint64,
+) error {
+	return translateError(
+		repository.membershipDetailsQuery().Where("m.organization_id = ?", organizationId).OrderBy("m.id").All(memberships),
+	)
+}
+
+// InsertNewMembership stores the membership with the organization locked, its key has to be sealed from the given
+// version of the organization key, otherwise ErrOrganizationChanged is returned
+func (repository *organizationRepositoryService) InsertNewMembership(
+	membership *model.OrganizationMembership, organizationKeyVersion int,
+) (db.InsertResult, error) {
+	var insertResult db.InsertResult
+	err := transaction(*repository.session, func(session db.Session) error {
+		keyVersion, err := lockOrganization(session, membership.OrganizationId)
+		if err != nil {
+			return err
+		}
+		if keyVersion != organizationKeyVersion {
+			return ErrOrganizationChanged
+		}
+
+		insertResult, err = session.Collection("organization_member").Insert(membership)
+		return err
+	})
+	return insertResult, translateError(err)
+}
+
+// UpdateMembershipStatusById updates the status with the organization of the membership locked
+func (repository *organizationRepositoryService) UpdateMembershipStatusById(status string, id uint64) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		membership := model.OrganizationMembership{}
+		err := session.SQL().Select("organization_id").From("organization_member").Where("id = ?", id).One(&membership)
+		if err != nil {
+			return err
+		}
+		if _, err = lockOrganization(session, membership.OrganizationId); err != nil {
+			return err
+		}
+
+		_, err = session.SQL().Update("organization_member").Set("status", status).Where("id = ?", id).Exec()
+		return err
+	}))
+}
+
+// RotateOrganizationKey removes the membership and replaces the organization key in a single transaction with the organization
+// locked, every remaining member needs a newly sealed key and every shared entry has to be re-encrypted,
+// otherwise ErrOrganizationChanged is returned and nothing changes
+func (repository *organizationRepositoryService) RotateOrganizationKey(
+	organizationId uint64, removedMembershipId uint64, sealedOrganizationKeys map[uint64][]byte, reencryptedPasswords model.Passwords,
+) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		if _, err := lockOrganization(session, organizationId); err != nil {
+			return err
+		}
+
+		_, err := session.SQL().
+			DeleteFrom("organization_member").
+			Where("id = ? AND organization_id = ?", removedMembershipId, organizationId).
+			Exec()
+		if err != nil {
+			return err
+		}
+
+		for membershipId, sealedOrganizationKey := range sealedOrganizationKeys {
+			update := session.SQL().
+				Update("organization_member").
+				Set("sealed_organization_key", sealedOrganizationKey).
+				Where("id = ? AND organization_id = ?", membershipId, organizationId)
+			if err = execAffectingRows(update); err != nil {
+				return ErrOrganizationChanged
+			}
+		}
+
+		for _, password := range reencryptedPasswords {
+			update := session.SQL().
+				Update("password").
+				Set("password", password.Password, "totp_uri", password.TotpUri).
+				Where("id = ? AND organization_id = ?", password.Id, organizationId)
+			if err = execAffectingRows(update); err != nil {
+				return ErrOrganizationChanged
+			}
+		}
+
+		memberships, err := session.Collection("organization_member").Find("organization_id", organizationId).Count()
+		if err != nil {
+			return err
+		}
+		passwords, err := session.Collection("password").Find("organization_id", organizationId).Count()
+		if err != nil {
+			return err
+		}
+		if memberships != uint64(len(sealedOrganizationKeys)) || passwords != uint64(len(reencryptedPasswords)) {
+			return ErrOrganizationChanged
+		}
+
+		update := session.SQL().Update("organization").Set("key_version = key_version + 1").Where("id = ?", organizationId)
+		_, err = update.Exec()
+		return err
+	}))
+}
+
+func (repository *organizationRepositoryService) membershipDetailsQuery() db.Selector {
+	return (*repository.session).SQL().
+		Select("m.*", "o.name AS organization_name", "o.key_version AS organization_key_version", "u.email").
+		From("organization_member AS m").
+		Join("organization AS o").On("o.id = m.organization_id").
+		Join("user AS u").On("u.id = m.user_id")
+}
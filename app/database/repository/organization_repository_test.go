@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+)
+
+type OrganizationRepositoryTestSuite struct {
+	suite.Suite
+	driver                 string
+	session                *db.Session
+	isDatabaseUp           bool
+	isDatabaseMigrated     bool
+	userRepository         UserRepository
+	passwordRepository     PasswordRepository
+	organizationRepository OrganizationRepository
+}
+
+func TestOrganizationSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &OrganizationRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *OrganizationRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.passwordRepository = NewPasswordRepositoryService(suite.session)
+	suite.organizationRepository = NewOrganizationRepositoryService(suite.session)
+}
+
+func (suite *OrganizationRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// InsertNewOrganization should create the organization together with its owner
+func (suite *OrganizationRepositoryTestSuite) TestInsertNewOrganization() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	ownerId := suite.insertTestUser("testInsertOrganization@test.com")
+	organizationId, ownerMembershipId := suite.insertTestOrganization(ownerId)
+	assert.NotZero(suite.T(), organizationId)
+
+	memberships := model.OrganizationMembershipsDetails{}
+	err := suite.organizationRepository.FetchMembershipsDetailsByUserId(&memberships, ownerId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), len(memberships), 1)
+	assert.Equal(suite.T(), memberships[0].Id, ownerMembershipId)
+	assert.Equal(suite.T(), memberships[0].OrganizationName, "team")
+	assert.Equal(suite.T(), memberships[0].Email, "testInsertOrganization@test.com")
+	assert.Equal(suite.T(), memberships[0].Role, model.OrganizationOwner)
+}
+
+// InsertNewMembership should not add the same user to an organization twice
+func (suite *OrganizationRepositoryTestSuite) TestInsertNewMembershipTwice() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	organizationId, _ := suite.insertTestOrganization(suite.insertTestUser("testDuplicateMemberOwner@test.com"))
+	memberId := suite.insertTestUser("testDuplicateMember@test.com")
+	suite.insertTestMembership(organizationId, memberId)
+
+	_, err := suite.organizationRepository.InsertNewMembership(&model.OrganizationMembership{
+		OrganizationId: organizationId, UserId: memberId, Role: model.OrganizationMember,
+		Status: model.OrganizationMemberInvited, SealedOrganizationKey: []byte("sealedKey"),
+	}, 1)
+	assert.NotNil(suite.T(), err)
+}
+
+// UpdateMembershipStatusById should update the membership status
+func (suite *OrganizationRepositoryTestSuite) TestUpdateMembershipStatusById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	organizationId, _ := suite.insertTestOrganization(suite.insertTestUser("testAcceptMembershipOwner@test.com"))
+	memberId := suite.insertTestUser("testAcceptMembership@test.com")
+	membershipId := suite.insertTestMembership(organizationId, memberId)
+
+	err := suite.organizationRepository.UpdateMembershipStatusById(model.OrganizationMemberAccepted, membershipId)
+	assert.Nil(suite.T(), err)
+
+	membership := model.OrganizationMembershipDetails{}
+	suite.organizationRepository.FetchMembershipByOrganizationIdAndUserId(&membership, organizationId, memberId)
+	assert.Equal(suite.T(), membership.Status, model.OrganizationMemberAccepted)
+}
+
+// FetchMembershipsDetailsByOrganizationId should fetch all members of the organization with their e-mails
+func (suite *OrganizationRepositoryTestSuite) TestFetchMembershipsDetailsByOrganizationId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	organizationId, ownerMembershipId := suite.insertTestOrganization(suite.insertTestUser("testFetchMembersOwner@test.com"))
+	membershipId := suite.insertTestMembership(organizationId, suite.insertTestUser("testFetchMembers@test.com"))
+	suite.insertTestOrganization(suite.insertTestUser("testFetchMembersAdditional@test.com"))
+
+	memberships := model.OrganizationMembershipsDetails{}
+	err := suite.organizationRepository.FetchMembershipsDetailsByOrganizationId(&memberships, organizationId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), len(memberships), 2)
+	assert.Equal(suite.T(), memberships[0].Id, ownerMembershipId)
+	assert.Equal(suite.T(), memberships[1].Id, membershipId)
+	assert.Equal(suite.T(), memberships[1].Email, "testFetchMembers@test.com")
+
+	membership := model.OrganizationMembershipDetails{}
+	err = suite.organizationRepository.FetchMembershipDetailsById(&membership, membershipId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), membership.OrganizationId, organizationId)
+}
+
+// RotateOrganizationKey should remove the member, replace the sealed keys and re-encrypt the shared vault
+func (suite *OrganizationRepositoryTestSuite) TestRotateOrganizationKey() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	ownerId := suite.insertTestUser("testRotateKeyOwner@test.com")
+	organizationId, ownerMembershipId := suite.insertTestOrganization(ownerId)
+	removedMembershipId := suite.insertTestMembership(organizationId, suite.insertTestUser("testRotateKeyRemoved@test.com"))
+	passwordId := suite.insertTestSharedPassword(ownerId, organizationId)
+
+	err := suite.organizationRepository.RotateOrganizationKey(
+		organizationId, removedMembershipId,
+		map[uint64][]byte{ownerMembershipId: []byte("rotatedSealedKey")},
+		model.Passwords{model.Password{Id: passwordId, Password: []byte("rotatedPassword")}},
+	)
+	assert.Nil(suite.T(), err)
+
+	memberships := model.OrganizationMemberships{}
+	suite.organizationRepository.FetchMembershipsByOrganizationId(&memberships, organizationId)
+	assert.Equal(suite.T(), len(memberships), 1)
+	assert.Equal(suite.T(), memberships[0].SealedOrganizationKey, []byte("rotatedSealedKey"))
+
+	password := model.Password{}
+	suite.passwordRepository.FetchPasswordById(context.Background(), &password, passwordId)
+	assert.Equal(suite.T(), password.Password, []byte("rotatedPassword"))
+
+	organization := model.Organization{}
+	(*suite.session).Collection("organization").Find(organizationId).One(&organization)
+	assert.Equal(suite.T(), organization.KeyVersion, 2)
+}
+
+// RotateOrganizationKey should change nothing if a member or an entry was left out of the rotation
+func (suite *OrganizationRepositoryTestSuite) TestRotateOrganizationKeyWithConcurrentChange() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	ownerId := suite.insertTestUser("testRotateKeyChangedOwner@test.com")
+	organizationId, ownerMembershipId := suite.insertTestOrganization(ownerId)
+	removedMembershipId := suite.insertTestMembership(organizationId, suite.insertTestUser("testRotateKeyChangedRemoved@test.com"))
+	suite.insertTestSharedPassword(ownerId, organizationId)
+
+	err := suite.organizationRepository.RotateOrganizationKey(
+		organizationId, removedMembershipId, map[uint64][]byte{ownerMembershipId: []byte("rotatedSealedKey")}, model.Passwords{},
+	)
+	assert.Equal(suite.T(), err, ErrOrganizationChanged)
+
+	memberships := model.OrganizationMemberships{}
+	suite.organizationRepository.FetchMembershipsByOrganizationId(&memberships, organizationId)
+	assert.Equal(suite.T(), len(memberships), 2)
+}
+
+func (suite *OrganizationRepositoryTestSuite) insertTestUser(email string) uint64 {
+	userId, _ := suite.userRepository.InsertNewUser(&model.User{Email: email, Username: "organizationUser", Password: []byte("password")})
+	return uint64(userId.ID().(int64))
+}
+
+func (suite *OrganizationRepositoryTestSuite) insertTestOrganization(ownerId uint64) (uint64, uint64) {
+	organization := model.Organization{Name: "team"}
+	owner := model.OrganizationMembership{
+		UserId: ownerId, Role: model.OrganizationOwner, Status: model.OrganizationMemberAccepted, SealedOrganizationKey: []byte("sealedKey"),
+	}
+	suite.organizationRepository.InsertNewOrganization(&organization, &owner)
+	return organization.Id, owner.Id
+}
+
+func (suite *OrganizationRepositoryTestSuite) insertTestMembership(organizationId uint64, userId uint64) uint64 {
+	membershipId, _ := suite.organizationRepository.InsertNewMembership(&model.OrganizationMembership{
+		OrganizationId: organizationId, UserId: userId, Role: model.OrganizationMember,
+		Status: model.OrganizationMemberInvited, SealedOrganizationKey: []byte("sealedKey"),
+	}, 1)
+	return uint64(membershipId.ID().(int64))
+}
+
+func (suite *OrganizationRepositoryTestSuite) insertTestSharedPassword(userId uint64, organizationId uint64) uint64 {
+	passwordId, _ := suite.passwordRepository.InsertNewPassword(&model.Password{UserId: userId, Name: "shared", Password: []byte("password")})
+	suite.passwordRepository.MovePasswordById(userId, &organizationId, 1, []byte("password"), nil, uint64(passwordId.ID().(int64)))
+	return uint64(passwordId.ID().(int64))
+}
@@ -1,21 +1,46 @@
 package repository
 
 import (
+	"context"
+	"database/sql"
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/database"
 	"github.com/KristijanFaust/gokeeper/app/database/model"
 	"github.com/iancoleman/strcase"
 	"github.com/upper/db/v4"
 )
 
+// VersionConflictError is returned by compare-and-swap writes of a password that changed since the expected version
+type VersionConflictError struct {
+	CurrentVersion int64
+}
+
+func (err *VersionConflictError) Error() string {
+	return fmt.Sprintf("password changed since the expected version, the current version is %d", err.CurrentVersion)
+}
+
+// Is makes version conflicts match ErrConflict
+func (err *VersionConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
 type PasswordRepository interface {
 	InsertNewPassword(password *model.Password) (db.InsertResult, error)
-	UpdatePasswordById(name string, password []byte, passwordId uint64) error
-	DeletePasswordById(passwordId uint64) error
-	FetchPasswordById(password *model.Password, passwordId uint64) error
-	FetchAllByUserId(passwords *model.Passwords, userId uint64, queryFields []string) error
+	UpdatePasswordById(password *model.Password, expectedVersion *int64) error
+	DeletePasswordById(passwordId uint64, expectedVersion *int64) error
+	// FetchPasswordById and FetchAllByUserId may read from a replica, unless the context reads from the primary
+	FetchPasswordById(ctx context.Context, password *model.Password, passwordId uint64) error
+	FetchAllByUserId(ctx context.Context, passwords *model.Passwords, userId uint64, queryFields []string) error
+	FetchAllByOrganizationId(passwords *model.Passwords, organizationId uint64) error
+	MovePasswordById(
+		userId uint64, organizationId *uint64, organizationKeyVersion int, password []byte, totpUri []byte, passwordId uint64,
+	) (int64, error)
+	FetchVaultChanges(changes *model.VaultChanges, userId uint64, sinceRevision int64) error
 }
 
 type passwordRepositoryService struct {
-	session *db.Session
+	session  *db.Session
+	replicas *database.ReplicaSet
 }
 
 func NewPasswordRepositoryService(session *db.Session) *passwordRepositoryService {
@@ -26,30 +51,245 @@ func (repository *passwordRepositoryService) Password() db.Collection {
 	return (*repository.session).Collection("password")
 }
 
+// InsertNewPassword inserts the first version of the password with the next revision of its owner's vault
 func (repository *passwordRepositoryService) InsertNewPassword(password *model.Password) (db.InsertResult, error) {
-	return repository.Password().Insert(password)
+	var insertResult db.InsertResult
+	err := transaction(*repository.session, func(session db.Session) error {
+		revision, err := nextVaultRevision(session, password.UserId)
+		if err != nil {
+			return err
+		}
+
+		password.Revision = revision
+		password.Version = 1
+		insertResult, err = session.Collection("password").Insert(password)
+		return err
+	})
+	return insertResult, translateError(err)
 }
 
-func (repository *passwordRepositoryService) UpdatePasswordById(name string, password []byte, passwordId uint64) error {
-	update := (*repository.session).SQL().Update("password").Set("name", name, "password", password).Where("id = ?", passwordId)
-	_, err := update.Exec()
-	return err
+// UpdatePasswordById updates the name, the password and the TOTP URI of the entry and reloads it with its new version and revision,
+// a nil expected version updates the password unconditionally
+func (repository *passwordRepositoryService) UpdatePasswordById(password *model.Password, expectedVersion *int64) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		return updatePassword(session, password, expectedVersion)
+	}))
 }
 
-func (repository *passwordRepositoryService) DeletePasswordById(passwordId uint64) error {
-	delete := (*repository.session).SQL().DeleteFrom("password").Where("id = ?", passwordId)
-	_, err := delete.Exec()
-	return err
+// DeletePasswordById leaves a tombstone in the owner's vault, a nil expected version deletes the password unconditionally
+func (repository *passwordRepositoryService) DeletePasswordById(passwordId uint64, expectedVersion *int64) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		userPassword := model.Password{}
+		if err := session.SQL().Select("user_id").From("password").Where("id = ?", passwordId).One(&userPassword); err != nil {
+			return err
+		}
+		revision, err := nextVaultRevision(session, userPassword.UserId)
+		if err != nil {
+			return err
+		}
+
+		if err = execAffectingRows(session.SQL().DeleteFrom("password").Where(versionCondition(passwordId, expectedVersion))); err != nil {
+			if err == ErrNotFound {
+				return versionConflict(session, passwordId)
+			}
+			return err
+		}
+		_, err = session.Collection("password_tombstone").Insert(
+			&model.PasswordTombstone{PasswordId: passwordId, UserId: userPassword.UserId, Revision: revision},
+		)
+		return err
+	}))
 }
 
-func (repository *passwordRepositoryService) FetchPasswordById(password *model.Password, passwordId uint64) error {
-	return (*repository.session).SQL().Select().From("password").Where("id = ?", passwordId).One(password)
+func (repository *passwordRepositoryService) FetchPasswordById(ctx context.Context, password *model.Password, passwordId uint64) error {
+	return translateError(readSession(ctx, *repository.session, repository.replicas).SQL().Select().From("password").Where("id = ?", passwordId).One(password))
 }
 
-func (repository *passwordRepositoryService) FetchAllByUserId(passwords *model.Passwords, userId uint64, queryFields []string) error {
-	query := (*repository.session).SQL().Select().Columns()
+// FetchAllByUserId fetches the user's personal vault, entries moved to an organization are left out
+func (repository *passwordRepositoryService) FetchAllByUserId(
+	ctx context.Context, passwords *model.Passwords, userId uint64, queryFields []string,
+) error {
+	query := readSession(ctx, *repository.session, repository.replicas).SQL().Select().Columns()
 	for _, field := range queryFields {
 		query = query.Columns(strcase.ToSnake(field))
 	}
-	return query.From("password").Where("user_id = ?", userId).All(passwords)
+	return translateError(query.From("password").Where("user_id = ? AND organization_id IS NULL", userId).All(passwords))
+}
+
+func (repository *passwordRepositoryService) FetchAllByOrganizationId(passwords *model.Passwords, organizationId uint64) error {
+	return translateError(
+		(*repository.session).SQL().Select().From("password").Where("organization_id = ?", organizationId).All(passwords),
+	)
+}
+
+// MovePasswordById moves an entry between a personal and a shared vault, a nil organization id means the personal vault
+// of the given user, the password and the TOTP URI have to be encrypted with the key of the target vault. Moving into an organization
+// locks it and requires its key to still be at the given version, otherwise ErrOrganizationChanged is returned. Leaving a personal
+// vault leaves a tombstone in it, the new revision of the password is returned.
+func (repository *passwordRepositoryService) MovePasswordById(
+	userId uint64, organizationId *uint64, organizationKeyVersion int, password []byte, totpUri []byte, passwordId uint64,
+) (int64, error) {
+	var revision int64
+	err := transaction(*repository.session, func(session db.Session) error {
+		if organizationId != nil {
+			keyVersion, err := lockOrganization(session, *organizationId)
+			if err != nil {
+				return err
+			}
+			if keyVersion != organizationKeyVersion {
+				return ErrOrganizationChanged
+			}
+		}
+
+		movedPassword := model.Password{}
+		err := session.SQL().Select("user_id", "organization_id").From("password").Where("id = ?", passwordId).One(&movedPassword)
+		if err != nil {
+			return err
+		}
+		if revision, err = nextVaultRevision(session, userId); err != nil {
+			return err
+		}
+
+		if movedPassword.OrganizationId == nil && (organizationId != nil || movedPassword.UserId != userId) {
+			tombstoneRevision := revision
+			if movedPassword.UserId != userId {
+				if tombstoneRevision, err = nextVaultRevision(session, movedPassword.UserId); err != nil {
+					return err
+				}
+			}
+			_, err = session.Collection("password_tombstone").Insert(
+				&model.PasswordTombstone{PasswordId: passwordId, UserId: movedPassword.UserId, Revision: tombstoneRevision},
+			)
+			if err != nil {
+				return err
+			}
+		}
+		if organizationId == nil {
+			_, err = session.SQL().DeleteFrom("password_tombstone").Where("password_id = ? AND user_id = ?", passwordId, userId).Exec()
+			if err != nil {
+				return err
+			}
+		}
+
+		return execAffectingRows(session.SQL().
+			Update("password").
+			Set("user_id", userId, "organization_id", organizationId, "password", password, "totp_uri", totpUri, "revision", revision).
+			Where("id = ?", passwordId),
+		)
+	})
+	return revision, translateError(err)
+}
+
+// FetchVaultChanges fetches the entries and tombstones of the user's personal vault with a revision after the given one,
+// everything is read from the same snapshot so the returned revision covers exactly the returned changes
+func (repository *passwordRepositoryService) FetchVaultChanges(changes *model.VaultChanges, userId uint64, sinceRevision int64) error {
+	options := &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true}
+	return translateError(transactionWithOptions(*repository.session, options, func(session db.Session) error {
+		row, err := session.SQL().QueryRow(`SELECT "vault_revision" FROM "user" WHERE "id" = ?`, userId)
+		if err != nil {
+			return err
+		}
+		if err = row.Scan(&changes.Revision); err != nil {
+			if err == sql.ErrNoRows {
+				return ErrNotFound
+			}
+			return err
+		}
+
+		changes.Passwords = model.Passwords{}
+		err = session.SQL().
+			Select().
+			From("password").
+			Where("user_id = ? AND organization_id IS NULL AND revision > ?", userId, sinceRevision).
+			OrderBy("revision").
+			All(&changes.Passwords)
+		if err != nil {
+			return err
+		}
+
+		changes.Tombstones = model.PasswordTombstones{}
+		return session.SQL().
+			Select().
+			From("password_tombstone").
+			Where("user_id = ? AND revision > ?", userId, sinceRevision).
+			OrderBy("revision").
+			All(&changes.Tombstones)
+	}))
+}
+
+// nextVaultRevision increments the revision of the user's vault, the row lock it takes serializes writes to the vault
+// until the transaction ends so revisions are committed in order. MySQL has no RETURNING clause, so the revision
+// is read back under the row lock of the update.
+func nextVaultRevision(session db.Session, userId uint64) (int64, error) {
+	var row *sql.Row
+	var err error
+	if database.Driver(session) == database.DriverMysql {
+		update := session.SQL().Update("user").Set("vault_revision = vault_revision + 1").Where("id = ?", userId)
+		if err = execAffectingRows(update); err != nil {
+			return 0, err
+		}
+		row, err = session.SQL().QueryRow(`SELECT "vault_revision" FROM "user" WHERE "id" = ?`, userId)
+	} else {
+		row, err = session.SQL().QueryRow(
+			`UPDATE "user" SET "vault_revision" = "vault_revision" + 1 WHERE "id" = ? RETURNING "vault_revision"`, userId,
+		)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var revision int64
+	if err = row.Scan(&revision); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrNotFound
+		}
+		return 0, err
+	}
+	return revision, nil
+}
+
+// updatePassword is a compare-and-swap on the version of the password, returning a VersionConflictError
+// if the password changed since the expected version
+func updatePassword(session db.Session, password *model.Password, expectedVersion *int64) error {
+	current := model.Password{}
+	if err := session.SQL().Select("user_id").From("password").Where("id = ?", password.Id).One(&current); err != nil {
+		return err
+	}
+	revision, err := nextVaultRevision(session, current.UserId)
+	if err != nil {
+		return err
+	}
+
+	update := session.SQL().
+		Update("password").
+		Set(
+			"name", password.Name, "password", password.Password, "totp_uri", password.TotpUri,
+			"revision", revision, "version", db.Raw(`"version" + 1`),
+		).
+		Where(versionCondition(password.Id, expectedVersion))
+	if err = execAffectingRows(update); err != nil {
+		if err == ErrNotFound {
+			return versionConflict(session, password.Id)
+		}
+		return err
+	}
+
+	return session.SQL().Select().From("password").Where("id = ?", password.Id).One(password)
+}
+
+// versionConflict returns the conflict with the current version of the password, or ErrNotFound if it got deleted
+func versionConflict(session db.Session, passwordId uint64) error {
+	current := model.Password{}
+	if err := session.SQL().Select("version").From("password").Where("id = ?", passwordId).One(&current); err != nil {
+		return err
+	}
+	return &VersionConflictError{CurrentVersion: current.Version}
+}
+
+func versionCondition(passwordId uint64, expectedVersion *int64) db.Cond {
+	condition := db.Cond{"id": passwordId}
+	if expectedVersion != nil {
+		condition["version"] = *expectedVersion
+	}
+	return condition
 }
@@ -1,10 +1,10 @@
 package repository
 
 import (
+	"context"
 	"github.com/KristijanFaust/gokeeper/app/database"
 	"github.com/KristijanFaust/gokeeper/app/database/model"
 	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
-	"github.com/KristijanFaust/gokeeper/app/utility/test/testcontainersutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/upper/db/v4"
@@ -13,6 +13,7 @@ import (
 
 type PasswordTestSuite struct {
 	suite.Suite
+	driver             string
 	session            *db.Session
 	isDatabaseUp       bool
 	isDatabaseMigrated bool
@@ -21,12 +22,14 @@ type PasswordTestSuite struct {
 }
 
 func TestPasswordSuite(t *testing.T) {
-	suite.Run(t, new(PasswordTestSuite))
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &PasswordTestSuite{driver: driver}) })
+	}
 }
 
 func (suite *PasswordTestSuite) SetupSuite() {
-	suite.isDatabaseUp = testcontainersutil.DockerComposeUp()
-	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration()
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
 	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
 	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
 	suite.userRepository = NewUserRepositoryService(suite.session)
@@ -34,7 +37,7 @@ func (suite *PasswordTestSuite) SetupSuite() {
 }
 
 func (suite *PasswordTestSuite) TearDownSuite() {
-	testcontainersutil.DockerComposeDown()
+	databaseutil.StopTestDatabase(suite.session)
 	database.CloseDatabaseConnection(suite.session)
 }
 
@@ -72,7 +75,13 @@ func (suite *PasswordTestSuite) TestUpdatePasswordById() {
 	newUserPassword := &model.Password{UserId: uint64(userId.ID().(int64)), Name: "SomeApplication", Password: []byte("password")}
 	passwordId, err := suite.passwordRepository.InsertNewPassword(newUserPassword)
 
-	suite.passwordRepository.UpdatePasswordById("UpdatedName", []byte("updatedPassword"), uint64(passwordId.ID().(int64)))
+	updatedPassword := &model.Password{
+		Id: uint64(passwordId.ID().(int64)), Name: "UpdatedName", Password: []byte("updatedPassword"), TotpUri: []byte("updatedTotpUri"),
+	}
+	err = suite.passwordRepository.UpdatePasswordById(updatedPassword, nil)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), updatedPassword.Version, newUserPassword.Version+1)
+	assert.Equal(suite.T(), updatedPassword.UserId, newUserPassword.UserId)
 
 	updatedUserPassword := model.Password{}
 	err = (*suite.session).Collection("password").Find("id", passwordId).One(&updatedUserPassword)
@@ -82,6 +91,7 @@ func (suite *PasswordTestSuite) TestUpdatePasswordById() {
 	assert.Equal(suite.T(), updatedUserPassword.UserId, newUserPassword.UserId)
 	assert.Equal(suite.T(), updatedUserPassword.Name, "UpdatedName")
 	assert.Equal(suite.T(), updatedUserPassword.Password, []byte("updatedPassword"))
+	assert.Equal(suite.T(), updatedUserPassword.TotpUri, []byte("updatedTotpUri"))
 }
 
 // DeletePasswordById should successfully delete a password record
@@ -96,7 +106,7 @@ func (suite *PasswordTestSuite) TestDeletePasswordById() {
 	newUserPassword := &model.Password{UserId: uint64(userId.ID().(int64)), Name: "SomeApplication", Password: []byte("password")}
 	passwordId, _ := suite.passwordRepository.InsertNewPassword(newUserPassword)
 
-	suite.passwordRepository.DeletePasswordById(uint64(passwordId.ID().(int64)))
+	suite.passwordRepository.DeletePasswordById(uint64(passwordId.ID().(int64)), nil)
 
 	userPassword := model.Password{}
 	_ = (*suite.session).Collection("password").Find("id", passwordId).One(&newUserPassword)
@@ -116,7 +126,7 @@ func (suite *PasswordTestSuite) TestFetchPasswordById() {
 	passwordId, err := suite.passwordRepository.InsertNewPassword(userPassword)
 
 	fetchedUserPassword := &model.Password{}
-	err = suite.passwordRepository.FetchPasswordById(fetchedUserPassword, uint64(passwordId.ID().(int64)))
+	err = suite.passwordRepository.FetchPasswordById(context.Background(), fetchedUserPassword, uint64(passwordId.ID().(int64)))
 	assert.Nil(suite.T(), err)
 
 	assert.Equal(suite.T(), fetchedUserPassword.Id, uint64(passwordId.ID().(int64)))
@@ -147,7 +157,7 @@ func (suite *PasswordTestSuite) TestFetchAllByUserId() {
 	_, err = suite.passwordRepository.InsertNewPassword(additionalUserPassword)
 
 	testUserPasswords := model.Passwords{}
-	err = suite.passwordRepository.FetchAllByUserId(&testUserPasswords, uint64(testUserId.ID().(int64)), nil)
+	err = suite.passwordRepository.FetchAllByUserId(context.Background(), &testUserPasswords, uint64(testUserId.ID().(int64)), nil)
 	assert.Nil(suite.T(), err)
 
 	assert.Equal(suite.T(), len(testUserPasswords), 2, "Should fetch exactly two passwords")
@@ -173,7 +183,7 @@ func (suite *PasswordTestSuite) TestFetchAllByUserIdWithSpecificFields() {
 	passwordId, err := suite.passwordRepository.InsertNewPassword(testUserPassword)
 
 	testUserPasswords := model.Passwords{}
-	err = suite.passwordRepository.FetchAllByUserId(&testUserPasswords, uint64(testUserId.ID().(int64)), []string{"id", "password"})
+	err = suite.passwordRepository.FetchAllByUserId(context.Background(), &testUserPasswords, uint64(testUserId.ID().(int64)), []string{"id", "password"})
 	assert.Nil(suite.T(), err)
 
 	assert.Equal(suite.T(), testUserPasswords[0].Id, uint64(passwordId.ID().(int64)))
@@ -181,3 +191,118 @@ func (suite *PasswordTestSuite) TestFetchAllByUserIdWithSpecificFields() {
 	assert.Equal(suite.T(), testUserPasswords[0].Name, "")
 	assert.Equal(suite.T(), testUserPasswords[0].Password, testUserPassword.Password)
 }
+
+// MovePasswordById should move a password between the personal and the shared vault
+func (suite *PasswordTestSuite) TestMovePasswordById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	testUser := &model.User{Email: "testMovePassword@test.com", Username: "testMovePassword", Password: []byte("testMovePassword")}
+	testUserId, _ := suite.userRepository.InsertNewUser(testUser)
+	userId := uint64(testUserId.ID().(int64))
+	organizationInsertResult, _ := (*suite.session).Collection("organization").Insert(&model.Organization{Name: "team"})
+	organizationId := uint64(organizationInsertResult.ID().(int64))
+
+	testUserPassword := &model.Password{UserId: userId, Name: "SomeApplication", Password: []byte("password")}
+	insertResult, _ := suite.passwordRepository.InsertNewPassword(testUserPassword)
+	passwordId := uint64(insertResult.ID().(int64))
+
+	_, err := suite.passwordRepository.MovePasswordById(userId, &organizationId, 1, []byte("sharedPassword"), []byte("sharedTotpUri"), passwordId)
+	assert.Nil(suite.T(), err)
+
+	personalPasswords := model.Passwords{}
+	suite.passwordRepository.FetchAllByUserId(context.Background(), &personalPasswords, userId, nil)
+	assert.Equal(suite.T(), len(personalPasswords), 0)
+	sharedPasswords := model.Passwords{}
+	suite.passwordRepository.FetchAllByOrganizationId(&sharedPasswords, organizationId)
+	assert.Equal(suite.T(), len(sharedPasswords), 1)
+	assert.Equal(suite.T(), sharedPasswords[0].Password, []byte("sharedPassword"))
+	assert.Equal(suite.T(), sharedPasswords[0].TotpUri, []byte("sharedTotpUri"), "The seed should move along with the password")
+	changes := model.VaultChanges{}
+	suite.passwordRepository.FetchVaultChanges(&changes, userId, testUserPassword.Revision)
+	assert.Equal(suite.T(), len(changes.Tombstones), 1, "Moving the entry to an organization should leave a tombstone")
+
+	_, err = suite.passwordRepository.MovePasswordById(userId, nil, 0, []byte("password"), nil, passwordId)
+	assert.Nil(suite.T(), err)
+
+	suite.passwordRepository.FetchAllByUserId(context.Background(), &personalPasswords, userId, nil)
+	assert.Equal(suite.T(), len(personalPasswords), 1)
+	assert.Nil(suite.T(), personalPasswords[0].OrganizationId)
+	suite.passwordRepository.FetchVaultChanges(&changes, userId, testUserPassword.Revision)
+	assert.Equal(suite.T(), len(changes.Tombstones), 0, "Moving the entry back should remove its tombstone")
+	assert.Equal(suite.T(), len(changes.Passwords), 1)
+}
+
+// UpdatePasswordById and DeletePasswordById should only change the password if it's still at the expected version
+func (suite *PasswordTestSuite) TestUpdatePasswordByIdWithExpectedVersion() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testUpdatePasswordVersion@test.com")
+	userPassword := &model.Password{UserId: userId, Name: "SomeApplication", Password: []byte("password")}
+	insertResult, _ := suite.passwordRepository.InsertNewPassword(userPassword)
+	passwordId := uint64(insertResult.ID().(int64))
+
+	assert.Equal(suite.T(), userPassword.Version, int64(1))
+
+	update := &model.Password{Id: passwordId, Name: "UpdatedName", Password: []byte("updatedPassword")}
+	err := suite.passwordRepository.UpdatePasswordById(update, &userPassword.Version)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), update.Version, int64(2))
+	assert.Equal(suite.T(), update.Revision, userPassword.Revision+1)
+
+	staleUpdate := &model.Password{Id: passwordId, Name: "StaleName", Password: []byte("stalePassword")}
+	err = suite.passwordRepository.UpdatePasswordById(staleUpdate, &userPassword.Version)
+	assert.Equal(suite.T(), err, &VersionConflictError{CurrentVersion: 2})
+	err = suite.passwordRepository.DeletePasswordById(passwordId, &userPassword.Version)
+	assert.Equal(suite.T(), err, &VersionConflictError{CurrentVersion: 2})
+
+	updatedPassword := model.Password{}
+	suite.passwordRepository.FetchPasswordById(context.Background(), &updatedPassword, passwordId)
+	assert.Equal(suite.T(), updatedPassword.Name, "UpdatedName")
+	assert.Equal(suite.T(), updatedPassword.Version, update.Version)
+
+	err = suite.passwordRepository.DeletePasswordById(passwordId, &update.Version)
+	assert.Nil(suite.T(), err)
+	err = suite.passwordRepository.UpdatePasswordById(update, &update.Version)
+	assert.Equal(suite.T(), err, ErrNotFound)
+}
+
+// FetchVaultChanges should only fetch the entries and tombstones after the given revision
+func (suite *PasswordTestSuite) TestFetchVaultChanges() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testFetchVaultChanges@test.com")
+	unchangedPassword := &model.Password{UserId: userId, Name: "Unchanged", Password: []byte("password")}
+	suite.passwordRepository.InsertNewPassword(unchangedPassword)
+	insertResult, _ := suite.passwordRepository.InsertNewPassword(&model.Password{UserId: userId, Name: "Deleted", Password: []byte("password")})
+	deletedPasswordId := uint64(insertResult.ID().(int64))
+	insertResult, _ = suite.passwordRepository.InsertNewPassword(&model.Password{UserId: userId, Name: "Updated", Password: []byte("password")})
+	updatedPasswordId := uint64(insertResult.ID().(int64))
+
+	suite.passwordRepository.DeletePasswordById(deletedPasswordId, nil)
+	suite.passwordRepository.UpdatePasswordById(&model.Password{Id: updatedPasswordId, Name: "Updated", Password: []byte("updatedPassword")}, nil)
+
+	changes := model.VaultChanges{}
+	err := suite.passwordRepository.FetchVaultChanges(&changes, userId, unchangedPassword.Revision)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), changes.Revision, unchangedPassword.Revision+4)
+	assert.Equal(suite.T(), len(changes.Passwords), 1)
+	assert.Equal(suite.T(), changes.Passwords[0].Id, updatedPasswordId)
+	assert.Equal(suite.T(), len(changes.Tombstones), 1)
+	assert.Equal(suite.T(), changes.Tombstones[0].PasswordId, deletedPasswordId)
+
+	err = suite.passwordRepository.FetchVaultChanges(&changes, userId, changes.Revision)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), len(changes.Passwords), 0)
+	assert.Equal(suite.T(), len(changes.Tombstones), 0)
+}
+
+func (suite *PasswordTestSuite) insertTestUser(email string) uint64 {
+	userId, _ := suite.userRepository.InsertNewUser(&model.User{Email: email, Username: "revisionUser", Password: []byte("password")})
+	return uint64(userId.ID().(int64))
+}
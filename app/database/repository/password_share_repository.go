@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+)
+
+// ErrPasswordSharesChanged is a conflict matching ErrConflict
+var ErrPasswordSharesChanged = fmt.Errorf("%w: password shares changed during the update", ErrConflict)
+
+type PasswordShareRepository interface {
+	InsertNewShare(share *model.PasswordShare) (db.InsertResult, error)
+	FetchShareDetailsById(share *model.PasswordShareDetails, id uint64) error
+	FetchSharesDetailsByRecipientId(shares *model.PasswordSharesDetails, recipientId uint64) error
+	FetchSharesDetailsByPasswordId(shares *model.PasswordSharesDetails, passwordId uint64) error
+	DeleteShareById(id uint64) error
+	UpdateSharedPasswordById(password *model.Password, sealedPasswords map[uint64][]byte, expectedVersion *int64) error
+}
+
+type passwordShareRepositoryService struct {
+	session *db.Session
+}
+
+func NewPasswordShareRepositoryService(session *db.Session) *passwordShareRepositoryService {
+	return &passwordShareRepositoryService{session: session}
+}
+
+func (repository *passwordShareRepositoryService) PasswordShare() db.Collection {
+	return (*repository.session).Collection("password_share")
+}
+
+func (repository *passwordShareRepositoryService) InsertNewShare(share *model.PasswordShare) (db.InsertResult, error) {
+	return insert(repository.PasswordShare(), share)
+}
+
+func (repository *passwordShareRepositoryService) FetchShareDetailsById(share *model.PasswordShareDetails, id uint64) error {
+	return translateError(repository.shareDetailsQuery().Where("s.id = ?", id).One(share))
+}
+
+func (repository *passwordShareRepositoryService) FetchSharesDetailsByRecipientId(
+	shares *model.PasswordSharesDetails, recipientId uint64,
+) error {
+	return translateError(repository.shareDetailsQuery().Where("s.recipient_id = ?", recipientId).OrderBy("s.id").All(shares))
+}
+
+func (repository *passwordShareRepositoryService) FetchSharesDetailsByPasswordId(
+	shares *model.PasswordSharesDetails, passwordId uint64,
+) error {
+	return translateError(repository.shareDetailsQuery().Where("s.password_id = ?", passwordId).OrderBy("s.id").All(shares))
+}
+
+func (repository *passwordShareRepositoryService) DeleteShareById(id uint64) error {
+	return translateError(repository.PasswordShare().Find("id", id).Delete())
+}
+
+// UpdateSharedPasswordById updates the entry together with every recipient's copy in a single transaction,
+// a copy for each share of the entry is required, otherwise ErrPasswordSharesChanged is returned and nothing changes.
+// The entry is reloaded with its new version and revision, a nil expected version updates the entry unconditionally.
+func (repository *passwordShareRepositoryService) UpdateSharedPasswordById(
+	password *model.Password, sealedPasswords map[uint64][]byte, expectedVersion *int64,
+) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		if err := updatePassword(session, password, expectedVersion); err != nil {
+			return err
+		}
+
+		for shareId, sealedPassword := range sealedPasswords {
+			update := session.SQL().
+				Update("password_share").
+				Set("sealed_password", sealedPassword).
+				Where("id = ? AND password_id = ?", shareId, password.Id)
+			if err := execAffectingRows(update); err != nil {
+				return ErrPasswordSharesChanged
+			}
+		}
+
+		shares, err := session.Collection("password_share").Find("password_id", password.Id).Count()
+		if err != nil {
+			return err
+		}
+		if shares != uint64(len(sealedPasswords)) {
+			return ErrPasswordSharesChanged
+		}
+
+		return nil
+	}))
+}
+
+func (repository *passwordShareRepositoryService) shareDetailsQuery() db.Selector {
+	return (*repository.session).SQL().
+		Select("s.*", "p.user_id AS owner_id", "o.email AS owner_email", "r.email AS recipient_email").
+		From("password_share AS s").
+		Join("password AS p").On("p.id = s.password_id").
+		Join("user AS o").On("o.id = p.user_id").
+		Join("user AS r").On("r.id = s.recipient_id")
+}
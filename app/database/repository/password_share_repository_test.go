@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+)
+
+type PasswordShareRepositoryTestSuite struct {
+	suite.Suite
+	driver                  string
+	session                 *db.Session
+	isDatabaseUp            bool
+	isDatabaseMigrated      bool
+	userRepository          UserRepository
+	passwordRepository      PasswordRepository
+	passwordShareRepository PasswordShareRepository
+}
+
+func TestPasswordShareSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &PasswordShareRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *PasswordShareRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.passwordRepository = NewPasswordRepositoryService(suite.session)
+	suite.passwordShareRepository = NewPasswordShareRepositoryService(suite.session)
+}
+
+func (suite *PasswordShareRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// FetchSharesDetailsByRecipientId should fetch the shares of the recipient with the owner's and the recipient's e-mail
+func (suite *PasswordShareRepositoryTestSuite) TestFetchSharesDetailsByRecipientId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	ownerId := suite.insertTestUser("testFetchSharesOwner@test.com")
+	recipientId := suite.insertTestUser("testFetchSharesRecipient@test.com")
+	shareId := suite.insertTestShare(suite.insertTestPassword(ownerId), recipientId)
+	suite.insertTestShare(suite.insertTestPassword(ownerId), suite.insertTestUser("testFetchSharesAdditional@test.com"))
+
+	shares := model.PasswordSharesDetails{}
+	err := suite.passwordShareRepository.FetchSharesDetailsByRecipientId(&shares, recipientId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), len(shares), 1)
+	assert.Equal(suite.T(), shares[0].Id, shareId)
+	assert.Equal(suite.T(), shares[0].OwnerId, ownerId)
+	assert.Equal(suite.T(), shares[0].OwnerEmail, "testFetchSharesOwner@test.com")
+	assert.Equal(suite.T(), shares[0].RecipientEmail, "testFetchSharesRecipient@test.com")
+}
+
+// InsertNewShare should not share the same entry with a recipient twice
+func (suite *PasswordShareRepositoryTestSuite) TestInsertNewShareTwice() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	passwordId := suite.insertTestPassword(suite.insertTestUser("testShareTwiceOwner@test.com"))
+	recipientId := suite.insertTestUser("testShareTwiceRecipient@test.com")
+	suite.insertTestShare(passwordId, recipientId)
+
+	_, err := suite.passwordShareRepository.InsertNewShare(&model.PasswordShare{
+		PasswordId: passwordId, RecipientId: recipientId, Permission: model.SharePermissionRead, SealedPassword: []byte("sealed"),
+	})
+	assert.NotNil(suite.T(), err)
+}
+
+// DeleteShareById should delete the share and DeletePasswordById should delete all shares of the entry
+func (suite *PasswordShareRepositoryTestSuite) TestDeleteShareById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	passwordId := suite.insertTestPassword(suite.insertTestUser("testDeleteShareOwner@test.com"))
+	shareId := suite.insertTestShare(passwordId, suite.insertTestUser("testDeleteShareRecipient@test.com"))
+	suite.insertTestShare(passwordId, suite.insertTestUser("testDeleteShareAdditional@test.com"))
+
+	err := suite.passwordShareRepository.DeleteShareById(shareId)
+	assert.Nil(suite.T(), err)
+	shares := model.PasswordSharesDetails{}
+	suite.passwordShareRepository.FetchSharesDetailsByPasswordId(&shares, passwordId)
+	assert.Equal(suite.T(), len(shares), 1)
+
+	suite.passwordRepository.DeletePasswordById(passwordId, nil)
+	suite.passwordShareRepository.FetchSharesDetailsByPasswordId(&shares, passwordId)
+	assert.Equal(suite.T(), len(shares), 0)
+}
+
+// UpdateSharedPasswordById should update the entry together with the recipients' copies
+func (suite *PasswordShareRepositoryTestSuite) TestUpdateSharedPasswordById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	passwordId := suite.insertTestPassword(suite.insertTestUser("testUpdateSharedOwner@test.com"))
+	shareId := suite.insertTestShare(passwordId, suite.insertTestUser("testUpdateSharedRecipient@test.com"))
+
+	err := suite.passwordShareRepository.UpdateSharedPasswordById(
+		&model.Password{Id: passwordId, Name: "updated", Password: []byte("updatedPassword")}, map[uint64][]byte{shareId: []byte("resealed")}, nil,
+	)
+	assert.Nil(suite.T(), err)
+
+	password := model.Password{}
+	suite.passwordRepository.FetchPasswordById(context.Background(), &password, passwordId)
+	assert.Equal(suite.T(), password.Name, "updated")
+	assert.Equal(suite.T(), password.Password, []byte("updatedPassword"))
+	share := model.PasswordShareDetails{}
+	suite.passwordShareRepository.FetchShareDetailsById(&share, shareId)
+	assert.Equal(suite.T(), share.SealedPassword, []byte("resealed"))
+}
+
+// UpdateSharedPasswordById should change nothing if a recipient's copy was left out of the update
+func (suite *PasswordShareRepositoryTestSuite) TestUpdateSharedPasswordByIdWithMissingShare() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	passwordId := suite.insertTestPassword(suite.insertTestUser("testUpdateSharedMissingOwner@test.com"))
+	suite.insertTestShare(passwordId, suite.insertTestUser("testUpdateSharedMissingRecipient@test.com"))
+
+	err := suite.passwordShareRepository.UpdateSharedPasswordById(
+		&model.Password{Id: passwordId, Name: "updated", Password: []byte("updatedPassword")}, map[uint64][]byte{}, nil,
+	)
+	assert.Equal(suite.T(), err, ErrPasswordSharesChanged)
+
+	password := model.Password{}
+	suite.passwordRepository.FetchPasswordById(context.Background(), &password, passwordId)
+	assert.Equal(suite.T(), password.Name, "shared")
+}
+
+func (suite *PasswordShareRepositoryTestSuite) insertTestUser(email string) uint64 {
+	userId, _ := suite.userRepository.InsertNewUser(&model.User{Email: email, Username: "shareUser", Password: []byte("password")})
+	return uint64(userId.ID().(int64))
+}
+
+func (suite *PasswordShareRepositoryTestSuite) insertTestPassword(userId uint64) uint64 {
+	passwordId, _ := suite.passwordRepository.InsertNewPassword(&model.Password{UserId: userId, Name: "shared", Password: []byte("password")})
+	return uint64(passwordId.ID().(int64))
+}
+
+func (suite *PasswordShareRepositoryTestSuite) insertTestShare(passwordId uint64, recipientId uint64) uint64 {
+	shareId, _ := suite.passwordShareRepository.InsertNewShare(&model.PasswordShare{
+		PasswordId: passwordId, RecipientId: recipientId, Permission: model.SharePermissionWrite, SealedPassword: []byte("sealed"),
+	})
+	return uint64(shareId.ID().(int64))
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/upper/db/v4"
+)
+
+type primaryReadsKey struct{}
+
+// WithPrimaryReads makes the reads with the returned context go to the primary database, for requests that have to
+// read their own writes
+func WithPrimaryReads(ctx context.Context) context.Context {
+	return context.WithValue(ctx, primaryReadsKey{}, true)
+}
+
+// PrimaryReads tells whether the reads with the context go to the primary database
+func PrimaryReads(ctx context.Context) bool {
+	primaryReads, _ := ctx.Value(primaryReadsKey{}).(bool)
+	return primaryReads
+}
+
+// readSession returns the session for reads that may lag behind the primary, which is a replica in the rotation unless
+// the context reads from the primary. Repositories of a unit of work have no replicas, so they always read their writes.
+func readSession(ctx context.Context, session db.Session, replicas *database.ReplicaSet) db.Session {
+	if PrimaryReads(ctx) {
+		return session
+	}
+	if replica := replicas.Session(); replica != nil {
+		return replica
+	}
+	return session
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+	"testing"
+	"time"
+)
+
+func initializeMigratedSqliteDatabase(t *testing.T) *db.Session {
+	datasourceConfig := databaseutil.GenerateTestDatasourceConfiguration(database.DriverSqlite)
+	session := database.InitializeDatabaseConnection(datasourceConfig)
+	t.Cleanup(func() {
+		database.CloseDatabaseConnection(session)
+		databaseutil.StopTestDatabase(session)
+	})
+	assert.True(t, databaseutil.RunDatabaseMigrations(datasourceConfig), "Test database should be migrated")
+	return session
+}
+
+// Repositories with replicas should read vault listings and lookups by email from the replicas in the rotation,
+// and from the primary when the context or a unit of work requires it
+func TestRepositoriesWithReplicas(t *testing.T) {
+	primarySession := initializeMigratedSqliteDatabase(t)
+	replicaSession := initializeMigratedSqliteDatabase(t)
+	user := &model.User{Email: "replica@test.com", Username: "replica", Password: []byte("replicaPassword")}
+	_, err := NewUserRepositoryService(replicaSession).InsertNewUser(user)
+	assert.Nil(t, err)
+	replicas := database.NewReplicaSet([]db.Session{*replicaSession}, time.Second, time.Second)
+	repositories := NewRepositoriesWithReplicas(primarySession, replicas)
+
+	fetchedUser := model.User{}
+	err = repositories.User.FetchByEmail(context.Background(), &fetchedUser, user.Email, nil)
+	assert.Nil(t, err, "Should read from the replica")
+	assert.Equal(t, fetchedUser.Username, user.Username)
+	passwords := model.Passwords{}
+	assert.Nil(t, repositories.Password.FetchAllByUserId(context.Background(), &passwords, fetchedUser.Id, nil))
+
+	err = repositories.User.FetchByEmail(WithPrimaryReads(context.Background()), &model.User{}, user.Email, nil)
+	assert.Equal(t, err, ErrNotFound, "Should read from the primary")
+	err = repositories.UnitOfWork.Execute(func(repositories *Repositories) error {
+		return repositories.User.FetchByEmail(context.Background(), &model.User{}, user.Email, nil)
+	})
+	assert.Equal(t, err, ErrNotFound, "Units of work should read from the primary")
+
+	database.CloseDatabaseConnection(replicaSession)
+	assert.Equal(t, replicas.Check(), 0)
+	err = repositories.Password.FetchPasswordById(context.Background(), &model.Password{}, 1)
+	assert.Equal(t, err, ErrNotFound, "Should read from the primary without any replicas in the rotation")
+}
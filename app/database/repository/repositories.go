@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/upper/db/v4"
+)
+
+// Repositories are the repositories of a single storage backend, handed to the server as a whole
+type Repositories struct {
+	User            UserRepository
+	Password        PasswordRepository
+	Webauthn        WebauthnRepository
+	KnownDevice     KnownDeviceRepository
+	KeyPair         KeyPairRepository
+	EmergencyAccess EmergencyAccessRepository
+	Session         SessionRepository
+	Organization    OrganizationRepository
+	PasswordShare   PasswordShareRepository
+	Send            SendRepository
+	Admin           AdminRepository
+	AuditEvent      AuditEventRepository
+	Webhook         WebhookRepository
+	Attachment      AttachmentRepository
+	UnitOfWork      UnitOfWork
+}
+
+// NewRepositories creates the repositories of the database the session is connected to
+func NewRepositories(session *db.Session) *Repositories {
+	return NewRepositoriesWithReplicas(session, nil)
+}
+
+// NewRepositoriesWithReplicas creates the repositories of the database the session is connected to, vault listings
+// and lookups by email are balanced over the replicas in the rotation
+func NewRepositoriesWithReplicas(session *db.Session, replicas *database.ReplicaSet) *Repositories {
+	return &Repositories{
+		User:            &userRepositoryService{session: session, replicas: replicas},
+		Password:        &passwordRepositoryService{session: session, replicas: replicas},
+		Webauthn:        NewWebauthnRepositoryService(session),
+		KnownDevice:     NewKnownDeviceRepositoryService(session),
+		KeyPair:         NewKeyPairRepositoryService(session),
+		EmergencyAccess: NewEmergencyAccessRepositoryService(session),
+		Session:         NewSessionRepositoryService(session),
+		Organization:    NewOrganizationRepositoryService(session),
+		PasswordShare:   NewPasswordShareRepositoryService(session),
+		Send:            NewSendRepositoryService(session),
+		Admin:           NewAdminRepositoryService(session),
+		AuditEvent:      NewAuditEventRepositoryService(session),
+		Webhook:         NewWebhookRepositoryService(session),
+		Attachment:      NewAttachmentRepositoryService(session),
+		UnitOfWork:      NewUnitOfWorkService(session),
+	}
+}
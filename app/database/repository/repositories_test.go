@@ -0,0 +1,32 @@
+package repository_test
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/repositoryutil"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+)
+
+func TestRepositoryConformanceSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		var session *db.Session
+		t.Run(driver, func(t *testing.T) {
+			suite.Run(t, &repositoryutil.ConformanceSuite{
+				SetupRepositories: func() (*repository.Repositories, bool) {
+					isDatabaseUp := databaseutil.StartTestDatabase(driver)
+					databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(driver)
+					session = database.InitializeDatabaseConnection(databaseConfiguration)
+					isDatabaseMigrated := databaseutil.RunDatabaseMigrations(databaseConfiguration)
+					return repository.NewRepositories(session), isDatabaseUp && isDatabaseMigrated
+				},
+				TearDownRepositories: func() {
+					databaseutil.StopTestDatabase(session)
+					database.CloseDatabaseConnection(session)
+				},
+			})
+		})
+	}
+}
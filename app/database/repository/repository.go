@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/upper/db/v4"
+	"net"
+	"strings"
+)
+
+// The errors every repository implementation returns instead of the errors of its backend, so callers never
+// have to know which database they run on
+var (
+	// ErrNotFound is returned when the record doesn't exist or didn't match the conditions of the write
+	ErrNotFound = errors.New("repository: record not found")
+	// ErrDuplicate is returned by writes violating a unique constraint
+	ErrDuplicate = errors.New("repository: duplicate key")
+	// ErrConflict is matched by errors of writes that lost a race with a concurrent change
+	ErrConflict = errors.New("repository: conflicting change")
+	// ErrUnavailable is returned when the storage can't be reached or refuses connections
+	ErrUnavailable = errors.New("repository: storage unavailable")
+)
+
+const (
+	postgresUniqueViolation     = "23505"
+	postgresConnectionException = "08"
+	postgresTooManyConnections  = "53300"
+	postgresAdminShutdown       = "57P01"
+	postgresCannotConnectNow    = "57P03"
+	mysqlTooManyConnections     = 1040
+	mysqlDuplicateEntry         = 1062
+)
+
+type statement interface {
+	Exec() (sql.Result, error)
+}
+
+// execAffectingRows executes the update or delete statement and returns ErrNotFound when no row matched its conditions
+func execAffectingRows(statement statement) error {
+	result, err := statement.Exec()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if rowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// lockUser locks the user for the rest of the transaction, SQLite transactions are immediate and hold the write lock
+// of the whole database already
+func lockUser(session db.Session, userId uint64) error {
+	if database.Driver(session) == database.DriverSqlite {
+		return nil
+	}
+	_, err := session.SQL().Exec(`SELECT "id" FROM "user" WHERE "id" = ? FOR UPDATE`, userId)
+	return err
+}
+
+// lockOrganization locks the organization for the rest of the transaction and returns the version of its key, SQLite
+// transactions are immediate and hold the write lock of the whole database already
+func lockOrganization(session db.Session, organizationId uint64) (int, error) {
+	query := `SELECT "key_version" FROM "organization" WHERE "id" = ?`
+	if database.Driver(session) != database.DriverSqlite {
+		query += " FOR UPDATE"
+	}
+	row, err := session.SQL().QueryRow(query, organizationId)
+	if err != nil {
+		return 0, err
+	}
+
+	var keyVersion int
+	if err = row.Scan(&keyVersion); err == sql.ErrNoRows {
+		return 0, ErrNotFound
+	}
+	return keyVersion, err
+}
+
+// insert inserts the item into the collection, on SQLite in a transaction of its own. The SQLite adapter doesn't roll
+// back the implicit transaction of a failed statement, which would keep holding a connection along with the write lock
+// of the database, while explicit transactions get rolled back on any error. Errors are translated like the ones of
+// the repositories.
+func insert(collection db.Collection, item interface{}) (db.InsertResult, error) {
+	session := collection.Session()
+	if database.Driver(session) != database.DriverSqlite {
+		insertResult, err := collection.Insert(item)
+		return insertResult, translateError(err)
+	}
+
+	var insertResult db.InsertResult
+	err := transaction(session, func(session db.Session) (err error) {
+		insertResult, err = session.Collection(collection.Name()).Insert(item)
+		return err
+	})
+	return insertResult, translateError(err)
+}
+
+// translateError replaces the errors of the backends with the errors of the repositories, errors of the repositories
+// themselves and errors without a counterpart are returned as they are
+func translateError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case err == db.ErrNoMoreRows || err == sql.ErrNoRows:
+		return ErrNotFound
+	case isDuplicate(err):
+		return ErrDuplicate
+	case isSerializationFailure(err):
+		return errSerializationFailure
+	case isUnavailable(err):
+		return ErrUnavailable
+	}
+	return err
+}
+
+func isDuplicate(err error) bool {
+	switch driverError := err.(type) {
+	case *pq.Error:
+		return driverError.Code == postgresUniqueViolation
+	case sqlite3.Error:
+		return driverError.ExtendedCode == sqlite3.ErrConstraintUnique || driverError.ExtendedCode == sqlite3.ErrConstraintPrimaryKey
+	case *mysql.MySQLError:
+		return driverError.Number == mysqlDuplicateEntry
+	}
+	return false
+}
+
+// isUnavailable tells whether the error is a lost or refused connection rather than a failure of the statement itself,
+// a database that stays locked past the busy timeout counts as unavailable as well
+func isUnavailable(err error) bool {
+	var netError net.Error
+	if errors.As(err, &netError) || errors.Is(err, driver.ErrBadConn) || errors.Is(err, sql.ErrConnDone) {
+		return true
+	}
+
+	switch driverError := err.(type) {
+	case *pq.Error:
+		code := string(driverError.Code)
+		return strings.HasPrefix(code, postgresConnectionException) || code == postgresTooManyConnections ||
+			code == postgresAdminShutdown || code == postgresCannotConnectNow
+	case sqlite3.Error:
+		return driverError.Code == sqlite3.ErrBusy || driverError.Code == sqlite3.ErrLocked || driverError.Code == sqlite3.ErrCantOpen
+	case *mysql.MySQLError:
+		return driverError.Number == mysqlTooManyConnections
+	}
+	return err == db.ErrTooManyClients || err == db.ErrGivingUpTryingToConnect || err == db.ErrNotConnected ||
+		err == mysql.ErrInvalidConn
+}
@@ -0,0 +1,40 @@
+package repository
+
+import (
+	"errors"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+	"github.com/upper/db/v4"
+	"net"
+	"testing"
+)
+
+// translateError should replace the errors of every backend with the errors of the repositories
+func TestTranslateError(t *testing.T) {
+	unrelatedError := errors.New("syntax error")
+	translations := map[error]error{
+		db.ErrNoMoreRows:                ErrNotFound,
+		&pq.Error{Code: "23505"}:        ErrDuplicate,
+		&mysql.MySQLError{Number: 1062}: ErrDuplicate,
+		sqlite3.Error{Code: sqlite3.ErrConstraint, ExtendedCode: sqlite3.ErrConstraintUnique}: ErrDuplicate,
+		&pq.Error{Code: "08006"}:                             ErrUnavailable,
+		&mysql.MySQLError{Number: 1040}:                      ErrUnavailable,
+		sqlite3.Error{Code: sqlite3.ErrBusy}:                 ErrUnavailable,
+		&net.OpError{Op: "dial", Err: errors.New("refused")}: ErrUnavailable,
+		db.ErrTooManyClients:                                 ErrUnavailable,
+		&pq.Error{Code: "40001"}:                             errSerializationFailure,
+		&mysql.MySQLError{Number: 1213}:                      errSerializationFailure,
+		sqlite3.Error{Code: sqlite3.ErrBusy, ExtendedCode: sqlite3.ErrBusySnapshot}: errSerializationFailure,
+		&pq.Error{Code: "23503"}: &pq.Error{Code: "23503"},
+		unrelatedError:           unrelatedError,
+		ErrConflict:              ErrConflict,
+	}
+
+	for err, expectedError := range translations {
+		assert.Equal(t, translateError(err), expectedError, "Unexpected translation of: %v", err)
+	}
+	assert.Nil(t, translateError(nil))
+	assert.True(t, errors.Is(errSerializationFailure, ErrConflict), "Serialization failures should be conflicts")
+}
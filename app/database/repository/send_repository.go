@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type SendRepository interface {
+	InsertNewSend(send *model.Send) (db.InsertResult, error)
+	FetchAvailableSendByTokenHash(send *model.Send, tokenHash []byte, now time.Time) error
+	FetchAvailableSendsByUserId(sends *model.Sends, userId uint64, now time.Time) error
+	ConsumeSendViewById(id uint64, now time.Time) error
+	DeleteSendByIdAndUserId(id uint64, userId uint64) error
+	DeleteUnavailableSends(now time.Time) (int64, error)
+}
+
+type sendRepositoryService struct {
+	session *db.Session
+}
+
+func NewSendRepositoryService(session *db.Session) *sendRepositoryService {
+	return &sendRepositoryService{session: session}
+}
+
+func (repository *sendRepositoryService) Send() db.Collection {
+	return (*repository.session).Collection("send")
+}
+
+func (repository *sendRepositoryService) InsertNewSend(send *model.Send) (db.InsertResult, error) {
+	return insert(repository.Send(), send)
+}
+
+// FetchAvailableSendByTokenHash fetches a send that neither expired nor ran out of views
+func (repository *sendRepositoryService) FetchAvailableSendByTokenHash(send *model.Send, tokenHash []byte, now time.Time) error {
+	return translateError((*repository.session).SQL().
+		Select().
+		From("send").
+		Where("token_hash = ? AND expires_at > ? AND view_count < max_views", tokenHash, now).
+		One(send))
+}
+
+func (repository *sendRepositoryService) FetchAvailableSendsByUserId(sends *model.Sends, userId uint64, now time.Time) error {
+	return translateError((*repository.session).SQL().
+		Select().
+		From("send").
+		Where("user_id = ? AND expires_at > ? AND view_count < max_views", userId, now).
+		OrderBy("-created_at").
+		All(sends))
+}
+
+// ConsumeSendViewById counts a view of the send and burns it after its last view, sends that became unavailable
+// in the meantime return ErrNotFound so concurrent reads can't exceed the maximum view count
+func (repository *sendRepositoryService) ConsumeSendViewById(id uint64, now time.Time) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		update := session.SQL().
+			Update("send").
+			Set("view_count", db.Raw("view_count + 1")).
+			Where("id = ? AND expires_at > ? AND view_count < max_views", id, now)
+		if err := execAffectingRows(update); err != nil {
+			return err
+		}
+
+		_, err := session.SQL().DeleteFrom("send").Where("id = ? AND view_count >= max_views", id).Exec()
+		return err
+	}))
+}
+
+func (repository *sendRepositoryService) DeleteSendByIdAndUserId(id uint64, userId uint64) error {
+	return translateError(
+		execAffectingRows((*repository.session).SQL().DeleteFrom("send").Where("id = ? AND user_id = ?", id, userId)),
+	)
+}
+
+// DeleteUnavailableSends purges expired and used up sends, returning how many were deleted
+func (repository *sendRepositoryService) DeleteUnavailableSends(now time.Time) (int64, error) {
+	result, err := (*repository.session).SQL().DeleteFrom("send").Where("expires_at <= ? OR view_count >= max_views", now).Exec()
+	if err != nil {
+		return 0, translateError(err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+	"time"
+)
+
+type SendRepositoryTestSuite struct {
+	suite.Suite
+	driver             string
+	session            *db.Session
+	isDatabaseUp       bool
+	isDatabaseMigrated bool
+	userRepository     UserRepository
+	sendRepository     SendRepository
+}
+
+func TestSendSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &SendRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *SendRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.sendRepository = NewSendRepositoryService(suite.session)
+}
+
+func (suite *SendRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// FetchAvailableSendsByUserId should fetch only the user's sends that neither expired nor ran out of views
+func (suite *SendRepositoryTestSuite) TestFetchAvailableSendsByUserId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testFetchSends@test.com")
+	sendId := suite.insertTestSend(userId, "testFetchSendsAvailable", 1, time.Hour)
+	suite.insertTestSend(userId, "testFetchSendsExpired", 1, -time.Hour)
+	suite.insertTestSend(suite.insertTestUser("testFetchSendsAdditional@test.com"), "testFetchSendsAdditional", 1, time.Hour)
+
+	sends := model.Sends{}
+	err := suite.sendRepository.FetchAvailableSendsByUserId(&sends, userId, time.Now().UTC())
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), len(sends), 1)
+	assert.Equal(suite.T(), sends[0].Id, sendId)
+
+	send := model.Send{}
+	err = suite.sendRepository.FetchAvailableSendByTokenHash(&send, []byte("testFetchSendsExpired"), time.Now().UTC())
+	assert.Equal(suite.T(), err, ErrNotFound)
+}
+
+// ConsumeSendViewById should count views and burn the send after its last view
+func (suite *SendRepositoryTestSuite) TestConsumeSendViewById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	sendId := suite.insertTestSend(suite.insertTestUser("testConsumeSend@test.com"), "testConsumeSend", 2, time.Hour)
+
+	err := suite.sendRepository.ConsumeSendViewById(sendId, time.Now().UTC())
+	assert.Nil(suite.T(), err)
+	send := model.Send{}
+	suite.sendRepository.FetchAvailableSendByTokenHash(&send, []byte("testConsumeSend"), time.Now().UTC())
+	assert.Equal(suite.T(), send.ViewCount, 1)
+
+	err = suite.sendRepository.ConsumeSendViewById(sendId, time.Now().UTC())
+	assert.Nil(suite.T(), err)
+	count, _ := (*suite.session).Collection("send").Find("id", sendId).Count()
+	assert.Zero(suite.T(), count, "Send should be burned after its last view")
+
+	err = suite.sendRepository.ConsumeSendViewById(sendId, time.Now().UTC())
+	assert.Equal(suite.T(), err, ErrNotFound)
+}
+
+// DeleteSendByIdAndUserId should delete only sends of the given user
+func (suite *SendRepositoryTestSuite) TestDeleteSendByIdAndUserId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testDeleteSend@test.com")
+	sendId := suite.insertTestSend(userId, "testDeleteSend", 1, time.Hour)
+
+	err := suite.sendRepository.DeleteSendByIdAndUserId(sendId, userId+1)
+	assert.Equal(suite.T(), err, ErrNotFound)
+	err = suite.sendRepository.DeleteSendByIdAndUserId(sendId, userId)
+	assert.Nil(suite.T(), err)
+}
+
+// DeleteUnavailableSends should purge expired sends only
+func (suite *SendRepositoryTestSuite) TestDeleteUnavailableSends() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testPurgeSends@test.com")
+	availableSendId := suite.insertTestSend(userId, "testPurgeSendsAvailable", 1, time.Hour)
+	expiredSendId := suite.insertTestSend(userId, "testPurgeSendsExpired", 1, -time.Hour)
+
+	purgedSends, err := suite.sendRepository.DeleteUnavailableSends(time.Now().UTC())
+	assert.Nil(suite.T(), err)
+	assert.GreaterOrEqual(suite.T(), purgedSends, int64(1))
+
+	count, _ := (*suite.session).Collection("send").Find("id", expiredSendId).Count()
+	assert.Zero(suite.T(), count)
+	count, _ = (*suite.session).Collection("send").Find("id", availableSendId).Count()
+	assert.Equal(suite.T(), count, uint64(1))
+}
+
+func (suite *SendRepositoryTestSuite) insertTestUser(email string) uint64 {
+	userId, _ := suite.userRepository.InsertNewUser(&model.User{Email: email, Username: "sendUser", Password: []byte("password")})
+	return uint64(userId.ID().(int64))
+}
+
+func (suite *SendRepositoryTestSuite) insertTestSend(userId uint64, tokenHash string, maxViews int, expiresIn time.Duration) uint64 {
+	now := time.Now().UTC()
+	sendId, _ := suite.sendRepository.InsertNewSend(&model.Send{
+		UserId: userId, Name: "send", TokenHash: []byte(tokenHash), EncryptedSecret: []byte("secret"),
+		MaxViews: maxViews, ExpiresAt: now.Add(expiresIn), CreatedAt: now,
+	})
+	return uint64(sendId.ID().(int64))
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type SessionRepository interface {
+	InsertNewSession(session *model.Session) (db.InsertResult, error)
+	FetchSessionById(session *model.Session, id uint64) error
+	FetchSessionsByUserId(sessions *model.Sessions, userId uint64) error
+	UpdateSessionStatusById(currentStatus string, newStatus string, id uint64) error
+	RevokeSessionById(id uint64) error
+	RevokeSessionsByUserId(userId uint64) error
+	TouchActiveSessionById(lastSeenAt time.Time, id uint64, userId uint64) error
+}
+
+type sessionRepositoryService struct {
+	session *db.Session
+}
+
+func NewSessionRepositoryService(session *db.Session) *sessionRepositoryService {
+	return &sessionRepositoryService{session: session}
+}
+
+func (repository *sessionRepositoryService) Session() db.Collection {
+	return (*repository.session).Collection("user_session")
+}
+
+func (repository *sessionRepositoryService) InsertNewSession(session *model.Session) (db.InsertResult, error) {
+	return insert(repository.Session(), session)
+}
+
+func (repository *sessionRepositoryService) FetchSessionById(session *model.Session, id uint64) error {
+	return translateError((*repository.session).SQL().Select().From("user_session").Where("id = ?", id).One(session))
+}
+
+// FetchSessionsByUserId fetches the user's sessions that weren't revoked, most recently used first
+func (repository *sessionRepositoryService) FetchSessionsByUserId(sessions *model.Sessions, userId uint64) error {
+	return translateError((*repository.session).SQL().
+		Select().
+		From("user_session").
+		Where("user_id = ? AND status <> ?", userId, model.SessionRevoked).
+		OrderBy("-last_seen_at").
+		All(sessions))
+}
+
+// UpdateSessionStatusById changes the status only if the session is still in the expected status,
+// so concurrent approvals and sign ins can't claim the same session twice
+func (repository *sessionRepositoryService) UpdateSessionStatusById(currentStatus string, newStatus string, id uint64) error {
+	update := (*repository.session).SQL().
+		Update("user_session").
+		Set("status", newStatus).
+		Where("id = ? AND status = ?", id, currentStatus)
+	return translateError(execAffectingRows(update))
+}
+
+func (repository *sessionRepositoryService) RevokeSessionById(id uint64) error {
+	update := (*repository.session).SQL().
+		Update("user_session").
+		Set("status", model.SessionRevoked, "revoked_at", time.Now().UTC()).
+		Where("id = ? AND status <> ?", id, model.SessionRevoked)
+	return translateError(execAffectingRows(update))
+}
+
+// RevokeSessionsByUserId revokes every session of the user, users without sessions aren't an error
+func (repository *sessionRepositoryService) RevokeSessionsByUserId(userId uint64) error {
+	return translateError(revokeUserSessions(*repository.session, userId))
+}
+
+// TouchActiveSessionById refreshes the last seen time of the user's session, sessions that aren't active anymore
+// or belong to another user return ErrNotFound
+func (repository *sessionRepositoryService) TouchActiveSessionById(lastSeenAt time.Time, id uint64, userId uint64) error {
+	update := (*repository.session).SQL().
+		Update("user_session").
+		Set("last_seen_at", lastSeenAt).
+		Where("id = ? AND user_id = ? AND status = ?", id, userId, model.SessionActive)
+	return translateError(execAffectingRows(update))
+}
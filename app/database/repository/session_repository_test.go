@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+	"time"
+)
+
+type SessionRepositoryTestSuite struct {
+	suite.Suite
+	driver             string
+	session            *db.Session
+	isDatabaseUp       bool
+	isDatabaseMigrated bool
+	userRepository     UserRepository
+	sessionRepository  SessionRepository
+}
+
+func TestSessionSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &SessionRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *SessionRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.sessionRepository = NewSessionRepositoryService(suite.session)
+}
+
+func (suite *SessionRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// FetchSessionsByUserId should fetch only the user's sessions that weren't revoked
+func (suite *SessionRepositoryTestSuite) TestFetchSessionsByUserId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testFetchSessions@test.com")
+	additionalUserId := suite.insertTestUser("testFetchSessionsAdditional@test.com")
+	activeSessionId := suite.insertTestSession(userId, model.SessionActive)
+	pendingSessionId := suite.insertTestSession(userId, model.SessionPendingApproval)
+	suite.insertTestSession(userId, model.SessionRevoked)
+	suite.insertTestSession(additionalUserId, model.SessionActive)
+
+	sessions := model.Sessions{}
+	err := suite.sessionRepository.FetchSessionsByUserId(&sessions, userId)
+	assert.Nil(suite.T(), err)
+
+	assert.Equal(suite.T(), len(sessions), 2)
+	sessionIds := []uint64{sessions[0].Id, sessions[1].Id}
+	assert.ElementsMatch(suite.T(), sessionIds, []uint64{activeSessionId, pendingSessionId})
+}
+
+// UpdateSessionStatusById should change the status only for sessions in the expected status
+func (suite *SessionRepositoryTestSuite) TestUpdateSessionStatusById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	sessionId := suite.insertTestSession(suite.insertTestUser("testUpdateSessionStatus@test.com"), model.SessionPendingApproval)
+
+	err := suite.sessionRepository.UpdateSessionStatusById(model.SessionPendingApproval, model.SessionApproved, sessionId)
+	assert.Nil(suite.T(), err)
+	err = suite.sessionRepository.UpdateSessionStatusById(model.SessionPendingApproval, model.SessionApproved, sessionId)
+	assert.Equal(suite.T(), err, ErrNotFound)
+
+	session := model.Session{}
+	suite.sessionRepository.FetchSessionById(&session, sessionId)
+	assert.Equal(suite.T(), session.Status, model.SessionApproved)
+}
+
+// RevokeSessionById should revoke the session and stop it from being touched
+func (suite *SessionRepositoryTestSuite) TestRevokeSessionById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testRevokeSession@test.com")
+	sessionId := suite.insertTestSession(userId, model.SessionActive)
+
+	err := suite.sessionRepository.RevokeSessionById(sessionId)
+	assert.Nil(suite.T(), err)
+
+	session := model.Session{}
+	suite.sessionRepository.FetchSessionById(&session, sessionId)
+	assert.Equal(suite.T(), session.Status, model.SessionRevoked)
+	assert.NotNil(suite.T(), session.RevokedAt)
+
+	err = suite.sessionRepository.TouchActiveSessionById(time.Now().UTC(), sessionId, userId)
+	assert.Equal(suite.T(), err, ErrNotFound)
+}
+
+// TouchActiveSessionById should refresh the last seen time of active sessions
+func (suite *SessionRepositoryTestSuite) TestTouchActiveSessionById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testTouchSession@test.com")
+	sessionId := suite.insertTestSession(userId, model.SessionActive)
+
+	lastSeenAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	err := suite.sessionRepository.TouchActiveSessionById(lastSeenAt, sessionId, userId)
+	assert.Nil(suite.T(), err)
+
+	session := model.Session{}
+	suite.sessionRepository.FetchSessionById(&session, sessionId)
+	assert.True(suite.T(), session.LastSeenAt.Equal(lastSeenAt))
+}
+
+// TouchActiveSessionById should return expected error for sessions of another user
+func (suite *SessionRepositoryTestSuite) TestTouchActiveSessionByIdOfAnotherUser() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	sessionId := suite.insertTestSession(suite.insertTestUser("testTouchSessionOwner@test.com"), model.SessionActive)
+	otherUserId := suite.insertTestUser("testTouchSessionOther@test.com")
+
+	lastSeenAt := time.Now().UTC().Add(time.Hour).Truncate(time.Second)
+	err := suite.sessionRepository.TouchActiveSessionById(lastSeenAt, sessionId, otherUserId)
+	assert.Equal(suite.T(), err, ErrNotFound)
+
+	session := model.Session{}
+	suite.sessionRepository.FetchSessionById(&session, sessionId)
+	assert.False(suite.T(), session.LastSeenAt.Equal(lastSeenAt), "Sessions of another user should not be touched")
+}
+
+func (suite *SessionRepositoryTestSuite) insertTestUser(email string) uint64 {
+	userId, _ := suite.userRepository.InsertNewUser(&model.User{Email: email, Username: "sessionUser", Password: []byte("password")})
+	return uint64(userId.ID().(int64))
+}
+
+func (suite *SessionRepositoryTestSuite) insertTestSession(userId uint64, status string) uint64 {
+	now := time.Now().UTC()
+	sessionId, _ := suite.sessionRepository.InsertNewSession(&model.Session{
+		UserId: userId, DeviceName: "laptop", UserAgent: "Firefox", IpAddress: "10.0.0.1", Status: status, CreatedAt: now, LastSeenAt: now,
+	})
+	return uint64(sessionId.ID().(int64))
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/go-sql-driver/mysql"
+	"github.com/lib/pq"
+	"github.com/mattn/go-sqlite3"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+const (
+	maxTransactionAttempts       = 3
+	transactionRetryInterval     = 10 * time.Millisecond
+	postgresSerializationFailure = "40001"
+	postgresDeadlockDetected     = "40P01"
+	mysqlLockDeadlock            = 1213
+)
+
+// errSerializationFailure is returned for transactions the database aborted in favour of a concurrent one, those are
+// retried by the unit of work and match ErrConflict once it gives up
+var errSerializationFailure = fmt.Errorf("%w: transaction aborted by a concurrent one", ErrConflict)
+
+// errUnitOfWorkPanicked rolls back the transaction of a panicking unit of work before the panic is resumed
+var errUnitOfWorkPanicked = errors.New("repository: unit of work panicked")
+
+// UnitOfWork runs several repository calls as a single transaction
+type UnitOfWork interface {
+	// Execute runs the work with repositories bound to one transaction, which is committed if the work returns no error
+	// and rolled back otherwise, panics included. Work aborted in favour of a concurrent transaction is run again,
+	// so it shouldn't have any effects outside of the repositories.
+	Execute(work func(repositories *Repositories) error) error
+}
+
+type unitOfWorkService struct {
+	session *db.Session
+}
+
+func NewUnitOfWorkService(session *db.Session) *unitOfWorkService {
+	return &unitOfWorkService{session: session}
+}
+
+func (unitOfWork *unitOfWorkService) Execute(work func(repositories *Repositories) error) error {
+	retryInterval := transactionRetryInterval
+	var err error
+	for attempt := 1; attempt <= maxTransactionAttempts; attempt++ {
+		err = unitOfWork.attempt(work)
+		if !errors.Is(err, errSerializationFailure) || isTransaction(*unitOfWork.session) {
+			return err
+		}
+		time.Sleep(retryInterval)
+		retryInterval *= 2
+	}
+	return err
+}
+
+// attempt runs the work in a transaction, a panicking work is rolled back and panics again once the transaction is over
+func (unitOfWork *unitOfWorkService) attempt(work func(repositories *Repositories) error) error {
+	var recovered interface{}
+	err := transaction(*unitOfWork.session, func(session db.Session) (err error) {
+		defer func() {
+			if recovered = recover(); recovered != nil {
+				err = errUnitOfWorkPanicked
+			}
+		}()
+		return work(NewRepositories(&session))
+	})
+	if recovered != nil {
+		panic(recovered)
+	}
+	return translateError(err)
+}
+
+// transaction runs the function in a transaction of its own, or in the transaction the session is bound to already,
+// so the repositories of a unit of work don't escape it with transactions of their own
+func transaction(session db.Session, function func(session db.Session) error) error {
+	return transactionWithOptions(session, nil, function)
+}
+
+func transactionWithOptions(session db.Session, options *sql.TxOptions, function func(session db.Session) error) error {
+	if isTransaction(session) {
+		return function(session)
+	}
+	return session.TxContext(session.Context(), function, options)
+}
+
+func isTransaction(session db.Session) bool {
+	transactionSession, ok := session.(interface{ IsTransaction() bool })
+	return ok && transactionSession.IsTransaction()
+}
+
+// isSerializationFailure tells whether the database aborted the transaction to resolve a conflict with a concurrent one,
+// which is expected to succeed when run again
+func isSerializationFailure(err error) bool {
+	var postgresError *pq.Error
+	var sqliteError sqlite3.Error
+	var mysqlError *mysql.MySQLError
+	switch {
+	case errors.As(err, &postgresError):
+		return postgresError.Code == postgresSerializationFailure || postgresError.Code == postgresDeadlockDetected
+	case errors.As(err, &sqliteError):
+		return sqliteError.ExtendedCode == sqlite3.ErrBusySnapshot
+	case errors.As(err, &mysqlError):
+		return mysqlError.Number == mysqlLockDeadlock
+	}
+	return false
+}
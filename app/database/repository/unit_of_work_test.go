@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Execute should run work aborted in favour of a concurrent transaction again, until it succeeds or runs out of attempts
+func TestUnitOfWorkRetry(t *testing.T) {
+	session := database.InitializeDatabaseConnection(databaseutil.GenerateTestDatasourceConfiguration(database.DriverSqlite))
+	defer database.CloseDatabaseConnection(session)
+	defer databaseutil.StopTestDatabase(session)
+	unitOfWork := NewUnitOfWorkService(session)
+
+	attempts := 0
+	err := unitOfWork.Execute(func(repositories *Repositories) error {
+		attempts++
+		if attempts == 1 {
+			return &pq.Error{Code: "40001"}
+		}
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, attempts, 2, "Should run the work again after a serialization failure")
+
+	attempts = 0
+	err = unitOfWork.Execute(func(repositories *Repositories) error {
+		attempts++
+		return &pq.Error{Code: "40P01"}
+	})
+	assert.True(t, errors.Is(err, ErrConflict), "Should return a conflict once it runs out of attempts")
+	assert.Equal(t, attempts, maxTransactionAttempts)
+
+	attempts = 0
+	err = unitOfWork.Execute(func(repositories *Repositories) error {
+		attempts++
+		return ErrDuplicate
+	})
+	assert.Equal(t, err, ErrDuplicate)
+	assert.Equal(t, attempts, 1, "Should not run the work again after other errors")
+}
@@ -1,19 +1,38 @@
 package repository
 
 import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/database"
 	"github.com/KristijanFaust/gokeeper/app/database/model"
 	"github.com/iancoleman/strcase"
 	"github.com/upper/db/v4"
+	"time"
 )
 
 type UserRepository interface {
 	InsertNewUser(user *model.User) (db.InsertResult, error)
-	FetchByEmail(user *model.User, email string, queryFields []string) error
+	// FetchByEmail may read from a replica, unless the context reads from the primary
+	FetchByEmail(ctx context.Context, user *model.User, email string, queryFields []string) error
+	FetchById(user *model.User, id uint64, queryFields []string) error
 	FetchMasterPasswordByUserId(user *model.User, id uint64) error
+	UpdateEmailVerifiedById(emailVerified bool, id uint64) error
+	InsertEmailVerificationToken(token *model.EmailVerificationToken) (db.InsertResult, error)
+	ConsumeEmailVerificationToken(token *model.EmailVerificationToken, tokenHash []byte) error
+	UpdateRecoveryWrappedKeyById(recoveryWrappedKey []byte, id uint64) error
+	UpdateMasterPasswordById(
+		masterPassword []byte,
+		recoveryWrappedKey []byte,
+		wrappedPrivateKey []byte,
+		reencryptedPasswords model.Passwords,
+		rewrappedAttachments model.Attachments,
+		id uint64,
+	) error
+	InsertAccountRecovery(recovery *model.AccountRecovery) (db.InsertResult, error)
 }
 
 type userRepositoryService struct {
-	session *db.Session
+	session  *db.Session
+	replicas *database.ReplicaSet
 }
 
 func NewUserRepositoryService(session *db.Session) *userRepositoryService {
@@ -25,17 +44,109 @@ func (repository *userRepositoryService) User() db.Collection {
 }
 
 func (repository *userRepositoryService) InsertNewUser(user *model.User) (db.InsertResult, error) {
-	return repository.User().Insert(user)
+	return insert(repository.User(), user)
 }
 
-func (repository *userRepositoryService) FetchByEmail(user *model.User, email string, queryFields []string) error {
+func (repository *userRepositoryService) FetchByEmail(ctx context.Context, user *model.User, email string, queryFields []string) error {
+	query := readSession(ctx, *repository.session, repository.replicas).SQL().Select().Columns()
+	for _, field := range queryFields {
+		query = query.Columns(strcase.ToSnake(field))
+	}
+	return translateError(query.From("user").Where("email = ?", email).One(user))
+}
+
+func (repository *userRepositoryService) FetchById(user *model.User, id uint64, queryFields []string) error {
 	query := (*repository.session).SQL().Select().Columns()
 	for _, field := range queryFields {
 		query = query.Columns(strcase.ToSnake(field))
 	}
-	return query.From("user").Where("email = ?", email).One(user)
+	return translateError(query.From("user").Where("id = ?", id).One(user))
 }
 
 func (repository *userRepositoryService) FetchMasterPasswordByUserId(user *model.User, id uint64) error {
-	return (*repository.session).SQL().Select("password").From("user").Where("id = ?", id).One(user)
+	return translateError((*repository.session).SQL().Select("password").From("user").Where("id = ?", id).One(user))
+}
+
+func (repository *userRepositoryService) UpdateEmailVerifiedById(emailVerified bool, id uint64) error {
+	update := (*repository.session).SQL().Update("user").Set("email_verified", emailVerified).Where("id = ?", id)
+	_, err := update.Exec()
+	return translateError(err)
+}
+
+func (repository *userRepositoryService) InsertEmailVerificationToken(token *model.EmailVerificationToken) (db.InsertResult, error) {
+	return insert((*repository.session).Collection("email_verification_token"), token)
+}
+
+// ConsumeEmailVerificationToken fetches an unexpired token and deletes it, so every token can be used only once
+func (repository *userRepositoryService) ConsumeEmailVerificationToken(token *model.EmailVerificationToken, tokenHash []byte) error {
+	err := (*repository.session).SQL().
+		Select().
+		From("email_verification_token").
+		Where("token_hash = ? AND expires_at > ?", tokenHash, time.Now().UTC()).
+		One(token)
+	if err != nil {
+		return translateError(err)
+	}
+
+	delete := (*repository.session).SQL().DeleteFrom("email_verification_token").Where("id = ?", token.Id)
+	_, err = delete.Exec()
+	return translateError(err)
+}
+
+func (repository *userRepositoryService) UpdateRecoveryWrappedKeyById(recoveryWrappedKey []byte, id uint64) error {
+	update := (*repository.session).SQL().Update("user").Set("recovery_wrapped_key", recoveryWrappedKey).Where("id = ?", id)
+	_, err := update.Exec()
+	return translateError(err)
+}
+
+// UpdateMasterPasswordById replaces the master password together with everything encrypted or wrapped with it in a single
+// transaction, so a failure can never leave the vault encrypted with two different keys, users without a key pair pass
+// a nil private key
+func (repository *userRepositoryService) UpdateMasterPasswordById(
+	masterPassword []byte,
+	recoveryWrappedKey []byte,
+	wrappedPrivateKey []byte,
+	reencryptedPasswords model.Passwords,
+	rewrappedAttachments model.Attachments,
+	id uint64,
+) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		update := session.SQL().Update("user").Set("password", masterPassword, "recovery_wrapped_key", recoveryWrappedKey).Where("id = ?", id)
+		if _, err := update.Exec(); err != nil {
+			return err
+		}
+
+		if wrappedPrivateKey != nil {
+			update = session.SQL().Update("user_key_pair").Set("wrapped_private_key", wrappedPrivateKey).Where("user_id = ?", id)
+			if _, err := update.Exec(); err != nil {
+				return err
+			}
+		}
+
+		for _, password := range reencryptedPasswords {
+			update = session.SQL().
+				Update("password").
+				Set("password", password.Password, "totp_uri", password.TotpUri).
+				Where("id = ? AND user_id = ?", password.Id, id)
+			if _, err := update.Exec(); err != nil {
+				return err
+			}
+		}
+
+		for _, attachment := range rewrappedAttachments {
+			update = session.SQL().
+				Update("attachment").
+				Set("wrapped_data_key", attachment.WrappedDataKey).
+				Where("id = ? AND user_id = ?", attachment.Id, id)
+			if _, err := update.Exec(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}))
+}
+
+func (repository *userRepositoryService) InsertAccountRecovery(recovery *model.AccountRecovery) (db.InsertResult, error) {
+	return insert((*repository.session).Collection("account_recovery"), recovery)
 }
@@ -1,18 +1,20 @@
 package repository
 
 import (
+	"context"
 	"github.com/KristijanFaust/gokeeper/app/database"
 	"github.com/KristijanFaust/gokeeper/app/database/model"
 	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
-	"github.com/KristijanFaust/gokeeper/app/utility/test/testcontainersutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 	"github.com/upper/db/v4"
 	"testing"
+	"time"
 )
 
 type UserRepositoryTestSuite struct {
 	suite.Suite
+	driver             string
 	session            *db.Session
 	isDatabaseUp       bool
 	isDatabaseMigrated bool
@@ -20,19 +22,21 @@ type UserRepositoryTestSuite struct {
 }
 
 func TestUserSuite(t *testing.T) {
-	suite.Run(t, new(UserRepositoryTestSuite))
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &UserRepositoryTestSuite{driver: driver}) })
+	}
 }
 
 func (suite *UserRepositoryTestSuite) SetupSuite() {
-	suite.isDatabaseUp = testcontainersutil.DockerComposeUp()
-	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration()
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
 	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
 	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
 	suite.userRepository = NewUserRepositoryService(suite.session)
 }
 
 func (suite *UserRepositoryTestSuite) TearDownSuite() {
-	testcontainersutil.DockerComposeDown()
+	databaseutil.StopTestDatabase(suite.session)
 	database.CloseDatabaseConnection(suite.session)
 }
 
@@ -66,7 +70,7 @@ func (suite *UserRepositoryTestSuite) TestFetchByEmail() {
 	newUserInsertResult, err := suite.userRepository.InsertNewUser(newUser)
 
 	targetUser := &model.User{}
-	err = suite.userRepository.FetchByEmail(targetUser, newUser.Email, nil)
+	err = suite.userRepository.FetchByEmail(context.Background(), targetUser, newUser.Email, nil)
 	assert.Nil(suite.T(), err)
 
 	assert.Equal(suite.T(), targetUser.Id, uint64(newUserInsertResult.ID().(int64)))
@@ -86,7 +90,7 @@ func (suite *UserRepositoryTestSuite) TestFetchByEmailWithSpecificFields() {
 	_, err := suite.userRepository.InsertNewUser(newUser)
 
 	targetUser := &model.User{}
-	err = suite.userRepository.FetchByEmail(targetUser, newUser.Email, []string{"username"})
+	err = suite.userRepository.FetchByEmail(context.Background(), targetUser, newUser.Email, []string{"username"})
 	assert.Nil(suite.T(), err)
 
 	assert.Equal(suite.T(), targetUser.Id, uint64(0))
@@ -95,6 +99,26 @@ func (suite *UserRepositoryTestSuite) TestFetchByEmailWithSpecificFields() {
 	assert.Equal(suite.T(), targetUser.Password, []byte(nil))
 }
 
+// FetchById should successfully fetch an existing user by id from the database
+func (suite *UserRepositoryTestSuite) TestFetchById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	newUser := &model.User{Email: "testFetchById@test.com", Username: "testFetchById", Password: []byte("testFetchById")}
+
+	newUserInsertResult, err := suite.userRepository.InsertNewUser(newUser)
+
+	targetUser := &model.User{}
+	err = suite.userRepository.FetchById(targetUser, uint64(newUserInsertResult.ID().(int64)), []string{"email", "username"})
+	assert.Nil(suite.T(), err)
+
+	assert.Equal(suite.T(), targetUser.Id, uint64(0))
+	assert.Equal(suite.T(), targetUser.Email, newUser.Email)
+	assert.Equal(suite.T(), targetUser.Username, newUser.Username)
+	assert.Equal(suite.T(), targetUser.Password, []byte(nil))
+}
+
 // FetchMasterPasswordByUserId should successfully fetch an existing user's master password by id from the database
 func (suite *UserRepositoryTestSuite) TestFetchMasterPasswordByUserId() {
 	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
@@ -114,3 +138,119 @@ func (suite *UserRepositoryTestSuite) TestFetchMasterPasswordByUserId() {
 	assert.Equal(suite.T(), targetUser.Username, "")
 	assert.Equal(suite.T(), targetUser.Password, newUser.Password)
 }
+
+// UpdateEmailVerifiedById should successfully mark a user's e-mail address as verified
+func (suite *UserRepositoryTestSuite) TestUpdateEmailVerifiedById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	newUser := &model.User{Email: "testVerifyEmail@test.com", Username: "testVerifyEmail", Password: []byte("testVerifyEmail")}
+	newUserInsertResult, err := suite.userRepository.InsertNewUser(newUser)
+	userId := uint64(newUserInsertResult.ID().(int64))
+
+	err = suite.userRepository.UpdateEmailVerifiedById(true, userId)
+	assert.Nil(suite.T(), err)
+
+	targetUser := &model.User{}
+	err = suite.userRepository.FetchById(targetUser, userId, []string{"email_verified"})
+	assert.Nil(suite.T(), err)
+	assert.True(suite.T(), targetUser.EmailVerified)
+}
+
+// ConsumeEmailVerificationToken should fetch an unexpired token and delete it
+func (suite *UserRepositoryTestSuite) TestConsumeEmailVerificationToken() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	newUser := &model.User{Email: "testConsumeToken@test.com", Username: "testConsumeToken", Password: []byte("testConsumeToken")}
+	newUserInsertResult, _ := suite.userRepository.InsertNewUser(newUser)
+	userId := uint64(newUserInsertResult.ID().(int64))
+	_, err := suite.userRepository.InsertEmailVerificationToken(&model.EmailVerificationToken{
+		UserId: userId, TokenHash: []byte("tokenHash"), ExpiresAt: time.Now().UTC().Add(time.Hour),
+	})
+	assert.Nil(suite.T(), err)
+
+	token := &model.EmailVerificationToken{}
+	err = suite.userRepository.ConsumeEmailVerificationToken(token, []byte("tokenHash"))
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), token.UserId, userId)
+
+	err = suite.userRepository.ConsumeEmailVerificationToken(&model.EmailVerificationToken{}, []byte("tokenHash"))
+	assert.Equal(suite.T(), err, ErrNotFound)
+}
+
+// ConsumeEmailVerificationToken should not fetch expired tokens
+func (suite *UserRepositoryTestSuite) TestConsumeExpiredEmailVerificationToken() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	newUser := &model.User{Email: "testExpiredToken@test.com", Username: "testExpiredToken", Password: []byte("testExpiredToken")}
+	newUserInsertResult, _ := suite.userRepository.InsertNewUser(newUser)
+	suite.userRepository.InsertEmailVerificationToken(&model.EmailVerificationToken{
+		UserId: uint64(newUserInsertResult.ID().(int64)), TokenHash: []byte("expiredTokenHash"), ExpiresAt: time.Now().UTC().Add(-time.Hour),
+	})
+
+	err := suite.userRepository.ConsumeEmailVerificationToken(&model.EmailVerificationToken{}, []byte("expiredTokenHash"))
+	assert.Equal(suite.T(), err, ErrNotFound)
+}
+
+// UpdateMasterPasswordById should replace the master password, recovery key, wrapped private key and re-encrypted passwords
+func (suite *UserRepositoryTestSuite) TestUpdateMasterPasswordById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	newUser := &model.User{Email: "testRecoverAccount@test.com", Username: "testRecoverAccount", Password: []byte("oldMasterPassword")}
+	newUserInsertResult, _ := suite.userRepository.InsertNewUser(newUser)
+	userId := uint64(newUserInsertResult.ID().(int64))
+	passwordInsertResult, _ := NewPasswordRepositoryService(suite.session).InsertNewPassword(
+		&model.Password{UserId: userId, Name: "domain.com", Password: []byte("oldEncryptedPassword")},
+	)
+	passwordId := uint64(passwordInsertResult.ID().(int64))
+	NewKeyPairRepositoryService(suite.session).InsertNewKeyPair(
+		&model.KeyPair{UserId: userId, PublicKey: []byte("publicKey"), WrappedPrivateKey: []byte("oldWrappedPrivateKey")},
+	)
+
+	err := suite.userRepository.UpdateMasterPasswordById(
+		[]byte("newMasterPassword"), []byte("recoveryWrappedKey"), []byte("newWrappedPrivateKey"),
+		model.Passwords{{Id: passwordId, UserId: userId, Password: []byte("newEncryptedPassword")}}, nil, userId,
+	)
+	assert.Nil(suite.T(), err)
+
+	targetUser := &model.User{}
+	suite.userRepository.FetchById(targetUser, userId, []string{"password", "recovery_wrapped_key"})
+	assert.Equal(suite.T(), targetUser.Password, []byte("newMasterPassword"))
+	assert.Equal(suite.T(), targetUser.RecoveryWrappedKey, []byte("recoveryWrappedKey"))
+
+	targetPassword := &model.Password{}
+	NewPasswordRepositoryService(suite.session).FetchPasswordById(context.Background(), targetPassword, passwordId)
+	assert.Equal(suite.T(), targetPassword.Password, []byte("newEncryptedPassword"))
+
+	targetKeyPair := &model.KeyPair{}
+	NewKeyPairRepositoryService(suite.session).FetchKeyPairByUserId(targetKeyPair, userId)
+	assert.Equal(suite.T(), targetKeyPair.WrappedPrivateKey, []byte("newWrappedPrivateKey"))
+}
+
+// InsertAccountRecovery should successfully store an account recovery
+func (suite *UserRepositoryTestSuite) TestInsertAccountRecovery() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	newUser := &model.User{Email: "testAccountRecovery@test.com", Username: "testAccountRecovery", Password: []byte("testAccountRecovery")}
+	newUserInsertResult, _ := suite.userRepository.InsertNewUser(newUser)
+	userId := uint64(newUserInsertResult.ID().(int64))
+
+	insertResult, err := suite.userRepository.InsertAccountRecovery(
+		&model.AccountRecovery{UserId: userId, UserAgent: "Firefox", IpAddress: "10.0.0.1", RecoveredAt: time.Now().UTC()},
+	)
+	assert.Nil(suite.T(), err)
+
+	accountRecovery := model.AccountRecovery{}
+	(*suite.session).Collection("account_recovery").Find("id", insertResult.ID()).One(&accountRecovery)
+	assert.Equal(suite.T(), accountRecovery.UserId, userId)
+	assert.Equal(suite.T(), accountRecovery.IpAddress, "10.0.0.1")
+}
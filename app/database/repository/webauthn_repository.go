@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type WebauthnRepository interface {
+	InsertNewCredential(credential *model.WebauthnCredential) (db.InsertResult, error)
+	FetchCredentialsByUserId(credentials *model.WebauthnCredentials, userId uint64) error
+	FetchCredentialByCredentialId(credential *model.WebauthnCredential, credentialId []byte) error
+	UpdateSignCountById(signCount uint32, id uint64) error
+	InsertNewChallenge(challenge *model.WebauthnChallenge) (db.InsertResult, error)
+	ConsumeChallenge(challenge *model.WebauthnChallenge, value []byte, ceremony string) error
+}
+
+type webauthnRepositoryService struct {
+	session *db.Session
+}
+
+func NewWebauthnRepositoryService(session *db.Session) *webauthnRepositoryService {
+	return &webauthnRepositoryService{session: session}
+}
+
+func (repository *webauthnRepositoryService) WebauthnCredential() db.Collection {
+	return (*repository.session).Collection("webauthn_credential")
+}
+
+func (repository *webauthnRepositoryService) WebauthnChallenge() db.Collection {
+	return (*repository.session).Collection("webauthn_challenge")
+}
+
+func (repository *webauthnRepositoryService) InsertNewCredential(credential *model.WebauthnCredential) (db.InsertResult, error) {
+	return insert(repository.WebauthnCredential(), credential)
+}
+
+func (repository *webauthnRepositoryService) FetchCredentialsByUserId(credentials *model.WebauthnCredentials, userId uint64) error {
+	return translateError(
+		(*repository.session).SQL().Select().From("webauthn_credential").Where("user_id = ?", userId).All(credentials),
+	)
+}
+
+func (repository *webauthnRepositoryService) FetchCredentialByCredentialId(credential *model.WebauthnCredential, credentialId []byte) error {
+	return translateError(
+		(*repository.session).SQL().Select().From("webauthn_credential").Where("credential_id = ?", credentialId).One(credential),
+	)
+}
+
+func (repository *webauthnRepositoryService) UpdateSignCountById(signCount uint32, id uint64) error {
+	update := (*repository.session).SQL().Update("webauthn_credential").Set("sign_count", signCount).Where("id = ?", id)
+	_, err := update.Exec()
+	return translateError(err)
+}
+
+func (repository *webauthnRepositoryService) InsertNewChallenge(challenge *model.WebauthnChallenge) (db.InsertResult, error) {
+	return insert(repository.WebauthnChallenge(), challenge)
+}
+
+// ConsumeChallenge fetches an unexpired challenge and deletes it, so every challenge can be used for a single ceremony only
+func (repository *webauthnRepositoryService) ConsumeChallenge(challenge *model.WebauthnChallenge, value []byte, ceremony string) error {
+	err := (*repository.session).SQL().
+		Select().
+		From("webauthn_challenge").
+		Where("challenge = ? AND ceremony = ? AND expires_at > ?", value, ceremony, time.Now().UTC()).
+		One(challenge)
+	if err != nil {
+		return translateError(err)
+	}
+
+	delete := (*repository.session).SQL().DeleteFrom("webauthn_challenge").Where("id = ?", challenge.Id)
+	_, err = delete.Exec()
+	return translateError(err)
+}
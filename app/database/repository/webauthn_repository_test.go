@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+	"time"
+)
+
+type WebauthnRepositoryTestSuite struct {
+	suite.Suite
+	driver             string
+	session            *db.Session
+	isDatabaseUp       bool
+	isDatabaseMigrated bool
+	userRepository     UserRepository
+	webauthnRepository WebauthnRepository
+}
+
+func TestWebauthnSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &WebauthnRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *WebauthnRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.webauthnRepository = NewWebauthnRepositoryService(suite.session)
+}
+
+func (suite *WebauthnRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// InsertNewCredential should successfully insert a new webauthn credential in the database
+func (suite *WebauthnRepositoryTestSuite) TestInsertNewCredential() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testInsertCredential@test.com")
+	newCredential := &model.WebauthnCredential{
+		UserId: userId, CredentialId: []byte("insertCredential"), PublicKey: []byte("publicKey"), Name: "key", PrfWrappedKey: []byte("wrapped"),
+	}
+	credentialId, err := suite.webauthnRepository.InsertNewCredential(newCredential)
+	assert.Nil(suite.T(), err)
+
+	insertedCredential := model.WebauthnCredential{}
+	err = (*suite.session).Collection("webauthn_credential").Find("id", credentialId).One(&insertedCredential)
+
+	assert.Equal(suite.T(), insertedCredential.Id, uint64(credentialId.ID().(int64)))
+	assert.Equal(suite.T(), insertedCredential.UserId, userId)
+	assert.Equal(suite.T(), insertedCredential.CredentialId, newCredential.CredentialId)
+	assert.Equal(suite.T(), insertedCredential.PublicKey, newCredential.PublicKey)
+	assert.Equal(suite.T(), insertedCredential.SignCount, uint32(0))
+	assert.Equal(suite.T(), insertedCredential.PrfWrappedKey, newCredential.PrfWrappedKey)
+}
+
+// FetchCredentialsByUserId should fetch only the credentials of the given user
+func (suite *WebauthnRepositoryTestSuite) TestFetchCredentialsByUserId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testFetchCredentials@test.com")
+	additionalUserId := suite.insertTestUser("testFetchCredentialsAdditional@test.com")
+	suite.webauthnRepository.InsertNewCredential(&model.WebauthnCredential{UserId: userId, CredentialId: []byte("fetch1"), PublicKey: []byte("key"), Name: "key1"})
+	suite.webauthnRepository.InsertNewCredential(&model.WebauthnCredential{UserId: userId, CredentialId: []byte("fetch2"), PublicKey: []byte("key"), Name: "key2"})
+	suite.webauthnRepository.InsertNewCredential(&model.WebauthnCredential{UserId: additionalUserId, CredentialId: []byte("fetch3"), PublicKey: []byte("key"), Name: "key3"})
+
+	credentials := model.WebauthnCredentials{}
+	err := suite.webauthnRepository.FetchCredentialsByUserId(&credentials, userId)
+	assert.Nil(suite.T(), err)
+
+	assert.Equal(suite.T(), len(credentials), 2, "Should fetch exactly two credentials")
+	assert.Equal(suite.T(), credentials[0].Name, "key1")
+	assert.Equal(suite.T(), credentials[1].Name, "key2")
+}
+
+// FetchCredentialByCredentialId should fetch a credential by its authenticator credential id
+func (suite *WebauthnRepositoryTestSuite) TestFetchCredentialByCredentialId() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testFetchCredential@test.com")
+	suite.webauthnRepository.InsertNewCredential(&model.WebauthnCredential{UserId: userId, CredentialId: []byte("fetchOne"), PublicKey: []byte("key"), Name: "key"})
+
+	credential := model.WebauthnCredential{}
+	err := suite.webauthnRepository.FetchCredentialByCredentialId(&credential, []byte("fetchOne"))
+	assert.Nil(suite.T(), err)
+
+	assert.Equal(suite.T(), credential.UserId, userId)
+	assert.Equal(suite.T(), credential.PrfWrappedKey, []byte(nil))
+}
+
+// UpdateSignCountById should successfully update the credential sign count
+func (suite *WebauthnRepositoryTestSuite) TestUpdateSignCountById() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testUpdateSignCount@test.com")
+	credentialId, _ := suite.webauthnRepository.InsertNewCredential(
+		&model.WebauthnCredential{UserId: userId, CredentialId: []byte("signCount"), PublicKey: []byte("key"), Name: "key"},
+	)
+
+	err := suite.webauthnRepository.UpdateSignCountById(5, uint64(credentialId.ID().(int64)))
+	assert.Nil(suite.T(), err)
+
+	credential := model.WebauthnCredential{}
+	_ = suite.webauthnRepository.FetchCredentialByCredentialId(&credential, []byte("signCount"))
+	assert.Equal(suite.T(), credential.SignCount, uint32(5))
+}
+
+// ConsumeChallenge should fetch a challenge only once
+func (suite *WebauthnRepositoryTestSuite) TestConsumeChallenge() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testConsumeChallenge@test.com")
+	_, err := suite.webauthnRepository.InsertNewChallenge(&model.WebauthnChallenge{
+		UserId: userId, Challenge: []byte("consumeChallenge"), Ceremony: "assertion", ExpiresAt: time.Now().UTC().Add(time.Minute),
+	})
+	assert.Nil(suite.T(), err)
+
+	challenge := model.WebauthnChallenge{}
+	err = suite.webauthnRepository.ConsumeChallenge(&challenge, []byte("consumeChallenge"), "assertion")
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), challenge.UserId, userId)
+
+	err = suite.webauthnRepository.ConsumeChallenge(&model.WebauthnChallenge{}, []byte("consumeChallenge"), "assertion")
+	assert.Equal(suite.T(), err, ErrNotFound, "Challenge should not be usable twice")
+}
+
+// ConsumeChallenge should not fetch expired challenges or challenges issued for another ceremony
+func (suite *WebauthnRepositoryTestSuite) TestConsumeChallengeWithExpiredOrMismatchedChallenge() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testExpiredChallenge@test.com")
+	suite.webauthnRepository.InsertNewChallenge(&model.WebauthnChallenge{
+		UserId: userId, Challenge: []byte("expiredChallenge"), Ceremony: "assertion", ExpiresAt: time.Now().UTC().Add(-time.Minute),
+	})
+	suite.webauthnRepository.InsertNewChallenge(&model.WebauthnChallenge{
+		UserId: userId, Challenge: []byte("registrationChallenge"), Ceremony: "registration", ExpiresAt: time.Now().UTC().Add(time.Minute),
+	})
+
+	err := suite.webauthnRepository.ConsumeChallenge(&model.WebauthnChallenge{}, []byte("expiredChallenge"), "assertion")
+	assert.Equal(suite.T(), err, ErrNotFound)
+	err = suite.webauthnRepository.ConsumeChallenge(&model.WebauthnChallenge{}, []byte("registrationChallenge"), "assertion")
+	assert.Equal(suite.T(), err, ErrNotFound)
+}
+
+func (suite *WebauthnRepositoryTestSuite) insertTestUser(email string) uint64 {
+	userId, _ := suite.userRepository.InsertNewUser(&model.User{Email: email, Username: "webauthnUser", Password: []byte("password")})
+	return uint64(userId.ID().(int64))
+}
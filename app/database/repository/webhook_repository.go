@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type WebhookRepository interface {
+	InsertNewWebhook(webhook *model.Webhook) (db.InsertResult, error)
+	FetchWebhooks(webhooks *model.Webhooks) error
+	FetchWebhooksByEventType(webhooks *model.Webhooks, eventType string) error
+	DeleteWebhookById(id uint64) error
+	InsertNewDeliveries(deliveries model.WebhookDeliveries) error
+	FetchDueDeliveries(deliveries *model.WebhookDeliveries, now time.Time, limit int) error
+	FetchDeliveriesByWebhookId(deliveries *model.WebhookDeliveries, webhookId uint64, offset int, limit int) error
+	UpdateDeliveryAttempt(delivery *model.WebhookDelivery) error
+	MoveDeliveryToDeadLetter(delivery *model.WebhookDelivery) error
+	FetchDeadLetters(deadLetters *model.WebhookDeadLetters, offset int, limit int) error
+	RedeliverDeadLetterById(id uint64, now time.Time) error
+}
+
+type webhookRepositoryService struct {
+	session *db.Session
+}
+
+func NewWebhookRepositoryService(session *db.Session) *webhookRepositoryService {
+	return &webhookRepositoryService{session: session}
+}
+
+func (repository *webhookRepositoryService) Webhook() db.Collection {
+	return (*repository.session).Collection("webhook")
+}
+
+func (repository *webhookRepositoryService) InsertNewWebhook(webhook *model.Webhook) (db.InsertResult, error) {
+	return insert(repository.Webhook(), webhook)
+}
+
+func (repository *webhookRepositoryService) FetchWebhooks(webhooks *model.Webhooks) error {
+	return translateError((*repository.session).SQL().Select().From("webhook").OrderBy("id").All(webhooks))
+}
+
+func (repository *webhookRepositoryService) FetchWebhooksByEventType(webhooks *model.Webhooks, eventType string) error {
+	condition := "? = ANY(event_types)"
+	switch database.Driver(*repository.session) {
+	case database.DriverSqlite:
+		// SQLite keeps the event types as the text of a postgres array, which quotes every element
+		condition = `instr(event_types, '"' || ? || '"') > 0`
+	case database.DriverMysql:
+		// So does MySQL, where || is a logical operator
+		condition = `instr(event_types, concat('"', ?, '"')) > 0`
+	}
+
+	return translateError((*repository.session).SQL().
+		Select().
+		From("webhook").
+		Where(condition, eventType).
+		All(webhooks))
+}
+
+// DeleteWebhookById deletes the webhook together with its delivery history and dead letters
+func (repository *webhookRepositoryService) DeleteWebhookById(id uint64) error {
+	return translateError(execAffectingRows((*repository.session).SQL().DeleteFrom("webhook").Where("id", id)))
+}
+
+func (repository *webhookRepositoryService) InsertNewDeliveries(deliveries model.WebhookDeliveries) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		for index := range deliveries {
+			if _, err := session.Collection("webhook_delivery").Insert(&deliveries[index]); err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+func (repository *webhookRepositoryService) FetchDueDeliveries(deliveries *model.WebhookDeliveries, now time.Time, limit int) error {
+	return translateError((*repository.session).SQL().
+		Select().
+		From("webhook_delivery").
+		Where("status = ? AND next_attempt_at <= ?", model.WebhookDeliveryPending, now).
+		OrderBy("next_attempt_at").
+		Limit(limit).
+		All(deliveries))
+}
+
+func (repository *webhookRepositoryService) FetchDeliveriesByWebhookId(
+	deliveries *model.WebhookDeliveries, webhookId uint64, offset int, limit int,
+) error {
+	return translateError((*repository.session).SQL().
+		Select().
+		From("webhook_delivery").
+		Where("webhook_id", webhookId).
+		OrderBy("-id").
+		Offset(offset).
+		Limit(limit).
+		All(deliveries))
+}
+
+func (repository *webhookRepositoryService) UpdateDeliveryAttempt(delivery *model.WebhookDelivery) error {
+	return translateError(updateDeliveryAttempt(*repository.session, delivery))
+}
+
+// MoveDeliveryToDeadLetter stores the last attempt of a delivery that exhausted its attempts and parks it as a dead letter
+func (repository *webhookRepositoryService) MoveDeliveryToDeadLetter(delivery *model.WebhookDelivery) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		delivery.Status = model.WebhookDeliveryDead
+		if err := updateDeliveryAttempt(session, delivery); err != nil {
+			return err
+		}
+
+		_, err := session.Collection("webhook_dead_letter").Insert(&model.WebhookDeadLetter{
+			DeliveryId: delivery.Id,
+			WebhookId:  delivery.WebhookId,
+			EventType:  delivery.EventType,
+			LastError:  delivery.LastError,
+			FailedAt:   time.Now().UTC(),
+		})
+		return err
+	}))
+}
+
+func (repository *webhookRepositoryService) FetchDeadLetters(deadLetters *model.WebhookDeadLetters, offset int, limit int) error {
+	return translateError((*repository.session).SQL().
+		Select().
+		From("webhook_dead_letter").
+		OrderBy("-id").
+		Offset(offset).
+		Limit(limit).
+		All(deadLetters))
+}
+
+// RedeliverDeadLetterById removes the dead letter and schedules its delivery again with a fresh set of attempts
+func (repository *webhookRepositoryService) RedeliverDeadLetterById(id uint64, now time.Time) error {
+	return translateError(transaction(*repository.session, func(session db.Session) error {
+		deadLetter := model.WebhookDeadLetter{}
+		if err := session.SQL().Select().From("webhook_dead_letter").Where("id", id).One(&deadLetter); err != nil {
+			return err
+		}
+
+		if _, err := session.SQL().DeleteFrom("webhook_dead_letter").Where("id", id).Exec(); err != nil {
+			return err
+		}
+		return execAffectingRows(session.SQL().
+			Update("webhook_delivery").
+			Set("status", model.WebhookDeliveryPending, "attempts", 0, "next_attempt_at", now).
+			Where("id", deadLetter.DeliveryId),
+		)
+	}))
+}
+
+func updateDeliveryAttempt(session db.Session, delivery *model.WebhookDelivery) error {
+	return execAffectingRows(session.SQL().
+		Update("webhook_delivery").
+		Set(
+			"status", delivery.Status,
+			"attempts", delivery.Attempts,
+			"next_attempt_at", delivery.NextAttemptAt,
+			"response_status", delivery.ResponseStatus,
+			"last_error", delivery.LastError,
+		).
+		Where("id", delivery.Id),
+	)
+}
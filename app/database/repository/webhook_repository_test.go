@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/databaseutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
+	"testing"
+	"time"
+)
+
+type WebhookRepositoryTestSuite struct {
+	suite.Suite
+	driver             string
+	session            *db.Session
+	isDatabaseUp       bool
+	isDatabaseMigrated bool
+	userRepository     UserRepository
+	webhookRepository  WebhookRepository
+}
+
+func TestWebhookSuite(t *testing.T) {
+	for _, driver := range databaseutil.TestDrivers {
+		t.Run(driver, func(t *testing.T) { suite.Run(t, &WebhookRepositoryTestSuite{driver: driver}) })
+	}
+}
+
+func (suite *WebhookRepositoryTestSuite) SetupSuite() {
+	suite.isDatabaseUp = databaseutil.StartTestDatabase(suite.driver)
+	databaseConfiguration := databaseutil.GenerateTestDatasourceConfiguration(suite.driver)
+	suite.session = database.InitializeDatabaseConnection(databaseConfiguration)
+	suite.isDatabaseMigrated = databaseutil.RunDatabaseMigrations(databaseConfiguration)
+	suite.userRepository = NewUserRepositoryService(suite.session)
+	suite.webhookRepository = NewWebhookRepositoryService(suite.session)
+}
+
+func (suite *WebhookRepositoryTestSuite) TearDownSuite() {
+	databaseutil.StopTestDatabase(suite.session)
+	database.CloseDatabaseConnection(suite.session)
+}
+
+// FetchWebhooksByEventType should only fetch webhooks subscribed to the event type
+func (suite *WebhookRepositoryTestSuite) TestFetchWebhooksByEventType() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	userId := suite.insertTestUser("testFetchWebhooksByEventType@test.com")
+	webhookId := suite.insertTestWebhook(userId, model.AuditEventUserDisabled)
+	suite.insertTestWebhook(userId, model.AuditEventSignUp)
+
+	webhooks := model.Webhooks{}
+	err := suite.webhookRepository.FetchWebhooksByEventType(&webhooks, model.AuditEventUserDisabled)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), len(webhooks), 1)
+	assert.Equal(suite.T(), webhooks[0].Id, webhookId)
+}
+
+// MoveDeliveryToDeadLetter should mark the delivery as dead and RedeliverDeadLetterById should schedule it again
+func (suite *WebhookRepositoryTestSuite) TestDeadLetterRedelivery() {
+	if !suite.isDatabaseUp || !suite.isDatabaseMigrated {
+		suite.T().Skip("Skipping test since database container is not ready")
+	}
+
+	webhookId := suite.insertTestWebhook(suite.insertTestUser("testDeadLetterRedelivery@test.com"), model.AuditEventSignIn)
+	err := suite.webhookRepository.InsertNewDeliveries(model.WebhookDeliveries{{
+		WebhookId: webhookId, EventType: model.AuditEventSignIn, Payload: "{}", Status: model.WebhookDeliveryPending,
+		NextAttemptAt: time.Now().Add(-time.Minute),
+	}})
+	assert.Nil(suite.T(), err)
+
+	deliveries := model.WebhookDeliveries{}
+	suite.webhookRepository.FetchDeliveriesByWebhookId(&deliveries, webhookId, 0, 10)
+	assert.Equal(suite.T(), len(deliveries), 1)
+	lastError := "connection refused"
+	deliveries[0].Attempts = 8
+	deliveries[0].LastError = &lastError
+	err = suite.webhookRepository.MoveDeliveryToDeadLetter(&deliveries[0])
+	assert.Nil(suite.T(), err)
+
+	deadLetters := model.WebhookDeadLetters{}
+	suite.webhookRepository.FetchDeadLetters(&deadLetters, 0, 100)
+	var deadLetterId uint64
+	for _, deadLetter := range deadLetters {
+		if deadLetter.DeliveryId == deliveries[0].Id {
+			deadLetterId = deadLetter.Id
+			assert.Equal(suite.T(), *deadLetter.LastError, lastError)
+		}
+	}
+	assert.NotZero(suite.T(), deadLetterId)
+
+	err = suite.webhookRepository.RedeliverDeadLetterById(deadLetterId, time.Now())
+	assert.Nil(suite.T(), err)
+	suite.webhookRepository.FetchDeliveriesByWebhookId(&deliveries, webhookId, 0, 10)
+	assert.Equal(suite.T(), deliveries[0].Status, model.WebhookDeliveryPending)
+	assert.Equal(suite.T(), deliveries[0].Attempts, 0)
+
+	err = suite.webhookRepository.RedeliverDeadLetterById(deadLetterId, time.Now())
+	assert.Equal(suite.T(), err, ErrNotFound)
+}
+
+func (suite *WebhookRepositoryTestSuite) insertTestUser(email string) uint64 {
+	userId, _ := suite.userRepository.InsertNewUser(&model.User{Email: email, Username: "webhookUser", Password: []byte("password")})
+	return uint64(userId.ID().(int64))
+}
+
+func (suite *WebhookRepositoryTestSuite) insertTestWebhook(userId uint64, eventType string) uint64 {
+	webhookId, _ := suite.webhookRepository.InsertNewWebhook(&model.Webhook{
+		CreatedBy: userId, Url: "http://localhost:9000/hooks", Secret: []byte("secret"), EventTypes: []string{eventType},
+	})
+	return uint64(webhookId.ID().(int64))
+}
@@ -0,0 +1,84 @@
+package demo
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"log"
+)
+
+// Password is the master password of every demo user
+const Password = "gokeeper-demo"
+
+type demoEntry struct {
+	name     string
+	password string
+	totpUri  string
+}
+
+type demoUser struct {
+	email    string
+	username string
+	role     string
+	entries  []demoEntry
+}
+
+var demoUsers = []demoUser{
+	{
+		email:    "demo@gokeeper.local",
+		username: "demo",
+		role:     model.UserRoleAdmin,
+		entries: []demoEntry{
+			{name: "GitHub", password: "correct-horse-battery-staple", totpUri: "otpauth://totp/GitHub:demo?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&issuer=GitHub"},
+			{name: "E-mail", password: "Tr0ub4dor&3"},
+			{name: "Bank", password: "4n0th3r-s3cr3t"},
+		},
+	},
+	{
+		email:    "alex@gokeeper.local",
+		username: "alex",
+		role:     model.UserRoleUser,
+		entries: []demoEntry{
+			{name: "Streaming", password: "popcorn-and-chill"},
+		},
+	},
+}
+
+// Seed fills the repositories with verified demo users and their vaults, the entries are encrypted the same way
+// the resolvers encrypt them so the demo users can sign in and read them with the demo password
+func Seed(repositories *repository.Repositories, hasher security.Argon2PasswordHasher, cryptor security.AesPasswordCryptor) error {
+	masterPassword := hasher.HashWithArgon2id(Password)
+
+	for _, user := range demoUsers {
+		insertResult, err := repositories.User.InsertNewUser(&model.User{
+			Email: user.email, Username: user.username, Password: masterPassword, EmailVerified: true, Role: user.role,
+		})
+		if err != nil {
+			return err
+		}
+		userId := uint64(insertResult.ID().(int64))
+
+		for _, entry := range user.entries {
+			encryptedPassword, err := cryptor.EncryptWithAes(entry.password, masterPassword)
+			if err != nil {
+				return err
+			}
+			var encryptedTotpUri []byte
+			if entry.totpUri != "" {
+				if encryptedTotpUri, err = cryptor.EncryptWithAes(entry.totpUri, masterPassword); err != nil {
+					return err
+				}
+			}
+
+			_, err = repositories.Password.InsertNewPassword(
+				&model.Password{UserId: userId, Name: entry.name, Password: encryptedPassword, TotpUri: encryptedTotpUri},
+			)
+			if err != nil {
+				return err
+			}
+		}
+		log.Printf("Seeded demo user %s with %d entries, the master password is %s", user.email, len(user.entries), Password)
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+package demo
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/database/memory"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Seed should store demo users that can sign in with the demo password and decrypt their entries
+func TestSeed(t *testing.T) {
+	repositories := memory.NewRepositories(memory.NewStore())
+	hasher, cryptor := &security.PasswordHashService{}, &security.PasswordCryptoService{}
+
+	err := Seed(repositories, hasher, cryptor)
+	assert.Nil(t, err, "Should seed the demo data without errors")
+
+	user := model.User{}
+	err = repositories.User.FetchByEmail(context.Background(), &user, "demo@gokeeper.local", nil)
+	assert.Nil(t, err, "Should seed the demo user")
+	assert.Equal(t, user.Password, hasher.HashWithArgon2id(Password), "Demo user should sign in with the demo password")
+	assert.True(t, user.EmailVerified)
+	assert.Equal(t, user.Role, model.UserRoleAdmin)
+
+	passwords := model.Passwords{}
+	repositories.Password.FetchAllByUserId(context.Background(), &passwords, user.Id, nil)
+	assert.Len(t, passwords, 3)
+	decryptedPassword, err := cryptor.DecryptWithAes(passwords[0].Password, user.Password)
+	assert.Nil(t, err, "Entries should be encrypted with the demo user's master password")
+	assert.Equal(t, decryptedPassword, "correct-horse-battery-staple")
+	decryptedTotpUri, err := cryptor.DecryptWithAes(passwords[0].TotpUri, user.Password)
+	assert.Nil(t, err)
+	assert.Contains(t, decryptedTotpUri, "otpauth://totp/")
+	assert.Nil(t, passwords[1].TotpUri, "Entries without a seed should store none")
+}
+
+// Seed should fail if the demo data was seeded already
+func TestSeedTwice(t *testing.T) {
+	repositories := memory.NewRepositories(memory.NewStore())
+	hasher, cryptor := &security.PasswordHashService{}, &security.PasswordCryptoService{}
+
+	assert.Nil(t, Seed(repositories, hasher, cryptor))
+	assert.NotNil(t, Seed(repositories, hasher, cryptor), "Demo users should not be seeded twice")
+}
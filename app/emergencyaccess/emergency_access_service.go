@@ -0,0 +1,87 @@
+package emergencyaccess
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"log"
+	"time"
+)
+
+var ErrInactiveGrant = errors.New("emergency access grant is not active")
+
+type Approver interface {
+	ApproveRequest(request *model.EmergencyAccessRequest) error
+	ApproveElapsedRequests() int
+}
+
+type emergencyAccessService struct {
+	emergencyAccessRepository repository.EmergencyAccessRepository
+	keyPairRepository         repository.KeyPairRepository
+	userRepository            repository.UserRepository
+	keyPairCryptor            security.KeyPairCryptor
+}
+
+func NewEmergencyAccessService(
+	emergencyAccessRepository repository.EmergencyAccessRepository,
+	keyPairRepository repository.KeyPairRepository,
+	userRepository repository.UserRepository,
+	keyPairCryptor security.KeyPairCryptor,
+) *emergencyAccessService {
+	return &emergencyAccessService{
+		emergencyAccessRepository: emergencyAccessRepository,
+		keyPairRepository:         keyPairRepository,
+		userRepository:            userRepository,
+		keyPairCryptor:            keyPairCryptor,
+	}
+}
+
+// ApproveRequest seals the grantor's vault key with the grantee's public key, so only the grantee can use the approval
+func (service *emergencyAccessService) ApproveRequest(request *model.EmergencyAccessRequest) error {
+	grant := model.EmergencyAccessGrant{}
+	if err := service.emergencyAccessRepository.FetchGrantById(&grant, request.GrantId); err != nil {
+		return err
+	}
+	if grant.Status != model.EmergencyAccessGrantAccepted {
+		return ErrInactiveGrant
+	}
+
+	grantor := model.User{}
+	if err := service.userRepository.FetchMasterPasswordByUserId(&grantor, grant.GrantorId); err != nil {
+		return err
+	}
+
+	granteeKeyPair := model.KeyPair{}
+	if err := service.keyPairRepository.FetchKeyPairByUserId(&granteeKeyPair, grant.GranteeId); err != nil {
+		return err
+	}
+
+	sealedVaultKey, err := service.keyPairCryptor.SealForPublicKey(grantor.Password, granteeKeyPair.PublicKey)
+	if err != nil {
+		return err
+	}
+
+	return service.emergencyAccessRepository.ApproveRequestById(sealedVaultKey, request.Id)
+}
+
+// ApproveElapsedRequests approves every pending request whose waiting period passed without a rejection from the grantor
+func (service *emergencyAccessService) ApproveElapsedRequests() int {
+	requests := model.EmergencyAccessRequests{}
+	err := service.emergencyAccessRepository.FetchPendingRequestsAvailableBefore(&requests, time.Now().UTC())
+	if err != nil {
+		log.Printf("Error while fetching pending emergency access requests: %s", err)
+		return 0
+	}
+
+	approvedRequests := 0
+	for index := range requests {
+		if err = service.ApproveRequest(&requests[index]); err != nil {
+			log.Printf("Error while approving emergency access request %d: %s", requests[index].Id, err)
+			continue
+		}
+		approvedRequests++
+	}
+
+	return approvedRequests
+}
@@ -0,0 +1,100 @@
+package emergencyaccess
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+)
+
+// ApproveRequest should seal the grantor's vault key with the grantee's public key and approve the request
+func TestApproveRequest(t *testing.T) {
+	emergencyAccessRepositoryMock := mockutil.DefaultEmergencyAccessRepositoryServiceMock()
+	keyPairRepositoryMock := new(mockutil.KeyPairRepositoryServiceMock)
+	keyPairRepositoryMock.On("FetchKeyPairByUserId", mock.Anything, mockutil.DefaultGranteeIdAsUint64).Return(nil).Times(1)
+	keyPairCryptorMock := mockutil.DefaultPasswordSecurityServiceMock()
+	service := NewEmergencyAccessService(
+		emergencyAccessRepositoryMock, keyPairRepositoryMock, mockutil.DefaultUserRepositoryServiceMock(), keyPairCryptorMock,
+	)
+	request := mockutil.DefaultEmergencyAccessRequest()
+
+	err := service.ApproveRequest(&request)
+	assert.Nil(t, err, "Request should be approved without errors")
+
+	keyPairCryptorMock.AssertCalled(t, "SealForPublicKey", []byte(mockutil.MockedUserMasterPassword), []byte(mockutil.MockedPublicKey))
+	emergencyAccessRepositoryMock.AssertCalled(t, "ApproveRequestById", []byte(mockutil.MockedSealedVaultKey), request.Id)
+}
+
+// ApproveRequest should never approve requests of grants that are not accepted
+func TestApproveRequestWithInactiveGrant(t *testing.T) {
+	grant := mockutil.DefaultEmergencyAccessGrant()
+	grant.Status = model.EmergencyAccessGrantRevoked
+	emergencyAccessRepositoryMock := new(mockutil.EmergencyAccessRepositoryServiceMock)
+	emergencyAccessRepositoryMock.On("FetchGrantById", mock.Anything, mock.Anything).Return(nil, grant).Times(1)
+	service := NewEmergencyAccessService(
+		emergencyAccessRepositoryMock, mockutil.DefaultKeyPairRepositoryServiceMock(),
+		mockutil.DefaultUserRepositoryServiceMock(), mockutil.DefaultPasswordSecurityServiceMock(),
+	)
+	request := mockutil.DefaultEmergencyAccessRequest()
+
+	err := service.ApproveRequest(&request)
+	assert.Equal(t, err, ErrInactiveGrant, "Should return expected error for inactive grants")
+	emergencyAccessRepositoryMock.AssertNotCalled(t, "ApproveRequestById", mock.Anything, mock.Anything)
+}
+
+// ApproveRequest should return the error when the grantee's key pair can't be fetched
+func TestApproveRequestWithKeyPairError(t *testing.T) {
+	emergencyAccessRepositoryMock := mockutil.DefaultEmergencyAccessRepositoryServiceMock()
+	service := NewEmergencyAccessService(
+		emergencyAccessRepositoryMock, mockutil.DefaultKeyPairRepositoryServiceMock(),
+		mockutil.DefaultUserRepositoryServiceMock(), mockutil.DefaultPasswordSecurityServiceMock(),
+	)
+	request := mockutil.DefaultEmergencyAccessRequest()
+
+	err := service.ApproveRequest(&request)
+	assert.Equal(t, err, repository.ErrNotFound, "Should return expected error")
+	emergencyAccessRepositoryMock.AssertNotCalled(t, "ApproveRequestById", mock.Anything, mock.Anything)
+}
+
+// ApproveElapsedRequests should approve every elapsed request and skip the ones that fail
+func TestApproveElapsedRequests(t *testing.T) {
+	firstRequest := mockutil.DefaultEmergencyAccessRequest()
+	secondRequest := mockutil.DefaultEmergencyAccessRequest()
+	secondRequest.Id = uint64(2)
+	emergencyAccessRepositoryMock := new(mockutil.EmergencyAccessRepositoryServiceMock)
+	emergencyAccessRepositoryMock.On("FetchPendingRequestsAvailableBefore", mock.Anything, mock.Anything).Return(
+		nil, model.EmergencyAccessRequests{firstRequest, secondRequest},
+	).Times(1)
+	emergencyAccessRepositoryMock.On("FetchGrantById", mock.Anything, mock.Anything).Return(nil, mockutil.DefaultEmergencyAccessGrant())
+	emergencyAccessRepositoryMock.On("ApproveRequestById", mock.Anything, firstRequest.Id).Return(nil).Times(1)
+	emergencyAccessRepositoryMock.On("ApproveRequestById", mock.Anything, secondRequest.Id).Return(repository.ErrNotFound).Times(1)
+	keyPairRepositoryMock := new(mockutil.KeyPairRepositoryServiceMock)
+	keyPairRepositoryMock.On("FetchKeyPairByUserId", mock.Anything, mock.Anything).Return(nil)
+	userRepositoryMock := new(mockutil.UserRepositoryServiceMock)
+	userRepositoryMock.On("FetchMasterPasswordByUserId", mock.Anything, mock.Anything).Return(nil)
+	keyPairCryptorMock := new(mockutil.PasswordSecurityServiceMock)
+	keyPairCryptorMock.On("SealForPublicKey", mock.Anything, mock.Anything).Return([]byte(mockutil.MockedSealedVaultKey), nil)
+	service := NewEmergencyAccessService(emergencyAccessRepositoryMock, keyPairRepositoryMock, userRepositoryMock, keyPairCryptorMock)
+
+	approvedRequests := service.ApproveElapsedRequests()
+	assert.Equal(t, approvedRequests, 1, "Should approve only the requests that didn't fail")
+	emergencyAccessRepositoryMock.AssertNumberOfCalls(t, "ApproveRequestById", 2)
+}
+
+// ApproveElapsedRequests should not approve anything when pending requests can't be fetched
+func TestApproveElapsedRequestsWithFetchError(t *testing.T) {
+	emergencyAccessRepositoryMock := new(mockutil.EmergencyAccessRepositoryServiceMock)
+	emergencyAccessRepositoryMock.On("FetchPendingRequestsAvailableBefore", mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage), nil,
+	).Times(1)
+	service := NewEmergencyAccessService(
+		emergencyAccessRepositoryMock, mockutil.DefaultKeyPairRepositoryServiceMock(),
+		mockutil.DefaultUserRepositoryServiceMock(), mockutil.DefaultPasswordSecurityServiceMock(),
+	)
+
+	assert.Equal(t, service.ApproveElapsedRequests(), 0, "Should not approve any requests")
+	emergencyAccessRepositoryMock.AssertNotCalled(t, "ApproveRequestById", mock.Anything, mock.Anything)
+}
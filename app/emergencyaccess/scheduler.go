@@ -0,0 +1,46 @@
+package emergencyaccess
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Scheduler periodically advances the waiting periods of emergency access requests
+type Scheduler struct {
+	approver  Approver
+	interval  time.Duration
+	stop      chan struct{}
+	stopOnce  sync.Once
+	waitGroup sync.WaitGroup
+}
+
+func NewScheduler(approver Approver, interval time.Duration) *Scheduler {
+	return &Scheduler{approver: approver, interval: interval, stop: make(chan struct{})}
+}
+
+func (scheduler *Scheduler) Start() {
+	scheduler.waitGroup.Add(1)
+	go func() {
+		defer scheduler.waitGroup.Done()
+		ticker := time.NewTicker(scheduler.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-scheduler.stop:
+				return
+			case <-ticker.C:
+				if approvedRequests := scheduler.approver.ApproveElapsedRequests(); approvedRequests > 0 {
+					log.Printf("Approved %d emergency access request/s with elapsed waiting periods", approvedRequests)
+				}
+			}
+		}
+	}()
+}
+
+// Stop waits for a running approval round to finish
+func (scheduler *Scheduler) Stop() {
+	scheduler.stopOnce.Do(func() { close(scheduler.stop) })
+	scheduler.waitGroup.Wait()
+}
@@ -0,0 +1,50 @@
+package emergencyaccess
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Scheduler should periodically approve elapsed requests until stopped
+func TestScheduler(t *testing.T) {
+	approver := &countingApprover{}
+	scheduler := NewScheduler(approver, 10*time.Millisecond)
+
+	scheduler.Start()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&approver.rounds) >= 2
+	}, time.Second, 5*time.Millisecond, "Scheduler should run approval rounds periodically")
+	scheduler.Stop()
+
+	roundsAfterStop := atomic.LoadInt32(&approver.rounds)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, atomic.LoadInt32(&approver.rounds), roundsAfterStop, "Scheduler should not run after it's stopped")
+}
+
+// Stop should be safe to call multiple times
+func TestSchedulerStopTwice(t *testing.T) {
+	scheduler := NewScheduler(mockutil.DefaultEmergencyAccessApproverMock(), time.Hour)
+	scheduler.Start()
+
+	assert.NotPanics(t, func() {
+		scheduler.Stop()
+		scheduler.Stop()
+	}, "Stopping the scheduler twice should not panic")
+}
+
+type countingApprover struct {
+	rounds int32
+}
+
+func (approver *countingApprover) ApproveRequest(*model.EmergencyAccessRequest) error {
+	return nil
+}
+
+func (approver *countingApprover) ApproveElapsedRequests() int {
+	atomic.AddInt32(&approver.rounds, 1)
+	return 1
+}
@@ -0,0 +1,89 @@
+package eventbus
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	VaultEntryCreated = "created"
+	VaultEntryUpdated = "updated"
+	VaultEntryDeleted = "deleted"
+)
+
+// subscriptionBufferSize is how many events a slow subscriber can fall behind before further events get dropped
+const subscriptionBufferSize = 16
+
+// Event notifies the subscriptions of a user about a change of one of their vault entries
+type Event struct {
+	UserId     uint64    `json:"userId"`
+	PasswordId uint64    `json:"passwordId"`
+	Action     string    `json:"action"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+type Publisher interface {
+	Publish(event Event)
+}
+
+// Subscriber returns a channel receiving the events of the user and a function ending the subscription,
+// the channel gets closed once the subscription ends
+type Subscriber interface {
+	Subscribe(userId uint64) (<-chan Event, func())
+}
+
+type Bus interface {
+	Publisher
+	Subscriber
+}
+
+// LocalBus delivers events to the subscriptions of this server instance
+type LocalBus struct {
+	mutex         sync.RWMutex
+	subscriptions map[uint64]map[chan Event]struct{}
+}
+
+func NewLocalBus() *LocalBus {
+	return &LocalBus{subscriptions: map[uint64]map[chan Event]struct{}{}}
+}
+
+// Publish never blocks, a subscription that can't keep up misses the event rather than holding back everyone else
+func (bus *LocalBus) Publish(event Event) {
+	bus.mutex.RLock()
+	defer bus.mutex.RUnlock()
+
+	for subscription := range bus.subscriptions[event.UserId] {
+		select {
+		case subscription <- event:
+		default:
+			log.Printf("Dropping vault event of user %d for a subscription that fell behind", event.UserId)
+		}
+	}
+}
+
+func (bus *LocalBus) Subscribe(userId uint64) (<-chan Event, func()) {
+	subscription := make(chan Event, subscriptionBufferSize)
+
+	bus.mutex.Lock()
+	if bus.subscriptions[userId] == nil {
+		bus.subscriptions[userId] = map[chan Event]struct{}{}
+	}
+	bus.subscriptions[userId][subscription] = struct{}{}
+	bus.mutex.Unlock()
+
+	unsubscribeOnce := sync.Once{}
+	unsubscribe := func() {
+		unsubscribeOnce.Do(func() {
+			bus.mutex.Lock()
+			defer bus.mutex.Unlock()
+
+			delete(bus.subscriptions[userId], subscription)
+			if len(bus.subscriptions[userId]) == 0 {
+				delete(bus.subscriptions, userId)
+			}
+			close(subscription)
+		})
+	}
+	return subscription, unsubscribe
+}
@@ -0,0 +1,56 @@
+package eventbus
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// Publish should deliver the event to every subscription of the user and to no one else
+func TestLocalBusPublish(t *testing.T) {
+	bus := NewLocalBus()
+	firstSubscription, unsubscribeFirst := bus.Subscribe(1)
+	defer unsubscribeFirst()
+	secondSubscription, unsubscribeSecond := bus.Subscribe(1)
+	defer unsubscribeSecond()
+	otherUserSubscription, unsubscribeOtherUser := bus.Subscribe(2)
+	defer unsubscribeOtherUser()
+
+	event := testEvent()
+	bus.Publish(event)
+
+	assert.Equal(t, <-firstSubscription, event)
+	assert.Equal(t, <-secondSubscription, event)
+	assert.Equal(t, len(otherUserSubscription), 0)
+}
+
+// Publish should drop events for subscriptions that fell behind instead of blocking
+func TestLocalBusPublishToFullSubscription(t *testing.T) {
+	bus := NewLocalBus()
+	subscription, unsubscribe := bus.Subscribe(1)
+	defer unsubscribe()
+
+	for index := 0; index < subscriptionBufferSize+1; index++ {
+		bus.Publish(testEvent())
+	}
+
+	assert.Equal(t, len(subscription), subscriptionBufferSize)
+}
+
+// Unsubscribe should close the subscription's channel and can be called more than once
+func TestLocalBusUnsubscribe(t *testing.T) {
+	bus := NewLocalBus()
+	subscription, unsubscribe := bus.Subscribe(1)
+
+	unsubscribe()
+	unsubscribe()
+	bus.Publish(testEvent())
+
+	_, open := <-subscription
+	assert.False(t, open)
+	assert.Equal(t, len(bus.subscriptions), 0)
+}
+
+func testEvent() Event {
+	return Event{UserId: 1, PasswordId: 2, Action: VaultEntryUpdated, OccurredAt: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
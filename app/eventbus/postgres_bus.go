@@ -0,0 +1,134 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/lib/pq"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/postgresql"
+	"log"
+	"sync"
+	"time"
+)
+
+const (
+	notificationChannel          = "gokeeper_vault_changed"
+	listenerMinReconnectInterval = 1 * time.Second
+	listenerMaxReconnectInterval = 1 * time.Minute
+	listenerPingInterval         = 90 * time.Second
+)
+
+// notificationListener is the part of pq.Listener used by the PostgresBus
+type notificationListener interface {
+	Listen(channel string) error
+	NotificationChannel() <-chan *pq.Notification
+	Ping() error
+	Close() error
+}
+
+// PostgresBus relays events through Postgres LISTEN/NOTIFY so the subscriptions of every server instance
+// connected to the same database receive them, each instance delivers the notifications it receives to its LocalBus
+type PostgresBus struct {
+	localBus  *LocalBus
+	listener  notificationListener
+	notify    func(payload string) error
+	stop      chan struct{}
+	stopOnce  sync.Once
+	waitGroup sync.WaitGroup
+}
+
+func NewPostgresBus(datasourceConfig *config.Datasource, session *db.Session) *PostgresBus {
+	connectionUrl := &postgresql.ConnectionURL{
+		Database: datasourceConfig.Database,
+		Host:     datasourceConfig.Host,
+		User:     datasourceConfig.User,
+		Password: datasourceConfig.Password,
+	}
+	listener := pq.NewListener(
+		connectionUrl.String(), listenerMinReconnectInterval, listenerMaxReconnectInterval,
+		func(event pq.ListenerEventType, err error) {
+			if err != nil {
+				log.Printf("Vault event listener connection error: %s", err)
+			}
+		},
+	)
+
+	return newPostgresBus(listener, func(payload string) error {
+		_, err := (*session).SQL().Exec("SELECT pg_notify(?, ?)", notificationChannel, payload)
+		return err
+	})
+}
+
+func newPostgresBus(listener notificationListener, notify func(payload string) error) *PostgresBus {
+	return &PostgresBus{localBus: NewLocalBus(), listener: listener, notify: notify, stop: make(chan struct{})}
+}
+
+// Publish notifies every server instance, this one included, if the notification fails the event
+// is at least delivered to the subscriptions of this instance
+func (bus *PostgresBus) Publish(event Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("Error while encoding vault event: %s", err)
+		return
+	}
+
+	if err = bus.notify(string(payload)); err != nil {
+		log.Printf("Error while notifying other instances about a vault event: %s", err)
+		bus.localBus.Publish(event)
+	}
+}
+
+func (bus *PostgresBus) Subscribe(userId uint64) (<-chan Event, func()) {
+	return bus.localBus.Subscribe(userId)
+}
+
+// Start listens for notifications until the bus is stopped
+func (bus *PostgresBus) Start() error {
+	if err := bus.listener.Listen(notificationChannel); err != nil {
+		return err
+	}
+
+	bus.waitGroup.Add(1)
+	go func() {
+		defer bus.waitGroup.Done()
+		ticker := time.NewTicker(listenerPingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-bus.stop:
+				return
+			case notification := <-bus.listener.NotificationChannel():
+				bus.handleNotification(notification)
+			case <-ticker.C:
+				if err := bus.listener.Ping(); err != nil {
+					log.Printf("Error while pinging vault event listener: %s", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (bus *PostgresBus) Stop() {
+	bus.stopOnce.Do(func() { close(bus.stop) })
+	bus.waitGroup.Wait()
+	if err := bus.listener.Close(); err != nil {
+		log.Printf("Error while closing vault event listener: %s", err)
+	}
+}
+
+func (bus *PostgresBus) handleNotification(notification *pq.Notification) {
+	// The listener sends nil after it reconnects, notifications sent in the meantime are lost
+	if notification == nil {
+		log.Println("Vault event listener reconnected, some vault events might have been missed")
+		return
+	}
+
+	event := Event{}
+	if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+		log.Printf("Error while decoding vault event: %s", err)
+		return
+	}
+	bus.localBus.Publish(event)
+}
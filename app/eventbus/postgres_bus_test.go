@@ -0,0 +1,76 @@
+package eventbus
+
+import (
+	"errors"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+type notificationListenerFake struct {
+	listenedChannel string
+	notifications   chan *pq.Notification
+	closed          bool
+}
+
+func (listener *notificationListenerFake) Listen(channel string) error {
+	listener.listenedChannel = channel
+	return nil
+}
+
+func (listener *notificationListenerFake) NotificationChannel() <-chan *pq.Notification {
+	return listener.notifications
+}
+
+func (listener *notificationListenerFake) Ping() error {
+	return nil
+}
+
+func (listener *notificationListenerFake) Close() error {
+	listener.closed = true
+	return nil
+}
+
+// Publish should only notify the database, subscriptions receive the event once the notification comes back
+func TestPostgresBusPublish(t *testing.T) {
+	listener := &notificationListenerFake{notifications: make(chan *pq.Notification, 1)}
+	bus := newPostgresBus(listener, func(payload string) error {
+		listener.notifications <- &pq.Notification{Channel: notificationChannel, Extra: payload}
+		return nil
+	})
+	subscription, unsubscribe := bus.Subscribe(1)
+	defer unsubscribe()
+
+	assert.Nil(t, bus.Start())
+	event := testEvent()
+	bus.Publish(event)
+
+	assert.Equal(t, <-subscription, event)
+	bus.Stop()
+	assert.Equal(t, listener.listenedChannel, notificationChannel)
+	assert.True(t, listener.closed)
+}
+
+// Publish should deliver the event locally if the database can't be notified
+func TestPostgresBusPublishWithNotifyError(t *testing.T) {
+	bus := newPostgresBus(&notificationListenerFake{}, func(payload string) error { return errors.New("connection refused") })
+	subscription, unsubscribe := bus.Subscribe(1)
+	defer unsubscribe()
+
+	event := testEvent()
+	bus.Publish(event)
+
+	assert.Equal(t, <-subscription, event)
+}
+
+// handleNotification should skip reconnect signals and malformed payloads
+func TestPostgresBusHandleInvalidNotification(t *testing.T) {
+	bus := newPostgresBus(&notificationListenerFake{}, nil)
+	subscription, unsubscribe := bus.Subscribe(1)
+	defer unsubscribe()
+
+	bus.handleNotification(nil)
+	bus.handleNotification(&pq.Notification{Channel: notificationChannel, Extra: "{invalid"})
+
+	assert.Equal(t, len(subscription), 0)
+}
@@ -0,0 +1,94 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"crypto/subtle"
+	"log"
+	"strconv"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) RecoverAccount(ctx context.Context, input model.AccountRecovery) (*model.UserWithRecoveryKey, error) {
+	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
+	if validationErrors != nil {
+		return nil, gqlerror.Errorf("validation error/s on account recovery input")
+	}
+
+	fetchedUser := databaseModel.User{}
+	err := r.userRepository.FetchByEmail(ctx, &fetchedUser, input.Email, nil)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, repositoryError(err, queryNonExistingEmailErrorMessage)
+		}
+		log.Printf("Error while fetching user by email: %s", err)
+		return nil, repositoryError(err, accountRecoveryErrorMessage)
+	}
+	if fetchedUser.RecoveryWrappedKey == nil {
+		return nil, gqlerror.Errorf(recoveryKeyNotSetUpErrorMessage)
+	}
+
+	// A recovery key generated before the last master password change unwraps a stale vault key
+	vaultKey, err := r.passwordSecurityService.UnwrapWithRecoveryKey(fetchedUser.RecoveryWrappedKey, input.RecoveryKey)
+	if err != nil || subtle.ConstantTimeCompare(vaultKey, fetchedUser.Password) == 0 {
+		log.Printf("Failed account recovery attempt for user %d", fetchedUser.Id)
+		return nil, gqlerror.Errorf(invalidRecoveryKeyErrorMessage)
+	}
+
+	recoveryKey, err := r.changeMasterPassword(ctx, fetchedUser.Id, vaultKey, input.NewPassword)
+	if err != nil {
+		log.Printf("Error while changing user master password: %s", err)
+		return nil, repositoryError(err, accountRecoveryErrorMessage)
+	}
+
+	r.logAccountRecovery(ctx, fetchedUser.Id)
+
+	user := &model.User{
+		ID:            strconv.FormatUint(fetchedUser.Id, 10),
+		Email:         fetchedUser.Email,
+		Username:      fetchedUser.Username,
+		EmailVerified: fetchedUser.EmailVerified,
+	}
+	return &model.UserWithRecoveryKey{User: user, RecoveryKey: recoveryKey}, nil
+}
+
+func (r *mutationResolver) RegenerateRecoveryKey(ctx context.Context) (string, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return "", gqlerror.Errorf(recoveryKeyAuthenticationErrorMessage)
+	}
+
+	user := databaseModel.User{}
+	err := r.userRepository.FetchMasterPasswordByUserId(&user, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching user master password: %s", err)
+		return "", repositoryError(err, recoveryKeyGenerationErrorMessage)
+	}
+
+	recoveryKey, err := r.passwordSecurityService.GenerateRecoveryKey()
+	if err != nil {
+		log.Printf("Error while generating recovery key: %s", err)
+		return "", gqlerror.Errorf(recoveryKeyGenerationErrorMessage)
+	}
+	recoveryWrappedKey, err := r.passwordSecurityService.WrapWithRecoveryKey(user.Password, recoveryKey)
+	if err != nil {
+		log.Printf("Error while wrapping vault key with recovery key: %s", err)
+		return "", gqlerror.Errorf(recoveryKeyGenerationErrorMessage)
+	}
+
+	err = r.userRepository.UpdateRecoveryWrappedKeyById(recoveryWrappedKey, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while updating user recovery key: %s", err)
+		return "", repositoryError(err, recoveryKeyGenerationErrorMessage)
+	}
+
+	r.notifySecurityChange(userAuthentication.UserId, recoveryKeyRegeneratedEvent)
+
+	return recoveryKey, nil
+}
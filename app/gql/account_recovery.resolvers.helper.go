@@ -0,0 +1,123 @@
+package gql
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"log"
+	"time"
+)
+
+const (
+	accountRecoveryErrorMessage           = "could not recover account"
+	invalidRecoveryKeyErrorMessage        = "invalid recovery key"
+	recoveryKeyNotSetUpErrorMessage       = "account has no recovery key"
+	recoveryKeyGenerationErrorMessage     = "could not generate a new recovery key"
+	recoveryKeyAuthenticationErrorMessage = "unauthorized recovery key request"
+	accountRecoveredEvent                 = "your account was recovered with a recovery key and the master password was changed"
+	recoveryKeyRegeneratedEvent           = "a new recovery key was generated, previous recovery keys no longer work"
+)
+
+// changeMasterPassword re-encrypts everything encrypted with the old vault key, wraps the attachment data keys with the
+// new one and issues a new recovery key, since recovery keys wrapping the old vault key become useless. Every session
+// of the user gets revoked, so whoever was signed in before the change has to sign in with the new master password.
+// It's all stored in a single unit of work, as a vault stored half way through no longer decrypts with either
+// master password.
+func (r *Resolver) changeMasterPassword(ctx context.Context, userId uint64, oldVaultKey []byte, newPassword string) (string, error) {
+	newVaultKey := r.passwordSecurityService.HashWithArgon2id(newPassword)
+	recoveryKey := ""
+	err := r.unitOfWork.Execute(func(repositories *repository.Repositories) error {
+		reencryptedPasswords, err := r.reencryptPasswords(ctx, repositories.Password, userId, oldVaultKey, newVaultKey)
+		if err != nil {
+			return err
+		}
+
+		wrappedPrivateKey, err := r.rewrapPrivateKey(repositories.KeyPair, userId, oldVaultKey, newVaultKey)
+		if err != nil {
+			return err
+		}
+
+		attachments := databaseModel.Attachments{}
+		if err = repositories.Attachment.FetchAttachmentsByUserId(&attachments, userId); err != nil {
+			return err
+		}
+		rewrappedAttachments, err := r.attachmentManager.RewrapDataKeys(attachments, oldVaultKey, newVaultKey)
+		if err != nil {
+			return err
+		}
+
+		recoveryKey, err = r.passwordSecurityService.GenerateRecoveryKey()
+		if err != nil {
+			return err
+		}
+		recoveryWrappedKey, err := r.passwordSecurityService.WrapWithRecoveryKey(newVaultKey, recoveryKey)
+		if err != nil {
+			return err
+		}
+
+		err = repositories.User.UpdateMasterPasswordById(
+			newVaultKey, recoveryWrappedKey, wrappedPrivateKey, reencryptedPasswords, rewrappedAttachments, userId,
+		)
+		if err != nil {
+			return err
+		}
+
+		return repositories.Session.RevokeSessionsByUserId(userId)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return recoveryKey, nil
+}
+
+// reencryptPasswords decrypts all of the user's passwords and seeds with the old vault key and encrypts them with the new one
+func (r *Resolver) reencryptPasswords(
+	ctx context.Context, passwordRepository repository.PasswordRepository, userId uint64, oldVaultKey []byte, newVaultKey []byte,
+) (databaseModel.Passwords, error) {
+	passwords := databaseModel.Passwords{}
+	if err := passwordRepository.FetchAllByUserId(ctx, &passwords, userId, nil); err != nil {
+		return nil, err
+	}
+
+	reencryptedPasswords := databaseModel.Passwords{}
+	for _, password := range passwords {
+		decryptedPassword, err := r.passwordSecurityService.DecryptWithAes(password.Password, oldVaultKey)
+		if err != nil {
+			return nil, err
+		}
+		encryptedPassword, err := r.passwordSecurityService.EncryptWithAes(decryptedPassword, newVaultKey)
+		if err != nil {
+			return nil, err
+		}
+		encryptedTotpUri, err := r.reencryptTotpUri(password.TotpUri, oldVaultKey, newVaultKey)
+		if err != nil {
+			return nil, err
+		}
+		reencryptedPasswords = append(
+			reencryptedPasswords,
+			databaseModel.Password{Id: password.Id, UserId: userId, Password: encryptedPassword, TotpUri: encryptedTotpUri},
+		)
+	}
+
+	return reencryptedPasswords, nil
+}
+
+// logAccountRecovery keeps a trail of every recovery key use and notifies the account owner about it
+func (r *Resolver) logAccountRecovery(ctx context.Context, userId uint64) {
+	clientInformation := authentication.GetClientInformationFromContext(ctx)
+	log.Printf("Account of user %d recovered with a recovery key from %s", userId, clientInformation.IpAddress)
+
+	_, err := r.userRepository.InsertAccountRecovery(&databaseModel.AccountRecovery{
+		UserId:      userId,
+		UserAgent:   clientInformation.UserAgent,
+		IpAddress:   clientInformation.IpAddress,
+		RecoveredAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Error while storing account recovery: %s", err)
+	}
+
+	r.notifySecurityChange(userId, accountRecoveredEvent)
+}
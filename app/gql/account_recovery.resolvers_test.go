@@ -0,0 +1,318 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KristijanFaust/gokeeper/app/attachment"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/database/memory"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"io/ioutil"
+	"strings"
+)
+
+// RecoverAccount should set a new master password, re-encrypt the user's passwords and issue a new recovery key
+func (suite *schemaResolverTestSuite) TestRecoverAccount() {
+	passwordSecurityServiceMock := passwordSecurityMockForAccountRecovery()
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+	userRepositoryServiceMock := mockutil.DefaultUserRepositoryServiceMock()
+	suite.resolver.userRepository = userRepositoryServiceMock
+	sessionRepositoryServiceMock := mockutil.DefaultSessionRepositoryServiceMock()
+	unitOfWorkMock := unitOfWorkMockForMasterPasswordChange(
+		userRepositoryServiceMock, mockutil.DefaultKeyPairRepositoryServiceMock(), sessionRepositoryServiceMock,
+	)
+	suite.resolver.unitOfWork = unitOfWorkMock
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+
+	userWithRecoveryKey, err := suite.mutationResolver.RecoverAccount(context.Background(), generateTestAccountRecovery())
+	assert.Nil(suite.T(), err, "Account should be recovered without errors")
+	unitOfWorkMock.AssertNumberOfCalls(suite.T(), "Execute", 1)
+
+	assert.Equal(suite.T(), userWithRecoveryKey.User.ID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), userWithRecoveryKey.User.Email, mockutil.DefaultEmail)
+	assert.Equal(suite.T(), userWithRecoveryKey.RecoveryKey, mockutil.MockedRecoveryKey)
+
+	reencryptedPasswords := databaseModel.Passwords{
+		{Id: uint64(1), UserId: uint64(1), Password: []byte(mockutil.MockedEncryptedPassword)},
+		{Id: uint64(1), UserId: uint64(1), Password: []byte(mockutil.MockedEncryptedPassword)},
+	}
+	userRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateMasterPasswordById",
+		[]byte("NewMasterPassword"), []byte(mockutil.MockedRecoveryWrappedKey), []byte(nil), reencryptedPasswords,
+		databaseModel.Attachments{mockutil.DefaultAttachment()}, mockutil.DefaultIdAsUint64,
+	)
+	sessionRepositoryServiceMock.AssertCalled(suite.T(), "RevokeSessionsByUserId", mockutil.DefaultIdAsUint64)
+	userRepositoryServiceMock.AssertCalled(suite.T(), "InsertAccountRecovery", mock.Anything)
+	mailServiceMock.AssertCalled(suite.T(), "SendSecurityNotification", defaultRecipient, accountRecoveredEvent)
+}
+
+// RecoverAccount should rewrap the user's private key with the new vault key
+func (suite *schemaResolverTestSuite) TestRecoverAccountWithKeyPair() {
+	passwordSecurityServiceMock := passwordSecurityMockForAccountRecovery()
+	passwordSecurityServiceMock.On(
+		"RewrapPrivateKey", []byte(mockutil.MockedWrappedPrivateKey), []byte(mockutil.MockedUserMasterPassword), []byte("NewMasterPassword"),
+	).Return([]byte("RewrappedPrivateKey"), nil).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+	keyPairRepositoryServiceMock := new(mockutil.KeyPairRepositoryServiceMock)
+	keyPairRepositoryServiceMock.On("FetchKeyPairByUserId", mock.Anything, mockutil.DefaultIdAsUint64).Return(nil).Times(1)
+	userRepositoryServiceMock := mockutil.DefaultUserRepositoryServiceMock()
+	suite.resolver.userRepository = userRepositoryServiceMock
+	suite.resolver.unitOfWork = unitOfWorkMockForMasterPasswordChange(
+		userRepositoryServiceMock, keyPairRepositoryServiceMock, mockutil.DefaultSessionRepositoryServiceMock(),
+	)
+
+	_, err := suite.mutationResolver.RecoverAccount(context.Background(), generateTestAccountRecovery())
+	assert.Nil(suite.T(), err, "Account should be recovered without errors")
+
+	userRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateMasterPasswordById",
+		mock.Anything, mock.Anything, []byte("RewrappedPrivateKey"), mock.Anything, mock.Anything, mockutil.DefaultIdAsUint64,
+	)
+}
+
+// RecoverAccount should keep the user's attachments readable with the new master password
+func (suite *schemaResolverTestSuite) TestRecoverAccountWithAttachment() {
+	repositories := memory.NewRepositories(memory.NewStore())
+	passwordSecurityService := &security.PasswordSecurityService{
+		Argon2PasswordHasher: &security.PasswordHashService{},
+		AesPasswordCryptor:   &security.PasswordCryptoService{},
+		RecoveryKeyWrapper:   &security.RecoveryKeyService{},
+		KeyPairCryptor:       &security.KeyPairService{},
+	}
+	attachmentService := attachment.NewAttachmentService(
+		&config.Attachment{MaxSizeInBytes: 100, QuotaInBytes: mockutil.DefaultStorageQuota},
+		repositories.Attachment, repositories.User, attachment.NewMemoryBlobStore(),
+	)
+	suite.resolver.userRepository = repositories.User
+	suite.resolver.unitOfWork = repositories.UnitOfWork
+	suite.resolver.passwordSecurityService = passwordSecurityService
+	suite.resolver.attachmentManager = attachmentService
+
+	vaultKey := passwordSecurityService.HashWithArgon2id(mockutil.DefaultPassword)
+	recoveryKey, _ := passwordSecurityService.GenerateRecoveryKey()
+	recoveryWrappedKey, _ := passwordSecurityService.WrapWithRecoveryKey(vaultKey, recoveryKey)
+	insertResult, _ := repositories.User.InsertNewUser(&databaseModel.User{
+		Email: mockutil.DefaultEmail, Username: mockutil.DefaultUsername, Password: vaultKey, RecoveryWrappedKey: recoveryWrappedKey,
+	})
+	userId := uint64(insertResult.ID().(int64))
+	encryptedPassword, _ := passwordSecurityService.EncryptWithAes(mockutil.DefaultPassword, vaultKey)
+	insertResult, _ = repositories.Password.InsertNewPassword(&databaseModel.Password{UserId: userId, Name: "entry", Password: encryptedPassword})
+	storedAttachment := &databaseModel.Attachment{
+		PasswordId: uint64(insertResult.ID().(int64)), UserId: userId, Name: mockutil.DefaultAttachmentName,
+		ContentType: "text/plain", Size: int64(len(mockutil.DefaultAttachmentContent)),
+	}
+	err := attachmentService.StoreAttachment(storedAttachment, strings.NewReader(mockutil.DefaultAttachmentContent))
+	assert.Nil(suite.T(), err)
+
+	input := model.AccountRecovery{Email: mockutil.DefaultEmail, RecoveryKey: recoveryKey, NewPassword: "NewPassword"}
+	_, err = suite.mutationResolver.RecoverAccount(context.Background(), input)
+	assert.Nil(suite.T(), err, "Account should be recovered without errors")
+
+	_, content, err := attachmentService.OpenAttachment(storedAttachment.Id, userId)
+	assert.Nil(suite.T(), err, "Attachment should be opened without errors after the recovery")
+	defer content.Close()
+	decryptedContent, err := ioutil.ReadAll(content)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), string(decryptedContent), mockutil.DefaultAttachmentContent)
+}
+
+// RecoverAccount should return expected error when the recovery key is wrong
+func (suite *schemaResolverTestSuite) TestRecoverAccountWithWrongRecoveryKey() {
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("UnwrapWithRecoveryKey", mock.Anything, mock.Anything).Return(nil, security.ErrInvalidRecoveryKey).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	userWithRecoveryKey, err := suite.mutationResolver.RecoverAccount(context.Background(), generateTestAccountRecovery())
+	assert.Equal(suite.T(), err, gqlerror.Errorf("invalid recovery key"), "Should return expected error on wrong recovery key")
+	assert.Nil(suite.T(), userWithRecoveryKey, "Should not return any user data")
+}
+
+// RecoverAccount should return expected error when the recovery key wraps an outdated vault key
+func (suite *schemaResolverTestSuite) TestRecoverAccountWithStaleRecoveryKey() {
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("UnwrapWithRecoveryKey", mock.Anything, mock.Anything).Return([]byte("OutdatedMasterPassword"), nil).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	userWithRecoveryKey, err := suite.mutationResolver.RecoverAccount(context.Background(), generateTestAccountRecovery())
+	assert.Equal(suite.T(), err, gqlerror.Errorf("invalid recovery key"), "Should return expected error on stale recovery key")
+	assert.Nil(suite.T(), userWithRecoveryKey, "Should not return any user data")
+}
+
+// RecoverAccount should return expected error when the user never set up a recovery key
+func (suite *schemaResolverTestSuite) TestRecoverAccountWithoutRecoveryKey() {
+	userRepositoryServiceMock := new(userWithoutRecoveryKeyRepositoryServiceMock)
+	userRepositoryServiceMock.On("FetchByEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	suite.resolver.userRepository = userRepositoryServiceMock
+
+	userWithRecoveryKey, err := suite.mutationResolver.RecoverAccount(context.Background(), generateTestAccountRecovery())
+	assert.Equal(suite.T(), err, gqlerror.Errorf("account has no recovery key"), "Should return expected error when there's no recovery key")
+	assert.Nil(suite.T(), userWithRecoveryKey, "Should not return any user data")
+}
+
+// RecoverAccount should return expected error on failed input validation
+func (suite *schemaResolverTestSuite) TestRecoverAccountValidation() {
+	ctx := graphql.WithResponseContext(context.Background(), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+	input := model.AccountRecovery{Email: mockutil.DefaultEmail, RecoveryKey: mockutil.MockedRecoveryKey, NewPassword: "short"}
+
+	userWithRecoveryKey, err := suite.mutationResolver.RecoverAccount(ctx, input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("validation error/s on account recovery input"),
+		"Should return expected error when input validation fails",
+	)
+	assert.Nil(suite.T(), userWithRecoveryKey, "Should not return any user data")
+}
+
+// RecoverAccount should return expected error and keep the old master password when updating the user fails
+func (suite *schemaResolverTestSuite) TestRecoverAccountWithUpdateError() {
+	suite.resolver.passwordSecurityService = passwordSecurityMockForAccountRecovery()
+	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
+	userRepositoryServiceMock.On("FetchByEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	userRepositoryServiceMock.On(
+		"UpdateMasterPasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+	).Return(errors.New(mockutil.MockedGenericErrorMessage)).Times(1)
+	suite.resolver.userRepository = userRepositoryServiceMock
+	suite.resolver.unitOfWork = unitOfWorkMockForMasterPasswordChange(
+		userRepositoryServiceMock, mockutil.DefaultKeyPairRepositoryServiceMock(), mockutil.DefaultSessionRepositoryServiceMock(),
+	)
+
+	userWithRecoveryKey, err := suite.mutationResolver.RecoverAccount(context.Background(), generateTestAccountRecovery())
+	assert.Equal(suite.T(), err, gqlerror.Errorf("could not recover account"), "Should return expected error when the update fails")
+	assert.Nil(suite.T(), userWithRecoveryKey, "Should not return any user data")
+	userRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertAccountRecovery", mock.Anything)
+}
+
+// RecoverAccount should pass on the code of the repository error when changing the master password fails
+func (suite *schemaResolverTestSuite) TestRecoverAccountWithConflict() {
+	suite.resolver.passwordSecurityService = passwordSecurityMockForAccountRecovery()
+	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
+	userRepositoryServiceMock.On("FetchByEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	userRepositoryServiceMock.On(
+		"UpdateMasterPasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+	).Return(repository.ErrConflict).Times(1)
+	suite.resolver.userRepository = userRepositoryServiceMock
+	suite.resolver.unitOfWork = unitOfWorkMockForMasterPasswordChange(
+		userRepositoryServiceMock, mockutil.DefaultKeyPairRepositoryServiceMock(), mockutil.DefaultSessionRepositoryServiceMock(),
+	)
+
+	userWithRecoveryKey, err := suite.mutationResolver.RecoverAccount(context.Background(), generateTestAccountRecovery())
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{Message: "could not recover account", Extensions: map[string]interface{}{"code": "CONFLICT"}},
+		"Should return expected error when the update conflicts with a concurrent change",
+	)
+	assert.Nil(suite.T(), userWithRecoveryKey, "Should not return any user data")
+}
+
+// RegenerateRecoveryKey should wrap the vault key with a new recovery key
+func (suite *schemaResolverTestSuite) TestRegenerateRecoveryKey() {
+	userRepositoryServiceMock := mockutil.DefaultUserRepositoryServiceMock()
+	suite.resolver.userRepository = userRepositoryServiceMock
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+
+	recoveryKey, err := suite.mutationResolver.RegenerateRecoveryKey(context.Background())
+	assert.Nil(suite.T(), err, "Recovery key should be regenerated without errors")
+	assert.Equal(suite.T(), recoveryKey, mockutil.MockedRecoveryKey)
+
+	userRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateRecoveryWrappedKeyById", []byte(mockutil.MockedRecoveryWrappedKey), mockutil.DefaultIdAsUint64,
+	)
+	mailServiceMock.AssertCalled(suite.T(), "SendSecurityNotification", defaultRecipient, recoveryKeyRegeneratedEvent)
+}
+
+// RegenerateRecoveryKey should return expected error on unauthenticated requests
+func (suite *schemaResolverTestSuite) TestRegenerateRecoveryKeyWithoutAuthentication() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	recoveryKey, err := suite.mutationResolver.RegenerateRecoveryKey(context.Background())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized recovery key request"),
+		"Should return expected error on unauthenticated request",
+	)
+	assert.Empty(suite.T(), recoveryKey, "Should not return a recovery key")
+}
+
+// RegenerateRecoveryKey should return expected error when the recovery key can't be generated
+func (suite *schemaResolverTestSuite) TestRegenerateRecoveryKeyWithGenerationError() {
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("GenerateRecoveryKey").Return("", errors.New(mockutil.MockedGenericErrorMessage)).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	recoveryKey, err := suite.mutationResolver.RegenerateRecoveryKey(context.Background())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("could not generate a new recovery key"),
+		"Should return expected error when generating the recovery key fails",
+	)
+	assert.Empty(suite.T(), recoveryKey, "Should not return a recovery key")
+}
+
+// SignUp should return expected error when the recovery key can't be generated
+func (suite *schemaResolverTestSuite) TestSignUpWithRecoveryKeyGenerationError() {
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("HashWithArgon2id", mock.Anything).Return([]byte(mockutil.MockedUserMasterPassword)).Times(1)
+	passwordSecurityServiceMock.On("GenerateRecoveryKey").Return("", errors.New(mockutil.MockedGenericErrorMessage)).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+	input := model.NewUser{Email: mockutil.DefaultEmail, Username: mockutil.DefaultUsername, Password: mockutil.DefaultPassword}
+
+	userWithRecoveryKey, err := suite.mutationResolver.SignUp(context.Background(), input)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("could not create a new user"), "Should return expected error")
+	assert.Nil(suite.T(), userWithRecoveryKey, "Should not return any user data")
+}
+
+type userWithoutRecoveryKeyRepositoryServiceMock struct {
+	mockutil.UserRepositoryServiceMock
+}
+
+func (service *userWithoutRecoveryKeyRepositoryServiceMock) FetchByEmail(
+	ctx context.Context, user *databaseModel.User, email string, queryFields []string,
+) error {
+	err := service.UserRepositoryServiceMock.FetchByEmail(ctx, user, email, queryFields)
+	user.RecoveryWrappedKey = nil
+	return err
+}
+
+// unitOfWorkMockForMasterPasswordChange runs master password changes with the given user, key pair and session repositories
+func unitOfWorkMockForMasterPasswordChange(
+	userRepository repository.UserRepository,
+	keyPairRepository repository.KeyPairRepository,
+	sessionRepository repository.SessionRepository,
+) *mockutil.UnitOfWorkMock {
+	return mockutil.NewUnitOfWorkMock(&repository.Repositories{
+		User:       userRepository,
+		Password:   mockutil.DefaultPasswordRepositoryServiceMock(),
+		KeyPair:    keyPairRepository,
+		Session:    sessionRepository,
+		Attachment: mockutil.DefaultAttachmentRepositoryServiceMock(),
+	})
+}
+
+func passwordSecurityMockForAccountRecovery() *mockutil.PasswordSecurityServiceMock {
+	passwordSecurityServiceMock := mockutil.DefaultPasswordSecurityServiceMock()
+	passwordSecurityServiceMock.ExpectedCalls = nil
+	passwordSecurityServiceMock.On("UnwrapWithRecoveryKey", mock.Anything, mock.Anything).Return([]byte(mockutil.MockedUserMasterPassword), nil).Times(1)
+	passwordSecurityServiceMock.On("HashWithArgon2id", mock.Anything).Return([]byte("NewMasterPassword")).Times(1)
+	passwordSecurityServiceMock.On("DecryptWithAes", mock.Anything, []byte(mockutil.MockedUserMasterPassword)).Return(mockutil.MockedDecryptedPassword, nil).Times(2)
+	passwordSecurityServiceMock.On("EncryptWithAes", mockutil.MockedDecryptedPassword, []byte("NewMasterPassword")).Return(
+		[]byte(mockutil.MockedEncryptedPassword), nil,
+	).Times(2)
+	passwordSecurityServiceMock.On("GenerateRecoveryKey").Return(mockutil.MockedRecoveryKey, nil).Times(1)
+	passwordSecurityServiceMock.On("WrapWithRecoveryKey", []byte("NewMasterPassword"), mockutil.MockedRecoveryKey).Return(
+		[]byte(mockutil.MockedRecoveryWrappedKey), nil,
+	).Times(1)
+
+	return passwordSecurityServiceMock
+}
+
+func generateTestAccountRecovery() model.AccountRecovery {
+	return model.AccountRecovery{Email: mockutil.DefaultEmail, RecoveryKey: mockutil.MockedRecoveryKey, NewPassword: "NewPassword"}
+}
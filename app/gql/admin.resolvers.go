@@ -0,0 +1,117 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+)
+
+func (r *mutationResolver) DisableUser(ctx context.Context, userID string) (*model.AdminUser, error) {
+	user, err := r.executeAdminAction(ctx, userID, databaseModel.AdminActionDisableUser, nil,
+		func(userId uint64, auditEntry *databaseModel.AdminAuditEntry) error {
+			return r.adminRepository.UpdateUserDisabledById(true, userId, auditEntry)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAdminUser(user), nil
+}
+
+func (r *mutationResolver) EnableUser(ctx context.Context, userID string) (*model.AdminUser, error) {
+	user, err := r.executeAdminAction(ctx, userID, databaseModel.AdminActionEnableUser, nil,
+		func(userId uint64, auditEntry *databaseModel.AdminAuditEntry) error {
+			return r.adminRepository.UpdateUserDisabledById(false, userId, auditEntry)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAdminUser(user), nil
+}
+
+func (r *mutationResolver) ForceSignOut(ctx context.Context, userID string) (bool, error) {
+	_, err := r.executeAdminAction(ctx, userID, databaseModel.AdminActionForceSignOut, nil,
+		func(userId uint64, auditEntry *databaseModel.AdminAuditEntry) error {
+			return r.adminRepository.RevokeUserSessionsById(userId, auditEntry)
+		},
+	)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) ChangeUserRole(ctx context.Context, userID string, role model.UserRole) (*model.AdminUser, error) {
+	newRole := strings.ToLower(role.String())
+	user, err := r.executeAdminAction(ctx, userID, databaseModel.AdminActionChangeRole, &newRole,
+		func(userId uint64, auditEntry *databaseModel.AdminAuditEntry) error {
+			return r.adminRepository.UpdateUserRoleById(newRole, userId, auditEntry)
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return toAdminUser(user), nil
+}
+
+func (r *queryResolver) Users(ctx context.Context, offset int, limit int) ([]*model.AdminUser, error) {
+	offset, limit = adminPage(offset, limit)
+	fetchedUsers := databaseModel.Users{}
+	err := r.adminRepository.FetchUsers(&fetchedUsers, offset, limit)
+	if err != nil {
+		log.Printf("Error while fetching users: %s", err)
+		return nil, repositoryError(err, adminErrorMessage)
+	}
+
+	users := []*model.AdminUser{}
+	for index := range fetchedUsers {
+		users = append(users, toAdminUser(&fetchedUsers[index]))
+	}
+	return users, nil
+}
+
+func (r *queryResolver) UsageStatistics(ctx context.Context) (*model.UsageStatistics, error) {
+	statistics := databaseModel.UsageStatistics{}
+	err := r.adminRepository.FetchUsageStatistics(&statistics)
+	if err != nil {
+		log.Printf("Error while fetching usage statistics: %s", err)
+		return nil, repositoryError(err, adminErrorMessage)
+	}
+
+	return &model.UsageStatistics{
+		Users:          statistics.Users,
+		DisabledUsers:  statistics.DisabledUsers,
+		Admins:         statistics.Admins,
+		Entries:        statistics.Entries,
+		Organizations:  statistics.Organizations,
+		ActiveSessions: statistics.ActiveSessions,
+		ActiveSends:    statistics.ActiveSends,
+	}, nil
+}
+
+func (r *queryResolver) AdminAuditLog(ctx context.Context, offset int, limit int) ([]*model.AdminAuditEntry, error) {
+	offset, limit = adminPage(offset, limit)
+	fetchedEntries := databaseModel.AdminAuditEntriesDetails{}
+	err := r.adminRepository.FetchAuditEntriesDetails(&fetchedEntries, offset, limit)
+	if err != nil {
+		log.Printf("Error while fetching admin audit entries: %s", err)
+		return nil, repositoryError(err, adminErrorMessage)
+	}
+
+	entries := []*model.AdminAuditEntry{}
+	for index := range fetchedEntries {
+		entries = append(entries, toAdminAuditEntry(&fetchedEntries[index]))
+	}
+	return entries, nil
+}
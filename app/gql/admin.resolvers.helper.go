@@ -0,0 +1,154 @@
+package gql
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	adminErrorMessage              = "could not process admin request"
+	adminAuthorizationErrorMessage = "insufficient permissions"
+	adminSelfActionErrorMessage    = "administrators can't disable or demote themselves"
+	adminUserNotFoundErrorMessage  = "user doesn't exist"
+	accountDisabledErrorMessage    = "the account is disabled"
+)
+
+const adminMaximumPageLimit = 100
+
+var userRoles = map[string]model.UserRole{
+	databaseModel.UserRoleUser:  model.UserRoleUser,
+	databaseModel.UserRoleAdmin: model.UserRoleAdmin,
+}
+
+// userRoleRanks orders the roles, a role grants access to everything the lower ranked roles can access
+var userRoleRanks = map[model.UserRole]int{
+	model.UserRoleUser:  0,
+	model.UserRoleAdmin: 1,
+}
+
+var adminActions = map[string]model.AdminAction{
+	databaseModel.AdminActionDisableUser:  model.AdminActionDisableUser,
+	databaseModel.AdminActionEnableUser:   model.AdminActionEnableUser,
+	databaseModel.AdminActionForceSignOut: model.AdminActionForceSignOut,
+	databaseModel.AdminActionChangeRole:   model.AdminActionChangeRole,
+}
+
+// adminActionAuditEvents maps the actions to the audit events published for them
+var adminActionAuditEvents = map[string]string{
+	databaseModel.AdminActionDisableUser:  databaseModel.AuditEventUserDisabled,
+	databaseModel.AdminActionEnableUser:   databaseModel.AuditEventUserEnabled,
+	databaseModel.AdminActionForceSignOut: databaseModel.AuditEventUserSignedOut,
+	databaseModel.AdminActionChangeRole:   databaseModel.AuditEventUserRoleChanged,
+}
+
+var adminUserFields = []string{"email", "username", "email_verified", "role", "disabled"}
+
+// HasRole implements the @hasRole directive, the role is read from the database on every request
+// so role changes and disabled accounts take effect immediately
+func (r *Resolver) HasRole(ctx context.Context, obj interface{}, next graphql.Resolver, role model.UserRole) (interface{}, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(adminAuthorizationErrorMessage)
+	}
+
+	user := databaseModel.User{}
+	err := r.userRepository.FetchById(&user, userAuthentication.UserId, []string{"role", "disabled"})
+	if err != nil {
+		log.Printf("Error while fetching user role: %s", err)
+		return nil, gqlerror.Errorf(adminAuthorizationErrorMessage)
+	}
+	userRole, ok := userRoles[user.Role]
+	if !ok || user.Disabled || userRoleRanks[userRole] < userRoleRanks[role] {
+		return nil, gqlerror.Errorf(adminAuthorizationErrorMessage)
+	}
+
+	return next(ctx)
+}
+
+func toAdminUser(user *databaseModel.User) *model.AdminUser {
+	return &model.AdminUser{
+		ID:            strconv.FormatUint(user.Id, 10),
+		Email:         user.Email,
+		Username:      user.Username,
+		EmailVerified: user.EmailVerified,
+		Role:          userRoles[user.Role],
+		Disabled:      user.Disabled,
+	}
+}
+
+func toAdminAuditEntry(entry *databaseModel.AdminAuditEntryDetails) *model.AdminAuditEntry {
+	return &model.AdminAuditEntry{
+		ID:          strconv.FormatUint(entry.Id, 10),
+		AdminEmail:  entry.AdminEmail,
+		Action:      adminActions[entry.Action],
+		TargetEmail: entry.TargetEmail,
+		Details:     entry.Details,
+		CreatedAt:   entry.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+// adminPage keeps pagination arguments within sane bounds
+func adminPage(offset int, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit < 1 || limit > adminMaximumPageLimit {
+		limit = adminMaximumPageLimit
+	}
+	return offset, limit
+}
+
+// executeAdminAction runs the audited action against the target user and returns the updated user,
+// administrators can't target themselves with actions that could lock them out
+func (r *Resolver) executeAdminAction(
+	ctx context.Context, userID string, action string, details *string,
+	execute func(userId uint64, auditEntry *databaseModel.AdminAuditEntry) error,
+) (*databaseModel.User, error) {
+	userId, err := strconv.ParseUint(userID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting user id to uint64: %s", err)
+		return nil, gqlerror.Errorf(adminErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(adminAuthorizationErrorMessage)
+	}
+	if userId == userAuthentication.UserId && action != databaseModel.AdminActionForceSignOut {
+		return nil, gqlerror.Errorf(adminSelfActionErrorMessage)
+	}
+
+	auditEntry := &databaseModel.AdminAuditEntry{
+		AdminId: userAuthentication.UserId, Action: action, TargetUserId: userId, Details: details,
+	}
+	err = execute(userId, auditEntry)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, gqlerror.Errorf(adminUserNotFoundErrorMessage)
+		}
+		log.Printf("Error while executing admin action %s: %s", action, err)
+		return nil, gqlerror.Errorf(adminErrorMessage)
+	}
+	eventDetails := "user " + userID
+	if details != nil {
+		eventDetails += " " + *details
+	}
+	r.recordAuditEvent(ctx, adminActionAuditEvents[action], &userAuthentication.UserId, &eventDetails)
+
+	user := &databaseModel.User{}
+	err = r.userRepository.FetchById(user, userId, adminUserFields)
+	if err != nil {
+		log.Printf("Error while fetching user: %s", err)
+		return nil, repositoryError(err, adminErrorMessage)
+	}
+	user.Id = userId
+
+	return user, nil
+}
@@ -0,0 +1,231 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// HasRole should resolve the field for users with the required role
+func (suite *schemaResolverTestSuite) TestHasRole() {
+	suite.resolver.userRepository = userRepositoryMockWithRole(databaseModel.UserRoleAdmin, false)
+
+	result, err := suite.resolver.HasRole(suite.graphqlRequestContext, nil, resolvedNext, model.UserRoleAdmin)
+	assert.Nil(suite.T(), err, "Should resolve the field without any errors")
+	assert.Equal(suite.T(), result, "resolved")
+}
+
+// HasRole should let higher ranked roles access fields of lower ranked roles
+func (suite *schemaResolverTestSuite) TestHasRoleWithHigherRole() {
+	suite.resolver.userRepository = userRepositoryMockWithRole(databaseModel.UserRoleAdmin, false)
+
+	result, err := suite.resolver.HasRole(suite.graphqlRequestContext, nil, resolvedNext, model.UserRoleUser)
+	assert.Nil(suite.T(), err, "Should resolve the field without any errors")
+	assert.Equal(suite.T(), result, "resolved")
+}
+
+// HasRole should deny access to users without the required role, disabled users and unauthenticated requests
+func (suite *schemaResolverTestSuite) TestHasRoleWithInsufficientPermissions() {
+	for _, userRepositoryMock := range []*mockutil.UserRepositoryServiceMock{
+		userRepositoryMockWithRole(databaseModel.UserRoleUser, false),
+		userRepositoryMockWithRole(databaseModel.UserRoleAdmin, true),
+	} {
+		suite.resolver.userRepository = userRepositoryMock
+		suite.resolver.authenticationService = mockutil.DefaultJwtAuthenticationServiceMock()
+
+		result, err := suite.resolver.HasRole(suite.graphqlRequestContext, nil, resolvedNext, model.UserRoleAdmin)
+		assert.Equal(suite.T(), err, gqlerror.Errorf("insufficient permissions"), "Should return expected error")
+		assert.Nil(suite.T(), result, "Should not resolve the field")
+	}
+
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	result, err := suite.resolver.HasRole(suite.graphqlRequestContext, nil, resolvedNext, model.UserRoleUser)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("insufficient permissions"), "Should return expected error")
+	assert.Nil(suite.T(), result, "Should not resolve the field")
+}
+
+// DisableUser should disable the user and record the action in the audit trail
+func (suite *schemaResolverTestSuite) TestDisableUser() {
+	adminRepositoryServiceMock := mockutil.DefaultAdminRepositoryServiceMock()
+	suite.resolver.adminRepository = adminRepositoryServiceMock
+	suite.resolver.userRepository = userRepositoryMockWithRole(databaseModel.UserRoleUser, true)
+
+	user, err := suite.mutationResolver.DisableUser(suite.graphqlRequestContext, "2")
+	assert.Nil(suite.T(), err, "Should disable the user without any errors")
+	assert.Equal(suite.T(), user.ID, "2")
+	assert.True(suite.T(), user.Disabled)
+	adminRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateUserDisabledById", true, mockutil.DefaultGranteeIdAsUint64, auditEntryMatching(databaseModel.AdminActionDisableUser),
+	)
+}
+
+// EnableUser should enable the user and record the action in the audit trail
+func (suite *schemaResolverTestSuite) TestEnableUser() {
+	adminRepositoryServiceMock := mockutil.DefaultAdminRepositoryServiceMock()
+	suite.resolver.adminRepository = adminRepositoryServiceMock
+	suite.resolver.userRepository = userRepositoryMockWithRole(databaseModel.UserRoleUser, false)
+
+	user, err := suite.mutationResolver.EnableUser(suite.graphqlRequestContext, "2")
+	assert.Nil(suite.T(), err, "Should enable the user without any errors")
+	assert.False(suite.T(), user.Disabled)
+	adminRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateUserDisabledById", false, mockutil.DefaultGranteeIdAsUint64, auditEntryMatching(databaseModel.AdminActionEnableUser),
+	)
+}
+
+// DisableUser should not let administrators lock themselves out
+func (suite *schemaResolverTestSuite) TestDisableUserThemselves() {
+	adminRepositoryServiceMock := mockutil.DefaultAdminRepositoryServiceMock()
+	suite.resolver.adminRepository = adminRepositoryServiceMock
+
+	user, err := suite.mutationResolver.DisableUser(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("administrators can't disable or demote themselves"),
+		"Should return expected error when disabling themselves",
+	)
+	assert.Nil(suite.T(), user, "Should not return a user")
+	adminRepositoryServiceMock.AssertNotCalled(suite.T(), "UpdateUserDisabledById", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// DisableUser should return expected error for non existing users
+func (suite *schemaResolverTestSuite) TestDisableNonExistingUser() {
+	adminRepositoryServiceMock := new(mockutil.AdminRepositoryServiceMock)
+	adminRepositoryServiceMock.On("UpdateUserDisabledById", mock.Anything, mock.Anything, mock.Anything).Return(repository.ErrNotFound).Times(1)
+	suite.resolver.adminRepository = adminRepositoryServiceMock
+
+	user, err := suite.mutationResolver.DisableUser(suite.graphqlRequestContext, "2")
+	assert.Equal(suite.T(), err, gqlerror.Errorf("user doesn't exist"), "Should return expected error")
+	assert.Nil(suite.T(), user, "Should not return a user")
+}
+
+// ForceSignOut should revoke all sessions of the user and record the action in the audit trail
+func (suite *schemaResolverTestSuite) TestForceSignOut() {
+	adminRepositoryServiceMock := mockutil.DefaultAdminRepositoryServiceMock()
+	suite.resolver.adminRepository = adminRepositoryServiceMock
+
+	signedOut, err := suite.mutationResolver.ForceSignOut(suite.graphqlRequestContext, "2")
+	assert.Nil(suite.T(), err, "Should sign the user out without any errors")
+	assert.True(suite.T(), signedOut, "Should confirm the sign out")
+	adminRepositoryServiceMock.AssertCalled(
+		suite.T(), "RevokeUserSessionsById", mockutil.DefaultGranteeIdAsUint64, auditEntryMatching(databaseModel.AdminActionForceSignOut),
+	)
+}
+
+// ForceSignOut should return expected error if the sessions can't be revoked
+func (suite *schemaResolverTestSuite) TestForceSignOutWithRevocationError() {
+	adminRepositoryServiceMock := new(mockutil.AdminRepositoryServiceMock)
+	adminRepositoryServiceMock.On("RevokeUserSessionsById", mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage),
+	).Times(1)
+	suite.resolver.adminRepository = adminRepositoryServiceMock
+
+	signedOut, err := suite.mutationResolver.ForceSignOut(suite.graphqlRequestContext, "2")
+	assert.Equal(suite.T(), err, gqlerror.Errorf("could not process admin request"), "Should return expected error")
+	assert.False(suite.T(), signedOut, "Should not confirm the sign out")
+}
+
+// ChangeUserRole should change the role and record the new role in the audit trail
+func (suite *schemaResolverTestSuite) TestChangeUserRole() {
+	adminRepositoryServiceMock := mockutil.DefaultAdminRepositoryServiceMock()
+	suite.resolver.adminRepository = adminRepositoryServiceMock
+	suite.resolver.userRepository = userRepositoryMockWithRole(databaseModel.UserRoleAdmin, false)
+
+	user, err := suite.mutationResolver.ChangeUserRole(suite.graphqlRequestContext, "2", model.UserRoleAdmin)
+	assert.Nil(suite.T(), err, "Should change the role without any errors")
+	assert.Equal(suite.T(), user.Role, model.UserRoleAdmin)
+	adminRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateUserRoleById", databaseModel.UserRoleAdmin, mockutil.DefaultGranteeIdAsUint64,
+		mock.MatchedBy(func(auditEntry *databaseModel.AdminAuditEntry) bool {
+			return auditEntry.Action == databaseModel.AdminActionChangeRole && *auditEntry.Details == databaseModel.UserRoleAdmin
+		}),
+	)
+}
+
+// Users should fetch a page of users with their roles
+func (suite *schemaResolverTestSuite) TestUsers() {
+	adminRepositoryServiceMock := mockutil.DefaultAdminRepositoryServiceMock()
+	suite.resolver.adminRepository = adminRepositoryServiceMock
+
+	users, err := suite.queryResolver.Users(suite.graphqlRequestContext, 0, 1000)
+	assert.Nil(suite.T(), err, "Should fetch users without any errors")
+	assert.Equal(suite.T(), len(users), 2)
+	assert.Equal(suite.T(), users[0].Role, model.UserRoleAdmin)
+	assert.Equal(suite.T(), users[1].Email, mockutil.DefaultGranteeEmail)
+	adminRepositoryServiceMock.AssertCalled(suite.T(), "FetchUsers", mock.Anything, 0, 100)
+}
+
+// UsageStatistics should return the record counts
+func (suite *schemaResolverTestSuite) TestUsageStatistics() {
+	statistics, err := suite.queryResolver.UsageStatistics(suite.graphqlRequestContext)
+	assert.Nil(suite.T(), err, "Should fetch statistics without any errors")
+	assert.Equal(suite.T(), statistics, &model.UsageStatistics{Users: 2, Admins: 1, Entries: 4})
+}
+
+// AdminAuditLog should fetch a page of the audit trail
+func (suite *schemaResolverTestSuite) TestAdminAuditLog() {
+	adminRepositoryServiceMock := mockutil.DefaultAdminRepositoryServiceMock()
+	suite.resolver.adminRepository = adminRepositoryServiceMock
+
+	entries, err := suite.queryResolver.AdminAuditLog(suite.graphqlRequestContext, -1, 10)
+	assert.Nil(suite.T(), err, "Should fetch the audit trail without any errors")
+	assert.Equal(suite.T(), len(entries), 1)
+	assert.Equal(suite.T(), entries[0].Action, model.AdminActionDisableUser)
+	assert.Equal(suite.T(), entries[0].AdminEmail, mockutil.DefaultEmail)
+	assert.Equal(suite.T(), entries[0].TargetEmail, mockutil.DefaultGranteeEmail)
+	assert.Equal(suite.T(), entries[0].CreatedAt, "2021-01-01T00:00:00Z")
+	adminRepositoryServiceMock.AssertCalled(suite.T(), "FetchAuditEntriesDetails", mock.Anything, 0, 10)
+}
+
+// SignIn should not let disabled users sign in
+func (suite *schemaResolverTestSuite) TestSignInWithDisabledUser() {
+	userRepositoryServiceMock := &disabledUserRepositoryServiceMock{}
+	userRepositoryServiceMock.On("FetchByEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	suite.resolver.userRepository = userRepositoryServiceMock
+	sessionRepositoryServiceMock := mockutil.DefaultSessionRepositoryServiceMock()
+	suite.resolver.sessionRepository = sessionRepositoryServiceMock
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	userWithToken, err := suite.mutationResolver.SignIn(context.Background(), input)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("the account is disabled"), "Should return expected error")
+	assert.Nil(suite.T(), userWithToken, "Should not return any user data")
+	sessionRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertNewSession", mock.Anything)
+}
+
+type disabledUserRepositoryServiceMock struct {
+	mockutil.UserRepositoryServiceMock
+}
+
+func (service *disabledUserRepositoryServiceMock) FetchByEmail(
+	ctx context.Context, user *databaseModel.User, email string, queryFields []string,
+) error {
+	err := service.UserRepositoryServiceMock.FetchByEmail(ctx, user, email, queryFields)
+	user.Disabled = true
+	return err
+}
+
+func resolvedNext(ctx context.Context) (interface{}, error) {
+	return "resolved", nil
+}
+
+func userRepositoryMockWithRole(role string, disabled bool) *mockutil.UserRepositoryServiceMock {
+	serviceMock := new(mockutil.UserRepositoryServiceMock)
+	serviceMock.On("FetchById", mock.Anything, mock.Anything, mock.Anything).Return(nil, databaseModel.User{Role: role, Disabled: disabled})
+	return serviceMock
+}
+
+// auditEntryMatching matches audit entries of the default user performing the action on the emergency access grantee
+func auditEntryMatching(action string) interface{} {
+	return mock.MatchedBy(func(auditEntry *databaseModel.AdminAuditEntry) bool {
+		return auditEntry.AdminId == mockutil.DefaultIdAsUint64 && auditEntry.Action == action &&
+			auditEntry.TargetUserId == mockutil.DefaultGranteeIdAsUint64
+	})
+}
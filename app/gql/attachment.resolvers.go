@@ -0,0 +1,129 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KristijanFaust/gokeeper/app/attachment"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) UploadAttachment(ctx context.Context, passwordID string, file graphql.Upload) (*model.Attachment, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(attachmentAuthenticationErrorMessage)
+	}
+	userPassword, err := r.fetchAttachablePassword(ctx, passwordID, userAuthentication.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	newAttachment := &databaseModel.Attachment{
+		PasswordId:  userPassword.Id,
+		UserId:      userAuthentication.UserId,
+		Name:        file.Filename,
+		ContentType: contentType,
+		Size:        file.Size,
+	}
+	err = r.attachmentManager.StoreAttachment(newAttachment, file.File)
+	if err != nil {
+		switch err {
+		case attachment.ErrAttachmentTooLarge:
+			return nil, gqlerror.Errorf(attachmentTooLargeErrorMessage)
+		case repository.ErrStorageQuotaExceeded:
+			return nil, gqlerror.Errorf(storageQuotaExceededErrorMessage)
+		}
+		log.Printf("Error while storing attachment: %s", err)
+		return nil, repositoryError(err, attachmentErrorMessage)
+	}
+
+	return toAttachment(newAttachment), nil
+}
+
+func (r *mutationResolver) DeleteAttachment(ctx context.Context, attachmentID string) (bool, error) {
+	attachmentId, err := strconv.ParseUint(attachmentID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting attachment id to uint64: %s", err)
+		return false, gqlerror.Errorf(attachmentErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return false, gqlerror.Errorf(attachmentAuthenticationErrorMessage)
+	}
+
+	storedAttachment := &databaseModel.Attachment{}
+	err = r.attachmentRepository.FetchAttachmentById(storedAttachment, attachmentId)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return false, repositoryError(err, attachmentNotFoundErrorMessage)
+		}
+		log.Printf("Error while fetching attachment: %s", err)
+		return false, repositoryError(err, attachmentErrorMessage)
+	}
+	if storedAttachment.UserId != userAuthentication.UserId {
+		return false, repositoryError(repository.ErrNotFound, attachmentNotFoundErrorMessage)
+	}
+
+	err = r.attachmentManager.DeleteAttachment(storedAttachment)
+	if err != nil {
+		if err == attachment.ErrAttachmentNotFound {
+			return false, repositoryError(repository.ErrNotFound, attachmentNotFoundErrorMessage)
+		}
+		log.Printf("Error while deleting attachment: %s", err)
+		return false, repositoryError(err, attachmentErrorMessage)
+	}
+
+	return true, nil
+}
+
+func (r *queryResolver) Attachments(ctx context.Context, passwordID string) ([]*model.Attachment, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(attachmentAuthenticationErrorMessage)
+	}
+	userPassword, err := r.fetchAttachablePassword(ctx, passwordID, userAuthentication.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchedAttachments := databaseModel.Attachments{}
+	err = r.attachmentRepository.FetchAttachmentsByPasswordId(&fetchedAttachments, userPassword.Id)
+	if err != nil {
+		log.Printf("Error while fetching attachments: %s", err)
+		return nil, repositoryError(err, attachmentErrorMessage)
+	}
+
+	attachments := make([]*model.Attachment, 0, len(fetchedAttachments))
+	for index := range fetchedAttachments {
+		attachments = append(attachments, toAttachment(&fetchedAttachments[index]))
+	}
+	return attachments, nil
+}
+
+func (r *queryResolver) StorageUsage(ctx context.Context) (*model.StorageUsage, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(attachmentAuthenticationErrorMessage)
+	}
+
+	usedBytes, err := r.attachmentRepository.FetchStorageUsageByUserId(userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching storage usage: %s", err)
+		return nil, repositoryError(err, attachmentErrorMessage)
+	}
+
+	return &model.StorageUsage{UsedBytes: int(usedBytes), QuotaBytes: int(r.attachmentManager.StorageQuota())}, nil
+}
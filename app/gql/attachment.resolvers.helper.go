@@ -0,0 +1,54 @@
+package gql
+
+import (
+	"context"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	attachmentErrorMessage               = "could not process attachment request"
+	attachmentAuthenticationErrorMessage = "unauthorized attachment request"
+	attachmentNotFoundErrorMessage       = "attachment doesn't exist"
+	attachmentTooLargeErrorMessage       = "the attachment exceeds the maximum attachment size"
+	storageQuotaExceededErrorMessage     = "the attachment exceeds your storage quota"
+)
+
+// fetchAttachablePassword fetches a personal entry of the user, attachments of shared vault entries aren't supported
+func (r *Resolver) fetchAttachablePassword(ctx context.Context, passwordID string, userId uint64) (*databaseModel.Password, error) {
+	passwordId, err := strconv.ParseUint(passwordID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting password id to uint64: %s", err)
+		return nil, gqlerror.Errorf(attachmentErrorMessage)
+	}
+
+	userPassword := &databaseModel.Password{}
+	err = r.passwordRepository.FetchPasswordById(ctx, userPassword, passwordId)
+	if err != nil {
+		log.Printf("Error occurred while fetching user password by id: %s", err)
+		return nil, repositoryError(err, attachmentErrorMessage)
+	}
+	if userPassword.UserId != userId {
+		return nil, gqlerror.Errorf(passwordAuthenticationErrorMessage)
+	}
+	if userPassword.OrganizationId != nil {
+		return nil, gqlerror.Errorf(sharedPasswordModificationErrorMessage)
+	}
+
+	return userPassword, nil
+}
+
+func toAttachment(attachment *databaseModel.Attachment) *model.Attachment {
+	return &model.Attachment{
+		ID:          strconv.FormatUint(attachment.Id, 10),
+		PasswordID:  strconv.FormatUint(attachment.PasswordId, 10),
+		Name:        attachment.Name,
+		ContentType: attachment.ContentType,
+		Size:        int(attachment.Size),
+		CreatedAt:   attachment.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
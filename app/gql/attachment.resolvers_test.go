@@ -0,0 +1,178 @@
+package gql
+
+import (
+	"errors"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KristijanFaust/gokeeper/app/attachment"
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"strings"
+)
+
+// UploadAttachment should store the uploaded file as an attachment of the user's entry
+func (suite *schemaResolverTestSuite) TestUploadAttachment() {
+	attachmentManagerMock := mockutil.DefaultAttachmentManagerMock()
+	suite.resolver.attachmentManager = attachmentManagerMock
+
+	uploadedAttachment, err := suite.mutationResolver.UploadAttachment(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, generateTestUpload(),
+	)
+	assert.Nil(suite.T(), err, "Should upload the attachment without any errors")
+	assert.Equal(suite.T(), uploadedAttachment.ID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), uploadedAttachment.PasswordID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), uploadedAttachment.Name, mockutil.DefaultAttachmentName)
+	assert.Equal(suite.T(), uploadedAttachment.ContentType, "text/plain")
+	assert.Equal(suite.T(), uploadedAttachment.Size, len(mockutil.DefaultAttachmentContent))
+	assert.Equal(suite.T(), uploadedAttachment.CreatedAt, "2021-01-01T00:00:00Z")
+
+	attachmentManagerMock.AssertCalled(suite.T(), "StoreAttachment", mock.MatchedBy(func(stored *databaseModel.Attachment) bool {
+		return stored.UserId == mockutil.DefaultIdAsUint64 && stored.PasswordId == mockutil.DefaultIdAsUint64
+	}), mock.Anything)
+}
+
+// UploadAttachment should return an error for unauthenticated users
+func (suite *schemaResolverTestSuite) TestUploadAttachmentWithoutAuthentication() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	uploadedAttachment, err := suite.mutationResolver.UploadAttachment(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, generateTestUpload(),
+	)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized attachment request"), "Should return expected error")
+	assert.Nil(suite.T(), uploadedAttachment, "Should not return an attachment")
+}
+
+// UploadAttachment should not attach files to entries of other users
+func (suite *schemaResolverTestSuite) TestUploadAttachmentToForeignEntry() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(
+		&authentication.UserAuthentication{UserId: uint64(2)},
+	).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+	attachmentManagerMock := mockutil.DefaultAttachmentManagerMock()
+	suite.resolver.attachmentManager = attachmentManagerMock
+
+	uploadedAttachment, err := suite.mutationResolver.UploadAttachment(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, generateTestUpload(),
+	)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized password input"), "Should return expected error")
+	assert.Nil(suite.T(), uploadedAttachment, "Should not return an attachment")
+	attachmentManagerMock.AssertNotCalled(suite.T(), "StoreAttachment", mock.Anything, mock.Anything)
+}
+
+// UploadAttachment should return expected errors when the attachment is too large or exceeds the storage quota
+func (suite *schemaResolverTestSuite) TestUploadAttachmentWithLimitErrors() {
+	limitErrors := map[error]string{
+		attachment.ErrAttachmentTooLarge:               "the attachment exceeds the maximum attachment size",
+		repository.ErrStorageQuotaExceeded:             "the attachment exceeds your storage quota",
+		errors.New(mockutil.MockedGenericErrorMessage): "could not process attachment request",
+	}
+	for storeError, expectedMessage := range limitErrors {
+		injectDefaultMockedResolverServices(suite)
+		attachmentManagerMock := new(mockutil.AttachmentManagerMock)
+		attachmentManagerMock.On("StoreAttachment", mock.Anything, mock.Anything).Return(storeError).Times(1)
+		suite.resolver.attachmentManager = attachmentManagerMock
+
+		uploadedAttachment, err := suite.mutationResolver.UploadAttachment(
+			suite.graphqlRequestContext, mockutil.DefaultIdAsString, generateTestUpload(),
+		)
+		assert.Equal(suite.T(), err, gqlerror.Errorf(expectedMessage), "Should return expected error")
+		assert.Nil(suite.T(), uploadedAttachment, "Should not return an attachment")
+	}
+}
+
+// UploadAttachment should pass on the code of the repository error when storing the attachment fails
+func (suite *schemaResolverTestSuite) TestUploadAttachmentWithUnavailableStorage() {
+	attachmentManagerMock := new(mockutil.AttachmentManagerMock)
+	attachmentManagerMock.On("StoreAttachment", mock.Anything, mock.Anything).Return(repository.ErrUnavailable).Times(1)
+	suite.resolver.attachmentManager = attachmentManagerMock
+
+	uploadedAttachment, err := suite.mutationResolver.UploadAttachment(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, generateTestUpload(),
+	)
+	assert.Equal(
+		suite.T(), err,
+		&gqlerror.Error{Message: "could not process attachment request", Extensions: map[string]interface{}{"code": "STORAGE_UNAVAILABLE"}},
+		"Should return expected error when the storage is unavailable",
+	)
+	assert.Nil(suite.T(), uploadedAttachment, "Should not return an attachment")
+}
+
+// DeleteAttachment should delete an attachment of the user
+func (suite *schemaResolverTestSuite) TestDeleteAttachment() {
+	attachmentManagerMock := mockutil.DefaultAttachmentManagerMock()
+	suite.resolver.attachmentManager = attachmentManagerMock
+
+	deleted, err := suite.mutationResolver.DeleteAttachment(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should delete the attachment without any errors")
+	assert.True(suite.T(), deleted)
+	attachmentManagerMock.AssertCalled(suite.T(), "DeleteAttachment", mock.MatchedBy(func(deleted *databaseModel.Attachment) bool {
+		return deleted.Id == mockutil.DefaultIdAsUint64
+	}))
+}
+
+// DeleteAttachment should report attachments of other users and missing ones alike
+func (suite *schemaResolverTestSuite) TestDeleteAttachmentOfOtherUserOrMissing() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(
+		&authentication.UserAuthentication{UserId: uint64(2)},
+	).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	deleted, err := suite.mutationResolver.DeleteAttachment(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, &gqlerror.Error{Message: "attachment doesn't exist", Extensions: map[string]interface{}{"code": "NOT_FOUND"}}, "Should return expected error")
+	assert.False(suite.T(), deleted)
+
+	injectDefaultMockedResolverServices(suite)
+	attachmentRepositoryServiceMock := new(mockutil.AttachmentRepositoryServiceMock)
+	attachmentRepositoryServiceMock.On("FetchAttachmentById", mock.Anything, mock.Anything).Return(repository.ErrNotFound, nil).Times(1)
+	suite.resolver.attachmentRepository = attachmentRepositoryServiceMock
+
+	deleted, err = suite.mutationResolver.DeleteAttachment(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, &gqlerror.Error{Message: "attachment doesn't exist", Extensions: map[string]interface{}{"code": "NOT_FOUND"}}, "Should return expected error")
+	assert.False(suite.T(), deleted)
+}
+
+// Attachments should list the attachments of the user's entry
+func (suite *schemaResolverTestSuite) TestAttachments() {
+	attachments, err := suite.queryResolver.Attachments(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should fetch attachments without any errors")
+	assert.Len(suite.T(), attachments, 1)
+	assert.Equal(suite.T(), attachments[0].Name, mockutil.DefaultAttachmentName)
+}
+
+// Attachments should return expected error when the attachments can't be fetched
+func (suite *schemaResolverTestSuite) TestAttachmentsWithFetchError() {
+	attachmentRepositoryServiceMock := new(mockutil.AttachmentRepositoryServiceMock)
+	attachmentRepositoryServiceMock.On("FetchAttachmentsByPasswordId", mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage), nil,
+	).Times(1)
+	suite.resolver.attachmentRepository = attachmentRepositoryServiceMock
+
+	attachments, err := suite.queryResolver.Attachments(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("could not process attachment request"), "Should return expected error")
+	assert.Nil(suite.T(), attachments, "Should not return attachments")
+}
+
+// StorageUsage should return the used storage and the quota of the user
+func (suite *schemaResolverTestSuite) TestStorageUsage() {
+	storageUsage, err := suite.queryResolver.StorageUsage(suite.graphqlRequestContext)
+	assert.Nil(suite.T(), err, "Should fetch the storage usage without any errors")
+	assert.Equal(suite.T(), storageUsage.UsedBytes, int(mockutil.MockedStorageUsage))
+	assert.Equal(suite.T(), storageUsage.QuotaBytes, int(mockutil.DefaultStorageQuota))
+}
+
+func generateTestUpload() graphql.Upload {
+	return graphql.Upload{
+		File:        strings.NewReader(mockutil.DefaultAttachmentContent),
+		Filename:    mockutil.DefaultAttachmentName,
+		Size:        int64(len(mockutil.DefaultAttachmentContent)),
+		ContentType: "text/plain",
+	}
+}
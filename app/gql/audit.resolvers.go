@@ -0,0 +1,53 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *queryResolver) AuditLog(ctx context.Context, filter *model.AuditLogFilter, offset int, limit int) ([]*model.AuditEvent, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(auditLogAuthenticationErrorMessage)
+	}
+
+	eventFilter, ok := toAuditEventFilter(filter)
+	if !ok {
+		return nil, gqlerror.Errorf(auditLogFilterErrorMessage)
+	}
+
+	// Administrators can read everyone's events, users only their own
+	user := databaseModel.User{}
+	err := r.userRepository.FetchById(&user, userAuthentication.UserId, []string{"role", "disabled"})
+	if err != nil {
+		log.Printf("Error while fetching user role: %s", err)
+		return nil, repositoryError(err, auditLogErrorMessage)
+	}
+	if user.Role != databaseModel.UserRoleAdmin || user.Disabled {
+		if eventFilter.UserId != nil && *eventFilter.UserId != userAuthentication.UserId {
+			return nil, gqlerror.Errorf(auditLogAuthenticationErrorMessage)
+		}
+		eventFilter.UserId = &userAuthentication.UserId
+	}
+
+	offset, limit = adminPage(offset, limit)
+	fetchedEvents := databaseModel.AuditEvents{}
+	err = r.auditEventRepository.FetchEvents(&fetchedEvents, eventFilter, offset, limit)
+	if err != nil {
+		log.Printf("Error while fetching audit events: %s", err)
+		return nil, repositoryError(err, auditLogErrorMessage)
+	}
+
+	events := []*model.AuditEvent{}
+	for index := range fetchedEvents {
+		events = append(events, toAuditEvent(&fetchedEvents[index]))
+	}
+	return events, nil
+}
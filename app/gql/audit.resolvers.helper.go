@@ -0,0 +1,90 @@
+package gql
+
+import (
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	auditLogErrorMessage               = "could not fetch the audit log"
+	auditLogAuthenticationErrorMessage = "unauthorized audit log request"
+	auditLogFilterErrorMessage         = "invalid audit log filter"
+)
+
+var auditEventTypes = map[string]model.AuditEventType{
+	databaseModel.AuditEventSignUp:                 model.AuditEventTypeSignUp,
+	databaseModel.AuditEventSignIn:                 model.AuditEventTypeSignIn,
+	databaseModel.AuditEventSignInFailed:           model.AuditEventTypeSignInFailed,
+	databaseModel.AuditEventAuthenticationRejected: model.AuditEventTypeAuthenticationRejected,
+	databaseModel.AuditEventPasswordCreated:        model.AuditEventTypePasswordCreated,
+	databaseModel.AuditEventPasswordsViewed:        model.AuditEventTypePasswordsViewed,
+	databaseModel.AuditEventPasswordUpdated:        model.AuditEventTypePasswordUpdated,
+	databaseModel.AuditEventPasswordDeleted:        model.AuditEventTypePasswordDeleted,
+	databaseModel.AuditEventUserDisabled:           model.AuditEventTypeUserDisabled,
+	databaseModel.AuditEventUserEnabled:            model.AuditEventTypeUserEnabled,
+	databaseModel.AuditEventUserSignedOut:          model.AuditEventTypeUserSignedOut,
+	databaseModel.AuditEventUserRoleChanged:        model.AuditEventTypeUserRoleChanged,
+}
+
+func toAuditEvent(event *databaseModel.AuditEvent) *model.AuditEvent {
+	auditEvent := &model.AuditEvent{
+		ID:        strconv.FormatUint(event.Id, 10),
+		Sequence:  int(event.Sequence),
+		Type:      auditEventTypes[event.Type],
+		IPAddress: event.IpAddress,
+		UserAgent: event.UserAgent,
+		Details:   event.Details,
+		CreatedAt: event.CreatedAt.Format(time.RFC3339),
+	}
+	if event.UserId != nil {
+		userId := strconv.FormatUint(*event.UserId, 10)
+		auditEvent.UserID = &userId
+	}
+	return auditEvent
+}
+
+// toAuditEventFilter converts the filter, times are expected in the RFC 3339 format
+func toAuditEventFilter(filter *model.AuditLogFilter) (*databaseModel.AuditEventFilter, bool) {
+	eventFilter := &databaseModel.AuditEventFilter{}
+	if filter == nil {
+		return eventFilter, true
+	}
+
+	if filter.UserID != nil {
+		userId, err := strconv.ParseUint(*filter.UserID, 10, 64)
+		if err != nil {
+			log.Printf("Error occurred while converting user id to uint64: %s", err)
+			return nil, false
+		}
+		eventFilter.UserId = &userId
+	}
+	for _, eventType := range filter.Types {
+		eventFilter.Types = append(eventFilter.Types, strings.ToLower(eventType.String()))
+	}
+
+	var err error
+	if eventFilter.From, err = parseAuditLogTime(filter.From); err != nil {
+		return nil, false
+	}
+	if eventFilter.To, err = parseAuditLogTime(filter.To); err != nil {
+		return nil, false
+	}
+
+	return eventFilter, true
+}
+
+func parseAuditLogTime(value *string) (*time.Time, error) {
+	if value == nil {
+		return nil, nil
+	}
+	parsedTime, err := time.Parse(time.RFC3339, *value)
+	if err != nil {
+		return nil, err
+	}
+	parsedTime = parsedTime.UTC()
+	return &parsedTime, nil
+}
@@ -0,0 +1,176 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"time"
+)
+
+// AuditLog should only fetch the user's own events for users that aren't administrators
+func (suite *schemaResolverTestSuite) TestAuditLog() {
+	auditEventRepositoryServiceMock := mockutil.DefaultAuditEventRepositoryServiceMock()
+	suite.resolver.auditEventRepository = auditEventRepositoryServiceMock
+	suite.resolver.userRepository = userRepositoryMockWithRole(databaseModel.UserRoleUser, false)
+	from := "2021-01-01T01:00:00+01:00"
+	filter := &model.AuditLogFilter{Types: []model.AuditEventType{model.AuditEventTypeSignIn}, From: &from}
+
+	events, err := suite.queryResolver.AuditLog(suite.graphqlRequestContext, filter, 0, 500)
+	assert.Nil(suite.T(), err, "Should fetch the audit log without any errors")
+	assert.Equal(suite.T(), len(events), 1)
+	assert.Equal(suite.T(), events[0].Type, model.AuditEventTypeSignIn)
+	assert.Equal(suite.T(), *events[0].UserID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), events[0].IPAddress, "127.0.0.1")
+	assert.Equal(suite.T(), events[0].CreatedAt, "2021-01-01T00:00:00Z")
+	auditEventRepositoryServiceMock.AssertCalled(suite.T(), "FetchEvents", mock.Anything, mock.MatchedBy(
+		func(filter *databaseModel.AuditEventFilter) bool {
+			return *filter.UserId == mockutil.DefaultIdAsUint64 && filter.Types[0] == databaseModel.AuditEventSignIn &&
+				filter.From.Equal(time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)) && filter.To == nil
+		},
+	), 0, adminMaximumPageLimit)
+}
+
+// AuditLog should let administrators fetch events of any user
+func (suite *schemaResolverTestSuite) TestAuditLogAsAdmin() {
+	auditEventRepositoryServiceMock := mockutil.DefaultAuditEventRepositoryServiceMock()
+	suite.resolver.auditEventRepository = auditEventRepositoryServiceMock
+	suite.resolver.userRepository = userRepositoryMockWithRole(databaseModel.UserRoleAdmin, false)
+
+	_, err := suite.queryResolver.AuditLog(suite.graphqlRequestContext, nil, 0, 10)
+	assert.Nil(suite.T(), err, "Should fetch the audit log without any errors")
+	auditEventRepositoryServiceMock.AssertCalled(suite.T(), "FetchEvents", mock.Anything, &databaseModel.AuditEventFilter{}, 0, 10)
+
+	suite.resolver.authenticationService = mockutil.DefaultJwtAuthenticationServiceMock()
+	auditEventRepositoryServiceMock = mockutil.DefaultAuditEventRepositoryServiceMock()
+	suite.resolver.auditEventRepository = auditEventRepositoryServiceMock
+	granteeId := "2"
+	_, err = suite.queryResolver.AuditLog(suite.graphqlRequestContext, &model.AuditLogFilter{UserID: &granteeId}, 0, 10)
+	assert.Nil(suite.T(), err, "Should fetch the audit log without any errors")
+	auditEventRepositoryServiceMock.AssertCalled(suite.T(), "FetchEvents", mock.Anything, mock.MatchedBy(
+		func(filter *databaseModel.AuditEventFilter) bool {
+			return filter.UserId != nil && *filter.UserId == mockutil.DefaultGranteeIdAsUint64
+		},
+	), 0, 10)
+}
+
+// AuditLog should not let users fetch events of other users
+func (suite *schemaResolverTestSuite) TestAuditLogOfOtherUser() {
+	auditEventRepositoryServiceMock := mockutil.DefaultAuditEventRepositoryServiceMock()
+	suite.resolver.auditEventRepository = auditEventRepositoryServiceMock
+	suite.resolver.userRepository = userRepositoryMockWithRole(databaseModel.UserRoleAdmin, true)
+	granteeId := "2"
+
+	events, err := suite.queryResolver.AuditLog(suite.graphqlRequestContext, &model.AuditLogFilter{UserID: &granteeId}, 0, 10)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized audit log request"), "Should return expected error")
+	assert.Nil(suite.T(), events)
+	auditEventRepositoryServiceMock.AssertNotCalled(suite.T(), "FetchEvents", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// AuditLog should reject unauthenticated requests and invalid filters
+func (suite *schemaResolverTestSuite) TestAuditLogWithInvalidRequest() {
+	invalidTime := "yesterday"
+	events, err := suite.queryResolver.AuditLog(suite.graphqlRequestContext, &model.AuditLogFilter{To: &invalidTime}, 0, 10)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("invalid audit log filter"), "Should return expected error")
+	assert.Nil(suite.T(), events)
+
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	events, err = suite.queryResolver.AuditLog(suite.graphqlRequestContext, nil, 0, 10)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized audit log request"), "Should return expected error")
+	assert.Nil(suite.T(), events)
+}
+
+// AuditLog should return expected error when fetching the events fails
+func (suite *schemaResolverTestSuite) TestAuditLogWithFetchError() {
+	auditEventRepositoryServiceMock := new(mockutil.AuditEventRepositoryServiceMock)
+	auditEventRepositoryServiceMock.On("FetchEvents", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage), nil,
+	)
+	suite.resolver.auditEventRepository = auditEventRepositoryServiceMock
+	suite.resolver.userRepository = userRepositoryMockWithRole(databaseModel.UserRoleUser, false)
+
+	events, err := suite.queryResolver.AuditLog(suite.graphqlRequestContext, nil, 0, 10)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("could not fetch the audit log"), "Should return expected error")
+	assert.Nil(suite.T(), events)
+}
+
+// SignIn should record successful and failed sign ins
+func (suite *schemaResolverTestSuite) TestSignInRecordsAuditEvents() {
+	auditRecorderMock := mockutil.DefaultAuditRecorderMock()
+	suite.resolver.auditRecorder = auditRecorderMock
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	_, err := suite.mutationResolver.SignIn(context.Background(), input)
+	assert.Nil(suite.T(), err, "User should sign in without any errors")
+	auditRecorderMock.AssertCalled(suite.T(), "Record", auditEventMatching(databaseModel.AuditEventSignIn, mockutil.DefaultIdAsUint64))
+
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("HashWithArgon2id", mock.Anything).Return([]byte("WrongPassword")).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+	suite.resolver.userRepository = mockutil.DefaultUserRepositoryServiceMock()
+
+	_, err = suite.mutationResolver.SignIn(context.Background(), input)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("wrong password"), "Should return expected error")
+	auditRecorderMock.AssertCalled(suite.T(), "Record", auditEventMatching(databaseModel.AuditEventSignInFailed, mockutil.DefaultIdAsUint64))
+}
+
+// SignIn should record failed sign ins of unknown e-mails without a user
+func (suite *schemaResolverTestSuite) TestSignInWithNonExistingUserRecordsAuditEvent() {
+	auditRecorderMock := mockutil.DefaultAuditRecorderMock()
+	suite.resolver.auditRecorder = auditRecorderMock
+	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
+	userRepositoryServiceMock.On("FetchByEmail", mock.Anything, mock.Anything, []string(nil)).Return(
+		repository.ErrNotFound,
+	).Times(1)
+	suite.resolver.userRepository = userRepositoryServiceMock
+
+	_, err := suite.mutationResolver.SignIn(context.Background(), model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword})
+	assert.Equal(suite.T(), err, &gqlerror.Error{Message: "user doesn't exist", Extensions: map[string]interface{}{"code": "NOT_FOUND"}}, "Should return expected error")
+	auditRecorderMock.AssertCalled(suite.T(), "Record", mock.MatchedBy(func(event *databaseModel.AuditEvent) bool {
+		return event.Type == databaseModel.AuditEventSignInFailed && event.UserId == nil
+	}))
+}
+
+// Password resolvers should record every change and every read of the vault
+func (suite *schemaResolverTestSuite) TestPasswordResolversRecordAuditEvents() {
+	auditRecorderMock := mockutil.DefaultAuditRecorderMock()
+	suite.resolver.auditRecorder = auditRecorderMock
+
+	_, err := suite.mutationResolver.CreatePassword(context.Background(), model.NewPassword{
+		UserID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword,
+	})
+	assert.Nil(suite.T(), err, "Password should be created without errors")
+	auditRecorderMock.AssertCalled(suite.T(), "Record", auditEventMatching(databaseModel.AuditEventPasswordCreated, mockutil.DefaultIdAsUint64))
+
+	injectDefaultMockedResolverServices(suite)
+	suite.resolver.auditRecorder = auditRecorderMock
+	_, err = suite.mutationResolver.DeletePassword(context.Background(), mockutil.DefaultIdAsString, nil)
+	assert.Nil(suite.T(), err, "Password should be deleted without errors")
+	auditRecorderMock.AssertCalled(suite.T(), "Record", auditEventMatching(databaseModel.AuditEventPasswordDeleted, mockutil.DefaultIdAsUint64))
+
+	injectDefaultMockedResolverServices(suite)
+	suite.resolver.auditRecorder = auditRecorderMock
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("DecryptWithAes", mock.Anything, mock.Anything).Return("DecryptedPasswordMock", nil)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+	_, err = suite.queryResolver.QueryUserPasswords(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should fetch passwords without errors")
+	auditRecorderMock.AssertCalled(suite.T(), "Record", mock.MatchedBy(func(event *databaseModel.AuditEvent) bool {
+		return event.Type == databaseModel.AuditEventPasswordsViewed && *event.Details == "2 entries"
+	}))
+}
+
+// auditEventMatching matches events of the given type recorded for the user
+func auditEventMatching(eventType string, userId uint64) interface{} {
+	return mock.MatchedBy(func(event *databaseModel.AuditEvent) bool {
+		return event.Type == eventType && event.UserId != nil && *event.UserId == userId
+	})
+}
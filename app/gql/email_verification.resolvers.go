@@ -0,0 +1,63 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/mail"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) VerifyEmail(ctx context.Context, token string) (bool, error) {
+	err := r.unitOfWork.Execute(func(repositories *repository.Repositories) error {
+		verificationToken := databaseModel.EmailVerificationToken{}
+		err := repositories.User.ConsumeEmailVerificationToken(&verificationToken, security.HashToken(token))
+		if err != nil {
+			return err
+		}
+		return repositories.User.UpdateEmailVerifiedById(true, verificationToken.UserId)
+	})
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return false, repositoryError(err, invalidEmailVerificationTokenErrorMessage)
+		}
+		log.Printf("Error while verifying e-mail address: %s", err)
+		return false, repositoryError(err, emailVerificationErrorMessage)
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) ResendEmailVerification(ctx context.Context) (bool, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return false, gqlerror.Errorf(emailVerificationAuthenticationErrorMessage)
+	}
+
+	user := databaseModel.User{}
+	err := r.userRepository.FetchById(&user, userAuthentication.UserId, []string{"email", "username", "email_verified"})
+	if err != nil {
+		log.Printf("Error while fetching user: %s", err)
+		return false, repositoryError(err, emailVerificationResendErrorMessage)
+	}
+	if user.EmailVerified {
+		return false, gqlerror.Errorf(emailAlreadyVerifiedErrorMessage)
+	}
+
+	token, err := r.issueEmailVerificationToken(r.userRepository, userAuthentication.UserId)
+	if err == nil {
+		err = r.mailer.SendEmailVerification(mail.Recipient{Email: user.Email, Username: user.Username}, token)
+	}
+	if err != nil {
+		log.Printf("Error while sending e-mail verification: %s", err)
+		return false, gqlerror.Errorf(emailVerificationResendErrorMessage)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,96 @@
+package gql
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/mail"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"log"
+	"time"
+)
+
+const (
+	emailVerificationErrorMessage               = "could not verify e-mail address"
+	emailVerificationResendErrorMessage         = "could not send e-mail verification"
+	emailVerificationAuthenticationErrorMessage = "unauthorized e-mail verification request"
+	invalidEmailVerificationTokenErrorMessage   = "invalid or expired e-mail verification token"
+	emailAlreadyVerifiedErrorMessage            = "the e-mail address is already verified"
+	emailNotVerifiedErrorMessage                = "the e-mail address is not verified"
+)
+
+const webauthnCredentialRegisteredEvent = "a new WebAuthn credential was registered"
+
+// issueEmailVerificationToken stores a new verification token with the user repository, only the token hash is stored
+func (r *Resolver) issueEmailVerificationToken(userRepository repository.UserRepository, userId uint64) (string, error) {
+	token, err := security.GenerateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	_, err = userRepository.InsertEmailVerificationToken(&databaseModel.EmailVerificationToken{
+		UserId:    userId,
+		TokenHash: security.HashToken(token),
+		ExpiresAt: time.Now().UTC().Add(r.mailer.VerificationTokenTtl()),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// alertOnNewDevice notifies the user about sign ins from an unknown user agent and IP address combination,
+// the very first sign in only registers the device
+func (r *Resolver) alertOnNewDevice(ctx context.Context, userId uint64, recipient mail.Recipient) {
+	clientInformation := authentication.GetClientInformationFromContext(ctx)
+
+	knownDevices := databaseModel.KnownDevices{}
+	err := r.knownDeviceRepository.FetchKnownDevicesByUserId(&knownDevices, userId)
+	if err != nil {
+		log.Printf("Error while fetching user known devices: %s", err)
+		return
+	}
+
+	for _, knownDevice := range knownDevices {
+		if knownDevice.UserAgent == clientInformation.UserAgent && knownDevice.IpAddress == clientInformation.IpAddress {
+			if err = r.knownDeviceRepository.UpdateLastSeenById(time.Now().UTC(), knownDevice.Id); err != nil {
+				log.Printf("Error while updating known device: %s", err)
+			}
+			return
+		}
+	}
+
+	_, err = r.knownDeviceRepository.InsertNewKnownDevice(&databaseModel.KnownDevice{
+		UserId:     userId,
+		UserAgent:  clientInformation.UserAgent,
+		IpAddress:  clientInformation.IpAddress,
+		LastSeenAt: time.Now().UTC(),
+	})
+	if err != nil {
+		log.Printf("Error while storing known device: %s", err)
+		return
+	}
+
+	if len(knownDevices) == 0 {
+		return
+	}
+	if err = r.mailer.SendNewSignInAlert(recipient, clientInformation.UserAgent, clientInformation.IpAddress); err != nil {
+		log.Printf("Error while sending new sign in alert: %s", err)
+	}
+}
+
+// notifySecurityChange informs the user about a security relevant change on their account, failures are only logged
+func (r *Resolver) notifySecurityChange(userId uint64, event string) {
+	user := databaseModel.User{}
+	err := r.userRepository.FetchById(&user, userId, []string{"email", "username"})
+	if err != nil {
+		log.Printf("Error while fetching user: %s", err)
+		return
+	}
+
+	if err = r.mailer.SendSecurityNotification(mail.Recipient{Email: user.Email, Username: user.Username}, event); err != nil {
+		log.Printf("Error while sending security notification: %s", err)
+	}
+}
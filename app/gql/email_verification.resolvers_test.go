@@ -0,0 +1,276 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/mail"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+var defaultRecipient = mail.Recipient{Email: mockutil.DefaultEmail, Username: mockutil.DefaultUsername}
+
+// VerifyEmail should mark the token owner's e-mail address as verified
+func (suite *schemaResolverTestSuite) TestVerifyEmail() {
+	userRepositoryServiceMock := mockutil.DefaultUserRepositoryServiceMock()
+	unitOfWorkMock := mockutil.NewUnitOfWorkMock(&repository.Repositories{User: userRepositoryServiceMock})
+	suite.resolver.unitOfWork = unitOfWorkMock
+
+	verified, err := suite.mutationResolver.VerifyEmail(context.Background(), "token")
+	assert.Nil(suite.T(), err, "E-mail address should be verified without errors")
+	assert.True(suite.T(), verified, "Should return true on successful verification")
+
+	unitOfWorkMock.AssertNumberOfCalls(suite.T(), "Execute", 1)
+
+	userRepositoryServiceMock.AssertCalled(suite.T(), "ConsumeEmailVerificationToken", mock.Anything, security.HashToken("token"))
+	userRepositoryServiceMock.AssertCalled(suite.T(), "UpdateEmailVerifiedById", true, mockutil.DefaultIdAsUint64)
+}
+
+// VerifyEmail should return expected error when the token doesn't exist or has expired
+func (suite *schemaResolverTestSuite) TestVerifyEmailWithInvalidToken() {
+	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
+	userRepositoryServiceMock.On("ConsumeEmailVerificationToken", mock.Anything, mock.Anything).Return(
+		repository.ErrNotFound,
+	).Times(1)
+	suite.resolver.unitOfWork = mockutil.NewUnitOfWorkMock(&repository.Repositories{User: userRepositoryServiceMock})
+
+	verified, err := suite.mutationResolver.VerifyEmail(context.Background(), "token")
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{Message: "invalid or expired e-mail verification token", Extensions: map[string]interface{}{"code": "NOT_FOUND"}},
+		"Should return expected error when the token is invalid",
+	)
+	assert.False(suite.T(), verified, "Should return false on failed verification")
+}
+
+// VerifyEmail should return expected error when updating the user fails
+func (suite *schemaResolverTestSuite) TestVerifyEmailWithUpdateError() {
+	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
+	userRepositoryServiceMock.On("ConsumeEmailVerificationToken", mock.Anything, mock.Anything).Return(nil).Times(1)
+	userRepositoryServiceMock.On("UpdateEmailVerifiedById", mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage),
+	).Times(1)
+	suite.resolver.unitOfWork = mockutil.NewUnitOfWorkMock(&repository.Repositories{User: userRepositoryServiceMock})
+
+	verified, err := suite.mutationResolver.VerifyEmail(context.Background(), "token")
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("could not verify e-mail address"),
+		"Should return expected error when updating the user fails",
+	)
+	assert.False(suite.T(), verified, "Should return false on failed verification")
+}
+
+// VerifyEmail should return expected error when the verification can't be committed
+func (suite *schemaResolverTestSuite) TestVerifyEmailWithCommitError() {
+	unitOfWorkMock := &mockutil.UnitOfWorkMock{
+		Repositories: &repository.Repositories{User: mockutil.DefaultUserRepositoryServiceMock()},
+	}
+	unitOfWorkMock.On("Execute", mock.Anything).Return(repository.ErrUnavailable).Times(1)
+	suite.resolver.unitOfWork = unitOfWorkMock
+
+	verified, err := suite.mutationResolver.VerifyEmail(context.Background(), "token")
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{Message: "could not verify e-mail address", Extensions: map[string]interface{}{"code": "STORAGE_UNAVAILABLE"}},
+		"Should return expected error when the storage is unavailable",
+	)
+	assert.False(suite.T(), verified, "Should return false on failed verification")
+}
+
+// ResendEmailVerification should send a new verification e-mail
+func (suite *schemaResolverTestSuite) TestResendEmailVerification() {
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+
+	sent, err := suite.mutationResolver.ResendEmailVerification(context.Background())
+	assert.Nil(suite.T(), err, "Verification should be resent without errors")
+	assert.True(suite.T(), sent, "Should return true on successfully resent verification")
+
+	mailServiceMock.AssertCalled(suite.T(), "SendEmailVerification", defaultRecipient, mock.Anything)
+}
+
+// ResendEmailVerification should return expected error on unauthenticated requests
+func (suite *schemaResolverTestSuite) TestResendEmailVerificationWithoutAuthentication() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	sent, err := suite.mutationResolver.ResendEmailVerification(context.Background())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized e-mail verification request"),
+		"Should return expected error on unauthenticated request",
+	)
+	assert.False(suite.T(), sent, "Should return false on failed resend")
+}
+
+// ResendEmailVerification should return expected error when the e-mail address is already verified
+func (suite *schemaResolverTestSuite) TestResendEmailVerificationWithVerifiedEmail() {
+	userRepositoryServiceMock := new(verifiedUserRepositoryServiceMock)
+	userRepositoryServiceMock.On("FetchById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	suite.resolver.userRepository = userRepositoryServiceMock
+
+	sent, err := suite.mutationResolver.ResendEmailVerification(context.Background())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("the e-mail address is already verified"),
+		"Should return expected error when the e-mail address is already verified",
+	)
+	assert.False(suite.T(), sent, "Should return false on failed resend")
+}
+
+// ResendEmailVerification should return expected error when storing the verification token fails
+func (suite *schemaResolverTestSuite) TestResendEmailVerificationWithTokenInsertError() {
+	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
+	userRepositoryServiceMock.On("FetchById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	userRepositoryServiceMock.On("InsertEmailVerificationToken", mock.Anything).Return(
+		nil, errors.New(mockutil.MockedGenericErrorMessage),
+	).Times(1)
+	suite.resolver.userRepository = userRepositoryServiceMock
+
+	sent, err := suite.mutationResolver.ResendEmailVerification(context.Background())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("could not send e-mail verification"),
+		"Should return expected error when storing the verification token fails",
+	)
+	assert.False(suite.T(), sent, "Should return false on failed resend")
+}
+
+// SignUp should send an e-mail verification to the new user
+func (suite *schemaResolverTestSuite) TestSignUpSendsEmailVerification() {
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+	input := model.NewUser{Email: mockutil.DefaultEmail, Username: mockutil.DefaultUsername, Password: mockutil.DefaultPassword}
+
+	_, err := suite.mutationResolver.SignUp(context.Background(), input)
+	assert.Nil(suite.T(), err, "User should be created without errors")
+
+	mailServiceMock.AssertCalled(suite.T(), "SendEmailVerification", defaultRecipient, mock.Anything)
+}
+
+// SignUp should create the user even if the e-mail verification can't be sent
+func (suite *schemaResolverTestSuite) TestSignUpWithEmailVerificationError() {
+	mailServiceMock := new(mockutil.MailServiceMock)
+	mailServiceMock.On("VerificationTokenTtl").Return(time.Hour).Times(1)
+	mailServiceMock.On("SendEmailVerification", mock.Anything, mock.Anything).Return(mail.ErrQueueFull).Times(1)
+	suite.resolver.mailer = mailServiceMock
+	input := model.NewUser{Email: mockutil.DefaultEmail, Username: mockutil.DefaultUsername, Password: mockutil.DefaultPassword}
+
+	userWithRecoveryKey, err := suite.mutationResolver.SignUp(context.Background(), input)
+	assert.Nil(suite.T(), err, "User should be created without errors")
+	assert.Equal(suite.T(), userWithRecoveryKey.User.ID, mockutil.DefaultIdAsString)
+}
+
+// SignIn should return expected error for unverified users when e-mail verification is required
+func (suite *schemaResolverTestSuite) TestSignInWithUnverifiedEmail() {
+	mailServiceMock := new(mockutil.MailServiceMock)
+	mailServiceMock.On("EmailVerificationRequired").Return(true).Times(1)
+	suite.resolver.mailer = mailServiceMock
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	userWithToken, err := suite.mutationResolver.SignIn(context.Background(), input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("the e-mail address is not verified"),
+		"Should return expected error when the user's e-mail address is not verified",
+	)
+	assert.Nil(suite.T(), userWithToken, "Token should not be generated")
+}
+
+// SignIn should only register the device on the user's first sign in
+func (suite *schemaResolverTestSuite) TestSignInFromFirstDevice() {
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+	knownDeviceRepositoryServiceMock := mockutil.DefaultKnownDeviceRepositoryServiceMock()
+	suite.resolver.knownDeviceRepository = knownDeviceRepositoryServiceMock
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	_, err := suite.mutationResolver.SignIn(generateClientInformationContext(), input)
+	assert.Nil(suite.T(), err, "User should sign in without any errors")
+
+	knownDeviceRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewKnownDevice", mock.Anything)
+	mailServiceMock.AssertNotCalled(suite.T(), "SendNewSignInAlert", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// SignIn should alert the user about a sign in from a new device
+func (suite *schemaResolverTestSuite) TestSignInFromNewDevice() {
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+	knownDeviceRepositoryServiceMock := knownDeviceRepositoryMockWithDevice("Chrome", "10.0.0.2")
+	suite.resolver.knownDeviceRepository = knownDeviceRepositoryServiceMock
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	_, err := suite.mutationResolver.SignIn(generateClientInformationContext(), input)
+	assert.Nil(suite.T(), err, "User should sign in without any errors")
+
+	knownDeviceRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewKnownDevice", mock.Anything)
+	mailServiceMock.AssertCalled(suite.T(), "SendNewSignInAlert", defaultRecipient, "Firefox", "10.0.0.1")
+}
+
+// SignIn should only update the last seen time of known devices
+func (suite *schemaResolverTestSuite) TestSignInFromKnownDevice() {
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+	knownDeviceRepositoryServiceMock := knownDeviceRepositoryMockWithDevice("Firefox", "10.0.0.1")
+	suite.resolver.knownDeviceRepository = knownDeviceRepositoryServiceMock
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	_, err := suite.mutationResolver.SignIn(generateClientInformationContext(), input)
+	assert.Nil(suite.T(), err, "User should sign in without any errors")
+
+	knownDeviceRepositoryServiceMock.AssertCalled(suite.T(), "UpdateLastSeenById", mock.Anything, mockutil.DefaultIdAsUint64)
+	knownDeviceRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertNewKnownDevice", mock.Anything)
+	mailServiceMock.AssertNotCalled(suite.T(), "SendNewSignInAlert", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// FinishWebauthnRegistration should notify the user about the new credential
+func (suite *schemaResolverTestSuite) TestFinishWebauthnRegistrationSendsSecurityNotification() {
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+
+	_, err := suite.mutationResolver.FinishWebauthnRegistration(context.Background(), generateTestWebauthnRegistration())
+	assert.Nil(suite.T(), err, "Credential should be registered without errors")
+
+	mailServiceMock.AssertCalled(suite.T(), "SendSecurityNotification", defaultRecipient, webauthnCredentialRegisteredEvent)
+}
+
+type verifiedUserRepositoryServiceMock struct {
+	mockutil.UserRepositoryServiceMock
+}
+
+func (service *verifiedUserRepositoryServiceMock) FetchById(user *databaseModel.User, id uint64, queryFields []string) error {
+	err := service.UserRepositoryServiceMock.FetchById(user, id, queryFields)
+	user.EmailVerified = true
+	return err
+}
+
+func knownDeviceRepositoryMockWithDevice(userAgent string, ipAddress string) *mockutil.KnownDeviceRepositoryServiceMock {
+	knownDeviceRepositoryServiceMock := mockutil.DefaultKnownDeviceRepositoryServiceMock()
+	knownDeviceRepositoryServiceMock.ExpectedCalls = nil
+	knownDeviceRepositoryServiceMock.On("FetchKnownDevicesByUserId", mock.Anything, mock.Anything).Return(
+		nil, databaseModel.KnownDevices{{Id: mockutil.DefaultIdAsUint64, UserId: mockutil.DefaultIdAsUint64, UserAgent: userAgent, IpAddress: ipAddress}},
+	).Times(1)
+	knownDeviceRepositoryServiceMock.On("InsertNewKnownDevice", mock.Anything).Return(db.NewInsertResult(int64(2)), nil).Times(1)
+	knownDeviceRepositoryServiceMock.On("UpdateLastSeenById", mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return knownDeviceRepositoryServiceMock
+}
+
+func generateClientInformationContext() context.Context {
+	var ctx context.Context
+	handler := authentication.ClientInformationMiddleware()(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		ctx = request.Context()
+	}))
+	request := httptest.NewRequest("POST", "/query", nil)
+	request.RemoteAddr = "10.0.0.1:54321"
+	request.Header.Set("User-Agent", "Firefox")
+	handler.ServeHTTP(httptest.NewRecorder(), request)
+
+	return ctx
+}
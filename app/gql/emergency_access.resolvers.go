@@ -0,0 +1,362 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/emergencyaccess"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/mail"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) InviteEmergencyContact(ctx context.Context, input model.EmergencyAccessInvite) (*model.EmergencyAccessGrant, error) {
+	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
+	if validationErrors != nil {
+		return nil, gqlerror.Errorf("validation error/s on emergency access invite input")
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(emergencyAccessAuthenticationErrorMessage)
+	}
+
+	grantee := databaseModel.User{}
+	err := r.userRepository.FetchByEmail(ctx, &grantee, input.Email, []string{"id", "email"})
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, repositoryError(err, queryNonExistingEmailErrorMessage)
+		}
+		log.Printf("Error while fetching user by email: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+	if grantee.Id == userAuthentication.UserId {
+		return nil, gqlerror.Errorf(emergencyAccessSelfInviteErrorMessage)
+	}
+
+	grantor := databaseModel.User{}
+	err = r.userRepository.FetchById(&grantor, userAuthentication.UserId, []string{"email"})
+	if err != nil {
+		log.Printf("Error while fetching user: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+
+	grant := databaseModel.EmergencyAccessGrant{
+		GrantorId:      userAuthentication.UserId,
+		GranteeId:      grantee.Id,
+		AccessType:     strings.ToLower(input.AccessType.String()),
+		WaitTimeInDays: input.WaitTimeInDays,
+		Status:         databaseModel.EmergencyAccessGrantInvited,
+	}
+	insertResult, err := r.emergencyAccessRepository.InsertNewGrant(&grant)
+	if err != nil {
+		if err == repository.ErrDuplicate {
+			return nil, repositoryError(err, emergencyAccessExistingGrantErrorMessage)
+		}
+		log.Printf("Error while storing emergency access grant: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+	grant.Id = uint64(insertResult.ID().(int64))
+
+	return toEmergencyAccessGrant(&grant, grantor.Email, grantee.Email), nil
+}
+
+func (r *mutationResolver) AcceptEmergencyAccess(ctx context.Context, grantID string) (*model.EmergencyAccessGrant, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	grant, err := r.fetchEmergencyAccessGrant(grantID)
+	if err != nil {
+		return nil, err
+	}
+	if userAuthentication == nil || grant.GranteeId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(emergencyAccessAuthenticationErrorMessage)
+	}
+	if grant.Status != databaseModel.EmergencyAccessGrantInvited {
+		return nil, gqlerror.Errorf(emergencyAccessGrantStatusErrorMessage)
+	}
+
+	// The grantor's vault key gets sealed with the grantee's public key once access is approved
+	if _, err = r.fetchOrCreateKeyPair(r.keyPairRepository, r.userRepository, userAuthentication.UserId); err != nil {
+		log.Printf("Error while creating user key pair: %s", err)
+		return nil, gqlerror.Errorf(emergencyAccessErrorMessage)
+	}
+
+	err = r.emergencyAccessRepository.UpdateGrantStatusById(databaseModel.EmergencyAccessGrantAccepted, grant.Id)
+	if err != nil {
+		log.Printf("Error while updating emergency access grant: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+	grant.Status = databaseModel.EmergencyAccessGrantAccepted
+
+	return toEmergencyAccessGrant(&grant.EmergencyAccessGrant, grant.GrantorEmail, grant.GranteeEmail), nil
+}
+
+func (r *mutationResolver) RevokeEmergencyAccess(ctx context.Context, grantID string) (*model.EmergencyAccessGrant, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	grant, err := r.fetchEmergencyAccessGrant(grantID)
+	if err != nil {
+		return nil, err
+	}
+	if userAuthentication == nil || grant.GrantorId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(emergencyAccessAuthenticationErrorMessage)
+	}
+	if grant.Status == databaseModel.EmergencyAccessGrantRevoked {
+		return nil, gqlerror.Errorf(emergencyAccessGrantStatusErrorMessage)
+	}
+
+	err = r.emergencyAccessRepository.UpdateGrantStatusById(databaseModel.EmergencyAccessGrantRevoked, grant.Id)
+	if err != nil {
+		log.Printf("Error while updating emergency access grant: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+	grant.Status = databaseModel.EmergencyAccessGrantRevoked
+
+	return toEmergencyAccessGrant(&grant.EmergencyAccessGrant, grant.GrantorEmail, grant.GranteeEmail), nil
+}
+
+func (r *mutationResolver) RequestEmergencyAccess(ctx context.Context, grantID string) (*model.EmergencyAccessRequest, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	grant, err := r.fetchEmergencyAccessGrant(grantID)
+	if err != nil {
+		return nil, err
+	}
+	if userAuthentication == nil || grant.GranteeId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(emergencyAccessAuthenticationErrorMessage)
+	}
+	if grant.Status != databaseModel.EmergencyAccessGrantAccepted {
+		return nil, gqlerror.Errorf(emergencyAccessGrantStatusErrorMessage)
+	}
+
+	requests := databaseModel.EmergencyAccessRequests{}
+	err = r.emergencyAccessRepository.FetchRequestsByGrantId(&requests, grant.Id)
+	if err != nil {
+		log.Printf("Error while fetching emergency access requests: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+	if len(requests) > 0 && requests[0].Status != databaseModel.EmergencyAccessRequestRejected {
+		return nil, gqlerror.Errorf(emergencyAccessPendingRequestErrorMessage)
+	}
+
+	requestedAt := time.Now().UTC()
+	request := databaseModel.EmergencyAccessRequest{
+		GrantId:     grant.Id,
+		Status:      databaseModel.EmergencyAccessRequestPending,
+		RequestedAt: requestedAt,
+		AvailableAt: requestedAt.AddDate(0, 0, grant.WaitTimeInDays),
+	}
+	insertResult, err := r.emergencyAccessRepository.InsertNewRequest(&request)
+	if err != nil {
+		log.Printf("Error while storing emergency access request: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+	request.Id = uint64(insertResult.ID().(int64))
+
+	r.notifySecurityChange(grant.GrantorId, fmt.Sprintf(
+		"%s requested emergency access to your vault, access will be granted on %s unless you reject the request",
+		grant.GranteeEmail, request.AvailableAt.Format(time.RFC1123),
+	))
+
+	return toEmergencyAccessRequest(&request), nil
+}
+
+func (r *mutationResolver) ApproveEmergencyAccess(ctx context.Context, requestID string) (*model.EmergencyAccessRequest, error) {
+	request, err := r.fetchPendingEmergencyAccessRequestOfGrantor(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.emergencyAccessApprover.ApproveRequest(request)
+	if err != nil {
+		if err == emergencyaccess.ErrInactiveGrant {
+			return nil, gqlerror.Errorf(emergencyAccessGrantStatusErrorMessage)
+		}
+		if err == repository.ErrNotFound {
+			return nil, gqlerror.Errorf(emergencyAccessRequestStatusErrorMessage)
+		}
+		log.Printf("Error while approving emergency access request: %s", err)
+		return nil, gqlerror.Errorf(emergencyAccessErrorMessage)
+	}
+	request.Status = databaseModel.EmergencyAccessRequestApproved
+
+	return toEmergencyAccessRequest(request), nil
+}
+
+func (r *mutationResolver) RejectEmergencyAccess(ctx context.Context, requestID string) (*model.EmergencyAccessRequest, error) {
+	request, err := r.fetchPendingEmergencyAccessRequestOfGrantor(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = r.emergencyAccessRepository.RejectRequestById(request.Id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, repositoryError(err, emergencyAccessRequestStatusErrorMessage)
+		}
+		log.Printf("Error while rejecting emergency access request: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+	request.Status = databaseModel.EmergencyAccessRequestRejected
+
+	return toEmergencyAccessRequest(request), nil
+}
+
+func (r *mutationResolver) TakeOverEmergencyAccount(ctx context.Context, input model.EmergencyAccountTakeover) (*model.UserWithRecoveryKey, error) {
+	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
+	if validationErrors != nil {
+		return nil, gqlerror.Errorf("validation error/s on emergency account takeover input")
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	grant, err := r.fetchEmergencyAccessGrant(input.GrantID)
+	if err != nil {
+		return nil, err
+	}
+	if userAuthentication == nil || grant.GranteeId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(emergencyAccessAuthenticationErrorMessage)
+	}
+	if grant.Status != databaseModel.EmergencyAccessGrantAccepted {
+		return nil, gqlerror.Errorf(emergencyAccessGrantStatusErrorMessage)
+	}
+	if grant.AccessType != databaseModel.EmergencyAccessTakeover {
+		return nil, gqlerror.Errorf(emergencyAccessTypeErrorMessage)
+	}
+
+	vaultKey, err := r.unsealApprovedGrantorVaultKey(&grant.EmergencyAccessGrant)
+	if err != nil {
+		return nil, err
+	}
+
+	grantor := databaseModel.User{}
+	err = r.userRepository.FetchById(&grantor, grant.GrantorId, []string{"email", "username", "email_verified"})
+	if err != nil {
+		log.Printf("Error while fetching user: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+
+	recoveryKey, err := r.changeMasterPassword(ctx, grant.GrantorId, vaultKey, input.NewPassword)
+	if err != nil {
+		log.Printf("Error while changing user master password: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+
+	log.Printf("Account of user %d taken over by emergency contact %d", grant.GrantorId, grant.GranteeId)
+	err = r.mailer.SendSecurityNotification(
+		mail.Recipient{Email: grantor.Email, Username: grantor.Username},
+		fmt.Sprintf("your emergency contact %s took over your account and changed the master password", grant.GranteeEmail),
+	)
+	if err != nil {
+		log.Printf("Error while sending security notification: %s", err)
+	}
+
+	user := &model.User{
+		ID:            strconv.FormatUint(grant.GrantorId, 10),
+		Email:         grantor.Email,
+		Username:      grantor.Username,
+		EmailVerified: grantor.EmailVerified,
+	}
+	return &model.UserWithRecoveryKey{User: user, RecoveryKey: recoveryKey}, nil
+}
+
+func (r *queryResolver) EmergencyAccessGrants(ctx context.Context) ([]*model.EmergencyAccessGrant, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(emergencyAccessAuthenticationErrorMessage)
+	}
+
+	fetchedGrants := databaseModel.EmergencyAccessGrantsDetails{}
+	err := r.emergencyAccessRepository.FetchGrantsDetailsByUserId(&fetchedGrants, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching emergency access grants: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+
+	grants := []*model.EmergencyAccessGrant{}
+	for index := range fetchedGrants {
+		grant := &fetchedGrants[index]
+		grants = append(grants, toEmergencyAccessGrant(&grant.EmergencyAccessGrant, grant.GrantorEmail, grant.GranteeEmail))
+	}
+	return grants, nil
+}
+
+func (r *queryResolver) EmergencyAccessRequests(ctx context.Context, grantID string) ([]*model.EmergencyAccessRequest, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	grant, err := r.fetchEmergencyAccessGrant(grantID)
+	if err != nil {
+		return nil, err
+	}
+	if userAuthentication == nil || (grant.GrantorId != userAuthentication.UserId && grant.GranteeId != userAuthentication.UserId) {
+		return nil, gqlerror.Errorf(emergencyAccessAuthenticationErrorMessage)
+	}
+
+	fetchedRequests := databaseModel.EmergencyAccessRequests{}
+	err = r.emergencyAccessRepository.FetchRequestsByGrantId(&fetchedRequests, grant.Id)
+	if err != nil {
+		log.Printf("Error while fetching emergency access requests: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+
+	requests := []*model.EmergencyAccessRequest{}
+	for index := range fetchedRequests {
+		requests = append(requests, toEmergencyAccessRequest(&fetchedRequests[index]))
+	}
+	return requests, nil
+}
+
+func (r *queryResolver) EmergencyVault(ctx context.Context, grantID string) ([]*model.Password, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	grant, err := r.fetchEmergencyAccessGrant(grantID)
+	if err != nil {
+		return nil, err
+	}
+	if userAuthentication == nil || grant.GranteeId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(emergencyAccessAuthenticationErrorMessage)
+	}
+	if grant.Status != databaseModel.EmergencyAccessGrantAccepted {
+		return nil, gqlerror.Errorf(emergencyAccessGrantStatusErrorMessage)
+	}
+
+	vaultKey, err := r.unsealApprovedGrantorVaultKey(&grant.EmergencyAccessGrant)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchedPasswords := databaseModel.Passwords{}
+	err = r.passwordRepository.FetchAllByUserId(ctx, &fetchedPasswords, grant.GrantorId, nil)
+	if err != nil {
+		log.Printf("Error while fetching user passwords: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+
+	passwords := []*model.Password{}
+	for _, password := range fetchedPasswords {
+		decryptedPassword, err := r.passwordSecurityService.DecryptWithAes(password.Password, vaultKey)
+		if err != nil {
+			log.Printf("Error while decrypting user password: %s", err)
+			return nil, gqlerror.Errorf(emergencyAccessErrorMessage)
+		}
+		totpUri, err := r.decryptTotpUri(password.TotpUri, vaultKey)
+		if err != nil {
+			log.Printf("Error while decrypting user TOTP uri: %s", err)
+			return nil, gqlerror.Errorf(emergencyAccessErrorMessage)
+		}
+		passwords = append(passwords, &model.Password{
+			ID:          strconv.FormatUint(password.Id, 10),
+			UserID:      strconv.FormatUint(password.UserId, 10),
+			Name:        password.Name,
+			Password:    decryptedPassword,
+			Revision:    int(password.Revision),
+			Version:     int(password.Version),
+			TotpURI:     totpUri,
+			CurrentCode: toOneTimeCode(totpUri),
+		})
+	}
+	return passwords, nil
+}
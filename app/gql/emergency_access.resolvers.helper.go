@@ -0,0 +1,216 @@
+package gql
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	emergencyAccessErrorMessage               = "could not process emergency access"
+	emergencyAccessAuthenticationErrorMessage = "unauthorized emergency access request"
+	emergencyAccessSelfInviteErrorMessage     = "you can't be your own emergency contact"
+	emergencyAccessExistingGrantErrorMessage  = "the user is already your emergency contact"
+	emergencyAccessGrantStatusErrorMessage    = "emergency access grant is not in a valid state for this operation"
+	emergencyAccessPendingRequestErrorMessage = "emergency access was already requested"
+	emergencyAccessRequestStatusErrorMessage  = "emergency access request is no longer pending"
+	emergencyAccessNotApprovedErrorMessage    = "emergency access is not approved"
+	emergencyAccessTypeErrorMessage           = "emergency access grant doesn't allow account takeover"
+	emergencyAccessOutdatedErrorMessage       = "emergency access approval is outdated, request access again"
+)
+
+var errOutdatedEmergencyAccess = errors.New("sealed vault key doesn't match the grantor's current vault key")
+
+var emergencyAccessTypes = map[string]model.EmergencyAccessType{
+	databaseModel.EmergencyAccessView:     model.EmergencyAccessTypeView,
+	databaseModel.EmergencyAccessTakeover: model.EmergencyAccessTypeTakeover,
+}
+
+var emergencyAccessGrantStatuses = map[string]model.EmergencyAccessGrantStatus{
+	databaseModel.EmergencyAccessGrantInvited:  model.EmergencyAccessGrantStatusInvited,
+	databaseModel.EmergencyAccessGrantAccepted: model.EmergencyAccessGrantStatusAccepted,
+	databaseModel.EmergencyAccessGrantRevoked:  model.EmergencyAccessGrantStatusRevoked,
+}
+
+var emergencyAccessRequestStatuses = map[string]model.EmergencyAccessRequestStatus{
+	databaseModel.EmergencyAccessRequestPending:  model.EmergencyAccessRequestStatusPending,
+	databaseModel.EmergencyAccessRequestApproved: model.EmergencyAccessRequestStatusApproved,
+	databaseModel.EmergencyAccessRequestRejected: model.EmergencyAccessRequestStatusRejected,
+}
+
+func toEmergencyAccessGrant(grant *databaseModel.EmergencyAccessGrant, grantorEmail string, granteeEmail string) *model.EmergencyAccessGrant {
+	return &model.EmergencyAccessGrant{
+		ID:             strconv.FormatUint(grant.Id, 10),
+		GrantorEmail:   grantorEmail,
+		GranteeEmail:   granteeEmail,
+		AccessType:     emergencyAccessTypes[grant.AccessType],
+		WaitTimeInDays: grant.WaitTimeInDays,
+		Status:         emergencyAccessGrantStatuses[grant.Status],
+	}
+}
+
+func toEmergencyAccessRequest(request *databaseModel.EmergencyAccessRequest) *model.EmergencyAccessRequest {
+	return &model.EmergencyAccessRequest{
+		ID:          strconv.FormatUint(request.Id, 10),
+		GrantID:     strconv.FormatUint(request.GrantId, 10),
+		Status:      emergencyAccessRequestStatuses[request.Status],
+		RequestedAt: request.RequestedAt.Format(time.RFC3339),
+		AvailableAt: request.AvailableAt.Format(time.RFC3339),
+	}
+}
+
+// fetchOrCreateKeyPair lazily creates key pairs, so users that signed up before key pairs existed get one when they need it
+func (r *Resolver) fetchOrCreateKeyPair(
+	keyPairRepository repository.KeyPairRepository, userRepository repository.UserRepository, userId uint64,
+) (*databaseModel.KeyPair, error) {
+	keyPair := &databaseModel.KeyPair{}
+	err := keyPairRepository.FetchKeyPairByUserId(keyPair, userId)
+	if err == nil {
+		return keyPair, nil
+	}
+	if err != repository.ErrNotFound {
+		return nil, err
+	}
+
+	user := databaseModel.User{}
+	if err = userRepository.FetchMasterPasswordByUserId(&user, userId); err != nil {
+		return nil, err
+	}
+
+	publicKey, wrappedPrivateKey, err := r.passwordSecurityService.GenerateKeyPair(user.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	keyPair = &databaseModel.KeyPair{UserId: userId, PublicKey: publicKey, WrappedPrivateKey: wrappedPrivateKey}
+	if err = keyPairRepository.InsertNewKeyPair(keyPair); err != nil {
+		return nil, err
+	}
+
+	return keyPair, nil
+}
+
+// rewrapPrivateKey returns nil for users without a key pair
+func (r *Resolver) rewrapPrivateKey(
+	keyPairRepository repository.KeyPairRepository, userId uint64, oldVaultKey []byte, newVaultKey []byte,
+) ([]byte, error) {
+	keyPair := databaseModel.KeyPair{}
+	err := keyPairRepository.FetchKeyPairByUserId(&keyPair, userId)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return r.passwordSecurityService.RewrapPrivateKey(keyPair.WrappedPrivateKey, oldVaultKey, newVaultKey)
+}
+
+// unsealGrantorVaultKey opens the grantor's vault key from the latest approved request of an active grant
+func (r *Resolver) unsealGrantorVaultKey(grant *databaseModel.EmergencyAccessGrant) ([]byte, error) {
+	requests := databaseModel.EmergencyAccessRequests{}
+	if err := r.emergencyAccessRepository.FetchRequestsByGrantId(&requests, grant.Id); err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 || requests[0].Status != databaseModel.EmergencyAccessRequestApproved {
+		return nil, nil
+	}
+
+	granteeKeyPair := databaseModel.KeyPair{}
+	if err := r.keyPairRepository.FetchKeyPairByUserId(&granteeKeyPair, grant.GranteeId); err != nil {
+		return nil, err
+	}
+	grantee := databaseModel.User{}
+	if err := r.userRepository.FetchMasterPasswordByUserId(&grantee, grant.GranteeId); err != nil {
+		return nil, err
+	}
+
+	vaultKey, err := r.passwordSecurityService.OpenWithPrivateKey(
+		requests[0].SealedVaultKey, granteeKeyPair.PublicKey, granteeKeyPair.WrappedPrivateKey, grantee.Password,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// The grantor may have changed the master password after the approval
+	grantor := databaseModel.User{}
+	if err = r.userRepository.FetchMasterPasswordByUserId(&grantor, grant.GrantorId); err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(vaultKey, grantor.Password) == 0 {
+		return nil, errOutdatedEmergencyAccess
+	}
+
+	return vaultKey, nil
+}
+
+func (r *Resolver) fetchEmergencyAccessGrant(grantID string) (*databaseModel.EmergencyAccessGrantDetails, error) {
+	grantId, err := strconv.ParseUint(grantID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting emergency access grant id to uint64: %s", err)
+		return nil, gqlerror.Errorf(emergencyAccessErrorMessage)
+	}
+
+	grant := &databaseModel.EmergencyAccessGrantDetails{}
+	err = r.emergencyAccessRepository.FetchGrantDetailsById(grant, grantId)
+	if err != nil {
+		log.Printf("Error while fetching emergency access grant: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+
+	return grant, nil
+}
+
+// fetchPendingEmergencyAccessRequestOfGrantor fetches a pending request that only the grantor is allowed to approve or reject
+func (r *Resolver) fetchPendingEmergencyAccessRequestOfGrantor(ctx context.Context, requestID string) (*databaseModel.EmergencyAccessRequest, error) {
+	requestId, err := strconv.ParseUint(requestID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting emergency access request id to uint64: %s", err)
+		return nil, gqlerror.Errorf(emergencyAccessErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	request := &databaseModel.EmergencyAccessRequest{}
+	err = r.emergencyAccessRepository.FetchRequestById(request, requestId)
+	if err != nil {
+		log.Printf("Error while fetching emergency access request: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+	grant := databaseModel.EmergencyAccessGrant{}
+	err = r.emergencyAccessRepository.FetchGrantById(&grant, request.GrantId)
+	if err != nil {
+		log.Printf("Error while fetching emergency access grant: %s", err)
+		return nil, repositoryError(err, emergencyAccessErrorMessage)
+	}
+	if userAuthentication == nil || grant.GrantorId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(emergencyAccessAuthenticationErrorMessage)
+	}
+	if request.Status != databaseModel.EmergencyAccessRequestPending {
+		return nil, gqlerror.Errorf(emergencyAccessRequestStatusErrorMessage)
+	}
+
+	return request, nil
+}
+
+func (r *Resolver) unsealApprovedGrantorVaultKey(grant *databaseModel.EmergencyAccessGrant) ([]byte, error) {
+	vaultKey, err := r.unsealGrantorVaultKey(grant)
+	if err != nil {
+		if err == errOutdatedEmergencyAccess {
+			return nil, gqlerror.Errorf(emergencyAccessOutdatedErrorMessage)
+		}
+		log.Printf("Error while unsealing grantor vault key: %s", err)
+		return nil, gqlerror.Errorf(emergencyAccessErrorMessage)
+	}
+	if vaultKey == nil {
+		return nil, gqlerror.Errorf(emergencyAccessNotApprovedErrorMessage)
+	}
+
+	return vaultKey, nil
+}
@@ -0,0 +1,439 @@
+package gql
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"time"
+)
+
+// InviteEmergencyContact should store an invited grant for the given user
+func (suite *schemaResolverTestSuite) TestInviteEmergencyContact() {
+	userRepositoryServiceMock := new(granteeUserRepositoryServiceMock)
+	userRepositoryServiceMock.On("FetchByEmail", mock.Anything, mockutil.DefaultGranteeEmail, mock.Anything).Return(nil).Times(1)
+	userRepositoryServiceMock.On("FetchById", mock.Anything, mockutil.DefaultIdAsUint64, mock.Anything).Return(nil).Times(1)
+	suite.resolver.userRepository = userRepositoryServiceMock
+	emergencyAccessRepositoryServiceMock := mockutil.DefaultEmergencyAccessRepositoryServiceMock()
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryServiceMock
+
+	grant, err := suite.mutationResolver.InviteEmergencyContact(context.Background(), generateTestEmergencyAccessInvite())
+	assert.Nil(suite.T(), err, "Emergency contact should be invited without errors")
+
+	assert.Equal(suite.T(), grant.ID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), grant.GrantorEmail, mockutil.DefaultEmail)
+	assert.Equal(suite.T(), grant.GranteeEmail, mockutil.DefaultGranteeEmail)
+	assert.Equal(suite.T(), grant.AccessType, model.EmergencyAccessTypeTakeover)
+	assert.Equal(suite.T(), grant.Status, model.EmergencyAccessGrantStatusInvited)
+	emergencyAccessRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewGrant", &databaseModel.EmergencyAccessGrant{
+		Id:             mockutil.DefaultIdAsUint64,
+		GrantorId:      mockutil.DefaultIdAsUint64,
+		GranteeId:      mockutil.DefaultGranteeIdAsUint64,
+		AccessType:     databaseModel.EmergencyAccessTakeover,
+		WaitTimeInDays: 7,
+		Status:         databaseModel.EmergencyAccessGrantInvited,
+	})
+}
+
+// InviteEmergencyContact should return expected error when users invite themselves
+func (suite *schemaResolverTestSuite) TestInviteEmergencyContactWithSelfInvite() {
+	grant, err := suite.mutationResolver.InviteEmergencyContact(context.Background(), generateTestEmergencyAccessInvite())
+	assert.Equal(suite.T(), err, gqlerror.Errorf("you can't be your own emergency contact"), "Should return expected error on self invite")
+	assert.Nil(suite.T(), grant, "Should not return any grant data")
+}
+
+// InviteEmergencyContact should return expected error when the user is already an emergency contact
+func (suite *schemaResolverTestSuite) TestInviteEmergencyContactWithExistingGrant() {
+	userRepositoryServiceMock := new(granteeUserRepositoryServiceMock)
+	userRepositoryServiceMock.On("FetchByEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	userRepositoryServiceMock.On("FetchById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	suite.resolver.userRepository = userRepositoryServiceMock
+	emergencyAccessRepositoryServiceMock := new(mockutil.EmergencyAccessRepositoryServiceMock)
+	emergencyAccessRepositoryServiceMock.On("InsertNewGrant", mock.Anything).Return(nil, repository.ErrDuplicate).Times(1)
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryServiceMock
+
+	grant, err := suite.mutationResolver.InviteEmergencyContact(context.Background(), generateTestEmergencyAccessInvite())
+	assert.Equal(suite.T(), err, &gqlerror.Error{Message: "the user is already your emergency contact", Extensions: map[string]interface{}{"code": "DUPLICATE"}}, "Should return expected error")
+	assert.Nil(suite.T(), grant, "Should not return any grant data")
+}
+
+// InviteEmergencyContact should return expected error on failed input validation
+func (suite *schemaResolverTestSuite) TestInviteEmergencyContactValidation() {
+	ctx := graphql.WithResponseContext(context.Background(), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+	input := model.EmergencyAccessInvite{Email: "invalidEmail", AccessType: model.EmergencyAccessTypeView, WaitTimeInDays: 0}
+
+	grant, err := suite.mutationResolver.InviteEmergencyContact(ctx, input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("validation error/s on emergency access invite input"),
+		"Should return expected error when input validation fails",
+	)
+	assert.Nil(suite.T(), grant, "Should not return any grant data")
+}
+
+// AcceptEmergencyAccess should accept the invitation and create the grantee's key pair
+func (suite *schemaResolverTestSuite) TestAcceptEmergencyAccess() {
+	authenticateEmergencyAccessGrantee(suite)
+	invitedGrant := mockutil.DefaultEmergencyAccessGrant()
+	invitedGrant.Status = databaseModel.EmergencyAccessGrantInvited
+	emergencyAccessRepositoryServiceMock := mockutil.DefaultEmergencyAccessRepositoryServiceMock()
+	emergencyAccessRepositoryServiceMock.ExpectedCalls = nil
+	emergencyAccessRepositoryServiceMock.On("FetchGrantDetailsById", mock.Anything, mock.Anything).Return(nil, invitedGrant).Times(1)
+	emergencyAccessRepositoryServiceMock.On("UpdateGrantStatusById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryServiceMock
+	keyPairRepositoryServiceMock := mockutil.DefaultKeyPairRepositoryServiceMock()
+	suite.resolver.keyPairRepository = keyPairRepositoryServiceMock
+
+	grant, err := suite.mutationResolver.AcceptEmergencyAccess(context.Background(), mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Emergency access should be accepted without errors")
+	assert.Equal(suite.T(), grant.Status, model.EmergencyAccessGrantStatusAccepted)
+
+	keyPairRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewKeyPair", &databaseModel.KeyPair{
+		UserId:            mockutil.DefaultGranteeIdAsUint64,
+		PublicKey:         []byte(mockutil.MockedPublicKey),
+		WrappedPrivateKey: []byte(mockutil.MockedWrappedPrivateKey),
+	})
+	emergencyAccessRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateGrantStatusById", databaseModel.EmergencyAccessGrantAccepted, mockutil.DefaultIdAsUint64,
+	)
+}
+
+// AcceptEmergencyAccess should allow only the grantee to accept the invitation
+func (suite *schemaResolverTestSuite) TestAcceptEmergencyAccessAsGrantor() {
+	emergencyAccessRepositoryServiceMock := mockutil.DefaultEmergencyAccessRepositoryServiceMock()
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryServiceMock
+
+	grant, err := suite.mutationResolver.AcceptEmergencyAccess(context.Background(), mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized emergency access request"), "Should return expected error")
+	assert.Nil(suite.T(), grant, "Should not return any grant data")
+	emergencyAccessRepositoryServiceMock.AssertNotCalled(suite.T(), "UpdateGrantStatusById", mock.Anything, mock.Anything)
+}
+
+// RevokeEmergencyAccess should revoke the grant
+func (suite *schemaResolverTestSuite) TestRevokeEmergencyAccess() {
+	emergencyAccessRepositoryServiceMock := mockutil.DefaultEmergencyAccessRepositoryServiceMock()
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryServiceMock
+
+	grant, err := suite.mutationResolver.RevokeEmergencyAccess(context.Background(), mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Emergency access should be revoked without errors")
+	assert.Equal(suite.T(), grant.Status, model.EmergencyAccessGrantStatusRevoked)
+
+	emergencyAccessRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateGrantStatusById", databaseModel.EmergencyAccessGrantRevoked, mockutil.DefaultIdAsUint64,
+	)
+}
+
+// RequestEmergencyAccess should store a pending request and notify the grantor
+func (suite *schemaResolverTestSuite) TestRequestEmergencyAccess() {
+	authenticateEmergencyAccessGrantee(suite)
+	emergencyAccessRepositoryServiceMock := mockutil.DefaultEmergencyAccessRepositoryServiceMock()
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryServiceMock
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+
+	request, err := suite.mutationResolver.RequestEmergencyAccess(context.Background(), mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Emergency access should be requested without errors")
+	assert.Equal(suite.T(), request.GrantID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), request.Status, model.EmergencyAccessRequestStatusPending)
+
+	emergencyAccessRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewRequest", mock.MatchedBy(
+		func(request *databaseModel.EmergencyAccessRequest) bool {
+			return request.AvailableAt.Sub(request.RequestedAt) == 7*24*time.Hour
+		},
+	))
+	mailServiceMock.AssertCalled(suite.T(), "SendSecurityNotification", defaultRecipient, mock.Anything)
+}
+
+// RequestEmergencyAccess should return expected error when access was already requested
+func (suite *schemaResolverTestSuite) TestRequestEmergencyAccessWithPendingRequest() {
+	authenticateEmergencyAccessGrantee(suite)
+	emergencyAccessRepositoryServiceMock := emergencyAccessRepositoryMockWithLatestRequest(databaseModel.EmergencyAccessRequestPending)
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryServiceMock
+
+	request, err := suite.mutationResolver.RequestEmergencyAccess(context.Background(), mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("emergency access was already requested"), "Should return expected error")
+	assert.Nil(suite.T(), request, "Should not return any request data")
+	emergencyAccessRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertNewRequest", mock.Anything)
+}
+
+// ApproveEmergencyAccess should approve the pending request ahead of its waiting period
+func (suite *schemaResolverTestSuite) TestApproveEmergencyAccess() {
+	emergencyAccessApproverMock := mockutil.DefaultEmergencyAccessApproverMock()
+	suite.resolver.emergencyAccessApprover = emergencyAccessApproverMock
+
+	request, err := suite.mutationResolver.ApproveEmergencyAccess(context.Background(), mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Emergency access should be approved without errors")
+	assert.Equal(suite.T(), request.Status, model.EmergencyAccessRequestStatusApproved)
+
+	emergencyAccessApproverMock.AssertCalled(suite.T(), "ApproveRequest", mock.Anything)
+}
+
+// ApproveEmergencyAccess should return expected error when the request got resolved in the meantime
+func (suite *schemaResolverTestSuite) TestApproveEmergencyAccessWithResolvedRequest() {
+	emergencyAccessApproverMock := new(mockutil.EmergencyAccessApproverMock)
+	emergencyAccessApproverMock.On("ApproveRequest", mock.Anything).Return(repository.ErrNotFound).Times(1)
+	suite.resolver.emergencyAccessApprover = emergencyAccessApproverMock
+
+	request, err := suite.mutationResolver.ApproveEmergencyAccess(context.Background(), mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("emergency access request is no longer pending"), "Should return expected error")
+	assert.Nil(suite.T(), request, "Should not return any request data")
+}
+
+// ApproveEmergencyAccess should allow only the grantor to approve requests
+func (suite *schemaResolverTestSuite) TestApproveEmergencyAccessAsGrantee() {
+	authenticateEmergencyAccessGrantee(suite)
+	emergencyAccessApproverMock := mockutil.DefaultEmergencyAccessApproverMock()
+	suite.resolver.emergencyAccessApprover = emergencyAccessApproverMock
+
+	request, err := suite.mutationResolver.ApproveEmergencyAccess(context.Background(), mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized emergency access request"), "Should return expected error")
+	assert.Nil(suite.T(), request, "Should not return any request data")
+	emergencyAccessApproverMock.AssertNotCalled(suite.T(), "ApproveRequest", mock.Anything)
+}
+
+// RejectEmergencyAccess should reject the pending request
+func (suite *schemaResolverTestSuite) TestRejectEmergencyAccess() {
+	emergencyAccessRepositoryServiceMock := mockutil.DefaultEmergencyAccessRepositoryServiceMock()
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryServiceMock
+
+	request, err := suite.mutationResolver.RejectEmergencyAccess(context.Background(), mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Emergency access should be rejected without errors")
+	assert.Equal(suite.T(), request.Status, model.EmergencyAccessRequestStatusRejected)
+
+	emergencyAccessRepositoryServiceMock.AssertCalled(suite.T(), "RejectRequestById", mockutil.DefaultIdAsUint64)
+}
+
+// EmergencyAccessGrants should return grants given by and given to the user
+func (suite *schemaResolverTestSuite) TestEmergencyAccessGrants() {
+	emergencyAccessRepositoryServiceMock := new(mockutil.EmergencyAccessRepositoryServiceMock)
+	emergencyAccessRepositoryServiceMock.On("FetchGrantsDetailsByUserId", mock.Anything, mockutil.DefaultIdAsUint64).Return(
+		nil, databaseModel.EmergencyAccessGrantsDetails{{
+			EmergencyAccessGrant: mockutil.DefaultEmergencyAccessGrant(),
+			GrantorEmail:         mockutil.DefaultEmail,
+			GranteeEmail:         mockutil.DefaultGranteeEmail,
+		}},
+	).Times(1)
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryServiceMock
+
+	grants, err := suite.queryResolver.EmergencyAccessGrants(context.Background())
+	assert.Nil(suite.T(), err, "Emergency access grants should be fetched without errors")
+
+	assert.Equal(suite.T(), len(grants), 1)
+	assert.Equal(suite.T(), grants[0].GranteeEmail, mockutil.DefaultGranteeEmail)
+	assert.Equal(suite.T(), grants[0].Status, model.EmergencyAccessGrantStatusAccepted)
+}
+
+// EmergencyAccessRequests should allow only the grantor and the grantee to see the grant's requests
+func (suite *schemaResolverTestSuite) TestEmergencyAccessRequestsWithoutPermission() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(
+		&authentication.UserAuthentication{UserId: uint64(3)},
+	).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	requests, err := suite.queryResolver.EmergencyAccessRequests(context.Background(), mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized emergency access request"), "Should return expected error")
+	assert.Nil(suite.T(), requests, "Should not return any request data")
+}
+
+// EmergencyVault should decrypt the grantor's passwords with the sealed vault key of the approved request
+func (suite *schemaResolverTestSuite) TestEmergencyVault() {
+	authenticateEmergencyAccessGrantee(suite)
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryMockWithLatestRequest(databaseModel.EmergencyAccessRequestApproved)
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.userRepository = unlimitedUserRepositoryMock()
+	passwordSecurityServiceMock := mockutil.DefaultPasswordSecurityServiceMock()
+	passwordSecurityServiceMock.On("DecryptWithAes", mock.Anything, mock.Anything).Return(mockutil.MockedDecryptedPassword, nil)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	passwords, err := suite.queryResolver.EmergencyVault(context.Background(), mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Emergency vault should be fetched without errors")
+
+	assert.Equal(suite.T(), len(passwords), 2)
+	assert.Equal(suite.T(), passwords[0].Password, mockutil.MockedDecryptedPassword)
+	passwordSecurityServiceMock.AssertCalled(
+		suite.T(), "OpenWithPrivateKey", []byte(mockutil.MockedSealedVaultKey), []byte(mockutil.MockedPublicKey),
+		[]byte(mockutil.MockedWrappedPrivateKey), []byte(mockutil.MockedUserMasterPassword),
+	)
+}
+
+// EmergencyVault should return expected error before the request is approved
+func (suite *schemaResolverTestSuite) TestEmergencyVaultWithoutApproval() {
+	authenticateEmergencyAccessGrantee(suite)
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryMockWithLatestRequest(databaseModel.EmergencyAccessRequestPending)
+
+	passwords, err := suite.queryResolver.EmergencyVault(context.Background(), mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("emergency access is not approved"), "Should return expected error")
+	assert.Nil(suite.T(), passwords, "Should not return any passwords")
+}
+
+// EmergencyVault should return expected error when the grantor changed the master password after the approval
+func (suite *schemaResolverTestSuite) TestEmergencyVaultWithOutdatedApproval() {
+	authenticateEmergencyAccessGrantee(suite)
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryMockWithLatestRequest(databaseModel.EmergencyAccessRequestApproved)
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.userRepository = unlimitedUserRepositoryMock()
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("OpenWithPrivateKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		[]byte("OutdatedMasterPassword"), nil,
+	).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	passwords, err := suite.queryResolver.EmergencyVault(context.Background(), mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("emergency access approval is outdated, request access again"), "Should return expected error",
+	)
+	assert.Nil(suite.T(), passwords, "Should not return any passwords")
+}
+
+// TakeOverEmergencyAccount should set a new master password for the grantor, sign the grantor out and notify the grantor
+func (suite *schemaResolverTestSuite) TestTakeOverEmergencyAccount() {
+	sessionRepositoryServiceMock := mockutil.DefaultSessionRepositoryServiceMock()
+	userRepositoryServiceMock := setUpEmergencyAccountTakeover(suite, sessionRepositoryServiceMock)
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+
+	input := model.EmergencyAccountTakeover{GrantID: mockutil.DefaultIdAsString, NewPassword: "NewPassword"}
+	userWithRecoveryKey, err := suite.mutationResolver.TakeOverEmergencyAccount(context.Background(), input)
+	assert.Nil(suite.T(), err, "Account should be taken over without errors")
+
+	assert.Equal(suite.T(), userWithRecoveryKey.User.ID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), userWithRecoveryKey.RecoveryKey, mockutil.MockedRecoveryKey)
+	userRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateMasterPasswordById",
+		[]byte("NewMasterPassword"), []byte(mockutil.MockedRecoveryWrappedKey), []byte("RewrappedPrivateKey"),
+		mock.Anything, databaseModel.Attachments{mockutil.DefaultAttachment()}, mockutil.DefaultIdAsUint64,
+	)
+	sessionRepositoryServiceMock.AssertCalled(suite.T(), "RevokeSessionsByUserId", mockutil.DefaultIdAsUint64)
+	sessionRepositoryServiceMock.AssertNotCalled(suite.T(), "RevokeSessionsByUserId", mockutil.DefaultGranteeIdAsUint64)
+	mailServiceMock.AssertCalled(suite.T(), "SendSecurityNotification", defaultRecipient, mock.Anything)
+}
+
+// TakeOverEmergencyAccount should fail, leaving the master password as it is, if the grantor's sessions can't be revoked
+func (suite *schemaResolverTestSuite) TestTakeOverEmergencyAccountWithSessionRevocationError() {
+	sessionRepositoryServiceMock := new(mockutil.SessionRepositoryServiceMock)
+	sessionRepositoryServiceMock.On("RevokeSessionsByUserId", mock.Anything).Return(repository.ErrUnavailable).Times(1)
+	setUpEmergencyAccountTakeover(suite, sessionRepositoryServiceMock)
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+
+	input := model.EmergencyAccountTakeover{GrantID: mockutil.DefaultIdAsString, NewPassword: "NewPassword"}
+	userWithRecoveryKey, err := suite.mutationResolver.TakeOverEmergencyAccount(context.Background(), input)
+	assert.Equal(
+		suite.T(), err,
+		&gqlerror.Error{Message: "could not process emergency access", Extensions: map[string]interface{}{"code": "STORAGE_UNAVAILABLE"}},
+		"Should return expected error when the sessions can't be revoked",
+	)
+	assert.Nil(suite.T(), userWithRecoveryKey, "Should not return any user data")
+	mailServiceMock.AssertNotCalled(suite.T(), "SendSecurityNotification", mock.Anything, mock.Anything)
+}
+
+// TakeOverEmergencyAccount should return expected error for grants that allow only viewing the vault
+func (suite *schemaResolverTestSuite) TestTakeOverEmergencyAccountWithViewAccess() {
+	authenticateEmergencyAccessGrantee(suite)
+	viewGrant := mockutil.DefaultEmergencyAccessGrant()
+	viewGrant.AccessType = databaseModel.EmergencyAccessView
+	emergencyAccessRepositoryServiceMock := new(mockutil.EmergencyAccessRepositoryServiceMock)
+	emergencyAccessRepositoryServiceMock.On("FetchGrantDetailsById", mock.Anything, mock.Anything).Return(nil, viewGrant).Times(1)
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryServiceMock
+	userRepositoryServiceMock := mockutil.DefaultUserRepositoryServiceMock()
+	suite.resolver.userRepository = userRepositoryServiceMock
+
+	input := model.EmergencyAccountTakeover{GrantID: mockutil.DefaultIdAsString, NewPassword: "NewPassword"}
+	userWithRecoveryKey, err := suite.mutationResolver.TakeOverEmergencyAccount(context.Background(), input)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("emergency access grant doesn't allow account takeover"), "Should return expected error")
+	assert.Nil(suite.T(), userWithRecoveryKey, "Should not return any user data")
+	userRepositoryServiceMock.AssertNotCalled(
+		suite.T(), "UpdateMasterPasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+	)
+}
+
+type granteeUserRepositoryServiceMock struct {
+	mockutil.UserRepositoryServiceMock
+}
+
+func (service *granteeUserRepositoryServiceMock) FetchByEmail(
+	ctx context.Context, user *databaseModel.User, email string, queryFields []string,
+) error {
+	err := service.UserRepositoryServiceMock.FetchByEmail(ctx, user, email, queryFields)
+	user.Id = mockutil.DefaultGranteeIdAsUint64
+	return err
+}
+
+func authenticateEmergencyAccessGrantee(suite *schemaResolverTestSuite) {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(
+		&authentication.UserAuthentication{UserId: mockutil.DefaultGranteeIdAsUint64},
+	).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+}
+
+func emergencyAccessRepositoryMockWithLatestRequest(status string) *mockutil.EmergencyAccessRepositoryServiceMock {
+	request := mockutil.DefaultEmergencyAccessRequest()
+	request.Status = status
+	if status == databaseModel.EmergencyAccessRequestApproved {
+		request.SealedVaultKey = []byte(mockutil.MockedSealedVaultKey)
+	}
+
+	serviceMock := mockutil.DefaultEmergencyAccessRepositoryServiceMock()
+	serviceMock.ExpectedCalls = nil
+	serviceMock.On("FetchGrantDetailsById", mock.Anything, mock.Anything).Return(nil, mockutil.DefaultEmergencyAccessGrant()).Times(1)
+	serviceMock.On("FetchRequestsByGrantId", mock.Anything, mock.Anything).Return(nil, databaseModel.EmergencyAccessRequests{request}).Times(1)
+	serviceMock.On("InsertNewRequest", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+
+	return serviceMock
+}
+
+func keyPairRepositoryMockWithKeyPair() *mockutil.KeyPairRepositoryServiceMock {
+	serviceMock := new(mockutil.KeyPairRepositoryServiceMock)
+	serviceMock.On("FetchKeyPairByUserId", mock.Anything, mock.Anything).Return(nil)
+
+	return serviceMock
+}
+
+// unlimitedUserRepositoryMock allows fetching both the grantor's and the grantee's master password
+func unlimitedUserRepositoryMock() *mockutil.UserRepositoryServiceMock {
+	serviceMock := new(mockutil.UserRepositoryServiceMock)
+	serviceMock.On("FetchMasterPasswordByUserId", mock.Anything, mock.Anything).Return(nil)
+
+	return serviceMock
+}
+
+func generateTestEmergencyAccessInvite() model.EmergencyAccessInvite {
+	return model.EmergencyAccessInvite{Email: mockutil.DefaultGranteeEmail, AccessType: model.EmergencyAccessTypeTakeover, WaitTimeInDays: 7}
+}
+
+// setUpEmergencyAccountTakeover sets up a takeover of the default grantor's account by the authenticated grantee,
+// changing the master password with the given session repository
+func setUpEmergencyAccountTakeover(
+	suite *schemaResolverTestSuite, sessionRepository repository.SessionRepository,
+) *mockutil.UserRepositoryServiceMock {
+	authenticateEmergencyAccessGrantee(suite)
+	suite.resolver.emergencyAccessRepository = emergencyAccessRepositoryMockWithLatestRequest(databaseModel.EmergencyAccessRequestApproved)
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	userRepositoryServiceMock := unlimitedUserRepositoryMock()
+	userRepositoryServiceMock.On("FetchById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	userRepositoryServiceMock.On(
+		"UpdateMasterPasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+	).Return(nil).Times(1)
+	suite.resolver.userRepository = userRepositoryServiceMock
+	suite.resolver.unitOfWork = unitOfWorkMockForMasterPasswordChange(
+		userRepositoryServiceMock, keyPairRepositoryMockWithKeyPair(), sessionRepository,
+	)
+	passwordSecurityServiceMock := passwordSecurityMockForAccountRecovery()
+	passwordSecurityServiceMock.On("OpenWithPrivateKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		[]byte(mockutil.MockedUserMasterPassword), nil,
+	).Times(1)
+	passwordSecurityServiceMock.On("RewrapPrivateKey", mock.Anything, mock.Anything, mock.Anything).Return(
+		[]byte("RewrappedPrivateKey"), nil,
+	).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	return userRepositoryServiceMock
+}
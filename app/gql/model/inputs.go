@@ -7,13 +7,77 @@ type NewUser struct {
 }
 
 type NewPassword struct {
-	UserID   string `json:"userId" validate:"required"`
-	Name     string `json:"name" validate:"required,min=1,max=64"`
-	Password string `json:"password" validate:"required"`
+	UserID   string  `json:"userId" validate:"required"`
+	Name     string  `json:"name" validate:"required,min=1,max=64"`
+	Password string  `json:"password" validate:"required"`
+	TotpURI  *string `json:"totpUri" validate:"omitempty,max=2048"`
 }
 
 type UpdatePassword struct {
-	ID       string `json:"id" validate:"required"`
-	Name     string `json:"name" validate:"required,min=1,max=64"`
-	Password string `json:"password" validate:"required"`
+	ID       string  `json:"id" validate:"required"`
+	Name     string  `json:"name" validate:"required,min=1,max=64"`
+	Password string  `json:"password" validate:"required"`
+	Version  int     `json:"version" validate:"min=1"`
+	TotpURI  *string `json:"totpUri" validate:"omitempty,max=2048"`
+}
+
+type WebauthnRegistration struct {
+	Name              string  `json:"name" validate:"required,min=1,max=64"`
+	ClientDataJSON    string  `json:"clientDataJson" validate:"required"`
+	AttestationObject string  `json:"attestationObject" validate:"required"`
+	PrfWrappedKey     *string `json:"prfWrappedKey"`
+}
+
+type WebauthnAssertion struct {
+	CredentialID      string `json:"credentialId" validate:"required"`
+	ClientDataJSON    string `json:"clientDataJson" validate:"required"`
+	AuthenticatorData string `json:"authenticatorData" validate:"required"`
+	Signature         string `json:"signature" validate:"required"`
+}
+
+type AccountRecovery struct {
+	Email       string `json:"email" validate:"required,email"`
+	RecoveryKey string `json:"recoveryKey" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=8,max=64"`
+}
+
+type EmergencyAccessInvite struct {
+	Email          string              `json:"email" validate:"required,email"`
+	AccessType     EmergencyAccessType `json:"accessType" validate:"required"`
+	WaitTimeInDays int                 `json:"waitTimeInDays" validate:"min=1,max=90"`
+}
+
+type EmergencyAccountTakeover struct {
+	GrantID     string `json:"grantId" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required,min=8,max=64"`
+}
+
+type NewOrganization struct {
+	Name string `json:"name" validate:"required,min=1,max=64"`
+}
+
+type OrganizationInvite struct {
+	OrganizationID string           `json:"organizationId" validate:"required"`
+	Email          string           `json:"email" validate:"required,email"`
+	Role           OrganizationRole `json:"role" validate:"required"`
+}
+
+type UpdateSharedEntry struct {
+	ShareID  string  `json:"shareId" validate:"required"`
+	Name     string  `json:"name" validate:"required,min=1,max=64"`
+	Password string  `json:"password" validate:"required"`
+	TotpURI  *string `json:"totpUri" validate:"omitempty,max=2048"`
+}
+
+type NewSend struct {
+	Name           string  `json:"name" validate:"required,min=1,max=64"`
+	Secret         string  `json:"secret" validate:"required,max=4096"`
+	ExpiresInHours int     `json:"expiresInHours" validate:"min=1,max=720"`
+	MaxViews       int     `json:"maxViews" validate:"min=1,max=100"`
+	Passphrase     *string `json:"passphrase" validate:"omitempty,max=64"`
+}
+
+type NewWebhook struct {
+	URL        string           `json:"url" validate:"required,url,max=2048"`
+	EventTypes []AuditEventType `json:"eventTypes" validate:"required,min=1,dive,required"`
 }
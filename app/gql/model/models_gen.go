@@ -2,25 +2,797 @@
 
 package model
 
+import (
+	"fmt"
+	"io"
+	"strconv"
+)
+
+type AdminAuditEntry struct {
+	ID          string      `json:"id"`
+	AdminEmail  string      `json:"adminEmail"`
+	Action      AdminAction `json:"action"`
+	TargetEmail string      `json:"targetEmail"`
+	Details     *string     `json:"details"`
+	CreatedAt   string      `json:"createdAt"`
+}
+
+type AdminUser struct {
+	ID            string   `json:"id"`
+	Email         string   `json:"email"`
+	Username      string   `json:"username"`
+	EmailVerified bool     `json:"emailVerified"`
+	Role          UserRole `json:"role"`
+	Disabled      bool     `json:"disabled"`
+}
+
+type Attachment struct {
+	ID          string `json:"id"`
+	PasswordID  string `json:"passwordId"`
+	Name        string `json:"name"`
+	ContentType string `json:"contentType"`
+	Size        int    `json:"size"`
+	CreatedAt   string `json:"createdAt"`
+}
+
+type AuditEvent struct {
+	ID        string         `json:"id"`
+	Sequence  int            `json:"sequence"`
+	UserID    *string        `json:"userId"`
+	Type      AuditEventType `json:"type"`
+	IPAddress string         `json:"ipAddress"`
+	UserAgent string         `json:"userAgent"`
+	Details   *string        `json:"details"`
+	CreatedAt string         `json:"createdAt"`
+}
+
+type AuditLogFilter struct {
+	UserID *string          `json:"userId"`
+	Types  []AuditEventType `json:"types"`
+	From   *string          `json:"from"`
+	To     *string          `json:"to"`
+}
+
+type CreatedSend struct {
+	Send *Send  `json:"send"`
+	Link string `json:"link"`
+}
+
+type CreatedWebhook struct {
+	Webhook *Webhook `json:"webhook"`
+	Secret  string   `json:"secret"`
+}
+
+type EmergencyAccessGrant struct {
+	ID             string                     `json:"id"`
+	GrantorEmail   string                     `json:"grantorEmail"`
+	GranteeEmail   string                     `json:"granteeEmail"`
+	AccessType     EmergencyAccessType        `json:"accessType"`
+	WaitTimeInDays int                        `json:"waitTimeInDays"`
+	Status         EmergencyAccessGrantStatus `json:"status"`
+}
+
+type EmergencyAccessRequest struct {
+	ID          string                       `json:"id"`
+	GrantID     string                       `json:"grantId"`
+	Status      EmergencyAccessRequestStatus `json:"status"`
+	RequestedAt string                       `json:"requestedAt"`
+	AvailableAt string                       `json:"availableAt"`
+}
+
+type EntryShare struct {
+	ID             string          `json:"id"`
+	EntryID        string          `json:"entryId"`
+	RecipientEmail string          `json:"recipientEmail"`
+	Permission     SharePermission `json:"permission"`
+}
+
+type OneTimeCode struct {
+	Code             string `json:"code"`
+	SecondsRemaining *int   `json:"secondsRemaining"`
+}
+
+type Organization struct {
+	ID     string                   `json:"id"`
+	Name   string                   `json:"name"`
+	Role   OrganizationRole         `json:"role"`
+	Status OrganizationMemberStatus `json:"status"`
+}
+
+type OrganizationMember struct {
+	ID     string                   `json:"id"`
+	Email  string                   `json:"email"`
+	Role   OrganizationRole         `json:"role"`
+	Status OrganizationMemberStatus `json:"status"`
+}
+
 type Password struct {
+	ID             string       `json:"id"`
+	UserID         string       `json:"userId"`
+	OrganizationID *string      `json:"organizationId"`
+	Name           string       `json:"name"`
+	Password       string       `json:"password"`
+	Revision       int          `json:"revision"`
+	Version        int          `json:"version"`
+	TotpURI        *string      `json:"totpUri"`
+	CurrentCode    *OneTimeCode `json:"currentCode"`
+}
+
+type PasswordTombstone struct {
 	ID       string `json:"id"`
-	UserID   string `json:"userId"`
-	Name     string `json:"name"`
-	Password string `json:"password"`
+	Revision int    `json:"revision"`
+}
+
+type Send struct {
+	ID            string `json:"id"`
+	Name          string `json:"name"`
+	HasPassphrase bool   `json:"hasPassphrase"`
+	MaxViews      int    `json:"maxViews"`
+	ViewCount     int    `json:"viewCount"`
+	ExpiresAt     string `json:"expiresAt"`
+	CreatedAt     string `json:"createdAt"`
+}
+
+type Session struct {
+	ID         string        `json:"id"`
+	DeviceName string        `json:"deviceName"`
+	UserAgent  string        `json:"userAgent"`
+	IPAddress  string        `json:"ipAddress"`
+	Status     SessionStatus `json:"status"`
+	CreatedAt  string        `json:"createdAt"`
+	LastSeenAt string        `json:"lastSeenAt"`
+	Current    bool          `json:"current"`
+}
+
+type SharedEntry struct {
+	ID          string          `json:"id"`
+	EntryID     string          `json:"entryId"`
+	OwnerEmail  string          `json:"ownerEmail"`
+	Permission  SharePermission `json:"permission"`
+	Name        string          `json:"name"`
+	Password    string          `json:"password"`
+	TotpURI     *string         `json:"totpUri"`
+	CurrentCode *OneTimeCode    `json:"currentCode"`
+}
+
+type StorageUsage struct {
+	UsedBytes  int `json:"usedBytes"`
+	QuotaBytes int `json:"quotaBytes"`
+}
+
+type UsageStatistics struct {
+	Users          int `json:"users"`
+	DisabledUsers  int `json:"disabledUsers"`
+	Admins         int `json:"admins"`
+	Entries        int `json:"entries"`
+	Organizations  int `json:"organizations"`
+	ActiveSessions int `json:"activeSessions"`
+	ActiveSends    int `json:"activeSends"`
 }
 
 type User struct {
-	ID       string `json:"id"`
-	Email    string `json:"email"`
-	Username string `json:"username"`
+	ID            string `json:"id"`
+	Email         string `json:"email"`
+	Username      string `json:"username"`
+	EmailVerified bool   `json:"emailVerified"`
 }
 
 type UserSignIn struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email             string             `json:"email"`
+	Password          string             `json:"password"`
+	WebauthnAssertion *WebauthnAssertion `json:"webauthnAssertion"`
+	DeviceName        *string            `json:"deviceName"`
+}
+
+type UserWithRecoveryKey struct {
+	User        *User  `json:"user"`
+	RecoveryKey string `json:"recoveryKey"`
 }
 
 type UserWithToken struct {
-	User  *User  `json:"user"`
-	Token string `json:"token"`
+	User          *User   `json:"user"`
+	Token         string  `json:"token"`
+	PrfWrappedKey *string `json:"prfWrappedKey"`
+}
+
+type VaultChange struct {
+	Action     VaultChangeAction `json:"action"`
+	PasswordID string            `json:"passwordId"`
+	ChangedAt  string            `json:"changedAt"`
+}
+
+type VaultDelta struct {
+	Revision         int                  `json:"revision"`
+	Passwords        []*Password          `json:"passwords"`
+	DeletedPasswords []*PasswordTombstone `json:"deletedPasswords"`
+}
+
+type WebauthnCreationOptions struct {
+	Challenge          string                `json:"challenge"`
+	RelyingParty       *WebauthnRelyingParty `json:"relyingParty"`
+	User               *WebauthnUser         `json:"user"`
+	Algorithms         []int                 `json:"algorithms"`
+	Timeout            int                   `json:"timeout"`
+	ExcludeCredentials []string              `json:"excludeCredentials"`
+}
+
+type WebauthnCredential struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	CreatedAt string `json:"createdAt"`
+}
+
+type WebauthnRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type WebauthnRequestOptions struct {
+	Challenge        string   `json:"challenge"`
+	RelyingPartyID   string   `json:"relyingPartyId"`
+	Timeout          int      `json:"timeout"`
+	AllowCredentials []string `json:"allowCredentials"`
+}
+
+type WebauthnUser struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+type Webhook struct {
+	ID         string           `json:"id"`
+	URL        string           `json:"url"`
+	EventTypes []AuditEventType `json:"eventTypes"`
+	CreatedAt  string           `json:"createdAt"`
+}
+
+type WebhookDeadLetter struct {
+	ID         string         `json:"id"`
+	DeliveryID string         `json:"deliveryId"`
+	WebhookID  string         `json:"webhookId"`
+	EventType  AuditEventType `json:"eventType"`
+	LastError  *string        `json:"lastError"`
+	FailedAt   string         `json:"failedAt"`
+}
+
+type WebhookDelivery struct {
+	ID             string                `json:"id"`
+	WebhookID      string                `json:"webhookId"`
+	EventType      AuditEventType        `json:"eventType"`
+	Payload        string                `json:"payload"`
+	Status         WebhookDeliveryStatus `json:"status"`
+	Attempts       int                   `json:"attempts"`
+	ResponseStatus *int                  `json:"responseStatus"`
+	LastError      *string               `json:"lastError"`
+	NextAttemptAt  string                `json:"nextAttemptAt"`
+	CreatedAt      string                `json:"createdAt"`
+}
+
+type AdminAction string
+
+const (
+	AdminActionDisableUser  AdminAction = "DISABLE_USER"
+	AdminActionEnableUser   AdminAction = "ENABLE_USER"
+	AdminActionForceSignOut AdminAction = "FORCE_SIGN_OUT"
+	AdminActionChangeRole   AdminAction = "CHANGE_ROLE"
+)
+
+var AllAdminAction = []AdminAction{
+	AdminActionDisableUser,
+	AdminActionEnableUser,
+	AdminActionForceSignOut,
+	AdminActionChangeRole,
+}
+
+func (e AdminAction) IsValid() bool {
+	switch e {
+	case AdminActionDisableUser, AdminActionEnableUser, AdminActionForceSignOut, AdminActionChangeRole:
+		return true
+	}
+	return false
+}
+
+func (e AdminAction) String() string {
+	return string(e)
+}
+
+func (e *AdminAction) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AdminAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AdminAction", str)
+	}
+	return nil
+}
+
+func (e AdminAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type AuditEventType string
+
+const (
+	AuditEventTypeSignUp                 AuditEventType = "SIGN_UP"
+	AuditEventTypeSignIn                 AuditEventType = "SIGN_IN"
+	AuditEventTypeSignInFailed           AuditEventType = "SIGN_IN_FAILED"
+	AuditEventTypeAuthenticationRejected AuditEventType = "AUTHENTICATION_REJECTED"
+	AuditEventTypePasswordCreated        AuditEventType = "PASSWORD_CREATED"
+	AuditEventTypePasswordsViewed        AuditEventType = "PASSWORDS_VIEWED"
+	AuditEventTypePasswordUpdated        AuditEventType = "PASSWORD_UPDATED"
+	AuditEventTypePasswordDeleted        AuditEventType = "PASSWORD_DELETED"
+	AuditEventTypeUserDisabled           AuditEventType = "USER_DISABLED"
+	AuditEventTypeUserEnabled            AuditEventType = "USER_ENABLED"
+	AuditEventTypeUserSignedOut          AuditEventType = "USER_SIGNED_OUT"
+	AuditEventTypeUserRoleChanged        AuditEventType = "USER_ROLE_CHANGED"
+)
+
+var AllAuditEventType = []AuditEventType{
+	AuditEventTypeSignUp,
+	AuditEventTypeSignIn,
+	AuditEventTypeSignInFailed,
+	AuditEventTypeAuthenticationRejected,
+	AuditEventTypePasswordCreated,
+	AuditEventTypePasswordsViewed,
+	AuditEventTypePasswordUpdated,
+	AuditEventTypePasswordDeleted,
+	AuditEventTypeUserDisabled,
+	AuditEventTypeUserEnabled,
+	AuditEventTypeUserSignedOut,
+	AuditEventTypeUserRoleChanged,
+}
+
+func (e AuditEventType) IsValid() bool {
+	switch e {
+	case AuditEventTypeSignUp, AuditEventTypeSignIn, AuditEventTypeSignInFailed, AuditEventTypeAuthenticationRejected, AuditEventTypePasswordCreated, AuditEventTypePasswordsViewed, AuditEventTypePasswordUpdated, AuditEventTypePasswordDeleted, AuditEventTypeUserDisabled, AuditEventTypeUserEnabled, AuditEventTypeUserSignedOut, AuditEventTypeUserRoleChanged:
+		return true
+	}
+	return false
+}
+
+func (e AuditEventType) String() string {
+	return string(e)
+}
+
+func (e *AuditEventType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = AuditEventType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid AuditEventType", str)
+	}
+	return nil
+}
+
+func (e AuditEventType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type EmergencyAccessGrantStatus string
+
+const (
+	EmergencyAccessGrantStatusInvited  EmergencyAccessGrantStatus = "INVITED"
+	EmergencyAccessGrantStatusAccepted EmergencyAccessGrantStatus = "ACCEPTED"
+	EmergencyAccessGrantStatusRevoked  EmergencyAccessGrantStatus = "REVOKED"
+)
+
+var AllEmergencyAccessGrantStatus = []EmergencyAccessGrantStatus{
+	EmergencyAccessGrantStatusInvited,
+	EmergencyAccessGrantStatusAccepted,
+	EmergencyAccessGrantStatusRevoked,
+}
+
+func (e EmergencyAccessGrantStatus) IsValid() bool {
+	switch e {
+	case EmergencyAccessGrantStatusInvited, EmergencyAccessGrantStatusAccepted, EmergencyAccessGrantStatusRevoked:
+		return true
+	}
+	return false
+}
+
+func (e EmergencyAccessGrantStatus) String() string {
+	return string(e)
+}
+
+func (e *EmergencyAccessGrantStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EmergencyAccessGrantStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EmergencyAccessGrantStatus", str)
+	}
+	return nil
+}
+
+func (e EmergencyAccessGrantStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type EmergencyAccessRequestStatus string
+
+const (
+	EmergencyAccessRequestStatusPending  EmergencyAccessRequestStatus = "PENDING"
+	EmergencyAccessRequestStatusApproved EmergencyAccessRequestStatus = "APPROVED"
+	EmergencyAccessRequestStatusRejected EmergencyAccessRequestStatus = "REJECTED"
+)
+
+var AllEmergencyAccessRequestStatus = []EmergencyAccessRequestStatus{
+	EmergencyAccessRequestStatusPending,
+	EmergencyAccessRequestStatusApproved,
+	EmergencyAccessRequestStatusRejected,
+}
+
+func (e EmergencyAccessRequestStatus) IsValid() bool {
+	switch e {
+	case EmergencyAccessRequestStatusPending, EmergencyAccessRequestStatusApproved, EmergencyAccessRequestStatusRejected:
+		return true
+	}
+	return false
+}
+
+func (e EmergencyAccessRequestStatus) String() string {
+	return string(e)
+}
+
+func (e *EmergencyAccessRequestStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EmergencyAccessRequestStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EmergencyAccessRequestStatus", str)
+	}
+	return nil
+}
+
+func (e EmergencyAccessRequestStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type EmergencyAccessType string
+
+const (
+	EmergencyAccessTypeView     EmergencyAccessType = "VIEW"
+	EmergencyAccessTypeTakeover EmergencyAccessType = "TAKEOVER"
+)
+
+var AllEmergencyAccessType = []EmergencyAccessType{
+	EmergencyAccessTypeView,
+	EmergencyAccessTypeTakeover,
+}
+
+func (e EmergencyAccessType) IsValid() bool {
+	switch e {
+	case EmergencyAccessTypeView, EmergencyAccessTypeTakeover:
+		return true
+	}
+	return false
+}
+
+func (e EmergencyAccessType) String() string {
+	return string(e)
+}
+
+func (e *EmergencyAccessType) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = EmergencyAccessType(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid EmergencyAccessType", str)
+	}
+	return nil
+}
+
+func (e EmergencyAccessType) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrganizationMemberStatus string
+
+const (
+	OrganizationMemberStatusInvited  OrganizationMemberStatus = "INVITED"
+	OrganizationMemberStatusAccepted OrganizationMemberStatus = "ACCEPTED"
+)
+
+var AllOrganizationMemberStatus = []OrganizationMemberStatus{
+	OrganizationMemberStatusInvited,
+	OrganizationMemberStatusAccepted,
+}
+
+func (e OrganizationMemberStatus) IsValid() bool {
+	switch e {
+	case OrganizationMemberStatusInvited, OrganizationMemberStatusAccepted:
+		return true
+	}
+	return false
+}
+
+func (e OrganizationMemberStatus) String() string {
+	return string(e)
+}
+
+func (e *OrganizationMemberStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrganizationMemberStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrganizationMemberStatus", str)
+	}
+	return nil
+}
+
+func (e OrganizationMemberStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type OrganizationRole string
+
+const (
+	OrganizationRoleOwner  OrganizationRole = "OWNER"
+	OrganizationRoleAdmin  OrganizationRole = "ADMIN"
+	OrganizationRoleMember OrganizationRole = "MEMBER"
+)
+
+var AllOrganizationRole = []OrganizationRole{
+	OrganizationRoleOwner,
+	OrganizationRoleAdmin,
+	OrganizationRoleMember,
+}
+
+func (e OrganizationRole) IsValid() bool {
+	switch e {
+	case OrganizationRoleOwner, OrganizationRoleAdmin, OrganizationRoleMember:
+		return true
+	}
+	return false
+}
+
+func (e OrganizationRole) String() string {
+	return string(e)
+}
+
+func (e *OrganizationRole) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = OrganizationRole(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid OrganizationRole", str)
+	}
+	return nil
+}
+
+func (e OrganizationRole) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SessionStatus string
+
+const (
+	SessionStatusPendingApproval SessionStatus = "PENDING_APPROVAL"
+	SessionStatusApproved        SessionStatus = "APPROVED"
+	SessionStatusActive          SessionStatus = "ACTIVE"
+)
+
+var AllSessionStatus = []SessionStatus{
+	SessionStatusPendingApproval,
+	SessionStatusApproved,
+	SessionStatusActive,
+}
+
+func (e SessionStatus) IsValid() bool {
+	switch e {
+	case SessionStatusPendingApproval, SessionStatusApproved, SessionStatusActive:
+		return true
+	}
+	return false
+}
+
+func (e SessionStatus) String() string {
+	return string(e)
+}
+
+func (e *SessionStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SessionStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SessionStatus", str)
+	}
+	return nil
+}
+
+func (e SessionStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type SharePermission string
+
+const (
+	SharePermissionRead  SharePermission = "READ"
+	SharePermissionWrite SharePermission = "WRITE"
+)
+
+var AllSharePermission = []SharePermission{
+	SharePermissionRead,
+	SharePermissionWrite,
+}
+
+func (e SharePermission) IsValid() bool {
+	switch e {
+	case SharePermissionRead, SharePermissionWrite:
+		return true
+	}
+	return false
+}
+
+func (e SharePermission) String() string {
+	return string(e)
+}
+
+func (e *SharePermission) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = SharePermission(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid SharePermission", str)
+	}
+	return nil
+}
+
+func (e SharePermission) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type UserRole string
+
+const (
+	UserRoleUser  UserRole = "USER"
+	UserRoleAdmin UserRole = "ADMIN"
+)
+
+var AllUserRole = []UserRole{
+	UserRoleUser,
+	UserRoleAdmin,
+}
+
+func (e UserRole) IsValid() bool {
+	switch e {
+	case UserRoleUser, UserRoleAdmin:
+		return true
+	}
+	return false
+}
+
+func (e UserRole) String() string {
+	return string(e)
+}
+
+func (e *UserRole) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = UserRole(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid UserRole", str)
+	}
+	return nil
+}
+
+func (e UserRole) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type VaultChangeAction string
+
+const (
+	VaultChangeActionCreated VaultChangeAction = "CREATED"
+	VaultChangeActionUpdated VaultChangeAction = "UPDATED"
+	VaultChangeActionDeleted VaultChangeAction = "DELETED"
+)
+
+var AllVaultChangeAction = []VaultChangeAction{
+	VaultChangeActionCreated,
+	VaultChangeActionUpdated,
+	VaultChangeActionDeleted,
+}
+
+func (e VaultChangeAction) IsValid() bool {
+	switch e {
+	case VaultChangeActionCreated, VaultChangeActionUpdated, VaultChangeActionDeleted:
+		return true
+	}
+	return false
+}
+
+func (e VaultChangeAction) String() string {
+	return string(e)
+}
+
+func (e *VaultChangeAction) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = VaultChangeAction(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid VaultChangeAction", str)
+	}
+	return nil
+}
+
+func (e VaultChangeAction) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryStatusPending   WebhookDeliveryStatus = "PENDING"
+	WebhookDeliveryStatusDelivered WebhookDeliveryStatus = "DELIVERED"
+	WebhookDeliveryStatusDead      WebhookDeliveryStatus = "DEAD"
+)
+
+var AllWebhookDeliveryStatus = []WebhookDeliveryStatus{
+	WebhookDeliveryStatusPending,
+	WebhookDeliveryStatusDelivered,
+	WebhookDeliveryStatusDead,
+}
+
+func (e WebhookDeliveryStatus) IsValid() bool {
+	switch e {
+	case WebhookDeliveryStatusPending, WebhookDeliveryStatusDelivered, WebhookDeliveryStatusDead:
+		return true
+	}
+	return false
+}
+
+func (e WebhookDeliveryStatus) String() string {
+	return string(e)
+}
+
+func (e *WebhookDeliveryStatus) UnmarshalGQL(v interface{}) error {
+	str, ok := v.(string)
+	if !ok {
+		return fmt.Errorf("enums must be strings")
+	}
+
+	*e = WebhookDeliveryStatus(str)
+	if !e.IsValid() {
+		return fmt.Errorf("%s is not a valid WebhookDeliveryStatus", str)
+	}
+	return nil
+}
+
+func (e WebhookDeliveryStatus) MarshalGQL(w io.Writer) {
+	fmt.Fprint(w, strconv.Quote(e.String()))
 }
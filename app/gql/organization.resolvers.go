@@ -0,0 +1,442 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/eventbus"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) CreateOrganization(ctx context.Context, input model.NewOrganization) (*model.Organization, error) {
+	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
+	if validationErrors != nil {
+		return nil, gqlerror.Errorf("validation error/s on organization input")
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(organizationAuthenticationErrorMessage)
+	}
+
+	organizationKey, err := security.GenerateOrganizationKey()
+	if err != nil {
+		log.Printf("Error while generating organization key: %s", err)
+		return nil, gqlerror.Errorf(organizationErrorMessage)
+	}
+
+	// A key pair created for the owner is only kept together with the organization
+	organization, owner := databaseModel.Organization{}, databaseModel.OrganizationMembership{}
+	err = r.unitOfWork.Execute(func(repositories *repository.Repositories) error {
+		keyPair, err := r.fetchOrCreateKeyPair(repositories.KeyPair, repositories.User, userAuthentication.UserId)
+		if err != nil {
+			return err
+		}
+		sealedOrganizationKey, err := r.passwordSecurityService.SealForPublicKey(organizationKey, keyPair.PublicKey)
+		if err != nil {
+			return err
+		}
+
+		organization = databaseModel.Organization{Name: input.Name}
+		owner = databaseModel.OrganizationMembership{
+			UserId:                userAuthentication.UserId,
+			Role:                  databaseModel.OrganizationOwner,
+			Status:                databaseModel.OrganizationMemberAccepted,
+			SealedOrganizationKey: sealedOrganizationKey,
+		}
+		return repositories.Organization.InsertNewOrganization(&organization, &owner)
+	})
+	if err != nil {
+		log.Printf("Error while storing organization: %s", err)
+		return nil, repositoryError(err, organizationErrorMessage)
+	}
+
+	return toOrganization(&databaseModel.OrganizationMembershipDetails{OrganizationMembership: owner, OrganizationName: organization.Name}), nil
+}
+
+func (r *mutationResolver) InviteOrganizationMember(ctx context.Context, input model.OrganizationInvite) (*model.OrganizationMember, error) {
+	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
+	if validationErrors != nil {
+		return nil, gqlerror.Errorf("validation error/s on organization invite input")
+	}
+
+	inviter, err := r.fetchAcceptedOrganizationMembership(ctx, input.OrganizationID)
+	if err != nil {
+		return nil, err
+	}
+	role := strings.ToLower(input.Role.String())
+	if !isOrganizationManager(inviter.Role) || role == databaseModel.OrganizationOwner ||
+		(inviter.Role == databaseModel.OrganizationAdmin && role != databaseModel.OrganizationMember) {
+		return nil, gqlerror.Errorf(organizationRoleErrorMessage)
+	}
+
+	invitee := databaseModel.User{}
+	err = r.userRepository.FetchByEmail(ctx, &invitee, input.Email, []string{"id", "email"})
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, repositoryError(err, queryNonExistingEmailErrorMessage)
+		}
+		log.Printf("Error while fetching user by email: %s", err)
+		return nil, repositoryError(err, organizationErrorMessage)
+	}
+
+	organizationKey, err := r.openOrganizationKey(&inviter.OrganizationMembership)
+	if err != nil {
+		return nil, err
+	}
+	sealedOrganizationKey, err := r.sealOrganizationKey(organizationKey, invitee.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	membership := databaseModel.OrganizationMembership{
+		OrganizationId:        inviter.OrganizationId,
+		UserId:                invitee.Id,
+		Role:                  role,
+		Status:                databaseModel.OrganizationMemberInvited,
+		SealedOrganizationKey: sealedOrganizationKey,
+	}
+	insertResult, err := r.organizationRepository.InsertNewMembership(&membership, inviter.OrganizationKeyVersion)
+	if err != nil {
+		if err == repository.ErrDuplicate {
+			return nil, repositoryError(err, organizationExistingMemberErrorMessage)
+		}
+		log.Printf("Error while storing organization membership: %s", err)
+		return nil, organizationRepositoryError(err, organizationErrorMessage)
+	}
+	membership.Id = uint64(insertResult.ID().(int64))
+
+	return toOrganizationMember(&membership, invitee.Email), nil
+}
+
+func (r *mutationResolver) AcceptOrganizationInvite(ctx context.Context, organizationID string) (*model.Organization, error) {
+	membership, err := r.fetchOwnOrganizationMembership(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if membership.Status != databaseModel.OrganizationMemberInvited {
+		return nil, gqlerror.Errorf(organizationInviteStatusErrorMessage)
+	}
+
+	err = r.organizationRepository.UpdateMembershipStatusById(databaseModel.OrganizationMemberAccepted, membership.Id)
+	if err != nil {
+		log.Printf("Error while updating organization membership: %s", err)
+		return nil, repositoryError(err, organizationErrorMessage)
+	}
+
+	acceptedMembership := databaseModel.OrganizationMembershipDetails{}
+	err = r.organizationRepository.FetchMembershipDetailsById(&acceptedMembership, membership.Id)
+	if err != nil {
+		log.Printf("Error while fetching organization membership: %s", err)
+		return nil, repositoryError(err, organizationErrorMessage)
+	}
+
+	return toOrganization(&acceptedMembership), nil
+}
+
+func (r *mutationResolver) RemoveOrganizationMember(ctx context.Context, memberID string) (bool, error) {
+	memberId, err := strconv.ParseUint(memberID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting organization member id to uint64: %s", err)
+		return false, gqlerror.Errorf(organizationErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return false, gqlerror.Errorf(organizationAuthenticationErrorMessage)
+	}
+
+	removedMembership := databaseModel.OrganizationMembershipDetails{}
+	err = r.organizationRepository.FetchMembershipDetailsById(&removedMembership, memberId)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return false, gqlerror.Errorf(organizationAuthenticationErrorMessage)
+		}
+		log.Printf("Error while fetching organization membership: %s", err)
+		return false, repositoryError(err, organizationErrorMessage)
+	}
+	actingMembership, err := r.fetchOrganizationMembershipOfUser(removedMembership.OrganizationId, userAuthentication.UserId)
+	if err != nil {
+		return false, err
+	}
+	if removedMembership.Role == databaseModel.OrganizationOwner {
+		return false, gqlerror.Errorf(organizationOwnerRemovalErrorMessage)
+	}
+	if !canRemoveOrganizationMember(&actingMembership.OrganizationMembership, &removedMembership.OrganizationMembership) {
+		return false, gqlerror.Errorf(organizationRoleErrorMessage)
+	}
+
+	err = r.removeOrganizationMember(&actingMembership.OrganizationMembership, &removedMembership.OrganizationMembership)
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) MovePasswordToOrganization(ctx context.Context, passwordID string, organizationID string) (*model.Password, error) {
+	passwordId, err := strconv.ParseUint(passwordID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting password id to uint64: %s", err)
+		return nil, gqlerror.Errorf(organizationPasswordMoveErrorMessage)
+	}
+
+	membership, err := r.fetchAcceptedOrganizationMembership(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	userPassword := databaseModel.Password{}
+	err = r.passwordRepository.FetchPasswordById(ctx, &userPassword, passwordId)
+	if err != nil {
+		log.Printf("Error occurred while fetching user password by id: %s", err)
+		return nil, repositoryError(err, organizationPasswordMoveErrorMessage)
+	}
+	if userPassword.UserId != membership.UserId || userPassword.OrganizationId != nil {
+		return nil, gqlerror.Errorf(passwordAuthenticationErrorMessage)
+	}
+
+	shares := databaseModel.PasswordSharesDetails{}
+	err = r.passwordShareRepository.FetchSharesDetailsByPasswordId(&shares, passwordId)
+	if err != nil {
+		log.Printf("Error while fetching entry shares: %s", err)
+		return nil, repositoryError(err, organizationPasswordMoveErrorMessage)
+	}
+	if len(shares) > 0 {
+		return nil, gqlerror.Errorf(sharedPasswordMoveErrorMessage)
+	}
+
+	user := databaseModel.User{}
+	err = r.userRepository.FetchMasterPasswordByUserId(&user, membership.UserId)
+	if err != nil {
+		log.Printf("Error while fetching user master password: %s", err)
+		return nil, repositoryError(err, organizationPasswordMoveErrorMessage)
+	}
+	decryptedPassword, err := r.passwordSecurityService.DecryptWithAes(userPassword.Password, user.Password)
+	if err != nil {
+		log.Printf("Error while decrypting user password: %s", err)
+		return nil, gqlerror.Errorf(organizationPasswordMoveErrorMessage)
+	}
+
+	organizationKey, err := r.openOrganizationKey(&membership.OrganizationMembership)
+	if err != nil {
+		return nil, err
+	}
+	encryptedPassword, err := r.passwordSecurityService.EncryptWithAes(decryptedPassword, organizationKey)
+	if err != nil {
+		log.Printf("Error while encrypting organization password: %s", err)
+		return nil, gqlerror.Errorf(organizationPasswordMoveErrorMessage)
+	}
+	totpUri, err := r.decryptTotpUri(userPassword.TotpUri, user.Password)
+	if err != nil {
+		log.Printf("Error while decrypting user TOTP uri: %s", err)
+		return nil, gqlerror.Errorf(organizationPasswordMoveErrorMessage)
+	}
+	encryptedTotpUri, err := r.encryptTotpUri(totpUri, organizationKey)
+	if err != nil {
+		log.Printf("Error while encrypting organization TOTP uri: %s", err)
+		return nil, gqlerror.Errorf(organizationPasswordMoveErrorMessage)
+	}
+
+	revision, err := r.passwordRepository.MovePasswordById(
+		userPassword.UserId, &membership.OrganizationId, membership.OrganizationKeyVersion, encryptedPassword, encryptedTotpUri, passwordId,
+	)
+	if err != nil {
+		log.Printf("Error while moving password to organization: %s", err)
+		return nil, organizationRepositoryError(err, organizationPasswordMoveErrorMessage)
+	}
+	r.publishVaultChange(userPassword.UserId, passwordId, eventbus.VaultEntryDeleted)
+
+	organizationId := strconv.FormatUint(membership.OrganizationId, 10)
+	return &model.Password{
+		ID:             passwordID,
+		UserID:         strconv.FormatUint(userPassword.UserId, 10),
+		OrganizationID: &organizationId,
+		Name:           userPassword.Name,
+		Password:       decryptedPassword,
+		Revision:       int(revision),
+		Version:        int(userPassword.Version),
+		TotpURI:        totpUri,
+		CurrentCode:    toOneTimeCode(totpUri),
+	}, nil
+}
+
+func (r *mutationResolver) MovePasswordToPersonalVault(ctx context.Context, passwordID string) (*model.Password, error) {
+	passwordId, err := strconv.ParseUint(passwordID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting password id to uint64: %s", err)
+		return nil, gqlerror.Errorf(organizationPasswordMoveErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(organizationAuthenticationErrorMessage)
+	}
+
+	sharedPassword := databaseModel.Password{}
+	err = r.passwordRepository.FetchPasswordById(ctx, &sharedPassword, passwordId)
+	if err != nil {
+		log.Printf("Error occurred while fetching user password by id: %s", err)
+		return nil, repositoryError(err, organizationPasswordMoveErrorMessage)
+	}
+	if sharedPassword.OrganizationId == nil {
+		return nil, gqlerror.Errorf(passwordAuthenticationErrorMessage)
+	}
+
+	membership, err := r.fetchOrganizationMembershipOfUser(*sharedPassword.OrganizationId, userAuthentication.UserId)
+	if err != nil {
+		return nil, err
+	}
+	if membership.Status != databaseModel.OrganizationMemberAccepted {
+		return nil, gqlerror.Errorf(organizationAuthenticationErrorMessage)
+	}
+	if !isOrganizationManager(membership.Role) {
+		return nil, gqlerror.Errorf(organizationRoleErrorMessage)
+	}
+
+	organizationKey, err := r.openOrganizationKey(&membership.OrganizationMembership)
+	if err != nil {
+		return nil, err
+	}
+	decryptedPassword, err := r.passwordSecurityService.DecryptWithAes(sharedPassword.Password, organizationKey)
+	if err != nil {
+		log.Printf("Error while decrypting organization password: %s", err)
+		return nil, gqlerror.Errorf(organizationPasswordMoveErrorMessage)
+	}
+
+	user := databaseModel.User{}
+	err = r.userRepository.FetchMasterPasswordByUserId(&user, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching user master password: %s", err)
+		return nil, repositoryError(err, organizationPasswordMoveErrorMessage)
+	}
+	encryptedPassword, err := r.passwordSecurityService.EncryptWithAes(decryptedPassword, user.Password)
+	if err != nil {
+		log.Printf("Error while encrypting user password: %s", err)
+		return nil, gqlerror.Errorf(organizationPasswordMoveErrorMessage)
+	}
+	totpUri, err := r.decryptTotpUri(sharedPassword.TotpUri, organizationKey)
+	if err != nil {
+		log.Printf("Error while decrypting organization TOTP uri: %s", err)
+		return nil, gqlerror.Errorf(organizationPasswordMoveErrorMessage)
+	}
+	encryptedTotpUri, err := r.encryptTotpUri(totpUri, user.Password)
+	if err != nil {
+		log.Printf("Error while encrypting user TOTP uri: %s", err)
+		return nil, gqlerror.Errorf(organizationPasswordMoveErrorMessage)
+	}
+
+	revision, err := r.passwordRepository.MovePasswordById(userAuthentication.UserId, nil, 0, encryptedPassword, encryptedTotpUri, passwordId)
+	if err != nil {
+		log.Printf("Error while moving password to personal vault: %s", err)
+		return nil, repositoryError(err, organizationPasswordMoveErrorMessage)
+	}
+	r.publishVaultChange(userAuthentication.UserId, passwordId, eventbus.VaultEntryCreated)
+
+	return &model.Password{
+		ID:          passwordID,
+		UserID:      strconv.FormatUint(userAuthentication.UserId, 10),
+		Name:        sharedPassword.Name,
+		Password:    decryptedPassword,
+		Revision:    int(revision),
+		Version:     int(sharedPassword.Version),
+		TotpURI:     totpUri,
+		CurrentCode: toOneTimeCode(totpUri),
+	}, nil
+}
+
+func (r *queryResolver) Organizations(ctx context.Context) ([]*model.Organization, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(organizationAuthenticationErrorMessage)
+	}
+
+	fetchedMemberships := databaseModel.OrganizationMembershipsDetails{}
+	err := r.organizationRepository.FetchMembershipsDetailsByUserId(&fetchedMemberships, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching organization memberships: %s", err)
+		return nil, repositoryError(err, organizationMembershipFetchErrorMessage)
+	}
+
+	organizations := []*model.Organization{}
+	for index := range fetchedMemberships {
+		organizations = append(organizations, toOrganization(&fetchedMemberships[index]))
+	}
+	return organizations, nil
+}
+
+func (r *queryResolver) OrganizationMembers(ctx context.Context, organizationID string) ([]*model.OrganizationMember, error) {
+	membership, err := r.fetchAcceptedOrganizationMembership(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchedMemberships := databaseModel.OrganizationMembershipsDetails{}
+	err = r.organizationRepository.FetchMembershipsDetailsByOrganizationId(&fetchedMemberships, membership.OrganizationId)
+	if err != nil {
+		log.Printf("Error while fetching organization members: %s", err)
+		return nil, repositoryError(err, organizationMembersFetchErrorMessage)
+	}
+
+	members := []*model.OrganizationMember{}
+	for index := range fetchedMemberships {
+		member := &fetchedMemberships[index]
+		members = append(members, toOrganizationMember(&member.OrganizationMembership, member.Email))
+	}
+	return members, nil
+}
+
+func (r *queryResolver) OrganizationPasswords(ctx context.Context, organizationID string) ([]*model.Password, error) {
+	membership, err := r.fetchAcceptedOrganizationMembership(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+
+	organizationKey, err := r.openOrganizationKey(&membership.OrganizationMembership)
+	if err != nil {
+		return nil, err
+	}
+
+	fetchedPasswords := databaseModel.Passwords{}
+	err = r.passwordRepository.FetchAllByOrganizationId(&fetchedPasswords, membership.OrganizationId)
+	if err != nil {
+		log.Printf("Error while fetching organization passwords: %s", err)
+		return nil, repositoryError(err, organizationPasswordsFetchErrorMessage)
+	}
+
+	organizationId := strconv.FormatUint(membership.OrganizationId, 10)
+	passwords := []*model.Password{}
+	for _, password := range fetchedPasswords {
+		decryptedPassword, err := r.passwordSecurityService.DecryptWithAes(password.Password, organizationKey)
+		if err != nil {
+			log.Printf("Error while decrypting organization password: %s", err)
+			return nil, gqlerror.Errorf(organizationPasswordsFetchErrorMessage)
+		}
+		totpUri, err := r.decryptTotpUri(password.TotpUri, organizationKey)
+		if err != nil {
+			log.Printf("Error while decrypting organization TOTP uri: %s", err)
+			return nil, gqlerror.Errorf(organizationPasswordsFetchErrorMessage)
+		}
+		passwords = append(passwords, &model.Password{
+			ID:             strconv.FormatUint(password.Id, 10),
+			UserID:         strconv.FormatUint(password.UserId, 10),
+			OrganizationID: &organizationId,
+			Name:           password.Name,
+			Password:       decryptedPassword,
+			Revision:       int(password.Revision),
+			Version:        int(password.Version),
+			TotpURI:        totpUri,
+			CurrentCode:    toOneTimeCode(totpUri),
+		})
+	}
+	return passwords, nil
+}
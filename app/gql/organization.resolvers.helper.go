@@ -0,0 +1,234 @@
+package gql
+
+import (
+	"context"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"log"
+	"strconv"
+)
+
+const (
+	organizationErrorMessage                = "could not process organization request"
+	organizationAuthenticationErrorMessage  = "unauthorized organization request"
+	organizationRoleErrorMessage            = "your organization role doesn't allow this operation"
+	organizationExistingMemberErrorMessage  = "the user is already a member of the organization"
+	organizationInviteStatusErrorMessage    = "organization invite is not pending"
+	organizationOwnerRemovalErrorMessage    = "the organization owner can't be removed"
+	organizationKeyRotationErrorMessage     = "the organization changed during its key rotation, try again"
+	organizationPasswordMoveErrorMessage    = "could not move password"
+	sharedPasswordModificationErrorMessage  = "shared passwords have to be moved to a personal vault before changing them"
+	organizationPasswordsFetchErrorMessage  = "could not fetch organization's passwords"
+	organizationMembersFetchErrorMessage    = "could not fetch organization's members"
+	organizationMembershipFetchErrorMessage = "could not fetch organization memberships"
+)
+
+var organizationRoles = map[string]model.OrganizationRole{
+	databaseModel.OrganizationOwner:  model.OrganizationRoleOwner,
+	databaseModel.OrganizationAdmin:  model.OrganizationRoleAdmin,
+	databaseModel.OrganizationMember: model.OrganizationRoleMember,
+}
+
+var organizationMemberStatuses = map[string]model.OrganizationMemberStatus{
+	databaseModel.OrganizationMemberInvited:  model.OrganizationMemberStatusInvited,
+	databaseModel.OrganizationMemberAccepted: model.OrganizationMemberStatusAccepted,
+}
+
+func toOrganization(membership *databaseModel.OrganizationMembershipDetails) *model.Organization {
+	return &model.Organization{
+		ID:     strconv.FormatUint(membership.OrganizationId, 10),
+		Name:   membership.OrganizationName,
+		Role:   organizationRoles[membership.Role],
+		Status: organizationMemberStatuses[membership.Status],
+	}
+}
+
+func toOrganizationMember(membership *databaseModel.OrganizationMembership, email string) *model.OrganizationMember {
+	return &model.OrganizationMember{
+		ID:     strconv.FormatUint(membership.Id, 10),
+		Email:  email,
+		Role:   organizationRoles[membership.Role],
+		Status: organizationMemberStatuses[membership.Status],
+	}
+}
+
+// isOrganizationManager reports whether the role is allowed to manage members and move passwords out of the shared vault
+func isOrganizationManager(role string) bool {
+	return role == databaseModel.OrganizationOwner || role == databaseModel.OrganizationAdmin
+}
+
+// canRemoveOrganizationMember allows owners to remove anyone, admins to remove members and everyone to leave by themselves
+func canRemoveOrganizationMember(actor *databaseModel.OrganizationMembership, target *databaseModel.OrganizationMembership) bool {
+	if actor.Id == target.Id {
+		return true
+	}
+	if actor.Status != databaseModel.OrganizationMemberAccepted {
+		return false
+	}
+
+	return actor.Role == databaseModel.OrganizationOwner ||
+		(actor.Role == databaseModel.OrganizationAdmin && target.Role == databaseModel.OrganizationMember)
+}
+
+// fetchOwnOrganizationMembership fetches the authenticated user's membership, users outside the organization are unauthorized
+func (r *Resolver) fetchOwnOrganizationMembership(
+	ctx context.Context, organizationID string,
+) (*databaseModel.OrganizationMembershipDetails, error) {
+	organizationId, err := strconv.ParseUint(organizationID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting organization id to uint64: %s", err)
+		return nil, gqlerror.Errorf(organizationErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(organizationAuthenticationErrorMessage)
+	}
+
+	return r.fetchOrganizationMembershipOfUser(organizationId, userAuthentication.UserId)
+}
+
+// fetchOrganizationMembershipOfUser fetches the membership along with the version of the organization key sealed in it
+func (r *Resolver) fetchOrganizationMembershipOfUser(
+	organizationId uint64, userId uint64,
+) (*databaseModel.OrganizationMembershipDetails, error) {
+	membership := &databaseModel.OrganizationMembershipDetails{}
+	err := r.organizationRepository.FetchMembershipByOrganizationIdAndUserId(membership, organizationId, userId)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, gqlerror.Errorf(organizationAuthenticationErrorMessage)
+		}
+		log.Printf("Error while fetching organization membership: %s", err)
+		return nil, repositoryError(err, organizationErrorMessage)
+	}
+
+	return membership, nil
+}
+
+// fetchAcceptedOrganizationMembership fetches the authenticated user's membership if the user has access to the shared vault
+func (r *Resolver) fetchAcceptedOrganizationMembership(
+	ctx context.Context, organizationID string,
+) (*databaseModel.OrganizationMembershipDetails, error) {
+	membership, err := r.fetchOwnOrganizationMembership(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	if membership.Status != databaseModel.OrganizationMemberAccepted {
+		return nil, gqlerror.Errorf(organizationAuthenticationErrorMessage)
+	}
+
+	return membership, nil
+}
+
+// organizationRepositoryError is the repositoryError of writes depending on the organization key, which report
+// a concurrently rotated key with a message of its own
+func organizationRepositoryError(err error, message string) *gqlerror.Error {
+	if err == repository.ErrOrganizationChanged {
+		return repositoryError(err, organizationKeyRotationErrorMessage)
+	}
+	return repositoryError(err, message)
+}
+
+// openOrganizationKey opens the organization key sealed for the member with the member's private key
+func (r *Resolver) openOrganizationKey(membership *databaseModel.OrganizationMembership) ([]byte, error) {
+	keyPair := databaseModel.KeyPair{}
+	if err := r.keyPairRepository.FetchKeyPairByUserId(&keyPair, membership.UserId); err != nil {
+		log.Printf("Error while fetching key pair: %s", err)
+		return nil, repositoryError(err, organizationErrorMessage)
+	}
+	user := databaseModel.User{}
+	if err := r.userRepository.FetchMasterPasswordByUserId(&user, membership.UserId); err != nil {
+		log.Printf("Error while fetching user master password: %s", err)
+		return nil, repositoryError(err, organizationErrorMessage)
+	}
+
+	organizationKey, err := r.passwordSecurityService.OpenWithPrivateKey(
+		membership.SealedOrganizationKey, keyPair.PublicKey, keyPair.WrappedPrivateKey, user.Password,
+	)
+	if err != nil {
+		log.Printf("Error while opening organization key: %s", err)
+		return nil, gqlerror.Errorf(organizationErrorMessage)
+	}
+
+	return organizationKey, nil
+}
+
+func (r *Resolver) sealOrganizationKey(organizationKey []byte, userId uint64) ([]byte, error) {
+	keyPair, err := r.fetchOrCreateKeyPair(r.keyPairRepository, r.userRepository, userId)
+	if err != nil {
+		log.Printf("Error while fetching key pair: %s", err)
+		return nil, repositoryError(err, organizationErrorMessage)
+	}
+
+	sealedOrganizationKey, err := r.passwordSecurityService.SealForPublicKey(organizationKey, keyPair.PublicKey)
+	if err != nil {
+		log.Printf("Error while sealing organization key: %s", err)
+		return nil, gqlerror.Errorf(organizationErrorMessage)
+	}
+
+	return sealedOrganizationKey, nil
+}
+
+// removeOrganizationMember rotates the organization key, so the removed member can't open the shared vault with a copy of the old one
+func (r *Resolver) removeOrganizationMember(actor *databaseModel.OrganizationMembership, removed *databaseModel.OrganizationMembership) error {
+	organizationKey, err := r.openOrganizationKey(actor)
+	if err != nil {
+		return err
+	}
+	newOrganizationKey, err := security.GenerateOrganizationKey()
+	if err != nil {
+		log.Printf("Error while generating organization key: %s", err)
+		return gqlerror.Errorf(organizationErrorMessage)
+	}
+
+	memberships := databaseModel.OrganizationMemberships{}
+	if err = r.organizationRepository.FetchMembershipsByOrganizationId(&memberships, removed.OrganizationId); err != nil {
+		log.Printf("Error while fetching organization memberships: %s", err)
+		return repositoryError(err, organizationErrorMessage)
+	}
+	sealedOrganizationKeys := map[uint64][]byte{}
+	for _, membership := range memberships {
+		if membership.Id == removed.Id {
+			continue
+		}
+		sealedOrganizationKeys[membership.Id], err = r.sealOrganizationKey(newOrganizationKey, membership.UserId)
+		if err != nil {
+			return err
+		}
+	}
+
+	passwords := databaseModel.Passwords{}
+	if err = r.passwordRepository.FetchAllByOrganizationId(&passwords, removed.OrganizationId); err != nil {
+		log.Printf("Error while fetching organization passwords: %s", err)
+		return repositoryError(err, organizationErrorMessage)
+	}
+	for index := range passwords {
+		if err = r.reencryptOrganizationPassword(&passwords[index], organizationKey, newOrganizationKey); err != nil {
+			log.Printf("Error while re-encrypting organization password: %s", err)
+			return gqlerror.Errorf(organizationErrorMessage)
+		}
+	}
+
+	err = r.organizationRepository.RotateOrganizationKey(removed.OrganizationId, removed.Id, sealedOrganizationKeys, passwords)
+	if err != nil {
+		log.Printf("Error while rotating organization key: %s", err)
+		return organizationRepositoryError(err, organizationErrorMessage)
+	}
+
+	return nil
+}
+
+func (r *Resolver) reencryptOrganizationPassword(password *databaseModel.Password, organizationKey []byte, newOrganizationKey []byte) error {
+	decryptedPassword, err := r.passwordSecurityService.DecryptWithAes(password.Password, organizationKey)
+	if err != nil {
+		return err
+	}
+	if password.Password, err = r.passwordSecurityService.EncryptWithAes(decryptedPassword, newOrganizationKey); err != nil {
+		return err
+	}
+	password.TotpUri, err = r.reencryptTotpUri(password.TotpUri, organizationKey, newOrganizationKey)
+	return err
+}
@@ -0,0 +1,643 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"github.com/99designs/gqlgen/graphql"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// CreateOrganization should create an organization owned by the user with the organization key sealed for the owner
+func (suite *schemaResolverTestSuite) TestCreateOrganization() {
+	organizationRepositoryServiceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	unitOfWorkMock := mockutil.NewUnitOfWorkMock(&repository.Repositories{
+		User:         mockutil.DefaultUserRepositoryServiceMock(),
+		KeyPair:      mockutil.DefaultKeyPairRepositoryServiceMock(),
+		Organization: organizationRepositoryServiceMock,
+	})
+	suite.resolver.unitOfWork = unitOfWorkMock
+
+	organization, err := suite.mutationResolver.CreateOrganization(
+		suite.graphqlRequestContext, model.NewOrganization{Name: mockutil.DefaultOrganizationName},
+	)
+	assert.Nil(suite.T(), err, "Should create an organization without any errors")
+	assert.Equal(suite.T(), &model.Organization{
+		ID:     mockutil.DefaultIdAsString,
+		Name:   mockutil.DefaultOrganizationName,
+		Role:   model.OrganizationRoleOwner,
+		Status: model.OrganizationMemberStatusAccepted,
+	}, organization)
+
+	organizationRepositoryServiceMock.AssertCalled(
+		suite.T(), "InsertNewOrganization", mock.Anything, mock.MatchedBy(func(owner *databaseModel.OrganizationMembership) bool {
+			return owner.UserId == mockutil.DefaultIdAsUint64 && owner.Role == databaseModel.OrganizationOwner &&
+				string(owner.SealedOrganizationKey) == mockutil.MockedSealedVaultKey
+		}),
+	)
+	unitOfWorkMock.AssertNumberOfCalls(suite.T(), "Execute", 1)
+}
+
+// CreateOrganization should return expected error when the organization can't be stored together with the owner's key pair
+func (suite *schemaResolverTestSuite) TestCreateOrganizationWithInsertError() {
+	keyPairRepositoryServiceMock := mockutil.DefaultKeyPairRepositoryServiceMock()
+	organizationRepositoryServiceMock := new(mockutil.OrganizationRepositoryServiceMock)
+	organizationRepositoryServiceMock.On("InsertNewOrganization", mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage),
+	).Times(1)
+	suite.resolver.unitOfWork = mockutil.NewUnitOfWorkMock(&repository.Repositories{
+		User:         mockutil.DefaultUserRepositoryServiceMock(),
+		KeyPair:      keyPairRepositoryServiceMock,
+		Organization: organizationRepositoryServiceMock,
+	})
+
+	organization, err := suite.mutationResolver.CreateOrganization(
+		suite.graphqlRequestContext, model.NewOrganization{Name: mockutil.DefaultOrganizationName},
+	)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("could not process organization request"),
+		"Should return expected error when the organization can't be stored",
+	)
+	assert.Nil(suite.T(), organization, "Should not return an organization")
+	keyPairRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewKeyPair", mock.Anything)
+}
+
+// CreateOrganization should return expected error on failed input validation
+func (suite *schemaResolverTestSuite) TestCreateOrganizationValidation() {
+	ctx := graphql.WithResponseContext(context.Background(), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	organization, err := suite.mutationResolver.CreateOrganization(ctx, model.NewOrganization{})
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("validation error/s on organization input"),
+		"Should return expected error when the input is invalid",
+	)
+	assert.Nil(suite.T(), organization, "Should not return an organization")
+}
+
+// CreateOrganization should return an error for unauthenticated users
+func (suite *schemaResolverTestSuite) TestCreateOrganizationWithoutAuthentication() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	organization, err := suite.mutationResolver.CreateOrganization(
+		suite.graphqlRequestContext, model.NewOrganization{Name: mockutil.DefaultOrganizationName},
+	)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized organization request"),
+		"Should return expected error when the user is not authenticated",
+	)
+	assert.Nil(suite.T(), organization, "Should not return an organization")
+}
+
+// InviteOrganizationMember should seal the organization key for the invited user
+func (suite *schemaResolverTestSuite) TestInviteOrganizationMember() {
+	organizationRepositoryServiceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+
+	member, err := suite.mutationResolver.InviteOrganizationMember(suite.graphqlRequestContext, generateTestOrganizationInvite())
+	assert.Nil(suite.T(), err, "Should invite the member without any errors")
+	assert.Equal(suite.T(), &model.OrganizationMember{
+		ID:     "2",
+		Email:  mockutil.DefaultGranteeEmail,
+		Role:   model.OrganizationRoleMember,
+		Status: model.OrganizationMemberStatusInvited,
+	}, member)
+
+	organizationRepositoryServiceMock.AssertCalled(
+		suite.T(), "InsertNewMembership", mock.MatchedBy(func(membership *databaseModel.OrganizationMembership) bool {
+			return membership.OrganizationId == mockutil.DefaultIdAsUint64 && membership.Role == databaseModel.OrganizationMember &&
+				membership.Status == databaseModel.OrganizationMemberInvited &&
+				string(membership.SealedOrganizationKey) == mockutil.MockedSealedVaultKey
+		}), mockutil.DefaultOrganizationKeyVersion,
+	)
+}
+
+// InviteOrganizationMember should only allow admins to invite regular members
+func (suite *schemaResolverTestSuite) TestInviteOrganizationAdminAsAdmin() {
+	admin := mockutil.DefaultOrganizationMembership()
+	admin.Role = databaseModel.OrganizationAdmin
+	organizationRepositoryServiceMock := organizationRepositoryMockWithMembership(admin, nil)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+	input := generateTestOrganizationInvite()
+	input.Role = model.OrganizationRoleAdmin
+
+	member, err := suite.mutationResolver.InviteOrganizationMember(suite.graphqlRequestContext, input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("your organization role doesn't allow this operation"),
+		"Should return expected error when an admin invites another admin",
+	)
+	assert.Nil(suite.T(), member, "Should not return a member")
+	organizationRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertNewMembership", mock.Anything, mock.Anything)
+}
+
+// InviteOrganizationMember should not allow regular members to invite anyone
+func (suite *schemaResolverTestSuite) TestInviteOrganizationMemberAsMember() {
+	suite.resolver.organizationRepository = organizationRepositoryMockWithMembership(mockutil.DefaultOrganizationMemberMembership(), nil)
+
+	member, err := suite.mutationResolver.InviteOrganizationMember(suite.graphqlRequestContext, generateTestOrganizationInvite())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("your organization role doesn't allow this operation"),
+		"Should return expected error when a member invites someone",
+	)
+	assert.Nil(suite.T(), member, "Should not return a member")
+}
+
+// InviteOrganizationMember should not allow anyone to invite another owner
+func (suite *schemaResolverTestSuite) TestInviteOrganizationOwner() {
+	input := generateTestOrganizationInvite()
+	input.Role = model.OrganizationRoleOwner
+
+	member, err := suite.mutationResolver.InviteOrganizationMember(suite.graphqlRequestContext, input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("your organization role doesn't allow this operation"),
+		"Should return expected error when inviting another owner",
+	)
+	assert.Nil(suite.T(), member, "Should not return a member")
+}
+
+// InviteOrganizationMember should return an error for users outside the organization
+func (suite *schemaResolverTestSuite) TestInviteOrganizationMemberWithoutMembership() {
+	organizationRepositoryServiceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	organizationRepositoryServiceMock.ExpectedCalls = nil
+	organizationRepositoryServiceMock.On("FetchMembershipByOrganizationIdAndUserId", mock.Anything, mock.Anything, mock.Anything).
+		Return(repository.ErrNotFound, nil).Times(1)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+
+	member, err := suite.mutationResolver.InviteOrganizationMember(suite.graphqlRequestContext, generateTestOrganizationInvite())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized organization request"),
+		"Should return expected error when the user is not a member of the organization",
+	)
+	assert.Nil(suite.T(), member, "Should not return a member")
+}
+
+// InviteOrganizationMember should return an error if the user is already a member
+func (suite *schemaResolverTestSuite) TestInviteExistingOrganizationMember() {
+	organizationRepositoryServiceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	organizationRepositoryServiceMock.ExpectedCalls = nil
+	organizationRepositoryServiceMock.On("FetchMembershipByOrganizationIdAndUserId", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, mockutil.DefaultOrganizationMembershipDetails()).Times(1)
+	organizationRepositoryServiceMock.On("InsertNewMembership", mock.Anything, mock.Anything).Return(nil, repository.ErrDuplicate).Times(1)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+
+	member, err := suite.mutationResolver.InviteOrganizationMember(suite.graphqlRequestContext, generateTestOrganizationInvite())
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{Message: "the user is already a member of the organization", Extensions: map[string]interface{}{"code": "DUPLICATE"}},
+		"Should return expected error when the user is already a member",
+	)
+	assert.Nil(suite.T(), member, "Should not return a member")
+}
+
+// InviteOrganizationMember should return a conflict if the organization key was rotated after it was sealed for the invitee
+func (suite *schemaResolverTestSuite) TestInviteOrganizationMemberWithRotatedKey() {
+	organizationRepositoryServiceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	organizationRepositoryServiceMock.ExpectedCalls = nil
+	organizationRepositoryServiceMock.On("FetchMembershipByOrganizationIdAndUserId", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, mockutil.DefaultOrganizationMembershipDetails()).Times(1)
+	organizationRepositoryServiceMock.On("InsertNewMembership", mock.Anything, mock.Anything).
+		Return(nil, repository.ErrOrganizationChanged).Times(1)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+
+	member, err := suite.mutationResolver.InviteOrganizationMember(suite.graphqlRequestContext, generateTestOrganizationInvite())
+	assert.Equal(
+		suite.T(), err,
+		&gqlerror.Error{Message: "the organization changed during its key rotation, try again", Extensions: map[string]interface{}{"code": "CONFLICT"}},
+		"Should return expected error when the organization key was rotated",
+	)
+	assert.Nil(suite.T(), member, "Should not return a member")
+}
+
+// InviteOrganizationMember should pass on the code of a failed key pair fetch
+func (suite *schemaResolverTestSuite) TestInviteOrganizationMemberWithUnavailableKeyPair() {
+	organizationRepositoryServiceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+	keyPairRepositoryServiceMock := new(mockutil.KeyPairRepositoryServiceMock)
+	keyPairRepositoryServiceMock.On("FetchKeyPairByUserId", mock.Anything, mock.Anything).Return(repository.ErrUnavailable).Times(1)
+	suite.resolver.keyPairRepository = keyPairRepositoryServiceMock
+
+	member, err := suite.mutationResolver.InviteOrganizationMember(suite.graphqlRequestContext, generateTestOrganizationInvite())
+	assert.Equal(
+		suite.T(), err,
+		&gqlerror.Error{Message: "could not process organization request", Extensions: map[string]interface{}{"code": "STORAGE_UNAVAILABLE"}},
+		"Should return expected error when the key pair can't be fetched",
+	)
+	assert.Nil(suite.T(), member, "Should not return a member")
+	organizationRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertNewMembership", mock.Anything, mock.Anything)
+}
+
+// AcceptOrganizationInvite should give the invited user access to the shared vault
+func (suite *schemaResolverTestSuite) TestAcceptOrganizationInvite() {
+	invited := mockutil.DefaultOrganizationMemberMembership()
+	invited.Status = databaseModel.OrganizationMemberInvited
+	organizationRepositoryServiceMock := organizationRepositoryMockWithMembership(invited, nil)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+
+	organization, err := suite.mutationResolver.AcceptOrganizationInvite(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should accept the invite without any errors")
+	assert.Equal(suite.T(), mockutil.DefaultOrganizationName, organization.Name)
+	assert.Equal(suite.T(), model.OrganizationMemberStatusAccepted, organization.Status)
+	organizationRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateMembershipStatusById", databaseModel.OrganizationMemberAccepted, invited.Id,
+	)
+}
+
+// AcceptOrganizationInvite should return an error if the invite was already accepted
+func (suite *schemaResolverTestSuite) TestAcceptAcceptedOrganizationInvite() {
+	organizationRepositoryServiceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+
+	organization, err := suite.mutationResolver.AcceptOrganizationInvite(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("organization invite is not pending"),
+		"Should return expected error when the invite is not pending",
+	)
+	assert.Nil(suite.T(), organization, "Should not return an organization")
+	organizationRepositoryServiceMock.AssertNotCalled(suite.T(), "UpdateMembershipStatusById", mock.Anything, mock.Anything)
+}
+
+// RemoveOrganizationMember should rotate the organization key for the remaining members and re-encrypt the shared vault
+func (suite *schemaResolverTestSuite) TestRemoveOrganizationMember() {
+	organizationRepositoryServiceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.passwordSecurityService = unlimitedPasswordSecurityServiceMock()
+
+	removed, err := suite.mutationResolver.RemoveOrganizationMember(suite.graphqlRequestContext, "2")
+	assert.Nil(suite.T(), err, "Should remove the member without any errors")
+	assert.True(suite.T(), removed, "Should confirm the removal")
+
+	organizationRepositoryServiceMock.AssertCalled(
+		suite.T(), "RotateOrganizationKey", mockutil.DefaultIdAsUint64, uint64(2),
+		map[uint64][]byte{mockutil.DefaultIdAsUint64: []byte(mockutil.MockedSealedVaultKey)},
+		mock.MatchedBy(func(passwords databaseModel.Passwords) bool {
+			return len(passwords) == 2 && string(passwords[0].Password) == mockutil.MockedEncryptedPassword &&
+				string(passwords[1].Password) == mockutil.MockedEncryptedPassword
+		}),
+	)
+}
+
+// RemoveOrganizationMember should let members leave the organization by themselves
+func (suite *schemaResolverTestSuite) TestLeaveOrganization() {
+	member := mockutil.DefaultOrganizationMemberMembership()
+	organizationRepositoryServiceMock := organizationRepositoryMockWithMembership(member, nil)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.passwordSecurityService = unlimitedPasswordSecurityServiceMock()
+
+	removed, err := suite.mutationResolver.RemoveOrganizationMember(suite.graphqlRequestContext, "2")
+	assert.Nil(suite.T(), err, "Should leave the organization without any errors")
+	assert.True(suite.T(), removed, "Should confirm the removal")
+	organizationRepositoryServiceMock.AssertCalled(
+		suite.T(), "RotateOrganizationKey", mockutil.DefaultIdAsUint64, member.Id, mock.Anything, mock.Anything,
+	)
+}
+
+// RemoveOrganizationMember should never remove the organization owner
+func (suite *schemaResolverTestSuite) TestRemoveOrganizationOwner() {
+	organizationRepositoryServiceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	organizationRepositoryServiceMock.ExpectedCalls = nil
+	organizationRepositoryServiceMock.On("FetchMembershipDetailsById", mock.Anything, mock.Anything).Return(
+		nil, databaseModel.OrganizationMembershipDetails{OrganizationMembership: mockutil.DefaultOrganizationMembership()},
+	).Times(1)
+	organizationRepositoryServiceMock.On("FetchMembershipByOrganizationIdAndUserId", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, mockutil.DefaultOrganizationMembershipDetails()).Times(1)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+
+	removed, err := suite.mutationResolver.RemoveOrganizationMember(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("the organization owner can't be removed"),
+		"Should return expected error when removing the owner",
+	)
+	assert.False(suite.T(), removed, "Should not confirm the removal")
+}
+
+// RemoveOrganizationMember should not allow regular members to remove others
+func (suite *schemaResolverTestSuite) TestRemoveOrganizationMemberAsMember() {
+	member := mockutil.DefaultOrganizationMemberMembership()
+	member.Id = uint64(3)
+	organizationRepositoryServiceMock := organizationRepositoryMockWithMembership(member, nil)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+
+	removed, err := suite.mutationResolver.RemoveOrganizationMember(suite.graphqlRequestContext, "2")
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("your organization role doesn't allow this operation"),
+		"Should return expected error when a member removes someone else",
+	)
+	assert.False(suite.T(), removed, "Should not confirm the removal")
+	organizationRepositoryServiceMock.AssertNotCalled(
+		suite.T(), "RotateOrganizationKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+	)
+}
+
+// RemoveOrganizationMember should return an error if the organization changed during the key rotation
+func (suite *schemaResolverTestSuite) TestRemoveOrganizationMemberWithConcurrentChange() {
+	organizationRepositoryServiceMock := organizationRepositoryMockWithMembership(
+		mockutil.DefaultOrganizationMembership(), repository.ErrOrganizationChanged,
+	)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.passwordSecurityService = unlimitedPasswordSecurityServiceMock()
+
+	removed, err := suite.mutationResolver.RemoveOrganizationMember(suite.graphqlRequestContext, "2")
+	assert.Equal(
+		suite.T(), err,
+		&gqlerror.Error{Message: "the organization changed during its key rotation, try again", Extensions: map[string]interface{}{"code": "CONFLICT"}},
+		"Should return expected error when the organization changed during the key rotation",
+	)
+	assert.False(suite.T(), removed, "Should not confirm the removal")
+}
+
+// RemoveOrganizationMember should pass on the code of the repository error when the key rotation fails
+func (suite *schemaResolverTestSuite) TestRemoveOrganizationMemberWithUnavailableStorage() {
+	organizationRepositoryServiceMock := organizationRepositoryMockWithMembership(
+		mockutil.DefaultOrganizationMembership(), repository.ErrUnavailable,
+	)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.passwordSecurityService = unlimitedPasswordSecurityServiceMock()
+
+	removed, err := suite.mutationResolver.RemoveOrganizationMember(suite.graphqlRequestContext, "2")
+	assert.Equal(
+		suite.T(), err,
+		&gqlerror.Error{Message: "could not process organization request", Extensions: map[string]interface{}{"code": "STORAGE_UNAVAILABLE"}},
+		"Should return expected error when the storage is unavailable",
+	)
+	assert.False(suite.T(), removed, "Should not confirm the removal")
+}
+
+// MovePasswordToOrganization should re-encrypt a personal password with the organization key
+func (suite *schemaResolverTestSuite) TestMovePasswordToOrganization() {
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.userRepository = unlimitedUserRepositoryMock()
+
+	password, err := suite.mutationResolver.MovePasswordToOrganization(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, mockutil.DefaultIdAsString,
+	)
+	assert.Nil(suite.T(), err, "Should move the password without any errors")
+	organizationId := mockutil.DefaultIdAsString
+	assert.Equal(suite.T(), &model.Password{
+		ID:             mockutil.DefaultIdAsString,
+		UserID:         mockutil.DefaultIdAsString,
+		OrganizationID: &organizationId,
+		Name:           mockutil.DefaultPasswordName,
+		Password:       mockutil.MockedDecryptedPassword,
+		Revision:       int(mockutil.MockedNextRevision),
+		Version:        int(mockutil.DefaultVersion),
+	}, password)
+
+	organizationIdAsUint64 := mockutil.DefaultIdAsUint64
+	passwordRepositoryServiceMock.AssertCalled(
+		suite.T(), "MovePasswordById", mockutil.DefaultIdAsUint64, &organizationIdAsUint64, mockutil.DefaultOrganizationKeyVersion,
+		[]byte(mockutil.MockedEncryptedPassword), []byte(nil), mockutil.DefaultIdAsUint64,
+	)
+}
+
+// MovePasswordToOrganization should not move passwords of other users
+func (suite *schemaResolverTestSuite) TestMovePasswordOfAnotherUserToOrganization() {
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	suite.resolver.organizationRepository = organizationRepositoryMockWithMembership(mockutil.DefaultOrganizationMemberMembership(), nil)
+
+	password, err := suite.mutationResolver.MovePasswordToOrganization(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, mockutil.DefaultIdAsString,
+	)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized password input"),
+		"Should return expected error when the password belongs to another user",
+	)
+	assert.Nil(suite.T(), password, "Should not return a password")
+	passwordRepositoryServiceMock.AssertNotCalled(suite.T(), "MovePasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// MovePasswordToOrganization should return a conflict if the organization key was rotated after the password was encrypted
+func (suite *schemaResolverTestSuite) TestMovePasswordToOrganizationWithRotatedKey() {
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	passwordRepositoryServiceMock.ExpectedCalls = nil
+	passwordRepositoryServiceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	passwordRepositoryServiceMock.On("MovePasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(int64(0), repository.ErrOrganizationChanged).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.userRepository = unlimitedUserRepositoryMock()
+
+	password, err := suite.mutationResolver.MovePasswordToOrganization(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, mockutil.DefaultIdAsString,
+	)
+	assert.Equal(
+		suite.T(), err,
+		&gqlerror.Error{Message: "the organization changed during its key rotation, try again", Extensions: map[string]interface{}{"code": "CONFLICT"}},
+		"Should return expected error when the organization key was rotated",
+	)
+	assert.Nil(suite.T(), password, "Should not return a password")
+}
+
+// MovePasswordToPersonalVault should re-encrypt a shared password with the vault key of the user moving it
+func (suite *schemaResolverTestSuite) TestMovePasswordToPersonalVault() {
+	passwordRepositoryServiceMock := passwordRepositoryMockWithSharedPassword()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.userRepository = unlimitedUserRepositoryMock()
+
+	password, err := suite.mutationResolver.MovePasswordToPersonalVault(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should move the password without any errors")
+	assert.Equal(suite.T(), &model.Password{
+		ID:       mockutil.DefaultIdAsString,
+		UserID:   mockutil.DefaultIdAsString,
+		Name:     mockutil.DefaultPasswordName,
+		Password: mockutil.MockedDecryptedPassword,
+		Revision: int(mockutil.MockedNextRevision),
+		Version:  int(mockutil.DefaultVersion),
+	}, password)
+
+	passwordRepositoryServiceMock.AssertCalled(
+		suite.T(), "MovePasswordById", mockutil.DefaultIdAsUint64, (*uint64)(nil), 0,
+		[]byte(mockutil.MockedEncryptedPassword), []byte(nil), mockutil.DefaultIdAsUint64,
+	)
+}
+
+// MovePasswordToPersonalVault should not allow regular members to take passwords out of the shared vault
+func (suite *schemaResolverTestSuite) TestMovePasswordToPersonalVaultAsMember() {
+	passwordRepositoryServiceMock := passwordRepositoryMockWithSharedPassword()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	suite.resolver.organizationRepository = organizationRepositoryMockWithMembership(mockutil.DefaultOrganizationMemberMembership(), nil)
+
+	password, err := suite.mutationResolver.MovePasswordToPersonalVault(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("your organization role doesn't allow this operation"),
+		"Should return expected error when a member moves a shared password",
+	)
+	assert.Nil(suite.T(), password, "Should not return a password")
+	passwordRepositoryServiceMock.AssertNotCalled(suite.T(), "MovePasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// MovePasswordToPersonalVault should return an error for passwords that are not shared
+func (suite *schemaResolverTestSuite) TestMovePersonalPasswordToPersonalVault() {
+	password, err := suite.mutationResolver.MovePasswordToPersonalVault(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized password input"),
+		"Should return expected error when the password is not shared",
+	)
+	assert.Nil(suite.T(), password, "Should not return a password")
+}
+
+// UpdatePassword should not change shared passwords with the user's vault key
+func (suite *schemaResolverTestSuite) TestUpdateSharedPassword() {
+	passwordRepositoryServiceMock := passwordRepositoryMockWithSharedPassword()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	authenticateEmergencyAccessGrantee(suite)
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
+
+	password, err := suite.mutationResolver.UpdatePassword(suite.graphqlRequestContext, input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("shared passwords have to be moved to a personal vault before changing them"),
+		"Should return expected error when updating a shared password",
+	)
+	assert.Nil(suite.T(), password, "Should not return a password")
+	passwordRepositoryServiceMock.AssertNotCalled(suite.T(), "UpdatePasswordById", mock.Anything, mock.Anything)
+}
+
+// DeletePassword should not delete shared passwords
+func (suite *schemaResolverTestSuite) TestDeleteSharedPassword() {
+	passwordRepositoryServiceMock := passwordRepositoryMockWithSharedPassword()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	authenticateEmergencyAccessGrantee(suite)
+
+	deleted, err := suite.mutationResolver.DeletePassword(suite.graphqlRequestContext, mockutil.DefaultIdAsString, nil)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("shared passwords have to be moved to a personal vault before changing them"),
+		"Should return expected error when deleting a shared password",
+	)
+	assert.False(suite.T(), deleted, "Should not confirm the deletion")
+	passwordRepositoryServiceMock.AssertNotCalled(suite.T(), "DeletePasswordById", mock.Anything, mock.Anything)
+}
+
+// Organizations should return all organizations of the user
+func (suite *schemaResolverTestSuite) TestOrganizations() {
+	organizations, err := suite.queryResolver.Organizations(suite.graphqlRequestContext)
+	assert.Nil(suite.T(), err, "Should fetch organizations without any errors")
+	assert.Equal(suite.T(), []*model.Organization{{
+		ID:     mockutil.DefaultIdAsString,
+		Name:   mockutil.DefaultOrganizationName,
+		Role:   model.OrganizationRoleOwner,
+		Status: model.OrganizationMemberStatusAccepted,
+	}}, organizations)
+}
+
+// OrganizationMembers should return all members of the organization
+func (suite *schemaResolverTestSuite) TestOrganizationMembers() {
+	members, err := suite.queryResolver.OrganizationMembers(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should fetch members without any errors")
+	assert.Len(suite.T(), members, 2, "Should return all members")
+	assert.Equal(suite.T(), mockutil.DefaultEmail, members[0].Email)
+	assert.Equal(suite.T(), model.OrganizationRoleOwner, members[0].Role)
+	assert.Equal(suite.T(), mockutil.DefaultGranteeEmail, members[1].Email)
+	assert.Equal(suite.T(), model.OrganizationRoleMember, members[1].Role)
+}
+
+// OrganizationMembers should not reveal members to users that didn't accept the invite yet
+func (suite *schemaResolverTestSuite) TestOrganizationMembersWithPendingInvite() {
+	invited := mockutil.DefaultOrganizationMemberMembership()
+	invited.Status = databaseModel.OrganizationMemberInvited
+	suite.resolver.organizationRepository = organizationRepositoryMockWithMembership(invited, nil)
+
+	members, err := suite.queryResolver.OrganizationMembers(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized organization request"),
+		"Should return expected error when the invite is not accepted",
+	)
+	assert.Nil(suite.T(), members, "Should not return any members")
+}
+
+// OrganizationPasswords should decrypt the shared vault with the organization key
+func (suite *schemaResolverTestSuite) TestOrganizationPasswords() {
+	passwordSecurityServiceMock := unlimitedPasswordSecurityServiceMock()
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+
+	passwords, err := suite.queryResolver.OrganizationPasswords(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should fetch passwords without any errors")
+	assert.Len(suite.T(), passwords, 2, "Should return the whole shared vault")
+	for _, password := range passwords {
+		assert.Equal(suite.T(), mockutil.DefaultIdAsString, *password.OrganizationID)
+		assert.Equal(suite.T(), mockutil.MockedDecryptedPassword, password.Password)
+	}
+	passwordSecurityServiceMock.AssertCalled(suite.T(), "DecryptWithAes", []byte("Password3"), []byte(mockutil.MockedUserMasterPassword))
+}
+
+// OrganizationPasswords should return an error for users outside the organization
+func (suite *schemaResolverTestSuite) TestOrganizationPasswordsWithoutMembership() {
+	organizationRepositoryServiceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	organizationRepositoryServiceMock.ExpectedCalls = nil
+	organizationRepositoryServiceMock.On("FetchMembershipByOrganizationIdAndUserId", mock.Anything, mock.Anything, mock.Anything).
+		Return(repository.ErrNotFound, nil).Times(1)
+	suite.resolver.organizationRepository = organizationRepositoryServiceMock
+
+	passwords, err := suite.queryResolver.OrganizationPasswords(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized organization request"),
+		"Should return expected error when the user is not a member of the organization",
+	)
+	assert.Nil(suite.T(), passwords, "Should not return any passwords")
+}
+
+// organizationRepositoryMockWithMembership mocks the authenticated user's membership in the default organization
+func organizationRepositoryMockWithMembership(
+	membership databaseModel.OrganizationMembership, rotationError error,
+) *mockutil.OrganizationRepositoryServiceMock {
+	memberDetails := mockutil.ToOrganizationMembershipDetails(mockutil.DefaultOrganizationMemberMembership())
+
+	serviceMock := mockutil.DefaultOrganizationRepositoryServiceMock()
+	serviceMock.ExpectedCalls = nil
+	serviceMock.On("FetchMembershipByOrganizationIdAndUserId", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, mockutil.ToOrganizationMembershipDetails(membership)).Times(1)
+	serviceMock.On("FetchMembershipDetailsById", mock.Anything, mock.Anything).Return(nil, memberDetails).Times(1)
+	serviceMock.On("FetchMembershipsByOrganizationId", mock.Anything, mock.Anything).Return(
+		nil, databaseModel.OrganizationMemberships{mockutil.DefaultOrganizationMembership(), mockutil.DefaultOrganizationMemberMembership()},
+	).Times(1)
+	serviceMock.On("UpdateMembershipStatusById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("RotateOrganizationKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(rotationError).Times(1)
+
+	return serviceMock
+}
+
+func passwordRepositoryMockWithSharedPassword() *mockutil.PasswordRepositoryServiceMock {
+	serviceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	serviceMock.ExpectedCalls = nil
+	serviceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(nil, mockutil.DefaultSharedPassword()).Times(1)
+	serviceMock.On("MovePasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockutil.MockedNextRevision, nil).Times(1)
+
+	return serviceMock
+}
+
+// unlimitedPasswordSecurityServiceMock allows encrypting and sealing the whole shared vault
+func unlimitedPasswordSecurityServiceMock() *mockutil.PasswordSecurityServiceMock {
+	serviceMock := new(mockutil.PasswordSecurityServiceMock)
+	serviceMock.On("EncryptWithAes", mock.Anything, mock.Anything).Return([]byte(mockutil.MockedEncryptedPassword), nil)
+	serviceMock.On("DecryptWithAes", mock.Anything, mock.Anything).Return(mockutil.MockedDecryptedPassword, nil)
+	serviceMock.On("SealForPublicKey", mock.Anything, mock.Anything).Return([]byte(mockutil.MockedSealedVaultKey), nil)
+	serviceMock.On("OpenWithPrivateKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]byte(mockutil.MockedUserMasterPassword), nil)
+
+	return serviceMock
+}
+
+func generateTestOrganizationInvite() model.OrganizationInvite {
+	return model.OrganizationInvite{
+		OrganizationID: mockutil.DefaultIdAsString,
+		Email:          mockutil.DefaultGranteeEmail,
+		Role:           model.OrganizationRoleMember,
+	}
+}
@@ -0,0 +1,247 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/eventbus"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) ShareEntry(ctx context.Context, entryID string, recipientEmail string, permission model.SharePermission) (*model.EntryShare, error) {
+	passwordId, err := strconv.ParseUint(entryID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting password id to uint64: %s", err)
+		return nil, gqlerror.Errorf(passwordShareErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	userPassword := databaseModel.Password{}
+	err = r.passwordRepository.FetchPasswordById(ctx, &userPassword, passwordId)
+	if err != nil {
+		log.Printf("Error occurred while fetching user password by id: %s", err)
+		return nil, repositoryError(err, passwordShareErrorMessage)
+	}
+	if userAuthentication == nil || userPassword.UserId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(passwordShareAuthenticationErrorMessage)
+	}
+	if userPassword.OrganizationId != nil {
+		return nil, gqlerror.Errorf(passwordShareOrganizationErrorMessage)
+	}
+
+	recipient := databaseModel.User{}
+	err = r.userRepository.FetchByEmail(ctx, &recipient, recipientEmail, []string{"id", "email"})
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, repositoryError(err, queryNonExistingEmailErrorMessage)
+		}
+		log.Printf("Error while fetching user by email: %s", err)
+		return nil, repositoryError(err, passwordShareErrorMessage)
+	}
+	if recipient.Id == userAuthentication.UserId {
+		return nil, gqlerror.Errorf(passwordShareSelfErrorMessage)
+	}
+
+	user := databaseModel.User{}
+	err = r.userRepository.FetchMasterPasswordByUserId(&user, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching user master password: %s", err)
+		return nil, repositoryError(err, passwordShareErrorMessage)
+	}
+	decryptedPassword, err := r.passwordSecurityService.DecryptWithAes(userPassword.Password, user.Password)
+	if err != nil {
+		log.Printf("Error while decrypting user password: %s", err)
+		return nil, gqlerror.Errorf(passwordShareErrorMessage)
+	}
+	totpUri, err := r.decryptTotpUri(userPassword.TotpUri, user.Password)
+	if err != nil {
+		log.Printf("Error while decrypting user TOTP uri: %s", err)
+		return nil, gqlerror.Errorf(passwordShareErrorMessage)
+	}
+
+	content := &sharedEntryContent{Name: userPassword.Name, Password: decryptedPassword, TotpUri: totpUri}
+	sealedPassword, err := r.sealSharedEntry(content, recipient.Id)
+	if err != nil {
+		log.Printf("Error while sealing shared entry: %s", err)
+		return nil, gqlerror.Errorf(passwordShareErrorMessage)
+	}
+
+	share := databaseModel.PasswordShareDetails{
+		PasswordShare: databaseModel.PasswordShare{
+			PasswordId:     passwordId,
+			RecipientId:    recipient.Id,
+			Permission:     strings.ToLower(permission.String()),
+			SealedPassword: sealedPassword,
+		},
+		RecipientEmail: recipient.Email,
+	}
+	insertResult, err := r.passwordShareRepository.InsertNewShare(&share.PasswordShare)
+	if err != nil {
+		if err == repository.ErrDuplicate {
+			return nil, repositoryError(err, passwordShareExistingErrorMessage)
+		}
+		log.Printf("Error while storing entry share: %s", err)
+		return nil, repositoryError(err, passwordShareErrorMessage)
+	}
+	share.Id = uint64(insertResult.ID().(int64))
+
+	return toEntryShare(&share), nil
+}
+
+func (r *mutationResolver) UpdateSharedEntry(ctx context.Context, input model.UpdateSharedEntry) (*model.SharedEntry, error) {
+	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
+	if validationErrors != nil {
+		return nil, gqlerror.Errorf("validation error/s on shared entry input")
+	}
+	totpUri, err := normalizeTotpUri(input.TotpURI)
+	if err != nil {
+		return nil, err
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	share, err := r.fetchPasswordShare(input.ShareID)
+	if err != nil {
+		return nil, err
+	}
+	if userAuthentication == nil || share.RecipientId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(passwordShareAuthenticationErrorMessage)
+	}
+	if share.Permission != databaseModel.SharePermissionWrite {
+		return nil, gqlerror.Errorf(passwordSharePermissionErrorMessage)
+	}
+
+	// The owner's copy stays encrypted with the owner's vault key
+	owner := databaseModel.User{}
+	err = r.userRepository.FetchMasterPasswordByUserId(&owner, share.OwnerId)
+	if err != nil {
+		log.Printf("Error while fetching user master password: %s", err)
+		return nil, repositoryError(err, passwordShareErrorMessage)
+	}
+	encryptedPassword, err := r.passwordSecurityService.EncryptWithAes(input.Password, owner.Password)
+	if err != nil {
+		log.Printf("Error while encrypting user password: %s", err)
+		return nil, gqlerror.Errorf(passwordShareErrorMessage)
+	}
+	encryptedTotpUri, err := r.encryptTotpUri(totpUri, owner.Password)
+	if err != nil {
+		log.Printf("Error while encrypting user TOTP uri: %s", err)
+		return nil, gqlerror.Errorf(passwordShareErrorMessage)
+	}
+
+	content := &sharedEntryContent{Name: input.Name, Password: input.Password, TotpUri: totpUri}
+	sharedPassword := &databaseModel.Password{Id: share.PasswordId, Name: input.Name, Password: encryptedPassword, TotpUri: encryptedTotpUri}
+	err = r.storePasswordUpdate(sharedPassword, content, nil)
+	if err != nil {
+		if err == repository.ErrPasswordSharesChanged {
+			return nil, repositoryError(err, passwordSharesChangedErrorMessage)
+		}
+		log.Printf("Error while updating shared entry: %s", err)
+		return nil, repositoryError(err, passwordShareErrorMessage)
+	}
+	r.publishVaultChange(share.OwnerId, share.PasswordId, eventbus.VaultEntryUpdated)
+
+	return toSharedEntry(share, content), nil
+}
+
+func (r *mutationResolver) RevokeEntryShare(ctx context.Context, shareID string) (bool, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	share, err := r.fetchPasswordShare(shareID)
+	if err != nil {
+		return false, err
+	}
+	// Recipients can give up an entry shared with them as well
+	if userAuthentication == nil || (share.OwnerId != userAuthentication.UserId && share.RecipientId != userAuthentication.UserId) {
+		return false, gqlerror.Errorf(passwordShareAuthenticationErrorMessage)
+	}
+
+	err = r.passwordShareRepository.DeleteShareById(share.Id)
+	if err != nil {
+		log.Printf("Error while deleting entry share: %s", err)
+		return false, repositoryError(err, passwordShareErrorMessage)
+	}
+
+	return true, nil
+}
+
+func (r *queryResolver) SharedWithMe(ctx context.Context) ([]*model.SharedEntry, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(passwordShareAuthenticationErrorMessage)
+	}
+
+	fetchedShares := databaseModel.PasswordSharesDetails{}
+	err := r.passwordShareRepository.FetchSharesDetailsByRecipientId(&fetchedShares, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching entry shares: %s", err)
+		return nil, repositoryError(err, sharedWithMeFetchErrorMessage)
+	}
+
+	sharedEntries := []*model.SharedEntry{}
+	if len(fetchedShares) == 0 {
+		return sharedEntries, nil
+	}
+
+	keyPair := databaseModel.KeyPair{}
+	err = r.keyPairRepository.FetchKeyPairByUserId(&keyPair, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching user key pair: %s", err)
+		return nil, repositoryError(err, sharedWithMeFetchErrorMessage)
+	}
+	user := databaseModel.User{}
+	err = r.userRepository.FetchMasterPasswordByUserId(&user, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching user master password: %s", err)
+		return nil, repositoryError(err, sharedWithMeFetchErrorMessage)
+	}
+
+	for index := range fetchedShares {
+		share := &fetchedShares[index]
+		content, err := r.openSharedEntry(share, &keyPair, user.Password)
+		if err != nil {
+			log.Printf("Error while opening shared entry: %s", err)
+			return nil, gqlerror.Errorf(sharedWithMeFetchErrorMessage)
+		}
+		sharedEntries = append(sharedEntries, toSharedEntry(share, content))
+	}
+	return sharedEntries, nil
+}
+
+func (r *queryResolver) EntryShares(ctx context.Context, entryID string) ([]*model.EntryShare, error) {
+	passwordId, err := strconv.ParseUint(entryID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting password id to uint64: %s", err)
+		return nil, gqlerror.Errorf(passwordShareErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	userPassword := databaseModel.Password{}
+	err = r.passwordRepository.FetchPasswordById(ctx, &userPassword, passwordId)
+	if err != nil {
+		log.Printf("Error occurred while fetching user password by id: %s", err)
+		return nil, repositoryError(err, passwordShareErrorMessage)
+	}
+	if userAuthentication == nil || userPassword.UserId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(passwordShareAuthenticationErrorMessage)
+	}
+
+	fetchedShares := databaseModel.PasswordSharesDetails{}
+	err = r.passwordShareRepository.FetchSharesDetailsByPasswordId(&fetchedShares, passwordId)
+	if err != nil {
+		log.Printf("Error while fetching entry shares: %s", err)
+		return nil, repositoryError(err, passwordShareErrorMessage)
+	}
+
+	shares := []*model.EntryShare{}
+	for index := range fetchedShares {
+		shares = append(shares, toEntryShare(&fetchedShares[index]))
+	}
+	return shares, nil
+}
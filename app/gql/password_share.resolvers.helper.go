@@ -0,0 +1,130 @@
+package gql
+
+import (
+	"encoding/json"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"log"
+	"strconv"
+)
+
+const (
+	passwordShareErrorMessage               = "could not process entry share"
+	passwordShareAuthenticationErrorMessage = "unauthorized entry share request"
+	passwordShareSelfErrorMessage           = "you can't share an entry with yourself"
+	passwordShareExistingErrorMessage       = "the entry is already shared with the user"
+	passwordShareOrganizationErrorMessage   = "entries in a shared vault can't be shared individually"
+	passwordSharePermissionErrorMessage     = "the entry share doesn't allow changes"
+	passwordSharesChangedErrorMessage       = "the entry's shares changed during the update, try again"
+	sharedPasswordMoveErrorMessage          = "revoke the entry's shares before moving it to an organization"
+	sharedWithMeFetchErrorMessage           = "could not fetch entries shared with you"
+)
+
+var sharePermissions = map[string]model.SharePermission{
+	databaseModel.SharePermissionRead:  model.SharePermissionRead,
+	databaseModel.SharePermissionWrite: model.SharePermissionWrite,
+}
+
+// sharedEntryContent is the part of an entry that gets sealed for each recipient
+type sharedEntryContent struct {
+	Name     string  `json:"name"`
+	Password string  `json:"password"`
+	TotpUri  *string `json:"totpUri,omitempty"`
+}
+
+func toEntryShare(share *databaseModel.PasswordShareDetails) *model.EntryShare {
+	return &model.EntryShare{
+		ID:             strconv.FormatUint(share.Id, 10),
+		EntryID:        strconv.FormatUint(share.PasswordId, 10),
+		RecipientEmail: share.RecipientEmail,
+		Permission:     sharePermissions[share.Permission],
+	}
+}
+
+func toSharedEntry(share *databaseModel.PasswordShareDetails, content *sharedEntryContent) *model.SharedEntry {
+	return &model.SharedEntry{
+		ID:          strconv.FormatUint(share.Id, 10),
+		EntryID:     strconv.FormatUint(share.PasswordId, 10),
+		OwnerEmail:  share.OwnerEmail,
+		Permission:  sharePermissions[share.Permission],
+		Name:        content.Name,
+		Password:    content.Password,
+		TotpURI:     content.TotpUri,
+		CurrentCode: toOneTimeCode(content.TotpUri),
+	}
+}
+
+func (r *Resolver) sealSharedEntry(content *sharedEntryContent, recipientId uint64) ([]byte, error) {
+	keyPair, err := r.fetchOrCreateKeyPair(r.keyPairRepository, r.userRepository, recipientId)
+	if err != nil {
+		return nil, err
+	}
+	message, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.passwordSecurityService.SealForPublicKey(message, keyPair.PublicKey)
+}
+
+func (r *Resolver) openSharedEntry(
+	share *databaseModel.PasswordShareDetails, keyPair *databaseModel.KeyPair, vaultKey []byte,
+) (*sharedEntryContent, error) {
+	message, err := r.passwordSecurityService.OpenWithPrivateKey(share.SealedPassword, keyPair.PublicKey, keyPair.WrappedPrivateKey, vaultKey)
+	if err != nil {
+		return nil, err
+	}
+
+	content := &sharedEntryContent{}
+	if err = json.Unmarshal(message, content); err != nil {
+		return nil, err
+	}
+	return content, nil
+}
+
+// storePasswordUpdate updates the entry and reseals it for every recipient, so the update propagates to all shared copies,
+// the entry is reloaded with its new version and revision
+func (r *Resolver) storePasswordUpdate(
+	password *databaseModel.Password, content *sharedEntryContent, expectedVersion *int64,
+) error {
+	shares := databaseModel.PasswordSharesDetails{}
+	if err := r.passwordShareRepository.FetchSharesDetailsByPasswordId(&shares, password.Id); err != nil {
+		return err
+	}
+	if len(shares) == 0 {
+		return r.passwordRepository.UpdatePasswordById(password, expectedVersion)
+	}
+
+	sealedPasswords := map[uint64][]byte{}
+	for _, share := range shares {
+		sealedPassword, err := r.sealSharedEntry(content, share.RecipientId)
+		if err != nil {
+			return err
+		}
+		sealedPasswords[share.Id] = sealedPassword
+	}
+
+	return r.passwordShareRepository.UpdateSharedPasswordById(password, sealedPasswords, expectedVersion)
+}
+
+func (r *Resolver) fetchPasswordShare(shareID string) (*databaseModel.PasswordShareDetails, error) {
+	shareId, err := strconv.ParseUint(shareID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting entry share id to uint64: %s", err)
+		return nil, gqlerror.Errorf(passwordShareErrorMessage)
+	}
+
+	share := &databaseModel.PasswordShareDetails{}
+	err = r.passwordShareRepository.FetchShareDetailsById(share, shareId)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, gqlerror.Errorf(passwordShareAuthenticationErrorMessage)
+		}
+		log.Printf("Error while fetching entry share: %s", err)
+		return nil, repositoryError(err, passwordShareErrorMessage)
+	}
+
+	return share, nil
+}
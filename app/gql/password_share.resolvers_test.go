@@ -0,0 +1,351 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/99designs/gqlgen/graphql"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ShareEntry should seal the decrypted entry for the recipient
+func (suite *schemaResolverTestSuite) TestShareEntry() {
+	suite.resolver.userRepository = recipientUserRepositoryMock()
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	passwordSecurityServiceMock := mockutil.DefaultPasswordSecurityServiceMock()
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+	passwordShareRepositoryServiceMock := mockutil.DefaultPasswordShareRepositoryServiceMock()
+	suite.resolver.passwordShareRepository = passwordShareRepositoryServiceMock
+
+	share, err := suite.mutationResolver.ShareEntry(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, mockutil.DefaultGranteeEmail, model.SharePermissionRead,
+	)
+	assert.Nil(suite.T(), err, "Should share the entry without any errors")
+	assert.Equal(suite.T(), &model.EntryShare{
+		ID:             mockutil.DefaultIdAsString,
+		EntryID:        mockutil.DefaultIdAsString,
+		RecipientEmail: mockutil.DefaultGranteeEmail,
+		Permission:     model.SharePermissionRead,
+	}, share)
+
+	sealedMessage, _ := json.Marshal(sharedEntryContent{Name: mockutil.DefaultPasswordName, Password: mockutil.MockedDecryptedPassword})
+	passwordSecurityServiceMock.AssertCalled(suite.T(), "SealForPublicKey", sealedMessage, []byte(mockutil.MockedPublicKey))
+	passwordShareRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewShare", &databaseModel.PasswordShare{
+		Id:             mockutil.DefaultIdAsUint64,
+		PasswordId:     mockutil.DefaultIdAsUint64,
+		RecipientId:    mockutil.DefaultGranteeIdAsUint64,
+		Permission:     databaseModel.SharePermissionRead,
+		SealedPassword: []byte(mockutil.MockedSealedVaultKey),
+	})
+}
+
+// ShareEntry should return an error when sharing an entry with yourself
+func (suite *schemaResolverTestSuite) TestShareEntryWithYourself() {
+	passwordShareRepositoryServiceMock := mockutil.DefaultPasswordShareRepositoryServiceMock()
+	suite.resolver.passwordShareRepository = passwordShareRepositoryServiceMock
+
+	share, err := suite.mutationResolver.ShareEntry(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, mockutil.DefaultEmail, model.SharePermissionRead,
+	)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("you can't share an entry with yourself"),
+		"Should return expected error when sharing an entry with yourself",
+	)
+	assert.Nil(suite.T(), share, "Should not return a share")
+	passwordShareRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertNewShare", mock.Anything)
+}
+
+// ShareEntry should not share entries of other users
+func (suite *schemaResolverTestSuite) TestShareEntryOfAnotherUser() {
+	authenticateEmergencyAccessGrantee(suite)
+
+	share, err := suite.mutationResolver.ShareEntry(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, mockutil.DefaultGranteeEmail, model.SharePermissionRead,
+	)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized entry share request"),
+		"Should return expected error when sharing an entry of another user",
+	)
+	assert.Nil(suite.T(), share, "Should not return a share")
+}
+
+// ShareEntry should not share entries from an organization's shared vault
+func (suite *schemaResolverTestSuite) TestShareOrganizationEntry() {
+	suite.resolver.passwordRepository = passwordRepositoryMockWithSharedPassword()
+	authenticateEmergencyAccessGrantee(suite)
+
+	share, err := suite.mutationResolver.ShareEntry(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, mockutil.DefaultEmail, model.SharePermissionRead,
+	)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("entries in a shared vault can't be shared individually"),
+		"Should return expected error when sharing an organization entry",
+	)
+	assert.Nil(suite.T(), share, "Should not return a share")
+}
+
+// ShareEntry should return an error if the entry is already shared with the recipient
+func (suite *schemaResolverTestSuite) TestShareEntryTwice() {
+	suite.resolver.userRepository = recipientUserRepositoryMock()
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	passwordShareRepositoryServiceMock := mockutil.DefaultPasswordShareRepositoryServiceMock()
+	passwordShareRepositoryServiceMock.ExpectedCalls = nil
+	passwordShareRepositoryServiceMock.On("InsertNewShare", mock.Anything).Return(nil, repository.ErrDuplicate).Times(1)
+	suite.resolver.passwordShareRepository = passwordShareRepositoryServiceMock
+
+	share, err := suite.mutationResolver.ShareEntry(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, mockutil.DefaultGranteeEmail, model.SharePermissionRead,
+	)
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{Message: "the entry is already shared with the user", Extensions: map[string]interface{}{"code": "DUPLICATE"}},
+		"Should return expected error when the entry is already shared with the recipient",
+	)
+	assert.Nil(suite.T(), share, "Should not return a share")
+}
+
+// UpdatePassword should propagate the update to every recipient's copy
+func (suite *schemaResolverTestSuite) TestUpdateSharedPasswordPropagatesToRecipients() {
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	passwordSecurityServiceMock := mockutil.DefaultPasswordSecurityServiceMock()
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+	passwordShareRepositoryServiceMock := passwordShareRepositoryMockWithShares(nil)
+	suite.resolver.passwordShareRepository = passwordShareRepositoryServiceMock
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
+
+	_, err := suite.mutationResolver.UpdatePassword(suite.graphqlRequestContext, input)
+	assert.Nil(suite.T(), err, "Should update the password without any errors")
+
+	sealedMessage, _ := json.Marshal(sharedEntryContent{Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword})
+	passwordSecurityServiceMock.AssertCalled(suite.T(), "SealForPublicKey", sealedMessage, []byte(mockutil.MockedPublicKey))
+	expectedVersion := mockutil.DefaultVersion
+	passwordShareRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateSharedPasswordById", updatedPasswordMatcher(mockutil.DefaultPasswordName),
+		map[uint64][]byte{mockutil.DefaultIdAsUint64: []byte(mockutil.MockedSealedVaultKey)}, &expectedVersion,
+	)
+	passwordRepositoryServiceMock.AssertNotCalled(suite.T(), "UpdatePasswordById", mock.Anything, mock.Anything)
+}
+
+// UpdatePassword should return an error if the entry got shared during the update
+func (suite *schemaResolverTestSuite) TestUpdateSharedPasswordWithChangedShares() {
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.passwordShareRepository = passwordShareRepositoryMockWithShares(repository.ErrPasswordSharesChanged)
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
+
+	password, err := suite.mutationResolver.UpdatePassword(suite.graphqlRequestContext, input)
+	assert.Equal(
+		suite.T(), err,
+		&gqlerror.Error{Message: "the entry's shares changed during the update, try again", Extensions: map[string]interface{}{"code": "CONFLICT"}},
+		"Should return expected error when the shares changed during the update",
+	)
+	assert.Nil(suite.T(), password, "Should not return a password")
+}
+
+// UpdateSharedEntry should let recipients with write permission update the owner's entry
+func (suite *schemaResolverTestSuite) TestUpdateSharedEntry() {
+	authenticateEmergencyAccessGrantee(suite)
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	userRepositoryServiceMock := mockutil.DefaultUserRepositoryServiceMock()
+	suite.resolver.userRepository = userRepositoryServiceMock
+	passwordShareRepositoryServiceMock := passwordShareRepositoryMockWithShares(nil)
+	suite.resolver.passwordShareRepository = passwordShareRepositoryServiceMock
+	input := model.UpdateSharedEntry{ShareID: mockutil.DefaultIdAsString, Name: "updated.com", Password: "updatedPassword"}
+
+	sharedEntry, err := suite.mutationResolver.UpdateSharedEntry(suite.graphqlRequestContext, input)
+	assert.Nil(suite.T(), err, "Should update the shared entry without any errors")
+	assert.Equal(suite.T(), &model.SharedEntry{
+		ID:         mockutil.DefaultIdAsString,
+		EntryID:    mockutil.DefaultIdAsString,
+		OwnerEmail: mockutil.DefaultEmail,
+		Permission: model.SharePermissionWrite,
+		Name:       "updated.com",
+		Password:   "updatedPassword",
+	}, sharedEntry)
+
+	userRepositoryServiceMock.AssertCalled(suite.T(), "FetchMasterPasswordByUserId", mock.Anything, mockutil.DefaultIdAsUint64)
+	passwordShareRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateSharedPasswordById", updatedPasswordMatcher("updated.com"), mock.Anything, (*int64)(nil),
+	)
+}
+
+// UpdateSharedEntry should return an error if the share only allows reading the entry
+func (suite *schemaResolverTestSuite) TestUpdateSharedEntryWithReadPermission() {
+	authenticateEmergencyAccessGrantee(suite)
+	share := mockutil.DefaultPasswordShare()
+	share.Permission = databaseModel.SharePermissionRead
+	passwordShareRepositoryServiceMock := mockutil.DefaultPasswordShareRepositoryServiceMock()
+	passwordShareRepositoryServiceMock.ExpectedCalls = nil
+	passwordShareRepositoryServiceMock.On("FetchShareDetailsById", mock.Anything, mock.Anything).Return(nil, share).Times(1)
+	suite.resolver.passwordShareRepository = passwordShareRepositoryServiceMock
+	input := model.UpdateSharedEntry{ShareID: mockutil.DefaultIdAsString, Name: "updated.com", Password: "updatedPassword"}
+
+	sharedEntry, err := suite.mutationResolver.UpdateSharedEntry(suite.graphqlRequestContext, input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("the entry share doesn't allow changes"),
+		"Should return expected error when the share only allows reading",
+	)
+	assert.Nil(suite.T(), sharedEntry, "Should not return a shared entry")
+}
+
+// UpdateSharedEntry should return expected error on failed input validation
+func (suite *schemaResolverTestSuite) TestUpdateSharedEntryValidation() {
+	ctx := graphql.WithResponseContext(context.Background(), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	sharedEntry, err := suite.mutationResolver.UpdateSharedEntry(ctx, model.UpdateSharedEntry{ShareID: mockutil.DefaultIdAsString})
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("validation error/s on shared entry input"),
+		"Should return expected error when the input is invalid",
+	)
+	assert.Nil(suite.T(), sharedEntry, "Should not return a shared entry")
+}
+
+// RevokeEntryShare should let the owner revoke a share
+func (suite *schemaResolverTestSuite) TestRevokeEntryShare() {
+	passwordShareRepositoryServiceMock := mockutil.DefaultPasswordShareRepositoryServiceMock()
+	suite.resolver.passwordShareRepository = passwordShareRepositoryServiceMock
+
+	revoked, err := suite.mutationResolver.RevokeEntryShare(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should revoke the share without any errors")
+	assert.True(suite.T(), revoked, "Should confirm the revocation")
+	passwordShareRepositoryServiceMock.AssertCalled(suite.T(), "DeleteShareById", mockutil.DefaultIdAsUint64)
+}
+
+// RevokeEntryShare should let the recipient give up a share
+func (suite *schemaResolverTestSuite) TestRevokeEntryShareAsRecipient() {
+	authenticateEmergencyAccessGrantee(suite)
+
+	revoked, err := suite.mutationResolver.RevokeEntryShare(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should revoke the share without any errors")
+	assert.True(suite.T(), revoked, "Should confirm the revocation")
+}
+
+// RevokeEntryShare should not let other users revoke a share
+func (suite *schemaResolverTestSuite) TestRevokeEntryShareOfAnotherUser() {
+	share := mockutil.DefaultPasswordShare()
+	share.OwnerId = uint64(3)
+	passwordShareRepositoryServiceMock := mockutil.DefaultPasswordShareRepositoryServiceMock()
+	passwordShareRepositoryServiceMock.ExpectedCalls = nil
+	passwordShareRepositoryServiceMock.On("FetchShareDetailsById", mock.Anything, mock.Anything).Return(nil, share).Times(1)
+	suite.resolver.passwordShareRepository = passwordShareRepositoryServiceMock
+
+	revoked, err := suite.mutationResolver.RevokeEntryShare(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized entry share request"),
+		"Should return expected error when revoking a share of another user",
+	)
+	assert.False(suite.T(), revoked, "Should not confirm the revocation")
+	passwordShareRepositoryServiceMock.AssertNotCalled(suite.T(), "DeleteShareById", mock.Anything)
+}
+
+// SharedWithMe should open every entry shared with the user
+func (suite *schemaResolverTestSuite) TestSharedWithMe() {
+	authenticateEmergencyAccessGrantee(suite)
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	sealedMessage, _ := json.Marshal(sharedEntryContent{Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword})
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("OpenWithPrivateKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(sealedMessage, nil).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	sharedEntries, err := suite.queryResolver.SharedWithMe(suite.graphqlRequestContext)
+	assert.Nil(suite.T(), err, "Should fetch shared entries without any errors")
+	assert.Equal(suite.T(), []*model.SharedEntry{{
+		ID:         mockutil.DefaultIdAsString,
+		EntryID:    mockutil.DefaultIdAsString,
+		OwnerEmail: mockutil.DefaultEmail,
+		Permission: model.SharePermissionWrite,
+		Name:       mockutil.DefaultPasswordName,
+		Password:   mockutil.DefaultPassword,
+	}}, sharedEntries)
+	passwordSecurityServiceMock.AssertCalled(
+		suite.T(), "OpenWithPrivateKey", []byte(mockutil.MockedSealedPassword), []byte(mockutil.MockedPublicKey),
+		[]byte(mockutil.MockedWrappedPrivateKey), []byte(mockutil.MockedUserMasterPassword),
+	)
+}
+
+// SharedWithMe should return an error if a shared entry can't be opened
+func (suite *schemaResolverTestSuite) TestSharedWithMeWithOpenError() {
+	authenticateEmergencyAccessGrantee(suite)
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("OpenWithPrivateKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]byte("not json"), nil).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	sharedEntries, err := suite.queryResolver.SharedWithMe(suite.graphqlRequestContext)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("could not fetch entries shared with you"),
+		"Should return expected error when a shared entry can't be opened",
+	)
+	assert.Nil(suite.T(), sharedEntries, "Should not return any shared entries")
+}
+
+// EntryShares should return all shares of the owner's entry
+func (suite *schemaResolverTestSuite) TestEntryShares() {
+	suite.resolver.passwordShareRepository = passwordShareRepositoryMockWithShares(nil)
+
+	shares, err := suite.queryResolver.EntryShares(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should fetch shares without any errors")
+	assert.Equal(suite.T(), []*model.EntryShare{{
+		ID:             mockutil.DefaultIdAsString,
+		EntryID:        mockutil.DefaultIdAsString,
+		RecipientEmail: mockutil.DefaultGranteeEmail,
+		Permission:     model.SharePermissionWrite,
+	}}, shares)
+}
+
+// MovePasswordToOrganization should not move entries that are shared with other users
+func (suite *schemaResolverTestSuite) TestMoveSharedEntryToOrganization() {
+	suite.resolver.passwordShareRepository = passwordShareRepositoryMockWithShares(nil)
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+
+	password, err := suite.mutationResolver.MovePasswordToOrganization(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, mockutil.DefaultIdAsString,
+	)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("revoke the entry's shares before moving it to an organization"),
+		"Should return expected error when moving a shared entry",
+	)
+	assert.Nil(suite.T(), password, "Should not return a password")
+	passwordRepositoryServiceMock.AssertNotCalled(suite.T(), "MovePasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+// recipientUserRepositoryMock resolves the recipient's e-mail to the grantee
+func recipientUserRepositoryMock() *granteeUserRepositoryServiceMock {
+	serviceMock := new(granteeUserRepositoryServiceMock)
+	serviceMock.On("FetchByEmail", mock.Anything, mockutil.DefaultGranteeEmail, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("FetchMasterPasswordByUserId", mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return serviceMock
+}
+
+// passwordShareRepositoryMockWithShares mocks the default password shared with the grantee
+func passwordShareRepositoryMockWithShares(updateError error) *mockutil.PasswordShareRepositoryServiceMock {
+	serviceMock := mockutil.DefaultPasswordShareRepositoryServiceMock()
+	serviceMock.ExpectedCalls = nil
+	serviceMock.On("FetchShareDetailsById", mock.Anything, mock.Anything).Return(nil, mockutil.DefaultPasswordShare()).Times(1)
+	serviceMock.On("FetchSharesDetailsByPasswordId", mock.Anything, mock.Anything).
+		Return(nil, databaseModel.PasswordSharesDetails{mockutil.DefaultPasswordShare()}).Times(1)
+	serviceMock.On("UpdateSharedPasswordById", mock.Anything, mock.Anything, mock.Anything).Return(updateError).Times(1)
+
+	return serviceMock
+}
+
+// updatedPasswordMatcher matches the default entry updated with the given name
+func updatedPasswordMatcher(name string) interface{} {
+	return mock.MatchedBy(func(password *databaseModel.Password) bool {
+		return password.Id == mockutil.DefaultIdAsUint64 && password.Name == name &&
+			string(password.Password) == mockutil.MockedEncryptedPassword
+	})
+}
@@ -0,0 +1,33 @@
+package gql
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/vektah/gqlparser/v2/ast"
+)
+
+// ReadConsistency makes mutations read from the primary database, so everything they read after writing reflects
+// their writes, while queries may read from replicas lagging slightly behind
+type ReadConsistency struct{}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = ReadConsistency{}
+
+func (ReadConsistency) ExtensionName() string {
+	return "ReadConsistency"
+}
+
+func (ReadConsistency) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (ReadConsistency) InterceptOperation(ctx context.Context, next graphql.OperationHandler) graphql.ResponseHandler {
+	operation := graphql.GetOperationContext(ctx).Operation
+	if operation != nil && operation.Operation == ast.Mutation {
+		ctx = repository.WithPrimaryReads(ctx)
+	}
+	return next(ctx)
+}
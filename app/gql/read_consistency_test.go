@@ -0,0 +1,26 @@
+package gql
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/ast"
+	"testing"
+)
+
+// ReadConsistency should make mutations read from the primary database and leave the reads of other operations as they are
+func TestReadConsistency(t *testing.T) {
+	expectedPrimaryReads := map[ast.Operation]bool{ast.Query: false, ast.Mutation: true, ast.Subscription: false}
+	for operation, expected := range expectedPrimaryReads {
+		ctx := graphql.WithOperationContext(
+			context.Background(), &graphql.OperationContext{Operation: &ast.OperationDefinition{Operation: operation}},
+		)
+		var primaryReads bool
+		ReadConsistency{}.InterceptOperation(ctx, func(ctx context.Context) graphql.ResponseHandler {
+			primaryReads = repository.PrimaryReads(ctx)
+			return nil
+		})
+		assert.Equal(t, primaryReads, expected, "Unexpected reads of a %s", operation)
+	}
+}
@@ -1,31 +1,98 @@
 package gql
 
 import (
+	"github.com/KristijanFaust/gokeeper/app/attachment"
+	"github.com/KristijanFaust/gokeeper/app/audit"
 	"github.com/KristijanFaust/gokeeper/app/authentication"
 	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/emergencyaccess"
+	"github.com/KristijanFaust/gokeeper/app/eventbus"
+	"github.com/KristijanFaust/gokeeper/app/mail"
 	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/KristijanFaust/gokeeper/app/send"
+	"github.com/KristijanFaust/gokeeper/app/webauthn"
 	"github.com/go-playground/validator"
 )
 
 type Resolver struct {
-	userRepository          repository.UserRepository
-	passwordRepository      repository.PasswordRepository
-	passwordSecurityService security.PasswordSecurity
-	authenticationService   authentication.JwtAuthenticator
-	validator               *validator.Validate
+	userRepository            repository.UserRepository
+	passwordRepository        repository.PasswordRepository
+	webauthnRepository        repository.WebauthnRepository
+	knownDeviceRepository     repository.KnownDeviceRepository
+	keyPairRepository         repository.KeyPairRepository
+	emergencyAccessRepository repository.EmergencyAccessRepository
+	sessionRepository         repository.SessionRepository
+	organizationRepository    repository.OrganizationRepository
+	passwordShareRepository   repository.PasswordShareRepository
+	sendRepository            repository.SendRepository
+	adminRepository           repository.AdminRepository
+	auditEventRepository      repository.AuditEventRepository
+	webhookRepository         repository.WebhookRepository
+	attachmentRepository      repository.AttachmentRepository
+	unitOfWork                repository.UnitOfWork
+	passwordSecurityService   security.PasswordSecurity
+	authenticationService     authentication.JwtAuthenticator
+	webauthnVerifier          webauthn.WebauthnVerifier
+	mailer                    mail.Mailer
+	emergencyAccessApprover   emergencyaccess.Approver
+	sendManager               send.Manager
+	auditRecorder             audit.Recorder
+	vaultEventBus             eventbus.Bus
+	attachmentManager         attachment.Manager
+	validator                 *validator.Validate
 }
 
 func NewResolver(
 	userRepository repository.UserRepository,
 	passwordRepository repository.PasswordRepository,
+	webauthnRepository repository.WebauthnRepository,
+	knownDeviceRepository repository.KnownDeviceRepository,
+	keyPairRepository repository.KeyPairRepository,
+	emergencyAccessRepository repository.EmergencyAccessRepository,
+	sessionRepository repository.SessionRepository,
+	organizationRepository repository.OrganizationRepository,
+	passwordShareRepository repository.PasswordShareRepository,
+	sendRepository repository.SendRepository,
+	adminRepository repository.AdminRepository,
+	auditEventRepository repository.AuditEventRepository,
+	webhookRepository repository.WebhookRepository,
+	attachmentRepository repository.AttachmentRepository,
+	unitOfWork repository.UnitOfWork,
 	passwordSecurityService security.PasswordSecurity,
 	authenticationService authentication.JwtAuthenticator,
+	webauthnVerifier webauthn.WebauthnVerifier,
+	mailer mail.Mailer,
+	emergencyAccessApprover emergencyaccess.Approver,
+	sendManager send.Manager,
+	auditRecorder audit.Recorder,
+	vaultEventBus eventbus.Bus,
+	attachmentManager attachment.Manager,
 ) *Resolver {
 	return &Resolver{
-		userRepository:          userRepository,
-		passwordRepository:      passwordRepository,
-		passwordSecurityService: passwordSecurityService,
-		authenticationService:   authenticationService,
-		validator:               validator.New(),
+		userRepository:            userRepository,
+		passwordRepository:        passwordRepository,
+		webauthnRepository:        webauthnRepository,
+		knownDeviceRepository:     knownDeviceRepository,
+		keyPairRepository:         keyPairRepository,
+		emergencyAccessRepository: emergencyAccessRepository,
+		sessionRepository:         sessionRepository,
+		organizationRepository:    organizationRepository,
+		passwordShareRepository:   passwordShareRepository,
+		sendRepository:            sendRepository,
+		adminRepository:           adminRepository,
+		auditEventRepository:      auditEventRepository,
+		webhookRepository:         webhookRepository,
+		attachmentRepository:      attachmentRepository,
+		unitOfWork:                unitOfWork,
+		passwordSecurityService:   passwordSecurityService,
+		authenticationService:     authenticationService,
+		webauthnVerifier:          webauthnVerifier,
+		mailer:                    mailer,
+		emergencyAccessApprover:   emergencyAccessApprover,
+		sendManager:               sendManager,
+		auditRecorder:             auditRecorder,
+		vaultEventBus:             vaultEventBus,
+		attachmentManager:         attachmentManager,
+		validator:                 validator.New(),
 	}
 }
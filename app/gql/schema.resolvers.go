@@ -6,19 +6,21 @@ package gql
 import (
 	"context"
 	"crypto/subtle"
+	"encoding/base64"
 	"log"
 	"strconv"
-	"strings"
 
 	"github.com/99designs/gqlgen/graphql"
 	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/eventbus"
 	"github.com/KristijanFaust/gokeeper/app/gql/generated"
 	"github.com/KristijanFaust/gokeeper/app/gql/model"
-	"github.com/lib/pq"
+	"github.com/KristijanFaust/gokeeper/app/mail"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
 
-func (r *mutationResolver) SignUp(ctx context.Context, input model.NewUser) (*model.User, error) {
+func (r *mutationResolver) SignUp(ctx context.Context, input model.NewUser) (*model.UserWithRecoveryKey, error) {
 	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
 	if validationErrors != nil {
 		return nil, gqlerror.Errorf("validation error/s on user input")
@@ -26,49 +28,108 @@ func (r *mutationResolver) SignUp(ctx context.Context, input model.NewUser) (*mo
 
 	passwordHash := r.passwordSecurityService.HashWithArgon2id(input.Password)
 
-	newUser := databaseModel.User{Email: input.Email, Username: input.Username, Password: passwordHash}
-	insertResult, err := r.userRepository.InsertNewUser(&newUser)
+	recoveryKey, err := r.passwordSecurityService.GenerateRecoveryKey()
 	if err != nil {
-		switch errorType := err.(type) {
-		case *pq.Error:
-			if errorType.Code == "23505" {
-				return nil, gqlerror.Errorf(existingEmailErrorMessage)
-			}
-		default:
-			return nil, gqlerror.Errorf(userCreationErrorMessage)
+		log.Printf("Error while generating recovery key: %s", err)
+		return nil, gqlerror.Errorf(userCreationErrorMessage)
+	}
+	recoveryWrappedKey, err := r.passwordSecurityService.WrapWithRecoveryKey(passwordHash, recoveryKey)
+	if err != nil {
+		log.Printf("Error while wrapping vault key with recovery key: %s", err)
+		return nil, gqlerror.Errorf(userCreationErrorMessage)
+	}
+
+	// The user is only kept together with the verification token, which is only sent once both are stored
+	userId, verificationToken := uint64(0), ""
+	err = r.unitOfWork.Execute(func(repositories *repository.Repositories) error {
+		newUser := databaseModel.User{Email: input.Email, Username: input.Username, Password: passwordHash, RecoveryWrappedKey: recoveryWrappedKey}
+		insertResult, err := repositories.User.InsertNewUser(&newUser)
+		if err != nil {
+			return err
+		}
+
+		userId = uint64(insertResult.ID().(int64))
+		verificationToken, err = r.issueEmailVerificationToken(repositories.User, userId)
+		return err
+	})
+	if err != nil {
+		if err == repository.ErrDuplicate {
+			return nil, repositoryError(err, existingEmailErrorMessage)
 		}
+		log.Printf("Error while storing user: %s", err)
+		return nil, repositoryError(err, userCreationErrorMessage)
+	}
+
+	r.recordAuditEvent(ctx, databaseModel.AuditEventSignUp, &userId, nil)
+	err = r.mailer.SendEmailVerification(mail.Recipient{Email: input.Email, Username: input.Username}, verificationToken)
+	if err != nil {
+		log.Printf("Error while sending e-mail verification: %s", err)
 	}
 
 	insertedUser := &model.User{
-		ID:       strconv.FormatUint(uint64(insertResult.ID().(int64)), 10),
+		ID:       strconv.FormatUint(userId, 10),
 		Email:    input.Email,
 		Username: input.Username,
 	}
-	return insertedUser, nil
+	return &model.UserWithRecoveryKey{User: insertedUser, RecoveryKey: recoveryKey}, nil
 }
 
 func (r *mutationResolver) SignIn(ctx context.Context, input model.UserSignIn) (*model.UserWithToken, error) {
 	fetchedUser := databaseModel.User{}
-	err := r.userRepository.FetchByEmail(&fetchedUser, input.Email, nil)
+	err := r.userRepository.FetchByEmail(ctx, &fetchedUser, input.Email, nil)
 	if err != nil {
-		if strings.Contains(err.Error(), "upper: no more rows in this result set") {
-			return nil, gqlerror.Errorf(queryNonExistingEmailErrorMessage)
+		if err == repository.ErrNotFound {
+			r.recordAuditEvent(ctx, databaseModel.AuditEventSignInFailed, nil, auditDetails("unknown e-mail"))
+			return nil, repositoryError(err, queryNonExistingEmailErrorMessage)
 		}
-		return nil, gqlerror.Errorf(signInErrorMessage)
+		return nil, repositoryError(err, signInErrorMessage)
 	}
 
 	if subtle.ConstantTimeCompare(r.passwordSecurityService.HashWithArgon2id(input.Password), fetchedUser.Password) == 0 {
+		r.recordAuditEvent(ctx, databaseModel.AuditEventSignInFailed, &fetchedUser.Id, auditDetails("wrong password"))
 		return nil, gqlerror.Errorf(wrongPasswordErrorMessage)
 	}
+	if fetchedUser.Disabled {
+		r.recordAuditEvent(ctx, databaseModel.AuditEventSignInFailed, &fetchedUser.Id, auditDetails("account disabled"))
+		return nil, gqlerror.Errorf(accountDisabledErrorMessage)
+	}
+	if r.mailer.EmailVerificationRequired() && !fetchedUser.EmailVerified {
+		r.recordAuditEvent(ctx, databaseModel.AuditEventSignInFailed, &fetchedUser.Id, auditDetails("e-mail not verified"))
+		return nil, gqlerror.Errorf(emailNotVerifiedErrorMessage)
+	}
 
-	jwt, err := r.authenticationService.GenerateJwt(fetchedUser.Id)
+	webauthnCredential, err := r.verifyWebauthnSecondFactor(fetchedUser.Id, input.WebauthnAssertion)
+	if err != nil {
+		r.recordAuditEvent(ctx, databaseModel.AuditEventSignInFailed, &fetchedUser.Id, auditDetails("second factor rejected"))
+		return nil, err
+	}
+
+	session, err := r.startSession(ctx, fetchedUser.Id, input.DeviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	jwt, err := r.authenticationService.GenerateJwt(fetchedUser.Id, session.Id)
 	if err != nil {
 		return nil, gqlerror.Errorf(signInErrorMessage)
 	}
 
-	user := &model.User{ID: strconv.FormatUint(fetchedUser.Id, 10), Email: fetchedUser.Email, Username: fetchedUser.Username}
+	r.recordAuditEvent(ctx, databaseModel.AuditEventSignIn, &fetchedUser.Id, auditDetails("session "+strconv.FormatUint(session.Id, 10)))
+	r.alertOnNewDevice(ctx, fetchedUser.Id, mail.Recipient{Email: fetchedUser.Email, Username: fetchedUser.Username})
+
+	user := &model.User{
+		ID:            strconv.FormatUint(fetchedUser.Id, 10),
+		Email:         fetchedUser.Email,
+		Username:      fetchedUser.Username,
+		EmailVerified: fetchedUser.EmailVerified,
+	}
+	userWithToken := &model.UserWithToken{User: user, Token: jwt}
+	if webauthnCredential != nil && webauthnCredential.PrfWrappedKey != nil {
+		prfWrappedKey := base64.RawURLEncoding.EncodeToString(webauthnCredential.PrfWrappedKey)
+		userWithToken.PrfWrappedKey = &prfWrappedKey
+	}
 
-	return &model.UserWithToken{User: user, Token: jwt}, nil
+	return userWithToken, nil
 }
 
 func (r *mutationResolver) CreatePassword(ctx context.Context, input model.NewPassword) (*model.Password, error) {
@@ -76,6 +137,10 @@ func (r *mutationResolver) CreatePassword(ctx context.Context, input model.NewPa
 	if validationErrors != nil {
 		return nil, gqlerror.Errorf("validation error/s on password input")
 	}
+	totpUri, err := normalizeTotpUri(input.TotpURI)
+	if err != nil {
+		return nil, err
+	}
 
 	userId, err := strconv.ParseUint(input.UserID, 10, 64)
 	if err != nil {
@@ -92,7 +157,7 @@ func (r *mutationResolver) CreatePassword(ctx context.Context, input model.NewPa
 	err = r.userRepository.FetchMasterPasswordByUserId(&user, userId)
 	if err != nil {
 		log.Printf("Error while fetching user master password: %s", err)
-		return nil, gqlerror.Errorf(passwordCreationErrorMessage)
+		return nil, repositoryError(err, passwordCreationErrorMessage)
 	}
 
 	encryptedPassword, err := r.passwordSecurityService.EncryptWithAes(input.Password, user.Password)
@@ -100,19 +165,32 @@ func (r *mutationResolver) CreatePassword(ctx context.Context, input model.NewPa
 		log.Printf("Error while encrypting user password: %s", err)
 		return nil, gqlerror.Errorf(passwordCreationErrorMessage)
 	}
+	encryptedTotpUri, err := r.encryptTotpUri(totpUri, user.Password)
+	if err != nil {
+		log.Printf("Error while encrypting user TOTP uri: %s", err)
+		return nil, gqlerror.Errorf(passwordCreationErrorMessage)
+	}
 
-	newPassword := databaseModel.Password{UserId: userId, Name: input.Name, Password: encryptedPassword}
+	newPassword := databaseModel.Password{UserId: userId, Name: input.Name, Password: encryptedPassword, TotpUri: encryptedTotpUri}
 
 	insertResult, err := r.passwordRepository.InsertNewPassword(&newPassword)
 	if err != nil {
-		return nil, gqlerror.Errorf(passwordCreationErrorMessage)
+		return nil, repositoryError(err, passwordCreationErrorMessage)
 	}
 
+	passwordId := strconv.FormatUint(uint64(insertResult.ID().(int64)), 10)
+	r.recordAuditEvent(ctx, databaseModel.AuditEventPasswordCreated, &userId, auditDetails("entry "+passwordId))
+	r.publishVaultChange(userId, uint64(insertResult.ID().(int64)), eventbus.VaultEntryCreated)
+
 	insertedPassword := &model.Password{
-		ID:       strconv.FormatUint(uint64(insertResult.ID().(int64)), 10),
-		UserID:   input.UserID,
-		Name:     input.Name,
-		Password: input.Password,
+		ID:          passwordId,
+		UserID:      input.UserID,
+		Name:        input.Name,
+		Password:    input.Password,
+		Revision:    int(newPassword.Revision),
+		Version:     int(newPassword.Version),
+		TotpURI:     totpUri,
+		CurrentCode: toOneTimeCode(totpUri),
 	}
 	return insertedPassword, nil
 }
@@ -122,6 +200,10 @@ func (r *mutationResolver) UpdatePassword(ctx context.Context, input model.Updat
 	if validationErrors != nil {
 		return nil, gqlerror.Errorf("validation error/s on password input")
 	}
+	totpUri, err := normalizeTotpUri(input.TotpURI)
+	if err != nil {
+		return nil, err
+	}
 
 	passwordId, err := strconv.ParseUint(input.ID, 10, 64)
 	if err != nil {
@@ -131,20 +213,23 @@ func (r *mutationResolver) UpdatePassword(ctx context.Context, input model.Updat
 
 	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
 	userPassword := &databaseModel.Password{}
-	err = r.passwordRepository.FetchPasswordById(userPassword, passwordId)
+	err = r.passwordRepository.FetchPasswordById(ctx, userPassword, passwordId)
 	if err != nil {
 		log.Printf("Error occurred while fetching user password by id: %s", err)
-		return nil, gqlerror.Errorf(passwordUpdateErrorMessage)
+		return nil, repositoryError(err, passwordUpdateErrorMessage)
 	}
 	if userAuthentication == nil || userPassword.UserId != userAuthentication.UserId {
 		return nil, gqlerror.Errorf(passwordAuthenticationErrorMessage)
 	}
+	if userPassword.OrganizationId != nil {
+		return nil, gqlerror.Errorf(sharedPasswordModificationErrorMessage)
+	}
 
 	user := databaseModel.User{}
 	err = r.userRepository.FetchMasterPasswordByUserId(&user, userAuthentication.UserId)
 	if err != nil {
 		log.Printf("Error while fetching user master password: %s", err)
-		return nil, gqlerror.Errorf(passwordUpdateErrorMessage)
+		return nil, repositoryError(err, passwordUpdateErrorMessage)
 	}
 
 	encryptedPassword, err := r.passwordSecurityService.EncryptWithAes(input.Password, user.Password)
@@ -152,17 +237,41 @@ func (r *mutationResolver) UpdatePassword(ctx context.Context, input model.Updat
 		log.Printf("Error while encrypting user password: %s", err)
 		return nil, gqlerror.Errorf(passwordUpdateErrorMessage)
 	}
-
-	err = r.passwordRepository.UpdatePasswordById(input.Name, encryptedPassword, passwordId)
+	encryptedTotpUri, err := r.encryptTotpUri(totpUri, user.Password)
 	if err != nil {
-		log.Printf("Error while updating user password: %s", err)
+		log.Printf("Error while encrypting user TOTP uri: %s", err)
 		return nil, gqlerror.Errorf(passwordUpdateErrorMessage)
 	}
 
-	return &model.Password{ID: input.ID, UserID: strconv.FormatUint(userPassword.UserId, 10), Name: input.Name, Password: input.Password}, nil
+	content := &sharedEntryContent{Name: input.Name, Password: input.Password, TotpUri: totpUri}
+	updatedPassword := &databaseModel.Password{Id: passwordId, Name: input.Name, Password: encryptedPassword, TotpUri: encryptedTotpUri}
+	err = r.storePasswordUpdate(updatedPassword, content, toExpectedVersion(&input.Version))
+	if err != nil {
+		if err == repository.ErrPasswordSharesChanged {
+			return nil, repositoryError(err, passwordSharesChangedErrorMessage)
+		}
+		if conflict := passwordVersionConflict(err); conflict != nil {
+			return nil, conflict
+		}
+		log.Printf("Error while updating user password: %s", err)
+		return nil, repositoryError(err, passwordUpdateErrorMessage)
+	}
+	r.recordAuditEvent(ctx, databaseModel.AuditEventPasswordUpdated, &userAuthentication.UserId, auditDetails("entry "+input.ID))
+	r.publishVaultChange(userPassword.UserId, passwordId, eventbus.VaultEntryUpdated)
+
+	return &model.Password{
+		ID:          input.ID,
+		UserID:      strconv.FormatUint(userPassword.UserId, 10),
+		Name:        input.Name,
+		Password:    input.Password,
+		Revision:    int(updatedPassword.Revision),
+		Version:     int(updatedPassword.Version),
+		TotpURI:     totpUri,
+		CurrentCode: toOneTimeCode(totpUri),
+	}, nil
 }
 
-func (r *mutationResolver) DeletePassword(ctx context.Context, input string) (bool, error) {
+func (r *mutationResolver) DeletePassword(ctx context.Context, input string, version *int) (bool, error) {
 	passwordId, err := strconv.ParseUint(input, 10, 64)
 	if err != nil {
 		log.Printf("Error occurred while converting password id to uint64: %s", err)
@@ -171,20 +280,37 @@ func (r *mutationResolver) DeletePassword(ctx context.Context, input string) (bo
 
 	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
 	userPassword := &databaseModel.Password{}
-	err = r.passwordRepository.FetchPasswordById(userPassword, passwordId)
+	err = r.passwordRepository.FetchPasswordById(ctx, userPassword, passwordId)
 	if err != nil {
 		log.Printf("Error occurred while fetching user password by id: %s", err)
-		return false, gqlerror.Errorf(passwordDeleteErrorMessage)
+		return false, repositoryError(err, passwordDeleteErrorMessage)
 	}
 	if userAuthentication == nil || userPassword.UserId != userAuthentication.UserId {
 		return false, gqlerror.Errorf(passwordAuthenticationErrorMessage)
 	}
+	if userPassword.OrganizationId != nil {
+		return false, gqlerror.Errorf(sharedPasswordModificationErrorMessage)
+	}
 
-	err = r.passwordRepository.DeletePasswordById(passwordId)
+	// The attachment rows cascade with the entry, their blobs have to be removed separately
+	attachments := databaseModel.Attachments{}
+	err = r.attachmentRepository.FetchAttachmentsByPasswordId(&attachments, passwordId)
 	if err != nil {
+		log.Printf("Error while fetching password attachments: %s", err)
+		return false, repositoryError(err, passwordDeleteErrorMessage)
+	}
+
+	err = r.passwordRepository.DeletePasswordById(passwordId, toExpectedVersion(version))
+	if err != nil {
+		if conflict := passwordVersionConflict(err); conflict != nil {
+			return false, conflict
+		}
 		log.Printf("Error while updating user password: %s", err)
-		return false, gqlerror.Errorf(passwordDeleteErrorMessage)
+		return false, repositoryError(err, passwordDeleteErrorMessage)
 	}
+	r.recordAuditEvent(ctx, databaseModel.AuditEventPasswordDeleted, &userAuthentication.UserId, auditDetails("entry "+input))
+	r.publishVaultChange(userPassword.UserId, passwordId, eventbus.VaultEntryDeleted)
+	r.attachmentManager.DeleteAttachmentBlobs(attachments)
 
 	return true, nil
 }
@@ -208,12 +334,12 @@ func (r *queryResolver) QueryUserPasswords(ctx context.Context, userID string) (
 	err = r.userRepository.FetchMasterPasswordByUserId(&user, userId)
 	if err != nil {
 		log.Printf("Error while fetching user master password: %s", err)
-		return nil, gqlerror.Errorf(userPasswordsFetchErrorMessage)
+		return nil, repositoryError(err, userPasswordsFetchErrorMessage)
 	}
 
-	err = r.passwordRepository.FetchAllByUserId(&fetchedPasswords, userId, graphql.CollectAllFields(ctx))
+	err = r.passwordRepository.FetchAllByUserId(ctx, &fetchedPasswords, userId, passwordQueryFields(graphql.CollectAllFields(ctx)))
 	if err != nil {
-		return nil, gqlerror.Errorf(userPasswordsFetchErrorMessage)
+		return nil, repositoryError(err, userPasswordsFetchErrorMessage)
 	}
 
 	for _, password := range fetchedPasswords {
@@ -222,16 +348,26 @@ func (r *queryResolver) QueryUserPasswords(ctx context.Context, userID string) (
 			log.Printf("Error while decrypting user password: %s", err)
 			return nil, gqlerror.Errorf(userPasswordsFetchErrorMessage)
 		}
+		totpUri, err := r.decryptTotpUri(password.TotpUri, user.Password)
+		if err != nil {
+			log.Printf("Error while decrypting user TOTP uri: %s", err)
+			return nil, gqlerror.Errorf(userPasswordsFetchErrorMessage)
+		}
 		passwords = append(
 			passwords,
 			&model.Password{
-				ID:       strconv.FormatUint(password.Id, 10),
-				UserID:   strconv.FormatUint(password.UserId, 10),
-				Name:     password.Name,
-				Password: decryptedPassword,
+				ID:          strconv.FormatUint(password.Id, 10),
+				UserID:      strconv.FormatUint(password.UserId, 10),
+				Name:        password.Name,
+				Password:    decryptedPassword,
+				Revision:    int(password.Revision),
+				Version:     int(password.Version),
+				TotpURI:     totpUri,
+				CurrentCode: toOneTimeCode(totpUri),
 			},
 		)
 	}
+	r.recordAuditEvent(ctx, databaseModel.AuditEventPasswordsViewed, &userId, auditDetails(strconv.Itoa(len(passwords))+" entries"))
 	return passwords, nil
 }
 
@@ -2,7 +2,11 @@ package gql
 
 import (
 	"context"
+	"errors"
 	"github.com/99designs/gqlgen/graphql"
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
 	"github.com/go-playground/validator"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 )
@@ -12,6 +16,7 @@ const (
 	passwordCreationErrorMessage            = "could not create a new password"
 	passwordUpdateErrorMessage              = "could not update password"
 	passwordDeleteErrorMessage              = "could not delete password"
+	passwordVersionConflictErrorMessage     = "password changed since the expected version"
 	passwordAuthenticationErrorMessage      = "unauthorized password input"
 	userPasswordsFetchErrorMessage          = "could not fetch user's passwords"
 	userPasswordsAuthenticationErrorMessage = "unauthorized passwords fetch"
@@ -21,6 +26,62 @@ const (
 	wrongPasswordErrorMessage               = "wrong password"
 )
 
+// The codes in the extensions of errors caused by the repositories, clients can rely on them instead of the messages
+const (
+	notFoundErrorCode           = "NOT_FOUND"
+	duplicateErrorCode          = "DUPLICATE"
+	conflictErrorCode           = "CONFLICT"
+	storageUnavailableErrorCode = "STORAGE_UNAVAILABLE"
+)
+
+// repositoryError returns the error with the given message for a failed repository call, carrying the code
+// of the repository error in its extensions. Errors that aren't repository errors get no code.
+func repositoryError(err error, message string) *gqlerror.Error {
+	gqlError := &gqlerror.Error{Message: message}
+	if code := repositoryErrorCode(err); code != "" {
+		gqlError.Extensions = map[string]interface{}{"code": code}
+	}
+	return gqlError
+}
+
+func repositoryErrorCode(err error) string {
+	switch {
+	case errors.Is(err, repository.ErrNotFound):
+		return notFoundErrorCode
+	case errors.Is(err, repository.ErrDuplicate):
+		return duplicateErrorCode
+	case errors.Is(err, repository.ErrConflict):
+		return conflictErrorCode
+	case errors.Is(err, repository.ErrUnavailable):
+		return storageUnavailableErrorCode
+	}
+	return ""
+}
+
+// passwordVersionConflict returns the conflict error carrying the current version of the password,
+// or nil if the write didn't fail on a version conflict
+func passwordVersionConflict(err error) *gqlerror.Error {
+	var conflict *repository.VersionConflictError
+	if !errors.As(err, &conflict) {
+		return nil
+	}
+
+	return &gqlerror.Error{
+		Message:    passwordVersionConflictErrorMessage,
+		Extensions: map[string]interface{}{"code": conflictErrorCode, "currentVersion": conflict.CurrentVersion},
+	}
+}
+
+// toExpectedVersion converts the optional expected version of a write, nil means the write doesn't check the version
+func toExpectedVersion(version *int) *int64 {
+	if version == nil {
+		return nil
+	}
+
+	expectedVersion := int64(*version)
+	return &expectedVersion
+}
+
 func manageValidationsErrors(validationErrors error, ctx context.Context) error {
 	if validationErrors != nil {
 		for _, err := range validationErrors.(validator.ValidationErrors) {
@@ -30,3 +91,19 @@ func manageValidationsErrors(validationErrors error, ctx context.Context) error
 
 	return validationErrors
 }
+
+// recordAuditEvent records an event of the request's client, the user is nil for events that can't be tied to an account
+func (r *Resolver) recordAuditEvent(ctx context.Context, eventType string, userId *uint64, details *string) {
+	clientInformation := authentication.GetClientInformationFromContext(ctx)
+	r.auditRecorder.Record(&databaseModel.AuditEvent{
+		UserId:    userId,
+		Type:      eventType,
+		IpAddress: clientInformation.IpAddress,
+		UserAgent: clientInformation.UserAgent,
+		Details:   details,
+	})
+}
+
+func auditDetails(details string) *string {
+	return &details
+}
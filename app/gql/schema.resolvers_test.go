@@ -5,13 +5,15 @@ import (
 	"errors"
 	"github.com/99designs/gqlgen/graphql"
 	"github.com/KristijanFaust/gokeeper/app/authentication"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
 	"github.com/KristijanFaust/gokeeper/app/gql/generated"
 	"github.com/KristijanFaust/gokeeper/app/gql/model"
 	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
-	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
+	"github.com/upper/db/v4"
 	"github.com/vektah/gqlparser/v2/gqlerror"
 	"testing"
 )
@@ -21,6 +23,7 @@ type schemaResolverTestSuite struct {
 	resolver              Resolver
 	mutationResolver      generated.MutationResolver
 	queryResolver         generated.QueryResolver
+	subscriptionResolver  generated.SubscriptionResolver
 	graphqlRequestContext context.Context
 }
 
@@ -46,12 +49,13 @@ func (suite *schemaResolverTestSuite) SetupTest() {
 func (suite *schemaResolverTestSuite) TestSignUp() {
 	input := model.NewUser{Email: mockutil.DefaultEmail, Username: mockutil.DefaultUsername, Password: mockutil.DefaultPassword}
 
-	user, err := suite.mutationResolver.SignUp(context.Background(), input)
+	userWithRecoveryKey, err := suite.mutationResolver.SignUp(context.Background(), input)
 	assert.Nil(suite.T(), err, "User should be created without errors")
 
-	assert.Equal(suite.T(), user.ID, mockutil.DefaultIdAsString)
-	assert.Equal(suite.T(), user.Email, input.Email)
-	assert.Equal(suite.T(), user.Username, input.Username)
+	assert.Equal(suite.T(), userWithRecoveryKey.User.ID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), userWithRecoveryKey.User.Email, input.Email)
+	assert.Equal(suite.T(), userWithRecoveryKey.User.Username, input.Username)
+	assert.Equal(suite.T(), userWithRecoveryKey.RecoveryKey, mockutil.MockedRecoveryKey)
 }
 
 // SignUp should return error on failed input validation
@@ -70,13 +74,13 @@ func (suite *schemaResolverTestSuite) TestSignUpValidation() {
 // SignUp should detect existing user emails
 func (suite *schemaResolverTestSuite) TestSignUpWithExistingEmail() {
 	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
-	userRepositoryServiceMock.On("InsertNewUser", mock.Anything).Return(nil, &pq.Error{Code: "23505"}).Times(1)
-	suite.resolver.userRepository = userRepositoryServiceMock
+	userRepositoryServiceMock.On("InsertNewUser", mock.Anything).Return(nil, repository.ErrDuplicate).Times(1)
+	suite.resolver.unitOfWork = mockutil.NewUnitOfWorkMock(&repository.Repositories{User: userRepositoryServiceMock})
 	input := model.NewUser{Email: mockutil.DefaultEmail, Username: mockutil.DefaultUsername, Password: mockutil.DefaultPassword}
 
 	user, err := suite.mutationResolver.SignUp(context.Background(), input)
 	assert.Equal(
-		suite.T(), err, gqlerror.Errorf("the e-mail address is already taken"),
+		suite.T(), err, &gqlerror.Error{Message: "the e-mail address is already taken", Extensions: map[string]interface{}{"code": "DUPLICATE"}},
 		"Should return expected error when user email already exists",
 	)
 	assert.Nil(suite.T(), user, "Should not return any user data")
@@ -86,7 +90,7 @@ func (suite *schemaResolverTestSuite) TestSignUpWithExistingEmail() {
 func (suite *schemaResolverTestSuite) TestSignUpWithInsertError() {
 	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
 	userRepositoryServiceMock.On("InsertNewUser", mock.Anything).Return(nil, errors.New(mockutil.MockedGenericErrorMessage)).Times(1)
-	suite.resolver.userRepository = userRepositoryServiceMock
+	suite.resolver.unitOfWork = mockutil.NewUnitOfWorkMock(&repository.Repositories{User: userRepositoryServiceMock})
 	input := model.NewUser{Email: mockutil.DefaultEmail, Username: mockutil.DefaultUsername, Password: mockutil.DefaultPassword}
 
 	user, err := suite.mutationResolver.SignUp(context.Background(), input)
@@ -97,6 +101,29 @@ func (suite *schemaResolverTestSuite) TestSignUpWithInsertError() {
 	assert.Nil(suite.T(), user, "Should not return any user data")
 }
 
+// SignUp should keep neither the user nor send the verification e-mail when storing the verification token fails
+func (suite *schemaResolverTestSuite) TestSignUpWithVerificationTokenInsertError() {
+	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
+	userRepositoryServiceMock.On("InsertNewUser", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	userRepositoryServiceMock.On("InsertEmailVerificationToken", mock.Anything).Return(
+		nil, errors.New(mockutil.MockedGenericErrorMessage),
+	).Times(1)
+	unitOfWorkMock := mockutil.NewUnitOfWorkMock(&repository.Repositories{User: userRepositoryServiceMock})
+	suite.resolver.unitOfWork = unitOfWorkMock
+	mailServiceMock := mockutil.DefaultMailServiceMock()
+	suite.resolver.mailer = mailServiceMock
+	input := model.NewUser{Email: mockutil.DefaultEmail, Username: mockutil.DefaultUsername, Password: mockutil.DefaultPassword}
+
+	user, err := suite.mutationResolver.SignUp(context.Background(), input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("could not create a new user"),
+		"Should return expected error when the verification token can't be stored",
+	)
+	assert.Nil(suite.T(), user, "Should not return any user data")
+	unitOfWorkMock.AssertNumberOfCalls(suite.T(), "Execute", 1)
+	mailServiceMock.AssertNotCalled(suite.T(), "SendEmailVerification", mock.Anything, mock.Anything)
+}
+
 // SignIn should successfully sign in a user
 func (suite *schemaResolverTestSuite) TestSignIn() {
 	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
@@ -115,14 +142,14 @@ func (suite *schemaResolverTestSuite) TestSignIn() {
 func (suite *schemaResolverTestSuite) TestSignInWithNonExistingUser() {
 	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
 	userRepositoryServiceMock.On("FetchByEmail", mock.Anything, mock.Anything, []string(nil)).Return(
-		errors.New("upper: no more rows in this result set"),
+		repository.ErrNotFound,
 	).Times(1)
 	suite.resolver.userRepository = userRepositoryServiceMock
 	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
 
 	token, err := suite.mutationResolver.SignIn(context.Background(), input)
 	assert.Equal(
-		suite.T(), err, gqlerror.Errorf("user doesn't exist"),
+		suite.T(), err, &gqlerror.Error{Message: "user doesn't exist", Extensions: map[string]interface{}{"code": "NOT_FOUND"}},
 		"Should return expected error when a non existing user is signing in",
 	)
 	assert.Nil(suite.T(), token, "Token should not be generated")
@@ -166,6 +193,7 @@ func (suite *schemaResolverTestSuite) TestSignInWithGenerateJwtError() {
 	jwtAuthenticationServiceMock.On("GenerateJwt", mock.Anything, mock.Anything).Return(
 		"", errors.New(mockutil.MockedGenericErrorMessage),
 	).Times(1)
+	jwtAuthenticationServiceMock.On("NewDeviceApprovalRequired").Return(false).Times(1)
 	suite.resolver.authenticationService = jwtAuthenticationServiceMock
 	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
 
@@ -300,7 +328,9 @@ func (suite *schemaResolverTestSuite) TestCreatePasswordWithEncryptionError() {
 
 // UpdatePassword should successfully update a user password
 func (suite *schemaResolverTestSuite) TestUpdatePassword() {
-	input := model.UpdatePassword{ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword}
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
 	password, err := suite.mutationResolver.UpdatePassword(context.Background(), input)
 	assert.Nil(suite.T(), err, "Password should be updated without errors")
 
@@ -308,6 +338,8 @@ func (suite *schemaResolverTestSuite) TestUpdatePassword() {
 	assert.Equal(suite.T(), password.UserID, mockutil.DefaultIdAsString)
 	assert.Equal(suite.T(), password.Name, input.Name)
 	assert.Equal(suite.T(), password.Password, input.Password)
+	assert.Equal(suite.T(), password.Revision, int(mockutil.MockedNextRevision))
+	assert.Equal(suite.T(), password.Version, int(mockutil.MockedNextVersion))
 }
 
 // UpdatePassword should return error on failed input validation
@@ -325,7 +357,7 @@ func (suite *schemaResolverTestSuite) TestUpdatePasswordValidation() {
 
 // UpdatePassword should return expected error when password id is of an unexpected value
 func (suite *schemaResolverTestSuite) TestUpdatePasswordWithUnexpectedPasswordIdValue() {
-	input := model.UpdatePassword{ID: "invalid", Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword}
+	input := model.UpdatePassword{ID: "invalid", Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion)}
 
 	password, err := suite.mutationResolver.UpdatePassword(context.Background(), input)
 	assert.Equal(
@@ -337,7 +369,9 @@ func (suite *schemaResolverTestSuite) TestUpdatePasswordWithUnexpectedPasswordId
 
 // UpdatePassword should return expected error when user target password fetch fails
 func (suite *schemaResolverTestSuite) TestUpdatePasswordWithTargetPasswordFetchError() {
-	input := model.UpdatePassword{ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword}
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
 	passwordRepositoryServiceMock := new(mockutil.PasswordRepositoryServiceMock)
 	passwordRepositoryServiceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(
 		errors.New(mockutil.MockedGenericErrorMessage),
@@ -354,7 +388,9 @@ func (suite *schemaResolverTestSuite) TestUpdatePasswordWithTargetPasswordFetchE
 
 // UpdatePassword should return expected error when request is not authenticated
 func (suite *schemaResolverTestSuite) TestUpdatePasswordUnauthenticated() {
-	input := model.UpdatePassword{ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword}
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
 	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
 	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
 	suite.resolver.authenticationService = jwtAuthenticationServiceMock
@@ -369,7 +405,9 @@ func (suite *schemaResolverTestSuite) TestUpdatePasswordUnauthenticated() {
 
 // UpdatePassword should return expected error when request authentication is invalid
 func (suite *schemaResolverTestSuite) TestUpdatePasswordWithInvalidAuthentication() {
-	input := model.UpdatePassword{ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword}
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
 	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
 	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(
 		&authentication.UserAuthentication{UserId: uint64(2)},
@@ -386,7 +424,9 @@ func (suite *schemaResolverTestSuite) TestUpdatePasswordWithInvalidAuthenticatio
 
 // UpdatePassword should return expected error when user's master password fetch fails
 func (suite *schemaResolverTestSuite) TestUpdatePasswordWithMasterPasswordFetchError() {
-	input := model.UpdatePassword{ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword}
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
 	userRepositoryServiceMock := new(mockutil.UserRepositoryServiceMock)
 	userRepositoryServiceMock.On("FetchMasterPasswordByUserId", mock.Anything, mock.Anything).Return(
 		errors.New(mockutil.MockedGenericErrorMessage),
@@ -402,7 +442,9 @@ func (suite *schemaResolverTestSuite) TestUpdatePasswordWithMasterPasswordFetchE
 
 // UpdatePassword should return expected error on unsuccessful password encryption
 func (suite *schemaResolverTestSuite) TestUpdatePasswordWithEncryptionError() {
-	input := model.UpdatePassword{ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword}
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
 	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
 	passwordSecurityServiceMock.On("EncryptWithAes", mock.Anything, mock.Anything).Return(
 		nil, errors.New(mockutil.MockedGenericErrorMessage),
@@ -419,10 +461,12 @@ func (suite *schemaResolverTestSuite) TestUpdatePasswordWithEncryptionError() {
 
 // UpdatePassword should return expected error when insert to database fails
 func (suite *schemaResolverTestSuite) TestUpdatePasswordWithUpdateError() {
-	input := model.UpdatePassword{ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword}
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
 	passwordRepositoryServiceMock := new(mockutil.PasswordRepositoryServiceMock)
 	passwordRepositoryServiceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(nil).Times(1)
-	passwordRepositoryServiceMock.On("UpdatePasswordById", mock.Anything, mock.Anything, mock.Anything).Return(
+	passwordRepositoryServiceMock.On("UpdatePasswordById", mock.Anything, mock.Anything).Return(
 		errors.New(mockutil.MockedGenericErrorMessage),
 	).Times(1)
 	suite.resolver.passwordRepository = passwordRepositoryServiceMock
@@ -435,21 +479,71 @@ func (suite *schemaResolverTestSuite) TestUpdatePasswordWithUpdateError() {
 	assert.Nil(suite.T(), password, "Should not return any password data")
 }
 
+// UpdatePassword should pass on the code of the repository error when the update fails
+func (suite *schemaResolverTestSuite) TestUpdatePasswordWithMissingPassword() {
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
+	passwordRepositoryServiceMock := new(mockutil.PasswordRepositoryServiceMock)
+	passwordRepositoryServiceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	passwordRepositoryServiceMock.On("UpdatePasswordById", mock.Anything, mock.Anything).Return(repository.ErrNotFound).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+
+	password, err := suite.mutationResolver.UpdatePassword(context.Background(), input)
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{Message: "could not update password", Extensions: map[string]interface{}{"code": "NOT_FOUND"}},
+		"Should return expected error when the password was deleted in the meantime",
+	)
+	assert.Nil(suite.T(), password, "Should not return any password data")
+}
+
+// UpdatePassword should return a conflict error carrying the current version when the password changed since the expected version
+func (suite *schemaResolverTestSuite) TestUpdatePasswordWithVersionConflict() {
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, Version: int(mockutil.DefaultVersion),
+	}
+	passwordRepositoryServiceMock := new(mockutil.PasswordRepositoryServiceMock)
+	passwordRepositoryServiceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	passwordRepositoryServiceMock.On("UpdatePasswordById", mock.Anything, mock.Anything).Return(
+		&repository.VersionConflictError{CurrentVersion: mockutil.MockedNextVersion},
+	).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+
+	password, err := suite.mutationResolver.UpdatePassword(context.Background(), input)
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{
+			Message:    "password changed since the expected version",
+			Extensions: map[string]interface{}{"code": "CONFLICT", "currentVersion": mockutil.MockedNextVersion},
+		},
+		"Should return a conflict error with the current version when the password changed since the expected version",
+	)
+	assert.Nil(suite.T(), password, "Should not return any password data")
+	expectedVersion := mockutil.DefaultVersion
+	passwordRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdatePasswordById", updatedPasswordMatcher(mockutil.DefaultPasswordName), &expectedVersion,
+	)
+}
+
 // DeletePassword should successfully delete a user password
 func (suite *schemaResolverTestSuite) TestDeletePassword() {
 	input := mockutil.DefaultIdAsString
+	attachmentManagerMock := mockutil.DefaultAttachmentManagerMock()
+	suite.resolver.attachmentManager = attachmentManagerMock
 
-	result, err := suite.mutationResolver.DeletePassword(context.Background(), input)
+	result, err := suite.mutationResolver.DeletePassword(context.Background(), input, nil)
 	assert.Nil(suite.T(), err, "Password should be deleted without errors")
 
 	assert.Equal(suite.T(), result, true)
+	attachmentManagerMock.AssertCalled(
+		suite.T(), "DeleteAttachmentBlobs", databaseModel.Attachments{mockutil.DefaultAttachment()},
+	)
 }
 
 // DeletePassword should return expected error when password id is of an unexpected value
 func (suite *schemaResolverTestSuite) TestDeletePasswordWithUnexpectedPasswordIdValue() {
 	input := "invalid"
 
-	result, err := suite.mutationResolver.DeletePassword(context.Background(), input)
+	result, err := suite.mutationResolver.DeletePassword(context.Background(), input, nil)
 	assert.Equal(
 		suite.T(), err, gqlerror.Errorf("could not delete password"),
 		"Should return expected error when user id is of an unexpected value",
@@ -466,7 +560,7 @@ func (suite *schemaResolverTestSuite) TestDeletePasswordWithTargetPasswordFetchE
 	).Times(1)
 	suite.resolver.passwordRepository = passwordRepositoryServiceMock
 
-	result, err := suite.mutationResolver.DeletePassword(context.Background(), input)
+	result, err := suite.mutationResolver.DeletePassword(context.Background(), input, nil)
 	assert.Equal(
 		suite.T(), err, gqlerror.Errorf("could not delete password"),
 		"Should return expected error when request is not authorized",
@@ -481,7 +575,7 @@ func (suite *schemaResolverTestSuite) TestDeletePasswordUnauthenticated() {
 	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
 	suite.resolver.authenticationService = jwtAuthenticationServiceMock
 
-	result, err := suite.mutationResolver.DeletePassword(context.Background(), input)
+	result, err := suite.mutationResolver.DeletePassword(context.Background(), input, nil)
 	assert.Equal(
 		suite.T(), err, gqlerror.Errorf("unauthorized password input"),
 		"Should return expected error when request is not authorized",
@@ -498,7 +592,7 @@ func (suite *schemaResolverTestSuite) TestDeletePasswordWithInvalidAuthenticatio
 	).Times(1)
 	suite.resolver.authenticationService = jwtAuthenticationServiceMock
 
-	result, err := suite.mutationResolver.DeletePassword(context.Background(), input)
+	result, err := suite.mutationResolver.DeletePassword(context.Background(), input, nil)
 	assert.Equal(
 		suite.T(), err, gqlerror.Errorf("unauthorized password input"),
 		"Should return expected error when request is not authorized",
@@ -510,18 +604,21 @@ func (suite *schemaResolverTestSuite) TestDeletePasswordWithInvalidAuthenticatio
 func (suite *schemaResolverTestSuite) TestDeletePasswordWithUpdateError() {
 	passwordRepositoryServiceMock := new(mockutil.PasswordRepositoryServiceMock)
 	passwordRepositoryServiceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(nil).Times(1)
-	passwordRepositoryServiceMock.On("DeletePasswordById", mock.Anything).Return(
+	passwordRepositoryServiceMock.On("DeletePasswordById", mock.Anything, mock.Anything).Return(
 		errors.New(mockutil.MockedGenericErrorMessage),
 	).Times(1)
 	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	attachmentManagerMock := mockutil.DefaultAttachmentManagerMock()
+	suite.resolver.attachmentManager = attachmentManagerMock
 	input := mockutil.DefaultIdAsString
 
-	result, err := suite.mutationResolver.DeletePassword(context.Background(), input)
+	result, err := suite.mutationResolver.DeletePassword(context.Background(), input, nil)
 	assert.Equal(
 		suite.T(), err, gqlerror.Errorf("could not delete password"),
 		"Should return expected error when insert to database fails",
 	)
 	assert.Equal(suite.T(), result, false)
+	attachmentManagerMock.AssertNotCalled(suite.T(), "DeleteAttachmentBlobs", mock.Anything)
 }
 
 // QueryUserPasswords should successfully query for all user's passwords
@@ -645,15 +742,59 @@ func (suite *schemaResolverTestSuite) TestQueryUserPasswordsWithDecryptionError(
 	assert.Nil(suite.T(), passwords, "Should not return any user data")
 }
 
+// DeletePassword should return a conflict error carrying the current version when the password changed since the expected version
+func (suite *schemaResolverTestSuite) TestDeletePasswordWithVersionConflict() {
+	passwordRepositoryServiceMock := new(mockutil.PasswordRepositoryServiceMock)
+	passwordRepositoryServiceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	passwordRepositoryServiceMock.On("DeletePasswordById", mock.Anything, mock.Anything).Return(
+		&repository.VersionConflictError{CurrentVersion: mockutil.MockedNextVersion},
+	).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	version := int(mockutil.DefaultVersion)
+
+	result, err := suite.mutationResolver.DeletePassword(context.Background(), mockutil.DefaultIdAsString, &version)
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{
+			Message:    "password changed since the expected version",
+			Extensions: map[string]interface{}{"code": "CONFLICT", "currentVersion": mockutil.MockedNextVersion},
+		},
+		"Should return a conflict error with the current version when the password changed since the expected version",
+	)
+	assert.False(suite.T(), result)
+	expectedVersion := mockutil.DefaultVersion
+	passwordRepositoryServiceMock.AssertCalled(suite.T(), "DeletePasswordById", mockutil.DefaultIdAsUint64, &expectedVersion)
+}
+
 func injectDefaultMockedResolverServices(suite *schemaResolverTestSuite) {
 	resolver := NewResolver(
 		mockutil.DefaultUserRepositoryServiceMock(),
 		mockutil.DefaultPasswordRepositoryServiceMock(),
+		mockutil.DefaultWebauthnRepositoryServiceMock(),
+		mockutil.DefaultKnownDeviceRepositoryServiceMock(),
+		mockutil.DefaultKeyPairRepositoryServiceMock(),
+		mockutil.DefaultEmergencyAccessRepositoryServiceMock(),
+		mockutil.DefaultSessionRepositoryServiceMock(),
+		mockutil.DefaultOrganizationRepositoryServiceMock(),
+		mockutil.DefaultPasswordShareRepositoryServiceMock(),
+		mockutil.DefaultSendRepositoryServiceMock(),
+		mockutil.DefaultAdminRepositoryServiceMock(),
+		mockutil.DefaultAuditEventRepositoryServiceMock(),
+		mockutil.DefaultWebhookRepositoryServiceMock(),
+		mockutil.DefaultAttachmentRepositoryServiceMock(),
+		mockutil.DefaultUnitOfWorkMock(),
 		mockutil.DefaultPasswordSecurityServiceMock(),
 		mockutil.DefaultJwtAuthenticationServiceMock(),
+		mockutil.DefaultWebauthnVerifierMock(),
+		mockutil.DefaultMailServiceMock(),
+		mockutil.DefaultEmergencyAccessApproverMock(),
+		mockutil.DefaultSendManagerMock(),
+		mockutil.DefaultAuditRecorderMock(),
+		mockutil.DefaultVaultEventBusMock(),
+		mockutil.DefaultAttachmentManagerMock(),
 	)
 	suite.resolver = *resolver
 
 	suite.mutationResolver = suite.resolver.Mutation()
 	suite.queryResolver = suite.resolver.Query()
+	suite.subscriptionResolver = suite.resolver.Subscription()
 }
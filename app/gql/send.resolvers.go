@@ -0,0 +1,90 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) CreateSend(ctx context.Context, input model.NewSend) (*model.CreatedSend, error) {
+	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
+	if validationErrors != nil {
+		return nil, gqlerror.Errorf("validation error/s on send input")
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(sendAuthenticationErrorMessage)
+	}
+
+	passphrase := ""
+	if input.Passphrase != nil {
+		passphrase = *input.Passphrase
+	}
+	send := &databaseModel.Send{
+		UserId:    userAuthentication.UserId,
+		Name:      input.Name,
+		MaxViews:  input.MaxViews,
+		ExpiresAt: time.Now().UTC().Add(time.Duration(input.ExpiresInHours) * time.Hour),
+	}
+	link, err := r.sendManager.CreateSend(send, input.Secret, passphrase)
+	if err != nil {
+		log.Printf("Error while creating send: %s", err)
+		return nil, gqlerror.Errorf(sendErrorMessage)
+	}
+
+	return &model.CreatedSend{Send: toSend(send), Link: link}, nil
+}
+
+func (r *mutationResolver) DeleteSend(ctx context.Context, sendID string) (bool, error) {
+	sendId, err := strconv.ParseUint(sendID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting send id to uint64: %s", err)
+		return false, gqlerror.Errorf(sendErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return false, gqlerror.Errorf(sendAuthenticationErrorMessage)
+	}
+
+	err = r.sendRepository.DeleteSendByIdAndUserId(sendId, userAuthentication.UserId)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return false, repositoryError(err, sendNotFoundErrorMessage)
+		}
+		log.Printf("Error while deleting send: %s", err)
+		return false, repositoryError(err, sendErrorMessage)
+	}
+
+	return true, nil
+}
+
+func (r *queryResolver) Sends(ctx context.Context) ([]*model.Send, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(sendAuthenticationErrorMessage)
+	}
+
+	fetchedSends := databaseModel.Sends{}
+	err := r.sendRepository.FetchAvailableSendsByUserId(&fetchedSends, userAuthentication.UserId, time.Now().UTC())
+	if err != nil {
+		log.Printf("Error while fetching user sends: %s", err)
+		return nil, repositoryError(err, sendErrorMessage)
+	}
+
+	sends := []*model.Send{}
+	for index := range fetchedSends {
+		sends = append(sends, toSend(&fetchedSends[index]))
+	}
+	return sends, nil
+}
@@ -0,0 +1,26 @@
+package gql
+
+import (
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"strconv"
+	"time"
+)
+
+const (
+	sendErrorMessage               = "could not process send"
+	sendAuthenticationErrorMessage = "unauthorized send request"
+	sendNotFoundErrorMessage       = "send doesn't exist or is no longer available"
+)
+
+func toSend(send *databaseModel.Send) *model.Send {
+	return &model.Send{
+		ID:            strconv.FormatUint(send.Id, 10),
+		Name:          send.Name,
+		HasPassphrase: send.HasPassphrase,
+		MaxViews:      send.MaxViews,
+		ViewCount:     send.ViewCount,
+		ExpiresAt:     send.ExpiresAt.Format(time.RFC3339),
+		CreatedAt:     send.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,157 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"github.com/99designs/gqlgen/graphql"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"time"
+)
+
+// CreateSend should create a send of the authenticated user and return its link
+func (suite *schemaResolverTestSuite) TestCreateSend() {
+	sendManagerMock := mockutil.DefaultSendManagerMock()
+	suite.resolver.sendManager = sendManagerMock
+	passphrase := "passphrase"
+	input := model.NewSend{
+		Name: mockutil.DefaultSendName, Secret: mockutil.DefaultPassword, ExpiresInHours: 24, MaxViews: 2, Passphrase: &passphrase,
+	}
+
+	createdSend, err := suite.mutationResolver.CreateSend(suite.graphqlRequestContext, input)
+	assert.Nil(suite.T(), err, "Should create a send without any errors")
+	assert.Equal(suite.T(), createdSend.Link, mockutil.MockedSendLink)
+	assert.Equal(suite.T(), createdSend.Send.ID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), createdSend.Send.Name, mockutil.DefaultSendName)
+	assert.Equal(suite.T(), createdSend.Send.MaxViews, 2)
+	assert.True(suite.T(), createdSend.Send.HasPassphrase)
+
+	sendManagerMock.AssertCalled(suite.T(), "CreateSend", mock.MatchedBy(func(send *databaseModel.Send) bool {
+		expiresIn := time.Until(send.ExpiresAt)
+		return send.UserId == mockutil.DefaultIdAsUint64 && expiresIn > 23*time.Hour && expiresIn <= 24*time.Hour
+	}), mockutil.DefaultPassword, passphrase)
+}
+
+// CreateSend should return expected error on failed input validation
+func (suite *schemaResolverTestSuite) TestCreateSendValidation() {
+	ctx := graphql.WithResponseContext(context.Background(), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+	sendManagerMock := mockutil.DefaultSendManagerMock()
+	suite.resolver.sendManager = sendManagerMock
+
+	createdSend, err := suite.mutationResolver.CreateSend(
+		ctx, model.NewSend{Name: mockutil.DefaultSendName, Secret: mockutil.DefaultPassword, ExpiresInHours: 24},
+	)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("validation error/s on send input"),
+		"Should return expected error when the input is invalid",
+	)
+	assert.Nil(suite.T(), createdSend, "Should not return a send")
+	sendManagerMock.AssertNotCalled(suite.T(), "CreateSend", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// CreateSend should return an error for unauthenticated users
+func (suite *schemaResolverTestSuite) TestCreateSendWithoutAuthentication() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	createdSend, err := suite.mutationResolver.CreateSend(suite.graphqlRequestContext, generateTestNewSend())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized send request"),
+		"Should return expected error when the user isn't authenticated",
+	)
+	assert.Nil(suite.T(), createdSend, "Should not return a send")
+}
+
+// CreateSend should return expected error if the send can't be created
+func (suite *schemaResolverTestSuite) TestCreateSendWithCreationError() {
+	sendManagerMock := new(mockutil.SendManagerMock)
+	sendManagerMock.On("CreateSend", mock.Anything, mock.Anything, mock.Anything).Return("", errors.New(mockutil.MockedGenericErrorMessage))
+	suite.resolver.sendManager = sendManagerMock
+
+	createdSend, err := suite.mutationResolver.CreateSend(suite.graphqlRequestContext, generateTestNewSend())
+	assert.Equal(suite.T(), err, gqlerror.Errorf("could not process send"), "Should return expected error")
+	assert.Nil(suite.T(), createdSend, "Should not return a send")
+}
+
+// DeleteSend should delete a send of the authenticated user
+func (suite *schemaResolverTestSuite) TestDeleteSend() {
+	sendRepositoryServiceMock := mockutil.DefaultSendRepositoryServiceMock()
+	suite.resolver.sendRepository = sendRepositoryServiceMock
+
+	deleted, err := suite.mutationResolver.DeleteSend(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should delete the send without any errors")
+	assert.True(suite.T(), deleted, "Should confirm the deletion")
+	sendRepositoryServiceMock.AssertCalled(suite.T(), "DeleteSendByIdAndUserId", mockutil.DefaultIdAsUint64, mockutil.DefaultIdAsUint64)
+}
+
+// DeleteSend should return expected error for sends that don't belong to the user or aren't available anymore
+func (suite *schemaResolverTestSuite) TestDeleteNonExistingSend() {
+	sendRepositoryServiceMock := new(mockutil.SendRepositoryServiceMock)
+	sendRepositoryServiceMock.On("DeleteSendByIdAndUserId", mock.Anything, mock.Anything).Return(repository.ErrNotFound).Times(1)
+	suite.resolver.sendRepository = sendRepositoryServiceMock
+
+	deleted, err := suite.mutationResolver.DeleteSend(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{Message: "send doesn't exist or is no longer available", Extensions: map[string]interface{}{"code": "NOT_FOUND"}},
+		"Should return expected error when the send doesn't exist",
+	)
+	assert.False(suite.T(), deleted, "Should not confirm the deletion")
+}
+
+// DeleteSend should return expected error with the storage unavailable code when the storage can't be reached
+func (suite *schemaResolverTestSuite) TestDeleteSendWithUnavailableStorage() {
+	sendRepositoryServiceMock := new(mockutil.SendRepositoryServiceMock)
+	sendRepositoryServiceMock.On("DeleteSendByIdAndUserId", mock.Anything, mock.Anything).Return(repository.ErrUnavailable).Times(1)
+	suite.resolver.sendRepository = sendRepositoryServiceMock
+
+	deleted, err := suite.mutationResolver.DeleteSend(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{Message: "could not process send", Extensions: map[string]interface{}{"code": "STORAGE_UNAVAILABLE"}},
+		"Should return expected error when the storage is unavailable",
+	)
+	assert.False(suite.T(), deleted, "Should not confirm the deletion")
+}
+
+// DeleteSend should return expected error on an invalid send id
+func (suite *schemaResolverTestSuite) TestDeleteSendWithInvalidId() {
+	deleted, err := suite.mutationResolver.DeleteSend(suite.graphqlRequestContext, "invalid")
+	assert.Equal(suite.T(), err, gqlerror.Errorf("could not process send"), "Should return expected error")
+	assert.False(suite.T(), deleted, "Should not confirm the deletion")
+}
+
+// Sends should fetch the available sends of the authenticated user
+func (suite *schemaResolverTestSuite) TestSends() {
+	sendRepositoryServiceMock := mockutil.DefaultSendRepositoryServiceMock()
+	suite.resolver.sendRepository = sendRepositoryServiceMock
+
+	sends, err := suite.queryResolver.Sends(suite.graphqlRequestContext)
+	assert.Nil(suite.T(), err, "Should fetch sends without any errors")
+	assert.Equal(suite.T(), len(sends), 1)
+	assert.Equal(suite.T(), sends[0].Name, mockutil.DefaultSendName)
+	assert.Equal(suite.T(), sends[0].ExpiresAt, "2021-01-02T00:00:00Z")
+	sendRepositoryServiceMock.AssertCalled(suite.T(), "FetchAvailableSendsByUserId", mock.Anything, mockutil.DefaultIdAsUint64, mock.Anything)
+}
+
+// Sends should return an error for unauthenticated users
+func (suite *schemaResolverTestSuite) TestSendsWithoutAuthentication() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	sends, err := suite.queryResolver.Sends(suite.graphqlRequestContext)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized send request"),
+		"Should return expected error when the user isn't authenticated",
+	)
+	assert.Nil(suite.T(), sends, "Should not return any sends")
+}
+
+func generateTestNewSend() model.NewSend {
+	return model.NewSend{Name: mockutil.DefaultSendName, Secret: mockutil.DefaultPassword, ExpiresInHours: 24, MaxViews: 1}
+}
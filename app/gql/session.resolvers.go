@@ -0,0 +1,71 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) ApproveSession(ctx context.Context, sessionID string) (*model.Session, error) {
+	session, userAuthentication, err := r.fetchOwnSession(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.Status != databaseModel.SessionPendingApproval {
+		return nil, gqlerror.Errorf(sessionStatusErrorMessage)
+	}
+
+	err = r.sessionRepository.UpdateSessionStatusById(databaseModel.SessionPendingApproval, databaseModel.SessionApproved, session.Id)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, repositoryError(err, sessionStatusErrorMessage)
+		}
+		log.Printf("Error while approving user session: %s", err)
+		return nil, repositoryError(err, sessionErrorMessage)
+	}
+	session.Status = databaseModel.SessionApproved
+
+	return toSession(session, userAuthentication.SessionId), nil
+}
+
+func (r *mutationResolver) RevokeSession(ctx context.Context, sessionID string) (bool, error) {
+	session, _, err := r.fetchOwnSession(ctx, sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	err = r.sessionRepository.RevokeSessionById(session.Id)
+	if err != nil && err != repository.ErrNotFound {
+		log.Printf("Error while revoking user session: %s", err)
+		return false, repositoryError(err, sessionErrorMessage)
+	}
+
+	return true, nil
+}
+
+func (r *queryResolver) Sessions(ctx context.Context) ([]*model.Session, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(sessionAuthenticationErrorMessage)
+	}
+
+	fetchedSessions := databaseModel.Sessions{}
+	err := r.sessionRepository.FetchSessionsByUserId(&fetchedSessions, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching user sessions: %s", err)
+		return nil, repositoryError(err, sessionErrorMessage)
+	}
+
+	sessions := []*model.Session{}
+	for index := range fetchedSessions {
+		sessions = append(sessions, toSession(&fetchedSessions[index], userAuthentication.SessionId))
+	}
+	return sessions, nil
+}
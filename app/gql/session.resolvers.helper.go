@@ -0,0 +1,191 @@
+package gql
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"log"
+	"strconv"
+	"time"
+)
+
+const (
+	sessionErrorMessage                = "could not process session"
+	sessionAuthenticationErrorMessage  = "unauthorized session request"
+	sessionStatusErrorMessage          = "session is not awaiting approval"
+	deviceApprovalRequiredErrorMessage = "sign in from a new device, approve it from a device you're already signed in on and sign in again"
+	deviceApprovalPendingErrorMessage  = "sign in from this device is still awaiting approval"
+)
+
+const (
+	unknownDeviceName      = "unknown device"
+	deviceNameMaximumRunes = 128
+)
+
+var sessionStatuses = map[string]model.SessionStatus{
+	databaseModel.SessionPendingApproval: model.SessionStatusPendingApproval,
+	databaseModel.SessionApproved:        model.SessionStatusApproved,
+	databaseModel.SessionActive:          model.SessionStatusActive,
+}
+
+func toSession(session *databaseModel.Session, currentSessionId uint64) *model.Session {
+	return &model.Session{
+		ID:         strconv.FormatUint(session.Id, 10),
+		DeviceName: session.DeviceName,
+		UserAgent:  session.UserAgent,
+		IPAddress:  session.IpAddress,
+		Status:     sessionStatuses[session.Status],
+		CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+		LastSeenAt: session.LastSeenAt.Format(time.RFC3339),
+		Current:    session.Id == currentSessionId,
+	}
+}
+
+// sessionDeviceName falls back to the user agent for clients that don't name their device
+func sessionDeviceName(deviceName *string, userAgent string) string {
+	name := userAgent
+	if deviceName != nil && *deviceName != "" {
+		name = *deviceName
+	}
+	if name == "" {
+		return unknownDeviceName
+	}
+	if runes := []rune(name); len(runes) > deviceNameMaximumRunes {
+		return string(runes[:deviceNameMaximumRunes])
+	}
+	return name
+}
+
+// startSession records a new session for the sign in, when new device approval is enabled sign ins from unknown devices
+// only get a session once an already signed in session approved them
+func (r *Resolver) startSession(ctx context.Context, userId uint64, deviceName *string) (*databaseModel.Session, error) {
+	clientInformation := authentication.GetClientInformationFromContext(ctx)
+
+	if r.authenticationService.NewDeviceApprovalRequired() {
+		approvalRequired, err := r.deviceApprovalRequired(userId, clientInformation)
+		if err != nil {
+			log.Printf("Error while checking user known devices: %s", err)
+			return nil, gqlerror.Errorf(signInErrorMessage)
+		}
+		if approvalRequired {
+			return r.claimApprovedSession(userId, deviceName, clientInformation)
+		}
+	}
+
+	session, err := r.insertSession(userId, deviceName, clientInformation, databaseModel.SessionActive)
+	if err != nil {
+		log.Printf("Error while storing user session: %s", err)
+		return nil, gqlerror.Errorf(signInErrorMessage)
+	}
+
+	return session, nil
+}
+
+// deviceApprovalRequired reports whether the sign in comes from an unknown device,
+// the very first sign in has no other device to be approved from
+func (r *Resolver) deviceApprovalRequired(userId uint64, clientInformation authentication.ClientInformation) (bool, error) {
+	knownDevices := databaseModel.KnownDevices{}
+	if err := r.knownDeviceRepository.FetchKnownDevicesByUserId(&knownDevices, userId); err != nil {
+		return false, err
+	}
+	if len(knownDevices) == 0 {
+		return false, nil
+	}
+
+	for _, knownDevice := range knownDevices {
+		if knownDevice.UserAgent == clientInformation.UserAgent && knownDevice.IpAddress == clientInformation.IpAddress {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// claimApprovedSession activates an approved session of the signing in device,
+// otherwise the sign in waits for an approval of its pending session
+func (r *Resolver) claimApprovedSession(
+	userId uint64, deviceName *string, clientInformation authentication.ClientInformation,
+) (*databaseModel.Session, error) {
+	sessions := databaseModel.Sessions{}
+	if err := r.sessionRepository.FetchSessionsByUserId(&sessions, userId); err != nil {
+		log.Printf("Error while fetching user sessions: %s", err)
+		return nil, repositoryError(err, signInErrorMessage)
+	}
+
+	for index := range sessions {
+		session := &sessions[index]
+		if session.UserAgent != clientInformation.UserAgent || session.IpAddress != clientInformation.IpAddress {
+			continue
+		}
+
+		switch session.Status {
+		case databaseModel.SessionPendingApproval:
+			return nil, gqlerror.Errorf(deviceApprovalPendingErrorMessage)
+		case databaseModel.SessionApproved:
+			err := r.sessionRepository.UpdateSessionStatusById(databaseModel.SessionApproved, databaseModel.SessionActive, session.Id)
+			if err != nil {
+				log.Printf("Error while activating approved user session: %s", err)
+				return nil, repositoryError(err, signInErrorMessage)
+			}
+			session.Status = databaseModel.SessionActive
+			return session, nil
+		}
+	}
+
+	_, err := r.insertSession(userId, deviceName, clientInformation, databaseModel.SessionPendingApproval)
+	if err != nil {
+		log.Printf("Error while storing user session: %s", err)
+		return nil, gqlerror.Errorf(signInErrorMessage)
+	}
+	return nil, gqlerror.Errorf(deviceApprovalRequiredErrorMessage)
+}
+
+func (r *Resolver) insertSession(
+	userId uint64, deviceName *string, clientInformation authentication.ClientInformation, status string,
+) (*databaseModel.Session, error) {
+	now := time.Now().UTC()
+	session := &databaseModel.Session{
+		UserId:     userId,
+		DeviceName: sessionDeviceName(deviceName, clientInformation.UserAgent),
+		UserAgent:  clientInformation.UserAgent,
+		IpAddress:  clientInformation.IpAddress,
+		Status:     status,
+		CreatedAt:  now,
+		LastSeenAt: now,
+	}
+
+	insertResult, err := r.sessionRepository.InsertNewSession(session)
+	if err != nil {
+		return nil, err
+	}
+	session.Id = uint64(insertResult.ID().(int64))
+
+	return session, nil
+}
+
+// fetchOwnSession fetches a session of the authenticated user
+func (r *Resolver) fetchOwnSession(ctx context.Context, sessionID string) (*databaseModel.Session, *authentication.UserAuthentication, error) {
+	sessionId, err := strconv.ParseUint(sessionID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting session id to uint64: %s", err)
+		return nil, nil, gqlerror.Errorf(sessionErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, nil, gqlerror.Errorf(sessionAuthenticationErrorMessage)
+	}
+
+	session := &databaseModel.Session{}
+	err = r.sessionRepository.FetchSessionById(session, sessionId)
+	if err != nil {
+		log.Printf("Error while fetching user session: %s", err)
+		return nil, nil, repositoryError(err, sessionErrorMessage)
+	}
+	if session.UserId != userAuthentication.UserId {
+		return nil, nil, gqlerror.Errorf(sessionAuthenticationErrorMessage)
+	}
+
+	return session, userAuthentication, nil
+}
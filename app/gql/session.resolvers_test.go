@@ -0,0 +1,243 @@
+package gql
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"strings"
+	"testing"
+)
+
+// SignIn should record an active session and bind the issued token to it
+func (suite *schemaResolverTestSuite) TestSignInStartsSession() {
+	sessionRepositoryServiceMock := mockutil.DefaultSessionRepositoryServiceMock()
+	suite.resolver.sessionRepository = sessionRepositoryServiceMock
+	jwtAuthenticationServiceMock := mockutil.DefaultJwtAuthenticationServiceMock()
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+	deviceName := mockutil.DefaultDeviceName
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword, DeviceName: &deviceName}
+
+	_, err := suite.mutationResolver.SignIn(generateClientInformationContext(), input)
+	assert.Nil(suite.T(), err, "User should sign in without any errors")
+
+	sessionRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewSession", mock.MatchedBy(func(session *databaseModel.Session) bool {
+		return session.DeviceName == mockutil.DefaultDeviceName && session.UserAgent == mockutil.DefaultUserAgent &&
+			session.IpAddress == mockutil.DefaultIpAddress && session.Status == databaseModel.SessionActive
+	}))
+	jwtAuthenticationServiceMock.AssertCalled(suite.T(), "GenerateJwt", mockutil.DefaultIdAsUint64, mockutil.DefaultIdAsUint64)
+}
+
+// SignIn should hold back the token and wait for an approval when signing in from a new device
+func (suite *schemaResolverTestSuite) TestSignInFromNewDeviceWithApprovalRequired() {
+	jwtAuthenticationServiceMock := jwtAuthenticationMockWithDeviceApproval()
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+	suite.resolver.knownDeviceRepository = unlimitedKnownDeviceRepositoryMock("Chrome", "10.0.0.2")
+	sessionRepositoryServiceMock := sessionRepositoryMockWithSessions(databaseModel.Sessions{})
+	suite.resolver.sessionRepository = sessionRepositoryServiceMock
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	userWithToken, err := suite.mutationResolver.SignIn(generateClientInformationContext(), input)
+	assert.Equal(
+		suite.T(), err,
+		gqlerror.Errorf("sign in from a new device, approve it from a device you're already signed in on and sign in again"),
+		"Should return expected error when the device needs an approval",
+	)
+	assert.Nil(suite.T(), userWithToken, "Should not return any user data")
+
+	sessionRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewSession", mock.MatchedBy(func(session *databaseModel.Session) bool {
+		return session.Status == databaseModel.SessionPendingApproval
+	}))
+	jwtAuthenticationServiceMock.AssertNotCalled(suite.T(), "GenerateJwt", mock.Anything, mock.Anything)
+}
+
+// SignIn should not create another pending session while the device still awaits approval
+func (suite *schemaResolverTestSuite) TestSignInFromNewDeviceWithPendingApproval() {
+	suite.resolver.authenticationService = jwtAuthenticationMockWithDeviceApproval()
+	suite.resolver.knownDeviceRepository = unlimitedKnownDeviceRepositoryMock("Chrome", "10.0.0.2")
+	pendingSession := mockutil.DefaultSession()
+	pendingSession.Status = databaseModel.SessionPendingApproval
+	sessionRepositoryServiceMock := sessionRepositoryMockWithSessions(databaseModel.Sessions{pendingSession})
+	suite.resolver.sessionRepository = sessionRepositoryServiceMock
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	userWithToken, err := suite.mutationResolver.SignIn(generateClientInformationContext(), input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("sign in from this device is still awaiting approval"),
+		"Should return expected error while the approval is pending",
+	)
+	assert.Nil(suite.T(), userWithToken, "Should not return any user data")
+	sessionRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertNewSession", mock.Anything)
+}
+
+// SignIn should activate the approved session of a new device and bind the issued token to it
+func (suite *schemaResolverTestSuite) TestSignInFromNewDeviceWithApprovedSession() {
+	jwtAuthenticationServiceMock := jwtAuthenticationMockWithDeviceApproval()
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+	suite.resolver.knownDeviceRepository = unlimitedKnownDeviceRepositoryMock("Chrome", "10.0.0.2")
+	approvedSession := mockutil.DefaultSession()
+	approvedSession.Id = uint64(2)
+	approvedSession.Status = databaseModel.SessionApproved
+	sessionRepositoryServiceMock := sessionRepositoryMockWithSessions(databaseModel.Sessions{approvedSession})
+	suite.resolver.sessionRepository = sessionRepositoryServiceMock
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	userWithToken, err := suite.mutationResolver.SignIn(generateClientInformationContext(), input)
+	assert.Nil(suite.T(), err, "User should sign in without any errors")
+	assert.Equal(suite.T(), userWithToken.Token, mockutil.MockedJwtToken)
+
+	sessionRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateSessionStatusById", databaseModel.SessionApproved, databaseModel.SessionActive, approvedSession.Id,
+	)
+	sessionRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertNewSession", mock.Anything)
+	jwtAuthenticationServiceMock.AssertCalled(suite.T(), "GenerateJwt", mockutil.DefaultIdAsUint64, approvedSession.Id)
+}
+
+// SignIn should not require an approval for known devices
+func (suite *schemaResolverTestSuite) TestSignInFromKnownDeviceWithApprovalRequired() {
+	suite.resolver.authenticationService = jwtAuthenticationMockWithDeviceApproval()
+	suite.resolver.knownDeviceRepository = unlimitedKnownDeviceRepositoryMock(mockutil.DefaultUserAgent, mockutil.DefaultIpAddress)
+	sessionRepositoryServiceMock := mockutil.DefaultSessionRepositoryServiceMock()
+	suite.resolver.sessionRepository = sessionRepositoryServiceMock
+	input := model.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	_, err := suite.mutationResolver.SignIn(generateClientInformationContext(), input)
+	assert.Nil(suite.T(), err, "User should sign in without any errors")
+
+	sessionRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewSession", mock.MatchedBy(func(session *databaseModel.Session) bool {
+		return session.Status == databaseModel.SessionActive
+	}))
+}
+
+// Sessions should return the user's sessions and mark the current one
+func (suite *schemaResolverTestSuite) TestSessions() {
+	otherSession := mockutil.DefaultSession()
+	otherSession.Id = uint64(2)
+	suite.resolver.sessionRepository = sessionRepositoryMockWithSessions(databaseModel.Sessions{mockutil.DefaultSession(), otherSession})
+
+	sessions, err := suite.queryResolver.Sessions(context.Background())
+	assert.Nil(suite.T(), err, "Sessions should be fetched without errors")
+
+	assert.Equal(suite.T(), len(sessions), 2)
+	assert.Equal(suite.T(), sessions[0].DeviceName, mockutil.DefaultDeviceName)
+	assert.Equal(suite.T(), sessions[0].Status, model.SessionStatusActive)
+	assert.True(suite.T(), sessions[0].Current, "Session of the request should be marked as current")
+	assert.False(suite.T(), sessions[1].Current, "Other sessions should not be marked as current")
+}
+
+// Sessions should return expected error on unauthenticated requests
+func (suite *schemaResolverTestSuite) TestSessionsWithoutAuthentication() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	sessions, err := suite.queryResolver.Sessions(context.Background())
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized session request"), "Should return expected error")
+	assert.Nil(suite.T(), sessions, "Should not return any sessions")
+}
+
+// ApproveSession should approve a session awaiting approval
+func (suite *schemaResolverTestSuite) TestApproveSession() {
+	pendingSession := mockutil.DefaultSession()
+	pendingSession.Id = uint64(2)
+	pendingSession.Status = databaseModel.SessionPendingApproval
+	sessionRepositoryServiceMock := new(mockutil.SessionRepositoryServiceMock)
+	sessionRepositoryServiceMock.On("FetchSessionById", mock.Anything, pendingSession.Id).Return(nil, pendingSession).Times(1)
+	sessionRepositoryServiceMock.On("UpdateSessionStatusById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	suite.resolver.sessionRepository = sessionRepositoryServiceMock
+
+	session, err := suite.mutationResolver.ApproveSession(context.Background(), "2")
+	assert.Nil(suite.T(), err, "Session should be approved without errors")
+	assert.Equal(suite.T(), session.Status, model.SessionStatusApproved)
+	assert.False(suite.T(), session.Current, "Approved session should not be the current session")
+
+	sessionRepositoryServiceMock.AssertCalled(
+		suite.T(), "UpdateSessionStatusById", databaseModel.SessionPendingApproval, databaseModel.SessionApproved, pendingSession.Id,
+	)
+}
+
+// ApproveSession should return expected error for sessions that don't await approval
+func (suite *schemaResolverTestSuite) TestApproveSessionWithActiveSession() {
+	sessionRepositoryServiceMock := mockutil.DefaultSessionRepositoryServiceMock()
+	suite.resolver.sessionRepository = sessionRepositoryServiceMock
+
+	session, err := suite.mutationResolver.ApproveSession(context.Background(), mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("session is not awaiting approval"), "Should return expected error")
+	assert.Nil(suite.T(), session, "Should not return any session data")
+	sessionRepositoryServiceMock.AssertNotCalled(suite.T(), "UpdateSessionStatusById", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// RevokeSession should revoke the given session of the user
+func (suite *schemaResolverTestSuite) TestRevokeSession() {
+	sessionRepositoryServiceMock := mockutil.DefaultSessionRepositoryServiceMock()
+	suite.resolver.sessionRepository = sessionRepositoryServiceMock
+
+	revoked, err := suite.mutationResolver.RevokeSession(context.Background(), mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Session should be revoked without errors")
+	assert.True(suite.T(), revoked, "Should return true on successful revocation")
+
+	sessionRepositoryServiceMock.AssertCalled(suite.T(), "RevokeSessionById", mockutil.DefaultIdAsUint64)
+}
+
+// RevokeSession should not revoke sessions of other users
+func (suite *schemaResolverTestSuite) TestRevokeSessionOfAnotherUser() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(
+		&authentication.UserAuthentication{UserId: uint64(2), SessionId: uint64(2)},
+	).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+	sessionRepositoryServiceMock := mockutil.DefaultSessionRepositoryServiceMock()
+	suite.resolver.sessionRepository = sessionRepositoryServiceMock
+
+	revoked, err := suite.mutationResolver.RevokeSession(context.Background(), mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized session request"), "Should return expected error")
+	assert.False(suite.T(), revoked, "Should return false on failed revocation")
+	sessionRepositoryServiceMock.AssertNotCalled(suite.T(), "RevokeSessionById", mock.Anything)
+}
+
+// sessionDeviceName should fall back to the user agent and cap the device name length
+func TestSessionDeviceName(t *testing.T) {
+	deviceName := mockutil.DefaultDeviceName
+	assert.Equal(t, sessionDeviceName(&deviceName, mockutil.DefaultUserAgent), mockutil.DefaultDeviceName)
+	assert.Equal(t, sessionDeviceName(nil, mockutil.DefaultUserAgent), mockutil.DefaultUserAgent)
+	assert.Equal(t, sessionDeviceName(nil, ""), "unknown device")
+
+	longDeviceName := strings.Repeat("d", 200)
+	assert.Equal(t, len(sessionDeviceName(&longDeviceName, "")), 128)
+}
+
+func jwtAuthenticationMockWithDeviceApproval() *mockutil.JwtAuthenticationServiceMock {
+	jwtAuthenticationServiceMock := mockutil.DefaultJwtAuthenticationServiceMock()
+	jwtAuthenticationServiceMock.ExpectedCalls = nil
+	jwtAuthenticationServiceMock.On("GenerateJwt", mock.Anything, mock.Anything).Return(mockutil.MockedJwtToken, nil).Times(1)
+	jwtAuthenticationServiceMock.On("NewDeviceApprovalRequired").Return(true).Times(1)
+
+	return jwtAuthenticationServiceMock
+}
+
+// unlimitedKnownDeviceRepositoryMock allows fetching known devices both for the device approval and the new device alert
+func unlimitedKnownDeviceRepositoryMock(userAgent string, ipAddress string) *mockutil.KnownDeviceRepositoryServiceMock {
+	knownDeviceRepositoryServiceMock := new(mockutil.KnownDeviceRepositoryServiceMock)
+	knownDeviceRepositoryServiceMock.On("FetchKnownDevicesByUserId", mock.Anything, mock.Anything).Return(
+		nil, databaseModel.KnownDevices{{Id: mockutil.DefaultIdAsUint64, UserId: mockutil.DefaultIdAsUint64, UserAgent: userAgent, IpAddress: ipAddress}},
+	)
+	knownDeviceRepositoryServiceMock.On("InsertNewKnownDevice", mock.Anything).Return(db.NewInsertResult(int64(2)), nil)
+	knownDeviceRepositoryServiceMock.On("UpdateLastSeenById", mock.Anything, mock.Anything).Return(nil)
+
+	return knownDeviceRepositoryServiceMock
+}
+
+func sessionRepositoryMockWithSessions(sessions databaseModel.Sessions) *mockutil.SessionRepositoryServiceMock {
+	sessionRepositoryServiceMock := mockutil.DefaultSessionRepositoryServiceMock()
+	sessionRepositoryServiceMock.ExpectedCalls = nil
+	sessionRepositoryServiceMock.On("FetchSessionsByUserId", mock.Anything, mock.Anything).Return(nil, sessions).Times(1)
+	sessionRepositoryServiceMock.On("InsertNewSession", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	sessionRepositoryServiceMock.On("UpdateSessionStatusById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return sessionRepositoryServiceMock
+}
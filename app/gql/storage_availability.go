@@ -0,0 +1,45 @@
+package gql
+
+import (
+	"context"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+const storageUnavailableErrorMessage = "storage unavailable"
+
+// StorageMonitor tells whether the storage is able to serve requests
+type StorageMonitor interface {
+	Available() bool
+}
+
+// StorageAvailability fails operations fast while the storage is unavailable, instead of having every resolver
+// wait for its own repository calls to time out
+type StorageAvailability struct {
+	Monitor StorageMonitor
+}
+
+var _ interface {
+	graphql.HandlerExtension
+	graphql.OperationInterceptor
+} = StorageAvailability{}
+
+func (StorageAvailability) ExtensionName() string {
+	return "StorageAvailability"
+}
+
+func (StorageAvailability) Validate(graphql.ExecutableSchema) error {
+	return nil
+}
+
+func (storageAvailability StorageAvailability) InterceptOperation(
+	ctx context.Context, next graphql.OperationHandler,
+) graphql.ResponseHandler {
+	if storageAvailability.Monitor.Available() {
+		return next(ctx)
+	}
+	return graphql.OneShot(&graphql.Response{Errors: gqlerror.List{{
+		Message:    storageUnavailableErrorMessage,
+		Extensions: map[string]interface{}{"code": storageUnavailableErrorCode},
+	}}})
+}
@@ -0,0 +1,42 @@
+package gql
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"testing"
+)
+
+type storageMonitorStub bool
+
+func (available storageMonitorStub) Available() bool {
+	return bool(available)
+}
+
+func nextOperationHandler(ctx context.Context) graphql.ResponseHandler {
+	return graphql.OneShot(&graphql.Response{Data: json.RawMessage(`{"user":null}`)})
+}
+
+// StorageAvailability should run operations while the storage is available
+func TestStorageAvailabilityWithAvailableStorage(t *testing.T) {
+	responseHandler := StorageAvailability{Monitor: storageMonitorStub(true)}.InterceptOperation(context.Background(), nextOperationHandler)
+
+	response := responseHandler(context.Background())
+	assert.Equal(t, string(response.Data), `{"user":null}`)
+	assert.Nil(t, response.Errors)
+}
+
+// StorageAvailability should fail operations fast while the storage is unavailable
+func TestStorageAvailabilityWithUnavailableStorage(t *testing.T) {
+	responseHandler := StorageAvailability{Monitor: storageMonitorStub(false)}.InterceptOperation(context.Background(), nextOperationHandler)
+
+	response := responseHandler(context.Background())
+	assert.Nil(t, response.Data)
+	assert.Equal(
+		t, response.Errors,
+		gqlerror.List{{Message: "storage unavailable", Extensions: map[string]interface{}{"code": "STORAGE_UNAVAILABLE"}}},
+	)
+	assert.Nil(t, responseHandler(context.Background()), "Should respond only once")
+}
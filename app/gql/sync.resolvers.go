@@ -0,0 +1,81 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *queryResolver) Sync(ctx context.Context, sinceRevision int) (*model.VaultDelta, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(syncAuthenticationErrorMessage)
+	}
+	if sinceRevision < 0 {
+		return nil, gqlerror.Errorf(syncRevisionErrorMessage)
+	}
+
+	user := databaseModel.User{}
+	err := r.userRepository.FetchMasterPasswordByUserId(&user, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching user master password: %s", err)
+		return nil, repositoryError(err, syncErrorMessage)
+	}
+
+	changes := databaseModel.VaultChanges{}
+	err = r.passwordRepository.FetchVaultChanges(&changes, userAuthentication.UserId, int64(sinceRevision))
+	if err != nil {
+		log.Printf("Error while fetching vault changes: %s", err)
+		return nil, repositoryError(err, syncErrorMessage)
+	}
+	// A client ahead of the vault holds revisions the server doesn't know about, e.g. after a database restore
+	if changes.Revision < int64(sinceRevision) {
+		return nil, gqlerror.Errorf(syncRevisionAheadErrorMessage)
+	}
+
+	vaultDelta := &model.VaultDelta{
+		Revision:         int(changes.Revision),
+		Passwords:        []*model.Password{},
+		DeletedPasswords: []*model.PasswordTombstone{},
+	}
+	for _, password := range changes.Passwords {
+		decryptedPassword, err := r.passwordSecurityService.DecryptWithAes(password.Password, user.Password)
+		if err != nil {
+			log.Printf("Error while decrypting user password: %s", err)
+			return nil, gqlerror.Errorf(syncErrorMessage)
+		}
+		totpUri, err := r.decryptTotpUri(password.TotpUri, user.Password)
+		if err != nil {
+			log.Printf("Error while decrypting user TOTP uri: %s", err)
+			return nil, gqlerror.Errorf(syncErrorMessage)
+		}
+		vaultDelta.Passwords = append(vaultDelta.Passwords, &model.Password{
+			ID:          strconv.FormatUint(password.Id, 10),
+			UserID:      strconv.FormatUint(password.UserId, 10),
+			Name:        password.Name,
+			Password:    decryptedPassword,
+			Revision:    int(password.Revision),
+			Version:     int(password.Version),
+			TotpURI:     totpUri,
+			CurrentCode: toOneTimeCode(totpUri),
+		})
+	}
+	for index := range changes.Tombstones {
+		vaultDelta.DeletedPasswords = append(vaultDelta.DeletedPasswords, toPasswordTombstone(&changes.Tombstones[index]))
+	}
+
+	if len(vaultDelta.Passwords) > 0 {
+		r.recordAuditEvent(
+			ctx, databaseModel.AuditEventPasswordsViewed, &userAuthentication.UserId,
+			auditDetails(strconv.Itoa(len(vaultDelta.Passwords))+" entries"),
+		)
+	}
+	return vaultDelta, nil
+}
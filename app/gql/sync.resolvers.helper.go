@@ -0,0 +1,21 @@
+package gql
+
+import (
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"strconv"
+)
+
+const (
+	syncErrorMessage               = "could not sync vault"
+	syncAuthenticationErrorMessage = "unauthorized vault sync"
+	syncRevisionErrorMessage       = "invalid sync revision"
+	syncRevisionAheadErrorMessage  = "sync revision is ahead of the vault, a full sync is required"
+)
+
+func toPasswordTombstone(tombstone *databaseModel.PasswordTombstone) *model.PasswordTombstone {
+	return &model.PasswordTombstone{
+		ID:       strconv.FormatUint(tombstone.PasswordId, 10),
+		Revision: int(tombstone.Revision),
+	}
+}
@@ -0,0 +1,93 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// Sync should return the changed entries and the tombstones of deleted ones since the given revision
+func (suite *schemaResolverTestSuite) TestSync() {
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+
+	vaultDelta, err := suite.queryResolver.Sync(suite.graphqlRequestContext, int(mockutil.DefaultRevision))
+	assert.Nil(suite.T(), err, "Should sync the vault without any errors")
+	assert.Equal(suite.T(), vaultDelta, &model.VaultDelta{
+		Revision: int(mockutil.DefaultRevision + 2),
+		Passwords: []*model.Password{{
+			ID:       mockutil.DefaultIdAsString,
+			UserID:   mockutil.DefaultIdAsString,
+			Name:     mockutil.DefaultPasswordName,
+			Password: mockutil.MockedDecryptedPassword,
+			Revision: int(mockutil.DefaultRevision + 1),
+		}},
+		DeletedPasswords: []*model.PasswordTombstone{{ID: "2", Revision: int(mockutil.DefaultRevision + 2)}},
+	})
+	passwordRepositoryServiceMock.AssertCalled(
+		suite.T(), "FetchVaultChanges", mock.Anything, mockutil.DefaultIdAsUint64, mockutil.DefaultRevision,
+	)
+}
+
+// Sync should return empty changes for a client that's up to date
+func (suite *schemaResolverTestSuite) TestSyncWithoutChanges() {
+	passwordRepositoryServiceMock := new(mockutil.PasswordRepositoryServiceMock)
+	passwordRepositoryServiceMock.On("FetchVaultChanges", mock.Anything, mock.Anything, mock.Anything).Return(
+		nil, databaseModel.VaultChanges{Revision: mockutil.DefaultRevision},
+	).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	auditRecorderMock := mockutil.DefaultAuditRecorderMock()
+	suite.resolver.auditRecorder = auditRecorderMock
+
+	vaultDelta, err := suite.queryResolver.Sync(suite.graphqlRequestContext, int(mockutil.DefaultRevision))
+	assert.Nil(suite.T(), err, "Should sync the vault without any errors")
+	assert.Equal(suite.T(), vaultDelta, &model.VaultDelta{
+		Revision: int(mockutil.DefaultRevision), Passwords: []*model.Password{}, DeletedPasswords: []*model.PasswordTombstone{},
+	})
+	auditRecorderMock.AssertNotCalled(suite.T(), "Record", mock.Anything)
+}
+
+// Sync should return expected error for revisions the vault hasn't reached
+func (suite *schemaResolverTestSuite) TestSyncWithRevisionAheadOfVault() {
+	vaultDelta, err := suite.queryResolver.Sync(suite.graphqlRequestContext, int(mockutil.DefaultRevision+3))
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("sync revision is ahead of the vault, a full sync is required"), "Should return expected error",
+	)
+	assert.Nil(suite.T(), vaultDelta)
+}
+
+// Sync should return expected error for negative revisions
+func (suite *schemaResolverTestSuite) TestSyncWithNegativeRevision() {
+	vaultDelta, err := suite.queryResolver.Sync(suite.graphqlRequestContext, -1)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("invalid sync revision"), "Should return expected error")
+	assert.Nil(suite.T(), vaultDelta)
+}
+
+// Sync should return expected error when fetching the vault changes fails
+func (suite *schemaResolverTestSuite) TestSyncWithFetchError() {
+	passwordRepositoryServiceMock := new(mockutil.PasswordRepositoryServiceMock)
+	passwordRepositoryServiceMock.On("FetchVaultChanges", mock.Anything, mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage),
+	).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+
+	vaultDelta, err := suite.queryResolver.Sync(suite.graphqlRequestContext, 0)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("could not sync vault"), "Should return expected error")
+	assert.Nil(suite.T(), vaultDelta)
+}
+
+// Sync should return expected error on unauthenticated requests
+func (suite *schemaResolverTestSuite) TestSyncWithoutAuthentication() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	vaultDelta, err := suite.queryResolver.Sync(context.Background(), 0)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized vault sync"), "Should return expected error")
+	assert.Nil(suite.T(), vaultDelta)
+}
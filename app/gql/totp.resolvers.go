@@ -0,0 +1,94 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/eventbus"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) AdvanceHotpCounter(ctx context.Context, input string, version int) (*model.Password, error) {
+	passwordId, err := strconv.ParseUint(input, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting password id to uint64: %s", err)
+		return nil, gqlerror.Errorf(hotpCounterErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	userPassword := &databaseModel.Password{}
+	err = r.passwordRepository.FetchPasswordById(ctx, userPassword, passwordId)
+	if err != nil {
+		log.Printf("Error occurred while fetching user password by id: %s", err)
+		return nil, repositoryError(err, hotpCounterErrorMessage)
+	}
+	if userAuthentication == nil || userPassword.UserId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(passwordAuthenticationErrorMessage)
+	}
+	if userPassword.OrganizationId != nil {
+		return nil, gqlerror.Errorf(sharedPasswordModificationErrorMessage)
+	}
+
+	user := databaseModel.User{}
+	err = r.userRepository.FetchMasterPasswordByUserId(&user, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching user master password: %s", err)
+		return nil, repositoryError(err, hotpCounterErrorMessage)
+	}
+
+	totpUri, err := r.decryptTotpUri(userPassword.TotpUri, user.Password)
+	if err != nil {
+		log.Printf("Error while decrypting user TOTP uri: %s", err)
+		return nil, gqlerror.Errorf(hotpCounterErrorMessage)
+	}
+	totpUri, err = nextHotpCounter(totpUri)
+	if err != nil {
+		return nil, err
+	}
+	encryptedTotpUri, err := r.encryptTotpUri(totpUri, user.Password)
+	if err != nil {
+		log.Printf("Error while encrypting user TOTP uri: %s", err)
+		return nil, gqlerror.Errorf(hotpCounterErrorMessage)
+	}
+	decryptedPassword, err := r.passwordSecurityService.DecryptWithAes(userPassword.Password, user.Password)
+	if err != nil {
+		log.Printf("Error while decrypting user password: %s", err)
+		return nil, gqlerror.Errorf(hotpCounterErrorMessage)
+	}
+
+	content := &sharedEntryContent{Name: userPassword.Name, Password: decryptedPassword, TotpUri: totpUri}
+	updatedPassword := &databaseModel.Password{
+		Id: passwordId, Name: userPassword.Name, Password: userPassword.Password, TotpUri: encryptedTotpUri,
+	}
+	err = r.storePasswordUpdate(updatedPassword, content, toExpectedVersion(&version))
+	if err != nil {
+		if err == repository.ErrPasswordSharesChanged {
+			return nil, repositoryError(err, passwordSharesChangedErrorMessage)
+		}
+		if conflict := passwordVersionConflict(err); conflict != nil {
+			return nil, conflict
+		}
+		log.Printf("Error while advancing HOTP counter: %s", err)
+		return nil, repositoryError(err, hotpCounterErrorMessage)
+	}
+	r.recordAuditEvent(ctx, databaseModel.AuditEventPasswordUpdated, &userAuthentication.UserId, auditDetails("entry "+input))
+	r.publishVaultChange(userPassword.UserId, passwordId, eventbus.VaultEntryUpdated)
+
+	return &model.Password{
+		ID:          input,
+		UserID:      strconv.FormatUint(userPassword.UserId, 10),
+		Name:        userPassword.Name,
+		Password:    decryptedPassword,
+		Revision:    int(updatedPassword.Revision),
+		Version:     int(updatedPassword.Version),
+		TotpURI:     totpUri,
+		CurrentCode: toOneTimeCode(totpUri),
+	}, nil
+}
@@ -0,0 +1,118 @@
+package gql
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/otp"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"log"
+	"strings"
+	"time"
+)
+
+const (
+	invalidTotpUriErrorMessage = "invalid otpauth uri, expected a totp, hotp or steam uri with a base32 secret"
+	hotpCounterErrorMessage    = "could not advance hotp counter"
+	notHotpErrorMessage        = "entry has no counter based (hotp) seed"
+)
+
+// Variable meant for mocking
+var oneTimeCodeNow = time.Now
+
+// normalizeTotpUri validates the otpauth URI of an entry input and returns its canonical form, entries without a seed
+// return nil
+func normalizeTotpUri(totpUri *string) (*string, error) {
+	if totpUri == nil || strings.TrimSpace(*totpUri) == "" {
+		return nil, nil
+	}
+
+	key, err := otp.Parse(*totpUri)
+	if err != nil {
+		return nil, gqlerror.Errorf(invalidTotpUriErrorMessage)
+	}
+	normalizedTotpUri := key.String()
+	return &normalizedTotpUri, nil
+}
+
+// encryptTotpUri encrypts the otpauth URI with the key of the entry's vault, entries without a seed store nil
+func (r *Resolver) encryptTotpUri(totpUri *string, vaultKey []byte) ([]byte, error) {
+	if totpUri == nil {
+		return nil, nil
+	}
+	return r.passwordSecurityService.EncryptWithAes(*totpUri, vaultKey)
+}
+
+func (r *Resolver) decryptTotpUri(encryptedTotpUri []byte, vaultKey []byte) (*string, error) {
+	if encryptedTotpUri == nil {
+		return nil, nil
+	}
+
+	totpUri, err := r.passwordSecurityService.DecryptWithAes(encryptedTotpUri, vaultKey)
+	if err != nil {
+		return nil, err
+	}
+	return &totpUri, nil
+}
+
+// reencryptTotpUri moves the seed of an entry along with its password whenever the entry changes vaults or keys
+func (r *Resolver) reencryptTotpUri(encryptedTotpUri []byte, oldVaultKey []byte, newVaultKey []byte) ([]byte, error) {
+	totpUri, err := r.decryptTotpUri(encryptedTotpUri, oldVaultKey)
+	if err != nil {
+		return nil, err
+	}
+	return r.encryptTotpUri(totpUri, newVaultKey)
+}
+
+// nextHotpCounter returns the otpauth URI of a counter based seed with its counter advanced to the next code,
+// entries without a counter based seed have no counter to advance
+func nextHotpCounter(totpUri *string) (*string, error) {
+	if totpUri == nil {
+		return nil, gqlerror.Errorf(notHotpErrorMessage)
+	}
+
+	key, err := otp.Parse(*totpUri)
+	if err != nil {
+		log.Printf("Error while parsing stored otpauth uri: %s", err)
+		return nil, gqlerror.Errorf(hotpCounterErrorMessage)
+	}
+	if key.IsTimeBased() {
+		return nil, gqlerror.Errorf(notHotpErrorMessage)
+	}
+
+	key.Counter++
+	nextTotpUri := key.String()
+	return &nextTotpUri, nil
+}
+
+// toOneTimeCode computes the current code of the seed, entries without a seed have no code
+func toOneTimeCode(totpUri *string) *model.OneTimeCode {
+	if totpUri == nil {
+		return nil
+	}
+
+	key, err := otp.Parse(*totpUri)
+	if err != nil {
+		log.Printf("Error while parsing stored otpauth uri: %s", err)
+		return nil
+	}
+
+	now := oneTimeCodeNow()
+	oneTimeCode := &model.OneTimeCode{Code: key.Code(now)}
+	if key.IsTimeBased() {
+		secondsRemaining := key.SecondsRemaining(now)
+		oneTimeCode.SecondsRemaining = &secondsRemaining
+	}
+	return oneTimeCode
+}
+
+// passwordQueryFields maps the requested fields of a password to the columns they're read from,
+// the current code is computed from the stored seed
+func passwordQueryFields(fields []string) []string {
+	queryFields := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field == "currentCode" {
+			field = "totpUri"
+		}
+		queryFields = append(queryFields, field)
+	}
+	return queryFields
+}
@@ -0,0 +1,274 @@
+package gql
+
+import (
+	"context"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"time"
+)
+
+// CreatePassword should store the canonical otpauth URI encrypted and return the current code of the seed
+func (suite *schemaResolverTestSuite) TestCreatePasswordWithTotpUri() {
+	defer mockOneTimeCodeNow(time.Unix(59, 0))()
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("EncryptWithAes", mockutil.DefaultPassword, mock.Anything).Return([]byte(mockutil.MockedEncryptedPassword), nil).Times(1)
+	passwordSecurityServiceMock.On("EncryptWithAes", mockutil.DefaultTotpUri, mock.Anything).Return([]byte(mockutil.MockedEncryptedTotpUri), nil).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	totpUri := "otpauth://totp/domain.com:username?secret=gezd gnbv gy3t qojq gezd gnbv gy3t qojq&issuer=domain.com"
+	input := model.NewPassword{
+		UserID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, TotpURI: &totpUri,
+	}
+
+	password, err := suite.mutationResolver.CreatePassword(context.Background(), input)
+	assert.Nil(suite.T(), err, "Password should be created without errors")
+	assert.Equal(suite.T(), *password.TotpURI, mockutil.DefaultTotpUri, "Should return the canonical otpauth URI")
+	assert.Equal(suite.T(), password.CurrentCode.Code, "287082")
+	assert.Equal(suite.T(), *password.CurrentCode.SecondsRemaining, 1)
+
+	passwordRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewPassword", mock.MatchedBy(func(password *databaseModel.Password) bool {
+		return string(password.TotpUri) == mockutil.MockedEncryptedTotpUri
+	}))
+}
+
+// CreatePassword should reject seeds that aren't valid otpauth URIs
+func (suite *schemaResolverTestSuite) TestCreatePasswordWithInvalidTotpUri() {
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	totpUri := "otpauth://totp/domain.com?secret=not-base32!"
+	input := model.NewPassword{
+		UserID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword, TotpURI: &totpUri,
+	}
+
+	password, err := suite.mutationResolver.CreatePassword(context.Background(), input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("invalid otpauth uri, expected a totp, hotp or steam uri with a base32 secret"),
+		"Should return expected error when the otpauth URI is invalid",
+	)
+	assert.Nil(suite.T(), password, "Should not return any password data")
+	passwordRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertNewPassword", mock.Anything)
+}
+
+// UpdatePassword should replace the seed of the entry and reseal it for the entry's recipients
+func (suite *schemaResolverTestSuite) TestUpdatePasswordWithTotpUri() {
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("EncryptWithAes", mockutil.DefaultPassword, mock.Anything).Return([]byte(mockutil.MockedEncryptedPassword), nil).Times(1)
+	passwordSecurityServiceMock.On("EncryptWithAes", mockutil.DefaultTotpUri, mock.Anything).Return([]byte(mockutil.MockedEncryptedTotpUri), nil).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	totpUri := mockutil.DefaultTotpUri
+	input := model.UpdatePassword{
+		ID: mockutil.DefaultIdAsString, Name: mockutil.DefaultPasswordName, Password: mockutil.DefaultPassword,
+		Version: int(mockutil.DefaultVersion), TotpURI: &totpUri,
+	}
+
+	password, err := suite.mutationResolver.UpdatePassword(suite.graphqlRequestContext, input)
+	assert.Nil(suite.T(), err, "Password should be updated without errors")
+	assert.Equal(suite.T(), *password.TotpURI, mockutil.DefaultTotpUri)
+	assert.NotNil(suite.T(), password.CurrentCode, "Should return the current code of the new seed")
+
+	passwordRepositoryServiceMock.AssertCalled(suite.T(), "UpdatePasswordById", mock.MatchedBy(func(password *databaseModel.Password) bool {
+		return string(password.TotpUri) == mockutil.MockedEncryptedTotpUri
+	}), mock.Anything)
+}
+
+// Sync should carry the decrypted seeds of changed entries along with their current codes
+func (suite *schemaResolverTestSuite) TestSyncWithTotpUri() {
+	defer mockOneTimeCodeNow(time.Unix(59, 0))()
+	changes := mockutil.DefaultVaultChanges()
+	changes.Passwords[0].TotpUri = []byte(mockutil.MockedEncryptedTotpUri)
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	passwordRepositoryServiceMock.ExpectedCalls = nil
+	passwordRepositoryServiceMock.On("FetchVaultChanges", mock.Anything, mock.Anything, mock.Anything).Return(nil, changes).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("DecryptWithAes", []byte(mockutil.MockedEncryptedPassword), mock.Anything).Return(mockutil.MockedDecryptedPassword, nil).Times(1)
+	passwordSecurityServiceMock.On("DecryptWithAes", []byte(mockutil.MockedEncryptedTotpUri), mock.Anything).Return(mockutil.DefaultTotpUri, nil).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	vaultDelta, err := suite.queryResolver.Sync(suite.graphqlRequestContext, int(mockutil.DefaultRevision))
+	assert.Nil(suite.T(), err, "Should sync the vault without any errors")
+	assert.Equal(suite.T(), *vaultDelta.Passwords[0].TotpURI, mockutil.DefaultTotpUri)
+	assert.Equal(suite.T(), vaultDelta.Passwords[0].CurrentCode.Code, "287082")
+}
+
+// MovePasswordToOrganization should re-encrypt the seed of the entry with the organization key
+func (suite *schemaResolverTestSuite) TestMovePasswordToOrganizationWithTotpUri() {
+	userPassword := databaseModel.Password{
+		Id: mockutil.DefaultIdAsUint64, UserId: mockutil.DefaultIdAsUint64, Name: mockutil.DefaultPasswordName,
+		Password: []byte(mockutil.MockedEncryptedPassword), TotpUri: []byte(mockutil.MockedEncryptedTotpUri),
+	}
+	passwordRepositoryServiceMock := mockutil.DefaultPasswordRepositoryServiceMock()
+	passwordRepositoryServiceMock.ExpectedCalls = nil
+	passwordRepositoryServiceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(nil, userPassword).Times(1)
+	passwordRepositoryServiceMock.On("MovePasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(mockutil.MockedNextRevision, nil).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	suite.resolver.keyPairRepository = keyPairRepositoryMockWithKeyPair()
+	suite.resolver.userRepository = unlimitedUserRepositoryMock()
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("DecryptWithAes", []byte(mockutil.MockedEncryptedTotpUri), mock.Anything).Return(mockutil.DefaultTotpUri, nil)
+	passwordSecurityServiceMock.On("DecryptWithAes", mock.Anything, mock.Anything).Return(mockutil.MockedDecryptedPassword, nil)
+	passwordSecurityServiceMock.On("EncryptWithAes", mockutil.DefaultTotpUri, mock.Anything).Return([]byte("OrganizationTotpUriMock"), nil)
+	passwordSecurityServiceMock.On("EncryptWithAes", mock.Anything, mock.Anything).Return([]byte(mockutil.MockedEncryptedPassword), nil)
+	passwordSecurityServiceMock.On("OpenWithPrivateKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]byte(mockutil.MockedUserMasterPassword), nil)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	password, err := suite.mutationResolver.MovePasswordToOrganization(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, mockutil.DefaultIdAsString,
+	)
+	assert.Nil(suite.T(), err, "Should move the password without any errors")
+	assert.Equal(suite.T(), *password.TotpURI, mockutil.DefaultTotpUri)
+
+	passwordRepositoryServiceMock.AssertCalled(
+		suite.T(), "MovePasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, []byte("OrganizationTotpUriMock"), mock.Anything,
+	)
+}
+
+// AdvanceHotpCounter should store the seed with its next counter and return the code of the next counter
+func (suite *schemaResolverTestSuite) TestAdvanceHotpCounter() {
+	passwordRepositoryServiceMock := passwordRepositoryMockWithTotpUri()
+	passwordRepositoryServiceMock.On("UpdatePasswordById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	passwordSecurityServiceMock := passwordSecurityMockWithTotpUri(defaultHotpUri)
+	passwordSecurityServiceMock.On("EncryptWithAes", nextHotpUri, mock.Anything).Return([]byte("EncryptedNextHotpUriMock"), nil).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	password, err := suite.mutationResolver.AdvanceHotpCounter(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, int(mockutil.DefaultVersion),
+	)
+	assert.Nil(suite.T(), err, "Counter should be advanced without errors")
+	assert.Equal(suite.T(), *password.TotpURI, nextHotpUri)
+	assert.Equal(suite.T(), password.CurrentCode.Code, "359152", "Should return the code of the next counter")
+	assert.Equal(suite.T(), password.Password, mockutil.MockedDecryptedPassword)
+	assert.Equal(suite.T(), password.Version, int(mockutil.MockedNextVersion))
+
+	expectedVersion := mockutil.DefaultVersion
+	passwordRepositoryServiceMock.AssertCalled(suite.T(), "UpdatePasswordById", mock.MatchedBy(func(password *databaseModel.Password) bool {
+		return string(password.TotpUri) == "EncryptedNextHotpUriMock" && string(password.Password) == mockutil.MockedEncryptedPassword
+	}), &expectedVersion)
+}
+
+// AdvanceHotpCounter should return a conflict error when the entry changed since the expected version,
+// so concurrent clients can't use the same counter twice
+func (suite *schemaResolverTestSuite) TestAdvanceHotpCounterWithVersionConflict() {
+	passwordRepositoryServiceMock := passwordRepositoryMockWithTotpUri()
+	passwordRepositoryServiceMock.On("UpdatePasswordById", mock.Anything, mock.Anything).Return(
+		&repository.VersionConflictError{CurrentVersion: mockutil.MockedNextVersion},
+	).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	passwordSecurityServiceMock := passwordSecurityMockWithTotpUri(defaultHotpUri)
+	passwordSecurityServiceMock.On("EncryptWithAes", mock.Anything, mock.Anything).Return([]byte("EncryptedNextHotpUriMock"), nil).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	password, err := suite.mutationResolver.AdvanceHotpCounter(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, int(mockutil.DefaultVersion),
+	)
+	assert.Equal(
+		suite.T(), err, &gqlerror.Error{
+			Message:    "password changed since the expected version",
+			Extensions: map[string]interface{}{"code": "CONFLICT", "currentVersion": mockutil.MockedNextVersion},
+		},
+		"Should return a conflict error with the current version when the password changed since the expected version",
+	)
+	assert.Nil(suite.T(), password, "Should not return any password data")
+}
+
+// AdvanceHotpCounter should pass on the code of the repository error when the update fails
+func (suite *schemaResolverTestSuite) TestAdvanceHotpCounterWithUnavailableStorage() {
+	passwordRepositoryServiceMock := passwordRepositoryMockWithTotpUri()
+	passwordRepositoryServiceMock.On("UpdatePasswordById", mock.Anything, mock.Anything).Return(repository.ErrUnavailable).Times(1)
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	passwordSecurityServiceMock := passwordSecurityMockWithTotpUri(defaultHotpUri)
+	passwordSecurityServiceMock.On("EncryptWithAes", mock.Anything, mock.Anything).Return([]byte("EncryptedNextHotpUriMock"), nil).Times(1)
+	suite.resolver.passwordSecurityService = passwordSecurityServiceMock
+
+	password, err := suite.mutationResolver.AdvanceHotpCounter(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, int(mockutil.DefaultVersion),
+	)
+	assert.Equal(
+		suite.T(), err,
+		&gqlerror.Error{Message: "could not advance hotp counter", Extensions: map[string]interface{}{"code": "STORAGE_UNAVAILABLE"}},
+		"Should return expected error when the storage is unavailable",
+	)
+	assert.Nil(suite.T(), password, "Should not return any password data")
+}
+
+// AdvanceHotpCounter should return expected error for entries without a counter based seed
+func (suite *schemaResolverTestSuite) TestAdvanceHotpCounterWithTimeBasedSeed() {
+	passwordRepositoryServiceMock := passwordRepositoryMockWithTotpUri()
+	suite.resolver.passwordRepository = passwordRepositoryServiceMock
+	suite.resolver.passwordSecurityService = passwordSecurityMockWithTotpUri(mockutil.DefaultTotpUri)
+
+	password, err := suite.mutationResolver.AdvanceHotpCounter(
+		suite.graphqlRequestContext, mockutil.DefaultIdAsString, int(mockutil.DefaultVersion),
+	)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("entry has no counter based (hotp) seed"),
+		"Should return expected error when the seed of the entry is time based",
+	)
+	assert.Nil(suite.T(), password, "Should not return any password data")
+	passwordRepositoryServiceMock.AssertNotCalled(suite.T(), "UpdatePasswordById", mock.Anything, mock.Anything)
+}
+
+// toOneTimeCode should compute alphanumeric Steam Guard codes and counter based codes without an expiry
+func (suite *schemaResolverTestSuite) TestToOneTimeCode() {
+	defer mockOneTimeCodeNow(time.Unix(1234567890, 0))()
+
+	steamUri := "otpauth://steam/Steam:username?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	steamCode := toOneTimeCode(&steamUri)
+	assert.Equal(suite.T(), steamCode.Code, "VHHQY")
+	assert.Equal(suite.T(), *steamCode.SecondsRemaining, 30)
+
+	hotpUri := "otpauth://hotp/username?secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ&counter=1"
+	hotpCode := toOneTimeCode(&hotpUri)
+	assert.Equal(suite.T(), hotpCode.Code, "287082")
+	assert.Nil(suite.T(), hotpCode.SecondsRemaining, "Counter based codes shouldn't expire")
+
+	assert.Nil(suite.T(), toOneTimeCode(nil), "Entries without a seed shouldn't have a code")
+}
+
+// passwordQueryFields should read the current code from the stored seed
+func (suite *schemaResolverTestSuite) TestPasswordQueryFields() {
+	assert.Equal(
+		suite.T(), passwordQueryFields([]string{"id", "name", "currentCode"}), []string{"id", "name", "totpUri"},
+	)
+}
+
+const (
+	defaultHotpUri = "otpauth://hotp/username?algorithm=SHA1&counter=1&digits=6&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	nextHotpUri    = "otpauth://hotp/username?algorithm=SHA1&counter=2&digits=6&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+)
+
+func passwordRepositoryMockWithTotpUri() *mockutil.PasswordRepositoryServiceMock {
+	passwordRepositoryServiceMock := new(mockutil.PasswordRepositoryServiceMock)
+	passwordRepositoryServiceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(nil, databaseModel.Password{
+		Id: mockutil.DefaultIdAsUint64, UserId: mockutil.DefaultIdAsUint64, Name: mockutil.DefaultPasswordName,
+		Password: []byte(mockutil.MockedEncryptedPassword), TotpUri: []byte(mockutil.MockedEncryptedTotpUri),
+		Version: mockutil.DefaultVersion,
+	}).Times(1)
+	return passwordRepositoryServiceMock
+}
+
+func passwordSecurityMockWithTotpUri(totpUri string) *mockutil.PasswordSecurityServiceMock {
+	passwordSecurityServiceMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityServiceMock.On("DecryptWithAes", []byte(mockutil.MockedEncryptedTotpUri), mock.Anything).Return(totpUri, nil).Times(1)
+	passwordSecurityServiceMock.On("DecryptWithAes", []byte(mockutil.MockedEncryptedPassword), mock.Anything).
+		Return(mockutil.MockedDecryptedPassword, nil).Times(1)
+	return passwordSecurityServiceMock
+}
+
+func mockOneTimeCodeNow(now time.Time) func() {
+	oneTimeCodeNow = func() time.Time { return now }
+	return func() { oneTimeCodeNow = time.Now }
+}
@@ -0,0 +1,48 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+
+	"github.com/KristijanFaust/gokeeper/app/gql/generated"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *subscriptionResolver) VaultChanged(ctx context.Context) (<-chan *model.VaultChange, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(vaultSubscriptionAuthenticationErrorMessage)
+	}
+
+	events, unsubscribe := r.vaultEventBus.Subscribe(userAuthentication.UserId)
+	vaultChanges := make(chan *model.VaultChange)
+	go func() {
+		defer close(vaultChanges)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, open := <-events:
+				if !open {
+					return
+				}
+				select {
+				case vaultChanges <- toVaultChange(&event):
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return vaultChanges, nil
+}
+
+// Subscription returns generated.SubscriptionResolver implementation.
+func (r *Resolver) Subscription() generated.SubscriptionResolver { return &subscriptionResolver{r} }
+
+type subscriptionResolver struct{ *Resolver }
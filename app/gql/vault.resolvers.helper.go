@@ -0,0 +1,29 @@
+package gql
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/eventbus"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"strconv"
+	"time"
+)
+
+const vaultSubscriptionAuthenticationErrorMessage = "unauthorized vault subscription"
+
+var vaultChangeActions = map[string]model.VaultChangeAction{
+	eventbus.VaultEntryCreated: model.VaultChangeActionCreated,
+	eventbus.VaultEntryUpdated: model.VaultChangeActionUpdated,
+	eventbus.VaultEntryDeleted: model.VaultChangeActionDeleted,
+}
+
+// publishVaultChange notifies the vault subscriptions of the user, so their other sessions can refresh the entry
+func (r *Resolver) publishVaultChange(userId uint64, passwordId uint64, action string) {
+	r.vaultEventBus.Publish(eventbus.Event{UserId: userId, PasswordId: passwordId, Action: action, OccurredAt: time.Now().UTC()})
+}
+
+func toVaultChange(event *eventbus.Event) *model.VaultChange {
+	return &model.VaultChange{
+		Action:     vaultChangeActions[event.Action],
+		PasswordID: strconv.FormatUint(event.PasswordId, 10),
+		ChangedAt:  event.OccurredAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,60 @@
+package gql
+
+import (
+	"context"
+	"github.com/KristijanFaust/gokeeper/app/eventbus"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"time"
+)
+
+// VaultChanged should stream the vault changes of the authenticated user until the subscription ends
+func (suite *schemaResolverTestSuite) TestVaultChanged() {
+	vaultEventBus := eventbus.NewLocalBus()
+	suite.resolver.vaultEventBus = vaultEventBus
+	ctx, cancel := context.WithCancel(context.Background())
+
+	vaultChanges, err := suite.subscriptionResolver.VaultChanged(ctx)
+	assert.Nil(suite.T(), err, "Should subscribe to vault changes without any errors")
+
+	changedAt := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	vaultEventBus.Publish(eventbus.Event{UserId: 2, PasswordId: 3, Action: eventbus.VaultEntryCreated, OccurredAt: changedAt})
+	vaultEventBus.Publish(eventbus.Event{
+		UserId: mockutil.DefaultIdAsUint64, PasswordId: 4, Action: eventbus.VaultEntryDeleted, OccurredAt: changedAt,
+	})
+	assert.Equal(suite.T(), <-vaultChanges, &model.VaultChange{
+		Action: model.VaultChangeActionDeleted, PasswordID: "4", ChangedAt: "2021-01-01T00:00:00Z",
+	})
+
+	cancel()
+	_, open := <-vaultChanges
+	assert.False(suite.T(), open, "Should close the subscription once its context is done")
+}
+
+// VaultChanged should return expected error on unauthenticated subscriptions
+func (suite *schemaResolverTestSuite) TestVaultChangedWithoutAuthentication() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	vaultChanges, err := suite.subscriptionResolver.VaultChanged(context.Background())
+	assert.Equal(suite.T(), err, gqlerror.Errorf("unauthorized vault subscription"), "Should return expected error")
+	assert.Nil(suite.T(), vaultChanges)
+}
+
+// UpdatePassword should notify the vault subscriptions of the entry's owner
+func (suite *schemaResolverTestSuite) TestUpdatePasswordPublishesVaultChange() {
+	vaultEventBusMock := mockutil.DefaultVaultEventBusMock()
+	suite.resolver.vaultEventBus = vaultEventBusMock
+	input := model.UpdatePassword{ID: mockutil.DefaultIdAsString, Name: "updatedName", Password: "updatedPassword", Version: int(mockutil.DefaultVersion)}
+
+	_, err := suite.mutationResolver.UpdatePassword(context.Background(), input)
+	assert.Nil(suite.T(), err, "Password should be updated without errors")
+	vaultEventBusMock.AssertCalled(suite.T(), "Publish", mock.MatchedBy(func(event eventbus.Event) bool {
+		return event.UserId == mockutil.DefaultIdAsUint64 && event.PasswordId == mockutil.DefaultIdAsUint64 &&
+			event.Action == eventbus.VaultEntryUpdated
+	}))
+}
@@ -0,0 +1,259 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"encoding/base64"
+	"log"
+	"strconv"
+	"time"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/mail"
+	"github.com/KristijanFaust/gokeeper/app/webauthn"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) BeginWebauthnRegistration(ctx context.Context) (*model.WebauthnCreationOptions, error) {
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(webauthnAuthenticationErrorMessage)
+	}
+
+	user := databaseModel.User{}
+	err := r.userRepository.FetchById(&user, userAuthentication.UserId, []string{"email", "username"})
+	if err != nil {
+		log.Printf("Error while fetching user: %s", err)
+		return nil, repositoryError(err, webauthnRegistrationErrorMessage)
+	}
+
+	credentials := databaseModel.WebauthnCredentials{}
+	err = r.webauthnRepository.FetchCredentialsByUserId(&credentials, userAuthentication.UserId)
+	if err != nil {
+		log.Printf("Error while fetching user webauthn credentials: %s", err)
+		return nil, repositoryError(err, webauthnRegistrationErrorMessage)
+	}
+
+	challenge, err := r.webauthnVerifier.GenerateChallenge()
+	if err != nil {
+		log.Printf("Error while generating webauthn challenge: %s", err)
+		return nil, gqlerror.Errorf(webauthnRegistrationErrorMessage)
+	}
+
+	_, err = r.webauthnRepository.InsertNewChallenge(&databaseModel.WebauthnChallenge{
+		UserId:    userAuthentication.UserId,
+		Challenge: challenge,
+		Ceremony:  webauthn.RegistrationCeremony,
+		ExpiresAt: time.Now().UTC().Add(r.webauthnVerifier.ChallengeTimeout()),
+	})
+	if err != nil {
+		log.Printf("Error while storing webauthn challenge: %s", err)
+		return nil, repositoryError(err, webauthnRegistrationErrorMessage)
+	}
+
+	return &model.WebauthnCreationOptions{
+		Challenge:    base64.RawURLEncoding.EncodeToString(challenge),
+		RelyingParty: &model.WebauthnRelyingParty{ID: r.webauthnVerifier.RelyingPartyId(), Name: r.webauthnVerifier.RelyingPartyName()},
+		User: &model.WebauthnUser{
+			ID:          base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatUint(userAuthentication.UserId, 10))),
+			Name:        user.Email,
+			DisplayName: user.Username,
+		},
+		Algorithms:         []int{webauthnEs256Algorithm},
+		Timeout:            int(r.webauthnVerifier.ChallengeTimeout().Milliseconds()),
+		ExcludeCredentials: encodeWebauthnCredentialIds(credentials),
+	}, nil
+}
+
+func (r *mutationResolver) FinishWebauthnRegistration(ctx context.Context, input model.WebauthnRegistration) (*model.WebauthnCredential, error) {
+	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
+	if validationErrors != nil {
+		return nil, gqlerror.Errorf("validation error/s on webauthn registration input")
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(webauthnAuthenticationErrorMessage)
+	}
+
+	clientDataJson, err := base64.RawURLEncoding.DecodeString(input.ClientDataJSON)
+	if err != nil {
+		log.Printf("Error while decoding webauthn client data: %s", err)
+		return nil, gqlerror.Errorf(webauthnRegistrationErrorMessage)
+	}
+	attestationObject, err := base64.RawURLEncoding.DecodeString(input.AttestationObject)
+	if err != nil {
+		log.Printf("Error while decoding webauthn attestation object: %s", err)
+		return nil, gqlerror.Errorf(webauthnRegistrationErrorMessage)
+	}
+	var prfWrappedKey []byte
+	if input.PrfWrappedKey != nil {
+		prfWrappedKey, err = base64.RawURLEncoding.DecodeString(*input.PrfWrappedKey)
+		if err != nil {
+			log.Printf("Error while decoding webauthn prf wrapped key: %s", err)
+			return nil, gqlerror.Errorf(webauthnRegistrationErrorMessage)
+		}
+	}
+
+	challengeValue, err := webauthn.ParseChallenge(clientDataJson)
+	if err != nil {
+		log.Printf("Error while parsing webauthn client data: %s", err)
+		return nil, gqlerror.Errorf(webauthnRegistrationErrorMessage)
+	}
+	challenge := databaseModel.WebauthnChallenge{}
+	err = r.webauthnRepository.ConsumeChallenge(&challenge, challengeValue, webauthn.RegistrationCeremony)
+	if err != nil {
+		log.Printf("Error while fetching webauthn challenge: %s", err)
+		return nil, repositoryError(err, webauthnRegistrationErrorMessage)
+	}
+	if challenge.UserId != userAuthentication.UserId {
+		return nil, gqlerror.Errorf(webauthnAuthenticationErrorMessage)
+	}
+
+	registeredCredential, err := r.webauthnVerifier.VerifyRegistration(challenge.Challenge, clientDataJson, attestationObject)
+	if err != nil {
+		log.Printf("Error while verifying webauthn registration: %s", err)
+		return nil, gqlerror.Errorf(webauthnRegistrationErrorMessage)
+	}
+
+	newCredential := databaseModel.WebauthnCredential{
+		UserId:        userAuthentication.UserId,
+		CredentialId:  registeredCredential.CredentialId,
+		PublicKey:     registeredCredential.PublicKey,
+		SignCount:     registeredCredential.SignCount,
+		Name:          input.Name,
+		PrfWrappedKey: prfWrappedKey,
+	}
+	insertResult, err := r.webauthnRepository.InsertNewCredential(&newCredential)
+	if err != nil {
+		log.Printf("Error while storing webauthn credential: %s", err)
+		return nil, repositoryError(err, webauthnRegistrationErrorMessage)
+	}
+
+	r.notifySecurityChange(userAuthentication.UserId, webauthnCredentialRegisteredEvent)
+
+	return &model.WebauthnCredential{
+		ID:        strconv.FormatUint(uint64(insertResult.ID().(int64)), 10),
+		Name:      input.Name,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}, nil
+}
+
+func (r *mutationResolver) BeginWebauthnAssertion(ctx context.Context, email string) (*model.WebauthnRequestOptions, error) {
+	user := databaseModel.User{}
+	err := r.userRepository.FetchByEmail(ctx, &user, email, []string{"id"})
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return nil, repositoryError(err, queryNonExistingEmailErrorMessage)
+		}
+		log.Printf("Error while fetching user by email: %s", err)
+		return nil, repositoryError(err, webauthnAssertionErrorMessage)
+	}
+
+	credentials := databaseModel.WebauthnCredentials{}
+	err = r.webauthnRepository.FetchCredentialsByUserId(&credentials, user.Id)
+	if err != nil {
+		log.Printf("Error while fetching user webauthn credentials: %s", err)
+		return nil, repositoryError(err, webauthnAssertionErrorMessage)
+	}
+	if len(credentials) == 0 {
+		return nil, gqlerror.Errorf(webauthnNoCredentialsErrorMessage)
+	}
+
+	challenge, err := r.webauthnVerifier.GenerateChallenge()
+	if err != nil {
+		log.Printf("Error while generating webauthn challenge: %s", err)
+		return nil, gqlerror.Errorf(webauthnAssertionErrorMessage)
+	}
+
+	_, err = r.webauthnRepository.InsertNewChallenge(&databaseModel.WebauthnChallenge{
+		UserId:    user.Id,
+		Challenge: challenge,
+		Ceremony:  webauthn.AssertionCeremony,
+		ExpiresAt: time.Now().UTC().Add(r.webauthnVerifier.ChallengeTimeout()),
+	})
+	if err != nil {
+		log.Printf("Error while storing webauthn challenge: %s", err)
+		return nil, repositoryError(err, webauthnAssertionErrorMessage)
+	}
+
+	return &model.WebauthnRequestOptions{
+		Challenge:        base64.RawURLEncoding.EncodeToString(challenge),
+		RelyingPartyID:   r.webauthnVerifier.RelyingPartyId(),
+		Timeout:          int(r.webauthnVerifier.ChallengeTimeout().Milliseconds()),
+		AllowCredentials: encodeWebauthnCredentialIds(credentials),
+	}, nil
+}
+
+func (r *mutationResolver) UnlockWithWebauthn(ctx context.Context, input model.WebauthnAssertion) (*model.UserWithToken, error) {
+	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
+	if validationErrors != nil {
+		return nil, gqlerror.Errorf("validation error/s on webauthn assertion input")
+	}
+
+	credentialId, err := base64.RawURLEncoding.DecodeString(input.CredentialID)
+	if err != nil {
+		log.Printf("Error while decoding webauthn credential id: %s", err)
+		return nil, gqlerror.Errorf(webauthnAssertionErrorMessage)
+	}
+
+	credential := databaseModel.WebauthnCredential{}
+	err = r.webauthnRepository.FetchCredentialByCredentialId(&credential, credentialId)
+	if err != nil {
+		log.Printf("Error while fetching webauthn credential: %s", err)
+		return nil, repositoryError(err, webauthnAssertionErrorMessage)
+	}
+	if credential.PrfWrappedKey == nil {
+		return nil, gqlerror.Errorf(webauthnUnlockNotEnabledErrorMessage)
+	}
+
+	// Without the master password the assertion is the only factor, so user verification is mandatory
+	err = r.verifyWebauthnAssertion(&credential, &input, true)
+	if err != nil {
+		log.Printf("Error while verifying webauthn assertion: %s", err)
+		r.recordAuditEvent(ctx, databaseModel.AuditEventSignInFailed, &credential.UserId, auditDetails("webauthn assertion rejected"))
+		return nil, gqlerror.Errorf(webauthnAssertionErrorMessage)
+	}
+
+	fetchedUser := databaseModel.User{}
+	err = r.userRepository.FetchById(&fetchedUser, credential.UserId, []string{"email", "username", "email_verified", "disabled"})
+	if err != nil {
+		log.Printf("Error while fetching user: %s", err)
+		return nil, repositoryError(err, signInErrorMessage)
+	}
+	if fetchedUser.Disabled {
+		r.recordAuditEvent(ctx, databaseModel.AuditEventSignInFailed, &credential.UserId, auditDetails("account disabled"))
+		return nil, gqlerror.Errorf(accountDisabledErrorMessage)
+	}
+	if r.mailer.EmailVerificationRequired() && !fetchedUser.EmailVerified {
+		r.recordAuditEvent(ctx, databaseModel.AuditEventSignInFailed, &credential.UserId, auditDetails("e-mail not verified"))
+		return nil, gqlerror.Errorf(emailNotVerifiedErrorMessage)
+	}
+
+	session, err := r.startSession(ctx, credential.UserId, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	jwt, err := r.authenticationService.GenerateJwt(credential.UserId, session.Id)
+	if err != nil {
+		return nil, gqlerror.Errorf(signInErrorMessage)
+	}
+
+	r.recordAuditEvent(ctx, databaseModel.AuditEventSignIn, &credential.UserId, auditDetails("session "+strconv.FormatUint(session.Id, 10)))
+	r.alertOnNewDevice(ctx, credential.UserId, mail.Recipient{Email: fetchedUser.Email, Username: fetchedUser.Username})
+
+	user := &model.User{
+		ID:            strconv.FormatUint(credential.UserId, 10),
+		Email:         fetchedUser.Email,
+		Username:      fetchedUser.Username,
+		EmailVerified: fetchedUser.EmailVerified,
+	}
+	prfWrappedKey := base64.RawURLEncoding.EncodeToString(credential.PrfWrappedKey)
+
+	return &model.UserWithToken{User: user, Token: jwt, PrfWrappedKey: &prfWrappedKey}, nil
+}
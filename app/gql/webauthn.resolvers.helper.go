@@ -0,0 +1,116 @@
+package gql
+
+import (
+	"encoding/base64"
+	"errors"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/webauthn"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"log"
+)
+
+const (
+	webauthnRegistrationErrorMessage         = "could not register webauthn credential"
+	webauthnAssertionErrorMessage            = "could not verify webauthn assertion"
+	webauthnAuthenticationErrorMessage       = "unauthorized webauthn request"
+	webauthnSecondFactorRequiredErrorMessage = "webauthn second factor required"
+	webauthnNoCredentialsErrorMessage        = "user has no webauthn credentials"
+	webauthnUnlockNotEnabledErrorMessage     = "webauthn credential is not enabled for vault unlock"
+)
+
+// ES256, the only algorithm supported for WebAuthn credentials
+const webauthnEs256Algorithm = -7
+
+var errWebauthnCredentialOwnership = errors.New("webauthn credential or challenge belongs to another user")
+
+type webauthnAssertionData struct {
+	clientDataJson    []byte
+	authenticatorData []byte
+	signature         []byte
+}
+
+func decodeWebauthnAssertion(assertion *model.WebauthnAssertion) (*webauthnAssertionData, error) {
+	data := &webauthnAssertionData{}
+	var err error
+	if data.clientDataJson, err = base64.RawURLEncoding.DecodeString(assertion.ClientDataJSON); err != nil {
+		return nil, err
+	}
+	if data.authenticatorData, err = base64.RawURLEncoding.DecodeString(assertion.AuthenticatorData); err != nil {
+		return nil, err
+	}
+	if data.signature, err = base64.RawURLEncoding.DecodeString(assertion.Signature); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func encodeWebauthnCredentialIds(credentials databaseModel.WebauthnCredentials) []string {
+	credentialIds := []string{}
+	for _, credential := range credentials {
+		credentialIds = append(credentialIds, base64.RawURLEncoding.EncodeToString(credential.CredentialId))
+	}
+
+	return credentialIds
+}
+
+// verifyWebauthnAssertion verifies an assertion made by the given credential against a challenge issued to the credential owner
+func (r *Resolver) verifyWebauthnAssertion(
+	credential *databaseModel.WebauthnCredential, assertion *model.WebauthnAssertion, requireUserVerification bool,
+) error {
+	assertionData, err := decodeWebauthnAssertion(assertion)
+	if err != nil {
+		return err
+	}
+
+	challengeValue, err := webauthn.ParseChallenge(assertionData.clientDataJson)
+	if err != nil {
+		return err
+	}
+	challenge := &databaseModel.WebauthnChallenge{}
+	if err = r.webauthnRepository.ConsumeChallenge(challenge, challengeValue, webauthn.AssertionCeremony); err != nil {
+		return err
+	}
+	if challenge.UserId != credential.UserId {
+		return errWebauthnCredentialOwnership
+	}
+
+	signCount, err := r.webauthnVerifier.VerifyAssertion(
+		challenge.Challenge, assertionData.clientDataJson, assertionData.authenticatorData, assertionData.signature,
+		credential.PublicKey, credential.SignCount, requireUserVerification,
+	)
+	if err != nil {
+		return err
+	}
+
+	return r.webauthnRepository.UpdateSignCountById(signCount, credential.Id)
+}
+
+// verifyWebauthnSecondFactor requires a valid assertion from users that have registered WebAuthn credentials
+func (r *Resolver) verifyWebauthnSecondFactor(userId uint64, assertion *model.WebauthnAssertion) (*databaseModel.WebauthnCredential, error) {
+	credentials := databaseModel.WebauthnCredentials{}
+	if err := r.webauthnRepository.FetchCredentialsByUserId(&credentials, userId); err != nil {
+		log.Printf("Error while fetching user webauthn credentials: %s", err)
+		return nil, repositoryError(err, signInErrorMessage)
+	}
+	if len(credentials) == 0 {
+		return nil, nil
+	}
+	if assertion == nil {
+		return nil, gqlerror.Errorf(webauthnSecondFactorRequiredErrorMessage)
+	}
+
+	for _, credential := range credentials {
+		if base64.RawURLEncoding.EncodeToString(credential.CredentialId) != assertion.CredentialID {
+			continue
+		}
+		if err := r.verifyWebauthnAssertion(&credential, assertion, false); err != nil {
+			log.Printf("Error while verifying webauthn second factor: %s", err)
+			return nil, gqlerror.Errorf(webauthnAssertionErrorMessage)
+		}
+		return &credential, nil
+	}
+
+	return nil, gqlerror.Errorf(webauthnAssertionErrorMessage)
+}
@@ -0,0 +1,233 @@
+package gql
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/authentication"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	gqlModel "github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// BeginWebauthnRegistration should successfully issue credential creation options
+func (suite *schemaResolverTestSuite) TestBeginWebauthnRegistration() {
+	options, err := suite.mutationResolver.BeginWebauthnRegistration(context.Background())
+	assert.Nil(suite.T(), err, "Creation options should be issued without errors")
+
+	assert.Equal(suite.T(), options.Challenge, base64.RawURLEncoding.EncodeToString([]byte(mockutil.MockedWebauthnChallenge)))
+	assert.Equal(suite.T(), options.RelyingParty.ID, "localhost")
+	assert.Equal(suite.T(), options.User.Name, mockutil.DefaultEmail)
+	assert.Equal(suite.T(), options.User.DisplayName, mockutil.DefaultUsername)
+	assert.Equal(suite.T(), options.Algorithms, []int{-7})
+	assert.Equal(suite.T(), options.ExcludeCredentials, []string{})
+}
+
+// BeginWebauthnRegistration should return expected error when request is not authenticated
+func (suite *schemaResolverTestSuite) TestBeginWebauthnRegistrationUnauthenticated() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(nil).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	options, err := suite.mutationResolver.BeginWebauthnRegistration(context.Background())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized webauthn request"),
+		"Should return expected error when request is not authorized",
+	)
+	assert.Nil(suite.T(), options, "Should not return any creation options")
+}
+
+// BeginWebauthnRegistration should return expected error when storing the challenge fails
+func (suite *schemaResolverTestSuite) TestBeginWebauthnRegistrationWithChallengeInsertError() {
+	webauthnRepositoryServiceMock := mockutil.DefaultWebauthnRepositoryServiceMock()
+	webauthnRepositoryServiceMock.ExpectedCalls = nil
+	webauthnRepositoryServiceMock.On("FetchCredentialsByUserId", mock.Anything, mock.Anything).Return(nil, nil).Times(1)
+	webauthnRepositoryServiceMock.On("InsertNewChallenge", mock.Anything).Return(nil, errors.New(mockutil.MockedGenericErrorMessage)).Times(1)
+	suite.resolver.webauthnRepository = webauthnRepositoryServiceMock
+
+	options, err := suite.mutationResolver.BeginWebauthnRegistration(context.Background())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("could not register webauthn credential"),
+		"Should return expected error when storing the challenge fails",
+	)
+	assert.Nil(suite.T(), options, "Should not return any creation options")
+}
+
+// FinishWebauthnRegistration should successfully register a new credential
+func (suite *schemaResolverTestSuite) TestFinishWebauthnRegistration() {
+	credential, err := suite.mutationResolver.FinishWebauthnRegistration(context.Background(), generateTestWebauthnRegistration())
+	assert.Nil(suite.T(), err, "Credential should be registered without errors")
+
+	assert.Equal(suite.T(), credential.ID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), credential.Name, mockutil.DefaultWebauthnCredentialName)
+}
+
+// FinishWebauthnRegistration should return expected error when the challenge was issued to another user
+func (suite *schemaResolverTestSuite) TestFinishWebauthnRegistrationWithForeignChallenge() {
+	jwtAuthenticationServiceMock := new(mockutil.JwtAuthenticationServiceMock)
+	jwtAuthenticationServiceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(
+		&authentication.UserAuthentication{UserId: uint64(2)},
+	).Times(1)
+	suite.resolver.authenticationService = jwtAuthenticationServiceMock
+
+	credential, err := suite.mutationResolver.FinishWebauthnRegistration(context.Background(), generateTestWebauthnRegistration())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("unauthorized webauthn request"),
+		"Should return expected error when the challenge belongs to another user",
+	)
+	assert.Nil(suite.T(), credential, "Should not return any credential data")
+}
+
+// FinishWebauthnRegistration should return expected error when registration verification fails
+func (suite *schemaResolverTestSuite) TestFinishWebauthnRegistrationWithVerificationError() {
+	webauthnVerifierMock := new(mockutil.WebauthnVerifierMock)
+	webauthnVerifierMock.On("VerifyRegistration", mock.Anything, mock.Anything, mock.Anything).Return(
+		nil, errors.New(mockutil.MockedGenericErrorMessage),
+	).Times(1)
+	suite.resolver.webauthnVerifier = webauthnVerifierMock
+
+	credential, err := suite.mutationResolver.FinishWebauthnRegistration(context.Background(), generateTestWebauthnRegistration())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("could not register webauthn credential"),
+		"Should return expected error when registration verification fails",
+	)
+	assert.Nil(suite.T(), credential, "Should not return any credential data")
+}
+
+// BeginWebauthnAssertion should successfully issue credential request options
+func (suite *schemaResolverTestSuite) TestBeginWebauthnAssertion() {
+	suite.resolver.webauthnRepository = webauthnRepositoryMockWithRegisteredCredential()
+
+	options, err := suite.mutationResolver.BeginWebauthnAssertion(context.Background(), mockutil.DefaultEmail)
+	assert.Nil(suite.T(), err, "Request options should be issued without errors")
+
+	assert.Equal(suite.T(), options.Challenge, base64.RawURLEncoding.EncodeToString([]byte(mockutil.MockedWebauthnChallenge)))
+	assert.Equal(suite.T(), options.RelyingPartyID, "localhost")
+	assert.Equal(
+		suite.T(), options.AllowCredentials, []string{base64.RawURLEncoding.EncodeToString([]byte(mockutil.MockedWebauthnCredentialId))},
+	)
+}
+
+// BeginWebauthnAssertion should return expected error when user has no registered credentials
+func (suite *schemaResolverTestSuite) TestBeginWebauthnAssertionWithoutCredentials() {
+	options, err := suite.mutationResolver.BeginWebauthnAssertion(context.Background(), mockutil.DefaultEmail)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("user has no webauthn credentials"),
+		"Should return expected error when user has no registered credentials",
+	)
+	assert.Nil(suite.T(), options, "Should not return any request options")
+}
+
+// UnlockWithWebauthn should sign in the user, record the sign in and return the PRF wrapped vault key
+func (suite *schemaResolverTestSuite) TestUnlockWithWebauthn() {
+	auditRecorderMock := mockutil.DefaultAuditRecorderMock()
+	suite.resolver.auditRecorder = auditRecorderMock
+
+	userWithToken, err := suite.mutationResolver.UnlockWithWebauthn(context.Background(), generateTestWebauthnAssertion())
+	assert.Nil(suite.T(), err, "User should be unlocked without errors")
+
+	assert.Equal(suite.T(), userWithToken.Token, mockutil.MockedJwtToken)
+	assert.Equal(suite.T(), userWithToken.User.ID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), *userWithToken.PrfWrappedKey, base64.RawURLEncoding.EncodeToString([]byte(mockutil.MockedPrfWrappedKey)))
+	auditRecorderMock.AssertCalled(suite.T(), "Record", auditEventMatching(model.AuditEventSignIn, mockutil.DefaultIdAsUint64))
+}
+
+// UnlockWithWebauthn should return expected error for unverified users when e-mail verification is required
+func (suite *schemaResolverTestSuite) TestUnlockWithWebauthnWithUnverifiedEmail() {
+	mailServiceMock := new(mockutil.MailServiceMock)
+	mailServiceMock.On("EmailVerificationRequired").Return(true).Times(1)
+	suite.resolver.mailer = mailServiceMock
+	auditRecorderMock := mockutil.DefaultAuditRecorderMock()
+	suite.resolver.auditRecorder = auditRecorderMock
+
+	userWithToken, err := suite.mutationResolver.UnlockWithWebauthn(context.Background(), generateTestWebauthnAssertion())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("the e-mail address is not verified"),
+		"Should return expected error when the user's e-mail address is not verified",
+	)
+	assert.Nil(suite.T(), userWithToken, "Token should not be generated")
+	auditRecorderMock.AssertCalled(suite.T(), "Record", auditEventMatching(model.AuditEventSignInFailed, mockutil.DefaultIdAsUint64))
+}
+
+// UnlockWithWebauthn should return expected error when the assertion is not valid
+func (suite *schemaResolverTestSuite) TestUnlockWithWebauthnWithVerificationError() {
+	webauthnVerifierMock := new(mockutil.WebauthnVerifierMock)
+	webauthnVerifierMock.On(
+		"VerifyAssertion", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, true,
+	).Return(uint32(0), errors.New(mockutil.MockedGenericErrorMessage)).Times(1)
+	suite.resolver.webauthnVerifier = webauthnVerifierMock
+
+	userWithToken, err := suite.mutationResolver.UnlockWithWebauthn(context.Background(), generateTestWebauthnAssertion())
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("could not verify webauthn assertion"),
+		"Should return expected error when assertion verification fails",
+	)
+	assert.Nil(suite.T(), userWithToken, "Should not return any user data")
+}
+
+// SignIn should require a webauthn assertion from users with registered credentials
+func (suite *schemaResolverTestSuite) TestSignInWithoutRequiredWebauthnSecondFactor() {
+	suite.resolver.webauthnRepository = webauthnRepositoryMockWithRegisteredCredential()
+	input := gqlModel.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword}
+
+	userWithToken, err := suite.mutationResolver.SignIn(context.Background(), input)
+	assert.Equal(
+		suite.T(), err, gqlerror.Errorf("webauthn second factor required"),
+		"Should return expected error when the second factor is missing",
+	)
+	assert.Nil(suite.T(), userWithToken, "Token should not be generated")
+}
+
+// SignIn should successfully sign in a user with a webauthn second factor
+func (suite *schemaResolverTestSuite) TestSignInWithWebauthnSecondFactor() {
+	suite.resolver.webauthnRepository = webauthnRepositoryMockWithRegisteredCredential()
+	assertion := generateTestWebauthnAssertion()
+	input := gqlModel.UserSignIn{Email: mockutil.DefaultEmail, Password: mockutil.DefaultPassword, WebauthnAssertion: &assertion}
+
+	userWithToken, err := suite.mutationResolver.SignIn(context.Background(), input)
+	assert.Nil(suite.T(), err, "User should sign in without any errors")
+
+	assert.Equal(suite.T(), userWithToken.Token, mockutil.MockedJwtToken)
+	assert.Equal(suite.T(), *userWithToken.PrfWrappedKey, base64.RawURLEncoding.EncodeToString([]byte(mockutil.MockedPrfWrappedKey)))
+}
+
+func webauthnRepositoryMockWithRegisteredCredential() *mockutil.WebauthnRepositoryServiceMock {
+	webauthnRepositoryServiceMock := mockutil.DefaultWebauthnRepositoryServiceMock()
+	webauthnRepositoryServiceMock.ExpectedCalls = nil
+	webauthnRepositoryServiceMock.On("FetchCredentialsByUserId", mock.Anything, mock.Anything).Return(
+		nil, model.WebauthnCredentials{mockutil.DefaultWebauthnCredential()},
+	).Times(1)
+	webauthnRepositoryServiceMock.On("FetchCredentialByCredentialId", mock.Anything, mock.Anything).Return(nil).Times(1)
+	webauthnRepositoryServiceMock.On("UpdateSignCountById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	webauthnRepositoryServiceMock.On("InsertNewChallenge", mock.Anything).Return(nil, nil).Times(1)
+	webauthnRepositoryServiceMock.On("ConsumeChallenge", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return webauthnRepositoryServiceMock
+}
+
+func generateTestClientDataJson() string {
+	clientDataJson := `{"type":"webauthn.get","challenge":"` + base64.RawURLEncoding.EncodeToString([]byte(mockutil.MockedWebauthnChallenge)) + `"}`
+	return base64.RawURLEncoding.EncodeToString([]byte(clientDataJson))
+}
+
+func generateTestWebauthnRegistration() gqlModel.WebauthnRegistration {
+	prfWrappedKey := base64.RawURLEncoding.EncodeToString([]byte(mockutil.MockedPrfWrappedKey))
+	return gqlModel.WebauthnRegistration{
+		Name:              mockutil.DefaultWebauthnCredentialName,
+		ClientDataJSON:    generateTestClientDataJson(),
+		AttestationObject: base64.RawURLEncoding.EncodeToString([]byte("attestation")),
+		PrfWrappedKey:     &prfWrappedKey,
+	}
+}
+
+func generateTestWebauthnAssertion() gqlModel.WebauthnAssertion {
+	return gqlModel.WebauthnAssertion{
+		CredentialID:      base64.RawURLEncoding.EncodeToString([]byte(mockutil.MockedWebauthnCredentialId)),
+		ClientDataJSON:    generateTestClientDataJson(),
+		AuthenticatorData: base64.RawURLEncoding.EncodeToString([]byte("authenticatorData")),
+		Signature:         base64.RawURLEncoding.EncodeToString([]byte("signature")),
+	}
+}
@@ -0,0 +1,149 @@
+package gql
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func (r *mutationResolver) CreateWebhook(ctx context.Context, input model.NewWebhook) (*model.CreatedWebhook, error) {
+	validationErrors := manageValidationsErrors(r.validator.Struct(input), ctx)
+	if validationErrors != nil {
+		return nil, gqlerror.Errorf("validation error/s on webhook input")
+	}
+	if !isValidWebhookUrl(input.URL) {
+		return nil, gqlerror.Errorf(webhookUrlErrorMessage)
+	}
+
+	userAuthentication := r.authenticationService.GetAuthenticatedUserDataFromContext(ctx)
+	if userAuthentication == nil {
+		return nil, gqlerror.Errorf(adminAuthorizationErrorMessage)
+	}
+
+	// The secret is needed to sign every delivery so it's stored as is, it's only ever shown on creation
+	secret, err := security.GenerateRandomToken()
+	if err != nil {
+		log.Printf("Error while generating webhook secret: %s", err)
+		return nil, gqlerror.Errorf(webhookErrorMessage)
+	}
+
+	webhook := databaseModel.Webhook{
+		CreatedBy: userAuthentication.UserId,
+		Url:       input.URL,
+		Secret:    []byte(secret),
+		CreatedAt: time.Now().UTC(),
+	}
+	for _, eventType := range input.EventTypes {
+		webhook.EventTypes = append(webhook.EventTypes, strings.ToLower(eventType.String()))
+	}
+	insertResult, err := r.webhookRepository.InsertNewWebhook(&webhook)
+	if err != nil {
+		log.Printf("Error while storing webhook: %s", err)
+		return nil, repositoryError(err, webhookErrorMessage)
+	}
+	webhook.Id = uint64(insertResult.ID().(int64))
+
+	return &model.CreatedWebhook{Webhook: toWebhook(&webhook), Secret: secret}, nil
+}
+
+func (r *mutationResolver) DeleteWebhook(ctx context.Context, webhookID string) (bool, error) {
+	webhookId, err := strconv.ParseUint(webhookID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting webhook id to uint64: %s", err)
+		return false, gqlerror.Errorf(webhookErrorMessage)
+	}
+
+	err = r.webhookRepository.DeleteWebhookById(webhookId)
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return false, repositoryError(err, webhookNotFoundErrorMessage)
+		}
+		log.Printf("Error while deleting webhook: %s", err)
+		return false, repositoryError(err, webhookErrorMessage)
+	}
+
+	return true, nil
+}
+
+func (r *mutationResolver) RedeliverWebhookDeadLetter(ctx context.Context, deadLetterID string) (bool, error) {
+	deadLetterId, err := strconv.ParseUint(deadLetterID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting dead letter id to uint64: %s", err)
+		return false, gqlerror.Errorf(webhookErrorMessage)
+	}
+
+	err = r.webhookRepository.RedeliverDeadLetterById(deadLetterId, time.Now().UTC())
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return false, repositoryError(err, webhookDeadLetterNotFoundErrorMessage)
+		}
+		log.Printf("Error while redelivering webhook dead letter: %s", err)
+		return false, repositoryError(err, webhookErrorMessage)
+	}
+
+	return true, nil
+}
+
+func (r *queryResolver) Webhooks(ctx context.Context) ([]*model.Webhook, error) {
+	fetchedWebhooks := databaseModel.Webhooks{}
+	err := r.webhookRepository.FetchWebhooks(&fetchedWebhooks)
+	if err != nil {
+		log.Printf("Error while fetching webhooks: %s", err)
+		return nil, repositoryError(err, webhookErrorMessage)
+	}
+
+	webhooks := []*model.Webhook{}
+	for index := range fetchedWebhooks {
+		webhooks = append(webhooks, toWebhook(&fetchedWebhooks[index]))
+	}
+	return webhooks, nil
+}
+
+func (r *queryResolver) WebhookDeliveries(ctx context.Context, webhookID string, offset int, limit int) ([]*model.WebhookDelivery, error) {
+	webhookId, err := strconv.ParseUint(webhookID, 10, 64)
+	if err != nil {
+		log.Printf("Error occurred while converting webhook id to uint64: %s", err)
+		return nil, gqlerror.Errorf(webhookErrorMessage)
+	}
+
+	offset, limit = adminPage(offset, limit)
+	fetchedDeliveries := databaseModel.WebhookDeliveries{}
+	err = r.webhookRepository.FetchDeliveriesByWebhookId(&fetchedDeliveries, webhookId, offset, limit)
+	if err != nil {
+		log.Printf("Error while fetching webhook deliveries: %s", err)
+		return nil, repositoryError(err, webhookErrorMessage)
+	}
+
+	deliveries := []*model.WebhookDelivery{}
+	for index := range fetchedDeliveries {
+		deliveries = append(deliveries, toWebhookDelivery(&fetchedDeliveries[index]))
+	}
+	return deliveries, nil
+}
+
+func (r *queryResolver) WebhookDeadLetters(ctx context.Context, offset int, limit int) ([]*model.WebhookDeadLetter, error) {
+	offset, limit = adminPage(offset, limit)
+	fetchedDeadLetters := databaseModel.WebhookDeadLetters{}
+	err := r.webhookRepository.FetchDeadLetters(&fetchedDeadLetters, offset, limit)
+	if err != nil {
+		log.Printf("Error while fetching webhook dead letters: %s", err)
+		return nil, repositoryError(err, webhookErrorMessage)
+	}
+
+	deadLetters := []*model.WebhookDeadLetter{}
+	for index := range fetchedDeadLetters {
+		deadLetters = append(deadLetters, toWebhookDeadLetter(&fetchedDeadLetters[index]))
+	}
+	return deadLetters, nil
+}
@@ -0,0 +1,70 @@
+package gql
+
+import (
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	webhookErrorMessage                   = "could not process webhook request"
+	webhookUrlErrorMessage                = "webhook url must be an absolute http or https url"
+	webhookNotFoundErrorMessage           = "webhook doesn't exist"
+	webhookDeadLetterNotFoundErrorMessage = "dead letter doesn't exist"
+)
+
+var webhookDeliveryStatuses = map[string]model.WebhookDeliveryStatus{
+	databaseModel.WebhookDeliveryPending:   model.WebhookDeliveryStatusPending,
+	databaseModel.WebhookDeliveryDelivered: model.WebhookDeliveryStatusDelivered,
+	databaseModel.WebhookDeliveryDead:      model.WebhookDeliveryStatusDead,
+}
+
+func isValidWebhookUrl(webhookUrl string) bool {
+	parsedUrl, err := url.Parse(webhookUrl)
+	if err != nil {
+		return false
+	}
+	return (parsedUrl.Scheme == "http" || parsedUrl.Scheme == "https") && parsedUrl.Host != ""
+}
+
+func toWebhook(webhook *databaseModel.Webhook) *model.Webhook {
+	eventTypes := []model.AuditEventType{}
+	for _, eventType := range webhook.EventTypes {
+		eventTypes = append(eventTypes, auditEventTypes[eventType])
+	}
+
+	return &model.Webhook{
+		ID:         strconv.FormatUint(webhook.Id, 10),
+		URL:        webhook.Url,
+		EventTypes: eventTypes,
+		CreatedAt:  webhook.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toWebhookDelivery(delivery *databaseModel.WebhookDelivery) *model.WebhookDelivery {
+	return &model.WebhookDelivery{
+		ID:             strconv.FormatUint(delivery.Id, 10),
+		WebhookID:      strconv.FormatUint(delivery.WebhookId, 10),
+		EventType:      auditEventTypes[delivery.EventType],
+		Payload:        delivery.Payload,
+		Status:         webhookDeliveryStatuses[delivery.Status],
+		Attempts:       delivery.Attempts,
+		ResponseStatus: delivery.ResponseStatus,
+		LastError:      delivery.LastError,
+		NextAttemptAt:  delivery.NextAttemptAt.Format(time.RFC3339),
+		CreatedAt:      delivery.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func toWebhookDeadLetter(deadLetter *databaseModel.WebhookDeadLetter) *model.WebhookDeadLetter {
+	return &model.WebhookDeadLetter{
+		ID:         strconv.FormatUint(deadLetter.Id, 10),
+		DeliveryID: strconv.FormatUint(deadLetter.DeliveryId, 10),
+		WebhookID:  strconv.FormatUint(deadLetter.WebhookId, 10),
+		EventType:  auditEventTypes[deadLetter.EventType],
+		LastError:  deadLetter.LastError,
+		FailedAt:   deadLetter.FailedAt.Format(time.RFC3339),
+	}
+}
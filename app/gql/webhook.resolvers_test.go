@@ -0,0 +1,137 @@
+package gql
+
+import (
+	"context"
+	"errors"
+	"github.com/99designs/gqlgen/graphql"
+	databaseModel "github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/gql/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// CreateWebhook should store the webhook with a random secret and return the secret once
+func (suite *schemaResolverTestSuite) TestCreateWebhook() {
+	webhookRepositoryServiceMock := mockutil.DefaultWebhookRepositoryServiceMock()
+	suite.resolver.webhookRepository = webhookRepositoryServiceMock
+	input := model.NewWebhook{
+		URL: "https://chat.example.com/hooks", EventTypes: []model.AuditEventType{model.AuditEventTypeSignInFailed, model.AuditEventTypeUserDisabled},
+	}
+
+	createdWebhook, err := suite.mutationResolver.CreateWebhook(suite.graphqlRequestContext, input)
+	assert.Nil(suite.T(), err, "Should create the webhook without any errors")
+	assert.Equal(suite.T(), createdWebhook.Webhook.ID, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), createdWebhook.Webhook.EventTypes, input.EventTypes)
+	assert.NotEmpty(suite.T(), createdWebhook.Secret)
+	webhookRepositoryServiceMock.AssertCalled(suite.T(), "InsertNewWebhook", mock.MatchedBy(func(webhook *databaseModel.Webhook) bool {
+		return webhook.CreatedBy == mockutil.DefaultIdAsUint64 && string(webhook.Secret) == createdWebhook.Secret &&
+			webhook.EventTypes[0] == databaseModel.AuditEventSignInFailed && webhook.EventTypes[1] == databaseModel.AuditEventUserDisabled
+	}))
+}
+
+// CreateWebhook should reject invalid input and urls that aren't http or https
+func (suite *schemaResolverTestSuite) TestCreateWebhookValidation() {
+	webhookRepositoryServiceMock := mockutil.DefaultWebhookRepositoryServiceMock()
+	suite.resolver.webhookRepository = webhookRepositoryServiceMock
+	ctx := graphql.WithResponseContext(context.Background(), graphql.DefaultErrorPresenter, graphql.DefaultRecover)
+
+	createdWebhook, err := suite.mutationResolver.CreateWebhook(ctx, model.NewWebhook{URL: "https://chat.example.com/hooks"})
+	assert.Equal(suite.T(), err, gqlerror.Errorf("validation error/s on webhook input"), "Should return expected error")
+	assert.Nil(suite.T(), createdWebhook)
+
+	input := model.NewWebhook{URL: "ftp://files.example.com/hooks", EventTypes: []model.AuditEventType{model.AuditEventTypeSignIn}}
+	createdWebhook, err = suite.mutationResolver.CreateWebhook(ctx, input)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("webhook url must be an absolute http or https url"), "Should return expected error")
+	assert.Nil(suite.T(), createdWebhook)
+	webhookRepositoryServiceMock.AssertNotCalled(suite.T(), "InsertNewWebhook", mock.Anything)
+}
+
+// DeleteWebhook should delete the webhook and report webhooks that don't exist
+func (suite *schemaResolverTestSuite) TestDeleteWebhook() {
+	result, err := suite.mutationResolver.DeleteWebhook(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should delete the webhook without any errors")
+	assert.True(suite.T(), result)
+
+	webhookRepositoryServiceMock := new(mockutil.WebhookRepositoryServiceMock)
+	webhookRepositoryServiceMock.On("DeleteWebhookById", mock.Anything).Return(repository.ErrNotFound)
+	suite.resolver.webhookRepository = webhookRepositoryServiceMock
+
+	result, err = suite.mutationResolver.DeleteWebhook(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, &gqlerror.Error{Message: "webhook doesn't exist", Extensions: map[string]interface{}{"code": "NOT_FOUND"}}, "Should return expected error")
+	assert.False(suite.T(), result)
+}
+
+// RedeliverWebhookDeadLetter should schedule the dead letter's delivery again
+func (suite *schemaResolverTestSuite) TestRedeliverWebhookDeadLetter() {
+	webhookRepositoryServiceMock := mockutil.DefaultWebhookRepositoryServiceMock()
+	suite.resolver.webhookRepository = webhookRepositoryServiceMock
+
+	result, err := suite.mutationResolver.RedeliverWebhookDeadLetter(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Nil(suite.T(), err, "Should redeliver the dead letter without any errors")
+	assert.True(suite.T(), result)
+	webhookRepositoryServiceMock.AssertCalled(suite.T(), "RedeliverDeadLetterById", mockutil.DefaultIdAsUint64, mock.Anything)
+
+	webhookRepositoryServiceMock = new(mockutil.WebhookRepositoryServiceMock)
+	webhookRepositoryServiceMock.On("RedeliverDeadLetterById", mock.Anything, mock.Anything).Return(repository.ErrNotFound)
+	suite.resolver.webhookRepository = webhookRepositoryServiceMock
+
+	result, err = suite.mutationResolver.RedeliverWebhookDeadLetter(suite.graphqlRequestContext, mockutil.DefaultIdAsString)
+	assert.Equal(suite.T(), err, &gqlerror.Error{Message: "dead letter doesn't exist", Extensions: map[string]interface{}{"code": "NOT_FOUND"}}, "Should return expected error")
+	assert.False(suite.T(), result)
+}
+
+// Webhooks should fetch all webhooks without their secrets
+func (suite *schemaResolverTestSuite) TestWebhooks() {
+	webhooks, err := suite.queryResolver.Webhooks(suite.graphqlRequestContext)
+	assert.Nil(suite.T(), err, "Should fetch the webhooks without any errors")
+	assert.Equal(suite.T(), webhooks, []*model.Webhook{{
+		ID:         mockutil.DefaultIdAsString,
+		URL:        "http://localhost:9000/hooks",
+		EventTypes: []model.AuditEventType{model.AuditEventTypeSignIn},
+		CreatedAt:  "2021-01-01T00:00:00Z",
+	}})
+}
+
+// WebhookDeliveries should fetch a page of the webhook's delivery history
+func (suite *schemaResolverTestSuite) TestWebhookDeliveries() {
+	webhookRepositoryServiceMock := mockutil.DefaultWebhookRepositoryServiceMock()
+	suite.resolver.webhookRepository = webhookRepositoryServiceMock
+
+	deliveries, err := suite.queryResolver.WebhookDeliveries(suite.graphqlRequestContext, mockutil.DefaultIdAsString, 0, 1000)
+	assert.Nil(suite.T(), err, "Should fetch the deliveries without any errors")
+	assert.Equal(suite.T(), len(deliveries), 1)
+	assert.Equal(suite.T(), deliveries[0].Status, model.WebhookDeliveryStatusPending)
+	assert.Equal(suite.T(), deliveries[0].EventType, model.AuditEventTypeSignIn)
+	webhookRepositoryServiceMock.AssertCalled(
+		suite.T(), "FetchDeliveriesByWebhookId", mock.Anything, mockutil.DefaultIdAsUint64, 0, adminMaximumPageLimit,
+	)
+}
+
+// WebhookDeadLetters should return expected error when fetching the dead letters fails
+func (suite *schemaResolverTestSuite) TestWebhookDeadLettersWithFetchError() {
+	webhookRepositoryServiceMock := new(mockutil.WebhookRepositoryServiceMock)
+	webhookRepositoryServiceMock.On("FetchDeadLetters", mock.Anything, mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage), nil,
+	)
+	suite.resolver.webhookRepository = webhookRepositoryServiceMock
+
+	deadLetters, err := suite.queryResolver.WebhookDeadLetters(suite.graphqlRequestContext, 0, 10)
+	assert.Equal(suite.T(), err, gqlerror.Errorf("could not process webhook request"), "Should return expected error")
+	assert.Nil(suite.T(), deadLetters)
+}
+
+// Admin actions should be recorded as audit events so webhooks can be notified about them
+func (suite *schemaResolverTestSuite) TestAdminActionRecordsAuditEvent() {
+	auditRecorderMock := mockutil.DefaultAuditRecorderMock()
+	suite.resolver.auditRecorder = auditRecorderMock
+	suite.resolver.userRepository = userRepositoryMockWithRole(databaseModel.UserRoleUser, true)
+
+	_, err := suite.mutationResolver.DisableUser(suite.graphqlRequestContext, "2")
+	assert.Nil(suite.T(), err, "Should disable the user without any errors")
+	auditRecorderMock.AssertCalled(suite.T(), "Record", mock.MatchedBy(func(event *databaseModel.AuditEvent) bool {
+		return event.Type == databaseModel.AuditEventUserDisabled && *event.UserId == mockutil.DefaultIdAsUint64 && *event.Details == "user 2"
+	}))
+}
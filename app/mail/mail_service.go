@@ -0,0 +1,90 @@
+package mail
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"net/url"
+	"time"
+)
+
+type Recipient struct {
+	Email    string
+	Username string
+}
+
+type Mailer interface {
+	SendEmailVerification(recipient Recipient, token string) error
+	SendNewSignInAlert(recipient Recipient, userAgent string, ipAddress string) error
+	SendSecurityNotification(recipient Recipient, event string) error
+	VerificationTokenTtl() time.Duration
+	EmailVerificationRequired() bool
+	Close()
+}
+
+type mailService struct {
+	from                 string
+	verificationUrl      string
+	verificationTokenTtl time.Duration
+	requireVerifiedEmail bool
+	templates            *messageTemplates
+	queue                *deliveryQueue
+}
+
+func NewMailService(mailConfig *config.Mail, sender Sender) *mailService {
+	return &mailService{
+		from:                 mailConfig.From,
+		verificationUrl:      mailConfig.VerificationUrl,
+		verificationTokenTtl: time.Duration(mailConfig.VerificationTokenTtlInHours) * time.Hour,
+		requireVerifiedEmail: mailConfig.RequireVerifiedEmail,
+		templates:            parseMessageTemplates(),
+		queue: newDeliveryQueue(
+			sender, mailConfig.MaxDeliveryAttempts, time.Duration(mailConfig.RetryIntervalInSeconds)*time.Second,
+		),
+	}
+}
+
+func (service *mailService) SendEmailVerification(recipient Recipient, token string) error {
+	return service.send(recipient, "Verify your GoKeeper e-mail address", emailVerificationTemplate, map[string]interface{}{
+		"Username":         recipient.Username,
+		"VerificationLink": service.verificationUrl + url.QueryEscape(token),
+		"ExpiresInHours":   int(service.verificationTokenTtl.Hours()),
+	})
+}
+
+func (service *mailService) SendNewSignInAlert(recipient Recipient, userAgent string, ipAddress string) error {
+	return service.send(recipient, "New sign in to your GoKeeper account", newSignInTemplate, map[string]interface{}{
+		"Username":  recipient.Username,
+		"UserAgent": userAgent,
+		"IpAddress": ipAddress,
+		"Time":      time.Now().UTC().Format(time.RFC1123),
+	})
+}
+
+func (service *mailService) SendSecurityNotification(recipient Recipient, event string) error {
+	return service.send(recipient, "Security change on your GoKeeper account", securityNotificationTemplate, map[string]interface{}{
+		"Username": recipient.Username,
+		"Event":    event,
+		"Time":     time.Now().UTC().Format(time.RFC1123),
+	})
+}
+
+func (service *mailService) VerificationTokenTtl() time.Duration {
+	return service.verificationTokenTtl
+}
+
+func (service *mailService) EmailVerificationRequired() bool {
+	return service.requireVerifiedEmail
+}
+
+// Close waits for queued messages to be delivered
+func (service *mailService) Close() {
+	service.queue.close()
+}
+
+func (service *mailService) send(recipient Recipient, subject string, templateName string, data interface{}) error {
+	textBody, htmlBody, err := service.templates.render(templateName, data)
+	if err != nil {
+		return err
+	}
+
+	return service.queue.enqueue(&Message{From: service.from, To: recipient.Email, Subject: subject, TextBody: textBody, HtmlBody: htmlBody})
+}
@@ -0,0 +1,148 @@
+package mail
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/smtputil"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testRecipient = Recipient{Email: "username@email.com", Username: "username"}
+
+// SendEmailVerification should deliver a verification message with both a text and an HTML part over SMTP
+func TestSendEmailVerification(t *testing.T) {
+	smtpServer := smtputil.StartSmtpServer()
+	defer smtpServer.Close()
+	mailConfig := generateTestMailConfiguration(smtpServer)
+	mailService := NewMailService(mailConfig, NewSender(mailConfig))
+	defer mailService.Close()
+
+	err := mailService.SendEmailVerification(testRecipient, "verification-token")
+	assert.Nil(t, err, "Message should be queued without errors")
+
+	message := waitForMessage(t, smtpServer)
+	assert.Equal(t, message.From, "no-reply@gokeeper.local")
+	assert.Equal(t, message.Recipients, []string{testRecipient.Email})
+	assert.Contains(t, message.Data, "Subject: Verify your GoKeeper e-mail address")
+	assert.Contains(t, message.Data, "Content-Type: text/plain; charset=UTF-8")
+	assert.Contains(t, message.Data, "Content-Type: text/html; charset=UTF-8")
+	assert.Contains(t, message.Data, "http://localhost:3000/verify-email?token=verification-token")
+}
+
+// SendNewSignInAlert should deliver a message containing the new device details
+func TestSendNewSignInAlert(t *testing.T) {
+	smtpServer := smtputil.StartSmtpServer()
+	defer smtpServer.Close()
+	mailConfig := generateTestMailConfiguration(smtpServer)
+	mailService := NewMailService(mailConfig, NewSender(mailConfig))
+	defer mailService.Close()
+
+	err := mailService.SendNewSignInAlert(testRecipient, "Firefox", "10.0.0.1")
+	assert.Nil(t, err, "Message should be queued without errors")
+
+	message := waitForMessage(t, smtpServer)
+	assert.Contains(t, message.Data, "Device: Firefox")
+	assert.Contains(t, message.Data, "IP address: 10.0.0.1")
+}
+
+// HTML message parts should escape user controlled values
+func TestSendSecurityNotificationEscapesHtml(t *testing.T) {
+	smtpServer := smtputil.StartSmtpServer()
+	defer smtpServer.Close()
+	mailConfig := generateTestMailConfiguration(smtpServer)
+	mailService := NewMailService(mailConfig, NewSender(mailConfig))
+	defer mailService.Close()
+
+	err := mailService.SendSecurityNotification(Recipient{Email: testRecipient.Email, Username: "<b>user</b>"}, "webauthn credential registered")
+	assert.Nil(t, err, "Message should be queued without errors")
+
+	message := waitForMessage(t, smtpServer)
+	assert.Contains(t, message.Data, "Hi <b>user</b>,\r\n")
+	assert.Contains(t, message.Data, "Hi &lt;b&gt;user&lt;/b&gt;,")
+	assert.Contains(t, message.Data, "webauthn credential registered")
+}
+
+// Queued messages should be redelivered after transient SMTP failures
+func TestMessageDeliveryRetry(t *testing.T) {
+	smtpServer := smtputil.StartSmtpServer()
+	defer smtpServer.Close()
+	smtpServer.RejectDeliveries(2)
+	mailConfig := generateTestMailConfiguration(smtpServer)
+	mailService := NewMailService(mailConfig, NewSender(mailConfig))
+	defer mailService.Close()
+
+	err := mailService.SendSecurityNotification(testRecipient, "master password changed")
+	assert.Nil(t, err, "Message should be queued without errors")
+
+	waitForMessage(t, smtpServer)
+	assert.Equal(t, len(smtpServer.Messages()), 1, "Message should be delivered exactly once")
+}
+
+// Queued messages should be dropped after the maximum number of delivery attempts
+func TestMessageDeliveryWithExhaustedAttempts(t *testing.T) {
+	smtpServer := smtputil.StartSmtpServer()
+	defer smtpServer.Close()
+	smtpServer.RejectDeliveries(3)
+	mailConfig := generateTestMailConfiguration(smtpServer)
+	mailService := NewMailService(mailConfig, NewSender(mailConfig))
+
+	err := mailService.SendSecurityNotification(testRecipient, "master password changed")
+	assert.Nil(t, err, "Message should be queued without errors")
+	assert.Eventually(t, func() bool { return smtpServer.PendingRejections() == 0 }, 5*time.Second, 10*time.Millisecond)
+	mailService.Close()
+
+	assert.Equal(t, len(smtpServer.Messages()), 0, "Message should not be delivered")
+}
+
+// Messages should not be accepted after the mail service is closed
+func TestSendAfterClose(t *testing.T) {
+	mailConfig := &config.Mail{Sink: LogSink, MaxDeliveryAttempts: 1}
+	mailService := NewMailService(mailConfig, NewSender(mailConfig))
+	mailService.Close()
+
+	err := mailService.SendSecurityNotification(testRecipient, "master password changed")
+	assert.Equal(t, err, ErrQueueClosed, "Should return queue closed error")
+}
+
+// The file sink should write every message as an .eml file
+func TestFileSink(t *testing.T) {
+	directory := t.TempDir()
+	mailConfig := &config.Mail{Sink: FileSink, FileSinkDirectory: directory, From: "no-reply@gokeeper.local", MaxDeliveryAttempts: 1}
+	mailService := NewMailService(mailConfig, NewSender(mailConfig))
+
+	err := mailService.SendEmailVerification(testRecipient, "verification-token")
+	assert.Nil(t, err, "Message should be queued without errors")
+	mailService.Close()
+
+	files, _ := filepath.Glob(filepath.Join(directory, "*.eml"))
+	assert.Equal(t, len(files), 1, "Should write exactly one message file")
+	content, _ := os.ReadFile(files[0])
+	assert.True(t, strings.Contains(string(content), "verification-token"), "Message file should contain the message")
+}
+
+func generateTestMailConfiguration(smtpServer *smtputil.SmtpServer) *config.Mail {
+	return &config.Mail{
+		Sink:                        SmtpSink,
+		Host:                        smtpServer.Host(),
+		Port:                        smtpServer.Port(),
+		From:                        "GoKeeper <no-reply@gokeeper.local>",
+		MaxDeliveryAttempts:         3,
+		RetryIntervalInSeconds:      0,
+		VerificationUrl:             "http://localhost:3000/verify-email?token=",
+		VerificationTokenTtlInHours: 24,
+	}
+}
+
+func waitForMessage(t *testing.T, smtpServer *smtputil.SmtpServer) smtputil.ReceivedMessage {
+	select {
+	case message := <-smtpServer.Received:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("Test SMTP server did not receive any message")
+		return smtputil.ReceivedMessage{}
+	}
+}
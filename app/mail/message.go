@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"strings"
+	textTemplate "text/template"
+	"time"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+const (
+	emailVerificationTemplate    = "email-verification"
+	newSignInTemplate            = "new-sign-in"
+	securityNotificationTemplate = "security-notification"
+)
+
+type Message struct {
+	From     string
+	To       string
+	Subject  string
+	TextBody string
+	HtmlBody string
+}
+
+type messageTemplates struct {
+	html *htmlTemplate.Template
+	text *textTemplate.Template
+}
+
+func parseMessageTemplates() *messageTemplates {
+	return &messageTemplates{
+		html: htmlTemplate.Must(htmlTemplate.ParseFS(templateFiles, "templates/*.html")),
+		text: textTemplate.Must(textTemplate.ParseFS(templateFiles, "templates/*.txt")),
+	}
+}
+
+func (templates *messageTemplates) render(templateName string, data interface{}) (string, string, error) {
+	textBody := &bytes.Buffer{}
+	if err := templates.text.ExecuteTemplate(textBody, templateName+".txt", data); err != nil {
+		return "", "", err
+	}
+
+	htmlBody := &bytes.Buffer{}
+	if err := templates.html.ExecuteTemplate(htmlBody, templateName+".html", data); err != nil {
+		return "", "", err
+	}
+
+	return textBody.String(), htmlBody.String(), nil
+}
+
+// Bytes encodes the message as a multipart/alternative MIME message with a plain text and an HTML part
+func (message *Message) Bytes() ([]byte, error) {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", message.TextBody},
+		{"text/html; charset=UTF-8", message.HtmlBody},
+	} {
+		partWriter, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"8bit"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err = partWriter.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	headers := &strings.Builder{}
+	fmt.Fprintf(headers, "From: %s\r\n", message.From)
+	fmt.Fprintf(headers, "To: %s\r\n", message.To)
+	fmt.Fprintf(headers, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", message.Subject))
+	fmt.Fprintf(headers, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(headers, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(headers, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", writer.Boundary())
+
+	return append([]byte(headers.String()), body.Bytes()...), nil
+}
@@ -0,0 +1,106 @@
+package mail
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+)
+
+const queueCapacity = 256
+
+var (
+	ErrQueueFull   = errors.New("mail queue is full")
+	ErrQueueClosed = errors.New("mail queue is closed")
+)
+
+// deliveryQueue delivers messages on a single background worker, retrying failed deliveries with an exponential backoff
+type deliveryQueue struct {
+	sender              Sender
+	messages            chan *Message
+	maxDeliveryAttempts int
+	retryInterval       time.Duration
+	closed              chan struct{}
+	isClosed            bool
+	mutex               sync.RWMutex
+	waitGroup           sync.WaitGroup
+}
+
+func newDeliveryQueue(sender Sender, maxDeliveryAttempts int, retryInterval time.Duration) *deliveryQueue {
+	if maxDeliveryAttempts < 1 {
+		maxDeliveryAttempts = 1
+	}
+
+	queue := &deliveryQueue{
+		sender:              sender,
+		messages:            make(chan *Message, queueCapacity),
+		maxDeliveryAttempts: maxDeliveryAttempts,
+		retryInterval:       retryInterval,
+		closed:              make(chan struct{}),
+	}
+
+	queue.waitGroup.Add(1)
+	go queue.work()
+
+	return queue
+}
+
+func (queue *deliveryQueue) enqueue(message *Message) error {
+	queue.mutex.RLock()
+	defer queue.mutex.RUnlock()
+	if queue.isClosed {
+		return ErrQueueClosed
+	}
+
+	select {
+	case queue.messages <- message:
+		return nil
+	default:
+		return ErrQueueFull
+	}
+}
+
+// close stops accepting new messages and waits for queued messages to be processed,
+// pending retries are abandoned
+func (queue *deliveryQueue) close() {
+	queue.mutex.Lock()
+	if !queue.isClosed {
+		queue.isClosed = true
+		close(queue.closed)
+		close(queue.messages)
+	}
+	queue.mutex.Unlock()
+
+	queue.waitGroup.Wait()
+}
+
+func (queue *deliveryQueue) work() {
+	defer queue.waitGroup.Done()
+	for message := range queue.messages {
+		queue.deliver(message)
+	}
+}
+
+func (queue *deliveryQueue) deliver(message *Message) {
+	retryInterval := queue.retryInterval
+	for attempt := 1; ; attempt++ {
+		err := queue.sender.Send(message)
+		if err == nil {
+			return
+		}
+
+		log.Printf("Mail delivery to %s failed on attempt %d/%d: %s", message.To, attempt, queue.maxDeliveryAttempts, err)
+		if attempt == queue.maxDeliveryAttempts {
+			log.Printf("Giving up on mail delivery to %s with subject '%s'", message.To, message.Subject)
+			return
+		}
+
+		select {
+		case <-queue.closed:
+			log.Printf("Mail queue closed, abandoning mail delivery to %s", message.To)
+			return
+		case <-time.After(retryInterval):
+			retryInterval *= 2
+		}
+	}
+}
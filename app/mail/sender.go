@@ -0,0 +1,89 @@
+package mail
+
+import (
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"log"
+	netMail "net/mail"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	SmtpSink = "smtp"
+	FileSink = "file"
+	LogSink  = "log"
+)
+
+// Variable meant for mocking
+var sendSmtpMail = smtp.SendMail
+
+type Sender interface {
+	Send(message *Message) error
+}
+
+// NewSender creates the sender for the configured sink, defaulting to the log sink so development setups never send real e-mails
+func NewSender(mailConfig *config.Mail) Sender {
+	switch mailConfig.Sink {
+	case SmtpSink:
+		return &smtpSender{address: mailConfig.Host + ":" + mailConfig.Port, host: mailConfig.Host, username: mailConfig.Username, password: mailConfig.Password}
+	case FileSink:
+		return &fileSender{directory: mailConfig.FileSinkDirectory}
+	default:
+		return &logSender{}
+	}
+}
+
+type smtpSender struct {
+	address  string
+	host     string
+	username string
+	password string
+}
+
+func (sender *smtpSender) Send(message *Message) error {
+	from, err := netMail.ParseAddress(message.From)
+	if err != nil {
+		return err
+	}
+
+	messageBytes, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if sender.username != "" {
+		auth = smtp.PlainAuth("", sender.username, sender.password, sender.host)
+	}
+
+	return sendSmtpMail(sender.address, auth, from.Address, []string{message.To}, messageBytes)
+}
+
+// fileSender writes every message as an .eml file, so they can be opened with any mail client during development
+type fileSender struct {
+	directory string
+}
+
+func (sender *fileSender) Send(message *Message) error {
+	messageBytes, err := message.Bytes()
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(sender.directory, 0700); err != nil {
+		return err
+	}
+
+	fileName := fmt.Sprintf("%d-%s.eml", time.Now().UnixNano(), message.To)
+	return os.WriteFile(filepath.Join(sender.directory, fileName), messageBytes, 0600)
+}
+
+type logSender struct{}
+
+func (sender *logSender) Send(message *Message) error {
+	log.Printf("Mail to %s with subject '%s':\n%s", message.To, message.Subject, message.TextBody)
+	return nil
+}
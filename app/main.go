@@ -2,10 +2,18 @@ package main
 
 import (
 	"context"
+	"flag"
+	"github.com/KristijanFaust/gokeeper/app/audit"
 	"github.com/KristijanFaust/gokeeper/app/config"
 	"github.com/KristijanFaust/gokeeper/app/database"
+	"github.com/KristijanFaust/gokeeper/app/database/memory"
+	"github.com/KristijanFaust/gokeeper/app/database/migration"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/demo"
+	"github.com/KristijanFaust/gokeeper/app/security"
 	"github.com/KristijanFaust/gokeeper/app/server"
 	"github.com/KristijanFaust/gokeeper/app/utility/stdout"
+	"github.com/upper/db/v4"
 	"log"
 	"os"
 	"os/signal"
@@ -15,14 +23,34 @@ import (
 )
 
 func main() {
+	applicationConfig, arguments, err := config.Load(os.Args[1:], os.Stderr)
+	if err == flag.ErrHelp {
+		os.Exit(0)
+	} else if err != nil {
+		os.Exit(2)
+	}
+	if len(arguments) > 0 && arguments[0] == "config" {
+		if err = config.Print(applicationConfig, os.Stdout); err != nil {
+			log.Panicf("Could not print the configuration\nError: %s", err)
+		}
+		os.Exit(0)
+	}
+
 	stdout.PrintApplicationBanner()
-	applicationConfig := config.LoadConfiguration("./config.yml")
-	session := database.InitializeDatabaseConnection(applicationConfig.Datasource)
-	defer database.CloseDatabaseConnection(session)
+	if len(arguments) > 0 && arguments[0] == "migrate" {
+		os.Exit(migration.RunCommand(arguments[1:], applicationConfig.Datasource, os.Stdout))
+	}
+	session, replicas, repositories := initializeStorage(applicationConfig)
+	if len(arguments) > 0 && arguments[0] == "audit" {
+		exitCode := audit.RunCommand(arguments[1:], repositories.AuditEvent, os.Stdout)
+		closeStorage(session, replicas)
+		os.Exit(exitCode)
+	}
+	defer closeStorage(session, replicas)
 
 	serverDoneWaitGroup := &sync.WaitGroup{}
 	serverDoneWaitGroup.Add(1)
-	server := server.Run(applicationConfig, serverDoneWaitGroup, session)
+	server := server.Run(applicationConfig, serverDoneWaitGroup, repositories, session)
 
 	waitForQuitSignal()
 
@@ -38,6 +66,42 @@ func main() {
 	log.Println("Application terminated successfully")
 }
 
+// initializeStorage connects to the configured database and its read replicas and applies the pending migrations
+// if configured to, or seeds an in-memory store when running the demo profile, in which case there is no database session
+func initializeStorage(applicationConfig *config.Config) (*db.Session, *database.ReplicaSet, *repository.Repositories) {
+	if applicationConfig.Profile != nil && applicationConfig.Profile.Demo {
+		log.Println("Running the demo profile on an in-memory store, all data will be lost on shutdown")
+		repositories := memory.NewRepositories(memory.NewStore())
+		if err := demo.Seed(repositories, &security.PasswordHashService{}, &security.PasswordCryptoService{}); err != nil {
+			log.Panicf("Could not seed the demo data\nError: %s", err)
+		}
+		return nil, nil, repositories
+	}
+
+	session := database.InitializeDatabaseConnection(applicationConfig.Datasource)
+	if applicationConfig.Datasource.MigrateOnStartup {
+		if err := migration.Up(applicationConfig.Datasource); err != nil {
+			log.Panicf("Could not migrate the database\nError: %s", err)
+		}
+	}
+
+	replicas := database.ConnectReplicas(applicationConfig.Datasource)
+	if replicas != nil {
+		replicas.Start()
+	}
+	return session, replicas, repository.NewRepositoriesWithReplicas(session, replicas)
+}
+
+func closeStorage(session *db.Session, replicas *database.ReplicaSet) {
+	if replicas != nil {
+		replicas.Stop()
+		replicas.Close()
+	}
+	if session != nil {
+		database.CloseDatabaseConnection(session)
+	}
+}
+
 func waitForQuitSignal() {
 	quitSignalChannel := make(chan os.Signal, 1)
 	signal.Notify(quitSignalChannel, syscall.SIGHUP, syscall.SIGINT, syscall.SIGQUIT)
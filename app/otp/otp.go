@@ -0,0 +1,202 @@
+package otp
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TypeTotp = "totp"
+	TypeHotp = "hotp"
+
+	AlgorithmSha1   = "SHA1"
+	AlgorithmSha256 = "SHA256"
+	AlgorithmSha512 = "SHA512"
+
+	// EncoderSteam marks keys producing Steam Guard codes, the encoder parameter is how KeePassXC stores them
+	EncoderSteam = "steam"
+
+	defaultDigits = 6
+	minDigits     = 6
+	maxDigits     = 8
+	defaultPeriod = 30
+	steamDigits   = 5
+	steamAlphabet = "23456789BCDFGHJKMNPQRTVWXY"
+)
+
+var ErrInvalidUri = errors.New("invalid otpauth uri")
+
+var hashFunctions = map[string]func() hash.Hash{
+	AlgorithmSha1:   sha1.New,
+	AlgorithmSha256: sha256.New,
+	AlgorithmSha512: sha512.New,
+}
+
+var secretEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// Key is the seed of an authenticator together with the parameters its codes are generated with
+type Key struct {
+	Type      string
+	Label     string
+	Issuer    string
+	Secret    []byte
+	Algorithm string
+	Digits    int
+	Period    int
+	Counter   uint64
+	Encoder   string
+}
+
+// Parse reads an otpauth URI as described by the Google Authenticator key URI format. Besides totp and hotp keys
+// it reads Steam Guard keys, either as otpauth://steam URIs or as totp URIs with the steam encoder.
+func Parse(uri string) (*Key, error) {
+	parsedUri, err := url.Parse(strings.TrimSpace(uri))
+	if err != nil || parsedUri.Scheme != "otpauth" {
+		return nil, ErrInvalidUri
+	}
+
+	query := parsedUri.Query()
+	key := &Key{
+		Type:      strings.ToLower(parsedUri.Host),
+		Label:     strings.TrimPrefix(parsedUri.Path, "/"),
+		Issuer:    query.Get("issuer"),
+		Algorithm: AlgorithmSha1,
+		Digits:    defaultDigits,
+		Period:    defaultPeriod,
+		Encoder:   strings.ToLower(query.Get("encoder")),
+	}
+	if key.Type == EncoderSteam {
+		key.Type, key.Encoder = TypeTotp, EncoderSteam
+	}
+	if key.Type != TypeTotp && key.Type != TypeHotp || key.Encoder != "" && key.Encoder != EncoderSteam {
+		return nil, ErrInvalidUri
+	}
+
+	key.Secret, err = decodeSecret(query.Get("secret"))
+	if err != nil {
+		return nil, err
+	}
+
+	if algorithm := query.Get("algorithm"); algorithm != "" {
+		key.Algorithm = strings.ToUpper(algorithm)
+		if _, ok := hashFunctions[key.Algorithm]; !ok {
+			return nil, ErrInvalidUri
+		}
+	}
+	if key.Encoder == EncoderSteam {
+		key.Digits = steamDigits
+	} else if key.Digits, err = intParameter(query, "digits", defaultDigits); err != nil || key.Digits < minDigits || key.Digits > maxDigits {
+		return nil, ErrInvalidUri
+	}
+
+	switch key.Type {
+	case TypeTotp:
+		if key.Period, err = intParameter(query, "period", defaultPeriod); err != nil || key.Period < 1 {
+			return nil, ErrInvalidUri
+		}
+	case TypeHotp:
+		key.Period = 0
+		if counter := query.Get("counter"); counter != "" {
+			if key.Counter, err = strconv.ParseUint(counter, 10, 64); err != nil {
+				return nil, ErrInvalidUri
+			}
+		}
+	}
+
+	return key, nil
+}
+
+// String returns the key as a canonical otpauth URI, which carries everything needed to generate its codes
+func (key *Key) String() string {
+	query := url.Values{}
+	query.Set("secret", secretEncoding.EncodeToString(key.Secret))
+	if key.Issuer != "" {
+		query.Set("issuer", key.Issuer)
+	}
+	query.Set("algorithm", key.Algorithm)
+	query.Set("digits", strconv.Itoa(key.Digits))
+	if key.Type == TypeHotp {
+		query.Set("counter", strconv.FormatUint(key.Counter, 10))
+	} else {
+		query.Set("period", strconv.Itoa(key.Period))
+	}
+	if key.Encoder != "" {
+		query.Set("encoder", key.Encoder)
+	}
+
+	uri := url.URL{Scheme: "otpauth", Host: key.Type, Path: "/" + key.Label, RawQuery: query.Encode()}
+	return uri.String()
+}
+
+// IsTimeBased tells if the codes of the key change with time rather than with a counter
+func (key *Key) IsTimeBased() bool {
+	return key.Type == TypeTotp
+}
+
+// Code returns the code of the key at the given time, counter based keys return the code of their current counter
+func (key *Key) Code(at time.Time) string {
+	counter := key.Counter
+	if key.IsTimeBased() {
+		counter = uint64(at.Unix()) / uint64(key.Period)
+	}
+
+	mac := hmac.New(hashFunctions[key.Algorithm], key.Secret)
+	binary.Write(mac, binary.BigEndian, counter)
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation as described in RFC 4226
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	if key.Encoder == EncoderSteam {
+		code := make([]byte, steamDigits)
+		for index := range code {
+			code[index] = steamAlphabet[value%uint32(len(steamAlphabet))]
+			value /= uint32(len(steamAlphabet))
+		}
+		return string(code)
+	}
+
+	modulo := uint32(1)
+	for digit := 0; digit < key.Digits; digit++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", key.Digits, value%modulo)
+}
+
+// SecondsRemaining returns how many seconds the code at the given time stays valid, 0 for counter based keys
+func (key *Key) SecondsRemaining(at time.Time) int {
+	if !key.IsTimeBased() {
+		return 0
+	}
+	return key.Period - int(at.Unix()%int64(key.Period))
+}
+
+// decodeSecret reads base32 secrets regardless of case, padding and the spaces they are often grouped with
+func decodeSecret(secret string) ([]byte, error) {
+	secret = strings.ToUpper(strings.NewReplacer(" ", "", "-", "", "=", "").Replace(secret))
+	decoded, err := secretEncoding.DecodeString(secret)
+	if err != nil || len(decoded) == 0 {
+		return nil, ErrInvalidUri
+	}
+	return decoded, nil
+}
+
+func intParameter(query url.Values, name string, defaultValue int) (int, error) {
+	value := query.Get(name)
+	if value == "" {
+		return defaultValue, nil
+	}
+	return strconv.Atoi(value)
+}
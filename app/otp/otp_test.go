@@ -0,0 +1,116 @@
+package otp
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const (
+	sha1Secret   = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+	sha256Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZA"
+	sha512Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQGEZDGNA"
+)
+
+// Code should generate the time based codes of the RFC 6238 test vectors
+func TestTotpCode(t *testing.T) {
+	secrets := map[string]string{AlgorithmSha1: sha1Secret, AlgorithmSha256: sha256Secret, AlgorithmSha512: sha512Secret}
+	expectedCodes := map[int64]map[string]string{
+		59:         {AlgorithmSha1: "94287082", AlgorithmSha256: "46119246", AlgorithmSha512: "90693936"},
+		1111111109: {AlgorithmSha1: "07081804", AlgorithmSha256: "68084774", AlgorithmSha512: "25091201"},
+		1234567890: {AlgorithmSha1: "89005924", AlgorithmSha256: "91819424", AlgorithmSha512: "93441116"},
+	}
+
+	for unixTime, codes := range expectedCodes {
+		for algorithm, expectedCode := range codes {
+			key, err := Parse("otpauth://totp/Example:alice?digits=8&algorithm=" + algorithm + "&secret=" + secrets[algorithm])
+			assert.Nil(t, err, "Should parse the %s key", algorithm)
+			assert.Equal(t, key.Code(time.Unix(unixTime, 0)), expectedCode, "Should generate the %s code at %d", algorithm, unixTime)
+		}
+	}
+}
+
+// Code should generate the counter based codes of the RFC 4226 test vectors
+func TestHotpCode(t *testing.T) {
+	for counter, expectedCode := range []string{"755224", "287082", "359152", "969429"} {
+		key, err := Parse("otpauth://hotp/Example:alice?secret=" + sha1Secret + "&counter=" + strconv.Itoa(counter))
+		assert.Nil(t, err, "Should parse the key")
+		assert.Equal(t, key.Code(time.Now()), expectedCode, "Should generate the code of counter %d", counter)
+		assert.False(t, key.IsTimeBased())
+		assert.Equal(t, key.SecondsRemaining(time.Now()), 0, "Counter based codes shouldn't expire")
+	}
+}
+
+// Code should generate Steam Guard codes for steam URIs and totp URIs with the steam encoder
+func TestSteamCode(t *testing.T) {
+	for _, uri := range []string{
+		"otpauth://steam/Steam:alice?secret=" + sha1Secret,
+		"otpauth://totp/Steam:alice?secret=" + sha1Secret + "&encoder=steam&digits=5",
+	} {
+		key, err := Parse(uri)
+		assert.Nil(t, err, "Should parse %s", uri)
+		assert.Equal(t, key.Code(time.Unix(1234567890, 0)), "VHHQY")
+		assert.Equal(t, key.Digits, 5)
+	}
+}
+
+// SecondsRemaining should count down to the next period of the key
+func TestSecondsRemaining(t *testing.T) {
+	key, err := Parse("otpauth://totp/alice?secret=" + sha1Secret + "&period=60")
+	assert.Nil(t, err)
+	assert.Equal(t, key.SecondsRemaining(time.Unix(120, 0)), 60)
+	assert.Equal(t, key.SecondsRemaining(time.Unix(179, 0)), 1)
+	assert.NotEqual(t, key.Code(time.Unix(120, 0)), key.Code(time.Unix(180, 0)), "Codes should change with the period")
+	assert.Equal(t, key.Code(time.Unix(120, 0)), key.Code(time.Unix(179, 0)), "Codes should hold for the whole period")
+}
+
+// Parse should apply the defaults and read secrets the way they are usually written down
+func TestParseDefaults(t *testing.T) {
+	key, err := Parse("otpauth://TOTP/ACME%20Co:alice@example.com?secret=gezd gnbv gy3t qojq gezd gnbv gy3t qojq====&issuer=ACME%20Co")
+	assert.Nil(t, err, "Should parse the key")
+	assert.Equal(t, key.Type, TypeTotp)
+	assert.Equal(t, key.Label, "ACME Co:alice@example.com")
+	assert.Equal(t, key.Issuer, "ACME Co")
+	assert.Equal(t, string(key.Secret), "12345678901234567890")
+	assert.Equal(t, key.Algorithm, AlgorithmSha1)
+	assert.Equal(t, key.Digits, 6)
+	assert.Equal(t, key.Period, 30)
+}
+
+// String should produce a canonical URI that parses back to the same key
+func TestStringRoundTrip(t *testing.T) {
+	for _, uri := range []string{
+		"otpauth://totp/ACME%20Co:alice?secret=" + sha256Secret + "&issuer=ACME%20Co&algorithm=sha256&digits=7&period=45",
+		"otpauth://hotp/alice?secret=" + sha1Secret + "&counter=42&algorithm=SHA512&digits=8",
+		"otpauth://steam/Steam:alice?secret=" + sha1Secret + "&issuer=Steam",
+	} {
+		key, err := Parse(uri)
+		assert.Nil(t, err, "Should parse %s", uri)
+
+		reparsedKey, err := Parse(key.String())
+		assert.Nil(t, err, "Should parse the canonical URI of %s", uri)
+		assert.Equal(t, reparsedKey, key, "Canonical URI of %s should carry the whole key", uri)
+		assert.Equal(t, reparsedKey.String(), key.String(), "Canonical URI should be stable")
+	}
+}
+
+// Parse should reject URIs that don't describe a usable key
+func TestParseInvalidUri(t *testing.T) {
+	for _, uri := range []string{
+		"",
+		"https://totp/alice?secret=" + sha1Secret,
+		"otpauth://motp/alice?secret=" + sha1Secret,
+		"otpauth://totp/alice",
+		"otpauth://totp/alice?secret=not-base32!",
+		"otpauth://totp/alice?secret=" + sha1Secret + "&algorithm=MD5",
+		"otpauth://totp/alice?secret=" + sha1Secret + "&digits=5",
+		"otpauth://totp/alice?secret=" + sha1Secret + "&digits=9",
+		"otpauth://totp/alice?secret=" + sha1Secret + "&period=0",
+		"otpauth://totp/alice?secret=" + sha1Secret + "&encoder=unknown",
+		"otpauth://hotp/alice?secret=" + sha1Secret + "&counter=-1",
+	} {
+		_, err := Parse(uri)
+		assert.Equal(t, err, ErrInvalidUri, "Should reject %q", uri)
+	}
+}
@@ -0,0 +1,66 @@
+package security
+
+import (
+	"errors"
+)
+
+// Beware that changing these constants will break compatibility with already wrapped data keys
+const (
+	dataKeyWrapSalt      = "Zq7LmW2xRb9TfK4s"
+	dataKeyNonceByteSize = 12
+)
+
+var ErrInvalidDataKey = errors.New("invalid data key")
+
+// GenerateDataKey generates the key of a single encrypted blob, it should only ever be persisted wrapped with the
+// owner's vault key, so changing the vault key only means wrapping the data key again instead of re-encrypting the blob
+func GenerateDataKey() ([]byte, error) {
+	key := make([]byte, keyByteSize)
+	if _, err := readRandomBytes(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func WrapDataKey(dataKey []byte, vaultKey []byte) ([]byte, error) {
+	gcm, err := setUpAes(vaultKey[:keyByteSize])
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, dataKeyNonceByteSize)
+	if _, err = readRandomBytes(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, dataKey, []byte(dataKeyWrapSalt)), nil
+}
+
+func UnwrapDataKey(wrappedDataKey []byte, vaultKey []byte) ([]byte, error) {
+	gcm, err := setUpAes(vaultKey[:keyByteSize])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrappedDataKey) < dataKeyNonceByteSize {
+		return nil, ErrInvalidDataKey
+	}
+	dataKey, err := gcm.Open(
+		nil, wrappedDataKey[:dataKeyNonceByteSize], wrappedDataKey[dataKeyNonceByteSize:], []byte(dataKeyWrapSalt),
+	)
+	if err != nil {
+		return nil, ErrInvalidDataKey
+	}
+
+	return dataKey, nil
+}
+
+func RewrapDataKey(wrappedDataKey []byte, oldVaultKey []byte, newVaultKey []byte) ([]byte, error) {
+	dataKey, err := UnwrapDataKey(wrappedDataKey, oldVaultKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return WrapDataKey(dataKey, newVaultKey)
+}
@@ -0,0 +1,49 @@
+package security
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// A wrapped data key should be unwrapped with the vault key it was wrapped with
+func TestWrapAndUnwrapDataKey(t *testing.T) {
+	dataKey, err := GenerateDataKey()
+	assert.Nil(t, err, "Should generate a data key without errors")
+	assert.Len(t, dataKey, keyByteSize, "Should generate a key usable with AES-256")
+
+	wrappedDataKey, err := WrapDataKey(dataKey, []byte(validEncryptionKey))
+	assert.Nil(t, err, "Should wrap the data key without errors")
+	assert.NotContains(t, string(wrappedDataKey), string(dataKey), "Data key should be wrapped")
+
+	unwrappedDataKey, err := UnwrapDataKey(wrappedDataKey, []byte(validEncryptionKey))
+	assert.Nil(t, err, "Should unwrap the data key without errors")
+	assert.Equal(t, unwrappedDataKey, dataKey)
+}
+
+// UnwrapDataKey should return an error when the data key is unwrapped with a wrong vault key
+func TestUnwrapDataKeyWithWrongVaultKey(t *testing.T) {
+	dataKey, _ := GenerateDataKey()
+	wrappedDataKey, _ := WrapDataKey(dataKey, []byte(validEncryptionKey))
+
+	unwrappedDataKey, err := UnwrapDataKey(wrappedDataKey, []byte(anotherValidEncryptionKey))
+	assert.Equal(t, err, ErrInvalidDataKey, "Should return invalid data key error")
+	assert.Nil(t, unwrappedDataKey)
+
+	unwrappedDataKey, err = UnwrapDataKey([]byte("short"), []byte(validEncryptionKey))
+	assert.Equal(t, err, ErrInvalidDataKey, "Should return invalid data key error for truncated keys")
+	assert.Nil(t, unwrappedDataKey)
+}
+
+// RewrapDataKey should wrap the same data key with the new vault key only
+func TestRewrapDataKey(t *testing.T) {
+	dataKey, _ := GenerateDataKey()
+	wrappedDataKey, _ := WrapDataKey(dataKey, []byte(validEncryptionKey))
+
+	rewrappedDataKey, err := RewrapDataKey(wrappedDataKey, []byte(validEncryptionKey), []byte(anotherValidEncryptionKey))
+	assert.Nil(t, err, "Should rewrap the data key without errors")
+	unwrappedDataKey, _ := UnwrapDataKey(rewrappedDataKey, []byte(anotherValidEncryptionKey))
+	assert.Equal(t, unwrappedDataKey, dataKey)
+
+	_, err = UnwrapDataKey(rewrappedDataKey, []byte(validEncryptionKey))
+	assert.Equal(t, err, ErrInvalidDataKey, "Old vault key should not unwrap the data key anymore")
+}
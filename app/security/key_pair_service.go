@@ -0,0 +1,115 @@
+package security
+
+import (
+	"crypto/rand"
+	"errors"
+	"golang.org/x/crypto/nacl/box"
+)
+
+const (
+	keyPairKeySize          = 32
+	privateKeyWrapSalt      = "h3XrQ8mTzV5cNp1W"
+	privateKeyNonceByteSize = 12
+)
+
+var ErrInvalidKeyPair = errors.New("invalid key pair")
+
+// Variable meant for mocking
+var generateBoxKeyPair = box.GenerateKey
+
+// KeyPairCryptor manages per user X25519 key pairs, private keys are always stored wrapped with the owner's vault key
+type KeyPairCryptor interface {
+	GenerateKeyPair(vaultKey []byte) (publicKey []byte, wrappedPrivateKey []byte, err error)
+	RewrapPrivateKey(wrappedPrivateKey []byte, oldVaultKey []byte, newVaultKey []byte) ([]byte, error)
+	SealForPublicKey(message []byte, publicKey []byte) ([]byte, error)
+	OpenWithPrivateKey(sealedMessage []byte, publicKey []byte, wrappedPrivateKey []byte, vaultKey []byte) ([]byte, error)
+}
+
+type KeyPairService struct{}
+
+func (service *KeyPairService) GenerateKeyPair(vaultKey []byte) ([]byte, []byte, error) {
+	publicKey, privateKey, err := generateBoxKeyPair(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	wrappedPrivateKey, err := wrapPrivateKey(privateKey[:], vaultKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return publicKey[:], wrappedPrivateKey, nil
+}
+
+func (service *KeyPairService) RewrapPrivateKey(wrappedPrivateKey []byte, oldVaultKey []byte, newVaultKey []byte) ([]byte, error) {
+	privateKey, err := unwrapPrivateKey(wrappedPrivateKey, oldVaultKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return wrapPrivateKey(privateKey, newVaultKey)
+}
+
+// SealForPublicKey encrypts a message that only the owner of the public key can decrypt
+func (service *KeyPairService) SealForPublicKey(message []byte, publicKey []byte) ([]byte, error) {
+	if len(publicKey) != keyPairKeySize {
+		return nil, ErrInvalidKeyPair
+	}
+
+	var recipientKey [keyPairKeySize]byte
+	copy(recipientKey[:], publicKey)
+	return box.SealAnonymous(nil, message, &recipientKey, rand.Reader)
+}
+
+func (service *KeyPairService) OpenWithPrivateKey(sealedMessage []byte, publicKey []byte, wrappedPrivateKey []byte, vaultKey []byte) ([]byte, error) {
+	privateKey, err := unwrapPrivateKey(wrappedPrivateKey, vaultKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(publicKey) != keyPairKeySize || len(privateKey) != keyPairKeySize {
+		return nil, ErrInvalidKeyPair
+	}
+
+	var recipientPublicKey, recipientPrivateKey [keyPairKeySize]byte
+	copy(recipientPublicKey[:], publicKey)
+	copy(recipientPrivateKey[:], privateKey)
+	message, ok := box.OpenAnonymous(nil, sealedMessage, &recipientPublicKey, &recipientPrivateKey)
+	if !ok {
+		return nil, ErrInvalidKeyPair
+	}
+
+	return message, nil
+}
+
+func wrapPrivateKey(privateKey []byte, vaultKey []byte) ([]byte, error) {
+	gcm, err := setUpAes(vaultKey[:keyByteSize])
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, privateKeyNonceByteSize)
+	if _, err = readRandomBytes(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, privateKey, []byte(privateKeyWrapSalt)), nil
+}
+
+func unwrapPrivateKey(wrappedPrivateKey []byte, vaultKey []byte) ([]byte, error) {
+	gcm, err := setUpAes(vaultKey[:keyByteSize])
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrappedPrivateKey) < privateKeyNonceByteSize {
+		return nil, ErrInvalidKeyPair
+	}
+	privateKey, err := gcm.Open(
+		nil, wrappedPrivateKey[:privateKeyNonceByteSize], wrappedPrivateKey[privateKeyNonceByteSize:], []byte(privateKeyWrapSalt),
+	)
+	if err != nil {
+		return nil, ErrInvalidKeyPair
+	}
+
+	return privateKey, nil
+}
@@ -0,0 +1,83 @@
+package security
+
+import (
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/nacl/box"
+	"io"
+	"testing"
+)
+
+const anotherValidEncryptionKey = "anotherKeyThatNeedsToBeAtLeast32BytesLong"
+
+// A sealed message should only be opened with the recipient's private key
+func TestSealAndOpenWithKeyPair(t *testing.T) {
+	keyPairService := KeyPairService{}
+	publicKey, wrappedPrivateKey, err := keyPairService.GenerateKeyPair([]byte(validEncryptionKey))
+	assert.Nil(t, err, "Should generate a key pair without errors")
+	assert.Len(t, publicKey, 32)
+
+	sealedMessage, err := keyPairService.SealForPublicKey([]byte("message"), publicKey)
+	assert.Nil(t, err, "Should seal a message without errors")
+
+	message, err := keyPairService.OpenWithPrivateKey(sealedMessage, publicKey, wrappedPrivateKey, []byte(validEncryptionKey))
+	assert.Nil(t, err, "Should open a message without errors")
+	assert.Equal(t, message, []byte("message"))
+}
+
+// OpenWithPrivateKey should return an error when the private key is unwrapped with a wrong vault key
+func TestOpenWithPrivateKeyWithWrongVaultKey(t *testing.T) {
+	keyPairService := KeyPairService{}
+	publicKey, wrappedPrivateKey, _ := keyPairService.GenerateKeyPair([]byte(validEncryptionKey))
+	sealedMessage, _ := keyPairService.SealForPublicKey([]byte("message"), publicKey)
+
+	message, err := keyPairService.OpenWithPrivateKey(sealedMessage, publicKey, wrappedPrivateKey, []byte(anotherValidEncryptionKey))
+	assert.Equal(t, err, ErrInvalidKeyPair, "Should return invalid key pair error")
+	assert.Nil(t, message, "Should not return a message")
+}
+
+// OpenWithPrivateKey should return an error for messages sealed for another key pair
+func TestOpenWithPrivateKeyWithForeignMessage(t *testing.T) {
+	keyPairService := KeyPairService{}
+	publicKey, wrappedPrivateKey, _ := keyPairService.GenerateKeyPair([]byte(validEncryptionKey))
+	anotherPublicKey, _, _ := keyPairService.GenerateKeyPair([]byte(validEncryptionKey))
+	sealedMessage, _ := keyPairService.SealForPublicKey([]byte("message"), anotherPublicKey)
+
+	message, err := keyPairService.OpenWithPrivateKey(sealedMessage, publicKey, wrappedPrivateKey, []byte(validEncryptionKey))
+	assert.Equal(t, err, ErrInvalidKeyPair, "Should return invalid key pair error")
+	assert.Nil(t, message, "Should not return a message")
+}
+
+// RewrapPrivateKey should wrap the private key with a new vault key
+func TestRewrapPrivateKey(t *testing.T) {
+	keyPairService := KeyPairService{}
+	publicKey, wrappedPrivateKey, _ := keyPairService.GenerateKeyPair([]byte(validEncryptionKey))
+	sealedMessage, _ := keyPairService.SealForPublicKey([]byte("message"), publicKey)
+
+	rewrappedPrivateKey, err := keyPairService.RewrapPrivateKey(wrappedPrivateKey, []byte(validEncryptionKey), []byte(anotherValidEncryptionKey))
+	assert.Nil(t, err, "Should rewrap the private key without errors")
+
+	message, err := keyPairService.OpenWithPrivateKey(sealedMessage, publicKey, rewrappedPrivateKey, []byte(anotherValidEncryptionKey))
+	assert.Nil(t, err, "Should open a message with the rewrapped private key")
+	assert.Equal(t, message, []byte("message"))
+}
+
+// GenerateKeyPair should return an error when key generation fails
+func TestGenerateKeyPairWithGenerationError(t *testing.T) {
+	generateBoxKeyPair = func(random io.Reader) (*[32]byte, *[32]byte, error) { return nil, nil, errors.New(mockedErrorMessage) }
+	defer func() { generateBoxKeyPair = box.GenerateKey }()
+
+	keyPairService := KeyPairService{}
+	publicKey, wrappedPrivateKey, err := keyPairService.GenerateKeyPair([]byte(validEncryptionKey))
+	assert.Equal(t, err, errors.New(mockedErrorMessage), "Should return root error")
+	assert.Nil(t, publicKey, "Should not return a public key")
+	assert.Nil(t, wrappedPrivateKey, "Should not return a private key")
+}
+
+// SealForPublicKey should return an error on malformed public keys
+func TestSealForPublicKeyWithInvalidPublicKey(t *testing.T) {
+	keyPairService := KeyPairService{}
+	sealedMessage, err := keyPairService.SealForPublicKey([]byte("message"), []byte("invalid"))
+	assert.Equal(t, err, ErrInvalidKeyPair, "Should return invalid key pair error")
+	assert.Nil(t, sealedMessage, "Should not return a sealed message")
+}
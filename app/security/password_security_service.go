@@ -3,9 +3,13 @@ package security
 type PasswordSecurity interface {
 	Argon2PasswordHasher
 	AesPasswordCryptor
+	RecoveryKeyWrapper
+	KeyPairCryptor
 }
 
 type PasswordSecurityService struct {
 	Argon2PasswordHasher
 	AesPasswordCryptor
+	RecoveryKeyWrapper
+	KeyPairCryptor
 }
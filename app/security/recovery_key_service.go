@@ -0,0 +1,104 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/base32"
+	"errors"
+	"strings"
+)
+
+const (
+	recoveryKeyByteSize   = 20
+	recoveryKeyGroupSize  = 4
+	recoveryKeySeparator  = "-"
+	recoveryKeyWrapSalt   = "2bWvYq7HcLk9TnRe"
+	recoveryKeyNonceBytes = 12
+)
+
+var ErrInvalidRecoveryKey = errors.New("invalid recovery key")
+
+// Printable alphabet without padding, recovery keys are meant to be written down or printed
+var recoveryKeyEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+type RecoveryKeyWrapper interface {
+	GenerateRecoveryKey() (string, error)
+	WrapWithRecoveryKey(vaultKey []byte, recoveryKey string) ([]byte, error)
+	UnwrapWithRecoveryKey(wrappedVaultKey []byte, recoveryKey string) ([]byte, error)
+}
+
+type RecoveryKeyService struct{}
+
+// GenerateRecoveryKey generates a random key formatted in dash separated groups, e.g. ABCD-EFGH-...
+func (service *RecoveryKeyService) GenerateRecoveryKey() (string, error) {
+	key := make([]byte, recoveryKeyByteSize)
+	if _, err := readRandomBytes(key); err != nil {
+		return "", err
+	}
+
+	encodedKey := recoveryKeyEncoding.EncodeToString(key)
+	var groups []string
+	for start := 0; start < len(encodedKey); start += recoveryKeyGroupSize {
+		end := start + recoveryKeyGroupSize
+		if end > len(encodedKey) {
+			end = len(encodedKey)
+		}
+		groups = append(groups, encodedKey[start:end])
+	}
+
+	return strings.Join(groups, recoveryKeySeparator), nil
+}
+
+func (service *RecoveryKeyService) WrapWithRecoveryKey(vaultKey []byte, recoveryKey string) ([]byte, error) {
+	wrappingKey, err := deriveRecoveryWrappingKey(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := setUpAes(wrappingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, recoveryKeyNonceBytes)
+	if _, err = readRandomBytes(nonce); err != nil {
+		return nil, err
+	}
+
+	return gcm.Seal(nonce, nonce, vaultKey, []byte(recoveryKeyWrapSalt)), nil
+}
+
+func (service *RecoveryKeyService) UnwrapWithRecoveryKey(wrappedVaultKey []byte, recoveryKey string) ([]byte, error) {
+	wrappingKey, err := deriveRecoveryWrappingKey(recoveryKey)
+	if err != nil {
+		return nil, err
+	}
+
+	gcm, err := setUpAes(wrappingKey)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(wrappedVaultKey) < recoveryKeyNonceBytes {
+		return nil, ErrInvalidRecoveryKey
+	}
+	vaultKey, err := gcm.Open(
+		nil, wrappedVaultKey[:recoveryKeyNonceBytes], wrappedVaultKey[recoveryKeyNonceBytes:], []byte(recoveryKeyWrapSalt),
+	)
+	if err != nil {
+		return nil, ErrInvalidRecoveryKey
+	}
+
+	return vaultKey, nil
+}
+
+// deriveRecoveryWrappingKey accepts recovery keys regardless of letter case, whitespace and separators
+func deriveRecoveryWrappingKey(recoveryKey string) ([]byte, error) {
+	normalizedKey := strings.ToUpper(strings.Join(strings.Fields(strings.ReplaceAll(recoveryKey, recoveryKeySeparator, " ")), ""))
+	key, err := recoveryKeyEncoding.DecodeString(normalizedKey)
+	if err != nil || len(key) != recoveryKeyByteSize {
+		return nil, ErrInvalidRecoveryKey
+	}
+
+	wrappingKey := sha256.Sum256(key)
+	return wrappingKey[:], nil
+}
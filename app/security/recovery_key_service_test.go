@@ -0,0 +1,64 @@
+package security
+
+import (
+	"crypto/rand"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// GenerateRecoveryKey should generate a printable recovery key
+func TestGenerateRecoveryKey(t *testing.T) {
+	recoveryKeyService := RecoveryKeyService{}
+	recoveryKey, err := recoveryKeyService.GenerateRecoveryKey()
+	assert.Nil(t, err, "Should not return any errors")
+	assert.Regexp(t, regexp.MustCompile(`^([A-Z2-7]{4}-){7}[A-Z2-7]{4}$`), recoveryKey)
+}
+
+// GenerateRecoveryKey should return an error if random bytes can't be read
+func TestGenerateRecoveryKeyWithRandomReadError(t *testing.T) {
+	readRandomBytes = func(b []byte) (int, error) { return 0, errors.New(mockedErrorMessage) }
+	defer func() { readRandomBytes = rand.Read }()
+
+	recoveryKeyService := RecoveryKeyService{}
+	recoveryKey, err := recoveryKeyService.GenerateRecoveryKey()
+	assert.Equal(t, err, errors.New(mockedErrorMessage), "Should return root error")
+	assert.Empty(t, recoveryKey, "Should not return a recovery key")
+}
+
+// UnwrapWithRecoveryKey should unwrap a vault key wrapped with the same recovery key
+func TestWrapAndUnwrapWithRecoveryKey(t *testing.T) {
+	recoveryKeyService := RecoveryKeyService{}
+	recoveryKey, _ := recoveryKeyService.GenerateRecoveryKey()
+
+	wrappedVaultKey, err := recoveryKeyService.WrapWithRecoveryKey([]byte(validEncryptionKey), recoveryKey)
+	assert.Nil(t, err, "Should not return any errors")
+	assert.NotEqual(t, wrappedVaultKey, []byte(validEncryptionKey), "Vault key should be encrypted")
+
+	vaultKey, err := recoveryKeyService.UnwrapWithRecoveryKey(wrappedVaultKey, strings.ToLower(strings.ReplaceAll(recoveryKey, "-", " ")))
+	assert.Nil(t, err, "Should accept recovery keys regardless of letter case and separators")
+	assert.Equal(t, vaultKey, []byte(validEncryptionKey))
+}
+
+// UnwrapWithRecoveryKey should return an error when using a wrong recovery key
+func TestUnwrapWithWrongRecoveryKey(t *testing.T) {
+	recoveryKeyService := RecoveryKeyService{}
+	recoveryKey, _ := recoveryKeyService.GenerateRecoveryKey()
+	wrongRecoveryKey, _ := recoveryKeyService.GenerateRecoveryKey()
+	wrappedVaultKey, _ := recoveryKeyService.WrapWithRecoveryKey([]byte(validEncryptionKey), recoveryKey)
+
+	vaultKey, err := recoveryKeyService.UnwrapWithRecoveryKey(wrappedVaultKey, wrongRecoveryKey)
+	assert.Equal(t, err, ErrInvalidRecoveryKey, "Should return invalid recovery key error")
+	assert.Nil(t, vaultKey, "Should not return a vault key")
+}
+
+// UnwrapWithRecoveryKey should return an error on malformed recovery keys
+func TestUnwrapWithMalformedRecoveryKey(t *testing.T) {
+	recoveryKeyService := RecoveryKeyService{}
+
+	vaultKey, err := recoveryKeyService.UnwrapWithRecoveryKey([]byte("wrappedVaultKey"), "not-a-recovery-key")
+	assert.Equal(t, err, ErrInvalidRecoveryKey, "Should return invalid recovery key error")
+	assert.Nil(t, vaultKey, "Should not return a vault key")
+}
@@ -0,0 +1,169 @@
+package security
+
+import (
+	"crypto/cipher"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"golang.org/x/crypto/hkdf"
+	"io"
+)
+
+// Beware that changing these constants will break compatibility with already encrypted streams
+const (
+	streamVersion    = byte(1)
+	streamSaltSize   = 16
+	streamHeaderSize = 1 + streamSaltSize
+	streamChunkSize  = 64 * 1024
+	streamTagSize    = 16
+	streamKeyInfo    = "gokeeper stream"
+)
+
+var ErrCorruptedStream = errors.New("encrypted stream is corrupted or was tampered with")
+
+// EncryptedStreamSize returns the size of the encrypted stream of a plaintext with the given size
+func EncryptedStreamSize(plaintextSize int64) int64 {
+	chunks := plaintextSize/streamChunkSize + 1
+	return streamHeaderSize + plaintextSize + chunks*streamTagSize
+}
+
+// NewEncryptingReader encrypts the plaintext in chunks with AES-GCM, so streams of any size can be encrypted without
+// buffering them whole. Every stream gets its own key derived from the given key and a random salt, chunks are
+// numbered and the last one is flagged so chunks can't be reordered, dropped or truncated without being noticed.
+// The additional data isn't part of the stream but has to match when decrypting it.
+func NewEncryptingReader(plaintext io.Reader, key []byte, additionalData []byte) (io.Reader, error) {
+	salt := make([]byte, streamSaltSize)
+	if _, err := readRandomBytes(salt); err != nil {
+		return nil, err
+	}
+
+	stream, err := newChunkedStream(key, salt, additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &encryptingReader{
+		chunkedStream: stream,
+		plaintext:     plaintext,
+		pending:       append([]byte{streamVersion}, salt...),
+		chunk:         make([]byte, streamChunkSize),
+	}, nil
+}
+
+// NewDecryptingReader decrypts a stream encrypted by NewEncryptingReader, reads fail with ErrCorruptedStream as soon
+// as a chunk doesn't authenticate, so the plaintext shouldn't be trusted before the stream was read to its end
+func NewDecryptingReader(ciphertext io.Reader, key []byte, additionalData []byte) (io.Reader, error) {
+	header := make([]byte, streamHeaderSize)
+	if _, err := io.ReadFull(ciphertext, header); err != nil || header[0] != streamVersion {
+		return nil, ErrCorruptedStream
+	}
+
+	stream, err := newChunkedStream(key, header[1:], additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	return &decryptingReader{
+		chunkedStream: stream,
+		ciphertext:    ciphertext,
+		chunk:         make([]byte, streamChunkSize+streamTagSize),
+	}, nil
+}
+
+type chunkedStream struct {
+	gcm            cipher.AEAD
+	additionalData []byte
+	counter        uint64
+	finished       bool
+}
+
+func newChunkedStream(key []byte, salt []byte, additionalData []byte) (*chunkedStream, error) {
+	streamKey := make([]byte, keyByteSize)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, key, salt, []byte(streamKeyInfo)), streamKey); err != nil {
+		return nil, err
+	}
+
+	gcm, err := setUpAes(streamKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chunkedStream{gcm: gcm, additionalData: additionalData}, nil
+}
+
+// nextNonce numbers the chunks and flags the last one in its final byte
+func (stream *chunkedStream) nextNonce(isFinal bool) []byte {
+	nonce := make([]byte, stream.gcm.NonceSize())
+	binary.BigEndian.PutUint64(nonce, stream.counter)
+	if isFinal {
+		nonce[len(nonce)-1] = 1
+	}
+	stream.counter++
+
+	return nonce
+}
+
+// encryptingReader emits full chunks until the plaintext runs out, the final chunk is always shorter than a full one,
+// even if that leaves it empty, so the decryption can tell it apart
+type encryptingReader struct {
+	*chunkedStream
+	plaintext io.Reader
+	pending   []byte
+	chunk     []byte
+}
+
+func (reader *encryptingReader) Read(buffer []byte) (int, error) {
+	for len(reader.pending) == 0 {
+		if reader.finished {
+			return 0, io.EOF
+		}
+
+		size, err := io.ReadFull(reader.plaintext, reader.chunk)
+		isFinal := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !isFinal {
+			return 0, err
+		}
+
+		reader.pending = reader.gcm.Seal(nil, reader.nextNonce(isFinal), reader.chunk[:size], reader.additionalData)
+		reader.finished = isFinal
+	}
+
+	read := copy(buffer, reader.pending)
+	reader.pending = reader.pending[read:]
+	return read, nil
+}
+
+type decryptingReader struct {
+	*chunkedStream
+	ciphertext io.Reader
+	pending    []byte
+	chunk      []byte
+}
+
+func (reader *decryptingReader) Read(buffer []byte) (int, error) {
+	for len(reader.pending) == 0 {
+		if reader.finished {
+			return 0, io.EOF
+		}
+
+		size, err := io.ReadFull(reader.ciphertext, reader.chunk)
+		isFinal := err == io.ErrUnexpectedEOF
+		if err == io.EOF {
+			return 0, ErrCorruptedStream
+		}
+		if err != nil && !isFinal {
+			return 0, err
+		}
+
+		plaintext, err := reader.gcm.Open(nil, reader.nextNonce(isFinal), reader.chunk[:size], reader.additionalData)
+		if err != nil {
+			return 0, ErrCorruptedStream
+		}
+		reader.pending = plaintext
+		reader.finished = isFinal
+	}
+
+	read := copy(buffer, reader.pending)
+	reader.pending = reader.pending[read:]
+	return read, nil
+}
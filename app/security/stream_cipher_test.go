@@ -0,0 +1,82 @@
+package security
+
+import (
+	"bytes"
+	"crypto/rand"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+// NewEncryptingReader should produce streams that decrypt to the original plaintext for any plaintext size
+func TestStreamCipherRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 1, streamChunkSize - 1, streamChunkSize, streamChunkSize + 1, 3*streamChunkSize + 42} {
+		plaintext := make([]byte, size)
+		rand.Read(plaintext)
+
+		ciphertext := encryptStream(t, plaintext, []byte("blob"))
+		assert.Equal(t, int64(len(ciphertext)), EncryptedStreamSize(int64(size)), "Encrypted size should be predictable")
+
+		decrypted, err := decryptStream(ciphertext, []byte("blob"))
+		assert.Nil(t, err, "Should decrypt a stream of %d bytes", size)
+		assert.True(t, bytes.Equal(decrypted, plaintext), "Should decrypt a stream of %d bytes to its plaintext", size)
+	}
+}
+
+// NewEncryptingReader should derive a new key for every stream, so equal plaintexts never produce equal streams
+func TestStreamCipherUsesRandomSalt(t *testing.T) {
+	plaintext := []byte("recovery codes")
+	assert.NotEqual(t, encryptStream(t, plaintext, nil), encryptStream(t, plaintext, nil))
+}
+
+// NewDecryptingReader should reject streams that were truncated, reordered, tampered with or bound to other data
+func TestStreamCipherWithTamperedStream(t *testing.T) {
+	plaintext := make([]byte, 2*streamChunkSize+100)
+	rand.Read(plaintext)
+	ciphertext := encryptStream(t, plaintext, []byte("blob"))
+	fullChunkSize := streamChunkSize + streamTagSize
+	firstChunk := ciphertext[streamHeaderSize : streamHeaderSize+fullChunkSize]
+	secondChunk := ciphertext[streamHeaderSize+fullChunkSize : streamHeaderSize+2*fullChunkSize]
+
+	flippedBit := append([]byte{}, ciphertext...)
+	flippedBit[streamHeaderSize+10] ^= 1
+	reordered := append(append(append([]byte{}, ciphertext[:streamHeaderSize]...), secondChunk...), firstChunk...)
+	reordered = append(reordered, ciphertext[streamHeaderSize+2*fullChunkSize:]...)
+
+	tamperedStreams := map[string][]byte{
+		"flipped bit":         flippedBit,
+		"reordered chunks":    reordered,
+		"dropped final chunk": ciphertext[:streamHeaderSize+2*fullChunkSize],
+		"truncated chunk":     ciphertext[:len(ciphertext)-1],
+		"missing header":      ciphertext[:streamHeaderSize-1],
+	}
+	for name, tamperedStream := range tamperedStreams {
+		_, err := decryptStream(tamperedStream, []byte("blob"))
+		assert.Equal(t, err, ErrCorruptedStream, "Should reject a stream with a %s", name)
+	}
+
+	_, err := decryptStream(ciphertext, []byte("other blob"))
+	assert.Equal(t, err, ErrCorruptedStream, "Should reject a stream bound to other additional data")
+}
+
+func encryptStream(t *testing.T, plaintext []byte, additionalData []byte) []byte {
+	reader, err := NewEncryptingReader(bytes.NewReader(plaintext), []byte(validEncryptionKey), additionalData)
+	assert.Nil(t, err)
+	ciphertext, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+
+	return ciphertext
+}
+
+func decryptStream(ciphertext []byte, additionalData []byte) ([]byte, error) {
+	reader, err := NewDecryptingReader(bytes.NewReader(ciphertext), []byte(validEncryptionKey), additionalData)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reads in odd sizes so chunks get split across reads
+	decrypted := &bytes.Buffer{}
+	_, err = io.CopyBuffer(decrypted, struct{ io.Reader }{reader}, make([]byte, 1000))
+	return decrypted.Bytes(), err
+}
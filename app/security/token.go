@@ -0,0 +1,37 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+)
+
+const tokenByteSize = 32
+
+// Variable meant for mocking
+var readRandomBytes = rand.Read
+
+// GenerateRandomToken generates an URL safe token, only its hash should ever be persisted
+func GenerateRandomToken() (string, error) {
+	token := make([]byte, tokenByteSize)
+	if _, err := readRandomBytes(token); err != nil {
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// GenerateOrganizationKey generates a key for a shared vault, it should only ever be persisted sealed for each member
+func GenerateOrganizationKey() ([]byte, error) {
+	key := make([]byte, keyByteSize)
+	if _, err := readRandomBytes(key); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
+
+func HashToken(token string) []byte {
+	hash := sha256.Sum256([]byte(token))
+	return hash[:]
+}
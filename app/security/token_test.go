@@ -0,0 +1,55 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// GenerateRandomToken should generate a unique URL safe token
+func TestGenerateRandomToken(t *testing.T) {
+	token, err := GenerateRandomToken()
+	assert.Nil(t, err, "Should generate a token without errors")
+	assert.Len(t, token, 43, "Should encode 32 random bytes")
+
+	anotherToken, _ := GenerateRandomToken()
+	assert.NotEqual(t, token, anotherToken, "Generated tokens should be unique")
+}
+
+// GenerateRandomToken should return an error if random bytes can't be read
+func TestGenerateRandomTokenWithRandomReadError(t *testing.T) {
+	readRandomBytes = func(b []byte) (int, error) { return 0, errors.New(mockedErrorMessage) }
+	defer func() { readRandomBytes = rand.Read }()
+
+	token, err := GenerateRandomToken()
+	assert.NotNil(t, err, "Should return an error")
+	assert.Empty(t, token, "Should not return a token")
+}
+
+// GenerateOrganizationKey should generate a unique AES-256 key
+func TestGenerateOrganizationKey(t *testing.T) {
+	key, err := GenerateOrganizationKey()
+	assert.Nil(t, err, "Should generate a key without errors")
+	assert.Len(t, key, keyByteSize, "Should generate a key usable with AES-256")
+
+	anotherKey, _ := GenerateOrganizationKey()
+	assert.NotEqual(t, key, anotherKey, "Generated keys should be unique")
+}
+
+// GenerateOrganizationKey should return an error if random bytes can't be read
+func TestGenerateOrganizationKeyWithRandomReadError(t *testing.T) {
+	readRandomBytes = func(b []byte) (int, error) { return 0, errors.New(mockedErrorMessage) }
+	defer func() { readRandomBytes = rand.Read }()
+
+	key, err := GenerateOrganizationKey()
+	assert.NotNil(t, err, "Should return an error")
+	assert.Nil(t, key, "Should not return a key")
+}
+
+// HashToken should return the SHA-256 hash of a token
+func TestHashToken(t *testing.T) {
+	expectedHash := sha256.Sum256([]byte("token"))
+	assert.Equal(t, HashToken("token"), expectedHash[:])
+}
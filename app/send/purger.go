@@ -0,0 +1,46 @@
+package send
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Purger periodically deletes expired and used up sends
+type Purger struct {
+	manager   Manager
+	interval  time.Duration
+	stop      chan struct{}
+	stopOnce  sync.Once
+	waitGroup sync.WaitGroup
+}
+
+func NewPurger(manager Manager, interval time.Duration) *Purger {
+	return &Purger{manager: manager, interval: interval, stop: make(chan struct{})}
+}
+
+func (purger *Purger) Start() {
+	purger.waitGroup.Add(1)
+	go func() {
+		defer purger.waitGroup.Done()
+		ticker := time.NewTicker(purger.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-purger.stop:
+				return
+			case <-ticker.C:
+				if purgedSends := purger.manager.PurgeUnavailableSends(); purgedSends > 0 {
+					log.Printf("Purged %d expired or used up send/s", purgedSends)
+				}
+			}
+		}
+	}()
+}
+
+// Stop waits for a running purge to finish
+func (purger *Purger) Stop() {
+	purger.stopOnce.Do(func() { close(purger.stop) })
+	purger.waitGroup.Wait()
+}
@@ -0,0 +1,54 @@
+package send
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Purger should periodically purge unavailable sends until stopped
+func TestPurger(t *testing.T) {
+	manager := &countingManager{}
+	purger := NewPurger(manager, 10*time.Millisecond)
+
+	purger.Start()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&manager.purges) >= 2
+	}, time.Second, 5*time.Millisecond, "Purger should purge sends periodically")
+	purger.Stop()
+
+	purgesAfterStop := atomic.LoadInt32(&manager.purges)
+	time.Sleep(30 * time.Millisecond)
+	assert.Equal(t, atomic.LoadInt32(&manager.purges), purgesAfterStop, "Purger should not run after it's stopped")
+}
+
+// Stop should be safe to call multiple times
+func TestPurgerStopTwice(t *testing.T) {
+	purger := NewPurger(mockutil.DefaultSendManagerMock(), time.Hour)
+	purger.Start()
+
+	assert.NotPanics(t, func() {
+		purger.Stop()
+		purger.Stop()
+	}, "Stopping the purger twice should not panic")
+}
+
+type countingManager struct {
+	purges int32
+}
+
+func (manager *countingManager) CreateSend(*model.Send, string, string) (string, error) {
+	return "", nil
+}
+
+func (manager *countingManager) OpenSend(string, string, string) (string, error) {
+	return "", nil
+}
+
+func (manager *countingManager) PurgeUnavailableSends() int {
+	atomic.AddInt32(&manager.purges, 1)
+	return 1
+}
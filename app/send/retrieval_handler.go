@@ -0,0 +1,56 @@
+package send
+
+import (
+	"encoding/json"
+	"github.com/go-chi/chi/v5"
+	"log"
+	"net/http"
+)
+
+const maxRetrievalRequestBytes = 4096
+
+type retrievalRequest struct {
+	Key        string `json:"key"`
+	Passphrase string `json:"passphrase"`
+}
+
+type retrievalResponse struct {
+	Secret string `json:"secret,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// RetrievalHandler serves sends without authentication, the client posts the key from the link's URL fragment
+// and every successful retrieval burns a view of the send
+func RetrievalHandler(manager Manager) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Cache-Control", "no-store")
+
+		retrieval := retrievalRequest{}
+		err := json.NewDecoder(http.MaxBytesReader(writer, request.Body, maxRetrievalRequestBytes)).Decode(&retrieval)
+		if err != nil || retrieval.Key == "" {
+			writeRetrievalResponse(writer, http.StatusBadRequest, retrievalResponse{Error: "send key is required"})
+			return
+		}
+
+		secret, err := manager.OpenSend(chi.URLParam(request, "token"), retrieval.Key, retrieval.Passphrase)
+		switch err {
+		case nil:
+			writeRetrievalResponse(writer, http.StatusOK, retrievalResponse{Secret: secret})
+		case ErrSendUnavailable:
+			writeRetrievalResponse(writer, http.StatusNotFound, retrievalResponse{Error: err.Error()})
+		case ErrInvalidSendKey:
+			writeRetrievalResponse(writer, http.StatusForbidden, retrievalResponse{Error: err.Error()})
+		default:
+			log.Printf("Error while opening send: %s", err)
+			writeRetrievalResponse(writer, http.StatusInternalServerError, retrievalResponse{Error: "could not open send"})
+		}
+	}
+}
+
+func writeRetrievalResponse(writer http.ResponseWriter, status int, response retrievalResponse) {
+	writer.Header().Set("Content-Type", "application/json")
+	writer.WriteHeader(status)
+	if err := json.NewEncoder(writer).Encode(response); err != nil {
+		log.Printf("Error while writing send retrieval response: %s", err)
+	}
+}
@@ -0,0 +1,62 @@
+package send
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// RetrievalHandler should return the secret of the send identified by the token in the path
+func TestRetrievalHandler(t *testing.T) {
+	managerMock := mockutil.DefaultSendManagerMock()
+
+	response := retrieveSend(managerMock, `{"key": "key", "passphrase": "passphrase"}`)
+	assert.Equal(t, response.Code, http.StatusOK)
+	assert.JSONEq(t, response.Body.String(), `{"secret": "`+mockutil.MockedDecryptedPassword+`"}`)
+	assert.Equal(t, response.Header().Get("Cache-Control"), "no-store", "Secrets should never be cached")
+	managerMock.AssertCalled(t, "OpenSend", "token", "key", "passphrase")
+}
+
+// RetrievalHandler should reject requests without a key
+func TestRetrievalHandlerWithoutKey(t *testing.T) {
+	managerMock := mockutil.DefaultSendManagerMock()
+
+	for _, body := range []string{`{"passphrase": "passphrase"}`, "invalid"} {
+		response := retrieveSend(managerMock, body)
+		assert.Equal(t, response.Code, http.StatusBadRequest)
+	}
+	managerMock.AssertNotCalled(t, "OpenSend", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// RetrievalHandler should map errors while opening the send to response statuses
+func TestRetrievalHandlerWithOpenErrors(t *testing.T) {
+	statuses := map[error]int{
+		ErrSendUnavailable: http.StatusNotFound,
+		ErrInvalidSendKey:  http.StatusForbidden,
+		errors.New(mockutil.MockedGenericErrorMessage): http.StatusInternalServerError,
+	}
+
+	for openError, status := range statuses {
+		managerMock := new(mockutil.SendManagerMock)
+		managerMock.On("OpenSend", mock.Anything, mock.Anything, mock.Anything).Return("", openError)
+
+		response := retrieveSend(managerMock, `{"key": "key"}`)
+		assert.Equal(t, response.Code, status)
+		assert.NotContains(t, response.Body.String(), "secret", "Should not return a secret")
+	}
+}
+
+func retrieveSend(manager Manager, body string) *httptest.ResponseRecorder {
+	router := chi.NewRouter()
+	router.Post("/send/{token}", RetrievalHandler(manager))
+
+	response := httptest.NewRecorder()
+	router.ServeHTTP(response, httptest.NewRequest(http.MethodPost, "/send/token", strings.NewReader(body)))
+	return response
+}
@@ -0,0 +1,119 @@
+package send
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"log"
+	"time"
+)
+
+var (
+	ErrSendUnavailable = errors.New("send doesn't exist, expired or was already burned")
+	ErrInvalidSendKey  = errors.New("invalid send key or passphrase")
+)
+
+// Variables meant for mocking
+var (
+	generateRandomToken = security.GenerateRandomToken
+	now                 = func() time.Time { return time.Now().UTC() }
+)
+
+type Manager interface {
+	CreateSend(send *model.Send, secret string, passphrase string) (string, error)
+	OpenSend(token string, key string, passphrase string) (string, error)
+	PurgeUnavailableSends() int
+}
+
+type sendService struct {
+	url                  string
+	sendRepository       repository.SendRepository
+	argon2PasswordHasher security.Argon2PasswordHasher
+	aesPasswordCryptor   security.AesPasswordCryptor
+}
+
+func NewSendService(
+	sendConfig *config.Send,
+	sendRepository repository.SendRepository,
+	argon2PasswordHasher security.Argon2PasswordHasher,
+	aesPasswordCryptor security.AesPasswordCryptor,
+) *sendService {
+	return &sendService{
+		url:                  sendConfig.Url,
+		sendRepository:       sendRepository,
+		argon2PasswordHasher: argon2PasswordHasher,
+		aesPasswordCryptor:   aesPasswordCryptor,
+	}
+}
+
+// CreateSend encrypts the secret with a random key and stores the send, the returned link carries the key in its
+// URL fragment so browsers never send it along with the request for the link
+func (service *sendService) CreateSend(send *model.Send, secret string, passphrase string) (string, error) {
+	token, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+	key, err := generateRandomToken()
+	if err != nil {
+		return "", err
+	}
+
+	send.EncryptedSecret, err = service.aesPasswordCryptor.EncryptWithAes(secret, service.encryptionKey(key, passphrase))
+	if err != nil {
+		return "", err
+	}
+	send.TokenHash = security.HashToken(token)
+	send.HasPassphrase = passphrase != ""
+	send.CreatedAt = now()
+
+	insertResult, err := service.sendRepository.InsertNewSend(send)
+	if err != nil {
+		return "", err
+	}
+	send.Id = uint64(insertResult.ID().(int64))
+
+	return service.url + token + "#" + key, nil
+}
+
+// OpenSend decrypts the secret and counts the view, a wrong key or passphrase doesn't use up a view
+func (service *sendService) OpenSend(token string, key string, passphrase string) (string, error) {
+	send := model.Send{}
+	err := service.sendRepository.FetchAvailableSendByTokenHash(&send, security.HashToken(token), now())
+	if err != nil {
+		if err == repository.ErrNotFound {
+			return "", ErrSendUnavailable
+		}
+		return "", err
+	}
+
+	secret, err := service.aesPasswordCryptor.DecryptWithAes(send.EncryptedSecret, service.encryptionKey(key, passphrase))
+	if err != nil {
+		return "", ErrInvalidSendKey
+	}
+
+	if err = service.sendRepository.ConsumeSendViewById(send.Id, now()); err != nil {
+		if err == repository.ErrNotFound {
+			return "", ErrSendUnavailable
+		}
+		return "", err
+	}
+
+	return secret, nil
+}
+
+func (service *sendService) PurgeUnavailableSends() int {
+	purgedSends, err := service.sendRepository.DeleteUnavailableSends(now())
+	if err != nil {
+		log.Printf("Error while purging unavailable sends: %s", err)
+		return 0
+	}
+
+	return int(purgedSends)
+}
+
+// encryptionKey derives the key from the link key and the optional passphrase, so neither is enough on its own
+func (service *sendService) encryptionKey(key string, passphrase string) []byte {
+	return service.argon2PasswordHasher.HashWithArgon2id(key + passphrase)
+}
@@ -0,0 +1,142 @@
+package send
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"strings"
+	"testing"
+)
+
+const testSendUrl = "http://localhost:3000/send/"
+
+// CreateSend should store the encrypted secret and return a link that carries the key only in its fragment
+func TestCreateSend(t *testing.T) {
+	sendRepositoryMock := mockutil.DefaultSendRepositoryServiceMock()
+	service := newTestSendService(sendRepositoryMock)
+	send := &model.Send{UserId: mockutil.DefaultIdAsUint64, Name: mockutil.DefaultSendName, MaxViews: 1}
+
+	link, err := service.CreateSend(send, mockutil.DefaultPassword, "")
+	assert.Nil(t, err, "Should create a send without errors")
+	assert.True(t, strings.HasPrefix(link, testSendUrl), "Link should point to the configured send url")
+	token, key := splitLink(link)
+	assert.NotEmpty(t, token, "Link should contain the send token")
+	assert.NotEmpty(t, key, "Link should contain the send key in its fragment")
+
+	assert.Equal(t, send.Id, mockutil.DefaultIdAsUint64)
+	assert.Equal(t, send.TokenHash, security.HashToken(token), "Only the hash of the token should be stored")
+	assert.False(t, send.HasPassphrase)
+	assert.NotContains(t, string(send.EncryptedSecret), mockutil.DefaultPassword, "Secret should be stored encrypted")
+	sendRepositoryMock.AssertCalled(t, "InsertNewSend", send)
+}
+
+// CreateSend should return an error and not store anything if the secret can't be encrypted
+func TestCreateSendWithEncryptionError(t *testing.T) {
+	sendRepositoryMock := mockutil.DefaultSendRepositoryServiceMock()
+	passwordSecurityMock := new(mockutil.PasswordSecurityServiceMock)
+	passwordSecurityMock.On("HashWithArgon2id", mock.Anything).Return([]byte(mockutil.MockedUserMasterPassword))
+	passwordSecurityMock.On("EncryptWithAes", mock.Anything, mock.Anything).Return(nil, errors.New(mockutil.MockedGenericErrorMessage))
+	service := NewSendService(&config.Send{Url: testSendUrl}, sendRepositoryMock, passwordSecurityMock, passwordSecurityMock)
+
+	link, err := service.CreateSend(&model.Send{}, mockutil.DefaultPassword, "")
+	assert.NotNil(t, err, "Should return an error")
+	assert.Empty(t, link, "Should not return a link")
+	sendRepositoryMock.AssertNotCalled(t, "InsertNewSend", mock.Anything)
+}
+
+// OpenSend should decrypt the secret with the key from the link and the passphrase, and count the view
+func TestOpenSend(t *testing.T) {
+	send, token, key := createTestSend(t, "passphrase")
+	sendRepositoryMock := sendRepositoryMockWithSend(send, nil)
+	service := newTestSendService(sendRepositoryMock)
+
+	secret, err := service.OpenSend(token, key, "passphrase")
+	assert.Nil(t, err, "Should open the send without errors")
+	assert.Equal(t, secret, mockutil.DefaultPassword)
+	sendRepositoryMock.AssertCalled(t, "FetchAvailableSendByTokenHash", mock.Anything, security.HashToken(token), mock.Anything)
+	sendRepositoryMock.AssertCalled(t, "ConsumeSendViewById", send.Id, mock.Anything)
+}
+
+// OpenSend should reject a wrong key or passphrase without using up a view
+func TestOpenSendWithInvalidKey(t *testing.T) {
+	send, token, key := createTestSend(t, "passphrase")
+	sendRepositoryMock := sendRepositoryMockWithSend(send, nil)
+	service := newTestSendService(sendRepositoryMock)
+
+	secret, err := service.OpenSend(token, key, "wrong passphrase")
+	assert.Equal(t, err, ErrInvalidSendKey, "Should reject a wrong passphrase")
+	assert.Empty(t, secret)
+
+	_, err = service.OpenSend(token, "wrong key", "passphrase")
+	assert.Equal(t, err, ErrInvalidSendKey, "Should reject a wrong key")
+	sendRepositoryMock.AssertNotCalled(t, "ConsumeSendViewById", mock.Anything, mock.Anything)
+}
+
+// OpenSend should report sends that don't exist, expired or were burned as unavailable
+func TestOpenSendWithUnavailableSend(t *testing.T) {
+	sendRepositoryMock := new(mockutil.SendRepositoryServiceMock)
+	sendRepositoryMock.On("FetchAvailableSendByTokenHash", mock.Anything, mock.Anything, mock.Anything).Return(repository.ErrNotFound, nil)
+	service := newTestSendService(sendRepositoryMock)
+
+	secret, err := service.OpenSend("token", "key", "")
+	assert.Equal(t, err, ErrSendUnavailable, "Should return expected error")
+	assert.Empty(t, secret)
+}
+
+// OpenSend should not return the secret when a concurrent retrieval burned the last view
+func TestOpenSendWithConcurrentlyBurnedSend(t *testing.T) {
+	send, token, key := createTestSend(t, "")
+	service := newTestSendService(sendRepositoryMockWithSend(send, repository.ErrNotFound))
+
+	secret, err := service.OpenSend(token, key, "")
+	assert.Equal(t, err, ErrSendUnavailable, "Should return expected error")
+	assert.Empty(t, secret)
+}
+
+// PurgeUnavailableSends should return the number of purged sends and zero on errors
+func TestPurgeUnavailableSends(t *testing.T) {
+	sendRepositoryMock := new(mockutil.SendRepositoryServiceMock)
+	sendRepositoryMock.On("DeleteUnavailableSends", mock.Anything).Return(int64(3), nil).Times(1)
+	sendRepositoryMock.On("DeleteUnavailableSends", mock.Anything).Return(int64(0), errors.New(mockutil.MockedGenericErrorMessage))
+	service := newTestSendService(sendRepositoryMock)
+
+	assert.Equal(t, service.PurgeUnavailableSends(), 3)
+	assert.Equal(t, service.PurgeUnavailableSends(), 0)
+}
+
+func newTestSendService(sendRepositoryMock *mockutil.SendRepositoryServiceMock) *sendService {
+	passwordSecurityService := &security.PasswordSecurityService{
+		Argon2PasswordHasher: &security.PasswordHashService{},
+		AesPasswordCryptor:   &security.PasswordCryptoService{},
+	}
+	return NewSendService(&config.Send{Url: testSendUrl}, sendRepositoryMock, passwordSecurityService, passwordSecurityService)
+}
+
+// createTestSend creates a send of the default password through the service, returning it with its token and key
+func createTestSend(t *testing.T, passphrase string) (model.Send, string, string) {
+	send := &model.Send{Name: mockutil.DefaultSendName, MaxViews: 1}
+	link, err := newTestSendService(mockutil.DefaultSendRepositoryServiceMock()).CreateSend(send, mockutil.DefaultPassword, passphrase)
+	if err != nil {
+		t.Fatalf("Could not create test send: %s", err)
+	}
+
+	token, key := splitLink(link)
+	return *send, token, key
+}
+
+func sendRepositoryMockWithSend(send model.Send, consumeError error) *mockutil.SendRepositoryServiceMock {
+	sendRepositoryMock := new(mockutil.SendRepositoryServiceMock)
+	sendRepositoryMock.On("FetchAvailableSendByTokenHash", mock.Anything, mock.Anything, mock.Anything).Return(nil, send)
+	sendRepositoryMock.On("ConsumeSendViewById", mock.Anything, mock.Anything).Return(consumeError)
+	return sendRepositoryMock
+}
+
+func splitLink(link string) (string, string) {
+	tokenWithKey := strings.SplitN(strings.TrimPrefix(link, testSendUrl), "#", 2)
+	return tokenWithKey[0], tokenWithKey[1]
+}
@@ -1,15 +1,29 @@
 package server
 
 import (
+	"github.com/99designs/gqlgen/graphql"
 	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
 	"github.com/99designs/gqlgen/graphql/playground"
+	"github.com/KristijanFaust/gokeeper/app/attachment"
+	"github.com/KristijanFaust/gokeeper/app/audit"
 	"github.com/KristijanFaust/gokeeper/app/authentication"
 	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/database"
 	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/KristijanFaust/gokeeper/app/emergencyaccess"
+	"github.com/KristijanFaust/gokeeper/app/eventbus"
 	"github.com/KristijanFaust/gokeeper/app/gql"
 	"github.com/KristijanFaust/gokeeper/app/gql/generated"
+	"github.com/KristijanFaust/gokeeper/app/mail"
 	"github.com/KristijanFaust/gokeeper/app/security"
+	"github.com/KristijanFaust/gokeeper/app/send"
+	"github.com/KristijanFaust/gokeeper/app/webauthn"
+	"github.com/KristijanFaust/gokeeper/app/webhook"
 	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
 	"github.com/rs/cors"
 	"github.com/upper/db/v4"
 	"log"
@@ -17,9 +31,14 @@ import (
 	"reflect"
 	"sync"
 	"syscall"
+	"time"
 )
 
-func Run(applicationConfig *config.Config, serverDoneWaitGroup *sync.WaitGroup, session *db.Session) *http.Server {
+// Run starts the server on top of the given repositories, the session of their database is needed only to relay
+// vault events through postgres and is nil for repositories that aren't backed by a database
+func Run(
+	applicationConfig *config.Config, serverDoneWaitGroup *sync.WaitGroup, repositories *repository.Repositories, session *db.Session,
+) *http.Server {
 	if reflect.ValueOf(applicationConfig).IsZero() {
 		log.Panic("Application configuration not loaded, cannot start server")
 	}
@@ -29,23 +48,86 @@ func Run(applicationConfig *config.Config, serverDoneWaitGroup *sync.WaitGroup,
 	log.Printf("Starting GoKeeper server on http://%s:%s", hostname, portNumber)
 
 	router := chi.NewRouter()
-	router.Use(authentication.AuthenticationMiddleware(applicationConfig.Authentication.JwtSigningKey))
-
-	graphqlHandler := handler.NewDefaultServer(generated.NewExecutableSchema(
-		generated.Config{Resolvers: gql.NewResolver(
-			repository.NewUserRepositoryService(session),
-			repository.NewPasswordRepositoryService(session),
-			&security.PasswordSecurityService{
-				Argon2PasswordHasher: &security.PasswordHashService{},
-				AesPasswordCryptor:   &security.PasswordCryptoService{},
-			},
-			authentication.NewJwtAuthenticationService(applicationConfig.Authentication),
-		)},
-	))
-
-	if reflect.ValueOf(applicationConfig.Profile).IsZero() || !applicationConfig.Profile.Production {
+	router.Use(authentication.ClientInformationMiddleware())
+	webhookWorker := webhook.NewWorker(applicationConfig.Webhook, repositories.Webhook)
+	auditService := audit.NewAuditService(repositories.AuditEvent, webhook.NewDispatcher(repositories.Webhook))
+	router.Use(authentication.AuthenticationMiddleware(applicationConfig.Authentication.JwtSigningKey, repositories.Session, auditService))
+	vaultEventBus := newVaultEventBus(applicationConfig, session)
+
+	mailer := mail.NewMailService(applicationConfig.Mail, mail.NewSender(applicationConfig.Mail))
+
+	keyPairCryptor := &security.KeyPairService{}
+	passwordSecurityService := &security.PasswordSecurityService{
+		Argon2PasswordHasher: &security.PasswordHashService{},
+		AesPasswordCryptor:   &security.PasswordCryptoService{},
+		RecoveryKeyWrapper:   &security.RecoveryKeyService{},
+		KeyPairCryptor:       keyPairCryptor,
+	}
+	emergencyAccessService := emergencyaccess.NewEmergencyAccessService(
+		repositories.EmergencyAccess, repositories.KeyPair, repositories.User, keyPairCryptor,
+	)
+	emergencyAccessScheduler := emergencyaccess.NewScheduler(
+		emergencyAccessService, time.Duration(applicationConfig.EmergencyAccess.SchedulerIntervalInSeconds)*time.Second,
+	)
+	sendService := send.NewSendService(applicationConfig.Send, repositories.Send, passwordSecurityService, passwordSecurityService)
+	sendPurger := send.NewPurger(sendService, time.Duration(applicationConfig.Send.PurgeIntervalInSeconds)*time.Second)
+	jwtAuthenticationService := authentication.NewJwtAuthenticationService(applicationConfig.Authentication)
+	attachmentService := attachment.NewAttachmentService(
+		applicationConfig.Attachment, repositories.Attachment, repositories.User, newBlobStore(applicationConfig.Attachment),
+	)
+
+	resolver := gql.NewResolver(
+		repositories.User,
+		repositories.Password,
+		repositories.Webauthn,
+		repositories.KnownDevice,
+		repositories.KeyPair,
+		repositories.EmergencyAccess,
+		repositories.Session,
+		repositories.Organization,
+		repositories.PasswordShare,
+		repositories.Send,
+		repositories.Admin,
+		repositories.AuditEvent,
+		repositories.Webhook,
+		repositories.Attachment,
+		repositories.UnitOfWork,
+		passwordSecurityService,
+		jwtAuthenticationService,
+		webauthn.NewWebauthnService(applicationConfig.Webauthn),
+		mailer,
+		emergencyAccessService,
+		sendService,
+		auditService,
+		vaultEventBus,
+		attachmentService,
+	)
+	isDevelopment := reflect.ValueOf(applicationConfig.Profile).IsZero() || !applicationConfig.Profile.Production
+	graphqlHandler := newGraphqlHandler(
+		generated.NewExecutableSchema(generated.Config{
+			Resolvers:  resolver,
+			Directives: generated.DirectiveRoot{HasRole: resolver.HasRole},
+		}),
+		authentication.WebsocketInitFunc(applicationConfig.Authentication.JwtSigningKey, repositories.Session, auditService),
+		isDevelopment,
+		applicationConfig.Attachment.MaxSizeInBytes,
+	)
+	graphqlHandler.Use(gql.ReadConsistency{})
+	var storageMonitor gql.StorageMonitor
+	var databaseMonitor *database.Monitor
+	if session != nil {
+		databaseMonitor = database.NewMonitor(
+			session,
+			time.Duration(applicationConfig.Datasource.HealthCheckIntervalInSeconds)*time.Second,
+			applicationConfig.Datasource.HealthCheckFailureThreshold,
+		)
+		storageMonitor = databaseMonitor
+		graphqlHandler.Use(gql.StorageAvailability{Monitor: storageMonitor})
+	}
+
+	if isDevelopment {
 		router.Use(cors.New(cors.Options{
-			AllowedOrigins: []string{"http://localhost:3000", "http://localhost:8080"},
+			AllowedOrigins: developmentOrigins,
 			AllowedHeaders: []string{"Authentication", "Content-Type"},
 		}).Handler)
 
@@ -53,11 +135,32 @@ func Run(applicationConfig *config.Config, serverDoneWaitGroup *sync.WaitGroup,
 		log.Printf("Serving GraphQL playground on http://%s:%s/playground", hostname, portNumber)
 	}
 	router.Handle("/query", graphqlHandler)
+	router.Post("/send/{token}", send.RetrievalHandler(sendService))
+	router.Get("/attachments/{attachmentId}", attachment.DownloadHandler(attachmentService, jwtAuthenticationService))
+	router.Get("/ready", readinessHandler(storageMonitor))
+	router.Get("/metrics", database.MetricsHandler)
 
 	server := &http.Server{
 		Addr:    hostname + ":" + portNumber,
 		Handler: router,
 	}
+	server.RegisterOnShutdown(mailer.Close)
+	emergencyAccessScheduler.Start()
+	server.RegisterOnShutdown(emergencyAccessScheduler.Stop)
+	sendPurger.Start()
+	server.RegisterOnShutdown(sendPurger.Stop)
+	webhookWorker.Start()
+	server.RegisterOnShutdown(webhookWorker.Stop)
+	if databaseMonitor != nil {
+		databaseMonitor.Start()
+		server.RegisterOnShutdown(databaseMonitor.Stop)
+	}
+	if postgresBus, ok := vaultEventBus.(*eventbus.PostgresBus); ok {
+		if err := postgresBus.Start(); err != nil {
+			log.Panicf("Could not listen for vault events: %s", err)
+		}
+		server.RegisterOnShutdown(postgresBus.Stop)
+	}
 
 	go func(server *http.Server) {
 		defer serverDoneWaitGroup.Done()
@@ -71,3 +174,89 @@ func Run(applicationConfig *config.Config, serverDoneWaitGroup *sync.WaitGroup,
 
 	return server
 }
+
+// multipartOverheadInBytes leaves room for the operation and the part headers next to an upload of the maximum size
+const multipartOverheadInBytes = 64 * 1024
+
+// developmentOrigins are the origins of the local frontend and playground, allowed outside of production
+var developmentOrigins = []string{"http://localhost:3000", "http://localhost:8080"}
+
+// readinessHandler reports whether the server is ready to serve requests, which it isn't while the storage is unavailable,
+// a server without a storage monitor is always ready
+func readinessHandler(storageMonitor gql.StorageMonitor) http.HandlerFunc {
+	return func(writer http.ResponseWriter, request *http.Request) {
+		writer.Header().Set("Content-Type", "application/json")
+		if storageMonitor != nil && !storageMonitor.Available() {
+			writer.WriteHeader(http.StatusServiceUnavailable)
+			writer.Write([]byte(`{"status":"storage unavailable"}`))
+			return
+		}
+		writer.Write([]byte(`{"status":"ready"}`))
+	}
+}
+
+func newVaultEventBus(applicationConfig *config.Config, session *db.Session) eventbus.Bus {
+	if applicationConfig.EventBus != nil && applicationConfig.EventBus.Adapter == "postgres" {
+		if session == nil || database.Driver(*session) != database.DriverPostgres {
+			log.Println("The postgres event bus needs a postgres datasource, falling back to the local event bus")
+			return eventbus.NewLocalBus()
+		}
+		log.Println("Relaying vault events through Postgres LISTEN/NOTIFY")
+		return eventbus.NewPostgresBus(applicationConfig.Datasource, session)
+	}
+	return eventbus.NewLocalBus()
+}
+
+func newBlobStore(attachmentConfig *config.Attachment) attachment.BlobStore {
+	switch attachmentConfig.BlobStore {
+	case "s3":
+		log.Printf("Storing attachments in the %s bucket at %s", attachmentConfig.S3Bucket, attachmentConfig.S3Endpoint)
+		return attachment.NewS3BlobStore(attachmentConfig)
+	case "memory":
+		log.Println("Storing attachments in memory, they will be lost on shutdown")
+		return attachment.NewMemoryBlobStore()
+	default:
+		log.Printf("Storing attachments in %s", attachmentConfig.Directory)
+		return attachment.NewLocalBlobStore(attachmentConfig.Directory)
+	}
+}
+
+// newGraphqlHandler sets up the same transports as handler.NewDefaultServer, except that WebSocket connections
+// get authenticated through their init payload and are only accepted from the development origins outside of production,
+// and multipart uploads are limited to the maximum attachment size
+func newGraphqlHandler(
+	executableSchema graphql.ExecutableSchema,
+	websocketInitFunc transport.WebsocketInitFunc,
+	isDevelopment bool,
+	maxUploadSizeInBytes int64,
+) *handler.Server {
+	graphqlHandler := handler.New(executableSchema)
+
+	upgrader := websocket.Upgrader{}
+	if isDevelopment {
+		upgrader.CheckOrigin = func(request *http.Request) bool {
+			origin := request.Header.Get("Origin")
+			for _, developmentOrigin := range developmentOrigins {
+				if origin == developmentOrigin {
+					return true
+				}
+			}
+			return origin == "" || origin == "http://"+request.Host
+		}
+	}
+	graphqlHandler.AddTransport(transport.Websocket{
+		Upgrader:              upgrader,
+		InitFunc:              websocketInitFunc,
+		KeepAlivePingInterval: 10 * time.Second,
+	})
+	graphqlHandler.AddTransport(transport.Options{})
+	graphqlHandler.AddTransport(transport.GET{})
+	graphqlHandler.AddTransport(transport.POST{})
+	graphqlHandler.AddTransport(transport.MultipartForm{MaxUploadSize: maxUploadSizeInBytes + multipartOverheadInBytes})
+
+	graphqlHandler.SetQueryCache(lru.New(1000))
+	graphqlHandler.Use(extension.Introspection{})
+	graphqlHandler.Use(extension.AutomaticPersistedQuery{Cache: lru.New(100)})
+
+	return graphqlHandler
+}
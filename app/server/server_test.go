@@ -3,8 +3,11 @@ package server
 import (
 	"context"
 	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/database/memory"
+	"github.com/KristijanFaust/gokeeper/app/gql"
 	"github.com/stretchr/testify/assert"
 	"net/http"
+	"net/http/httptest"
 	"os/signal"
 	"sync"
 	"syscall"
@@ -18,7 +21,9 @@ func TestRun(t *testing.T) {
 	var server *http.Server
 	serverDoneWaitGroup := &sync.WaitGroup{}
 	serverDoneWaitGroup.Add(1)
-	assert.NotPanics(t, func() { server = Run(applicationConfig, serverDoneWaitGroup, nil) }, "Server should run without panics")
+	assert.NotPanics(t, func() {
+		server = Run(applicationConfig, serverDoneWaitGroup, memory.NewRepositories(memory.NewStore()), nil)
+	}, "Server should run without panics")
 
 	err := server.Shutdown(context.TODO())
 	assert.Nil(t, err, "Server should shutdown without any errors")
@@ -29,7 +34,7 @@ func TestRun(t *testing.T) {
 func TestRunWithoutConfiguration(t *testing.T) {
 	assert.PanicsWithValue(
 		t, "Application configuration not loaded, cannot start server",
-		func() { Run(nil, nil, nil) }, "Server boot should panic if no configuration is loaded",
+		func() { Run(nil, nil, nil, nil) }, "Server boot should panic if no configuration is loaded",
 	)
 }
 
@@ -42,7 +47,33 @@ func TestRunWithServerBootError(t *testing.T) {
 	serverDoneWaitGroup.Add(1)
 
 	signal.Ignore(syscall.SIGINT)
-	assert.NotPanics(t, func() { Run(applicationConfig, serverDoneWaitGroup, nil) }, "Server should try to boot without panics")
+	assert.NotPanics(t, func() { Run(applicationConfig, serverDoneWaitGroup, memory.NewRepositories(memory.NewStore()), nil) }, "Server should try to boot without panics")
 
 	serverDoneWaitGroup.Wait()
 }
+
+type storageMonitorStub bool
+
+func (available storageMonitorStub) Available() bool {
+	return bool(available)
+}
+
+// readinessHandler should report the server as ready while the storage is available
+func TestReadinessHandler(t *testing.T) {
+	for _, storageMonitor := range []gql.StorageMonitor{nil, storageMonitorStub(true)} {
+		recorder := httptest.NewRecorder()
+		readinessHandler(storageMonitor)(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+		assert.Equal(t, recorder.Code, http.StatusOK)
+		assert.Equal(t, recorder.Body.String(), `{"status":"ready"}`)
+	}
+}
+
+// readinessHandler should report the server as unavailable while the storage is
+func TestReadinessHandlerWithUnavailableStorage(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	readinessHandler(storageMonitorStub(false))(recorder, httptest.NewRequest(http.MethodGet, "/ready", nil))
+
+	assert.Equal(t, recorder.Code, http.StatusServiceUnavailable)
+	assert.Equal(t, recorder.Body.String(), `{"status":"storage unavailable"}`)
+}
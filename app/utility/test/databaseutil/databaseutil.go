@@ -1,62 +1,85 @@
 package databaseutil
 
 import (
-	"fmt"
 	"github.com/KristijanFaust/gokeeper/app/config"
-	"github.com/golang-migrate/migrate/v4"
+	"github.com/KristijanFaust/gokeeper/app/database/migration"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/testcontainersutil"
+	_ "github.com/golang-migrate/migrate/v4/database/mysql"
 	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/database/sqlite3"
 	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+	"github.com/upper/db/v4"
+	"github.com/upper/db/v4/adapter/sqlite"
 	"log"
+	"os"
 	"path/filepath"
 	"reflect"
-	"runtime"
 )
 
-func GenerateTestDatasourceConfiguration() *config.Datasource {
+const (
+	driverPostgres = "postgres"
+	driverSqlite   = "sqlite"
+	driverMysql    = "mysql"
+)
+
+// TestDrivers are the datasource drivers every repository test suite runs against
+var TestDrivers = []string{driverPostgres, driverSqlite, driverMysql}
+
+func GenerateTestDatasourceConfiguration(driver string) *config.Datasource {
 	datasourceConfig := new(config.Datasource)
+	datasourceConfig.Driver = driver
+	datasourceConfig.MaxOpenConnections = 1
+	datasourceConfig.MaxConnectionLifetime = 1
+	datasourceConfig.ConnectionAttempts = 60
+	datasourceConfig.ConnectionBackoffInSeconds = 1
+	datasourceConfig.MaxConnectionBackoffInSeconds = 1
+	datasourceConfig.HealthCheckIntervalInSeconds = 1
+	datasourceConfig.HealthCheckFailureThreshold = 1
+
+	if driver == driverSqlite {
+		datasourceConfig.Database = filepath.Join(os.TempDir(), "gokeeper-test-"+uuid.New().String()+".db")
+		return datasourceConfig
+	}
+
 	datasourceConfig.Host = "localhost:50000"
+	if driver == driverMysql {
+		datasourceConfig.Host = "localhost:50001"
+	}
 	datasourceConfig.User = "gokeeperapp-test"
 	datasourceConfig.Password = "password-test"
 	datasourceConfig.Database = "gokeeper-test"
-	datasourceConfig.MaxOpenConnections = 1
-	datasourceConfig.MaxConnectionLifetime = 1
-
 	return datasourceConfig
 }
 
+// StartTestDatabase starts the test database of the driver, postgres and mariadb run in docker containers
+// while a sqlite database is just a temporary file
+func StartTestDatabase(driver string) bool {
+	if driver == driverSqlite {
+		return true
+	}
+	return testcontainersutil.DockerComposeUp()
+}
+
+// StopTestDatabase stops the test database of the session, removing the files of a sqlite database
+func StopTestDatabase(session *db.Session) {
+	if settings, ok := (*session).ConnectionURL().(sqlite.ConnectionURL); ok {
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			os.Remove(settings.Database + suffix)
+		}
+		return
+	}
+	testcontainersutil.DockerComposeDown()
+}
+
 func RunDatabaseMigrations(datasourceConfig *config.Datasource) bool {
 	if reflect.ValueOf(datasourceConfig).IsZero() {
 		log.Panic("Datasource configuration not loaded, cannot run test database migrations")
 	}
 
-	migrationFilesPath := "file://" + getRelativePathToDatabaseMigrationFiles()
-	databaseUri := fmt.Sprintf(
-		"postgres://%s:%s@%s/%s?sslmode=disable",
-		datasourceConfig.User,
-		datasourceConfig.Password,
-		datasourceConfig.Host,
-		datasourceConfig.Database,
-	)
-
-	migration, err := migrate.New(migrationFilesPath, databaseUri)
-	if err != nil {
-		log.Printf(
-			"An error occured while trying to setup test migrations from: %s, with database uri: %s\nError: %s",
-			migrationFilesPath, databaseUri, err,
-		)
-		return false
-	}
-
-	err = migration.Steps(1)
-	if err != nil {
+	if err := migration.Up(datasourceConfig); err != nil {
 		log.Printf("An error occured during test migration execution: %s", err)
 		return false
 	}
-
 	return true
 }
-
-func getRelativePathToDatabaseMigrationFiles() string {
-	_, filename, _, _ := runtime.Caller(0)
-	return filepath.Dir(filename) + "/../../../../support/database/postgres/migration"
-}
@@ -0,0 +1,90 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type AdminRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *AdminRepositoryServiceMock) FetchUsers(users *model.Users, offset int, limit int) error {
+	arguments := service.Called(users, offset, limit)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*users = arguments.Get(1).(model.Users)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *AdminRepositoryServiceMock) FetchUsageStatistics(statistics *model.UsageStatistics) error {
+	arguments := service.Called(statistics)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*statistics = arguments.Get(1).(model.UsageStatistics)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *AdminRepositoryServiceMock) FetchAuditEntriesDetails(
+	entries *model.AdminAuditEntriesDetails, offset int, limit int,
+) error {
+	arguments := service.Called(entries, offset, limit)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*entries = arguments.Get(1).(model.AdminAuditEntriesDetails)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *AdminRepositoryServiceMock) UpdateUserDisabledById(disabled bool, id uint64, auditEntry *model.AdminAuditEntry) error {
+	arguments := service.Called(disabled, id, auditEntry)
+	return arguments.Error(0)
+}
+
+func (service *AdminRepositoryServiceMock) UpdateUserRoleById(role string, id uint64, auditEntry *model.AdminAuditEntry) error {
+	arguments := service.Called(role, id, auditEntry)
+	return arguments.Error(0)
+}
+
+func (service *AdminRepositoryServiceMock) RevokeUserSessionsById(id uint64, auditEntry *model.AdminAuditEntry) error {
+	arguments := service.Called(id, auditEntry)
+	return arguments.Error(0)
+}
+
+// DefaultAdminAuditEntry is an entry of the default user disabling the emergency access grantee
+func DefaultAdminAuditEntry() model.AdminAuditEntryDetails {
+	return model.AdminAuditEntryDetails{
+		AdminAuditEntry: model.AdminAuditEntry{
+			Id:           DefaultIdAsUint64,
+			AdminId:      DefaultIdAsUint64,
+			Action:       model.AdminActionDisableUser,
+			TargetUserId: DefaultGranteeIdAsUint64,
+			CreatedAt:    time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		AdminEmail:  DefaultEmail,
+		TargetEmail: DefaultGranteeEmail,
+	}
+}
+
+func DefaultAdminRepositoryServiceMock() *AdminRepositoryServiceMock {
+	serviceMock := new(AdminRepositoryServiceMock)
+	serviceMock.On("FetchUsers", mock.Anything, mock.Anything, mock.Anything).Return(nil, model.Users{
+		{Id: DefaultIdAsUint64, Email: DefaultEmail, Username: DefaultUsername, Role: model.UserRoleAdmin},
+		{Id: DefaultGranteeIdAsUint64, Email: DefaultGranteeEmail, Username: DefaultUsername, Role: model.UserRoleUser},
+	}).Times(1)
+	serviceMock.On("FetchUsageStatistics", mock.Anything).Return(nil, model.UsageStatistics{Users: 2, Admins: 1, Entries: 4}).Times(1)
+	serviceMock.On("FetchAuditEntriesDetails", mock.Anything, mock.Anything, mock.Anything).Return(
+		nil, model.AdminAuditEntriesDetails{DefaultAdminAuditEntry()},
+	).Times(1)
+	serviceMock.On("UpdateUserDisabledById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("UpdateUserRoleById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("RevokeUserSessionsById", mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return serviceMock
+}
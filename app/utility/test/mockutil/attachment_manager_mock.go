@@ -0,0 +1,74 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"io/ioutil"
+	"strings"
+)
+
+type AttachmentManagerMock struct {
+	mock.Mock
+}
+
+func (service *AttachmentManagerMock) StoreAttachment(attachment *model.Attachment, content io.Reader) error {
+	arguments := service.Called(attachment, content)
+
+	if arguments.Error(0) == nil {
+		attachment.Id = DefaultIdAsUint64
+		attachment.BlobKey = DefaultAttachment().BlobKey
+		attachment.CreatedAt = DefaultAttachment().CreatedAt
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *AttachmentManagerMock) OpenAttachment(id uint64, userId uint64) (*model.Attachment, io.ReadCloser, error) {
+	arguments := service.Called(id, userId)
+
+	if arguments.Error(1) != nil {
+		return nil, nil, arguments.Error(1)
+	}
+
+	attachment := arguments.Get(0).(model.Attachment)
+	return &attachment, ioutil.NopCloser(strings.NewReader(DefaultAttachmentContent)), nil
+}
+
+func (service *AttachmentManagerMock) DeleteAttachment(attachment *model.Attachment) error {
+	arguments := service.Called(attachment)
+	return arguments.Error(0)
+}
+
+func (service *AttachmentManagerMock) DeleteAttachmentBlobs(attachments model.Attachments) {
+	service.Called(attachments)
+}
+
+func (service *AttachmentManagerMock) RewrapDataKeys(
+	attachments model.Attachments, oldVaultKey []byte, newVaultKey []byte,
+) (model.Attachments, error) {
+	arguments := service.Called(attachments, oldVaultKey, newVaultKey)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(model.Attachments), arguments.Error(1)
+}
+
+func (service *AttachmentManagerMock) StorageQuota() int64 {
+	arguments := service.Called()
+	return arguments.Get(0).(int64)
+}
+
+func DefaultAttachmentManagerMock() *AttachmentManagerMock {
+	serviceMock := new(AttachmentManagerMock)
+	serviceMock.On("StoreAttachment", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("OpenAttachment", mock.Anything, mock.Anything).Return(DefaultAttachment(), nil).Times(1)
+	serviceMock.On("DeleteAttachment", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("DeleteAttachmentBlobs", mock.Anything).Return()
+	serviceMock.On("RewrapDataKeys", mock.Anything, mock.Anything, mock.Anything).Return(model.Attachments{DefaultAttachment()}, nil)
+	serviceMock.On("StorageQuota").Return(DefaultStorageQuota)
+
+	return serviceMock
+}
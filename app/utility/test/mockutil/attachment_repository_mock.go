@@ -0,0 +1,89 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type AttachmentRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *AttachmentRepositoryServiceMock) InsertNewAttachment(attachment *model.Attachment, quotaInBytes int64) (db.InsertResult, error) {
+	arguments := service.Called(attachment, quotaInBytes)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *AttachmentRepositoryServiceMock) FetchAttachmentById(attachment *model.Attachment, id uint64) error {
+	arguments := service.Called(attachment, id)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*attachment = arguments.Get(1).(model.Attachment)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *AttachmentRepositoryServiceMock) FetchAttachmentsByPasswordId(attachments *model.Attachments, passwordId uint64) error {
+	arguments := service.Called(attachments, passwordId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*attachments = arguments.Get(1).(model.Attachments)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *AttachmentRepositoryServiceMock) FetchAttachmentsByUserId(attachments *model.Attachments, userId uint64) error {
+	arguments := service.Called(attachments, userId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*attachments = arguments.Get(1).(model.Attachments)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *AttachmentRepositoryServiceMock) FetchStorageUsageByUserId(userId uint64) (int64, error) {
+	arguments := service.Called(userId)
+	return arguments.Get(0).(int64), arguments.Error(1)
+}
+
+func (service *AttachmentRepositoryServiceMock) DeleteAttachmentById(id uint64) error {
+	arguments := service.Called(id)
+	return arguments.Error(0)
+}
+
+// DefaultAttachment is an attachment of the default user's entry
+func DefaultAttachment() model.Attachment {
+	return model.Attachment{
+		Id:             DefaultIdAsUint64,
+		PasswordId:     DefaultIdAsUint64,
+		UserId:         DefaultIdAsUint64,
+		Name:           DefaultAttachmentName,
+		ContentType:    "text/plain",
+		Size:           int64(len(DefaultAttachmentContent)),
+		BlobKey:        "attachments/1/blob",
+		WrappedDataKey: []byte("wrappedDataKey"),
+		CreatedAt:      time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func DefaultAttachmentRepositoryServiceMock() *AttachmentRepositoryServiceMock {
+	serviceMock := new(AttachmentRepositoryServiceMock)
+	serviceMock.On("InsertNewAttachment", mock.Anything, mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("FetchAttachmentById", mock.Anything, mock.Anything).Return(nil, DefaultAttachment()).Times(1)
+	serviceMock.On("FetchAttachmentsByPasswordId", mock.Anything, mock.Anything).Return(nil, model.Attachments{DefaultAttachment()}).Times(1)
+	serviceMock.On("FetchAttachmentsByUserId", mock.Anything, mock.Anything).Return(nil, model.Attachments{DefaultAttachment()}).Times(1)
+	serviceMock.On("FetchStorageUsageByUserId", mock.Anything).Return(MockedStorageUsage, nil).Times(1)
+	serviceMock.On("DeleteAttachmentById", mock.Anything).Return(nil).Times(1)
+
+	return serviceMock
+}
@@ -0,0 +1,65 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type AuditEventRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *AuditEventRepositoryServiceMock) AppendEvent(
+	event *model.AuditEvent, hashEvent func(event *model.AuditEvent) []byte,
+) error {
+	arguments := service.Called(event, hashEvent)
+	return arguments.Error(0)
+}
+
+func (service *AuditEventRepositoryServiceMock) FetchEvents(
+	events *model.AuditEvents, filter *model.AuditEventFilter, offset int, limit int,
+) error {
+	arguments := service.Called(events, filter, offset, limit)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*events = arguments.Get(1).(model.AuditEvents)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *AuditEventRepositoryServiceMock) FetchEventsAfterSequence(events *model.AuditEvents, sequence int64, limit int) error {
+	arguments := service.Called(events, sequence, limit)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*events = arguments.Get(1).(model.AuditEvents)
+	}
+
+	return arguments.Error(0)
+}
+
+// DefaultAuditEvent is a sign in of the default user
+func DefaultAuditEvent() model.AuditEvent {
+	userId := DefaultIdAsUint64
+	return model.AuditEvent{
+		Id:        DefaultIdAsUint64,
+		Sequence:  1,
+		UserId:    &userId,
+		Type:      model.AuditEventSignIn,
+		IpAddress: "127.0.0.1",
+		UserAgent: "test-agent",
+		CreatedAt: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func DefaultAuditEventRepositoryServiceMock() *AuditEventRepositoryServiceMock {
+	serviceMock := new(AuditEventRepositoryServiceMock)
+	serviceMock.On("AppendEvent", mock.Anything, mock.Anything).Return(nil)
+	serviceMock.On("FetchEvents", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		nil, model.AuditEvents{DefaultAuditEvent()},
+	).Times(1)
+	serviceMock.On("FetchEventsAfterSequence", mock.Anything, mock.Anything, mock.Anything).Return(nil, model.AuditEvents{}).Times(1)
+
+	return serviceMock
+}
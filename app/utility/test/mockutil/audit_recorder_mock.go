@@ -0,0 +1,22 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type AuditRecorderMock struct {
+	mock.Mock
+}
+
+func (service *AuditRecorderMock) Record(event *model.AuditEvent) {
+	service.Called(event)
+}
+
+// DefaultAuditRecorderMock records any number of events, since most resolvers record one or more
+func DefaultAuditRecorderMock() *AuditRecorderMock {
+	serviceMock := new(AuditRecorderMock)
+	serviceMock.On("Record", mock.Anything).Return()
+
+	return serviceMock
+}
@@ -0,0 +1,28 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type EmergencyAccessApproverMock struct {
+	mock.Mock
+}
+
+func (service *EmergencyAccessApproverMock) ApproveRequest(request *model.EmergencyAccessRequest) error {
+	arguments := service.Called(request)
+	return arguments.Error(0)
+}
+
+func (service *EmergencyAccessApproverMock) ApproveElapsedRequests() int {
+	arguments := service.Called()
+	return arguments.Int(0)
+}
+
+func DefaultEmergencyAccessApproverMock() *EmergencyAccessApproverMock {
+	serviceMock := new(EmergencyAccessApproverMock)
+	serviceMock.On("ApproveRequest", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("ApproveElapsedRequests").Return(0)
+
+	return serviceMock
+}
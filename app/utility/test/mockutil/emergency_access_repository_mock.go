@@ -0,0 +1,153 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type EmergencyAccessRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) InsertNewGrant(grant *model.EmergencyAccessGrant) (db.InsertResult, error) {
+	arguments := service.Called(grant)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) FetchGrantById(grant *model.EmergencyAccessGrant, id uint64) error {
+	arguments := service.Called(grant, id)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*grant = arguments.Get(1).(model.EmergencyAccessGrant)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) FetchGrantDetailsById(grant *model.EmergencyAccessGrantDetails, id uint64) error {
+	arguments := service.Called(grant, id)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		grant.EmergencyAccessGrant = arguments.Get(1).(model.EmergencyAccessGrant)
+		grant.GrantorEmail = DefaultEmail
+		grant.GranteeEmail = DefaultGranteeEmail
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) FetchGrantsDetailsByUserId(grants *model.EmergencyAccessGrantsDetails, userId uint64) error {
+	arguments := service.Called(grants, userId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*grants = arguments.Get(1).(model.EmergencyAccessGrantsDetails)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) UpdateGrantStatusById(status string, id uint64) error {
+	arguments := service.Called(status, id)
+	return arguments.Error(0)
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) InsertNewRequest(request *model.EmergencyAccessRequest) (db.InsertResult, error) {
+	arguments := service.Called(request)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) FetchRequestById(request *model.EmergencyAccessRequest, id uint64) error {
+	arguments := service.Called(request, id)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*request = arguments.Get(1).(model.EmergencyAccessRequest)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) FetchRequestsByGrantId(requests *model.EmergencyAccessRequests, grantId uint64) error {
+	arguments := service.Called(requests, grantId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*requests = arguments.Get(1).(model.EmergencyAccessRequests)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) FetchPendingRequestsAvailableBefore(
+	requests *model.EmergencyAccessRequests, availableBefore time.Time,
+) error {
+	arguments := service.Called(requests, availableBefore)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*requests = arguments.Get(1).(model.EmergencyAccessRequests)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) ApproveRequestById(sealedVaultKey []byte, id uint64) error {
+	arguments := service.Called(sealedVaultKey, id)
+	return arguments.Error(0)
+}
+
+func (service *EmergencyAccessRepositoryServiceMock) RejectRequestById(id uint64) error {
+	arguments := service.Called(id)
+	return arguments.Error(0)
+}
+
+// DefaultEmergencyAccessGrant is an accepted takeover grant from the default user to the default grantee
+func DefaultEmergencyAccessGrant() model.EmergencyAccessGrant {
+	return model.EmergencyAccessGrant{
+		Id:             DefaultIdAsUint64,
+		GrantorId:      DefaultIdAsUint64,
+		GranteeId:      DefaultGranteeIdAsUint64,
+		AccessType:     model.EmergencyAccessTakeover,
+		WaitTimeInDays: 7,
+		Status:         model.EmergencyAccessGrantAccepted,
+	}
+}
+
+// DefaultEmergencyAccessRequest is a pending request on the default grant
+func DefaultEmergencyAccessRequest() model.EmergencyAccessRequest {
+	requestedAt := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return model.EmergencyAccessRequest{
+		Id:          DefaultIdAsUint64,
+		GrantId:     DefaultIdAsUint64,
+		Status:      model.EmergencyAccessRequestPending,
+		RequestedAt: requestedAt,
+		AvailableAt: requestedAt.AddDate(0, 0, 7),
+	}
+}
+
+// DefaultEmergencyAccessRepositoryServiceMock mocks the default grant without any requests
+func DefaultEmergencyAccessRepositoryServiceMock() *EmergencyAccessRepositoryServiceMock {
+	serviceMock := new(EmergencyAccessRepositoryServiceMock)
+	serviceMock.On("InsertNewGrant", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("FetchGrantById", mock.Anything, mock.Anything).Return(nil, DefaultEmergencyAccessGrant()).Times(1)
+	serviceMock.On("FetchGrantDetailsById", mock.Anything, mock.Anything).Return(nil, DefaultEmergencyAccessGrant()).Times(1)
+	serviceMock.On("FetchGrantsDetailsByUserId", mock.Anything, mock.Anything).Return(nil, nil).Times(1)
+	serviceMock.On("UpdateGrantStatusById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("InsertNewRequest", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("FetchRequestById", mock.Anything, mock.Anything).Return(nil, DefaultEmergencyAccessRequest()).Times(1)
+	serviceMock.On("FetchRequestsByGrantId", mock.Anything, mock.Anything).Return(nil, nil).Times(1)
+	serviceMock.On("FetchPendingRequestsAvailableBefore", mock.Anything, mock.Anything).Return(nil, nil).Times(1)
+	serviceMock.On("ApproveRequestById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("RejectRequestById", mock.Anything).Return(nil).Times(1)
+
+	return serviceMock
+}
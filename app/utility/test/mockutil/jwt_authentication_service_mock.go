@@ -10,8 +10,8 @@ type JwtAuthenticationServiceMock struct {
 	mock.Mock
 }
 
-func (service *JwtAuthenticationServiceMock) GenerateJwt(userID uint64) (string, error) {
-	arguments := service.Called(userID)
+func (service *JwtAuthenticationServiceMock) GenerateJwt(userID uint64, sessionID uint64) (string, error) {
+	arguments := service.Called(userID, sessionID)
 	return arguments.String(0), arguments.Error(1)
 }
 
@@ -24,12 +24,18 @@ func (service *JwtAuthenticationServiceMock) GetAuthenticatedUserDataFromContext
 	return arguments.Get(0).(*authentication.UserAuthentication)
 }
 
+func (service *JwtAuthenticationServiceMock) NewDeviceApprovalRequired() bool {
+	arguments := service.Called()
+	return arguments.Bool(0)
+}
+
 func DefaultJwtAuthenticationServiceMock() *JwtAuthenticationServiceMock {
 	serviceMock := new(JwtAuthenticationServiceMock)
 	serviceMock.On("GenerateJwt", mock.Anything, mock.Anything).Return(MockedJwtToken, nil).Times(1)
 	serviceMock.On("GetAuthenticatedUserDataFromContext", mock.Anything).Return(
-		&authentication.UserAuthentication{UserId: DefaultIdAsUint64},
+		&authentication.UserAuthentication{UserId: DefaultIdAsUint64, SessionId: DefaultIdAsUint64},
 	).Times(1)
+	serviceMock.On("NewDeviceApprovalRequired").Return(false).Times(1)
 
 	return serviceMock
 }
@@ -0,0 +1,37 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+type KeyPairRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *KeyPairRepositoryServiceMock) InsertNewKeyPair(keyPair *model.KeyPair) error {
+	arguments := service.Called(keyPair)
+	return arguments.Error(0)
+}
+
+func (service *KeyPairRepositoryServiceMock) FetchKeyPairByUserId(keyPair *model.KeyPair, userId uint64) error {
+	arguments := service.Called(keyPair, userId)
+
+	if arguments.Error(0) == nil {
+		keyPair.UserId = userId
+		keyPair.PublicKey = []byte(MockedPublicKey)
+		keyPair.WrappedPrivateKey = []byte(MockedWrappedPrivateKey)
+	}
+
+	return arguments.Error(0)
+}
+
+// DefaultKeyPairRepositoryServiceMock mocks a user without a key pair
+func DefaultKeyPairRepositoryServiceMock() *KeyPairRepositoryServiceMock {
+	serviceMock := new(KeyPairRepositoryServiceMock)
+	serviceMock.On("InsertNewKeyPair", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("FetchKeyPairByUserId", mock.Anything, mock.Anything).Return(repository.ErrNotFound).Times(1)
+
+	return serviceMock
+}
@@ -0,0 +1,47 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type KnownDeviceRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *KnownDeviceRepositoryServiceMock) InsertNewKnownDevice(device *model.KnownDevice) (db.InsertResult, error) {
+	arguments := service.Called(device)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *KnownDeviceRepositoryServiceMock) FetchKnownDevicesByUserId(devices *model.KnownDevices, userId uint64) error {
+	arguments := service.Called(devices, userId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*devices = arguments.Get(1).(model.KnownDevices)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *KnownDeviceRepositoryServiceMock) UpdateLastSeenById(lastSeenAt time.Time, id uint64) error {
+	arguments := service.Called(lastSeenAt, id)
+	return arguments.Error(0)
+}
+
+// DefaultKnownDeviceRepositoryServiceMock mocks a user without any known devices
+func DefaultKnownDeviceRepositoryServiceMock() *KnownDeviceRepositoryServiceMock {
+	serviceMock := new(KnownDeviceRepositoryServiceMock)
+	serviceMock.On("InsertNewKnownDevice", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("FetchKnownDevicesByUserId", mock.Anything, mock.Anything).Return(nil, nil).Times(1)
+	serviceMock.On("UpdateLastSeenById", mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return serviceMock
+}
@@ -0,0 +1,52 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/mail"
+	"github.com/stretchr/testify/mock"
+	"time"
+)
+
+type MailServiceMock struct {
+	mock.Mock
+}
+
+func (service *MailServiceMock) SendEmailVerification(recipient mail.Recipient, token string) error {
+	arguments := service.Called(recipient, token)
+	return arguments.Error(0)
+}
+
+func (service *MailServiceMock) SendNewSignInAlert(recipient mail.Recipient, userAgent string, ipAddress string) error {
+	arguments := service.Called(recipient, userAgent, ipAddress)
+	return arguments.Error(0)
+}
+
+func (service *MailServiceMock) SendSecurityNotification(recipient mail.Recipient, event string) error {
+	arguments := service.Called(recipient, event)
+	return arguments.Error(0)
+}
+
+func (service *MailServiceMock) VerificationTokenTtl() time.Duration {
+	arguments := service.Called()
+	return arguments.Get(0).(time.Duration)
+}
+
+func (service *MailServiceMock) EmailVerificationRequired() bool {
+	arguments := service.Called()
+	return arguments.Bool(0)
+}
+
+func (service *MailServiceMock) Close() {
+	service.Called()
+}
+
+func DefaultMailServiceMock() *MailServiceMock {
+	serviceMock := new(MailServiceMock)
+	serviceMock.On("SendEmailVerification", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("SendNewSignInAlert", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("SendSecurityNotification", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("VerificationTokenTtl").Return(24 * time.Hour).Times(1)
+	serviceMock.On("EmailVerificationRequired").Return(false).Times(1)
+	serviceMock.On("Close").Return().Times(1)
+
+	return serviceMock
+}
@@ -12,3 +12,43 @@ const DefaultEmail = "username@email.com"
 const DefaultUsername = "username"
 const DefaultPassword = "password"
 const DefaultPasswordName = "domain.com"
+const DefaultRevision = int64(1)
+const MockedNextRevision = int64(2)
+const DefaultVersion = int64(1)
+const MockedNextVersion = int64(2)
+
+const MockedWebauthnChallenge = "WebauthnChallengeMock"
+const MockedWebauthnCredentialId = "WebauthnCredentialIdMock"
+const MockedWebauthnPublicKey = "WebauthnPublicKeyMock"
+const MockedPrfWrappedKey = "PrfWrappedKeyMock"
+const DefaultWebauthnCredentialName = "security key"
+
+const MockedRecoveryKey = "RECO-VERY-KEYM-OCK"
+const MockedRecoveryWrappedKey = "RecoveryWrappedKeyMock"
+
+const MockedPublicKey = "PublicKeyMock"
+const MockedWrappedPrivateKey = "WrappedPrivateKeyMock"
+const MockedSealedVaultKey = "SealedVaultKeyMock"
+const DefaultGranteeEmail = "grantee@email.com"
+const DefaultGranteeIdAsUint64 = uint64(2)
+
+const DefaultDeviceName = "laptop"
+const DefaultUserAgent = "Firefox"
+const DefaultIpAddress = "10.0.0.1"
+
+const MockedSealedOrganizationKey = "SealedOrganizationKeyMock"
+const DefaultOrganizationName = "team"
+const DefaultOrganizationKeyVersion = 1
+
+const MockedSealedPassword = "SealedPasswordMock"
+
+const MockedSendLink = "http://localhost:3000/send/SendTokenMock#SendKeyMock"
+const DefaultSendName = "contractor"
+
+const DefaultTotpUri = "otpauth://totp/domain.com:username?algorithm=SHA1&digits=6&issuer=domain.com&period=30&secret=GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+const MockedEncryptedTotpUri = "EncryptedTotpUriMock"
+
+const DefaultAttachmentName = "recovery-codes.txt"
+const DefaultAttachmentContent = "AttachmentContentMock"
+const DefaultStorageQuota = int64(1024)
+const MockedStorageUsage = int64(512)
@@ -0,0 +1,173 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+)
+
+type OrganizationRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *OrganizationRepositoryServiceMock) InsertNewOrganization(
+	organization *model.Organization, owner *model.OrganizationMembership,
+) error {
+	arguments := service.Called(organization, owner)
+
+	if arguments.Error(0) == nil {
+		organization.Id = DefaultIdAsUint64
+		owner.Id = DefaultIdAsUint64
+		owner.OrganizationId = DefaultIdAsUint64
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *OrganizationRepositoryServiceMock) FetchMembershipDetailsById(membership *model.OrganizationMembershipDetails, id uint64) error {
+	arguments := service.Called(membership, id)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*membership = arguments.Get(1).(model.OrganizationMembershipDetails)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *OrganizationRepositoryServiceMock) FetchMembershipByOrganizationIdAndUserId(
+	membership *model.OrganizationMembershipDetails, organizationId uint64, userId uint64,
+) error {
+	arguments := service.Called(membership, organizationId, userId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*membership = arguments.Get(1).(model.OrganizationMembershipDetails)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *OrganizationRepositoryServiceMock) FetchMembershipsByOrganizationId(
+	memberships *model.OrganizationMemberships, organizationId uint64,
+) error {
+	arguments := service.Called(memberships, organizationId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*memberships = arguments.Get(1).(model.OrganizationMemberships)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *OrganizationRepositoryServiceMock) FetchMembershipsDetailsByUserId(
+	memberships *model.OrganizationMembershipsDetails, userId uint64,
+) error {
+	arguments := service.Called(memberships, userId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*memberships = arguments.Get(1).(model.OrganizationMembershipsDetails)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *OrganizationRepositoryServiceMock) FetchMembershipsDetailsByOrganizationId(
+	memberships *model.OrganizationMembershipsDetails, organizationId uint64,
+) error {
+	arguments := service.Called(memberships, organizationId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*memberships = arguments.Get(1).(model.OrganizationMembershipsDetails)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *OrganizationRepositoryServiceMock) InsertNewMembership(
+	membership *model.OrganizationMembership, organizationKeyVersion int,
+) (db.InsertResult, error) {
+	arguments := service.Called(membership, organizationKeyVersion)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *OrganizationRepositoryServiceMock) UpdateMembershipStatusById(status string, id uint64) error {
+	arguments := service.Called(status, id)
+	return arguments.Error(0)
+}
+
+func (service *OrganizationRepositoryServiceMock) RotateOrganizationKey(
+	organizationId uint64, removedMembershipId uint64, sealedOrganizationKeys map[uint64][]byte, reencryptedPasswords model.Passwords,
+) error {
+	arguments := service.Called(organizationId, removedMembershipId, sealedOrganizationKeys, reencryptedPasswords)
+	return arguments.Error(0)
+}
+
+// DefaultOrganizationMembership is the default user's ownership of the default organization
+func DefaultOrganizationMembership() model.OrganizationMembership {
+	return model.OrganizationMembership{
+		Id:                    DefaultIdAsUint64,
+		OrganizationId:        DefaultIdAsUint64,
+		UserId:                DefaultIdAsUint64,
+		Role:                  model.OrganizationOwner,
+		Status:                model.OrganizationMemberAccepted,
+		SealedOrganizationKey: []byte(MockedSealedOrganizationKey),
+	}
+}
+
+// DefaultOrganizationMemberMembership is an accepted membership of the grantee in the default organization
+func DefaultOrganizationMemberMembership() model.OrganizationMembership {
+	return model.OrganizationMembership{
+		Id:                    uint64(2),
+		OrganizationId:        DefaultIdAsUint64,
+		UserId:                DefaultGranteeIdAsUint64,
+		Role:                  model.OrganizationMember,
+		Status:                model.OrganizationMemberAccepted,
+		SealedOrganizationKey: []byte(MockedSealedOrganizationKey),
+	}
+}
+
+// DefaultOrganizationMembershipDetails are the details of the default user's ownership of the default organization
+func DefaultOrganizationMembershipDetails() model.OrganizationMembershipDetails {
+	return ToOrganizationMembershipDetails(DefaultOrganizationMembership())
+}
+
+// ToOrganizationMembershipDetails joins the membership with the default organization and its member's e-mail address
+func ToOrganizationMembershipDetails(membership model.OrganizationMembership) model.OrganizationMembershipDetails {
+	email := DefaultEmail
+	if membership.UserId == DefaultGranteeIdAsUint64 {
+		email = DefaultGranteeEmail
+	}
+
+	return model.OrganizationMembershipDetails{
+		OrganizationMembership: membership,
+		OrganizationName:       DefaultOrganizationName,
+		OrganizationKeyVersion: DefaultOrganizationKeyVersion,
+		Email:                  email,
+	}
+}
+
+func DefaultOrganizationRepositoryServiceMock() *OrganizationRepositoryServiceMock {
+	ownerDetails := DefaultOrganizationMembershipDetails()
+	memberDetails := ToOrganizationMembershipDetails(DefaultOrganizationMemberMembership())
+
+	serviceMock := new(OrganizationRepositoryServiceMock)
+	serviceMock.On("InsertNewOrganization", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("FetchMembershipDetailsById", mock.Anything, mock.Anything).Return(nil, memberDetails).Times(1)
+	serviceMock.On("FetchMembershipByOrganizationIdAndUserId", mock.Anything, mock.Anything, mock.Anything).
+		Return(nil, ownerDetails).Times(1)
+	serviceMock.On("FetchMembershipsByOrganizationId", mock.Anything, mock.Anything).
+		Return(nil, model.OrganizationMemberships{DefaultOrganizationMembership(), DefaultOrganizationMemberMembership()}).Times(1)
+	serviceMock.On("FetchMembershipsDetailsByUserId", mock.Anything, mock.Anything).
+		Return(nil, model.OrganizationMembershipsDetails{ownerDetails}).Times(1)
+	serviceMock.On("FetchMembershipsDetailsByOrganizationId", mock.Anything, mock.Anything).
+		Return(nil, model.OrganizationMembershipsDetails{ownerDetails, memberDetails}).Times(1)
+	serviceMock.On("InsertNewMembership", mock.Anything, mock.Anything).Return(db.NewInsertResult(int64(2)), nil).Times(1)
+	serviceMock.On("UpdateMembershipStatusById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("RotateOrganizationKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return serviceMock
+}
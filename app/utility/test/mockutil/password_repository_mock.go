@@ -1,6 +1,7 @@
 package mockutil
 
 import (
+	"context"
 	"github.com/KristijanFaust/gokeeper/app/database/model"
 	"github.com/stretchr/testify/mock"
 	"github.com/upper/db/v4"
@@ -20,30 +21,43 @@ func (service *PasswordRepositoryServiceMock) InsertNewPassword(password *model.
 	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
 }
 
-func (service *PasswordRepositoryServiceMock) UpdatePasswordById(name string, password []byte, passwordId uint64) error {
-	arguments := service.Called(name, password, passwordId)
+func (service *PasswordRepositoryServiceMock) UpdatePasswordById(password *model.Password, expectedVersion *int64) error {
+	arguments := service.Called(password, expectedVersion)
+
+	if arguments.Error(0) == nil {
+		password.UserId = DefaultIdAsUint64
+		password.Revision = MockedNextRevision
+		password.Version = MockedNextVersion
+	}
+
 	return arguments.Error(0)
 }
 
-func (service *PasswordRepositoryServiceMock) DeletePasswordById(passwordId uint64) error {
-	arguments := service.Called(passwordId)
+func (service *PasswordRepositoryServiceMock) DeletePasswordById(passwordId uint64, expectedVersion *int64) error {
+	arguments := service.Called(passwordId, expectedVersion)
 	return arguments.Error(0)
 }
 
-func (service *PasswordRepositoryServiceMock) FetchPasswordById(password *model.Password, passwordId uint64) error {
+func (service *PasswordRepositoryServiceMock) FetchPasswordById(ctx context.Context, password *model.Password, passwordId uint64) error {
 	arguments := service.Called(password, passwordId)
 
-	if arguments.Error(0) == nil {
+	if arguments.Error(0) == nil && len(arguments) > 1 {
+		*password = arguments.Get(1).(model.Password)
+	} else if arguments.Error(0) == nil {
 		password.Id = DefaultIdAsUint64
 		password.UserId = DefaultIdAsUint64
 		password.Name = DefaultPasswordName
 		password.Password = []byte(DefaultPassword)
+		password.Revision = DefaultRevision
+		password.Version = DefaultVersion
 	}
 
 	return arguments.Error(0)
 }
 
-func (service *PasswordRepositoryServiceMock) FetchAllByUserId(passwords *model.Passwords, userId uint64, queryFields []string) error {
+func (service *PasswordRepositoryServiceMock) FetchAllByUserId(
+	ctx context.Context, passwords *model.Passwords, userId uint64, queryFields []string,
+) error {
 	arguments := service.Called(passwords, userId, queryFields)
 
 	if arguments.Error(0) == nil && userId == uint64(1) {
@@ -56,13 +70,72 @@ func (service *PasswordRepositoryServiceMock) FetchAllByUserId(passwords *model.
 	return arguments.Error(0)
 }
 
+func (service *PasswordRepositoryServiceMock) FetchAllByOrganizationId(passwords *model.Passwords, organizationId uint64) error {
+	arguments := service.Called(passwords, organizationId)
+
+	if arguments.Error(0) == nil {
+		*passwords = model.Passwords{
+			model.Password{Id: uint64(3), UserId: uint64(1), OrganizationId: &organizationId, Name: "Domain3", Password: []byte("Password3")},
+			model.Password{Id: uint64(4), UserId: uint64(2), OrganizationId: &organizationId, Name: "Domain4", Password: []byte("Password4")},
+		}
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *PasswordRepositoryServiceMock) MovePasswordById(
+	userId uint64, organizationId *uint64, organizationKeyVersion int, password []byte, totpUri []byte, passwordId uint64,
+) (int64, error) {
+	arguments := service.Called(userId, organizationId, organizationKeyVersion, password, totpUri, passwordId)
+	return arguments.Get(0).(int64), arguments.Error(1)
+}
+
+func (service *PasswordRepositoryServiceMock) FetchVaultChanges(changes *model.VaultChanges, userId uint64, sinceRevision int64) error {
+	arguments := service.Called(changes, userId, sinceRevision)
+
+	if arguments.Error(0) == nil && len(arguments) > 1 {
+		*changes = arguments.Get(1).(model.VaultChanges)
+	}
+
+	return arguments.Error(0)
+}
+
+// DefaultVaultChanges are an updated entry and a deleted one after the default revision
+func DefaultVaultChanges() model.VaultChanges {
+	return model.VaultChanges{
+		Revision: DefaultRevision + 2,
+		Passwords: model.Passwords{
+			model.Password{Id: DefaultIdAsUint64, UserId: DefaultIdAsUint64, Name: DefaultPasswordName, Password: []byte(MockedEncryptedPassword), Revision: DefaultRevision + 1},
+		},
+		Tombstones: model.PasswordTombstones{
+			model.PasswordTombstone{PasswordId: uint64(2), UserId: DefaultIdAsUint64, Revision: DefaultRevision + 2},
+		},
+	}
+}
+
+// DefaultSharedPassword is an entry of the default organization's shared vault
+func DefaultSharedPassword() model.Password {
+	organizationId := DefaultIdAsUint64
+	return model.Password{
+		Id:             DefaultIdAsUint64,
+		UserId:         DefaultGranteeIdAsUint64,
+		OrganizationId: &organizationId,
+		Name:           DefaultPasswordName,
+		Password:       []byte(MockedEncryptedPassword),
+		Version:        DefaultVersion,
+	}
+}
+
 func DefaultPasswordRepositoryServiceMock() *PasswordRepositoryServiceMock {
 	serviceMock := new(PasswordRepositoryServiceMock)
 	serviceMock.On("InsertNewPassword", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
-	serviceMock.On("UpdatePasswordById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
-	serviceMock.On("DeletePasswordById", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("UpdatePasswordById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("DeletePasswordById", mock.Anything, mock.Anything).Return(nil).Times(1)
 	serviceMock.On("FetchPasswordById", mock.Anything, mock.Anything).Return(nil).Times(1)
 	serviceMock.On("FetchAllByUserId", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("FetchAllByOrganizationId", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("MovePasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(MockedNextRevision, nil).Times(1)
+	serviceMock.On("FetchVaultChanges", mock.Anything, mock.Anything, mock.Anything).Return(nil, DefaultVaultChanges()).Times(1)
 
 	return serviceMock
 }
@@ -28,11 +28,86 @@ func (service *PasswordSecurityServiceMock) HashWithArgon2id(password string) []
 	return arguments.Get(0).([]byte)
 }
 
+func (service *PasswordSecurityServiceMock) GenerateRecoveryKey() (string, error) {
+	arguments := service.Called()
+	return arguments.String(0), arguments.Error(1)
+}
+
+func (service *PasswordSecurityServiceMock) WrapWithRecoveryKey(vaultKey []byte, recoveryKey string) ([]byte, error) {
+	arguments := service.Called(vaultKey, recoveryKey)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).([]byte), arguments.Error(1)
+}
+
+func (service *PasswordSecurityServiceMock) UnwrapWithRecoveryKey(wrappedVaultKey []byte, recoveryKey string) ([]byte, error) {
+	arguments := service.Called(wrappedVaultKey, recoveryKey)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).([]byte), arguments.Error(1)
+}
+
+func (service *PasswordSecurityServiceMock) GenerateKeyPair(vaultKey []byte) ([]byte, []byte, error) {
+	arguments := service.Called(vaultKey)
+
+	if arguments.Get(0) == nil {
+		return nil, nil, arguments.Error(2)
+	}
+
+	return arguments.Get(0).([]byte), arguments.Get(1).([]byte), arguments.Error(2)
+}
+
+func (service *PasswordSecurityServiceMock) RewrapPrivateKey(wrappedPrivateKey []byte, oldVaultKey []byte, newVaultKey []byte) ([]byte, error) {
+	arguments := service.Called(wrappedPrivateKey, oldVaultKey, newVaultKey)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).([]byte), arguments.Error(1)
+}
+
+func (service *PasswordSecurityServiceMock) SealForPublicKey(message []byte, publicKey []byte) ([]byte, error) {
+	arguments := service.Called(message, publicKey)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).([]byte), arguments.Error(1)
+}
+
+func (service *PasswordSecurityServiceMock) OpenWithPrivateKey(
+	sealedMessage []byte, publicKey []byte, wrappedPrivateKey []byte, vaultKey []byte,
+) ([]byte, error) {
+	arguments := service.Called(sealedMessage, publicKey, wrappedPrivateKey, vaultKey)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).([]byte), arguments.Error(1)
+}
+
 func DefaultPasswordSecurityServiceMock() *PasswordSecurityServiceMock {
 	serviceMock := new(PasswordSecurityServiceMock)
 	serviceMock.On("EncryptWithAes", mock.Anything, mock.Anything).Return([]byte(MockedEncryptedPassword), nil).Times(1)
 	serviceMock.On("DecryptWithAes", mock.Anything, mock.Anything).Return(MockedDecryptedPassword, nil).Times(1)
 	serviceMock.On("HashWithArgon2id", mock.Anything).Return([]byte(MockedUserMasterPassword)).Times(1)
+	serviceMock.On("GenerateRecoveryKey").Return(MockedRecoveryKey, nil).Times(1)
+	serviceMock.On("WrapWithRecoveryKey", mock.Anything, mock.Anything).Return([]byte(MockedRecoveryWrappedKey), nil).Times(1)
+	serviceMock.On("UnwrapWithRecoveryKey", mock.Anything, mock.Anything).Return([]byte(MockedUserMasterPassword), nil).Times(1)
+	serviceMock.On("GenerateKeyPair", mock.Anything).Return([]byte(MockedPublicKey), []byte(MockedWrappedPrivateKey), nil).Times(1)
+	serviceMock.On("RewrapPrivateKey", mock.Anything, mock.Anything, mock.Anything).Return([]byte(MockedWrappedPrivateKey), nil).Times(1)
+	serviceMock.On("SealForPublicKey", mock.Anything, mock.Anything).Return([]byte(MockedSealedVaultKey), nil).Times(1)
+	serviceMock.On("OpenWithPrivateKey", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]byte(MockedUserMasterPassword), nil).Times(1)
 
 	return serviceMock
 }
@@ -0,0 +1,104 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+)
+
+type PasswordShareRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *PasswordShareRepositoryServiceMock) InsertNewShare(share *model.PasswordShare) (db.InsertResult, error) {
+	arguments := service.Called(share)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *PasswordShareRepositoryServiceMock) FetchShareDetailsById(share *model.PasswordShareDetails, id uint64) error {
+	arguments := service.Called(share, id)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*share = arguments.Get(1).(model.PasswordShareDetails)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *PasswordShareRepositoryServiceMock) FetchSharesDetailsByRecipientId(
+	shares *model.PasswordSharesDetails, recipientId uint64,
+) error {
+	arguments := service.Called(shares, recipientId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*shares = arguments.Get(1).(model.PasswordSharesDetails)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *PasswordShareRepositoryServiceMock) FetchSharesDetailsByPasswordId(
+	shares *model.PasswordSharesDetails, passwordId uint64,
+) error {
+	arguments := service.Called(shares, passwordId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*shares = arguments.Get(1).(model.PasswordSharesDetails)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *PasswordShareRepositoryServiceMock) DeleteShareById(id uint64) error {
+	arguments := service.Called(id)
+	return arguments.Error(0)
+}
+
+func (service *PasswordShareRepositoryServiceMock) UpdateSharedPasswordById(
+	password *model.Password, sealedPasswords map[uint64][]byte, expectedVersion *int64,
+) error {
+	arguments := service.Called(password, sealedPasswords, expectedVersion)
+
+	if arguments.Error(0) == nil {
+		password.UserId = DefaultIdAsUint64
+		password.Revision = MockedNextRevision
+		password.Version = MockedNextVersion
+	}
+
+	return arguments.Error(0)
+}
+
+// DefaultPasswordShare is the default user's password shared with the grantee, allowing changes
+func DefaultPasswordShare() model.PasswordShareDetails {
+	return model.PasswordShareDetails{
+		PasswordShare: model.PasswordShare{
+			Id:             DefaultIdAsUint64,
+			PasswordId:     DefaultIdAsUint64,
+			RecipientId:    DefaultGranteeIdAsUint64,
+			Permission:     model.SharePermissionWrite,
+			SealedPassword: []byte(MockedSealedPassword),
+		},
+		OwnerId:        DefaultIdAsUint64,
+		OwnerEmail:     DefaultEmail,
+		RecipientEmail: DefaultGranteeEmail,
+	}
+}
+
+// DefaultPasswordShareRepositoryServiceMock mocks passwords that aren't shared with anyone
+func DefaultPasswordShareRepositoryServiceMock() *PasswordShareRepositoryServiceMock {
+	serviceMock := new(PasswordShareRepositoryServiceMock)
+	serviceMock.On("InsertNewShare", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("FetchShareDetailsById", mock.Anything, mock.Anything).Return(nil, DefaultPasswordShare()).Times(1)
+	serviceMock.On("FetchSharesDetailsByRecipientId", mock.Anything, mock.Anything).
+		Return(nil, model.PasswordSharesDetails{DefaultPasswordShare()}).Times(1)
+	serviceMock.On("FetchSharesDetailsByPasswordId", mock.Anything, mock.Anything).Return(nil, model.PasswordSharesDetails{}).Times(1)
+	serviceMock.On("DeleteShareById", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("UpdateSharedPasswordById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return serviceMock
+}
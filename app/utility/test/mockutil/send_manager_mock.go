@@ -0,0 +1,40 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type SendManagerMock struct {
+	mock.Mock
+}
+
+func (service *SendManagerMock) CreateSend(send *model.Send, secret string, passphrase string) (string, error) {
+	arguments := service.Called(send, secret, passphrase)
+
+	if arguments.Error(1) == nil {
+		send.Id = DefaultIdAsUint64
+		send.HasPassphrase = passphrase != ""
+	}
+
+	return arguments.String(0), arguments.Error(1)
+}
+
+func (service *SendManagerMock) OpenSend(token string, key string, passphrase string) (string, error) {
+	arguments := service.Called(token, key, passphrase)
+	return arguments.String(0), arguments.Error(1)
+}
+
+func (service *SendManagerMock) PurgeUnavailableSends() int {
+	arguments := service.Called()
+	return arguments.Int(0)
+}
+
+func DefaultSendManagerMock() *SendManagerMock {
+	serviceMock := new(SendManagerMock)
+	serviceMock.On("CreateSend", mock.Anything, mock.Anything, mock.Anything).Return(MockedSendLink, nil).Times(1)
+	serviceMock.On("OpenSend", mock.Anything, mock.Anything, mock.Anything).Return(MockedDecryptedPassword, nil).Times(1)
+	serviceMock.On("PurgeUnavailableSends").Return(0)
+
+	return serviceMock
+}
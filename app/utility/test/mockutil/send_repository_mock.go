@@ -0,0 +1,83 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type SendRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *SendRepositoryServiceMock) InsertNewSend(send *model.Send) (db.InsertResult, error) {
+	arguments := service.Called(send)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *SendRepositoryServiceMock) FetchAvailableSendByTokenHash(send *model.Send, tokenHash []byte, now time.Time) error {
+	arguments := service.Called(send, tokenHash, now)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*send = arguments.Get(1).(model.Send)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *SendRepositoryServiceMock) FetchAvailableSendsByUserId(sends *model.Sends, userId uint64, now time.Time) error {
+	arguments := service.Called(sends, userId, now)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*sends = arguments.Get(1).(model.Sends)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *SendRepositoryServiceMock) ConsumeSendViewById(id uint64, now time.Time) error {
+	arguments := service.Called(id, now)
+	return arguments.Error(0)
+}
+
+func (service *SendRepositoryServiceMock) DeleteSendByIdAndUserId(id uint64, userId uint64) error {
+	arguments := service.Called(id, userId)
+	return arguments.Error(0)
+}
+
+func (service *SendRepositoryServiceMock) DeleteUnavailableSends(now time.Time) (int64, error) {
+	arguments := service.Called(now)
+	return arguments.Get(0).(int64), arguments.Error(1)
+}
+
+// DefaultSend is a send of the default user that can be viewed once
+func DefaultSend() model.Send {
+	createdAt := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return model.Send{
+		Id:              DefaultIdAsUint64,
+		UserId:          DefaultIdAsUint64,
+		Name:            DefaultSendName,
+		EncryptedSecret: []byte(MockedEncryptedPassword),
+		MaxViews:        1,
+		ExpiresAt:       createdAt.Add(24 * time.Hour),
+		CreatedAt:       createdAt,
+	}
+}
+
+func DefaultSendRepositoryServiceMock() *SendRepositoryServiceMock {
+	serviceMock := new(SendRepositoryServiceMock)
+	serviceMock.On("InsertNewSend", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("FetchAvailableSendByTokenHash", mock.Anything, mock.Anything, mock.Anything).Return(nil, DefaultSend()).Times(1)
+	serviceMock.On("FetchAvailableSendsByUserId", mock.Anything, mock.Anything, mock.Anything).Return(nil, model.Sends{DefaultSend()}).Times(1)
+	serviceMock.On("ConsumeSendViewById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("DeleteSendByIdAndUserId", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("DeleteUnavailableSends", mock.Anything).Return(int64(0), nil)
+
+	return serviceMock
+}
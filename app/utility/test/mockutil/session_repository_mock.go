@@ -0,0 +1,90 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type SessionRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *SessionRepositoryServiceMock) InsertNewSession(session *model.Session) (db.InsertResult, error) {
+	arguments := service.Called(session)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *SessionRepositoryServiceMock) FetchSessionById(session *model.Session, id uint64) error {
+	arguments := service.Called(session, id)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*session = arguments.Get(1).(model.Session)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *SessionRepositoryServiceMock) FetchSessionsByUserId(sessions *model.Sessions, userId uint64) error {
+	arguments := service.Called(sessions, userId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*sessions = arguments.Get(1).(model.Sessions)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *SessionRepositoryServiceMock) UpdateSessionStatusById(currentStatus string, newStatus string, id uint64) error {
+	arguments := service.Called(currentStatus, newStatus, id)
+	return arguments.Error(0)
+}
+
+func (service *SessionRepositoryServiceMock) RevokeSessionById(id uint64) error {
+	arguments := service.Called(id)
+	return arguments.Error(0)
+}
+
+func (service *SessionRepositoryServiceMock) RevokeSessionsByUserId(userId uint64) error {
+	arguments := service.Called(userId)
+	return arguments.Error(0)
+}
+
+func (service *SessionRepositoryServiceMock) TouchActiveSessionById(lastSeenAt time.Time, id uint64, userId uint64) error {
+	arguments := service.Called(lastSeenAt, id, userId)
+	return arguments.Error(0)
+}
+
+// DefaultSession is an active session of the default user
+func DefaultSession() model.Session {
+	createdAt := time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC)
+	return model.Session{
+		Id:         DefaultIdAsUint64,
+		UserId:     DefaultIdAsUint64,
+		DeviceName: DefaultDeviceName,
+		UserAgent:  DefaultUserAgent,
+		IpAddress:  DefaultIpAddress,
+		Status:     model.SessionActive,
+		CreatedAt:  createdAt,
+		LastSeenAt: createdAt,
+	}
+}
+
+func DefaultSessionRepositoryServiceMock() *SessionRepositoryServiceMock {
+	serviceMock := new(SessionRepositoryServiceMock)
+	serviceMock.On("InsertNewSession", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("FetchSessionById", mock.Anything, mock.Anything).Return(nil, DefaultSession()).Times(1)
+	serviceMock.On("FetchSessionsByUserId", mock.Anything, mock.Anything).Return(nil, model.Sessions{DefaultSession()}).Times(1)
+	serviceMock.On("UpdateSessionStatusById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("RevokeSessionById", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("RevokeSessionsByUserId", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("TouchActiveSessionById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return serviceMock
+}
@@ -0,0 +1,48 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/stretchr/testify/mock"
+)
+
+// UnitOfWorkMock runs the work with its repositories right away, the mocked error stands for a failed commit
+type UnitOfWorkMock struct {
+	mock.Mock
+	Repositories *repository.Repositories
+}
+
+func (unitOfWork *UnitOfWorkMock) Execute(work func(repositories *repository.Repositories) error) error {
+	arguments := unitOfWork.Called(work)
+	if err := work(unitOfWork.Repositories); err != nil {
+		return err
+	}
+	return arguments.Error(0)
+}
+
+// NewUnitOfWorkMock commits any number of units of work done with the given repositories
+func NewUnitOfWorkMock(repositories *repository.Repositories) *UnitOfWorkMock {
+	unitOfWorkMock := &UnitOfWorkMock{Repositories: repositories}
+	unitOfWorkMock.On("Execute", mock.Anything).Return(nil)
+
+	return unitOfWorkMock
+}
+
+// DefaultUnitOfWorkMock commits any number of units of work done with the default repository mocks
+func DefaultUnitOfWorkMock() *UnitOfWorkMock {
+	return NewUnitOfWorkMock(&repository.Repositories{
+		User:            DefaultUserRepositoryServiceMock(),
+		Password:        DefaultPasswordRepositoryServiceMock(),
+		Webauthn:        DefaultWebauthnRepositoryServiceMock(),
+		KnownDevice:     DefaultKnownDeviceRepositoryServiceMock(),
+		KeyPair:         DefaultKeyPairRepositoryServiceMock(),
+		EmergencyAccess: DefaultEmergencyAccessRepositoryServiceMock(),
+		Session:         DefaultSessionRepositoryServiceMock(),
+		Organization:    DefaultOrganizationRepositoryServiceMock(),
+		PasswordShare:   DefaultPasswordShareRepositoryServiceMock(),
+		Send:            DefaultSendRepositoryServiceMock(),
+		Admin:           DefaultAdminRepositoryServiceMock(),
+		AuditEvent:      DefaultAuditEventRepositoryServiceMock(),
+		Webhook:         DefaultWebhookRepositoryServiceMock(),
+		Attachment:      DefaultAttachmentRepositoryServiceMock(),
+	})
+}
@@ -1,6 +1,7 @@
 package mockutil
 
 import (
+	"context"
 	"github.com/KristijanFaust/gokeeper/app/database/model"
 	"github.com/stretchr/testify/mock"
 	"github.com/upper/db/v4"
@@ -20,7 +21,7 @@ func (service *UserRepositoryServiceMock) InsertNewUser(user *model.User) (db.In
 	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
 }
 
-func (service *UserRepositoryServiceMock) FetchByEmail(user *model.User, email string, queryFields []string) error {
+func (service *UserRepositoryServiceMock) FetchByEmail(ctx context.Context, user *model.User, email string, queryFields []string) error {
 	arguments := service.Called(user, email, queryFields)
 
 	if arguments.Error(0) == nil {
@@ -28,6 +29,25 @@ func (service *UserRepositoryServiceMock) FetchByEmail(user *model.User, email s
 		user.Email = email
 		user.Username = "username"
 		user.Password = []byte(MockedUserMasterPassword)
+		user.RecoveryWrappedKey = []byte(MockedRecoveryWrappedKey)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *UserRepositoryServiceMock) FetchById(user *model.User, id uint64, queryFields []string) error {
+	arguments := service.Called(user, id, queryFields)
+
+	if arguments.Error(0) == nil {
+		user.Id = id
+		user.Email = DefaultEmail
+		user.Username = DefaultUsername
+		user.Password = []byte(MockedUserMasterPassword)
+		if len(arguments) > 1 {
+			fetchedUser := arguments.Get(1).(model.User)
+			user.Role = fetchedUser.Role
+			user.Disabled = fetchedUser.Disabled
+		}
 	}
 
 	return arguments.Error(0)
@@ -43,11 +63,72 @@ func (service *UserRepositoryServiceMock) FetchMasterPasswordByUserId(user *mode
 	return arguments.Error(0)
 }
 
+func (service *UserRepositoryServiceMock) UpdateEmailVerifiedById(emailVerified bool, id uint64) error {
+	arguments := service.Called(emailVerified, id)
+	return arguments.Error(0)
+}
+
+func (service *UserRepositoryServiceMock) InsertEmailVerificationToken(token *model.EmailVerificationToken) (db.InsertResult, error) {
+	arguments := service.Called(token)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *UserRepositoryServiceMock) ConsumeEmailVerificationToken(token *model.EmailVerificationToken, tokenHash []byte) error {
+	arguments := service.Called(token, tokenHash)
+
+	if arguments.Error(0) == nil {
+		token.Id = uint64(1)
+		token.UserId = uint64(1)
+		token.TokenHash = tokenHash
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *UserRepositoryServiceMock) UpdateRecoveryWrappedKeyById(recoveryWrappedKey []byte, id uint64) error {
+	arguments := service.Called(recoveryWrappedKey, id)
+	return arguments.Error(0)
+}
+
+func (service *UserRepositoryServiceMock) UpdateMasterPasswordById(
+	masterPassword []byte,
+	recoveryWrappedKey []byte,
+	wrappedPrivateKey []byte,
+	reencryptedPasswords model.Passwords,
+	rewrappedAttachments model.Attachments,
+	id uint64,
+) error {
+	arguments := service.Called(masterPassword, recoveryWrappedKey, wrappedPrivateKey, reencryptedPasswords, rewrappedAttachments, id)
+	return arguments.Error(0)
+}
+
+func (service *UserRepositoryServiceMock) InsertAccountRecovery(recovery *model.AccountRecovery) (db.InsertResult, error) {
+	arguments := service.Called(recovery)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
 func DefaultUserRepositoryServiceMock() *UserRepositoryServiceMock {
 	serviceMock := new(UserRepositoryServiceMock)
 	serviceMock.On("InsertNewUser", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
 	serviceMock.On("FetchByEmail", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("FetchById", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
 	serviceMock.On("FetchMasterPasswordByUserId", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("UpdateEmailVerifiedById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("InsertEmailVerificationToken", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("ConsumeEmailVerificationToken", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("UpdateRecoveryWrappedKeyById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("UpdateMasterPasswordById", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("InsertAccountRecovery", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
 
 	return serviceMock
 }
@@ -0,0 +1,28 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/eventbus"
+	"github.com/stretchr/testify/mock"
+)
+
+type VaultEventBusMock struct {
+	mock.Mock
+}
+
+func (bus *VaultEventBusMock) Publish(event eventbus.Event) {
+	bus.Called(event)
+}
+
+func (bus *VaultEventBusMock) Subscribe(userId uint64) (<-chan eventbus.Event, func()) {
+	arguments := bus.Called(userId)
+	return arguments.Get(0).(<-chan eventbus.Event), arguments.Get(1).(func())
+}
+
+// DefaultVaultEventBusMock publishes any number of events and subscribes to a channel that never receives any
+func DefaultVaultEventBusMock() *VaultEventBusMock {
+	busMock := new(VaultEventBusMock)
+	busMock.On("Publish", mock.Anything).Return()
+	busMock.On("Subscribe", mock.Anything).Return(make(<-chan eventbus.Event), func() {})
+
+	return busMock
+}
@@ -0,0 +1,158 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/webauthn"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type WebauthnRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *WebauthnRepositoryServiceMock) InsertNewCredential(credential *model.WebauthnCredential) (db.InsertResult, error) {
+	arguments := service.Called(credential)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *WebauthnRepositoryServiceMock) FetchCredentialsByUserId(credentials *model.WebauthnCredentials, userId uint64) error {
+	arguments := service.Called(credentials, userId)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*credentials = arguments.Get(1).(model.WebauthnCredentials)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *WebauthnRepositoryServiceMock) FetchCredentialByCredentialId(credential *model.WebauthnCredential, credentialId []byte) error {
+	arguments := service.Called(credential, credentialId)
+
+	if arguments.Error(0) == nil {
+		*credential = DefaultWebauthnCredential()
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *WebauthnRepositoryServiceMock) UpdateSignCountById(signCount uint32, id uint64) error {
+	arguments := service.Called(signCount, id)
+	return arguments.Error(0)
+}
+
+func (service *WebauthnRepositoryServiceMock) InsertNewChallenge(challenge *model.WebauthnChallenge) (db.InsertResult, error) {
+	arguments := service.Called(challenge)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *WebauthnRepositoryServiceMock) ConsumeChallenge(challenge *model.WebauthnChallenge, value []byte, ceremony string) error {
+	arguments := service.Called(challenge, value, ceremony)
+
+	if arguments.Error(0) == nil {
+		challenge.Id = DefaultIdAsUint64
+		challenge.UserId = DefaultIdAsUint64
+		challenge.Challenge = value
+		challenge.Ceremony = ceremony
+		challenge.ExpiresAt = time.Now().Add(time.Minute)
+	}
+
+	return arguments.Error(0)
+}
+
+func DefaultWebauthnCredential() model.WebauthnCredential {
+	return model.WebauthnCredential{
+		Id:            DefaultIdAsUint64,
+		UserId:        DefaultIdAsUint64,
+		CredentialId:  []byte(MockedWebauthnCredentialId),
+		PublicKey:     []byte(MockedWebauthnPublicKey),
+		Name:          DefaultWebauthnCredentialName,
+		PrfWrappedKey: []byte(MockedPrfWrappedKey),
+	}
+}
+
+// DefaultWebauthnRepositoryServiceMock mocks a user without registered credentials, so sign in doesn't require a second factor
+func DefaultWebauthnRepositoryServiceMock() *WebauthnRepositoryServiceMock {
+	serviceMock := new(WebauthnRepositoryServiceMock)
+	serviceMock.On("InsertNewCredential", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("FetchCredentialsByUserId", mock.Anything, mock.Anything).Return(nil, nil).Times(1)
+	serviceMock.On("FetchCredentialByCredentialId", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("UpdateSignCountById", mock.Anything, mock.Anything).Return(nil).Times(1)
+	serviceMock.On("InsertNewChallenge", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("ConsumeChallenge", mock.Anything, mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return serviceMock
+}
+
+type WebauthnVerifierMock struct {
+	mock.Mock
+}
+
+func (service *WebauthnVerifierMock) GenerateChallenge() ([]byte, error) {
+	arguments := service.Called()
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).([]byte), arguments.Error(1)
+}
+
+func (service *WebauthnVerifierMock) ChallengeTimeout() time.Duration {
+	return time.Minute
+}
+
+func (service *WebauthnVerifierMock) RelyingPartyId() string {
+	return "localhost"
+}
+
+func (service *WebauthnVerifierMock) RelyingPartyName() string {
+	return "GoKeeper"
+}
+
+func (service *WebauthnVerifierMock) VerifyRegistration(
+	challenge []byte, clientDataJson []byte, attestationObject []byte,
+) (*webauthn.RegisteredCredential, error) {
+	arguments := service.Called(challenge, clientDataJson, attestationObject)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+
+	return arguments.Get(0).(*webauthn.RegisteredCredential), arguments.Error(1)
+}
+
+func (service *WebauthnVerifierMock) VerifyAssertion(
+	challenge []byte, clientDataJson []byte, authenticatorData []byte, signature []byte,
+	publicKey []byte, storedSignCount uint32, requireUserVerification bool,
+) (uint32, error) {
+	arguments := service.Called(challenge, clientDataJson, authenticatorData, signature, publicKey, storedSignCount, requireUserVerification)
+	return arguments.Get(0).(uint32), arguments.Error(1)
+}
+
+func DefaultWebauthnVerifierMock() *WebauthnVerifierMock {
+	serviceMock := new(WebauthnVerifierMock)
+	serviceMock.On("GenerateChallenge").Return([]byte(MockedWebauthnChallenge), nil).Times(1)
+	serviceMock.On("VerifyRegistration", mock.Anything, mock.Anything, mock.Anything).Return(
+		&webauthn.RegisteredCredential{
+			CredentialId: []byte(MockedWebauthnCredentialId),
+			PublicKey:    []byte(MockedWebauthnPublicKey),
+		}, nil,
+	).Times(1)
+	serviceMock.On(
+		"VerifyAssertion", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything,
+	).Return(uint32(1), nil).Times(1)
+
+	return serviceMock
+}
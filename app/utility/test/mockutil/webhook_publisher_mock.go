@@ -0,0 +1,21 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+)
+
+type WebhookPublisherMock struct {
+	mock.Mock
+}
+
+func (service *WebhookPublisherMock) Publish(event *model.AuditEvent) {
+	service.Called(event)
+}
+
+func DefaultWebhookPublisherMock() *WebhookPublisherMock {
+	serviceMock := new(WebhookPublisherMock)
+	serviceMock.On("Publish", mock.Anything).Return()
+
+	return serviceMock
+}
@@ -0,0 +1,144 @@
+package mockutil
+
+import (
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/stretchr/testify/mock"
+	"github.com/upper/db/v4"
+	"time"
+)
+
+type WebhookRepositoryServiceMock struct {
+	mock.Mock
+}
+
+func (service *WebhookRepositoryServiceMock) InsertNewWebhook(webhook *model.Webhook) (db.InsertResult, error) {
+	arguments := service.Called(webhook)
+
+	if arguments.Get(0) == nil {
+		return nil, arguments.Error(1)
+	}
+	return arguments.Get(0).(db.InsertResult), arguments.Error(1)
+}
+
+func (service *WebhookRepositoryServiceMock) FetchWebhooks(webhooks *model.Webhooks) error {
+	arguments := service.Called(webhooks)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*webhooks = arguments.Get(1).(model.Webhooks)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *WebhookRepositoryServiceMock) FetchWebhooksByEventType(webhooks *model.Webhooks, eventType string) error {
+	arguments := service.Called(webhooks, eventType)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*webhooks = arguments.Get(1).(model.Webhooks)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *WebhookRepositoryServiceMock) DeleteWebhookById(id uint64) error {
+	arguments := service.Called(id)
+	return arguments.Error(0)
+}
+
+func (service *WebhookRepositoryServiceMock) InsertNewDeliveries(deliveries model.WebhookDeliveries) error {
+	arguments := service.Called(deliveries)
+	return arguments.Error(0)
+}
+
+func (service *WebhookRepositoryServiceMock) FetchDueDeliveries(deliveries *model.WebhookDeliveries, now time.Time, limit int) error {
+	arguments := service.Called(deliveries, now, limit)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*deliveries = arguments.Get(1).(model.WebhookDeliveries)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *WebhookRepositoryServiceMock) FetchDeliveriesByWebhookId(
+	deliveries *model.WebhookDeliveries, webhookId uint64, offset int, limit int,
+) error {
+	arguments := service.Called(deliveries, webhookId, offset, limit)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*deliveries = arguments.Get(1).(model.WebhookDeliveries)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *WebhookRepositoryServiceMock) UpdateDeliveryAttempt(delivery *model.WebhookDelivery) error {
+	arguments := service.Called(delivery)
+	return arguments.Error(0)
+}
+
+func (service *WebhookRepositoryServiceMock) MoveDeliveryToDeadLetter(delivery *model.WebhookDelivery) error {
+	arguments := service.Called(delivery)
+	return arguments.Error(0)
+}
+
+func (service *WebhookRepositoryServiceMock) FetchDeadLetters(deadLetters *model.WebhookDeadLetters, offset int, limit int) error {
+	arguments := service.Called(deadLetters, offset, limit)
+
+	if arguments.Error(0) == nil && arguments.Get(1) != nil {
+		*deadLetters = arguments.Get(1).(model.WebhookDeadLetters)
+	}
+
+	return arguments.Error(0)
+}
+
+func (service *WebhookRepositoryServiceMock) RedeliverDeadLetterById(id uint64, now time.Time) error {
+	arguments := service.Called(id, now)
+	return arguments.Error(0)
+}
+
+// DefaultWebhook is a webhook of the default user subscribed to sign ins
+func DefaultWebhook() model.Webhook {
+	return model.Webhook{
+		Id:         DefaultIdAsUint64,
+		CreatedBy:  DefaultIdAsUint64,
+		Url:        "http://localhost:9000/hooks",
+		Secret:     []byte("webhookSecret"),
+		EventTypes: []string{model.AuditEventSignIn},
+		CreatedAt:  time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// DefaultWebhookDelivery is a pending delivery of the default webhook
+func DefaultWebhookDelivery() model.WebhookDelivery {
+	return model.WebhookDelivery{
+		Id:            DefaultIdAsUint64,
+		WebhookId:     DefaultIdAsUint64,
+		EventType:     model.AuditEventSignIn,
+		Payload:       `{"event":"sign_in"}`,
+		Status:        model.WebhookDeliveryPending,
+		NextAttemptAt: time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		CreatedAt:     time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func DefaultWebhookRepositoryServiceMock() *WebhookRepositoryServiceMock {
+	serviceMock := new(WebhookRepositoryServiceMock)
+	serviceMock.On("InsertNewWebhook", mock.Anything).Return(db.NewInsertResult(int64(1)), nil).Times(1)
+	serviceMock.On("FetchWebhooks", mock.Anything).Return(nil, model.Webhooks{DefaultWebhook()}).Times(1)
+	serviceMock.On("FetchWebhooksByEventType", mock.Anything, mock.Anything).Return(nil, model.Webhooks{DefaultWebhook()}).Times(1)
+	serviceMock.On("DeleteWebhookById", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("InsertNewDeliveries", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("FetchDueDeliveries", mock.Anything, mock.Anything, mock.Anything).Return(
+		nil, model.WebhookDeliveries{DefaultWebhookDelivery()},
+	).Times(1)
+	serviceMock.On("FetchDeliveriesByWebhookId", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(
+		nil, model.WebhookDeliveries{DefaultWebhookDelivery()},
+	).Times(1)
+	serviceMock.On("UpdateDeliveryAttempt", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("MoveDeliveryToDeadLetter", mock.Anything).Return(nil).Times(1)
+	serviceMock.On("FetchDeadLetters", mock.Anything, mock.Anything, mock.Anything).Return(nil, model.WebhookDeadLetters{}).Times(1)
+	serviceMock.On("RedeliverDeadLetterById", mock.Anything, mock.Anything).Return(nil).Times(1)
+
+	return serviceMock
+}
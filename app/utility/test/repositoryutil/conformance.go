@@ -0,0 +1,951 @@
+package repositoryutil
+
+import (
+	"context"
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/suite"
+	"time"
+)
+
+// ConformanceSuite is the behaviour every implementation of the repositories has to share, whatever stores their records.
+// The repositories are shared by all tests, so every test works with records of its own.
+type ConformanceSuite struct {
+	suite.Suite
+	// SetupRepositories returns the repositories under test, or false if their storage isn't available
+	SetupRepositories func() (*repository.Repositories, bool)
+	// TearDownRepositories releases the storage of the repositories, if it needs to be released
+	TearDownRepositories func()
+	repositories         *repository.Repositories
+	isStorageReady       bool
+}
+
+func (suite *ConformanceSuite) SetupSuite() {
+	suite.repositories, suite.isStorageReady = suite.SetupRepositories()
+}
+
+func (suite *ConformanceSuite) TearDownSuite() {
+	if suite.TearDownRepositories != nil {
+		suite.TearDownRepositories()
+	}
+}
+
+func (suite *ConformanceSuite) SetupTest() {
+	if !suite.isStorageReady {
+		suite.T().Skip("Skipping test since the storage of the repositories is not ready")
+	}
+}
+
+// InsertNewUser should store the user with the default role, and both fetches should return only the requested fields
+func (suite *ConformanceSuite) TestInsertNewUserAndFetchUser() {
+	email := uniqueEmail()
+	insertResult, err := suite.repositories.User.InsertNewUser(
+		&model.User{Email: email, Username: "conformance", Password: []byte("masterPassword")},
+	)
+	assert.Nil(suite.T(), err)
+	userId := uint64(insertResult.ID().(int64))
+
+	user := model.User{}
+	err = suite.repositories.User.FetchById(&user, userId, nil)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), user.Email, email)
+	assert.Equal(suite.T(), user.Username, "conformance")
+	assert.Equal(suite.T(), user.Password, []byte("masterPassword"))
+	assert.Equal(suite.T(), user.Role, model.UserRoleUser, "Users should get the user role by default")
+
+	user = model.User{}
+	err = suite.repositories.User.FetchByEmail(context.Background(), &user, email, []string{"id", "username"})
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), user.Id, userId)
+	assert.Equal(suite.T(), user.Username, "conformance")
+	assert.Empty(suite.T(), user.Email, "Fields that weren't requested should be left empty")
+	assert.Nil(suite.T(), user.Password, "Fields that weren't requested should be left empty")
+
+	user = model.User{}
+	err = suite.repositories.User.FetchMasterPasswordByUserId(&user, userId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), user.Password, []byte("masterPassword"))
+	assert.Empty(suite.T(), user.Email)
+}
+
+// InsertNewUser should reject e-mail addresses that are taken already
+func (suite *ConformanceSuite) TestInsertNewUserWithDuplicateEmail() {
+	email := uniqueEmail()
+	_, err := suite.repositories.User.InsertNewUser(&model.User{Email: email, Username: "first", Password: []byte("password")})
+	assert.Nil(suite.T(), err)
+
+	_, err = suite.repositories.User.InsertNewUser(&model.User{Email: email, Username: "second", Password: []byte("password")})
+	assert.Equal(suite.T(), err, repository.ErrDuplicate, "Should not store a second user with the same e-mail address")
+}
+
+// FetchById and FetchByEmail should return repository.ErrNotFound for users that don't exist
+func (suite *ConformanceSuite) TestFetchNonexistentUser() {
+	err := suite.repositories.User.FetchById(&model.User{}, 0, nil)
+	assert.Equal(suite.T(), err, repository.ErrNotFound)
+
+	err = suite.repositories.User.FetchByEmail(context.Background(), &model.User{}, uniqueEmail(), nil)
+	assert.Equal(suite.T(), err, repository.ErrNotFound)
+}
+
+// ConsumeEmailVerificationToken should consume an unexpired token only once
+func (suite *ConformanceSuite) TestConsumeEmailVerificationToken() {
+	userId := suite.insertUser()
+	tokenHash := []byte(uuid.New().String())
+	expiredTokenHash := []byte(uuid.New().String())
+	suite.repositories.User.InsertEmailVerificationToken(
+		&model.EmailVerificationToken{UserId: userId, TokenHash: tokenHash, ExpiresAt: time.Now().UTC().Add(time.Hour)},
+	)
+	suite.repositories.User.InsertEmailVerificationToken(
+		&model.EmailVerificationToken{UserId: userId, TokenHash: expiredTokenHash, ExpiresAt: time.Now().UTC().Add(-time.Hour)},
+	)
+
+	token := model.EmailVerificationToken{}
+	err := suite.repositories.User.ConsumeEmailVerificationToken(&token, tokenHash)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), token.UserId, userId)
+
+	err = suite.repositories.User.ConsumeEmailVerificationToken(&model.EmailVerificationToken{}, tokenHash)
+	assert.Equal(suite.T(), err, repository.ErrNotFound, "Tokens should be usable only once")
+	err = suite.repositories.User.ConsumeEmailVerificationToken(&model.EmailVerificationToken{}, expiredTokenHash)
+	assert.Equal(suite.T(), err, repository.ErrNotFound, "Expired tokens should not be usable")
+}
+
+// UpdateMasterPasswordById should replace the master password, the private key and the given entries and attachment
+// keys of the user only
+func (suite *ConformanceSuite) TestUpdateMasterPasswordById() {
+	userId := suite.insertUser()
+	otherUserId := suite.insertUser()
+	suite.repositories.KeyPair.InsertNewKeyPair(
+		&model.KeyPair{UserId: userId, PublicKey: []byte("publicKey"), WrappedPrivateKey: []byte("wrappedPrivateKey")},
+	)
+	passwordId := suite.insertPassword(userId)
+	otherPasswordId := suite.insertPassword(otherUserId)
+	attachmentId := suite.insertAttachment(userId, passwordId)
+	otherAttachmentId := suite.insertAttachment(otherUserId, otherPasswordId)
+
+	err := suite.repositories.User.UpdateMasterPasswordById(
+		[]byte("newMasterPassword"), []byte("newRecoveryKey"), []byte("newWrappedPrivateKey"),
+		model.Passwords{
+			{Id: passwordId, Password: []byte("reencrypted"), TotpUri: []byte("reencryptedTotpUri")},
+			{Id: otherPasswordId, Password: []byte("reencrypted")},
+		},
+		model.Attachments{
+			{Id: attachmentId, WrappedDataKey: []byte("rewrappedDataKey")},
+			{Id: otherAttachmentId, WrappedDataKey: []byte("rewrappedDataKey")},
+		},
+		userId,
+	)
+	assert.Nil(suite.T(), err)
+
+	user := model.User{}
+	suite.repositories.User.FetchById(&user, userId, nil)
+	assert.Equal(suite.T(), user.Password, []byte("newMasterPassword"))
+	assert.Equal(suite.T(), user.RecoveryWrappedKey, []byte("newRecoveryKey"))
+	keyPair := model.KeyPair{}
+	suite.repositories.KeyPair.FetchKeyPairByUserId(&keyPair, userId)
+	assert.Equal(suite.T(), keyPair.WrappedPrivateKey, []byte("newWrappedPrivateKey"))
+
+	password := model.Password{}
+	suite.repositories.Password.FetchPasswordById(context.Background(), &password, passwordId)
+	assert.Equal(suite.T(), password.Password, []byte("reencrypted"))
+	assert.Equal(suite.T(), password.TotpUri, []byte("reencryptedTotpUri"))
+	suite.repositories.Password.FetchPasswordById(context.Background(), &password, otherPasswordId)
+	assert.Equal(suite.T(), password.Password, []byte("password"), "Entries of other users should not change")
+
+	attachment := model.Attachment{}
+	suite.repositories.Attachment.FetchAttachmentById(&attachment, attachmentId)
+	assert.Equal(suite.T(), attachment.WrappedDataKey, []byte("rewrappedDataKey"))
+	suite.repositories.Attachment.FetchAttachmentById(&attachment, otherAttachmentId)
+	assert.Equal(suite.T(), attachment.WrappedDataKey, []byte("wrappedDataKey"), "Attachments of other users should not change")
+}
+
+// InsertNewPassword should store the first version of the entry with the next revision of its owner's vault
+func (suite *ConformanceSuite) TestInsertNewPassword() {
+	userId := suite.insertUser()
+	firstPasswordId := suite.insertPassword(userId)
+
+	newPassword := &model.Password{UserId: userId, Name: "second", Password: []byte("password"), TotpUri: []byte("totpUri")}
+	insertResult, err := suite.repositories.Password.InsertNewPassword(newPassword)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), newPassword.Version, int64(1))
+	assert.Equal(suite.T(), newPassword.Revision, int64(2))
+
+	password := model.Password{}
+	err = suite.repositories.Password.FetchPasswordById(context.Background(), &password, uint64(insertResult.ID().(int64)))
+	assert.Nil(suite.T(), err)
+	assert.NotEqual(suite.T(), password.Id, firstPasswordId)
+	assert.Equal(suite.T(), password.UserId, userId)
+	assert.Equal(suite.T(), password.Name, "second")
+	assert.Equal(suite.T(), password.Password, []byte("password"))
+	assert.Equal(suite.T(), password.TotpUri, []byte("totpUri"))
+	assert.Nil(suite.T(), password.OrganizationId)
+	assert.Equal(suite.T(), password.Revision, int64(2))
+
+	_, err = suite.repositories.Password.InsertNewPassword(&model.Password{UserId: 0, Name: "orphan", Password: []byte("password")})
+	assert.NotNil(suite.T(), err, "Should not store entries of users that don't exist")
+}
+
+// UpdatePasswordById should update the entry only if it's still at the expected version, and reload it
+func (suite *ConformanceSuite) TestUpdatePasswordById() {
+	userId := suite.insertUser()
+	passwordId := suite.insertPassword(userId)
+
+	expectedVersion := int64(1)
+	password := &model.Password{Id: passwordId, Name: "updated", Password: []byte("updatedPassword")}
+	err := suite.repositories.Password.UpdatePasswordById(password, &expectedVersion)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), password.UserId, userId, "Should reload the whole entry")
+	assert.Equal(suite.T(), password.Version, int64(2))
+	assert.Equal(suite.T(), password.Revision, int64(2))
+
+	err = suite.repositories.Password.UpdatePasswordById(
+		&model.Password{Id: passwordId, Name: "stale", Password: []byte("stalePassword")}, &expectedVersion,
+	)
+	assert.Equal(suite.T(), err, &repository.VersionConflictError{CurrentVersion: 2})
+	assert.True(suite.T(), errors.Is(err, repository.ErrConflict), "Version conflicts should match ErrConflict")
+
+	err = suite.repositories.Password.UpdatePasswordById(&model.Password{Id: passwordId, Name: "forced", Password: []byte("forced")}, nil)
+	assert.Nil(suite.T(), err, "Should update the entry unconditionally without an expected version")
+
+	fetchedPassword := model.Password{}
+	suite.repositories.Password.FetchPasswordById(context.Background(), &fetchedPassword, passwordId)
+	assert.Equal(suite.T(), fetchedPassword.Name, "forced")
+	assert.Equal(suite.T(), fetchedPassword.Version, int64(3))
+
+	err = suite.repositories.Password.UpdatePasswordById(&model.Password{Id: 0, Name: "missing"}, nil)
+	assert.Equal(suite.T(), err, repository.ErrNotFound)
+}
+
+// DeletePasswordById should delete the entry only if it's still at the expected version, leaving a tombstone in the vault
+func (suite *ConformanceSuite) TestDeletePasswordById() {
+	userId := suite.insertUser()
+	passwordId := suite.insertPassword(userId)
+
+	staleVersion := int64(2)
+	err := suite.repositories.Password.DeletePasswordById(passwordId, &staleVersion)
+	assert.Equal(suite.T(), err, &repository.VersionConflictError{CurrentVersion: 1})
+
+	expectedVersion := int64(1)
+	err = suite.repositories.Password.DeletePasswordById(passwordId, &expectedVersion)
+	assert.Nil(suite.T(), err)
+
+	err = suite.repositories.Password.FetchPasswordById(context.Background(), &model.Password{}, passwordId)
+	assert.Equal(suite.T(), err, repository.ErrNotFound)
+	err = suite.repositories.Password.DeletePasswordById(passwordId, nil)
+	assert.Equal(suite.T(), err, repository.ErrNotFound)
+
+	changes := model.VaultChanges{}
+	suite.repositories.Password.FetchVaultChanges(&changes, userId, 1)
+	assert.Equal(suite.T(), changes.Revision, int64(2))
+	assert.Empty(suite.T(), changes.Passwords)
+	assert.Len(suite.T(), changes.Tombstones, 1)
+	assert.Equal(suite.T(), changes.Tombstones[0].PasswordId, passwordId)
+	assert.Equal(suite.T(), changes.Tombstones[0].Revision, int64(2))
+}
+
+// FetchVaultChanges should return only the changes after the given revision, in order
+func (suite *ConformanceSuite) TestFetchVaultChanges() {
+	userId := suite.insertUser()
+	firstPasswordId := suite.insertPassword(userId)
+	secondPasswordId := suite.insertPassword(userId)
+	suite.repositories.Password.UpdatePasswordById(&model.Password{Id: firstPasswordId, Name: "updated", Password: []byte("updated")}, nil)
+
+	changes := model.VaultChanges{}
+	err := suite.repositories.Password.FetchVaultChanges(&changes, userId, 1)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), changes.Revision, int64(3))
+	assert.Len(suite.T(), changes.Passwords, 2)
+	assert.Equal(suite.T(), changes.Passwords[0].Id, secondPasswordId)
+	assert.Equal(suite.T(), changes.Passwords[1].Id, firstPasswordId)
+	assert.NotNil(suite.T(), changes.Tombstones)
+	assert.Empty(suite.T(), changes.Tombstones)
+
+	err = suite.repositories.Password.FetchVaultChanges(&changes, userId, 3)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), changes.Passwords, "Should not return anything for an up to date vault")
+
+	err = suite.repositories.Password.FetchVaultChanges(&changes, 0, 0)
+	assert.Equal(suite.T(), err, repository.ErrNotFound)
+}
+
+// FetchAllByUserId should fetch only the requested fields of the entries in the user's personal vault
+func (suite *ConformanceSuite) TestFetchAllByUserId() {
+	userId := suite.insertUser()
+	passwordId := suite.insertPassword(userId)
+	sharedPasswordId := suite.insertPassword(userId)
+	organizationId := suite.insertOrganization(userId)
+	suite.repositories.Password.MovePasswordById(userId, &organizationId, 1, []byte("shared"), nil, sharedPasswordId)
+
+	passwords := model.Passwords{}
+	err := suite.repositories.Password.FetchAllByUserId(context.Background(), &passwords, userId, []string{"id", "name"})
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), passwords, 1, "Entries moved to an organization should be left out")
+	assert.Equal(suite.T(), passwords[0].Id, passwordId)
+	assert.Equal(suite.T(), passwords[0].Name, "entry")
+	assert.Nil(suite.T(), passwords[0].Password, "Fields that weren't requested should be left empty")
+
+	err = suite.repositories.Password.FetchAllByUserId(context.Background(), &passwords, 0, nil)
+	assert.Nil(suite.T(), err)
+	assert.Empty(suite.T(), passwords)
+}
+
+// MovePasswordById should move entries between vaults, leaving a tombstone in the personal vault they leave
+func (suite *ConformanceSuite) TestMovePasswordById() {
+	userId := suite.insertUser()
+	passwordId := suite.insertPassword(userId)
+	organizationId := suite.insertOrganization(userId)
+
+	revision, err := suite.repositories.Password.MovePasswordById(userId, &organizationId, 1, []byte("shared"), []byte("sharedTotp"), passwordId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), revision, int64(2))
+
+	passwords := model.Passwords{}
+	suite.repositories.Password.FetchAllByOrganizationId(&passwords, organizationId)
+	assert.Len(suite.T(), passwords, 1)
+	assert.Equal(suite.T(), passwords[0].Password, []byte("shared"))
+	assert.Equal(suite.T(), passwords[0].TotpUri, []byte("sharedTotp"))
+	changes := model.VaultChanges{}
+	suite.repositories.Password.FetchVaultChanges(&changes, userId, 1)
+	assert.Len(suite.T(), changes.Tombstones, 1, "Leaving the personal vault should leave a tombstone")
+
+	revision, err = suite.repositories.Password.MovePasswordById(userId, nil, 0, []byte("personal"), nil, passwordId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), revision, int64(3))
+	suite.repositories.Password.FetchVaultChanges(&changes, userId, 0)
+	assert.Len(suite.T(), changes.Passwords, 1)
+	assert.Equal(suite.T(), changes.Passwords[0].Password, []byte("personal"))
+	assert.Empty(suite.T(), changes.Tombstones, "Returning to the personal vault should remove its tombstone")
+
+	_, err = suite.repositories.Password.MovePasswordById(userId, nil, 0, []byte("personal"), nil, 0)
+	assert.Equal(suite.T(), err, repository.ErrNotFound)
+}
+
+// Organization memberships should be unique per user and fetched along with the organization name and member e-mail
+func (suite *ConformanceSuite) TestOrganizationMemberships() {
+	ownerId := suite.insertUser()
+	memberId := suite.insertUser()
+	organization := &model.Organization{Name: "Zebra " + uuid.New().String()}
+	owner := &model.OrganizationMembership{
+		UserId: ownerId, Role: model.OrganizationOwner, Status: model.OrganizationMemberAccepted, SealedOrganizationKey: []byte("key"),
+	}
+	err := suite.repositories.Organization.InsertNewOrganization(organization, owner)
+	assert.Nil(suite.T(), err)
+	assert.NotZero(suite.T(), organization.Id)
+	assert.NotZero(suite.T(), owner.Id)
+	assert.Equal(suite.T(), owner.OrganizationId, organization.Id)
+	otherOrganizationId := suite.insertOrganization(ownerId)
+
+	membership := &model.OrganizationMembership{
+		OrganizationId: organization.Id, UserId: memberId, Role: model.OrganizationMember,
+		Status: model.OrganizationMemberInvited, SealedOrganizationKey: []byte("memberKey"),
+	}
+	insertResult, err := suite.repositories.Organization.InsertNewMembership(membership, 1)
+	assert.Nil(suite.T(), err)
+	_, err = suite.repositories.Organization.InsertNewMembership(membership, 1)
+	assert.Equal(suite.T(), err, repository.ErrDuplicate, "Should not store a second membership of the same user")
+
+	membershipId := uint64(insertResult.ID().(int64))
+	suite.repositories.Organization.UpdateMembershipStatusById(model.OrganizationMemberAccepted, membershipId)
+	membershipDetails := model.OrganizationMembershipDetails{}
+	err = suite.repositories.Organization.FetchMembershipDetailsById(&membershipDetails, membershipId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), membershipDetails.Status, model.OrganizationMemberAccepted)
+	assert.Equal(suite.T(), membershipDetails.OrganizationName, organization.Name)
+	assert.NotEmpty(suite.T(), membershipDetails.Email)
+
+	ownerMembership := model.OrganizationMembershipDetails{}
+	err = suite.repositories.Organization.FetchMembershipByOrganizationIdAndUserId(&ownerMembership, organization.Id, ownerId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), ownerMembership.Role, model.OrganizationOwner)
+	assert.Equal(suite.T(), ownerMembership.OrganizationKeyVersion, 1)
+	err = suite.repositories.Organization.FetchMembershipByOrganizationIdAndUserId(&ownerMembership, otherOrganizationId, memberId)
+	assert.Equal(suite.T(), err, repository.ErrNotFound)
+
+	membershipsDetails := model.OrganizationMembershipsDetails{}
+	suite.repositories.Organization.FetchMembershipsDetailsByUserId(&membershipsDetails, ownerId)
+	assert.Len(suite.T(), membershipsDetails, 2)
+	assert.Equal(suite.T(), membershipsDetails[0].OrganizationId, otherOrganizationId, "Should order memberships by organization name")
+	suite.repositories.Organization.FetchMembershipsDetailsByOrganizationId(&membershipsDetails, organization.Id)
+	assert.Len(suite.T(), membershipsDetails, 2)
+	assert.Equal(suite.T(), membershipsDetails[0].UserId, ownerId)
+	memberships := model.OrganizationMemberships{}
+	suite.repositories.Organization.FetchMembershipsByOrganizationId(&memberships, organization.Id)
+	assert.Len(suite.T(), memberships, 2)
+}
+
+// RotateOrganizationKey should change nothing unless every remaining member and shared entry is covered
+func (suite *ConformanceSuite) TestRotateOrganizationKey() {
+	ownerId := suite.insertUser()
+	memberId := suite.insertUser()
+	organization := &model.Organization{Name: "Rotation"}
+	owner := &model.OrganizationMembership{
+		UserId: ownerId, Role: model.OrganizationOwner, Status: model.OrganizationMemberAccepted, SealedOrganizationKey: []byte("key"),
+	}
+	suite.repositories.Organization.InsertNewOrganization(organization, owner)
+	insertResult, _ := suite.repositories.Organization.InsertNewMembership(&model.OrganizationMembership{
+		OrganizationId: organization.Id, UserId: memberId, Role: model.OrganizationMember,
+		Status: model.OrganizationMemberAccepted, SealedOrganizationKey: []byte("key"),
+	}, 1)
+	membershipId := uint64(insertResult.ID().(int64))
+	passwordId := suite.insertPassword(ownerId)
+	suite.repositories.Password.MovePasswordById(ownerId, &organization.Id, 1, []byte("shared"), nil, passwordId)
+
+	err := suite.repositories.Organization.RotateOrganizationKey(
+		organization.Id, membershipId, map[uint64][]byte{owner.Id: []byte("rotatedKey")}, model.Passwords{},
+	)
+	assert.Equal(suite.T(), err, repository.ErrOrganizationChanged, "Should require every shared entry to be re-encrypted")
+	assert.True(suite.T(), errors.Is(err, repository.ErrConflict))
+
+	memberships := model.OrganizationMemberships{}
+	suite.repositories.Organization.FetchMembershipsByOrganizationId(&memberships, organization.Id)
+	assert.Len(suite.T(), memberships, 2, "A failed rotation should not remove the membership")
+
+	err = suite.repositories.Organization.RotateOrganizationKey(
+		organization.Id, membershipId, map[uint64][]byte{owner.Id: []byte("rotatedKey")},
+		model.Passwords{{Id: passwordId, Password: []byte("rotated"), TotpUri: []byte("rotatedTotp")}},
+	)
+	assert.Nil(suite.T(), err)
+
+	suite.repositories.Organization.FetchMembershipsByOrganizationId(&memberships, organization.Id)
+	assert.Len(suite.T(), memberships, 1)
+	assert.Equal(suite.T(), memberships[0].SealedOrganizationKey, []byte("rotatedKey"))
+	password := model.Password{}
+	suite.repositories.Password.FetchPasswordById(context.Background(), &password, passwordId)
+	assert.Equal(suite.T(), password.Password, []byte("rotated"))
+	assert.Equal(suite.T(), password.TotpUri, []byte("rotatedTotp"))
+}
+
+// Moving entries into an organization and inviting members should be refused once the organization key was rotated
+func (suite *ConformanceSuite) TestWritesWithRotatedOrganizationKey() {
+	ownerId := suite.insertUser()
+	memberId := suite.insertUser()
+	organizationId := suite.insertOrganization(ownerId)
+	ownerMembership := model.OrganizationMembershipDetails{}
+	suite.repositories.Organization.FetchMembershipByOrganizationIdAndUserId(&ownerMembership, organizationId, ownerId)
+	err := suite.repositories.Organization.RotateOrganizationKey(
+		organizationId, 0, map[uint64][]byte{ownerMembership.Id: []byte("rotatedKey")}, model.Passwords{},
+	)
+	suite.Require().Nil(err)
+	passwordId := suite.insertPassword(ownerId)
+	membership := &model.OrganizationMembership{
+		OrganizationId: organizationId, UserId: memberId, Role: model.OrganizationMember,
+		Status: model.OrganizationMemberInvited, SealedOrganizationKey: []byte("key"),
+	}
+
+	_, err = suite.repositories.Password.MovePasswordById(ownerId, &organizationId, 1, []byte("shared"), nil, passwordId)
+	assert.Equal(suite.T(), err, repository.ErrOrganizationChanged, "Should not store an entry encrypted with the old key")
+	assert.True(suite.T(), errors.Is(err, repository.ErrConflict))
+	_, err = suite.repositories.Organization.InsertNewMembership(membership, 1)
+	assert.Equal(suite.T(), err, repository.ErrOrganizationChanged, "Should not store a membership sealing the old key")
+
+	passwords := model.Passwords{}
+	suite.repositories.Password.FetchAllByOrganizationId(&passwords, organizationId)
+	assert.Empty(suite.T(), passwords)
+	memberships := model.OrganizationMemberships{}
+	suite.repositories.Organization.FetchMembershipsByOrganizationId(&memberships, organizationId)
+	assert.Len(suite.T(), memberships, 1)
+
+	_, err = suite.repositories.Password.MovePasswordById(ownerId, &organizationId, 2, []byte("shared"), nil, passwordId)
+	assert.Nil(suite.T(), err)
+	_, err = suite.repositories.Organization.InsertNewMembership(membership, 2)
+	assert.Nil(suite.T(), err)
+}
+
+// UpdateSharedPasswordById should update the entry only together with the copies of all of its shares
+func (suite *ConformanceSuite) TestUpdateSharedPasswordById() {
+	ownerId := suite.insertUser()
+	recipientId := suite.insertUser()
+	passwordId := suite.insertPassword(ownerId)
+	insertResult, err := suite.repositories.PasswordShare.InsertNewShare(&model.PasswordShare{
+		PasswordId: passwordId, RecipientId: recipientId, Permission: model.SharePermissionWrite, SealedPassword: []byte("sealed"),
+	})
+	assert.Nil(suite.T(), err)
+	shareId := uint64(insertResult.ID().(int64))
+
+	err = suite.repositories.PasswordShare.UpdateSharedPasswordById(
+		&model.Password{Id: passwordId, Name: "updated", Password: []byte("updated")}, map[uint64][]byte{}, nil,
+	)
+	assert.Equal(suite.T(), err, repository.ErrPasswordSharesChanged)
+	password := model.Password{}
+	suite.repositories.Password.FetchPasswordById(context.Background(), &password, passwordId)
+	assert.Equal(suite.T(), password.Version, int64(1), "A failed update should not change the entry")
+
+	expectedVersion := int64(1)
+	updatedPassword := &model.Password{Id: passwordId, Name: "updated", Password: []byte("updated")}
+	err = suite.repositories.PasswordShare.UpdateSharedPasswordById(
+		updatedPassword, map[uint64][]byte{shareId: []byte("resealed")}, &expectedVersion,
+	)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), updatedPassword.Version, int64(2))
+
+	shares := model.PasswordSharesDetails{}
+	suite.repositories.PasswordShare.FetchSharesDetailsByRecipientId(&shares, recipientId)
+	assert.Len(suite.T(), shares, 1)
+	assert.Equal(suite.T(), shares[0].SealedPassword, []byte("resealed"))
+	assert.Equal(suite.T(), shares[0].OwnerId, ownerId)
+	assert.NotEmpty(suite.T(), shares[0].OwnerEmail)
+	assert.NotEmpty(suite.T(), shares[0].RecipientEmail)
+
+	suite.repositories.Password.DeletePasswordById(passwordId, nil)
+	err = suite.repositories.PasswordShare.FetchShareDetailsById(&model.PasswordShareDetails{}, shareId)
+	assert.Equal(suite.T(), err, repository.ErrNotFound, "Deleting an entry should delete its shares")
+}
+
+// ConsumeSendViewById should burn the send after its last view
+func (suite *ConformanceSuite) TestConsumeSendViewById() {
+	userId := suite.insertUser()
+	now := time.Now().UTC()
+	tokenHash := []byte(uuid.New().String())
+	insertResult, err := suite.repositories.Send.InsertNewSend(&model.Send{
+		UserId: userId, Name: "send", TokenHash: tokenHash, EncryptedSecret: []byte("secret"),
+		MaxViews: 2, ExpiresAt: now.Add(time.Hour), CreatedAt: now,
+	})
+	assert.Nil(suite.T(), err)
+	sendId := uint64(insertResult.ID().(int64))
+
+	sends := model.Sends{}
+	suite.repositories.Send.FetchAvailableSendsByUserId(&sends, userId, now)
+	assert.Len(suite.T(), sends, 1)
+
+	assert.Nil(suite.T(), suite.repositories.Send.ConsumeSendViewById(sendId, now))
+	send := model.Send{}
+	err = suite.repositories.Send.FetchAvailableSendByTokenHash(&send, tokenHash, now)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), send.ViewCount, 1)
+
+	assert.Nil(suite.T(), suite.repositories.Send.ConsumeSendViewById(sendId, now))
+	assert.Equal(suite.T(), suite.repositories.Send.ConsumeSendViewById(sendId, now), repository.ErrNotFound)
+	err = suite.repositories.Send.FetchAvailableSendByTokenHash(&send, tokenHash, now)
+	assert.Equal(suite.T(), err, repository.ErrNotFound, "Sends should be burned after their last view")
+	assert.Equal(suite.T(), suite.repositories.Send.DeleteSendByIdAndUserId(sendId, userId), repository.ErrNotFound)
+}
+
+// DeleteUnavailableSends should purge expired sends only
+func (suite *ConformanceSuite) TestDeleteUnavailableSends() {
+	userId := suite.insertUser()
+	now := time.Now().UTC()
+	for _, expiresAt := range []time.Time{now.Add(-time.Hour), now.Add(time.Hour)} {
+		suite.repositories.Send.InsertNewSend(&model.Send{
+			UserId: userId, Name: "send", TokenHash: []byte(uuid.New().String()), EncryptedSecret: []byte("secret"),
+			MaxViews: 1, ExpiresAt: expiresAt, CreatedAt: now,
+		})
+	}
+
+	deleted, err := suite.repositories.Send.DeleteUnavailableSends(now)
+	assert.Nil(suite.T(), err)
+	assert.GreaterOrEqual(suite.T(), deleted, int64(1))
+	sends := model.Sends{}
+	suite.repositories.Send.FetchAvailableSendsByUserId(&sends, userId, now)
+	assert.Len(suite.T(), sends, 1, "Available sends should be kept")
+}
+
+// Session status changes should apply only to sessions in the expected status
+func (suite *ConformanceSuite) TestSessionStatusChanges() {
+	userId := suite.insertUser()
+	now := time.Now().UTC()
+	insertResult, err := suite.repositories.Session.InsertNewSession(&model.Session{
+		UserId: userId, DeviceName: "device", Status: model.SessionPendingApproval, CreatedAt: now, LastSeenAt: now,
+	})
+	assert.Nil(suite.T(), err)
+	sessionId := uint64(insertResult.ID().(int64))
+
+	assert.Equal(suite.T(), suite.repositories.Session.TouchActiveSessionById(now, sessionId, userId), repository.ErrNotFound)
+	assert.Equal(suite.T(), suite.repositories.Session.UpdateSessionStatusById(model.SessionApproved, model.SessionActive, sessionId), repository.ErrNotFound)
+	assert.Nil(suite.T(), suite.repositories.Session.UpdateSessionStatusById(model.SessionPendingApproval, model.SessionActive, sessionId))
+	assert.Nil(suite.T(), suite.repositories.Session.TouchActiveSessionById(now.Add(time.Minute), sessionId, userId))
+	assert.Equal(
+		suite.T(), suite.repositories.Session.TouchActiveSessionById(now, sessionId, suite.insertUser()), repository.ErrNotFound,
+		"Sessions of another user should not be touched",
+	)
+
+	sessions := model.Sessions{}
+	suite.repositories.Session.FetchSessionsByUserId(&sessions, userId)
+	assert.Len(suite.T(), sessions, 1)
+
+	assert.Nil(suite.T(), suite.repositories.Session.RevokeSessionById(sessionId))
+	assert.Equal(suite.T(), suite.repositories.Session.RevokeSessionById(sessionId), repository.ErrNotFound)
+	session := model.Session{}
+	suite.repositories.Session.FetchSessionById(&session, sessionId)
+	assert.Equal(suite.T(), session.Status, model.SessionRevoked)
+	assert.NotNil(suite.T(), session.RevokedAt)
+	suite.repositories.Session.FetchSessionsByUserId(&sessions, userId)
+	assert.Empty(suite.T(), sessions, "Revoked sessions should be left out")
+}
+
+// RevokeSessionsByUserId should revoke every session of the user and only those
+func (suite *ConformanceSuite) TestRevokeSessionsByUserId() {
+	userId, otherUserId := suite.insertUser(), suite.insertUser()
+	now := time.Now().UTC()
+	for _, sessionUserId := range []uint64{userId, userId, otherUserId} {
+		_, err := suite.repositories.Session.InsertNewSession(&model.Session{
+			UserId: sessionUserId, DeviceName: "device", Status: model.SessionActive, CreatedAt: now, LastSeenAt: now,
+		})
+		suite.Require().Nil(err)
+	}
+
+	assert.Nil(suite.T(), suite.repositories.Session.RevokeSessionsByUserId(userId))
+	assert.Nil(suite.T(), suite.repositories.Session.RevokeSessionsByUserId(userId), "Revoking no sessions should not be an error")
+	sessions := model.Sessions{}
+	suite.repositories.Session.FetchSessionsByUserId(&sessions, userId)
+	assert.Empty(suite.T(), sessions, "Every session of the user should be revoked")
+	suite.repositories.Session.FetchSessionsByUserId(&sessions, otherUserId)
+	assert.Len(suite.T(), sessions, 1, "Sessions of other users should be left active")
+}
+
+// ConsumeChallenge should consume a challenge only once and only for its own ceremony
+func (suite *ConformanceSuite) TestConsumeChallenge() {
+	userId := suite.insertUser()
+	value := []byte(uuid.New().String())
+	_, err := suite.repositories.Webauthn.InsertNewChallenge(
+		&model.WebauthnChallenge{UserId: userId, Challenge: value, Ceremony: "login", ExpiresAt: time.Now().UTC().Add(time.Hour)},
+	)
+	assert.Nil(suite.T(), err)
+
+	assert.Equal(suite.T(), suite.repositories.Webauthn.ConsumeChallenge(&model.WebauthnChallenge{}, value, "registration"), repository.ErrNotFound)
+	challenge := model.WebauthnChallenge{}
+	assert.Nil(suite.T(), suite.repositories.Webauthn.ConsumeChallenge(&challenge, value, "login"))
+	assert.Equal(suite.T(), challenge.UserId, userId)
+	assert.Equal(suite.T(), suite.repositories.Webauthn.ConsumeChallenge(&model.WebauthnChallenge{}, value, "login"), repository.ErrNotFound)
+}
+
+// Webauthn credentials should be unique and keep their sign count
+func (suite *ConformanceSuite) TestWebauthnCredentials() {
+	userId := suite.insertUser()
+	credential := &model.WebauthnCredential{UserId: userId, CredentialId: []byte(uuid.New().String()), PublicKey: []byte("key"), Name: "key"}
+	insertResult, err := suite.repositories.Webauthn.InsertNewCredential(credential)
+	assert.Nil(suite.T(), err)
+	_, err = suite.repositories.Webauthn.InsertNewCredential(credential)
+	assert.Equal(suite.T(), err, repository.ErrDuplicate, "Should not store the same credential twice")
+
+	suite.repositories.Webauthn.UpdateSignCountById(7, uint64(insertResult.ID().(int64)))
+	fetchedCredential := model.WebauthnCredential{}
+	err = suite.repositories.Webauthn.FetchCredentialByCredentialId(&fetchedCredential, credential.CredentialId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), fetchedCredential.SignCount, uint32(7))
+	credentials := model.WebauthnCredentials{}
+	suite.repositories.Webauthn.FetchCredentialsByUserId(&credentials, userId)
+	assert.Len(suite.T(), credentials, 1)
+}
+
+// Known devices should be stored per user
+func (suite *ConformanceSuite) TestKnownDevices() {
+	userId := suite.insertUser()
+	insertResult, err := suite.repositories.KnownDevice.InsertNewKnownDevice(
+		&model.KnownDevice{UserId: userId, UserAgent: "agent", IpAddress: "127.0.0.1", LastSeenAt: time.Now().UTC()},
+	)
+	assert.Nil(suite.T(), err)
+	lastSeenAt := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	suite.repositories.KnownDevice.UpdateLastSeenById(lastSeenAt, uint64(insertResult.ID().(int64)))
+
+	devices := model.KnownDevices{}
+	suite.repositories.KnownDevice.FetchKnownDevicesByUserId(&devices, userId)
+	assert.Len(suite.T(), devices, 1)
+	assert.True(suite.T(), devices[0].LastSeenAt.Equal(lastSeenAt))
+}
+
+// Emergency access requests should be resolved only while pending, and grants fetched along with both e-mail addresses
+func (suite *ConformanceSuite) TestEmergencyAccess() {
+	grantorId := suite.insertUser()
+	granteeId := suite.insertUser()
+	grant := &model.EmergencyAccessGrant{
+		GrantorId: grantorId, GranteeId: granteeId, AccessType: model.EmergencyAccessView,
+		WaitTimeInDays: 1, Status: model.EmergencyAccessGrantInvited,
+	}
+	insertResult, err := suite.repositories.EmergencyAccess.InsertNewGrant(grant)
+	assert.Nil(suite.T(), err)
+	_, err = suite.repositories.EmergencyAccess.InsertNewGrant(grant)
+	assert.Equal(suite.T(), err, repository.ErrDuplicate, "Should not store a second grant between the same users")
+	grantId := uint64(insertResult.ID().(int64))
+	suite.repositories.EmergencyAccess.UpdateGrantStatusById(model.EmergencyAccessGrantAccepted, grantId)
+
+	grants := model.EmergencyAccessGrantsDetails{}
+	suite.repositories.EmergencyAccess.FetchGrantsDetailsByUserId(&grants, granteeId)
+	assert.Len(suite.T(), grants, 1)
+	assert.Equal(suite.T(), grants[0].Status, model.EmergencyAccessGrantAccepted)
+	assert.NotEmpty(suite.T(), grants[0].GrantorEmail)
+	assert.NotEmpty(suite.T(), grants[0].GranteeEmail)
+
+	now := time.Now().UTC()
+	requestIds := []uint64{}
+	for index := 0; index < 2; index++ {
+		insertResult, err = suite.repositories.EmergencyAccess.InsertNewRequest(&model.EmergencyAccessRequest{
+			GrantId: grantId, Status: model.EmergencyAccessRequestPending, RequestedAt: now, AvailableAt: now.Add(-time.Minute),
+		})
+		assert.Nil(suite.T(), err)
+		requestIds = append(requestIds, uint64(insertResult.ID().(int64)))
+	}
+
+	assert.Nil(suite.T(), suite.repositories.EmergencyAccess.ApproveRequestById([]byte("sealedVaultKey"), requestIds[0]))
+	assert.Equal(suite.T(), suite.repositories.EmergencyAccess.RejectRequestById(requestIds[0]), repository.ErrNotFound)
+	assert.Nil(suite.T(), suite.repositories.EmergencyAccess.RejectRequestById(requestIds[1]))
+	assert.Equal(suite.T(), suite.repositories.EmergencyAccess.ApproveRequestById([]byte("key"), requestIds[1]), repository.ErrNotFound)
+
+	requests := model.EmergencyAccessRequests{}
+	suite.repositories.EmergencyAccess.FetchRequestsByGrantId(&requests, grantId)
+	assert.Len(suite.T(), requests, 2)
+	assert.Equal(suite.T(), requests[0].Id, requestIds[1], "Should fetch the latest request first")
+	assert.Equal(suite.T(), requests[1].SealedVaultKey, []byte("sealedVaultKey"))
+	assert.NotNil(suite.T(), requests[1].ResolvedAt)
+}
+
+// InsertNewAttachment should keep every user within the storage quota
+func (suite *ConformanceSuite) TestAttachmentStorageQuota() {
+	userId := suite.insertUser()
+	passwordId := suite.insertPassword(userId)
+	newAttachment := func(size int64) *model.Attachment {
+		return &model.Attachment{
+			PasswordId: passwordId, UserId: userId, Name: "file", ContentType: "text/plain",
+			Size: size, BlobKey: uuid.New().String(), WrappedDataKey: []byte("wrappedDataKey"), CreatedAt: time.Now().UTC(),
+		}
+	}
+
+	insertResult, err := suite.repositories.Attachment.InsertNewAttachment(newAttachment(60), 100)
+	assert.Nil(suite.T(), err)
+	_, err = suite.repositories.Attachment.InsertNewAttachment(newAttachment(50), 100)
+	assert.Equal(suite.T(), err, repository.ErrStorageQuotaExceeded)
+
+	usedBytes, err := suite.repositories.Attachment.FetchStorageUsageByUserId(userId)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), usedBytes, int64(60))
+
+	attachmentId := uint64(insertResult.ID().(int64))
+	attachments := model.Attachments{}
+	suite.repositories.Attachment.FetchAttachmentsByPasswordId(&attachments, passwordId)
+	assert.Len(suite.T(), attachments, 1)
+	suite.repositories.Attachment.FetchAttachmentsByUserId(&attachments, userId)
+	assert.Len(suite.T(), attachments, 1)
+	suite.repositories.Attachment.FetchAttachmentsByUserId(&attachments, suite.insertUser())
+	assert.Empty(suite.T(), attachments, "Attachments of other users should not be fetched")
+	assert.Nil(suite.T(), suite.repositories.Attachment.DeleteAttachmentById(attachmentId))
+	assert.Equal(suite.T(), suite.repositories.Attachment.DeleteAttachmentById(attachmentId), repository.ErrNotFound)
+	assert.Equal(suite.T(), suite.repositories.Attachment.FetchAttachmentById(&model.Attachment{}, attachmentId), repository.ErrNotFound)
+}
+
+// AppendEvent should link every event to its predecessor in the chain
+func (suite *ConformanceSuite) TestAppendEvent() {
+	userId := suite.insertUser()
+	hashEvent := func(event *model.AuditEvent) []byte { return []byte(uuid.New().String()) }
+	firstEvent := &model.AuditEvent{UserId: &userId, Type: model.AuditEventSignUp, CreatedAt: time.Now().UTC()}
+	secondEvent := &model.AuditEvent{UserId: &userId, Type: model.AuditEventSignIn, CreatedAt: time.Now().UTC()}
+
+	assert.Nil(suite.T(), suite.repositories.AuditEvent.AppendEvent(firstEvent, hashEvent))
+	assert.Nil(suite.T(), suite.repositories.AuditEvent.AppendEvent(secondEvent, hashEvent))
+	assert.Equal(suite.T(), secondEvent.Sequence, firstEvent.Sequence+1)
+	assert.Equal(suite.T(), secondEvent.PreviousHash, firstEvent.Hash)
+
+	events := model.AuditEvents{}
+	err := suite.repositories.AuditEvent.FetchEvents(&events, &model.AuditEventFilter{UserId: &userId}, 0, 10)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), events, 2)
+	assert.Equal(suite.T(), events[0].Type, model.AuditEventSignIn, "Should fetch the most recent events first")
+	suite.repositories.AuditEvent.FetchEvents(&events, &model.AuditEventFilter{UserId: &userId, Types: []string{model.AuditEventSignUp}}, 0, 10)
+	assert.Len(suite.T(), events, 1)
+
+	suite.repositories.AuditEvent.FetchEventsAfterSequence(&events, firstEvent.Sequence-1, 10)
+	assert.Len(suite.T(), events, 2)
+	assert.Equal(suite.T(), events[0].Id, firstEvent.Id)
+	assert.Equal(suite.T(), events[1].Hash, secondEvent.Hash)
+}
+
+// Webhook deliveries should move to the dead letters and back, and go along with their webhook
+func (suite *ConformanceSuite) TestWebhookDeliveries() {
+	userId := suite.insertUser()
+	eventType := "conformance_" + uuid.New().String()
+	insertResult, err := suite.repositories.Webhook.InsertNewWebhook(
+		&model.Webhook{CreatedBy: userId, Url: "http://localhost", Secret: []byte("secret"), EventTypes: []string{"other", eventType}},
+	)
+	assert.Nil(suite.T(), err)
+	webhookId := uint64(insertResult.ID().(int64))
+
+	webhooks := model.Webhooks{}
+	suite.repositories.Webhook.FetchWebhooksByEventType(&webhooks, eventType)
+	assert.Len(suite.T(), webhooks, 1)
+	assert.Equal(suite.T(), webhooks[0].Id, webhookId)
+
+	now := time.Now().UTC()
+	err = suite.repositories.Webhook.InsertNewDeliveries(model.WebhookDeliveries{{
+		WebhookId: webhookId, EventType: eventType, Payload: "{}", Status: model.WebhookDeliveryPending, NextAttemptAt: now,
+	}})
+	assert.Nil(suite.T(), err)
+	deliveries := model.WebhookDeliveries{}
+	suite.repositories.Webhook.FetchDeliveriesByWebhookId(&deliveries, webhookId, 0, 10)
+	assert.Len(suite.T(), deliveries, 1)
+
+	delivery := deliveries[0]
+	lastError := "connection refused"
+	delivery.Attempts = 8
+	delivery.LastError = &lastError
+	assert.Nil(suite.T(), suite.repositories.Webhook.MoveDeliveryToDeadLetter(&delivery))
+	deadLetters := model.WebhookDeadLetters{}
+	suite.repositories.Webhook.FetchDeadLetters(&deadLetters, 0, 1)
+	assert.Len(suite.T(), deadLetters, 1)
+	assert.Equal(suite.T(), deadLetters[0].DeliveryId, delivery.Id)
+	assert.Equal(suite.T(), *deadLetters[0].LastError, lastError)
+
+	assert.Nil(suite.T(), suite.repositories.Webhook.RedeliverDeadLetterById(deadLetters[0].Id, now))
+	suite.repositories.Webhook.FetchDeliveriesByWebhookId(&deliveries, webhookId, 0, 10)
+	assert.Equal(suite.T(), deliveries[0].Status, model.WebhookDeliveryPending)
+	assert.Equal(suite.T(), deliveries[0].Attempts, 0)
+	assert.Equal(suite.T(), suite.repositories.Webhook.RedeliverDeadLetterById(deadLetters[0].Id, now), repository.ErrNotFound)
+
+	assert.Nil(suite.T(), suite.repositories.Webhook.DeleteWebhookById(webhookId))
+	assert.Equal(suite.T(), suite.repositories.Webhook.DeleteWebhookById(webhookId), repository.ErrNotFound)
+	suite.repositories.Webhook.FetchDeliveriesByWebhookId(&deliveries, webhookId, 0, 10)
+	assert.Empty(suite.T(), deliveries, "Deleting a webhook should delete its deliveries")
+	assert.Equal(suite.T(), suite.repositories.Webhook.UpdateDeliveryAttempt(&delivery), repository.ErrNotFound)
+}
+
+// Disabling a user should revoke the user's sessions and record the change in the admin audit trail
+func (suite *ConformanceSuite) TestUpdateUserDisabledById() {
+	adminId := suite.insertUser()
+	userId := suite.insertUser()
+	now := time.Now().UTC()
+	suite.repositories.Session.InsertNewSession(&model.Session{
+		UserId: userId, DeviceName: "device", Status: model.SessionActive, CreatedAt: now, LastSeenAt: now,
+	})
+	statistics := model.UsageStatistics{}
+	assert.Nil(suite.T(), suite.repositories.Admin.FetchUsageStatistics(&statistics))
+
+	auditEntry := &model.AdminAuditEntry{AdminId: adminId, Action: model.AdminActionDisableUser, TargetUserId: userId}
+	err := suite.repositories.Admin.UpdateUserDisabledById(true, userId, auditEntry)
+	assert.Nil(suite.T(), err)
+	assert.NotZero(suite.T(), auditEntry.Id)
+
+	user := model.User{}
+	suite.repositories.User.FetchById(&user, userId, nil)
+	assert.True(suite.T(), user.Disabled)
+	sessions := model.Sessions{}
+	suite.repositories.Session.FetchSessionsByUserId(&sessions, userId)
+	assert.Empty(suite.T(), sessions, "Disabling a user should revoke the user's sessions")
+
+	entries := model.AdminAuditEntriesDetails{}
+	suite.repositories.Admin.FetchAuditEntriesDetails(&entries, 0, 1)
+	assert.Len(suite.T(), entries, 1)
+	assert.Equal(suite.T(), entries[0].Id, auditEntry.Id)
+	assert.NotEmpty(suite.T(), entries[0].TargetEmail)
+
+	updatedStatistics := model.UsageStatistics{}
+	suite.repositories.Admin.FetchUsageStatistics(&updatedStatistics)
+	assert.Equal(suite.T(), updatedStatistics.DisabledUsers, statistics.DisabledUsers+1)
+	assert.Equal(suite.T(), updatedStatistics.ActiveSessions, statistics.ActiveSessions-1)
+
+	err = suite.repositories.Admin.UpdateUserRoleById(model.UserRoleAdmin, 0, &model.AdminAuditEntry{AdminId: adminId})
+	assert.Equal(suite.T(), err, repository.ErrNotFound)
+}
+
+// FetchUsers should leave out the master password hashes and recovery keys
+func (suite *ConformanceSuite) TestFetchUsers() {
+	suite.insertUser()
+
+	users := model.Users{}
+	err := suite.repositories.Admin.FetchUsers(&users, 0, 1)
+	assert.Nil(suite.T(), err)
+	assert.Len(suite.T(), users, 1)
+	assert.NotEmpty(suite.T(), users[0].Email)
+	assert.Nil(suite.T(), users[0].Password)
+}
+
+// Execute should commit the changes of all repositories together once the work is done
+func (suite *ConformanceSuite) TestUnitOfWorkCommit() {
+	email := uniqueEmail()
+	var userId uint64
+	err := suite.repositories.UnitOfWork.Execute(func(repositories *repository.Repositories) error {
+		insertResult, err := repositories.User.InsertNewUser(&model.User{Email: email, Username: "conformance", Password: []byte("password")})
+		if err != nil {
+			return err
+		}
+		userId = uint64(insertResult.ID().(int64))
+		_, err = repositories.Password.InsertNewPassword(&model.Password{UserId: userId, Name: "entry", Password: []byte("password")})
+		return err
+	})
+	assert.Nil(suite.T(), err)
+
+	changes := model.VaultChanges{}
+	err = suite.repositories.Password.FetchVaultChanges(&changes, userId, 0)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), changes.Revision, int64(1))
+	assert.Len(suite.T(), changes.Passwords, 1, "Changes of the work should be visible once it's committed")
+}
+
+// Execute should roll back the changes of all repositories when the work returns an error
+func (suite *ConformanceSuite) TestUnitOfWorkRollbackOnError() {
+	userId := suite.insertUser()
+	workError := errors.New("work failed")
+
+	err := suite.repositories.UnitOfWork.Execute(func(repositories *repository.Repositories) error {
+		if _, err := repositories.Password.InsertNewPassword(&model.Password{UserId: userId, Name: "entry", Password: []byte("password")}); err != nil {
+			return err
+		}
+		if err := repositories.User.UpdateEmailVerifiedById(true, userId); err != nil {
+			return err
+		}
+		return workError
+	})
+	assert.Equal(suite.T(), err, workError, "Should return the error of the work")
+
+	changes := model.VaultChanges{}
+	err = suite.repositories.Password.FetchVaultChanges(&changes, userId, 0)
+	assert.Nil(suite.T(), err)
+	assert.Equal(suite.T(), changes.Revision, int64(0), "The vault revision should be rolled back")
+	assert.Empty(suite.T(), changes.Passwords, "The inserted password should be rolled back")
+	user := model.User{}
+	err = suite.repositories.User.FetchById(&user, userId, []string{"email_verified"})
+	assert.Nil(suite.T(), err)
+	assert.False(suite.T(), user.EmailVerified, "The update should be rolled back")
+}
+
+// Execute should roll back the changes of all repositories and resume the panic of the work
+func (suite *ConformanceSuite) TestUnitOfWorkRollbackOnPanic() {
+	email := uniqueEmail()
+	assert.PanicsWithValue(suite.T(), "work panicked", func() {
+		_ = suite.repositories.UnitOfWork.Execute(func(repositories *repository.Repositories) error {
+			_, err := repositories.User.InsertNewUser(&model.User{Email: email, Username: "conformance", Password: []byte("password")})
+			suite.Require().Nil(err)
+			panic("work panicked")
+		})
+	})
+
+	err := suite.repositories.User.FetchByEmail(context.Background(), &model.User{}, email, nil)
+	assert.Equal(suite.T(), err, repository.ErrNotFound, "The inserted user should be rolled back")
+}
+
+func (suite *ConformanceSuite) insertUser() uint64 {
+	insertResult, err := suite.repositories.User.InsertNewUser(
+		&model.User{Email: uniqueEmail(), Username: "conformance", Password: []byte("masterPassword")},
+	)
+	suite.Require().Nil(err)
+	return uint64(insertResult.ID().(int64))
+}
+
+func (suite *ConformanceSuite) insertAttachment(userId uint64, passwordId uint64) uint64 {
+	insertResult, err := suite.repositories.Attachment.InsertNewAttachment(&model.Attachment{
+		PasswordId: passwordId, UserId: userId, Name: "file", ContentType: "text/plain", Size: 1,
+		BlobKey: uuid.New().String(), WrappedDataKey: []byte("wrappedDataKey"), CreatedAt: time.Now().UTC(),
+	}, 100)
+	suite.Require().Nil(err)
+	return uint64(insertResult.ID().(int64))
+}
+
+func (suite *ConformanceSuite) insertPassword(userId uint64) uint64 {
+	insertResult, err := suite.repositories.Password.InsertNewPassword(
+		&model.Password{UserId: userId, Name: "entry", Password: []byte("password")},
+	)
+	suite.Require().Nil(err)
+	return uint64(insertResult.ID().(int64))
+}
+
+func (suite *ConformanceSuite) insertOrganization(ownerId uint64) uint64 {
+	organization := &model.Organization{Name: "Aardvark " + uuid.New().String()}
+	err := suite.repositories.Organization.InsertNewOrganization(organization, &model.OrganizationMembership{
+		UserId: ownerId, Role: model.OrganizationOwner, Status: model.OrganizationMemberAccepted, SealedOrganizationKey: []byte("key"),
+	})
+	suite.Require().Nil(err)
+	return organization.Id
+}
+
+func uniqueEmail() string {
+	return uuid.New().String() + "@conformance.test"
+}
@@ -0,0 +1,159 @@
+package smtputil
+
+import (
+	"bufio"
+	"log"
+	"net"
+	"strings"
+	"sync"
+)
+
+type ReceivedMessage struct {
+	From       string
+	Recipients []string
+	Data       string
+}
+
+// SmtpServer is a minimal local SMTP stand-in that records every received message,
+// it can be set up to reject a number of deliveries to test retries
+type SmtpServer struct {
+	listener           net.Listener
+	mutex              sync.Mutex
+	messages           []ReceivedMessage
+	rejectedDeliveries int
+	Received           chan ReceivedMessage
+}
+
+func StartSmtpServer() *SmtpServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Panicf("Could not start test SMTP server: %s", err)
+	}
+
+	server := &SmtpServer{listener: listener, Received: make(chan ReceivedMessage, 16)}
+	go server.acceptConnections()
+
+	return server
+}
+
+func (server *SmtpServer) Host() string {
+	host, _, _ := net.SplitHostPort(server.listener.Addr().String())
+	return host
+}
+
+func (server *SmtpServer) Port() string {
+	_, port, _ := net.SplitHostPort(server.listener.Addr().String())
+	return port
+}
+
+// RejectDeliveries makes the server reject the next given number of deliveries with a transient error
+func (server *SmtpServer) RejectDeliveries(count int) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	server.rejectedDeliveries = count
+}
+
+func (server *SmtpServer) PendingRejections() int {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return server.rejectedDeliveries
+}
+
+func (server *SmtpServer) Messages() []ReceivedMessage {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	return append([]ReceivedMessage{}, server.messages...)
+}
+
+func (server *SmtpServer) Close() {
+	server.listener.Close()
+}
+
+func (server *SmtpServer) acceptConnections() {
+	for {
+		connection, err := server.listener.Accept()
+		if err != nil {
+			return
+		}
+		go server.handleConnection(connection)
+	}
+}
+
+func (server *SmtpServer) handleConnection(connection net.Conn) {
+	defer connection.Close()
+	reader := bufio.NewReader(connection)
+	reply := func(line string) { connection.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost test SMTP server ready")
+	message := ReceivedMessage{}
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			if server.shouldReject() {
+				reply("451 temporary failure, try again later")
+				continue
+			}
+			message = ReceivedMessage{From: extractAddress(line)}
+			reply("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			message.Recipients = append(message.Recipients, extractAddress(line))
+			reply("250 OK")
+		case command == "DATA":
+			reply("354 end data with <CR><LF>.<CR><LF>")
+			data := &strings.Builder{}
+			for {
+				dataLine, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if dataLine == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(dataLine, "."))
+			}
+			message.Data = data.String()
+			server.record(message)
+			reply("250 OK")
+		case command == "RSET", command == "NOOP":
+			reply("250 OK")
+		case command == "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("502 command not implemented")
+		}
+	}
+}
+
+func (server *SmtpServer) shouldReject() bool {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+	if server.rejectedDeliveries > 0 {
+		server.rejectedDeliveries--
+		return true
+	}
+	return false
+}
+
+func (server *SmtpServer) record(message ReceivedMessage) {
+	server.mutex.Lock()
+	server.messages = append(server.messages, message)
+	server.mutex.Unlock()
+	server.Received <- message
+}
+
+func extractAddress(line string) string {
+	start, end := strings.Index(line, "<"), strings.Index(line, ">")
+	if start == -1 || end < start {
+		return ""
+	}
+	return line[start+1 : end]
+}
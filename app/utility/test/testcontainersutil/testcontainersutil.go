@@ -14,10 +14,12 @@ import (
 var dockerComposeFilePaths []string
 var dockerComposeExecutionIdentifier string
 
+// databaseContainerAddresses are the addresses of the postgres and the mariadb test databases
+var databaseContainerAddresses = []string{"localhost:50000", "localhost:50001"}
+
 func DockerComposeUp() bool {
 	setRelativePathToDockerComposeFile()
-	response, err := pingDatabaseContainer()
-	if err != nil && strings.Contains(err.Error(), "EOF") || err == nil && response.StatusCode == http.StatusOK {
+	if areDatabaseContainersUp() {
 		log.Println("Docker container already running, skipping test containers setup")
 		return true
 	}
@@ -27,7 +29,7 @@ func DockerComposeUp() bool {
 	dockerComposeExecutionError := dockerCompose.
 		WithCommand([]string{"up", "-d"}).
 		Invoke()
-	err = dockerComposeExecutionError.Error
+	err := dockerComposeExecutionError.Error
 	if err != nil {
 		log.Printf("Could not run docker-compose files: %v - %v", dockerComposeFilePaths, err)
 		return false
@@ -52,8 +54,8 @@ func DockerComposeDown() {
 	log.Println("Test docker containers terminated")
 }
 
-func pingDatabaseContainer() (*http.Response, error) {
-	response, err := http.Get("http://localhost:50000")
+func pingDatabaseContainer(address string) (*http.Response, error) {
+	response, err := http.Get("http://" + address)
 	if err != nil {
 		return nil, err
 	} else {
@@ -61,6 +63,20 @@ func pingDatabaseContainer() (*http.Response, error) {
 	}
 }
 
+// areDatabaseContainersUp checks whether every test database answers, postgres hangs up on the ping
+// while mariadb greets it with its handshake
+func areDatabaseContainersUp() bool {
+	for _, address := range databaseContainerAddresses {
+		response, err := pingDatabaseContainer(address)
+		isUp := err != nil && (strings.Contains(err.Error(), "EOF") || strings.Contains(err.Error(), "malformed HTTP response")) ||
+			err == nil && response.StatusCode == http.StatusOK
+		if !isUp {
+			return false
+		}
+	}
+	return true
+}
+
 // For some reason I can't get the wait strategy to work with testcontainers-go
 // so this function should wait for the database to be ready
 func isDatabaseReady() bool {
@@ -73,8 +89,7 @@ func isDatabaseReady() bool {
 			DockerComposeDown()
 			return false
 		case <-tick:
-			response, err := pingDatabaseContainer()
-			if err != nil && strings.Contains(err.Error(), "EOF") || err == nil && response.StatusCode == http.StatusOK {
+			if areDatabaseContainersUp() {
 				log.Println("Test database is ready to use")
 				return true
 			}
@@ -0,0 +1,138 @@
+package webauthnutil
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/fxamacker/cbor/v2"
+	"log"
+)
+
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+// SoftwareAuthenticator emulates a WebAuthn authenticator holding a single ES256 credential,
+// so registration and assertion ceremonies can be tested without any hardware
+type SoftwareAuthenticator struct {
+	CredentialId     []byte
+	PrivateKey       *ecdsa.PrivateKey
+	SignCount        uint32
+	RelyingPartyId   string
+	Origin           string
+	UserVerification bool
+}
+
+type AssertionResponse struct {
+	ClientDataJson    []byte
+	AuthenticatorData []byte
+	Signature         []byte
+}
+
+func GenerateTestWebauthnConfiguration() *config.Webauthn {
+	return &config.Webauthn{
+		RelyingPartyId:            "localhost",
+		RelyingPartyName:          "GoKeeper",
+		Origin:                    "http://localhost:3000",
+		ChallengeTimeoutInSeconds: 60,
+	}
+}
+
+func NewSoftwareAuthenticator(webauthnConfig *config.Webauthn) *SoftwareAuthenticator {
+	privateKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		log.Panicf("Could not generate software authenticator key: %s", err)
+	}
+
+	credentialId := make([]byte, 16)
+	rand.Read(credentialId)
+
+	return &SoftwareAuthenticator{
+		CredentialId:     credentialId,
+		PrivateKey:       privateKey,
+		RelyingPartyId:   webauthnConfig.RelyingPartyId,
+		Origin:           webauthnConfig.Origin,
+		UserVerification: true,
+	}
+}
+
+// Register returns the client data JSON and the attestation object of a registration ceremony
+func (authenticator *SoftwareAuthenticator) Register(challenge []byte) ([]byte, []byte) {
+	publicKey, err := cbor.Marshal(map[int]interface{}{
+		1:  2,  // EC2 key type
+		3:  -7, // ES256 algorithm
+		-1: 1,  // P-256 curve
+		-2: authenticator.PrivateKey.X.FillBytes(make([]byte, 32)),
+		-3: authenticator.PrivateKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		log.Panicf("Could not encode software authenticator public key: %s", err)
+	}
+
+	attestedCredentialData := make([]byte, 16)
+	credentialIdLength := make([]byte, 2)
+	binary.BigEndian.PutUint16(credentialIdLength, uint16(len(authenticator.CredentialId)))
+	attestedCredentialData = append(attestedCredentialData, credentialIdLength...)
+	attestedCredentialData = append(attestedCredentialData, authenticator.CredentialId...)
+	attestedCredentialData = append(attestedCredentialData, publicKey...)
+
+	authenticatorData := append(authenticator.authenticatorData(flagAttestedCredentialData), attestedCredentialData...)
+	attestationObject, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authenticatorData,
+	})
+	if err != nil {
+		log.Panicf("Could not encode software authenticator attestation object: %s", err)
+	}
+
+	return authenticator.clientData("webauthn.create", challenge), attestationObject
+}
+
+// Assert signs the given challenge incrementing the authenticator sign count
+func (authenticator *SoftwareAuthenticator) Assert(challenge []byte) *AssertionResponse {
+	authenticator.SignCount++
+	clientDataJson := authenticator.clientData("webauthn.get", challenge)
+	authenticatorData := authenticator.authenticatorData(0)
+
+	clientDataHash := sha256.Sum256(clientDataJson)
+	signedData := sha256.Sum256(append(append([]byte{}, authenticatorData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, authenticator.PrivateKey, signedData[:])
+	if err != nil {
+		log.Panicf("Could not sign assertion with software authenticator: %s", err)
+	}
+
+	return &AssertionResponse{ClientDataJson: clientDataJson, AuthenticatorData: authenticatorData, Signature: signature}
+}
+
+func (authenticator *SoftwareAuthenticator) authenticatorData(additionalFlags byte) []byte {
+	flags := flagUserPresent | additionalFlags
+	if authenticator.UserVerification {
+		flags |= flagUserVerified
+	}
+
+	relyingPartyIdHash := sha256.Sum256([]byte(authenticator.RelyingPartyId))
+	signCount := make([]byte, 4)
+	binary.BigEndian.PutUint32(signCount, authenticator.SignCount)
+
+	data := append([]byte{}, relyingPartyIdHash[:]...)
+	data = append(data, flags)
+	return append(data, signCount...)
+}
+
+func (authenticator *SoftwareAuthenticator) clientData(ceremonyType string, challenge []byte) []byte {
+	clientDataJson, _ := json.Marshal(map[string]string{
+		"type":      ceremonyType,
+		"challenge": base64.RawURLEncoding.EncodeToString(challenge),
+		"origin":    authenticator.Origin,
+	})
+
+	return clientDataJson
+}
@@ -0,0 +1,76 @@
+package webauthn
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Authenticator data flags as defined by the WebAuthn specification
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+)
+
+const (
+	relyingPartyIdHashLength = 32
+	minimalAuthenticatorData = relyingPartyIdHashLength + 1 + 4
+	aaguidLength             = 16
+)
+
+var errMalformedAuthenticatorData = errors.New("malformed authenticator data")
+
+type authenticatorData struct {
+	relyingPartyIdHash []byte
+	flags              byte
+	signCount          uint32
+	credentialId       []byte
+	publicKey          []byte
+}
+
+func (data *authenticatorData) userPresent() bool {
+	return data.flags&flagUserPresent != 0
+}
+
+func (data *authenticatorData) userVerified() bool {
+	return data.flags&flagUserVerified != 0
+}
+
+func parseAuthenticatorData(rawData []byte) (*authenticatorData, error) {
+	if len(rawData) < minimalAuthenticatorData {
+		return nil, errMalformedAuthenticatorData
+	}
+
+	data := &authenticatorData{
+		relyingPartyIdHash: rawData[:relyingPartyIdHashLength],
+		flags:              rawData[relyingPartyIdHashLength],
+		signCount:          binary.BigEndian.Uint32(rawData[relyingPartyIdHashLength+1 : minimalAuthenticatorData]),
+	}
+
+	if data.flags&flagAttestedCredentialData == 0 {
+		return data, nil
+	}
+
+	attestedCredentialData := rawData[minimalAuthenticatorData:]
+	if len(attestedCredentialData) < aaguidLength+2 {
+		return nil, errMalformedAuthenticatorData
+	}
+	credentialIdLength := int(binary.BigEndian.Uint16(attestedCredentialData[aaguidLength : aaguidLength+2]))
+	attestedCredentialData = attestedCredentialData[aaguidLength+2:]
+	if len(attestedCredentialData) < credentialIdLength {
+		return nil, errMalformedAuthenticatorData
+	}
+	data.credentialId = attestedCredentialData[:credentialIdLength]
+
+	// The credential public key is a single CBOR item, any extension data following it is ignored
+	var publicKey cbor.RawMessage
+	decoder := cbor.NewDecoder(bytes.NewReader(attestedCredentialData[credentialIdLength:]))
+	if err := decoder.Decode(&publicKey); err != nil {
+		return nil, errMalformedAuthenticatorData
+	}
+	data.publicKey = publicKey
+
+	return data, nil
+}
@@ -0,0 +1,44 @@
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"errors"
+	"github.com/fxamacker/cbor/v2"
+	"math/big"
+)
+
+// COSE key parameters, only ES256 keys on the P-256 curve are supported
+const (
+	coseKeyTypeEc2     = 2
+	coseAlgorithmEs256 = -7
+	coseCurveP256      = 1
+)
+
+var errUnsupportedPublicKey = errors.New("unsupported credential public key")
+
+type coseKey struct {
+	KeyType   int    `cbor:"1,keyasint"`
+	Algorithm int    `cbor:"3,keyasint"`
+	Curve     int    `cbor:"-1,keyasint"`
+	X         []byte `cbor:"-2,keyasint"`
+	Y         []byte `cbor:"-3,keyasint"`
+}
+
+func parsePublicKey(encodedKey []byte) (*ecdsa.PublicKey, error) {
+	key := coseKey{}
+	if err := cbor.Unmarshal(encodedKey, &key); err != nil {
+		return nil, errUnsupportedPublicKey
+	}
+
+	if key.KeyType != coseKeyTypeEc2 || key.Algorithm != coseAlgorithmEs256 || key.Curve != coseCurveP256 {
+		return nil, errUnsupportedPublicKey
+	}
+
+	publicKey := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(key.X), Y: new(big.Int).SetBytes(key.Y)}
+	if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+		return nil, errUnsupportedPublicKey
+	}
+
+	return publicKey, nil
+}
@@ -0,0 +1,221 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/fxamacker/cbor/v2"
+	"time"
+)
+
+const (
+	RegistrationCeremony = "registration"
+	AssertionCeremony    = "assertion"
+
+	clientDataTypeCreate = "webauthn.create"
+	clientDataTypeGet    = "webauthn.get"
+	attestationNone      = "none"
+	challengeByteSize    = 32
+)
+
+// Variable meant for mocking
+var readRandom = rand.Read
+
+var (
+	ErrClientDataMismatch      = errors.New("client data does not match the ceremony")
+	ErrRelyingPartyMismatch    = errors.New("authenticator data was not created for this relying party")
+	ErrUserNotPresent          = errors.New("user presence was not asserted by the authenticator")
+	ErrUserNotVerified         = errors.New("user verification was not performed by the authenticator")
+	ErrUnsupportedAttestation  = errors.New("unsupported attestation format")
+	ErrMissingCredentialData   = errors.New("attestation does not contain credential data")
+	ErrInvalidSignature        = errors.New("invalid assertion signature")
+	ErrSignCountNotIncremented = errors.New("authenticator sign count did not increase, the credential might be cloned")
+)
+
+type RegisteredCredential struct {
+	CredentialId []byte
+	PublicKey    []byte
+	SignCount    uint32
+}
+
+type WebauthnVerifier interface {
+	GenerateChallenge() ([]byte, error)
+	ChallengeTimeout() time.Duration
+	RelyingPartyId() string
+	RelyingPartyName() string
+	VerifyRegistration(challenge []byte, clientDataJson []byte, attestationObject []byte) (*RegisteredCredential, error)
+	VerifyAssertion(
+		challenge []byte, clientDataJson []byte, authenticatorData []byte, signature []byte,
+		publicKey []byte, storedSignCount uint32, requireUserVerification bool,
+	) (uint32, error)
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type attestationObject struct {
+	Format            string          `cbor:"fmt"`
+	AttestationStmt   cbor.RawMessage `cbor:"attStmt"`
+	AuthenticatorData []byte          `cbor:"authData"`
+}
+
+type webauthnService struct {
+	relyingPartyId     string
+	relyingPartyName   string
+	relyingPartyIdHash []byte
+	origin             string
+	challengeTimeout   time.Duration
+}
+
+func NewWebauthnService(webauthnConfig *config.Webauthn) *webauthnService {
+	relyingPartyIdHash := sha256.Sum256([]byte(webauthnConfig.RelyingPartyId))
+	return &webauthnService{
+		relyingPartyId:     webauthnConfig.RelyingPartyId,
+		relyingPartyName:   webauthnConfig.RelyingPartyName,
+		relyingPartyIdHash: relyingPartyIdHash[:],
+		origin:             webauthnConfig.Origin,
+		challengeTimeout:   time.Duration(webauthnConfig.ChallengeTimeoutInSeconds) * time.Second,
+	}
+}
+
+func (service *webauthnService) GenerateChallenge() ([]byte, error) {
+	challenge := make([]byte, challengeByteSize)
+	if _, err := readRandom(challenge); err != nil {
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+func (service *webauthnService) ChallengeTimeout() time.Duration {
+	return service.challengeTimeout
+}
+
+func (service *webauthnService) RelyingPartyId() string {
+	return service.relyingPartyId
+}
+
+func (service *webauthnService) RelyingPartyName() string {
+	return service.relyingPartyName
+}
+
+// VerifyRegistration verifies a registration ceremony response, attestation statements are not verified
+// since GoKeeper doesn't restrict which authenticators users can register
+func (service *webauthnService) VerifyRegistration(
+	challenge []byte, clientDataJson []byte, attestationObjectData []byte,
+) (*RegisteredCredential, error) {
+	if err := service.verifyClientData(clientDataJson, clientDataTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	attestation := attestationObject{}
+	if err := cbor.Unmarshal(attestationObjectData, &attestation); err != nil {
+		return nil, err
+	}
+	if attestation.Format != attestationNone {
+		return nil, ErrUnsupportedAttestation
+	}
+
+	data, err := service.verifyAuthenticatorData(attestation.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	if data.credentialId == nil || data.publicKey == nil {
+		return nil, ErrMissingCredentialData
+	}
+	if _, err := parsePublicKey(data.publicKey); err != nil {
+		return nil, err
+	}
+
+	return &RegisteredCredential{CredentialId: data.credentialId, PublicKey: data.publicKey, SignCount: data.signCount}, nil
+}
+
+// VerifyAssertion verifies an assertion ceremony response and returns the new authenticator sign count,
+// user verification should be required whenever the assertion replaces the master password
+func (service *webauthnService) VerifyAssertion(
+	challenge []byte, clientDataJson []byte, authenticatorData []byte, signature []byte,
+	publicKey []byte, storedSignCount uint32, requireUserVerification bool,
+) (uint32, error) {
+	if err := service.verifyClientData(clientDataJson, clientDataTypeGet, challenge); err != nil {
+		return 0, err
+	}
+
+	data, err := service.verifyAuthenticatorData(authenticatorData)
+	if err != nil {
+		return 0, err
+	}
+	if requireUserVerification && !data.userVerified() {
+		return 0, ErrUserNotVerified
+	}
+
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJson)
+	signedData := sha256.Sum256(append(append([]byte{}, authenticatorData...), clientDataHash[:]...))
+	if !ecdsa.VerifyASN1(key, signedData[:], signature) {
+		return 0, ErrInvalidSignature
+	}
+
+	// Authenticators that don't implement a signature counter always report zero
+	if (data.signCount != 0 || storedSignCount != 0) && data.signCount <= storedSignCount {
+		return 0, ErrSignCountNotIncremented
+	}
+
+	return data.signCount, nil
+}
+
+// ParseChallenge extracts the challenge a ceremony response was made for, so the stored challenge can be looked up
+func ParseChallenge(clientDataJson []byte) ([]byte, error) {
+	data := clientData{}
+	if err := json.Unmarshal(clientDataJson, &data); err != nil {
+		return nil, err
+	}
+
+	return base64.RawURLEncoding.DecodeString(data.Challenge)
+}
+
+func (service *webauthnService) verifyClientData(clientDataJson []byte, expectedType string, challenge []byte) error {
+	data := clientData{}
+	if err := json.Unmarshal(clientDataJson, &data); err != nil {
+		return err
+	}
+
+	receivedChallenge, err := base64.RawURLEncoding.DecodeString(data.Challenge)
+	if err != nil {
+		return ErrClientDataMismatch
+	}
+
+	if data.Type != expectedType || data.Origin != service.origin || subtle.ConstantTimeCompare(receivedChallenge, challenge) == 0 {
+		return ErrClientDataMismatch
+	}
+
+	return nil
+}
+
+func (service *webauthnService) verifyAuthenticatorData(rawData []byte) (*authenticatorData, error) {
+	data, err := parseAuthenticatorData(rawData)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.Equal(data.relyingPartyIdHash, service.relyingPartyIdHash) {
+		return nil, ErrRelyingPartyMismatch
+	}
+	if !data.userPresent() {
+		return nil, ErrUserNotPresent
+	}
+
+	return data, nil
+}
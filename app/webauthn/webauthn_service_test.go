@@ -0,0 +1,148 @@
+package webauthn
+
+import (
+	"crypto/rand"
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/webauthnutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+// GenerateChallenge should generate a random challenge of the expected size
+func TestGenerateChallenge(t *testing.T) {
+	webauthnService := NewWebauthnService(webauthnutil.GenerateTestWebauthnConfiguration())
+	challenge, err := webauthnService.GenerateChallenge()
+	assert.Nil(t, err, "Should not return any errors")
+	assert.Equal(t, len(challenge), 32, "Challenge should be of expected length")
+	assert.Equal(t, webauthnService.ChallengeTimeout(), 60*time.Second)
+}
+
+// GenerateChallenge should return an error when reading random bytes fails
+func TestGenerateChallengeWithRandomReadError(t *testing.T) {
+	readRandom = func(b []byte) (int, error) { return 0, errors.New("error") }
+	defer func() { readRandom = rand.Read }()
+
+	webauthnService := NewWebauthnService(webauthnutil.GenerateTestWebauthnConfiguration())
+	challenge, err := webauthnService.GenerateChallenge()
+	assert.Equal(t, err, errors.New("error"), "Should return root error")
+	assert.Nil(t, challenge, "Should not return any challenge")
+}
+
+// VerifyRegistration should successfully verify a registration made by an authenticator
+func TestVerifyRegistration(t *testing.T) {
+	webauthnConfig := webauthnutil.GenerateTestWebauthnConfiguration()
+	webauthnService := NewWebauthnService(webauthnConfig)
+	authenticator := webauthnutil.NewSoftwareAuthenticator(webauthnConfig)
+	challenge, _ := webauthnService.GenerateChallenge()
+
+	clientDataJson, attestationObject := authenticator.Register(challenge)
+	credential, err := webauthnService.VerifyRegistration(challenge, clientDataJson, attestationObject)
+	assert.Nil(t, err, "Should not return any errors")
+	assert.Equal(t, credential.CredentialId, authenticator.CredentialId)
+	assert.NotNil(t, credential.PublicKey, "Should return the credential public key")
+	assert.Equal(t, credential.SignCount, uint32(0))
+}
+
+// VerifyRegistration should reject responses signed for another challenge
+func TestVerifyRegistrationWithChallengeMismatch(t *testing.T) {
+	webauthnConfig := webauthnutil.GenerateTestWebauthnConfiguration()
+	webauthnService := NewWebauthnService(webauthnConfig)
+	authenticator := webauthnutil.NewSoftwareAuthenticator(webauthnConfig)
+	challenge, _ := webauthnService.GenerateChallenge()
+	otherChallenge, _ := webauthnService.GenerateChallenge()
+
+	clientDataJson, attestationObject := authenticator.Register(otherChallenge)
+	credential, err := webauthnService.VerifyRegistration(challenge, clientDataJson, attestationObject)
+	assert.Equal(t, err, ErrClientDataMismatch)
+	assert.Nil(t, credential, "Should not return any credential")
+}
+
+// VerifyRegistration should reject responses created for another relying party
+func TestVerifyRegistrationWithRelyingPartyMismatch(t *testing.T) {
+	webauthnConfig := webauthnutil.GenerateTestWebauthnConfiguration()
+	webauthnService := NewWebauthnService(webauthnConfig)
+	authenticator := webauthnutil.NewSoftwareAuthenticator(webauthnConfig)
+	authenticator.RelyingPartyId = "evil.com"
+	challenge, _ := webauthnService.GenerateChallenge()
+
+	clientDataJson, attestationObject := authenticator.Register(challenge)
+	credential, err := webauthnService.VerifyRegistration(challenge, clientDataJson, attestationObject)
+	assert.Equal(t, err, ErrRelyingPartyMismatch)
+	assert.Nil(t, credential, "Should not return any credential")
+}
+
+// VerifyAssertion should successfully verify an assertion made with a registered credential
+func TestVerifyAssertion(t *testing.T) {
+	webauthnConfig := webauthnutil.GenerateTestWebauthnConfiguration()
+	webauthnService := NewWebauthnService(webauthnConfig)
+	authenticator := webauthnutil.NewSoftwareAuthenticator(webauthnConfig)
+	credential := registerTestCredential(webauthnService, authenticator)
+
+	challenge, _ := webauthnService.GenerateChallenge()
+	assertion := authenticator.Assert(challenge)
+	signCount, err := webauthnService.VerifyAssertion(
+		challenge, assertion.ClientDataJson, assertion.AuthenticatorData, assertion.Signature, credential.PublicKey, 0, true,
+	)
+	assert.Nil(t, err, "Should not return any errors")
+	assert.Equal(t, signCount, uint32(1), "Should return the new sign count")
+}
+
+// VerifyAssertion should reject assertions signed by another key
+func TestVerifyAssertionWithInvalidSignature(t *testing.T) {
+	webauthnConfig := webauthnutil.GenerateTestWebauthnConfiguration()
+	webauthnService := NewWebauthnService(webauthnConfig)
+	credential := registerTestCredential(webauthnService, webauthnutil.NewSoftwareAuthenticator(webauthnConfig))
+
+	challenge, _ := webauthnService.GenerateChallenge()
+	assertion := webauthnutil.NewSoftwareAuthenticator(webauthnConfig).Assert(challenge)
+	signCount, err := webauthnService.VerifyAssertion(
+		challenge, assertion.ClientDataJson, assertion.AuthenticatorData, assertion.Signature, credential.PublicKey, 0, false,
+	)
+	assert.Equal(t, err, ErrInvalidSignature)
+	assert.Equal(t, signCount, uint32(0))
+}
+
+// VerifyAssertion should reject assertions whose sign count didn't increase
+func TestVerifyAssertionWithStaleSignCount(t *testing.T) {
+	webauthnConfig := webauthnutil.GenerateTestWebauthnConfiguration()
+	webauthnService := NewWebauthnService(webauthnConfig)
+	authenticator := webauthnutil.NewSoftwareAuthenticator(webauthnConfig)
+	credential := registerTestCredential(webauthnService, authenticator)
+
+	challenge, _ := webauthnService.GenerateChallenge()
+	assertion := authenticator.Assert(challenge)
+	signCount, err := webauthnService.VerifyAssertion(
+		challenge, assertion.ClientDataJson, assertion.AuthenticatorData, assertion.Signature, credential.PublicKey, 5, false,
+	)
+	assert.Equal(t, err, ErrSignCountNotIncremented)
+	assert.Equal(t, signCount, uint32(0))
+}
+
+// VerifyAssertion should reject assertions without user verification when it is required
+func TestVerifyAssertionWithoutUserVerification(t *testing.T) {
+	webauthnConfig := webauthnutil.GenerateTestWebauthnConfiguration()
+	webauthnService := NewWebauthnService(webauthnConfig)
+	authenticator := webauthnutil.NewSoftwareAuthenticator(webauthnConfig)
+	credential := registerTestCredential(webauthnService, authenticator)
+	authenticator.UserVerification = false
+
+	challenge, _ := webauthnService.GenerateChallenge()
+	assertion := authenticator.Assert(challenge)
+	signCount, err := webauthnService.VerifyAssertion(
+		challenge, assertion.ClientDataJson, assertion.AuthenticatorData, assertion.Signature, credential.PublicKey, 0, true,
+	)
+	assert.Equal(t, err, ErrUserNotVerified)
+	assert.Equal(t, signCount, uint32(0))
+}
+
+func registerTestCredential(webauthnService *webauthnService, authenticator *webauthnutil.SoftwareAuthenticator) *RegisteredCredential {
+	challenge, _ := webauthnService.GenerateChallenge()
+	clientDataJson, attestationObject := authenticator.Register(challenge)
+	credential, err := webauthnService.VerifyRegistration(challenge, clientDataJson, attestationObject)
+	if err != nil {
+		panic(err)
+	}
+
+	return credential
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"encoding/json"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Variables meant for mocking
+var now = func() time.Time { return time.Now().UTC() }
+
+// payload is everything a receiver gets to know about an event, it's built field by field
+// so no secret value can ever end up in a delivery
+type payload struct {
+	Event      string  `json:"event"`
+	Sequence   int64   `json:"sequence"`
+	OccurredAt string  `json:"occurredAt"`
+	UserId     *string `json:"userId"`
+	IpAddress  string  `json:"ipAddress"`
+	Details    *string `json:"details"`
+}
+
+type dispatcher struct {
+	webhookRepository repository.WebhookRepository
+}
+
+func NewDispatcher(webhookRepository repository.WebhookRepository) *dispatcher {
+	return &dispatcher{webhookRepository: webhookRepository}
+}
+
+// Publish queues a delivery of the event for every webhook subscribed to its type, deliveries are sent by the Worker
+func (dispatcher *dispatcher) Publish(event *model.AuditEvent) {
+	webhooks := model.Webhooks{}
+	if err := dispatcher.webhookRepository.FetchWebhooksByEventType(&webhooks, event.Type); err != nil {
+		log.Printf("Error while fetching webhooks for %s event: %s", event.Type, err)
+		return
+	}
+	if len(webhooks) == 0 {
+		return
+	}
+
+	eventPayload, err := json.Marshal(toPayload(event))
+	if err != nil {
+		log.Printf("Error while encoding webhook payload: %s", err)
+		return
+	}
+
+	deliveries := model.WebhookDeliveries{}
+	for _, webhook := range webhooks {
+		deliveries = append(deliveries, model.WebhookDelivery{
+			WebhookId:     webhook.Id,
+			EventType:     event.Type,
+			Payload:       string(eventPayload),
+			Status:        model.WebhookDeliveryPending,
+			NextAttemptAt: now(),
+			CreatedAt:     now(),
+		})
+	}
+	if err = dispatcher.webhookRepository.InsertNewDeliveries(deliveries); err != nil {
+		log.Printf("Error while queuing webhook deliveries for %s event: %s", event.Type, err)
+	}
+}
+
+func toPayload(event *model.AuditEvent) *payload {
+	eventPayload := &payload{
+		Event:      event.Type,
+		Sequence:   event.Sequence,
+		OccurredAt: event.CreatedAt.UTC().Format(time.RFC3339Nano),
+		IpAddress:  event.IpAddress,
+		Details:    event.Details,
+	}
+	if event.UserId != nil {
+		userId := strconv.FormatUint(*event.UserId, 10)
+		eventPayload.UserId = &userId
+	}
+	return eventPayload
+}
@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+)
+
+// Publish should queue a delivery with the event's metadata for every subscribed webhook
+func TestPublish(t *testing.T) {
+	webhookRepositoryMock := new(mockutil.WebhookRepositoryServiceMock)
+	secondWebhook := mockutil.DefaultWebhook()
+	secondWebhook.Id = 2
+	webhookRepositoryMock.On("FetchWebhooksByEventType", mock.Anything, model.AuditEventSignIn).Return(
+		nil, model.Webhooks{mockutil.DefaultWebhook(), secondWebhook},
+	)
+	webhookRepositoryMock.On("InsertNewDeliveries", mock.Anything).Return(nil)
+
+	NewDispatcher(webhookRepositoryMock).Publish(testEvent())
+
+	deliveries := webhookRepositoryMock.Calls[1].Arguments.Get(0).(model.WebhookDeliveries)
+	assert.Equal(t, len(deliveries), 2)
+	assert.Equal(t, deliveries[1].WebhookId, uint64(2))
+	assert.Equal(t, deliveries[0].Status, model.WebhookDeliveryPending)
+	assert.Equal(t, deliveries[0].EventType, model.AuditEventSignIn)
+
+	payload := map[string]interface{}{}
+	assert.Nil(t, json.Unmarshal([]byte(deliveries[0].Payload), &payload))
+	assert.Equal(t, payload, map[string]interface{}{
+		"event":      model.AuditEventSignIn,
+		"sequence":   float64(7),
+		"occurredAt": "2021-01-01T00:00:00Z",
+		"userId":     mockutil.DefaultIdAsString,
+		"ipAddress":  "127.0.0.1",
+		"details":    "session 1",
+	}, "Payload should only contain the event's metadata")
+}
+
+// Publish should not queue anything if no webhook is subscribed to the event or the webhooks can't be fetched
+func TestPublishWithoutSubscribedWebhooks(t *testing.T) {
+	webhookRepositoryMock := new(mockutil.WebhookRepositoryServiceMock)
+	webhookRepositoryMock.On("FetchWebhooksByEventType", mock.Anything, mock.Anything).Return(nil, model.Webhooks{}).Once()
+	webhookRepositoryMock.On("FetchWebhooksByEventType", mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage), nil,
+	).Once()
+
+	NewDispatcher(webhookRepositoryMock).Publish(testEvent())
+	NewDispatcher(webhookRepositoryMock).Publish(testEvent())
+	webhookRepositoryMock.AssertNotCalled(t, "InsertNewDeliveries", mock.Anything)
+}
+
+func testEvent() *model.AuditEvent {
+	userId := mockutil.DefaultIdAsUint64
+	details := "session 1"
+	return &model.AuditEvent{
+		Id:           3,
+		Sequence:     7,
+		UserId:       &userId,
+		Type:         model.AuditEventSignIn,
+		IpAddress:    "127.0.0.1",
+		UserAgent:    "test-agent",
+		Details:      &details,
+		CreatedAt:    time.Date(2021, time.January, 1, 0, 0, 0, 0, time.UTC),
+		PreviousHash: []byte("previous"),
+		Hash:         []byte("hash"),
+	}
+}
@@ -0,0 +1,24 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+const (
+	EventHeader     = "X-GoKeeper-Event"
+	DeliveryHeader  = "X-GoKeeper-Delivery"
+	TimestampHeader = "X-GoKeeper-Timestamp"
+	SignatureHeader = "X-GoKeeper-Signature"
+)
+
+// Sign computes the HMAC-SHA256 signature of a delivery, the timestamp is signed along with the payload
+// so receivers can reject replayed deliveries
+func Sign(secret []byte, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,20 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// Sign should compute the HMAC-SHA256 signature of the timestamp and the payload
+func TestSign(t *testing.T) {
+	mac := hmac.New(sha256.New, []byte("secret"))
+	mac.Write([]byte(`1609459200.{"event":"sign_in"}`))
+
+	signature := Sign([]byte("secret"), "1609459200", []byte(`{"event":"sign_in"}`))
+	assert.Equal(t, signature, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	assert.NotEqual(t, Sign([]byte("secret"), "1609459201", []byte(`{"event":"sign_in"}`)), signature, "Should sign the timestamp")
+	assert.NotEqual(t, Sign([]byte("other"), "1609459200", []byte(`{"event":"sign_in"}`)), signature, "Should depend on the secret")
+}
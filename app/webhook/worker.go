@@ -0,0 +1,189 @@
+package webhook
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/database/repository"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	deliveryBatchSize      = 100
+	maximumErrorLength     = 256
+	maximumResponseBodyLog = 1024
+)
+
+// Worker periodically sends due deliveries, failed deliveries are retried with an exponential backoff
+// and moved to the dead letters once they exhaust their attempts
+type Worker struct {
+	webhookRepository   repository.WebhookRepository
+	client              *http.Client
+	interval            time.Duration
+	maxDeliveryAttempts int
+	initialBackoff      time.Duration
+	maxBackoff          time.Duration
+	stop                chan struct{}
+	stopOnce            sync.Once
+	waitGroup           sync.WaitGroup
+}
+
+func NewWorker(webhookConfig *config.Webhook, webhookRepository repository.WebhookRepository) *Worker {
+	maxDeliveryAttempts := webhookConfig.MaxDeliveryAttempts
+	if maxDeliveryAttempts < 1 {
+		maxDeliveryAttempts = 1
+	}
+
+	return &Worker{
+		webhookRepository: webhookRepository,
+		client: &http.Client{
+			Timeout: time.Duration(webhookConfig.TimeoutInSeconds) * time.Second,
+			// A redirect could point the signed delivery somewhere it wasn't meant to go
+			CheckRedirect: func(request *http.Request, via []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		interval:            time.Duration(webhookConfig.DeliveryIntervalInSeconds) * time.Second,
+		maxDeliveryAttempts: maxDeliveryAttempts,
+		initialBackoff:      time.Duration(webhookConfig.InitialBackoffInSeconds) * time.Second,
+		maxBackoff:          time.Duration(webhookConfig.MaxBackoffInSeconds) * time.Second,
+		stop:                make(chan struct{}),
+	}
+}
+
+func (worker *Worker) Start() {
+	worker.waitGroup.Add(1)
+	go func() {
+		defer worker.waitGroup.Done()
+		ticker := time.NewTicker(worker.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-worker.stop:
+				return
+			case <-ticker.C:
+				worker.SendDueDeliveries()
+			}
+		}
+	}()
+}
+
+// Stop waits for running deliveries to finish
+func (worker *Worker) Stop() {
+	worker.stopOnce.Do(func() { close(worker.stop) })
+	worker.waitGroup.Wait()
+}
+
+// SendDueDeliveries sends a batch of due deliveries and returns the number of successful ones
+func (worker *Worker) SendDueDeliveries() int {
+	deliveries := model.WebhookDeliveries{}
+	if err := worker.webhookRepository.FetchDueDeliveries(&deliveries, now(), deliveryBatchSize); err != nil {
+		log.Printf("Error while fetching due webhook deliveries: %s", err)
+		return 0
+	}
+	if len(deliveries) == 0 {
+		return 0
+	}
+
+	webhooks := model.Webhooks{}
+	if err := worker.webhookRepository.FetchWebhooks(&webhooks); err != nil {
+		log.Printf("Error while fetching webhooks: %s", err)
+		return 0
+	}
+	webhooksById := map[uint64]*model.Webhook{}
+	for index := range webhooks {
+		webhooksById[webhooks[index].Id] = &webhooks[index]
+	}
+
+	successfulDeliveries := 0
+	for index := range deliveries {
+		delivery := &deliveries[index]
+		webhook, ok := webhooksById[delivery.WebhookId]
+		if !ok {
+			// The webhook got deleted in the meantime, together with its deliveries
+			continue
+		}
+		if worker.send(webhook, delivery) {
+			successfulDeliveries++
+		}
+	}
+	return successfulDeliveries
+}
+
+func (worker *Worker) send(webhook *model.Webhook, delivery *model.WebhookDelivery) bool {
+	delivery.Attempts++
+	responseStatus, err := worker.post(webhook, delivery)
+	delivery.ResponseStatus = responseStatus
+	if err == nil {
+		delivery.Status = model.WebhookDeliveryDelivered
+		delivery.LastError = nil
+		if err = worker.webhookRepository.UpdateDeliveryAttempt(delivery); err != nil {
+			log.Printf("Error while storing webhook delivery %d: %s", delivery.Id, err)
+		}
+		return true
+	}
+
+	lastError := err.Error()
+	if len(lastError) > maximumErrorLength {
+		lastError = lastError[:maximumErrorLength]
+	}
+	delivery.LastError = &lastError
+	log.Printf(
+		"Webhook delivery %d to %s failed on attempt %d/%d: %s",
+		delivery.Id, webhook.Url, delivery.Attempts, worker.maxDeliveryAttempts, lastError,
+	)
+
+	if delivery.Attempts >= worker.maxDeliveryAttempts {
+		err = worker.webhookRepository.MoveDeliveryToDeadLetter(delivery)
+	} else {
+		delivery.NextAttemptAt = now().Add(worker.backoff(delivery.Attempts))
+		err = worker.webhookRepository.UpdateDeliveryAttempt(delivery)
+	}
+	if err != nil {
+		log.Printf("Error while storing webhook delivery %d: %s", delivery.Id, err)
+	}
+	return false
+}
+
+func (worker *Worker) post(webhook *model.Webhook, delivery *model.WebhookDelivery) (*int, error) {
+	timestamp := strconv.FormatInt(now().Unix(), 10)
+	request, err := http.NewRequest(http.MethodPost, webhook.Url, bytes.NewBufferString(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "GoKeeper-Webhook")
+	request.Header.Set(EventHeader, delivery.EventType)
+	request.Header.Set(DeliveryHeader, strconv.FormatUint(delivery.Id, 10))
+	request.Header.Set(TimestampHeader, timestamp)
+	request.Header.Set(SignatureHeader, Sign(webhook.Secret, timestamp, []byte(delivery.Payload)))
+
+	response, err := worker.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(response.Body, maximumResponseBodyLog))
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return &response.StatusCode, fmt.Errorf("unexpected response status %d", response.StatusCode)
+	}
+	return &response.StatusCode, nil
+}
+
+// backoff doubles the wait after every failed attempt, up to the configured maximum
+func (worker *Worker) backoff(attempts int) time.Duration {
+	backoff := worker.initialBackoff
+	for attempt := 1; attempt < attempts && backoff < worker.maxBackoff; attempt++ {
+		backoff *= 2
+	}
+	if backoff > worker.maxBackoff {
+		return worker.maxBackoff
+	}
+	return backoff
+}
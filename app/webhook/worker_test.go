@@ -0,0 +1,147 @@
+package webhook
+
+import (
+	"errors"
+	"github.com/KristijanFaust/gokeeper/app/config"
+	"github.com/KristijanFaust/gokeeper/app/database/model"
+	"github.com/KristijanFaust/gokeeper/app/utility/test/mockutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var testWebhookConfig = &config.Webhook{
+	DeliveryIntervalInSeconds: 1,
+	MaxDeliveryAttempts:       3,
+	InitialBackoffInSeconds:   30,
+	MaxBackoffInSeconds:       100,
+	TimeoutInSeconds:          1,
+}
+
+// SendDueDeliveries should post signed deliveries and mark them as delivered
+func TestSendDueDeliveries(t *testing.T) {
+	defer func(original func() time.Time) { now = original }(now)
+	now = func() time.Time { return time.Unix(1609459200, 0).UTC() }
+	var receivedRequest *http.Request
+	var receivedBody []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		receivedRequest = request
+		receivedBody, _ = io.ReadAll(request.Body)
+	}))
+	defer receiver.Close()
+	webhookRepositoryMock := webhookRepositoryMockWithReceiver(receiver.URL)
+
+	successfulDeliveries := NewWorker(testWebhookConfig, webhookRepositoryMock).SendDueDeliveries()
+	assert.Equal(t, successfulDeliveries, 1)
+
+	assert.Equal(t, string(receivedBody), mockutil.DefaultWebhookDelivery().Payload)
+	assert.Equal(t, receivedRequest.Header.Get(EventHeader), model.AuditEventSignIn)
+	assert.Equal(t, receivedRequest.Header.Get(DeliveryHeader), mockutil.DefaultIdAsString)
+	assert.Equal(t, receivedRequest.Header.Get(TimestampHeader), "1609459200")
+	assert.Equal(
+		t, receivedRequest.Header.Get(SignatureHeader), Sign(mockutil.DefaultWebhook().Secret, "1609459200", receivedBody),
+	)
+	webhookRepositoryMock.AssertCalled(t, "UpdateDeliveryAttempt", mock.MatchedBy(func(delivery *model.WebhookDelivery) bool {
+		return delivery.Status == model.WebhookDeliveryDelivered && delivery.Attempts == 1 && *delivery.ResponseStatus == http.StatusOK
+	}))
+}
+
+// SendDueDeliveries should schedule failed deliveries for a retry with an exponential backoff
+func TestSendDueDeliveriesWithFailedDelivery(t *testing.T) {
+	defer func(original func() time.Time) { now = original }(now)
+	now = func() time.Time { return time.Unix(1609459200, 0).UTC() }
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		writer.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+	webhookRepositoryMock := webhookRepositoryMockWithReceiver(receiver.URL, 1)
+
+	successfulDeliveries := NewWorker(testWebhookConfig, webhookRepositoryMock).SendDueDeliveries()
+	assert.Equal(t, successfulDeliveries, 0)
+	webhookRepositoryMock.AssertCalled(t, "UpdateDeliveryAttempt", mock.MatchedBy(func(delivery *model.WebhookDelivery) bool {
+		return delivery.Status == model.WebhookDeliveryPending && delivery.Attempts == 2 &&
+			delivery.NextAttemptAt.Equal(now().Add(60*time.Second)) && *delivery.LastError == "unexpected response status 500"
+	}))
+	webhookRepositoryMock.AssertNotCalled(t, "MoveDeliveryToDeadLetter", mock.Anything)
+}
+
+// SendDueDeliveries should move deliveries that exhausted their attempts to the dead letters
+func TestSendDueDeliveriesWithExhaustedAttempts(t *testing.T) {
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		http.Redirect(writer, request, "http://localhost:1/elsewhere", http.StatusFound)
+	}))
+	defer receiver.Close()
+	webhookRepositoryMock := webhookRepositoryMockWithReceiver(receiver.URL, 2)
+
+	successfulDeliveries := NewWorker(testWebhookConfig, webhookRepositoryMock).SendDueDeliveries()
+	assert.Equal(t, successfulDeliveries, 0, "Redirects should not be followed")
+	webhookRepositoryMock.AssertCalled(t, "MoveDeliveryToDeadLetter", mock.MatchedBy(func(delivery *model.WebhookDelivery) bool {
+		return delivery.Attempts == 3 && *delivery.ResponseStatus == http.StatusFound
+	}))
+	webhookRepositoryMock.AssertNotCalled(t, "UpdateDeliveryAttempt", mock.Anything)
+}
+
+// SendDueDeliveries should not send anything if the due deliveries can't be fetched
+func TestSendDueDeliveriesWithFetchError(t *testing.T) {
+	webhookRepositoryMock := new(mockutil.WebhookRepositoryServiceMock)
+	webhookRepositoryMock.On("FetchDueDeliveries", mock.Anything, mock.Anything, mock.Anything).Return(
+		errors.New(mockutil.MockedGenericErrorMessage), nil,
+	)
+
+	assert.Equal(t, NewWorker(testWebhookConfig, webhookRepositoryMock).SendDueDeliveries(), 0)
+	webhookRepositoryMock.AssertNotCalled(t, "FetchWebhooks", mock.Anything)
+}
+
+// backoff should double after every attempt up to the maximum
+func TestBackoff(t *testing.T) {
+	worker := NewWorker(testWebhookConfig, nil)
+
+	assert.Equal(t, worker.backoff(1), 30*time.Second)
+	assert.Equal(t, worker.backoff(2), 60*time.Second)
+	assert.Equal(t, worker.backoff(3), 100*time.Second)
+	assert.Equal(t, worker.backoff(50), 100*time.Second)
+}
+
+// Worker should periodically send due deliveries until stopped
+func TestWorker(t *testing.T) {
+	var requests int32
+	receiver := httptest.NewServer(http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		atomic.AddInt32(&requests, 1)
+	}))
+	defer receiver.Close()
+	worker := NewWorker(testWebhookConfig, webhookRepositoryMockWithReceiver(receiver.URL))
+	worker.interval = 10 * time.Millisecond
+
+	worker.Start()
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&requests) >= 1
+	}, time.Second, 5*time.Millisecond, "Worker should send due deliveries")
+	assert.NotPanics(t, func() {
+		worker.Stop()
+		worker.Stop()
+	}, "Stopping the worker twice should not panic")
+}
+
+func webhookRepositoryMockWithReceiver(receiverUrl string, previousAttempts ...int) *mockutil.WebhookRepositoryServiceMock {
+	webhook := mockutil.DefaultWebhook()
+	webhook.Url = receiverUrl
+	delivery := mockutil.DefaultWebhookDelivery()
+	if len(previousAttempts) > 0 {
+		delivery.Attempts = previousAttempts[0]
+	}
+
+	webhookRepositoryMock := new(mockutil.WebhookRepositoryServiceMock)
+	webhookRepositoryMock.On("FetchDueDeliveries", mock.Anything, mock.Anything, deliveryBatchSize).Return(
+		nil, model.WebhookDeliveries{delivery},
+	).Once()
+	webhookRepositoryMock.On("FetchDueDeliveries", mock.Anything, mock.Anything, deliveryBatchSize).Return(nil, model.WebhookDeliveries{})
+	webhookRepositoryMock.On("FetchWebhooks", mock.Anything).Return(nil, model.Webhooks{webhook})
+	webhookRepositoryMock.On("UpdateDeliveryAttempt", mock.Anything).Return(nil)
+	webhookRepositoryMock.On("MoveDeliveryToDeadLetter", mock.Anything).Return(nil)
+	return webhookRepositoryMock
+}
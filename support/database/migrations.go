@@ -0,0 +1,11 @@
+package database
+
+import (
+	"embed"
+)
+
+// Migrations are the schema migrations of every datasource driver, embedded into the binary. The migrations of a driver
+// are in the migration directory named after it.
+//
+//go:embed postgres/migration mysql/migration sqlite/migration
+var Migrations embed.FS
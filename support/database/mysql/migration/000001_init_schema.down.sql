@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `password`;
+DROP TABLE IF EXISTS `user`;
@@ -0,0 +1,18 @@
+CREATE TABLE `user`
+(
+    `id`       bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `email`    varchar(254) NOT NULL UNIQUE, -- RFC standard prohibits e-mails longer than 254 characters
+    `username` varchar(32) NOT NULL,
+    `password` blob NOT NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `password`
+(
+    `id`       bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id`  bigint NOT NULL,
+    `name`     varchar(64) NOT NULL,
+    `password` blob NOT NULL,
+    CONSTRAINT fk_password_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
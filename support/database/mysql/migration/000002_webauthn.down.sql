@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `webauthn_challenge`;
+DROP TABLE IF EXISTS `webauthn_credential`;
@@ -0,0 +1,26 @@
+CREATE TABLE `webauthn_credential`
+(
+    `id`              bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id`         bigint NOT NULL,
+    `credential_id`   varbinary(1023) NOT NULL UNIQUE, -- WebAuthn limits credential ids to 1023 bytes
+    `public_key`      blob NOT NULL, -- COSE encoded credential public key
+    `sign_count`      bigint NOT NULL DEFAULT 0,
+    `name`            varchar(64) NOT NULL,
+    `prf_wrapped_key` blob, -- vault key wrapped client side with the PRF extension output, opaque to the server
+    `created_at`      datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT fk_webauthn_credential_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `webauthn_challenge`
+(
+    `id`         bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id`    bigint NOT NULL,
+    `challenge`  varbinary(255) NOT NULL UNIQUE,
+    `ceremony`   varchar(16) NOT NULL,
+    `expires_at` datetime(6) NOT NULL,
+    CONSTRAINT fk_webauthn_challenge_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `known_device`;
+DROP TABLE IF EXISTS `email_verification_token`;
+ALTER TABLE `user` DROP COLUMN `email_verified`;
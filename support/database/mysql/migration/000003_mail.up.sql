@@ -0,0 +1,24 @@
+ALTER TABLE `user` ADD COLUMN `email_verified` boolean NOT NULL DEFAULT false;
+
+CREATE TABLE `email_verification_token`
+(
+    `id`         bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id`    bigint NOT NULL,
+    `token_hash` varbinary(64) NOT NULL UNIQUE,
+    `expires_at` datetime(6) NOT NULL,
+    CONSTRAINT fk_email_verification_token_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `known_device`
+(
+    `id`           bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id`      bigint NOT NULL,
+    `user_agent`   varchar(512) NOT NULL,
+    `ip_address`   varchar(45) NOT NULL, -- long enough for IPv6 addresses
+    `last_seen_at` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT fk_known_device_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
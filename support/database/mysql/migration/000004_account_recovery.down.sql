@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `account_recovery`;
+ALTER TABLE `user` DROP COLUMN `recovery_wrapped_key`;
@@ -0,0 +1,13 @@
+ALTER TABLE `user` ADD COLUMN `recovery_wrapped_key` blob; -- users created before recovery kits have no recovery key
+
+CREATE TABLE `account_recovery`
+(
+    `id`           bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id`      bigint NOT NULL,
+    `user_agent`   varchar(512) NOT NULL,
+    `ip_address`   varchar(45) NOT NULL,
+    `recovered_at` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT fk_account_recovery_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,46 @@
+CREATE TABLE `user_key_pair`
+(
+    `user_id`             bigint NOT NULL PRIMARY KEY,
+    `public_key`          blob NOT NULL,
+    `wrapped_private_key` blob NOT NULL, -- encrypted with the owner's vault key
+    `created_at`          datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT fk_user_key_pair_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `emergency_access_grant`
+(
+    `id`                bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `grantor_id`        bigint NOT NULL,
+    `grantee_id`        bigint NOT NULL,
+    `access_type`       varchar(16) NOT NULL,
+    `wait_time_in_days` integer NOT NULL,
+    `status`            varchar(16) NOT NULL,
+    `created_at`        datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (`grantor_id`, `grantee_id`),
+    CONSTRAINT fk_emergency_access_grant_grantor
+        FOREIGN KEY(`grantor_id`)
+            REFERENCES `user`(`id`),
+    CONSTRAINT fk_emergency_access_grant_grantee
+        FOREIGN KEY(`grantee_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `emergency_access_request`
+(
+    `id`                bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `grant_id`          bigint NOT NULL,
+    `status`            varchar(16) NOT NULL,
+    `requested_at`      datetime(6) NOT NULL,
+    `available_at`      datetime(6) NOT NULL,
+    `resolved_at`       datetime(6),
+    `sealed_vault_key`  blob, -- grantor's vault key sealed for the grantee once the request is approved
+    CONSTRAINT fk_emergency_access_request_grant
+        FOREIGN KEY(`grant_id`)
+            REFERENCES `emergency_access_grant`(`id`)
+            ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- MySQL has no partial indexes, the status leads the index instead
+CREATE INDEX `emergency_access_request_pending_idx` ON `emergency_access_request` (`status`, `available_at`);
@@ -0,0 +1,17 @@
+CREATE TABLE `user_session`
+(
+    `id`           bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id`      bigint NOT NULL,
+    `device_name`  varchar(128) NOT NULL,
+    `user_agent`   varchar(512) NOT NULL,
+    `ip_address`   varchar(45) NOT NULL, -- long enough for IPv6 addresses
+    `status`       varchar(16) NOT NULL,
+    `created_at`   datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    `last_seen_at` datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    `revoked_at`   datetime(6),
+    CONSTRAINT fk_user_session_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE INDEX `user_session_user_id_idx` ON `user_session` (`user_id`, `status`);
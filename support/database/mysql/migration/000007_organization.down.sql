@@ -0,0 +1,5 @@
+ALTER TABLE `password` DROP FOREIGN KEY fk_password_organization;
+DROP INDEX `password_organization_id_idx` ON `password`;
+ALTER TABLE `password` DROP COLUMN `organization_id`;
+DROP TABLE IF EXISTS `organization_member`;
+DROP TABLE IF EXISTS `organization`;
@@ -0,0 +1,31 @@
+CREATE TABLE `organization`
+(
+    `id`          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `name`        varchar(64) NOT NULL,
+    `key_version` integer NOT NULL DEFAULT 1, -- incremented on every organization key rotation
+    `created_at`  datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE `organization_member`
+(
+    `id`                      bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `organization_id`         bigint NOT NULL,
+    `user_id`                 bigint NOT NULL,
+    `role`                    varchar(16) NOT NULL,
+    `status`                  varchar(16) NOT NULL,
+    `sealed_organization_key` blob NOT NULL, -- organization key sealed with the member's public key
+    `created_at`              datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (`organization_id`, `user_id`),
+    CONSTRAINT fk_organization_member_organization
+        FOREIGN KEY(`organization_id`)
+            REFERENCES `organization`(`id`)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_organization_member_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- Entries with an organization belong to its shared vault and are encrypted with the organization key
+ALTER TABLE `password` ADD COLUMN `organization_id` bigint;
+CREATE INDEX `password_organization_id_idx` ON `password` (`organization_id`);
+ALTER TABLE `password` ADD CONSTRAINT fk_password_organization FOREIGN KEY(`organization_id`) REFERENCES `organization`(`id`);
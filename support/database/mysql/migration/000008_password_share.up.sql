@@ -0,0 +1,19 @@
+CREATE TABLE `password_share`
+(
+    `id`              bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `password_id`     bigint NOT NULL,
+    `recipient_id`    bigint NOT NULL,
+    `permission`      varchar(16) NOT NULL,
+    `sealed_password` blob NOT NULL, -- entry sealed with the recipient's public key, resealed on every update
+    `created_at`      datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    UNIQUE (`password_id`, `recipient_id`),
+    CONSTRAINT fk_password_share_password
+        FOREIGN KEY(`password_id`)
+            REFERENCES `password`(`id`)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_password_share_recipient
+        FOREIGN KEY(`recipient_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE INDEX `password_share_recipient_id_idx` ON `password_share` (`recipient_id`);
@@ -0,0 +1,19 @@
+CREATE TABLE `send`
+(
+    `id`               bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `user_id`          bigint NOT NULL,
+    `name`             varchar(64) NOT NULL,
+    `token_hash`       varbinary(64) NOT NULL UNIQUE,
+    `encrypted_secret` blob NOT NULL,
+    `has_passphrase`   boolean NOT NULL DEFAULT false,
+    `max_views`        integer NOT NULL,
+    `view_count`       integer NOT NULL DEFAULT 0,
+    `expires_at`       datetime(6) NOT NULL,
+    `created_at`       datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT fk_send_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE INDEX `send_user_id_idx` ON `send` (`user_id`);
+CREATE INDEX `send_expires_at_idx` ON `send` (`expires_at`);
@@ -0,0 +1,19 @@
+-- The first administrator has to be promoted manually: UPDATE `user` SET `role` = 'admin' WHERE `email` = '...';
+ALTER TABLE `user` ADD COLUMN `role` varchar(16) NOT NULL DEFAULT 'user';
+ALTER TABLE `user` ADD COLUMN `disabled` boolean NOT NULL DEFAULT false;
+
+CREATE TABLE `admin_audit_entry`
+(
+    `id`             bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `admin_id`       bigint NOT NULL,
+    `action`         varchar(32) NOT NULL,
+    `target_user_id` bigint NOT NULL,
+    `details`        varchar(64),
+    `created_at`     datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT fk_admin_audit_entry_admin
+        FOREIGN KEY(`admin_id`)
+            REFERENCES `user`(`id`),
+    CONSTRAINT fk_admin_audit_entry_target_user
+        FOREIGN KEY(`target_user_id`)
+            REFERENCES `user`(`id`)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS `audit_event_chain_lock`;
+DROP TABLE IF EXISTS `audit_event`;
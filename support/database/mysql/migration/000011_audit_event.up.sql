@@ -0,0 +1,31 @@
+-- Every event stores the hash of the previous one, so the chain can be verified for gaps and tampering.
+-- There are no foreign keys since the audit log has to stay intact regardless of the records it mentions.
+CREATE TABLE `audit_event`
+(
+    `id`            bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `sequence`      bigint NOT NULL UNIQUE,
+    `user_id`       bigint,
+    `type`          varchar(32) NOT NULL,
+    `ip_address`    varchar(45) NOT NULL,
+    `user_agent`    varchar(512) NOT NULL,
+    `details`       varchar(256),
+    `created_at`    datetime(6) NOT NULL,
+    `previous_hash` blob NOT NULL,
+    `hash`          blob NOT NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE INDEX `audit_event_user_id_idx` ON `audit_event` (`user_id`, `sequence`);
+
+-- MySQL has no transaction scoped advisory locks, appends to the chain lock this single row instead
+CREATE TABLE `audit_event_chain_lock`
+(
+    `id` integer NOT NULL PRIMARY KEY
+) ENGINE = InnoDB;
+
+INSERT INTO `audit_event_chain_lock` (`id`) VALUES (1);
+
+-- Triggers don't fire on TRUNCATE in MySQL, which takes the DROP privilege the application user shouldn't have
+CREATE TRIGGER `audit_event_no_update` BEFORE UPDATE ON `audit_event`
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit events are append-only';
+CREATE TRIGGER `audit_event_no_delete` BEFORE DELETE ON `audit_event`
+    FOR EACH ROW SIGNAL SQLSTATE '45000' SET MESSAGE_TEXT = 'audit events are append-only';
@@ -0,0 +1,55 @@
+-- Event types are kept in the text format of a postgres array, e.g. {"password.created","password.deleted"}
+CREATE TABLE `webhook`
+(
+    `id`          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `created_by`  bigint NOT NULL,
+    `url`         varchar(2048) NOT NULL,
+    `secret`      blob NOT NULL,
+    `event_types` text NOT NULL,
+    `created_at`  datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT fk_webhook_created_by
+        FOREIGN KEY(`created_by`)
+            REFERENCES `user`(`id`)
+            ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- Deliveries are kept as the delivery history, payloads only ever contain event metadata
+CREATE TABLE `webhook_delivery`
+(
+    `id`              bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `webhook_id`      bigint NOT NULL,
+    `event_type`      varchar(32) NOT NULL,
+    `payload`         text NOT NULL,
+    `status`          varchar(16) NOT NULL DEFAULT 'pending',
+    `attempts`        int NOT NULL DEFAULT 0,
+    `next_attempt_at` datetime(6) NOT NULL,
+    `response_status` int,
+    `last_error`      varchar(256),
+    `created_at`      datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT fk_webhook_delivery_webhook
+        FOREIGN KEY(`webhook_id`)
+            REFERENCES `webhook`(`id`)
+            ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE INDEX `webhook_delivery_webhook_id_idx` ON `webhook_delivery` (`webhook_id`, `id`);
+CREATE INDEX `webhook_delivery_pending_idx` ON `webhook_delivery` (`status`, `next_attempt_at`);
+
+-- Deliveries that exhausted their attempts wait here until they get redelivered or the webhook gets deleted
+CREATE TABLE `webhook_dead_letter`
+(
+    `id`          bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `delivery_id` bigint NOT NULL UNIQUE,
+    `webhook_id`  bigint NOT NULL,
+    `event_type`  varchar(32) NOT NULL,
+    `last_error`  varchar(256),
+    `failed_at`   datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT fk_webhook_dead_letter_delivery
+        FOREIGN KEY(`delivery_id`)
+            REFERENCES `webhook_delivery`(`id`)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_webhook_dead_letter_webhook
+        FOREIGN KEY(`webhook_id`)
+            REFERENCES `webhook`(`id`)
+            ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `password_tombstone`;
+DROP INDEX `password_user_id_revision_idx` ON `password`;
+ALTER TABLE `password` DROP COLUMN `revision`;
+ALTER TABLE `user` DROP COLUMN `vault_revision`;
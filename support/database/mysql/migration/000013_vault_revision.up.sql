@@ -0,0 +1,24 @@
+-- Every change to a personal vault gets the next revision of its owner, existing entries start at revision 1
+ALTER TABLE `user` ADD COLUMN `vault_revision` bigint NOT NULL DEFAULT 0;
+ALTER TABLE `password` ADD COLUMN `revision` bigint NOT NULL DEFAULT 0;
+
+UPDATE `password` SET `revision` = 1;
+UPDATE `user` SET `vault_revision` = 1 WHERE EXISTS (SELECT 1 FROM `password` WHERE `password`.`user_id` = `user`.`id`);
+
+CREATE INDEX `password_user_id_revision_idx` ON `password` (`user_id`, `revision`);
+
+-- Entries that left a personal vault, either deleted or moved to an organization, so syncing clients can drop them
+CREATE TABLE `password_tombstone`
+(
+    `password_id` bigint NOT NULL,
+    `user_id`     bigint NOT NULL,
+    `revision`    bigint NOT NULL,
+    `deleted_at`  datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    PRIMARY KEY (`password_id`, `user_id`),
+    CONSTRAINT fk_password_tombstone_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+            ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE INDEX `password_tombstone_user_id_revision_idx` ON `password_tombstone` (`user_id`, `revision`);
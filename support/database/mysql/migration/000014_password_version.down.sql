@@ -0,0 +1 @@
+ALTER TABLE `password` DROP COLUMN `version`;
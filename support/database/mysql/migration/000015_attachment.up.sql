@@ -0,0 +1,26 @@
+-- Attachment contents are encrypted and kept in the blob store under the blob key, only their metadata lives here.
+-- The size is the one of the plaintext and counts towards the user's storage quota. Each content is encrypted with its
+-- own data key, which is only kept wrapped with the owner's vault key.
+CREATE TABLE `attachment`
+(
+    `id`               bigint NOT NULL AUTO_INCREMENT PRIMARY KEY,
+    `password_id`      bigint NOT NULL,
+    `user_id`          bigint NOT NULL,
+    `name`             varchar(255) NOT NULL,
+    `content_type`     varchar(255) NOT NULL,
+    `size`             bigint NOT NULL,
+    `blob_key`         varchar(128) NOT NULL UNIQUE,
+    `wrapped_data_key` blob NOT NULL,
+    `created_at`       datetime(6) NOT NULL DEFAULT CURRENT_TIMESTAMP(6),
+    CONSTRAINT fk_attachment_password
+        FOREIGN KEY(`password_id`)
+            REFERENCES `password`(`id`)
+            ON DELETE CASCADE,
+    CONSTRAINT fk_attachment_user
+        FOREIGN KEY(`user_id`)
+            REFERENCES `user`(`id`)
+            ON DELETE CASCADE
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE INDEX `attachment_password_id_idx` ON `attachment` (`password_id`);
+CREATE INDEX `attachment_user_id_idx` ON `attachment` (`user_id`);
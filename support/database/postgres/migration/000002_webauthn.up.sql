@@ -0,0 +1,26 @@
+CREATE TABLE "webauthn_credential"
+(
+    "id"              bigserial PRIMARY KEY,
+    "user_id"         bigint NOT NULL,
+    "credential_id"   bytea NOT NULL UNIQUE,
+    "public_key"      bytea NOT NULL, -- COSE encoded credential public key
+    "sign_count"      bigint NOT NULL DEFAULT 0,
+    "name"            varchar(64) NOT NULL,
+    "prf_wrapped_key" bytea, -- vault key wrapped client side with the PRF extension output, opaque to the server
+    "created_at"      timestamp NOT NULL DEFAULT now(),
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
+
+CREATE TABLE "webauthn_challenge"
+(
+    "id"         bigserial PRIMARY KEY,
+    "user_id"    bigint NOT NULL,
+    "challenge"  bytea NOT NULL UNIQUE,
+    "ceremony"   varchar(16) NOT NULL,
+    "expires_at" timestamp NOT NULL,
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
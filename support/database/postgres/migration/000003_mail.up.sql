@@ -0,0 +1,24 @@
+ALTER TABLE "user" ADD COLUMN "email_verified" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "email_verification_token"
+(
+    "id"         bigserial PRIMARY KEY,
+    "user_id"    bigint NOT NULL,
+    "token_hash" bytea NOT NULL UNIQUE,
+    "expires_at" timestamp NOT NULL,
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
+
+CREATE TABLE "known_device"
+(
+    "id"           bigserial PRIMARY KEY,
+    "user_id"      bigint NOT NULL,
+    "user_agent"   varchar(512) NOT NULL,
+    "ip_address"   varchar(45) NOT NULL, -- long enough for IPv6 addresses
+    "last_seen_at" timestamp NOT NULL DEFAULT now(),
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "account_recovery";
+ALTER TABLE "user" DROP COLUMN IF EXISTS "recovery_wrapped_key";
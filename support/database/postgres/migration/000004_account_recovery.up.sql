@@ -0,0 +1,13 @@
+ALTER TABLE "user" ADD COLUMN "recovery_wrapped_key" bytea; -- users created before recovery kits have no recovery key
+
+CREATE TABLE "account_recovery"
+(
+    "id"           bigserial PRIMARY KEY,
+    "user_id"      bigint NOT NULL,
+    "user_agent"   varchar(512) NOT NULL,
+    "ip_address"   varchar(45) NOT NULL,
+    "recovered_at" timestamp NOT NULL DEFAULT now(),
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
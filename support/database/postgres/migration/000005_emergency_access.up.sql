@@ -0,0 +1,45 @@
+CREATE TABLE "user_key_pair"
+(
+    "user_id"             bigint PRIMARY KEY,
+    "public_key"          bytea NOT NULL,
+    "wrapped_private_key" bytea NOT NULL, -- encrypted with the owner's vault key
+    "created_at"          timestamp NOT NULL DEFAULT now(),
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
+
+CREATE TABLE "emergency_access_grant"
+(
+    "id"                bigserial PRIMARY KEY,
+    "grantor_id"        bigint NOT NULL,
+    "grantee_id"        bigint NOT NULL,
+    "access_type"       varchar(16) NOT NULL,
+    "wait_time_in_days" integer NOT NULL,
+    "status"            varchar(16) NOT NULL,
+    "created_at"        timestamp NOT NULL DEFAULT now(),
+    UNIQUE ("grantor_id", "grantee_id"),
+    CONSTRAINT fk_grantor
+        FOREIGN KEY("grantor_id")
+            REFERENCES "user"("id"),
+    CONSTRAINT fk_grantee
+        FOREIGN KEY("grantee_id")
+            REFERENCES "user"("id")
+);
+
+CREATE TABLE "emergency_access_request"
+(
+    "id"                bigserial PRIMARY KEY,
+    "grant_id"          bigint NOT NULL,
+    "status"            varchar(16) NOT NULL,
+    "requested_at"      timestamp NOT NULL,
+    "available_at"      timestamp NOT NULL,
+    "resolved_at"       timestamp,
+    "sealed_vault_key"  bytea, -- grantor's vault key sealed for the grantee once the request is approved
+    CONSTRAINT fk_grant
+        FOREIGN KEY("grant_id")
+            REFERENCES "emergency_access_grant"("id")
+            ON DELETE CASCADE
+);
+
+CREATE INDEX "emergency_access_request_pending_idx" ON "emergency_access_request" ("available_at") WHERE "status" = 'pending';
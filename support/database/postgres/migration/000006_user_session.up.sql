@@ -0,0 +1,17 @@
+CREATE TABLE "user_session"
+(
+    "id"           bigserial PRIMARY KEY,
+    "user_id"      bigint NOT NULL,
+    "device_name"  varchar(128) NOT NULL,
+    "user_agent"   varchar(512) NOT NULL,
+    "ip_address"   varchar(45) NOT NULL, -- long enough for IPv6 addresses
+    "status"       varchar(16) NOT NULL,
+    "created_at"   timestamp NOT NULL DEFAULT now(),
+    "last_seen_at" timestamp NOT NULL DEFAULT now(),
+    "revoked_at"   timestamp,
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
+
+CREATE INDEX "user_session_user_id_idx" ON "user_session" ("user_id") WHERE "status" <> 'revoked';
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS "password_organization_id_idx";
+ALTER TABLE "password" DROP CONSTRAINT IF EXISTS fk_organization;
+ALTER TABLE "password" DROP COLUMN IF EXISTS "organization_id";
+DROP TABLE IF EXISTS "organization_member";
+DROP TABLE IF EXISTS "organization";
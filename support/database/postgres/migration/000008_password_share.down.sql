@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "password_share_recipient_id_idx";
+DROP TABLE IF EXISTS "password_share";
@@ -0,0 +1,19 @@
+CREATE TABLE "password_share"
+(
+    "id"              bigserial PRIMARY KEY,
+    "password_id"     bigint NOT NULL,
+    "recipient_id"    bigint NOT NULL,
+    "permission"      varchar(16) NOT NULL,
+    "sealed_password" bytea NOT NULL, -- entry sealed with the recipient's public key, resealed on every update
+    "created_at"      timestamp NOT NULL DEFAULT now(),
+    UNIQUE ("password_id", "recipient_id"),
+    CONSTRAINT fk_password
+        FOREIGN KEY("password_id")
+            REFERENCES "password"("id")
+            ON DELETE CASCADE,
+    CONSTRAINT fk_recipient
+        FOREIGN KEY("recipient_id")
+            REFERENCES "user"("id")
+);
+
+CREATE INDEX "password_share_recipient_id_idx" ON "password_share" ("recipient_id");
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "send_expires_at_idx";
+DROP INDEX IF EXISTS "send_user_id_idx";
+DROP TABLE IF EXISTS "send";
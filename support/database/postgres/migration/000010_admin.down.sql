@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "admin_audit_entry";
+ALTER TABLE "user" DROP COLUMN IF EXISTS "disabled";
+ALTER TABLE "user" DROP COLUMN IF EXISTS "role";
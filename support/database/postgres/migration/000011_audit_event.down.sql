@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "audit_event";
+DROP FUNCTION IF EXISTS reject_audit_event_change();
@@ -0,0 +1,28 @@
+-- Every event stores the hash of the previous one, so the chain can be verified for gaps and tampering.
+-- There are no foreign keys since the audit log has to stay intact regardless of the records it mentions.
+CREATE TABLE "audit_event"
+(
+    "id"            bigserial PRIMARY KEY,
+    "sequence"      bigint NOT NULL UNIQUE,
+    "user_id"       bigint,
+    "type"          varchar(32) NOT NULL,
+    "ip_address"    varchar(45) NOT NULL,
+    "user_agent"    varchar(512) NOT NULL,
+    "details"       varchar(256),
+    "created_at"    timestamp NOT NULL,
+    "previous_hash" bytea NOT NULL,
+    "hash"          bytea NOT NULL
+);
+
+CREATE INDEX "audit_event_user_id_idx" ON "audit_event" ("user_id", "sequence");
+
+CREATE FUNCTION reject_audit_event_change() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit events are append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_event_append_only" BEFORE UPDATE OR DELETE ON "audit_event"
+    FOR EACH ROW EXECUTE FUNCTION reject_audit_event_change();
+CREATE TRIGGER "audit_event_no_truncate" BEFORE TRUNCATE ON "audit_event"
+    FOR EACH STATEMENT EXECUTE FUNCTION reject_audit_event_change();
@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS "webhook_dead_letter";
+DROP INDEX IF EXISTS "webhook_delivery_pending_idx";
+DROP INDEX IF EXISTS "webhook_delivery_webhook_id_idx";
+DROP TABLE IF EXISTS "webhook_delivery";
+DROP TABLE IF EXISTS "webhook";
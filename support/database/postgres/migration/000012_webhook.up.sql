@@ -0,0 +1,38 @@
+CREATE TABLE "webhook"
+(
+    "id"          bigserial PRIMARY KEY,
+    "created_by"  bigint NOT NULL REFERENCES "user" ("id") ON DELETE CASCADE,
+    "url"         varchar(2048) NOT NULL,
+    "secret"      bytea NOT NULL,
+    "event_types" varchar(32)[] NOT NULL,
+    "created_at"  timestamp NOT NULL DEFAULT now()
+);
+
+-- Deliveries are kept as the delivery history, payloads only ever contain event metadata
+CREATE TABLE "webhook_delivery"
+(
+    "id"              bigserial PRIMARY KEY,
+    "webhook_id"      bigint NOT NULL REFERENCES "webhook" ("id") ON DELETE CASCADE,
+    "event_type"      varchar(32) NOT NULL,
+    "payload"         text NOT NULL,
+    "status"          varchar(16) NOT NULL DEFAULT 'pending',
+    "attempts"        int NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamp NOT NULL,
+    "response_status" int,
+    "last_error"      varchar(256),
+    "created_at"      timestamp NOT NULL DEFAULT now()
+);
+
+CREATE INDEX "webhook_delivery_webhook_id_idx" ON "webhook_delivery" ("webhook_id", "id");
+CREATE INDEX "webhook_delivery_pending_idx" ON "webhook_delivery" ("next_attempt_at") WHERE "status" = 'pending';
+
+-- Deliveries that exhausted their attempts wait here until they get redelivered or the webhook gets deleted
+CREATE TABLE "webhook_dead_letter"
+(
+    "id"          bigserial PRIMARY KEY,
+    "delivery_id" bigint NOT NULL UNIQUE REFERENCES "webhook_delivery" ("id") ON DELETE CASCADE,
+    "webhook_id"  bigint NOT NULL REFERENCES "webhook" ("id") ON DELETE CASCADE,
+    "event_type"  varchar(32) NOT NULL,
+    "last_error"  varchar(256),
+    "failed_at"   timestamp NOT NULL DEFAULT now()
+);
@@ -0,0 +1,2 @@
+-- The otpauth URI of the authenticator seed kept with an entry, encrypted with the key of the vault the entry is in
+ALTER TABLE "password" ADD COLUMN "totp_uri" bytea;
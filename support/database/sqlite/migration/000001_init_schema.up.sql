@@ -0,0 +1,18 @@
+CREATE TABLE "user"
+(
+    "id"       integer PRIMARY KEY AUTOINCREMENT,
+    "email"    varchar(254) NOT NULL UNIQUE, -- RFC standard prohibits e-mails longer than 254 characters
+    "username" varchar(32) NOT NULL,
+    "password" blob NOT NULL
+);
+
+CREATE TABLE "password"
+(
+    "id"       integer PRIMARY KEY AUTOINCREMENT,
+    "user_id"  bigint  NOT NULL,
+    "name"     varchar(64) NOT NULL,
+    "password" blob NOT NULL,
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
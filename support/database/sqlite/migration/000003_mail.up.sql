@@ -0,0 +1,24 @@
+ALTER TABLE "user" ADD COLUMN "email_verified" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "email_verification_token"
+(
+    "id"         integer PRIMARY KEY AUTOINCREMENT,
+    "user_id"    bigint NOT NULL,
+    "token_hash" blob NOT NULL UNIQUE,
+    "expires_at" timestamp NOT NULL,
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
+
+CREATE TABLE "known_device"
+(
+    "id"           integer PRIMARY KEY AUTOINCREMENT,
+    "user_id"      bigint NOT NULL,
+    "user_agent"   varchar(512) NOT NULL,
+    "ip_address"   varchar(45) NOT NULL, -- long enough for IPv6 addresses
+    "last_seen_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
@@ -0,0 +1,13 @@
+ALTER TABLE "user" ADD COLUMN "recovery_wrapped_key" blob; -- users created before recovery kits have no recovery key
+
+CREATE TABLE "account_recovery"
+(
+    "id"           integer PRIMARY KEY AUTOINCREMENT,
+    "user_id"      bigint NOT NULL,
+    "user_agent"   varchar(512) NOT NULL,
+    "ip_address"   varchar(45) NOT NULL,
+    "recovered_at" timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
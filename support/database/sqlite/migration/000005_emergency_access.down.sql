@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "emergency_access_request";
+DROP TABLE IF EXISTS "emergency_access_grant";
+DROP TABLE IF EXISTS "user_key_pair";
@@ -0,0 +1,20 @@
+DROP INDEX IF EXISTS "password_organization_id_idx";
+
+-- SQLite can't drop columns with a foreign key, so the password table gets rebuilt without it
+CREATE TABLE "password_without_organization"
+(
+    "id"       integer PRIMARY KEY AUTOINCREMENT,
+    "user_id"  bigint  NOT NULL,
+    "name"     varchar(64) NOT NULL,
+    "password" blob NOT NULL,
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
+INSERT INTO "password_without_organization" ("id", "user_id", "name", "password")
+    SELECT "id", "user_id", "name", "password" FROM "password";
+DROP TABLE "password";
+ALTER TABLE "password_without_organization" RENAME TO "password";
+
+DROP TABLE IF EXISTS "organization_member";
+DROP TABLE IF EXISTS "organization";
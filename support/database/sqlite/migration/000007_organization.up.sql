@@ -0,0 +1,30 @@
+CREATE TABLE "organization"
+(
+    "id"          integer PRIMARY KEY AUTOINCREMENT,
+    "name"        varchar(64) NOT NULL,
+    "key_version" integer NOT NULL DEFAULT 1, -- incremented on every organization key rotation
+    "created_at"  timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE "organization_member"
+(
+    "id"                      integer PRIMARY KEY AUTOINCREMENT,
+    "organization_id"         bigint NOT NULL,
+    "user_id"                 bigint NOT NULL,
+    "role"                    varchar(16) NOT NULL,
+    "status"                  varchar(16) NOT NULL,
+    "sealed_organization_key" blob NOT NULL, -- organization key sealed with the member's public key
+    "created_at"              timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE ("organization_id", "user_id"),
+    CONSTRAINT fk_organization
+        FOREIGN KEY("organization_id")
+            REFERENCES "organization"("id")
+            ON DELETE CASCADE,
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
+
+-- Entries with an organization belong to its shared vault and are encrypted with the organization key
+ALTER TABLE "password" ADD COLUMN "organization_id" bigint CONSTRAINT fk_organization REFERENCES "organization"("id");
+CREATE INDEX "password_organization_id_idx" ON "password" ("organization_id");
@@ -0,0 +1,19 @@
+CREATE TABLE "send"
+(
+    "id"               integer PRIMARY KEY AUTOINCREMENT,
+    "user_id"          bigint NOT NULL,
+    "name"             varchar(64) NOT NULL,
+    "token_hash"       blob NOT NULL UNIQUE,
+    "encrypted_secret" blob NOT NULL,
+    "has_passphrase"   boolean NOT NULL DEFAULT false,
+    "max_views"        integer NOT NULL,
+    "view_count"       integer NOT NULL DEFAULT 0,
+    "expires_at"       timestamp NOT NULL,
+    "created_at"       timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_user
+        FOREIGN KEY("user_id")
+            REFERENCES "user"("id")
+);
+
+CREATE INDEX "send_user_id_idx" ON "send" ("user_id");
+CREATE INDEX "send_expires_at_idx" ON "send" ("expires_at");
@@ -0,0 +1,19 @@
+-- The first administrator has to be promoted manually: UPDATE "user" SET "role" = 'admin' WHERE "email" = '...';
+ALTER TABLE "user" ADD COLUMN "role" varchar(16) NOT NULL DEFAULT 'user';
+ALTER TABLE "user" ADD COLUMN "disabled" boolean NOT NULL DEFAULT false;
+
+CREATE TABLE "admin_audit_entry"
+(
+    "id"             integer PRIMARY KEY AUTOINCREMENT,
+    "admin_id"       bigint NOT NULL,
+    "action"         varchar(32) NOT NULL,
+    "target_user_id" bigint NOT NULL,
+    "details"        varchar(64),
+    "created_at"     timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    CONSTRAINT fk_admin
+        FOREIGN KEY("admin_id")
+            REFERENCES "user"("id"),
+    CONSTRAINT fk_target_user
+        FOREIGN KEY("target_user_id")
+            REFERENCES "user"("id")
+);
@@ -0,0 +1,3 @@
+DROP TRIGGER IF EXISTS "audit_event_no_delete";
+DROP TRIGGER IF EXISTS "audit_event_no_update";
+DROP TABLE IF EXISTS "audit_event";
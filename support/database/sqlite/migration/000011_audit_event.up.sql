@@ -0,0 +1,26 @@
+-- Every event stores the hash of the previous one, so the chain can be verified for gaps and tampering.
+-- There are no foreign keys since the audit log has to stay intact regardless of the records it mentions.
+CREATE TABLE "audit_event"
+(
+    "id"            integer PRIMARY KEY AUTOINCREMENT,
+    "sequence"      bigint NOT NULL UNIQUE,
+    "user_id"       bigint,
+    "type"          varchar(32) NOT NULL,
+    "ip_address"    varchar(45) NOT NULL,
+    "user_agent"    varchar(512) NOT NULL,
+    "details"       varchar(256),
+    "created_at"    timestamp NOT NULL,
+    "previous_hash" blob NOT NULL,
+    "hash"          blob NOT NULL
+);
+
+CREATE INDEX "audit_event_user_id_idx" ON "audit_event" ("user_id", "sequence");
+
+CREATE TRIGGER "audit_event_no_update" BEFORE UPDATE ON "audit_event"
+BEGIN
+    SELECT RAISE(ABORT, 'audit events are append-only');
+END;
+CREATE TRIGGER "audit_event_no_delete" BEFORE DELETE ON "audit_event"
+BEGIN
+    SELECT RAISE(ABORT, 'audit events are append-only');
+END;
@@ -0,0 +1,39 @@
+-- Event types are kept in the text format of a postgres array, e.g. {"password.created","password.deleted"}
+CREATE TABLE "webhook"
+(
+    "id"          integer PRIMARY KEY AUTOINCREMENT,
+    "created_by"  bigint NOT NULL REFERENCES "user" ("id") ON DELETE CASCADE,
+    "url"         varchar(2048) NOT NULL,
+    "secret"      blob NOT NULL,
+    "event_types" text NOT NULL,
+    "created_at"  timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Deliveries are kept as the delivery history, payloads only ever contain event metadata
+CREATE TABLE "webhook_delivery"
+(
+    "id"              integer PRIMARY KEY AUTOINCREMENT,
+    "webhook_id"      bigint NOT NULL REFERENCES "webhook" ("id") ON DELETE CASCADE,
+    "event_type"      varchar(32) NOT NULL,
+    "payload"         text NOT NULL,
+    "status"          varchar(16) NOT NULL DEFAULT 'pending',
+    "attempts"        int NOT NULL DEFAULT 0,
+    "next_attempt_at" timestamp NOT NULL,
+    "response_status" int,
+    "last_error"      varchar(256),
+    "created_at"      timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX "webhook_delivery_webhook_id_idx" ON "webhook_delivery" ("webhook_id", "id");
+CREATE INDEX "webhook_delivery_pending_idx" ON "webhook_delivery" ("next_attempt_at") WHERE "status" = 'pending';
+
+-- Deliveries that exhausted their attempts wait here until they get redelivered or the webhook gets deleted
+CREATE TABLE "webhook_dead_letter"
+(
+    "id"          integer PRIMARY KEY AUTOINCREMENT,
+    "delivery_id" bigint NOT NULL UNIQUE REFERENCES "webhook_delivery" ("id") ON DELETE CASCADE,
+    "webhook_id"  bigint NOT NULL REFERENCES "webhook" ("id") ON DELETE CASCADE,
+    "event_type"  varchar(32) NOT NULL,
+    "last_error"  varchar(256),
+    "failed_at"   timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,2 @@
+-- Counts the changes of an entry, updates have to name the version they're based on so concurrent edits can't overwrite each other
+ALTER TABLE "password" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
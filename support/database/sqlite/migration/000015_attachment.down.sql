@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS "attachment_user_id_idx";
+DROP INDEX IF EXISTS "attachment_password_id_idx";
+DROP TABLE IF EXISTS "attachment";
@@ -32,7 +32,11 @@ const SignIn = ({signInCallback}) => {
   useEffect(() => {
     if (location.state?.email) {
       setEmail(location.state.email);
-      setNotifications(['You are signed up!']);
+      const notifications = ['You are signed up!'];
+      if (location.state.recoveryKey) {
+        notifications.push(`Your recovery key is ${location.state.recoveryKey}, print it or write it down and keep it safe. It is the only way to recover your account if you forget your password.`);
+      }
+      setNotifications(notifications);
     } else if (location.state?.authenticationExpired) {
       setErrors(['Authentication expired.']);
     }
@@ -17,7 +17,7 @@ const SignUp = () => {
   const [errors, setErrors] = useState(null);
   const [signUp, {loading}] = useMutation(signUpMutation, {
     onCompleted: (data) => {
-      history.push('/sign-in', {email: data.signUp.email});
+      history.push('/sign-in', {email: data.signUp.user.email, recoveryKey: data.signUp.recoveryKey});
     },
     onError: (response) => {
       setErrors(response.graphQLErrors.map(error => error.message));